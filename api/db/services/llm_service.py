@@ -110,6 +110,19 @@ class LLMBundle(LLM4Tenant):
 
         return embeddings, used_tokens
 
+    def encode_images(self, images: list[bytes]):
+        if self.langfuse:
+            generation = self._start_langfuse_observation(trace_context=self.trace_context, as_type="generation", name="encode_images", model=self.model_config["llm_name"], input={"images": len(images)})
+
+        embeddings, used_tokens = self.mdl.encode_images(images)
+        logging.info("LLMBundle.encode_images used_tokens: %d", used_tokens)
+
+        if self.langfuse:
+            generation.update(usage_details={"total_tokens": used_tokens})
+            generation.end()
+
+        return embeddings, used_tokens
+
     def encode_queries(self, query: str):
         if self.langfuse:
             generation = self._start_langfuse_observation(trace_context=self.trace_context, as_type="generation", name="encode_queries", model=self.model_config["llm_name"], input={"query": query})
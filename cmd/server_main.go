@@ -41,7 +41,7 @@ import (
 
 	"ragflow/internal/agent/audio"
 	"ragflow/internal/agent/canvas"
-	_ "ragflow/internal/agent/component" // blank import: registers every Component factory (Begin / Agent / LLM / Message / Retrieval / ...) into the shared runtime at package init
+	"ragflow/internal/agent/component" // registers every Component factory (Begin / Agent / LLM / Message / Retrieval / ...) into the shared runtime at package init
 	"ragflow/internal/agent/runtime"
 	agenttool "ragflow/internal/agent/tool"
 	"ragflow/internal/dao"
@@ -232,6 +232,8 @@ func startServer(config *server.Config) {
 	fileService := service.NewFileService()
 	memoryService := service.NewMemoryService()
 	mcpService := service.NewMCPService()
+	openAPIService := service.NewOpenAPIService()
+	emailAccountService := service.NewEmailAccountService()
 	modelProviderService := service.NewModelProviderService()
 
 	// Initialize doc engine for skill search
@@ -239,6 +241,14 @@ func startServer(config *server.Config) {
 	documentDAO := dao.NewDocumentDAO()
 	agenttool.SetRetrievalService(agenttool.NewNLPRetrievalAdapterFromDeps(docEngine, documentDAO))
 	common.Info("agent: retrieval service adapter installed")
+	// Attachments read by the imap: tools go through the upload parsers.
+	agenttool.SetEmailAttachmentParser(service.ParseEmailAttachment)
+	// Tool result cache (cache_ttl) and email idempotency keys; both
+	// call straight through when Redis is unavailable.
+	if redis.IsEnabled() && redis.Get() != nil {
+		agenttool.SetToolResultStore(agenttool.NewRedisToolResultStore())
+		common.Info("agent: redis-backed tool result cache installed")
+	}
 
 	// Initialize handler layer
 	authHandler := handler.NewAuthHandler()
@@ -260,6 +270,8 @@ func startServer(config *server.Config) {
 	fileHandler := handler.NewFileHandler(fileService, userService)
 	memoryHandler := handler.NewMemoryHandler(memoryService)
 	mcpHandler := handler.NewMCPHandler(mcpService)
+	openAPIHandler := handler.NewOpenAPIHandler(openAPIService)
+	emailAccountHandler := handler.NewEmailAccountHandler(emailAccountService)
 	skillSearchHandler := handler.NewSkillSearchHandler(docEngine)
 	providerHandler := handler.NewProviderHandler(userService, modelProviderService)
 	// Install the agent service's Redis-backed run infrastructure
@@ -276,7 +288,16 @@ func startServer(config *server.Config) {
 		agentOpts.stateSerializer,
 		agentOpts.runTracker,
 	)
+	agentService.SetNodeCheckpointStore(agentOpts.nodeCheckpoints)
+	agentService.SetApprovalStore(agentOpts.approvals)
+	agentService.SetComponentCatalog(component.IsRegistered)
+	// SubAgent nodes run child canvases through the same AgentService.
+	component.SetSubAgentRunner(agentService)
+	// DatasetWriter nodes store canvas output as dataset documents.
+	component.SetDatasetWriter(service.NewAgentDatasetWriter(datasetsService, documentService))
 	agentHandler := handler.NewAgentHandler(agentService, fileService)
+	// Built-in MCP server over datasets, agents and memories.
+	mcpEndpointHandler := handler.NewMCPEndpointHandler(chunkService, datasetsService, documentService, agentService, memoryService)
 
 	// Public chatbot/agentbot endpoints (api/v1/chatbots/...,
 	// api/v1/agentbots/...) and the agent attachment download.
@@ -295,6 +316,7 @@ func startServer(config *server.Config) {
 	// no-op echo (the audio package contract), so this is always
 	// safe to call.
 	configureTTSSynthesizer(modelProviderService)
+	configureImageGenerator(modelProviderService)
 	searchBotLLM := &handler.SearchBotRealLLM{Svc: modelProviderService}
 	searchBotHandler := handler.NewSearchBotHandler(
 		searchService,
@@ -336,7 +358,7 @@ func startServer(config *server.Config) {
 	adminRuntimeHandler := handler.NewAdminRuntimeHandler(adminRuntimeSelector)
 
 	// Initialize router
-	r := router.NewRouter(authHandler, userHandler, tenantHandler, documentHandler, datasetsHandler, systemHandler, knowledgebaseHandler, chunkHandler, llmHandler, chatHandler, chatChannelHandler, langfuseHandler, chatSessionHandler, connectorHandler, searchHandler, fileHandler, memoryHandler, mcpHandler, skillSearchHandler, providerHandler, agentHandler, searchBotHandler, difyRetrievalHandler, pluginHandler, modelHandler, fileCommitHandler, adminRuntimeHandler, openaiChatHandler, botHandler)
+	r := router.NewRouter(authHandler, userHandler, tenantHandler, documentHandler, datasetsHandler, systemHandler, knowledgebaseHandler, chunkHandler, llmHandler, chatHandler, chatChannelHandler, langfuseHandler, chatSessionHandler, connectorHandler, searchHandler, fileHandler, memoryHandler, mcpHandler, openAPIHandler, emailAccountHandler, mcpEndpointHandler, skillSearchHandler, providerHandler, agentHandler, searchBotHandler, difyRetrievalHandler, pluginHandler, modelHandler, fileCommitHandler, adminRuntimeHandler, openaiChatHandler, botHandler)
 
 	// Create Gin engine
 	ginEngine := gin.New()
@@ -408,6 +430,25 @@ func startServer(config *server.Config) {
 		defer heartbeatReporter.Stop()
 	}
 
+	// Periodically probe tenant model instances and mark broken ones degraded
+	if interval := service.ModelHealthProbeInterval(); interval > 0 {
+		modelHealthProber := utility.NewScheduledTask("Model health prober", interval, service.NewModelHealthProber().ProbeAll)
+		modelHealthProber.Start()
+		defer modelHealthProber.Stop()
+	}
+
+	// Fire schedule and event triggers attached to published agents
+	agentTriggerTask := utility.NewScheduledTask("Agent trigger scheduler", 30*time.Second,
+		service.NewAgentTriggerScheduler(agentService, memoryService).Tick)
+	agentTriggerTask.Start()
+	defer agentTriggerTask.Stop()
+
+	// Escalate or reject approval gates whose timeout has passed
+	agentApprovalTask := utility.NewScheduledTask("Agent approval sweeper", 30*time.Second,
+		service.NewAgentApprovalSweeper(agentService).Tick)
+	agentApprovalTask.Start()
+	defer agentApprovalTask.Stop()
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGUSR2)
@@ -428,7 +469,8 @@ func startServer(config *server.Config) {
 
 // agentRunOptions bundles the three optional injection slots the
 // agent service accepts via NewAgentServiceWithOptions: the Redis-
-// backed CheckPointStore, StateSerializer, and RunTracker. The
+// backed CheckPointStore, StateSerializer, and RunTracker — plus the
+// per-node checkpoint store installed via SetNodeCheckpointStore. The
 // fields stay nil when the underlying constructors fail (Redis
 // unreachable, etc.); the agent service treats nil as "in-memory
 // / no-tracking" so the server continues to serve traffic without
@@ -437,6 +479,8 @@ type agentRunOptions struct {
 	checkpointStore canvas.CheckPointStore
 	stateSerializer canvas.StateSerializer
 	runTracker      *canvas.RunTracker
+	nodeCheckpoints canvas.NodeCheckpointStore
+	approvals       canvas.ApprovalStore
 }
 
 // buildAgentRunOptions installs the Redis-backed run infrastructure
@@ -468,7 +512,12 @@ func buildAgentRunOptions() agentRunOptions {
 	// compose.channel". Rely on eino's default instead.
 	rt := canvas.NewRunTracker(24 * time.Hour)
 	out.runTracker = rt
-	common.Info("agent: redis-backed run infra installed (24h TTL on checkpoint store + run tracker; eino default serializer)")
+	out.nodeCheckpoints = canvas.NewRedisNodeCheckpointStore(24 * time.Hour)
+	// Approval records outlive the checkpoint so decided and expired
+	// approvals stay inspectable; a paused run itself is only
+	// resumable while its 24h checkpoint exists.
+	out.approvals = canvas.NewRedisApprovalStore(7 * 24 * time.Hour)
+	common.Info("agent: redis-backed run infra installed (24h TTL on checkpoint store + run tracker + node checkpoints; eino default serializer)")
 	return out
 }
 
@@ -494,3 +543,18 @@ func configureTTSSynthesizer(modelProviderService *service.ModelProviderService)
 	audio.SetModelProviderSynthesizer(audio.NewTTSDispatchFunc(modelProviderService))
 	common.Info("agent: TTS model-provider dispatch installed (audio.Synthesize → ModelProviderService.AudioSpeech)")
 }
+
+// configureImageGenerator installs the component.ImageModelProvider
+// that routes ImageGenerator requests through
+// ModelProviderService.GenerateImage, which resolves the tenant's
+// image_generation model driver from the composite llm_id. A nil
+// model provider reverts the component to its erroring stub.
+func configureImageGenerator(modelProviderService *service.ModelProviderService) {
+	if modelProviderService == nil {
+		common.Info("agent: model provider service not initialised; ImageGenerator disabled")
+		component.SetImageModelProvider(nil)
+		return
+	}
+	component.SetImageModelProvider(component.NewImageModelProvider(modelProviderService))
+	common.Info("agent: image generation dispatch installed (ImageGenerator → ModelProviderService.GenerateImage)")
+}
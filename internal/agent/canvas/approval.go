@@ -0,0 +1,286 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// approval.go — the Approval node: a human approval gate built on the
+// same eino interrupt/resume cycle as UserFillUp (interrupt_resume.go).
+//
+// On first execution the node renders its title / message / content
+// templates and calls compose.Interrupt with an info payload tagged
+// kind="approval". The service layer turns that interrupt into a
+// pending Approval record (approval_store.go), notifies the approvers
+// and resumes the run once a decision arrives, either through the
+// approvals API or through the timeout sweeper.
+//
+// Unlike UserFillUp, the resume payload must be an ApprovalDecision.
+// Free-text chat input that happens to target the paused session is
+// not a decision: the node interrupts again and the approval stays
+// pending, so a run can only pass the gate through the authenticated
+// decision path.
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+
+	"ragflow/internal/agent/runtime"
+)
+
+// Approval timeout actions.
+const (
+	// ApprovalTimeoutReject rejects the approval when it times out.
+	ApprovalTimeoutReject = "reject"
+	// ApprovalTimeoutEscalate hands the approval to the escalation
+	// approvers when it times out; a second timeout rejects it.
+	ApprovalTimeoutEscalate = "escalate"
+)
+
+// Approval decision actions.
+const (
+	ApprovalActionApprove = "approve"
+	ApprovalActionReject  = "reject"
+	// ApprovalActionEdit approves the request with edited content.
+	ApprovalActionEdit = "edit"
+)
+
+// defaultApprovalTimeout applies when the DSL omits timeout_seconds.
+const defaultApprovalTimeout = 24 * time.Hour
+
+// ApprovalDecision is the resume payload of an Approval node.
+type ApprovalDecision struct {
+	Action   string `json:"action"`
+	Approver string `json:"approver"`
+	Comment  string `json:"comment,omitempty"`
+	// Content is the content passed downstream: the edited content for
+	// an edit, the content under review otherwise.
+	Content  string `json:"content"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+}
+
+// Approved reports whether the decision lets the run through.
+func (d ApprovalDecision) Approved() bool {
+	return d.Action == ApprovalActionApprove || d.Action == ApprovalActionEdit
+}
+
+// ApprovalRequest is what an Approval node asks for when it pauses. It
+// travels as the interrupt info (see info) and is decoded on the
+// service side by ApprovalRequestFromInfo.
+type ApprovalRequest struct {
+	ComponentID         string
+	Title               string
+	Message             string
+	Content             string
+	Approvers           []string
+	EscalationApprovers []string
+	WebhookURL          string
+	Timeout             time.Duration
+	TimeoutAction       string
+	EscalationTimeout   time.Duration
+}
+
+// approvalParams is the parsed DSL configuration of an Approval node.
+// The text fields are templates rendered against the canvas state when
+// the node pauses.
+type approvalParams struct {
+	title               string
+	message             string
+	content             string
+	approvers           []string
+	escalationApprovers []string
+	webhookURL          string
+	timeout             time.Duration
+	timeoutAction       string
+	escalationTimeout   time.Duration
+}
+
+// parseApprovalParams reads and validates the Approval DSL params.
+func parseApprovalParams(params map[string]any) (approvalParams, error) {
+	p := approvalParams{
+		timeout:       defaultApprovalTimeout,
+		timeoutAction: ApprovalTimeoutReject,
+	}
+	p.title, _ = params["title"].(string)
+	p.message, _ = params["message"].(string)
+	p.content, _ = params["content"].(string)
+	p.webhookURL, _ = params["webhook_url"].(string)
+	p.approvers = approverList(params["approvers"])
+	p.escalationApprovers = approverList(params["escalation_approvers"])
+	if secs, ok := params["timeout_seconds"].(float64); ok {
+		if secs <= 0 {
+			return p, fmt.Errorf("timeout_seconds must be positive, got %v", secs)
+		}
+		p.timeout = time.Duration(secs * float64(time.Second))
+	}
+	p.escalationTimeout = p.timeout
+	if secs, ok := params["escalation_timeout_seconds"].(float64); ok {
+		if secs <= 0 {
+			return p, fmt.Errorf("escalation_timeout_seconds must be positive, got %v", secs)
+		}
+		p.escalationTimeout = time.Duration(secs * float64(time.Second))
+	}
+	if action, ok := params["timeout_action"].(string); ok && action != "" {
+		p.timeoutAction = strings.ToLower(action)
+	}
+	switch p.timeoutAction {
+	case ApprovalTimeoutReject:
+	case ApprovalTimeoutEscalate:
+		if len(p.escalationApprovers) == 0 {
+			return p, fmt.Errorf("timeout_action %q needs escalation_approvers", ApprovalTimeoutEscalate)
+		}
+	default:
+		return p, fmt.Errorf("timeout_action must be %q or %q, got %q", ApprovalTimeoutReject, ApprovalTimeoutEscalate, p.timeoutAction)
+	}
+	return p, nil
+}
+
+// approverList accepts a JSON list or a comma-separated string of
+// approver emails.
+func approverList(v any) []string {
+	var raw []string
+	switch t := v.(type) {
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	case []string:
+		raw = t
+	case string:
+		raw = strings.Split(t, ",")
+	}
+	out := make([]string, 0, len(raw))
+	for _, s := range raw {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// info builds the interrupt info payload. Only JSON-shaped values are
+// used so eino's checkpoint serializer round-trips it.
+func (p approvalParams) info(cpnID string, state *CanvasState) map[string]any {
+	return map[string]any{
+		"kind":                       "approval",
+		"cpn_id":                     cpnID,
+		"title":                      runtime.ResolveTemplateForDisplay(p.title, state),
+		"message":                    runtime.ResolveTemplateForDisplay(p.message, state),
+		"content":                    runtime.ResolveTemplateForDisplay(p.content, state),
+		"approvers":                  anyList(p.approvers),
+		"escalation_approvers":       anyList(p.escalationApprovers),
+		"webhook_url":                p.webhookURL,
+		"timeout_seconds":            p.timeout.Seconds(),
+		"timeout_action":             p.timeoutAction,
+		"escalation_timeout_seconds": p.escalationTimeout.Seconds(),
+	}
+}
+
+func anyList(s []string) []any {
+	out := make([]any, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+	return out
+}
+
+// ApprovalRequestFromInfo decodes the interrupt info of an Approval
+// node. ok is false for any other interrupt.
+func ApprovalRequestFromInfo(info any) (*ApprovalRequest, bool) {
+	m, ok := info.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	if kind, _ := m["kind"].(string); kind != "approval" {
+		return nil, false
+	}
+	req := &ApprovalRequest{
+		Approvers:           approverList(m["approvers"]),
+		EscalationApprovers: approverList(m["escalation_approvers"]),
+	}
+	req.ComponentID, _ = m["cpn_id"].(string)
+	req.Title, _ = m["title"].(string)
+	req.Message, _ = m["message"].(string)
+	req.Content, _ = m["content"].(string)
+	req.WebhookURL, _ = m["webhook_url"].(string)
+	req.TimeoutAction, _ = m["timeout_action"].(string)
+	if secs, ok := m["timeout_seconds"].(float64); ok {
+		req.Timeout = time.Duration(secs * float64(time.Second))
+	}
+	if secs, ok := m["escalation_timeout_seconds"].(float64); ok {
+		req.EscalationTimeout = time.Duration(secs * float64(time.Second))
+	}
+	return req, true
+}
+
+// FirstApprovalInterrupt returns the first interrupt context raised by
+// an Approval node, walking up from each leaf like
+// FirstUserFillUpInterrupt.
+func FirstApprovalInterrupt(ctxs []*compose.InterruptCtx) *compose.InterruptCtx {
+	for _, ctx := range ctxs {
+		for cur := ctx; cur != nil; cur = cur.Parent {
+			if _, ok := ApprovalRequestFromInfo(cur.Info); ok {
+				return cur
+			}
+		}
+	}
+	return nil
+}
+
+// ApprovalNodeBody returns the node function of an Approval node. A
+// params error is reported at build time so a misconfigured gate fails
+// compilation instead of pausing a run nobody can resume.
+func ApprovalNodeBody(cpnID string, params map[string]any) (nodeBodyFn, error) {
+	p, err := parseApprovalParams(params)
+	if err != nil {
+		return nil, fmt.Errorf("canvas: Approval %q: %w", cpnID, err)
+	}
+	return func(ctx context.Context, _ map[string]any) (map[string]any, error) {
+		if isResume, hasData, data := compose.GetResumeContext[any](ctx); isResume && hasData {
+			if decision, ok := data.(ApprovalDecision); ok {
+				return approvalOutput(cpnID, decision), nil
+			}
+		}
+		state, _, _ := GetStateFromContext[*CanvasState](ctx)
+		if err := compose.Interrupt(ctx, p.info(cpnID, state)); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("canvas: Approval %q: interrupt did not halt execution", cpnID)
+	}, nil
+}
+
+// approvalOutput maps a decision onto the node's outputs. Downstream
+// Switch nodes branch on `approved` or `decision`.
+func approvalOutput(cpnID string, d ApprovalDecision) map[string]any {
+	decision := "rejected"
+	if d.Approved() {
+		decision = "approved"
+	}
+	return map[string]any{
+		"__cpn_id__": cpnID,
+		"decision":   decision,
+		"approved":   d.Approved(),
+		"edited":     d.Action == ApprovalActionEdit,
+		"approver":   d.Approver,
+		"comment":    d.Comment,
+		// Not "content": the run's answer extraction reads that key.
+		"final_content": d.Content,
+		"timed_out":     d.TimedOut,
+	}
+}
@@ -0,0 +1,220 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// approval_store.go persists Approval records next to the run
+// checkpoints so a paused approval survives a server restart: the eino
+// checkpoint (checkpoint_store.go) holds the graph, the record holds
+// the interrupt id and everything the approvers and the sweeper need.
+//
+// Redis layout:
+//
+//	agent:approval:{id}                JSON record, TTL
+//	agent:approvals:tenant:{tenant_id} set of pending approval ids
+//	agent:approvals:due                sorted set id -> deadline (unix ms)
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	redis2 "ragflow/internal/engine/redis"
+)
+
+const (
+	approvalKeyPrefix       = "agent:approval:"
+	approvalTenantKeyPrefix = "agent:approvals:tenant:"
+	approvalDueKey          = "agent:approvals:due"
+)
+
+// Approval statuses.
+const (
+	ApprovalStatusPending  = "pending"
+	ApprovalStatusApproved = "approved"
+	ApprovalStatusRejected = "rejected"
+)
+
+// Approval is one approval request raised by an Approval node.
+// Approvers is the list currently allowed to decide; it switches to the
+// escalation list when the request escalates.
+type Approval struct {
+	ID                  string            `json:"id"`
+	TenantID            string            `json:"tenant_id"`
+	UserID              string            `json:"user_id"`
+	CanvasID            string            `json:"canvas_id"`
+	SessionID           string            `json:"session_id"`
+	VersionID           string            `json:"version_id,omitempty"`
+	ComponentID         string            `json:"component_id"`
+	InterruptID         string            `json:"interrupt_id"`
+	Title               string            `json:"title,omitempty"`
+	Message             string            `json:"message,omitempty"`
+	Content             string            `json:"content,omitempty"`
+	Approvers           []string          `json:"approvers"`
+	EscalationApprovers []string          `json:"escalation_approvers,omitempty"`
+	WebhookURL          string            `json:"webhook_url,omitempty"`
+	TimeoutAction       string            `json:"timeout_action"`
+	EscalationTimeoutMs int64             `json:"escalation_timeout_ms,omitempty"`
+	Escalated           bool              `json:"escalated"`
+	Status              string            `json:"status"`
+	Decision            *ApprovalDecision `json:"decision,omitempty"`
+	CreatedAt           int64             `json:"created_at"`
+	Deadline            int64             `json:"deadline"`
+	DecidedAt           int64             `json:"decided_at,omitempty"`
+}
+
+// ApprovalStore persists approval records.
+type ApprovalStore interface {
+	// Save writes a, indexing it as pending or dropping it from the
+	// pending indexes according to a.Status.
+	Save(ctx context.Context, a *Approval) error
+	// Get returns the approval with id; ok is false when it is unknown
+	// or has expired.
+	Get(ctx context.Context, id string) (a *Approval, ok bool, err error)
+	// ListPending returns the pending approvals of tenantID.
+	ListPending(ctx context.Context, tenantID string) ([]*Approval, error)
+	// Due returns the ids of pending approvals whose deadline is at or
+	// before now.
+	Due(ctx context.Context, now time.Time) ([]string, error)
+	// Claim takes id off the deadline index. Exactly one caller gets
+	// true, which lets a decision and the timeout sweeper race safely.
+	Claim(ctx context.Context, id string) (bool, error)
+}
+
+// RedisApprovalStore is the Redis-backed ApprovalStore.
+type RedisApprovalStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisApprovalStore returns a store wired to the global Redis
+// client. Methods error (rather than panic) when the cache is not
+// initialised.
+func NewRedisApprovalStore(ttl time.Duration) *RedisApprovalStore {
+	var client *redis.Client
+	if rc := redis2.Get(); rc != nil {
+		client = rc.GetClient()
+	}
+	return &RedisApprovalStore{client: client, ttl: ttl}
+}
+
+// NewRedisApprovalStoreWithClient returns a store wired to a
+// caller-supplied redis.Client (tests, dedicated pools).
+func NewRedisApprovalStoreWithClient(client *redis.Client, ttl time.Duration) *RedisApprovalStore {
+	return &RedisApprovalStore{client: client, ttl: ttl}
+}
+
+var errApprovalStoreUninitialized = errors.New("approval store: redis client not initialized")
+
+// Save implements ApprovalStore. The record and its index entries go
+// through one transaction so a pending record is never missing from
+// the indexes.
+func (s *RedisApprovalStore) Save(ctx context.Context, a *Approval) error {
+	if s == nil || s.client == nil {
+		return errApprovalStoreUninitialized
+	}
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	tenantKey := approvalTenantKeyPrefix + a.TenantID
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, approvalKeyPrefix+a.ID, payload, s.ttl)
+	if a.Status == ApprovalStatusPending {
+		pipe.SAdd(ctx, tenantKey, a.ID)
+		pipe.Expire(ctx, tenantKey, s.ttl)
+		pipe.ZAdd(ctx, approvalDueKey, redis.Z{Score: float64(a.Deadline), Member: a.ID})
+	} else {
+		pipe.SRem(ctx, tenantKey, a.ID)
+		pipe.ZRem(ctx, approvalDueKey, a.ID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Get implements ApprovalStore.
+func (s *RedisApprovalStore) Get(ctx context.Context, id string) (*Approval, bool, error) {
+	if s == nil || s.client == nil {
+		return nil, false, errApprovalStoreUninitialized
+	}
+	data, err := s.client.Get(ctx, approvalKeyPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	a := &Approval{}
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, false, err
+	}
+	return a, true, nil
+}
+
+// ListPending implements ApprovalStore. Ids whose record has expired
+// are pruned from the tenant index on the way.
+func (s *RedisApprovalStore) ListPending(ctx context.Context, tenantID string) ([]*Approval, error) {
+	if s == nil || s.client == nil {
+		return nil, errApprovalStoreUninitialized
+	}
+	tenantKey := approvalTenantKeyPrefix + tenantID
+	ids, err := s.client.SMembers(ctx, tenantKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Approval, 0, len(ids))
+	for _, id := range ids {
+		a, ok, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			s.client.SRem(ctx, tenantKey, id)
+			s.client.ZRem(ctx, approvalDueKey, id)
+			continue
+		}
+		if a.Status == ApprovalStatusPending {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// Due implements ApprovalStore.
+func (s *RedisApprovalStore) Due(ctx context.Context, now time.Time) ([]string, error) {
+	if s == nil || s.client == nil {
+		return nil, errApprovalStoreUninitialized
+	}
+	return s.client.ZRangeByScore(ctx, approvalDueKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+}
+
+// Claim implements ApprovalStore.
+func (s *RedisApprovalStore) Claim(ctx context.Context, id string) (bool, error) {
+	if s == nil || s.client == nil {
+		return false, errApprovalStoreUninitialized
+	}
+	n, err := s.client.ZRem(ctx, approvalDueKey, id).Result()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,169 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package canvas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cloudwego/eino/compose"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseApprovalParams(t *testing.T) {
+	p, err := parseApprovalParams(map[string]any{
+		"approvers":                  []any{"a@x.com", " b@x.com "},
+		"escalation_approvers":       "boss@x.com, ",
+		"timeout_seconds":            float64(60),
+		"timeout_action":             "Escalate",
+		"escalation_timeout_seconds": float64(30),
+	})
+	if err != nil {
+		t.Fatalf("parseApprovalParams: %v", err)
+	}
+	if len(p.approvers) != 2 || p.approvers[1] != "b@x.com" || len(p.escalationApprovers) != 1 {
+		t.Errorf("approvers = %v / %v", p.approvers, p.escalationApprovers)
+	}
+	if p.timeout != time.Minute || p.escalationTimeout != 30*time.Second || p.timeoutAction != ApprovalTimeoutEscalate {
+		t.Errorf("timeouts = %v / %v / %q", p.timeout, p.escalationTimeout, p.timeoutAction)
+	}
+
+	def, err := parseApprovalParams(nil)
+	if err != nil || def.timeout != defaultApprovalTimeout || def.timeoutAction != ApprovalTimeoutReject {
+		t.Errorf("defaults = %+v, %v", def, err)
+	}
+
+	for name, params := range map[string]map[string]any{
+		"bad action":          {"timeout_action": "ignore"},
+		"escalate w/o list":   {"timeout_action": "escalate"},
+		"non-positive period": {"timeout_seconds": float64(0)},
+	} {
+		if _, err := parseApprovalParams(params); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+	if _, err := buildNodeBody("approval_0", "Approval", map[string]any{"timeout_action": "ignore"}); err == nil {
+		t.Error("buildNodeBody: want params error for a bad Approval")
+	}
+}
+
+func TestApprovalNodeBody_InterruptsWithRequest(t *testing.T) {
+	body, err := ApprovalNodeBody("approval_0", map[string]any{
+		"title":     "Send {{sys.query}}?",
+		"content":   "draft for {{sys.query}}",
+		"approvers": []any{"a@x.com"},
+	})
+	if err != nil {
+		t.Fatalf("ApprovalNodeBody: %v", err)
+	}
+	state := NewCanvasState("run-1", "task-1")
+	state.Sys["query"] = "acme"
+	_, err = body(withState(context.Background(), state), map[string]any{})
+	if !IsInterruptError(err) {
+		t.Fatalf("first run: want interrupt, got %v", err)
+	}
+	info, ok := compose.IsInterruptRerunError(err)
+	if !ok {
+		t.Fatalf("interrupt carries no info: %v", err)
+	}
+	req, ok := ApprovalRequestFromInfo(info)
+	if !ok {
+		t.Fatalf("info %#v is not an approval request", info)
+	}
+	if req.ComponentID != "approval_0" || req.Title != "Send acme?" || req.Content != "draft for acme" {
+		t.Errorf("request = %+v", req)
+	}
+	if len(req.Approvers) != 1 || req.Timeout != defaultApprovalTimeout || req.TimeoutAction != ApprovalTimeoutReject {
+		t.Errorf("request = %+v", req)
+	}
+	if _, ok := ApprovalRequestFromInfo(BuildInputSpec(nil)); ok {
+		t.Error("a UserFillUp spec decoded as an approval request")
+	}
+}
+
+func TestApprovalOutput(t *testing.T) {
+	out := approvalOutput("approval_0", ApprovalDecision{Action: ApprovalActionEdit, Approver: "a@x.com", Content: "v2"})
+	if out["decision"] != "approved" || out["approved"] != true || out["edited"] != true || out["final_content"] != "v2" {
+		t.Errorf("edit output = %v", out)
+	}
+	out = approvalOutput("approval_0", ApprovalDecision{Action: ApprovalActionReject, TimedOut: true})
+	if out["decision"] != "rejected" || out["approved"] != false || out["timed_out"] != true {
+		t.Errorf("reject output = %v", out)
+	}
+}
+
+func TestRedisApprovalStore(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisApprovalStoreWithClient(client, time.Hour)
+	ctx := context.Background()
+
+	now := time.UnixMilli(1_000_000)
+	for _, a := range []*Approval{
+		{ID: "a1", TenantID: "t1", Status: ApprovalStatusPending, Deadline: now.Add(-time.Second).UnixMilli()},
+		{ID: "a2", TenantID: "t1", Status: ApprovalStatusPending, Deadline: now.Add(time.Hour).UnixMilli()},
+		{ID: "a3", TenantID: "t2", Status: ApprovalStatusPending, Deadline: now.Add(time.Hour).UnixMilli()},
+	} {
+		if err := store.Save(ctx, a); err != nil {
+			t.Fatalf("Save(%s): %v", a.ID, err)
+		}
+	}
+	if pending, err := store.ListPending(ctx, "t1"); err != nil || len(pending) != 2 {
+		t.Fatalf("ListPending(t1) = %v, %v; want 2", pending, err)
+	}
+	due, err := store.Due(ctx, now)
+	if err != nil || len(due) != 1 || due[0] != "a1" {
+		t.Fatalf("Due = %v, %v; want [a1]", due, err)
+	}
+
+	if ok, err := store.Claim(ctx, "a1"); err != nil || !ok {
+		t.Fatalf("first Claim = %v, %v; want true", ok, err)
+	}
+	if ok, _ := store.Claim(ctx, "a1"); ok {
+		t.Error("second Claim succeeded")
+	}
+
+	a, ok, err := store.Get(ctx, "a1")
+	if err != nil || !ok {
+		t.Fatalf("Get(a1) = %v, %v", ok, err)
+	}
+	a.Status = ApprovalStatusRejected
+	if err := store.Save(ctx, a); err != nil {
+		t.Fatalf("Save(decided): %v", err)
+	}
+	if pending, _ := store.ListPending(ctx, "t1"); len(pending) != 1 || pending[0].ID != "a2" {
+		t.Errorf("ListPending after decision = %v, want [a2]", pending)
+	}
+	if _, ok, _ := store.Get(ctx, "missing"); ok {
+		t.Error("Get(missing) reported ok")
+	}
+
+	mr.Del(approvalKeyPrefix + "a2")
+	if pending, _ := store.ListPending(ctx, "t1"); len(pending) != 0 {
+		t.Errorf("ListPending with expired record = %v, want empty", pending)
+	}
+	if due, _ := store.Due(ctx, now.Add(2*time.Hour)); len(due) != 1 || due[0] != "a3" {
+		t.Errorf("Due after prune = %v, want [a3]", due)
+	}
+}
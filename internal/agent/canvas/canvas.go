@@ -47,6 +47,9 @@ type Canvas struct {
 	History    []map[string]any           `json:"history,omitempty"`
 	Retrieval  map[string]any             `json:"retrieval,omitempty"`
 	Globals    map[string]any             `json:"globals,omitempty"`
+	// Variables declares the env.* globals ({name: {type, value, ...}});
+	// their types feed DeclaredVarTypes.
+	Variables map[string]any `json:"variables,omitempty"`
 	// NodeParents preserves the front-end graph's grouping metadata
 	// (graph.nodes[*].parentId) for runtime-only subgraph expansion.
 	// The backend treats the incoming DSL as read-only; this is a
@@ -59,6 +59,7 @@ type nodeBodyFn = func(ctx context.Context, in map[string]any) (map[string]any,
 //     UserFillUpComponent.Invoke body. UserFillUpNodeBody calls
 //     compose.Interrupt on first execution and reads the resume
 //     payload via compose.GetResumeContext on subsequent runs.
+//     "Approval" routes to ApprovalNodeBody for the same reason.
 //  3. runtime.DefaultFactory() is non-nil → call the factory once to
 //     construct a runtime.Component, then return a body that delegates
 //     to that component's Invoke. A factory error surfaces here with
@@ -89,6 +90,11 @@ func buildNodeBody(cpnID, name string, params map[string]any) (nodeBodyFn, error
 	if strings.EqualFold(name, "UserFillUp") {
 		return UserFillUpNodeBody(cpnID, params), nil
 	}
+	// Approval pauses the same way; its decision arrives through the
+	// approvals API instead of the chat input (approval.go).
+	if strings.EqualFold(name, "Approval") {
+		return ApprovalNodeBody(cpnID, params)
+	}
 	if factory := runtime.DefaultFactory(); factory != nil {
 		comp, err := factory(name, params)
 		if err != nil {
@@ -161,6 +167,9 @@ func componentTimeout() time.Duration {
 // parent-context cancellation as `cancelled: <wrapped>`; all other
 // errors wrap the component's own error with the cpn_id for diagnostics.
 //
+// The component sees its own cpn id via runtime.ComponentIDFromContext
+// (SubAgent uses it to attribute nested child-canvas events).
+//
 // The output map is tagged with __cpn_id__ before return so statePost
 // can attribute the result; if the component already populated that
 // key it is overwritten with the canvas-controlled value to keep
@@ -168,7 +177,7 @@ func componentTimeout() time.Duration {
 func realComponentBody(cpnID, componentClass string, comp runtime.Component) nodeBodyFn {
 	return func(ctx context.Context, in map[string]any) (map[string]any, error) {
 		timeout := resolveTimeout(componentClass)
-		cctx, cancel := context.WithTimeout(ctx, timeout)
+		cctx, cancel := context.WithTimeout(runtime.WithComponentID(ctx, cpnID), timeout)
 		defer cancel()
 		out, err := comp.Invoke(cctx, in)
 		if err != nil {
@@ -0,0 +1,201 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// node_checkpoint.go records a CanvasState snapshot after every node
+// finishes so a run can be inspected node by node and forked from any
+// point ("time travel"). eino's own checkpoints (checkpoint_store.go)
+// are only written on interrupt and hold opaque graph bytes; these are
+// plain JSON keyed by run id in the Redis list "agent:nodecp:{run_id}",
+// one entry per finished node in completion order.
+//
+// Forking works by replay: WithReplayOutputs hands BuildWorkflow's node
+// bodies the recorded outputs of every node that had finished at the
+// chosen checkpoint, and those nodes return them instead of invoking
+// their component. Everything after the checkpoint runs live.
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"ragflow/internal/agent/runtime"
+	"ragflow/internal/common"
+	redis2 "ragflow/internal/engine/redis"
+)
+
+// nodeCheckpointKeyPrefix is the Redis list namespace for per-node
+// checkpoints. The full key is "agent:nodecp:{run_id}".
+const nodeCheckpointKeyPrefix = "agent:nodecp:"
+
+// NodeCheckpoint is the state of a run right after one node finished.
+// Seq is the entry's position in the run (0 = first finished node) and
+// is assigned by the store on read.
+type NodeCheckpoint struct {
+	Seq           int             `json:"seq"`
+	ComponentID   string          `json:"component_id"`
+	ComponentName string          `json:"component_name"`
+	VersionID     string          `json:"version_id,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	CreatedAt     int64           `json:"created_at"`
+	State         json.RawMessage `json:"state,omitempty"`
+}
+
+// NodeCheckpointStore persists per-node checkpoints of canvas runs.
+type NodeCheckpointStore interface {
+	// Append records cp as the next checkpoint of runID.
+	Append(ctx context.Context, runID string, cp NodeCheckpoint) error
+	// List returns every checkpoint of runID in completion order.
+	List(ctx context.Context, runID string) ([]NodeCheckpoint, error)
+	// Reset drops the checkpoints of runID before a new run reuses the id.
+	Reset(ctx context.Context, runID string) error
+}
+
+// RedisNodeCheckpointStore is the Redis-backed NodeCheckpointStore.
+type RedisNodeCheckpointStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisNodeCheckpointStore returns a store wired to the global Redis
+// client. Methods error (rather than panic) when the cache is not
+// initialised.
+func NewRedisNodeCheckpointStore(ttl time.Duration) *RedisNodeCheckpointStore {
+	var client *redis.Client
+	if rc := redis2.Get(); rc != nil {
+		client = rc.GetClient()
+	}
+	return &RedisNodeCheckpointStore{client: client, ttl: ttl}
+}
+
+// NewRedisNodeCheckpointStoreWithClient returns a store wired to a
+// caller-supplied redis.Client (tests, dedicated pools).
+func NewRedisNodeCheckpointStoreWithClient(client *redis.Client, ttl time.Duration) *RedisNodeCheckpointStore {
+	return &RedisNodeCheckpointStore{client: client, ttl: ttl}
+}
+
+// Append implements NodeCheckpointStore. RPUSH + EXPIRE go through one
+// pipeline so the list never exists without a TTL.
+func (s *RedisNodeCheckpointStore) Append(ctx context.Context, runID string, cp NodeCheckpoint) error {
+	if s == nil || s.client == nil {
+		return errors.New("node checkpoint store: redis client not initialized")
+	}
+	payload, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	key := nodeCheckpointKeyPrefix + runID
+	pipe := s.client.Pipeline()
+	pipe.RPush(ctx, key, payload)
+	pipe.Expire(ctx, key, s.ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// List implements NodeCheckpointStore. Entries that fail to decode are
+// skipped so one corrupt entry does not hide the rest of the run.
+func (s *RedisNodeCheckpointStore) List(ctx context.Context, runID string) ([]NodeCheckpoint, error) {
+	if s == nil || s.client == nil {
+		return nil, errors.New("node checkpoint store: redis client not initialized")
+	}
+	raw, err := s.client.LRange(ctx, nodeCheckpointKeyPrefix+runID, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]NodeCheckpoint, 0, len(raw))
+	for i, item := range raw {
+		var cp NodeCheckpoint
+		if err := json.Unmarshal([]byte(item), &cp); err != nil {
+			continue
+		}
+		cp.Seq = i
+		out = append(out, cp)
+	}
+	return out, nil
+}
+
+// Reset implements NodeCheckpointStore.
+func (s *RedisNodeCheckpointStore) Reset(ctx context.Context, runID string) error {
+	if s == nil || s.client == nil {
+		return errors.New("node checkpoint store: redis client not initialized")
+	}
+	return s.client.Del(ctx, nodeCheckpointKeyPrefix+runID).Err()
+}
+
+// recordNodeCheckpoint appends the post-node state to the run's
+// checkpoint list. Best-effort: a store failure is logged and never
+// fails the node. The context-attached state is preferred because it
+// carries the sys/env namespaces the service seeded.
+func recordNodeCheckpoint(ctx context.Context, state *CanvasState, cpnID, componentName string, nodeErr error) {
+	meta := GetRunMeta(ctx)
+	if meta == nil || meta.Checkpoints == nil || meta.RunID == "" {
+		return
+	}
+	if ctxState, _, _ := runtime.GetStateFromContext[*runtime.CanvasState](ctx); ctxState != nil {
+		state = ctxState
+	}
+	if state == nil {
+		return
+	}
+	snapshot, err := json.Marshal(state)
+	if err != nil {
+		common.Warn("canvas: node checkpoint marshal failed", zap.String("run", meta.RunID), zap.Error(err))
+		return
+	}
+	cp := NodeCheckpoint{
+		ComponentID:   cpnID,
+		ComponentName: componentName,
+		VersionID:     meta.VersionID,
+		CreatedAt:     time.Now().UnixMilli(),
+		State:         snapshot,
+	}
+	if nodeErr != nil {
+		cp.Error = nodeErr.Error()
+	}
+	if err := meta.Checkpoints.Append(ctx, meta.RunID, cp); err != nil {
+		common.Warn("canvas: node checkpoint append failed", zap.String("run", meta.RunID), zap.Error(err))
+	}
+}
+
+// replayCtxKey keys the recorded outputs a forked run replays.
+type replayCtxKey struct{}
+
+// WithReplayOutputs attaches per-cpn outputs that BuildWorkflow's node
+// bodies return verbatim instead of invoking their component.
+func WithReplayOutputs(ctx context.Context, outputs map[string]map[string]any) context.Context {
+	return context.WithValue(ctx, replayCtxKey{}, outputs)
+}
+
+// replayBody wraps a node body so a forked run returns the recorded
+// outputs of nodes that had already finished at the fork point.
+func replayBody(cpnID string, body nodeBodyFn) nodeBodyFn {
+	return func(ctx context.Context, in map[string]any) (map[string]any, error) {
+		outputs, _ := ctx.Value(replayCtxKey{}).(map[string]map[string]any)
+		recorded, ok := outputs[cpnID]
+		if !ok {
+			return body(ctx, in)
+		}
+		out := make(map[string]any, len(recorded)+1)
+		maps.Copy(out, recorded)
+		out["__cpn_id__"] = cpnID
+		return out, nil
+	}
+}
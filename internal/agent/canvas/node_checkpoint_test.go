@@ -0,0 +1,110 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestNodeCheckpointStore(t *testing.T) (*RedisNodeCheckpointStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisNodeCheckpointStoreWithClient(client, time.Hour), mr
+}
+
+func TestRedisNodeCheckpointStore_AppendListReset(t *testing.T) {
+	store, mr := newTestNodeCheckpointStore(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"begin_0", "llm_0"} {
+		if err := store.Append(ctx, "run-1", NodeCheckpoint{ComponentID: id, State: json.RawMessage(`{"outputs":{}}`)}); err != nil {
+			t.Fatalf("Append(%s): %v", id, err)
+		}
+	}
+	got, err := store.List(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 2 || got[0].ComponentID != "begin_0" || got[1].Seq != 1 || got[1].ComponentID != "llm_0" {
+		t.Fatalf("List = %+v, want begin_0 then llm_0 with seq 0,1", got)
+	}
+	if ttl := mr.TTL(nodeCheckpointKeyPrefix + "run-1"); ttl <= 0 {
+		t.Errorf("TTL = %v, want positive", ttl)
+	}
+
+	if err := store.Reset(ctx, "run-1"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if got, _ := store.List(ctx, "run-1"); len(got) != 0 {
+		t.Errorf("List after Reset = %+v, want empty", got)
+	}
+}
+
+func TestRecordNodeCheckpoint_UsesRunMeta(t *testing.T) {
+	store, _ := newTestNodeCheckpointStore(t)
+	state := NewCanvasState("run-1", "task-1")
+	state.SetVar("begin_0", "query", "hi")
+	ctx := WithRunMeta(context.Background(), &RunMeta{RunID: "run-1", VersionID: "v1", Checkpoints: store})
+
+	recordNodeCheckpoint(ctx, state, "begin_0", "Begin", nil)
+	// Without a store nothing is recorded and nothing panics.
+	recordNodeCheckpoint(WithRunMeta(context.Background(), &RunMeta{RunID: "run-1"}), state, "x", "X", nil)
+
+	got, err := store.List(context.Background(), "run-1")
+	if err != nil || len(got) != 1 {
+		t.Fatalf("List = %+v, %v; want one checkpoint", got, err)
+	}
+	restored := &CanvasState{}
+	if err := json.Unmarshal(got[0].State, restored); err != nil {
+		t.Fatalf("decode state: %v", err)
+	}
+	if got[0].VersionID != "v1" || restored.Outputs["begin_0"]["query"] != "hi" {
+		t.Errorf("checkpoint = %+v, outputs = %v", got[0], restored.Outputs)
+	}
+}
+
+func TestReplayBody_ReturnsRecordedOutputs(t *testing.T) {
+	calls := 0
+	body := replayBody("llm_0", func(context.Context, map[string]any) (map[string]any, error) {
+		calls++
+		return map[string]any{"content": "live"}, nil
+	})
+
+	out, err := body(WithReplayOutputs(context.Background(), map[string]map[string]any{
+		"llm_0": {"content": "recorded"},
+	}), nil)
+	if err != nil || out["content"] != "recorded" || out["__cpn_id__"] != "llm_0" || calls != 0 {
+		t.Fatalf("replayed out = %v, err = %v, calls = %d", out, err, calls)
+	}
+
+	out, _ = body(WithReplayOutputs(context.Background(), map[string]map[string]any{"other": {}}), nil)
+	if out["content"] != "live" || calls != 1 {
+		t.Fatalf("non-replayed out = %v, calls = %d", out, calls)
+	}
+}
@@ -239,6 +239,7 @@ func buildParallelOuterWorkflow(
 			localState.Sys = shallowCopyAnyMap(parentState.Sys)
 			localState.Globals = shallowCopyAnyMap(parentState.Globals)
 		}
+		localState.SetVarTypes(parentState.VarTypes())
 		localState.Globals["__item__"] = itemMap["item"]
 		localState.Globals["__index__"] = index
 		return runtime.WithState(ctx, localState)
@@ -0,0 +1,237 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// run_report.go — per-run cost and latency accounting.
+//
+// A RunReportCollector rides on RunMeta.Report. nodeFinishedNow feeds it
+// every finished node (wall time, error, outputs); the collector reads
+// the accounting keys components publish in their outputs:
+//
+//   - LLM, Categorize: model, tokens, prompt_tokens, completion_tokens, retries
+//   - Agent: model and token counts summed over its ReAct rounds, plus
+//     tool_calls (one entry per call)
+//   - CodeExec: the node's wall time is its sandbox time
+//
+// A node that runs several times in one run (loops) is reported once,
+// with its counters summed. Finish prices the token counts with the
+// operator's model price table and returns the RunReport the service
+// persists with the session.
+package canvas
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run report statuses.
+const (
+	RunReportSucceeded = "succeeded"
+	RunReportFailed    = "failed"
+	// RunReportPaused marks a run segment that stopped on an interrupt
+	// (UserFillUp, Approval); its resume is reported as a new run.
+	RunReportPaused = "paused"
+)
+
+// sandboxComponentName is the component whose wall time counts as
+// sandbox execution time.
+const sandboxComponentName = "CodeExec"
+
+// ModelPrice is the price of one model in currency units per million
+// tokens.
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// NodeReport is the accounting of one component over a run.
+type NodeReport struct {
+	ComponentID      string  `json:"component_id"`
+	ComponentName    string  `json:"component_name"`
+	Runs             int     `json:"runs"`
+	Errors           int     `json:"errors"`
+	WallTimeMs       float64 `json:"wall_time_ms"`
+	Retries          int     `json:"retries"`
+	Model            string  `json:"model,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	EstimatedCost    float64 `json:"estimated_cost"`
+	// Priced is false when the node used tokens but its model has no
+	// entry in the price table, so EstimatedCost understates the cost.
+	Priced        bool    `json:"priced"`
+	ToolCalls     int     `json:"tool_calls"`
+	SandboxTimeMs float64 `json:"sandbox_time_ms"`
+}
+
+// RunReportTotals sums the node reports of a run.
+type RunReportTotals struct {
+	Retries          int     `json:"retries"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	EstimatedCost    float64 `json:"estimated_cost"`
+	ToolCalls        int     `json:"tool_calls"`
+	SandboxTimeMs    float64 `json:"sandbox_time_ms"`
+}
+
+// RunReport is the cost and latency report of one run.
+type RunReport struct {
+	Status     string          `json:"status"`
+	StartedAt  int64           `json:"started_at"`
+	DurationMs float64         `json:"duration_ms"`
+	Nodes      []NodeReport    `json:"nodes"`
+	Totals     RunReportTotals `json:"totals"`
+}
+
+// RunReportCollector accumulates node accounting during a run. Nodes on
+// parallel branches finish concurrently, so it is safe for concurrent
+// use.
+type RunReportCollector struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	order     []string
+	nodes     map[string]*NodeReport
+}
+
+// NewRunReportCollector starts a collector for a run that began at
+// startedAt.
+func NewRunReportCollector(startedAt time.Time) *RunReportCollector {
+	return &RunReportCollector{startedAt: startedAt, nodes: map[string]*NodeReport{}}
+}
+
+// RecordNode accounts one finished execution of cpnID.
+func (c *RunReportCollector) RecordNode(cpnID, componentName string, elapsed time.Duration, outputs map[string]any, nodeErr error) {
+	if c == nil || cpnID == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.nodes[cpnID]
+	if !ok {
+		n = &NodeReport{ComponentID: cpnID, ComponentName: componentName}
+		c.nodes[cpnID] = n
+		c.order = append(c.order, cpnID)
+	}
+	ms := float64(elapsed) / float64(time.Millisecond)
+	n.Runs++
+	n.WallTimeMs += ms
+	if nodeErr != nil {
+		n.Errors++
+	}
+	if strings.EqualFold(componentName, sandboxComponentName) {
+		n.SandboxTimeMs += ms
+	}
+	if model, _ := outputs["model"].(string); model != "" {
+		n.Model = model
+	}
+	n.Retries += reportInt(outputs["retries"])
+	prompt, completion := reportInt(outputs["prompt_tokens"]), reportInt(outputs["completion_tokens"])
+	total := reportInt(outputs["tokens"])
+	if total == 0 {
+		total = prompt + completion
+	}
+	n.PromptTokens += prompt
+	n.CompletionTokens += completion
+	n.TotalTokens += total
+	switch calls := outputs["tool_calls"].(type) {
+	case []map[string]any:
+		n.ToolCalls += len(calls)
+	case []any:
+		n.ToolCalls += len(calls)
+	}
+}
+
+// Finish closes the run at finishedAt and prices it. prices may be nil.
+func (c *RunReportCollector) Finish(status string, finishedAt time.Time, prices map[string]ModelPrice) *RunReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := &RunReport{
+		Status:     status,
+		StartedAt:  c.startedAt.UnixMilli(),
+		DurationMs: float64(finishedAt.Sub(c.startedAt)) / float64(time.Millisecond),
+		Nodes:      make([]NodeReport, 0, len(c.order)),
+	}
+	for _, id := range c.order {
+		n := *c.nodes[id]
+		n.EstimatedCost, n.Priced = priceNode(n, prices)
+		r.Nodes = append(r.Nodes, n)
+
+		r.Totals.Retries += n.Retries
+		r.Totals.PromptTokens += n.PromptTokens
+		r.Totals.CompletionTokens += n.CompletionTokens
+		r.Totals.TotalTokens += n.TotalTokens
+		r.Totals.EstimatedCost += n.EstimatedCost
+		r.Totals.ToolCalls += n.ToolCalls
+		r.Totals.SandboxTimeMs += n.SandboxTimeMs
+	}
+	return r
+}
+
+// priceNode estimates the cost of a node's tokens. Nodes without tokens
+// are trivially priced. Without a prompt/completion split the total is
+// priced at the input rate.
+func priceNode(n NodeReport, prices map[string]ModelPrice) (float64, bool) {
+	if n.TotalTokens == 0 {
+		return 0, true
+	}
+	p, ok := LookupModelPrice(prices, n.Model)
+	if !ok {
+		return 0, false
+	}
+	if n.PromptTokens == 0 && n.CompletionTokens == 0 {
+		return float64(n.TotalTokens) * p.InputPerMillion / 1e6, true
+	}
+	return (float64(n.PromptTokens)*p.InputPerMillion + float64(n.CompletionTokens)*p.OutputPerMillion) / 1e6, true
+}
+
+// LookupModelPrice finds the price of model, case-insensitively. A
+// composite "<model>@<instance>@<provider>" id falls back to its model
+// name.
+func LookupModelPrice(prices map[string]ModelPrice, model string) (ModelPrice, bool) {
+	if model == "" || len(prices) == 0 {
+		return ModelPrice{}, false
+	}
+	candidates := []string{model}
+	if i := strings.Index(model, "@"); i > 0 {
+		candidates = append(candidates, model[:i])
+	}
+	for _, m := range candidates {
+		if p, ok := prices[m]; ok {
+			return p, true
+		}
+		for name, p := range prices {
+			if strings.EqualFold(name, m) {
+				return p, true
+			}
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// reportInt reads a count from a component output, which is an int in
+// process and a float64 after a JSON round trip.
+func reportInt(v any) int {
+	switch t := v.(type) {
+	case int:
+		return t
+	case int64:
+		return int(t)
+	case float64:
+		return int(t)
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package canvas
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRunReportCollector_AggregatesNodes(t *testing.T) {
+	start := time.Unix(1000, 0)
+	c := NewRunReportCollector(start)
+
+	c.RecordNode("begin_0", "Begin", 5*time.Millisecond, map[string]any{"query": "hi"}, nil)
+	// A loop body LLM runs twice; the second call needed a retry.
+	c.RecordNode("llm_0", "LLM", 100*time.Millisecond, map[string]any{
+		"model": "gpt-4o@openai", "tokens": 150, "prompt_tokens": 100, "completion_tokens": 50, "retries": 0,
+	}, nil)
+	c.RecordNode("llm_0", "LLM", 200*time.Millisecond, map[string]any{
+		"model": "gpt-4o@openai", "tokens": float64(300), "prompt_tokens": float64(200), "completion_tokens": float64(100), "retries": float64(2),
+	}, nil)
+	c.RecordNode("agent_0", "Agent", 50*time.Millisecond, map[string]any{
+		"model":      "local-model",
+		"tokens":     1000,
+		"tool_calls": []map[string]any{{"name": "search"}, {"name": "fetch"}},
+	}, nil)
+	c.RecordNode("code_0", "CodeExec", 40*time.Millisecond, nil, errors.New("sandbox timeout"))
+
+	r := c.Finish(RunReportSucceeded, start.Add(time.Second), map[string]ModelPrice{
+		"GPT-4o": {InputPerMillion: 2.5, OutputPerMillion: 10},
+	})
+
+	if r.Status != RunReportSucceeded || r.StartedAt != start.UnixMilli() || r.DurationMs != 1000 {
+		t.Fatalf("report header = %+v", r)
+	}
+	if len(r.Nodes) != 4 || r.Nodes[0].ComponentID != "begin_0" || r.Nodes[3].ComponentID != "code_0" {
+		t.Fatalf("nodes = %+v, want first-seen order", r.Nodes)
+	}
+
+	llm := r.Nodes[1]
+	if llm.Runs != 2 || llm.WallTimeMs != 300 || llm.Retries != 2 {
+		t.Fatalf("llm runs/wall/retries = %d/%v/%d", llm.Runs, llm.WallTimeMs, llm.Retries)
+	}
+	if llm.PromptTokens != 300 || llm.CompletionTokens != 150 || llm.TotalTokens != 450 {
+		t.Fatalf("llm tokens = %+v", llm)
+	}
+	wantCost := (300*2.5 + 150*10) / 1e6
+	if !llm.Priced || math.Abs(llm.EstimatedCost-wantCost) > 1e-12 {
+		t.Fatalf("llm cost = %v (priced %v), want %v", llm.EstimatedCost, llm.Priced, wantCost)
+	}
+
+	agent := r.Nodes[2]
+	if agent.ToolCalls != 2 || agent.Priced || agent.EstimatedCost != 0 {
+		t.Fatalf("agent = %+v, want 2 tool calls and unpriced", agent)
+	}
+
+	code := r.Nodes[3]
+	if code.Errors != 1 || code.SandboxTimeMs != 40 || !code.Priced {
+		t.Fatalf("code = %+v, want one error and 40ms sandbox time", code)
+	}
+
+	if r.Totals.TotalTokens != 1450 || r.Totals.ToolCalls != 2 || r.Totals.Retries != 2 || r.Totals.SandboxTimeMs != 40 {
+		t.Fatalf("totals = %+v", r.Totals)
+	}
+	if math.Abs(r.Totals.EstimatedCost-wantCost) > 1e-12 {
+		t.Fatalf("total cost = %v, want %v", r.Totals.EstimatedCost, wantCost)
+	}
+}
+
+func TestRunReportCollector_TotalOnlyPricedAtInputRate(t *testing.T) {
+	c := NewRunReportCollector(time.Unix(0, 0))
+	c.RecordNode("llm_0", "LLM", time.Millisecond, map[string]any{"model": "m", "tokens": 2000}, nil)
+
+	r := c.Finish(RunReportFailed, time.Unix(1, 0), map[string]ModelPrice{"m": {InputPerMillion: 1, OutputPerMillion: 100}})
+	if got := r.Nodes[0].EstimatedCost; math.Abs(got-0.002) > 1e-12 {
+		t.Fatalf("cost = %v, want 0.002", got)
+	}
+}
+
+func TestRunReportCollector_NilIsNoop(t *testing.T) {
+	var c *RunReportCollector
+	c.RecordNode("llm_0", "LLM", time.Second, map[string]any{"tokens": 1}, nil)
+}
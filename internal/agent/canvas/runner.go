@@ -194,6 +194,14 @@ func (r *Runner) saveInterruptID(canvasID, sessionID, interruptID string) {
 	r.mu.Unlock()
 }
 
+// RestoreInterruptID re-installs an interrupt id that was persisted
+// outside the Runner (approval records, approval_store.go), so the next
+// Run on the session resumes it even after a server restart emptied
+// the in-memory map.
+func (r *Runner) RestoreInterruptID(canvasID, sessionID, interruptID string) {
+	r.saveInterruptID(canvasID, sessionID, interruptID)
+}
+
 // getInterruptID reads back the interrupt id saved by the previous
 // run, then deletes it (the resume consumes it). Returns "" when no
 // prior paused run exists for this session.
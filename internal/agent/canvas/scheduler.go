@@ -48,11 +48,41 @@ type RunMeta struct {
 	MessageID string
 	TaskID    string
 	SessionID string
+
+	// RunID, VersionID and Checkpoints enable per-node checkpoints
+	// (node_checkpoint.go). A nil Checkpoints store disables them.
+	RunID       string
+	VersionID   string
+	Checkpoints NodeCheckpointStore
+
+	// Report accumulates per-node cost and latency (run_report.go). A
+	// nil Report disables the accounting.
+	Report *RunReportCollector
 }
 
 // WithRunMeta attaches run metadata to the context for consumption by
-// the per-node statePre/statePost wrappers in BuildWorkflow.
+// the per-node statePre/statePost wrappers in BuildWorkflow. A
+// non-empty SessionID is also attached via runtime.WithSessionID so
+// packages below canvas (tools) can scope resources to the session.
+// When m has an events channel, runtime.EmitEvent calls on ctx are
+// pushed onto it tagged with the run's message/task/session ids.
 func WithRunMeta(ctx context.Context, m *RunMeta) context.Context {
+	if m != nil && m.SessionID != "" {
+		ctx = runtime.WithSessionID(ctx, m.SessionID)
+	}
+	if m != nil && m.Events != nil {
+		ctx = runtime.WithEventSink(ctx, func(eventType string, data any) {
+			payload, err := json.Marshal(data)
+			if err != nil {
+				return
+			}
+			PushEvent(m.Events, RunEvent{
+				Type: eventType, Data: string(payload),
+				MessageID: m.MessageID, CreatedAt: time.Now().Unix(),
+				TaskID: m.TaskID, SessionID: m.SessionID,
+			})
+		})
+	}
 	return context.WithValue(ctx, ctxKeyRunMeta, m)
 }
 
@@ -310,6 +340,7 @@ func nodeFinishedNow(ctx context.Context, state *CanvasState, cpnID, componentNa
 	msgID, taskID, sessionID := "", "", ""
 	if meta != nil {
 		msgID, taskID, sessionID = meta.MessageID, meta.TaskID, meta.SessionID
+		meta.Report.RecordNode(cpnID, componentName, time.Duration(elapsed*float64(time.Second)), outputs, nodeErr)
 	}
 	emitEventFromCtx(ctx, RunEvent{
 		Type: "node_finished", Data: string(nfData),
@@ -352,8 +383,10 @@ func BuildWorkflow(ctx context.Context, c *Canvas) (*compose.Workflow[map[string
 	// seeding here mirrors the Python canvas.__init__ →
 	// self.globals["env.counter"] = 0 path.
 	globals := c.Globals
+	types := DeclaredVarTypes(c)
 	genState := func(_ context.Context) *CanvasState {
 		st := NewCanvasState("", "")
+		st.SetVarTypes(types)
 		if globals != nil {
 			for k, v := range globals {
 				if strings.HasPrefix(k, "sys.") {
@@ -478,9 +511,10 @@ func BuildWorkflow(ctx context.Context, c *Canvas) (*compose.Workflow[map[string
 		nodePost := func(ctx context.Context, out map[string]any, state *CanvasState) (map[string]any, error) {
 			result, postErr := statePost(ctx, out, state)
 			nodeFinishedNow(ctx, state, cpnID, componentName, componentName, postErr)
+			recordNodeCheckpoint(ctx, state, cpnID, componentName, postErr)
 			return result, postErr
 		}
-		lambda := compose.InvokableLambda[map[string]any, map[string]any](body)
+		lambda := compose.InvokableLambda[map[string]any, map[string]any](replayBody(cpnID, body))
 		node := wf.AddLambdaNode(cpnID, lambda,
 			compose.WithStatePreHandler[map[string]any, *CanvasState](nodePre),
 			compose.WithStatePostHandler[map[string]any, *CanvasState](nodePost),
@@ -5,6 +5,8 @@ import (
 	"context"
 	"strings"
 	"testing"
+
+	"ragflow/internal/agent/runtime"
 )
 
 // TestBuildWorkflow_3NodeLinear exercises a trivial Begin → LLM → Message
@@ -245,3 +247,31 @@ func TestBuildWorkflow_ErrorsOnSelfEdge(t *testing.T) {
 		t.Fatalf("expected 'self-edge' in error, got: %v", err)
 	}
 }
+
+func TestWithRunMeta_AttachesSessionID(t *testing.T) {
+	ctx := WithRunMeta(context.Background(), &RunMeta{SessionID: "session-1"})
+	if got := runtime.SessionIDFromContext(ctx); got != "session-1" {
+		t.Errorf("SessionIDFromContext = %q, want session-1", got)
+	}
+	if GetRunMeta(ctx).SessionID != "session-1" {
+		t.Error("run meta not attached")
+	}
+	if got := runtime.SessionIDFromContext(WithRunMeta(context.Background(), &RunMeta{})); got != "" {
+		t.Errorf("empty SessionID attached %q", got)
+	}
+}
+
+func TestWithRunMeta_ForwardsRuntimeEvents(t *testing.T) {
+	events := make(chan RunEvent, 1)
+	ctx := WithRunMeta(context.Background(), &RunMeta{Events: events, MessageID: "m1", TaskID: "t1", SessionID: "s1"})
+	runtime.EmitEvent(ctx, "tool_cache_hit", map[string]string{"tool": "tavily"})
+	ev := <-events
+	if ev.Type != "tool_cache_hit" || ev.Data != `{"tool":"tavily"}` {
+		t.Errorf("event = %+v", ev)
+	}
+	if ev.MessageID != "m1" || ev.TaskID != "t1" || ev.SessionID != "s1" {
+		t.Errorf("event ids = %+v", ev)
+	}
+	// Without an events channel the emit is dropped.
+	runtime.EmitEvent(WithRunMeta(context.Background(), &RunMeta{}), "tool_cache_hit", nil)
+}
@@ -0,0 +1,53 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package canvas
+
+import (
+	"strings"
+
+	"ragflow/internal/agent/runtime"
+	"ragflow/internal/agent/vartype"
+)
+
+// DeclaredVarTypes collects the type declarations of c — component
+// outputs, Begin / UserFillUp form inputs, Loop variables and the
+// env.* globals in c.Variables — for CanvasState.SetVarTypes. Invalid
+// declarations are skipped here; the DSL validator reports them. It
+// returns nil when c declares no types so untyped canvases skip the
+// checks entirely.
+func DeclaredVarTypes(c *Canvas) *runtime.VarTypes {
+	if c == nil {
+		return nil
+	}
+	types := runtime.NewVarTypes()
+	for cpnID, comp := range c.Components {
+		for output, decl := range vartype.Declarations(comp.Obj.Params) {
+			if t, err := vartype.Parse(decl); err == nil {
+				types.DeclareOutput(cpnID, comp.Obj.ComponentName, output, t)
+			}
+		}
+	}
+	for name, decl := range c.Variables {
+		if t, err := vartype.Parse(decl); err == nil {
+			types.DeclareEnv(strings.TrimPrefix(name, "env."), t)
+		}
+	}
+	if types.Empty() {
+		return nil
+	}
+	return types
+}
@@ -0,0 +1,230 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package canvastest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ragflow/internal/harness/core/evals"
+	hschema "ragflow/internal/harness/core/schema"
+)
+
+// namedScorer pairs an evals.Scorer with the assertion name reported
+// when it fails.
+type namedScorer struct {
+	name  string
+	score evals.Scorer
+}
+
+// score runs every assertion of c against run. Answer and error checks
+// reuse the evals scorers; outputs, variables and path are canvas
+// specific.
+func score(ctx context.Context, c Case, run *caseRun, took time.Duration) []Failure {
+	result := &evals.EvalResult{
+		Case:     evals.EvalCase{Name: c.Name, Query: c.Query},
+		Messages: []*hschema.Message{hschema.AssistantMessage(run.answer)},
+		Duration: took,
+		Err:      run.err,
+	}
+
+	var scorers []namedScorer
+	if c.Expect.Error == "" {
+		scorers = append(scorers, namedScorer{"error", evals.AgentError()})
+	} else {
+		scorers = append(scorers, namedScorer{"error", evals.AgentErrorContains(c.Expect.Error)})
+	}
+	for _, s := range c.Expect.AnswerContains {
+		scorers = append(scorers, namedScorer{"answer_contains", evals.FinalTextContains(s)})
+	}
+	for _, s := range c.Expect.AnswerExcludes {
+		scorers = append(scorers, namedScorer{"answer_excludes", evals.FinalTextExcludes(s)})
+	}
+	for _, ref := range sortedKeys(c.Expect.Outputs) {
+		scorers = append(scorers, namedScorer{"outputs", stateValue(run, ref, c.Expect.Outputs[ref])})
+	}
+	for _, ref := range sortedKeys(c.Expect.Variables) {
+		scorers = append(scorers, namedScorer{"variables", stateValue(run, ref, c.Expect.Variables[ref])})
+	}
+	if len(c.Expect.Path) > 0 {
+		scorers = append(scorers, namedScorer{"path", exactPath(run, c.Expect.Path)})
+	}
+	for _, id := range c.Expect.Visited {
+		scorers = append(scorers, namedScorer{"visited", visited(run, id, true)})
+	}
+	for _, id := range c.Expect.NotVisited {
+		scorers = append(scorers, namedScorer{"not_visited", visited(run, id, false)})
+	}
+
+	var failures []Failure
+	for _, s := range scorers {
+		if err := s.score(ctx, result); err != nil {
+			failures = append(failures, Failure{Assertion: s.name, Message: err.Error()})
+		}
+	}
+	return failures
+}
+
+func stateValue(run *caseRun, ref string, want any) evals.Scorer {
+	return func(context.Context, *evals.EvalResult) error {
+		if run.state == nil {
+			return fmt.Errorf("%s: run produced no state", ref)
+		}
+		got, err := run.state.GetVar(ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		if err := matchValue(got, want); err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		return nil
+	}
+}
+
+func exactPath(run *caseRun, want []string) evals.Scorer {
+	return func(context.Context, *evals.EvalResult) error {
+		if !reflect.DeepEqual(run.path, want) {
+			return fmt.Errorf("path %v, want %v", run.path, want)
+		}
+		return nil
+	}
+}
+
+func visited(run *caseRun, id string, want bool) evals.Scorer {
+	return func(context.Context, *evals.EvalResult) error {
+		got := false
+		for _, p := range run.path {
+			if p == id {
+				got = true
+				break
+			}
+		}
+		switch {
+		case want && !got:
+			return fmt.Errorf("node %s was not visited (path %v)", id, run.path)
+		case !want && got:
+			return fmt.Errorf("node %s was visited (path %v)", id, run.path)
+		}
+		return nil
+	}
+}
+
+// matchValue compares got against an expectation: a {$contains: s} or
+// {$matches: re} matcher on the string form of got, or otherwise
+// equality after both sides are normalised through JSON.
+func matchValue(got, want any) error {
+	if m, ok := want.(map[string]any); ok && len(m) == 1 {
+		if sub, ok := m["$contains"].(string); ok {
+			if !strings.Contains(stringForm(got), sub) {
+				return fmt.Errorf("%s does not contain %q", truncate(stringForm(got), 200), sub)
+			}
+			return nil
+		}
+		if expr, ok := m["$matches"].(string); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("bad $matches %q: %w", expr, err)
+			}
+			if !re.MatchString(stringForm(got)) {
+				return fmt.Errorf("%s does not match %q", truncate(stringForm(got), 200), expr)
+			}
+			return nil
+		}
+	}
+	g, err := normalise(got)
+	if err != nil {
+		return err
+	}
+	w, err := normalise(want)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(g, w) {
+		return fmt.Errorf("got %s, want %s", truncate(stringForm(got), 200), truncate(stringForm(want), 200))
+	}
+	return nil
+}
+
+func normalise(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("value is not JSON-encodable: %w", err)
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func stringForm(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EvalReport converts r to the evals report shape.
+func (r *Report) EvalReport() *evals.EvalReport {
+	out := &evals.EvalReport{
+		Total:    r.Total,
+		Passed:   r.Passed,
+		Failed:   r.Failed,
+		Duration: time.Duration(r.DurationMs) * time.Millisecond,
+	}
+	for _, c := range r.Cases {
+		cr := evals.CaseReport{
+			CaseName: c.Name,
+			Passed:   c.Passed,
+			Duration: time.Duration(c.DurationMs) * time.Millisecond,
+		}
+		for _, f := range c.Failures {
+			cr.Failures = append(cr.Failures, evals.Failure{ScorerName: f.Assertion, Message: f.Message})
+		}
+		out.Cases = append(out.Cases, cr)
+	}
+	return out
+}
+
+// WriteJUnit renders r as a JUnit XML report.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	name := r.Suite
+	if name == "" {
+		name = "canvastest"
+	}
+	return evals.WriteJUnit(w, name, r.EvalReport())
+}
@@ -0,0 +1,166 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package canvastest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+
+	"ragflow/internal/agent/component"
+	"ragflow/internal/agent/runtime"
+)
+
+// fakeModel answers chat calls from a case's LLM mocks. It implements
+// component.ChatInvoker.
+type fakeModel struct {
+	mu    sync.Mutex
+	mocks []LLMMock
+	used  []bool
+	calls int
+}
+
+func newFakeModel(mocks []LLMMock) *fakeModel {
+	return &fakeModel{mocks: mocks, used: make([]bool, len(mocks))}
+}
+
+// Invoke implements component.ChatInvoker.
+func (f *fakeModel) Invoke(ctx context.Context, req component.ChatInvokeRequest) (*component.ChatInvokeResponse, error) {
+	cpnID := runtime.ComponentIDFromContext(ctx)
+	prompt := promptText(req.Messages)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	for i, m := range f.mocks {
+		if f.used[i] {
+			continue
+		}
+		if m.ComponentID != "" && m.ComponentID != cpnID {
+			continue
+		}
+		if m.PromptContains != "" && !strings.Contains(prompt, m.PromptContains) {
+			continue
+		}
+		if !m.Repeat {
+			f.used[i] = true
+		}
+		if m.Error != "" {
+			return nil, errors.New(m.Error)
+		}
+		calls, err := toolCalls(m.ToolCalls, f.calls)
+		if err != nil {
+			return nil, err
+		}
+		return &component.ChatInvokeResponse{Content: m.Response, Model: "canvastest", ToolCalls: calls}, nil
+	}
+	return nil, fmt.Errorf("canvastest: no llm mock matches call from %q (prompt: %s)", cpnID, truncate(prompt, 200))
+}
+
+// promptText joins the message contents so PromptContains can look
+// across the system prompt and the conversation.
+func promptText(msgs []schema.Message) string {
+	parts := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		if m.Content != "" {
+			parts = append(parts, m.Content)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func toolCalls(in []MockToolCall, seq int) ([]schema.ToolCall, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make([]schema.ToolCall, 0, len(in))
+	for i, c := range in {
+		args, err := jsonText(c.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("canvastest: tool call %q arguments: %w", c.Name, err)
+		}
+		out = append(out, schema.ToolCall{
+			ID:       fmt.Sprintf("call_%d_%d", seq, i),
+			Type:     "function",
+			Function: schema.FunctionCall{Name: c.Name, Arguments: args},
+		})
+	}
+	return out, nil
+}
+
+// fakeTools answers Agent tool calls from a case's tool mocks.
+type fakeTools struct {
+	mu    sync.Mutex
+	mocks []ToolMock
+	used  []bool
+}
+
+func newFakeTools(mocks []ToolMock) *fakeTools {
+	return &fakeTools{mocks: mocks, used: make([]bool, len(mocks))}
+}
+
+// mock is the component.ToolMocker. Every call is handled: a call no
+// mock matches is an error rather than a pass-through to the real tool.
+func (f *fakeTools) mock(_ context.Context, toolName, argumentsJSON string) (string, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, m := range f.mocks {
+		if f.used[i] || m.Name != toolName {
+			continue
+		}
+		if m.ArgumentsContains != "" && !strings.Contains(argumentsJSON, m.ArgumentsContains) {
+			continue
+		}
+		if !m.Repeat {
+			f.used[i] = true
+		}
+		if m.Error != "" {
+			return "", true, errors.New(m.Error)
+		}
+		out, err := jsonText(m.Output)
+		return out, true, err
+	}
+	return "", true, fmt.Errorf("canvastest: no tool mock matches %s(%s)", toolName, truncate(argumentsJSON, 200))
+}
+
+// jsonText renders v as tool-call text: strings pass through, anything
+// else is JSON-encoded.
+func jsonText(v any) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
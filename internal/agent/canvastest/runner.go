@@ -0,0 +1,243 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package canvastest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/agent/component"
+	"ragflow/internal/agent/runtime"
+)
+
+// defaultCaseTimeout bounds one case when the suite sets no timeout.
+const defaultCaseTimeout = 60 * time.Second
+
+// CanvasFactory returns a fresh canvas for one case. Each case gets its
+// own so no compiled state leaks between cases.
+type CanvasFactory func() (*canvas.Canvas, error)
+
+// Report is the outcome of a suite run.
+type Report struct {
+	Suite      string       `json:"suite"`
+	Total      int          `json:"total"`
+	Passed     int          `json:"passed"`
+	Failed     int          `json:"failed"`
+	DurationMs int64        `json:"duration_ms"`
+	Cases      []CaseResult `json:"cases"`
+}
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	Name       string    `json:"name"`
+	Passed     bool      `json:"passed"`
+	DurationMs int64     `json:"duration_ms"`
+	Answer     string    `json:"answer"`
+	Path       []string  `json:"path"`
+	Error      string    `json:"error,omitempty"`
+	LLMCalls   int       `json:"llm_calls"`
+	Failures   []Failure `json:"failures,omitempty"`
+}
+
+// Failure is one assertion that did not hold.
+type Failure struct {
+	Assertion string `json:"assertion"`
+	Message   string `json:"message"`
+}
+
+// Run executes every case of suite in order against canvases from
+// newCanvas and scores them. Run itself only fails through the
+// per-case results; a canvas that cannot be built fails each case.
+func Run(ctx context.Context, suite *Suite, newCanvas CanvasFactory) *Report {
+	start := time.Now()
+	report := &Report{Suite: suite.Name, Cases: make([]CaseResult, 0, len(suite.Cases))}
+	timeout := defaultCaseTimeout
+	if suite.TimeoutSeconds > 0 {
+		timeout = time.Duration(suite.TimeoutSeconds) * time.Second
+	}
+	for _, c := range suite.Cases {
+		res := runCase(ctx, c, newCanvas, timeout)
+		if res.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Cases = append(report.Cases, res)
+	}
+	report.Total = len(report.Cases)
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report
+}
+
+// caseRun is what one case produced, as seen by the assertions.
+type caseRun struct {
+	state  *canvas.CanvasState
+	path   []string
+	answer string
+	err    error
+}
+
+func runCase(ctx context.Context, c Case, newCanvas CanvasFactory, timeout time.Duration) CaseResult {
+	start := time.Now()
+	model := newFakeModel(c.Mocks.LLM)
+	run := execute(ctx, c, newCanvas, model, timeout)
+
+	res := CaseResult{
+		Name:     c.Name,
+		Answer:   run.answer,
+		Path:     run.path,
+		LLMCalls: model.calls,
+	}
+	if run.err != nil {
+		res.Error = run.err.Error()
+	}
+	res.DurationMs = time.Since(start).Milliseconds()
+	res.Failures = score(ctx, c, run, time.Since(start))
+	res.Passed = len(res.Failures) == 0
+	return res
+}
+
+func execute(ctx context.Context, c Case, newCanvas CanvasFactory, model *fakeModel, timeout time.Duration) *caseRun {
+	run := &caseRun{}
+	cv, err := newCanvas()
+	if err != nil {
+		run.err = err
+		return run
+	}
+
+	runID := "canvastest-" + c.Name
+	state := canvas.NewCanvasState(runID, "")
+	seedState(state, cv.Globals, c)
+	state.SetVarTypes(canvas.DeclaredVarTypes(cv))
+	run.state = state
+
+	path := &pathRecorder{}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctx = canvas.WithRunMeta(ctx, &canvas.RunMeta{RunID: runID, Checkpoints: path})
+	ctx = component.WithChatInvoker(ctx, model)
+	ctx = component.WithToolMocker(ctx, newFakeTools(c.Mocks.Tools).mock)
+	if len(c.Mocks.Components) > 0 {
+		ctx = canvas.WithReplayOutputs(ctx, c.Mocks.Components)
+	}
+	ctx = runtime.WithState(ctx, state)
+
+	cc, err := canvas.Compile(ctx, cv)
+	if err != nil {
+		run.err = fmt.Errorf("canvas compile: %w", err)
+		return run
+	}
+	in := map[string]any{"query": c.Query}
+	for k, v := range c.Inputs {
+		if k != "query" {
+			in[k] = v
+		}
+	}
+	_, run.err = cc.Workflow.Invoke(ctx, in)
+	run.path = path.ids()
+	run.answer = finalAnswer(state, run.path)
+	return run
+}
+
+// seedState fills the sys/env namespaces the way a service run does,
+// then applies the case's env overrides.
+func seedState(state *canvas.CanvasState, globals map[string]any, c Case) {
+	for k, v := range globals {
+		switch {
+		case strings.HasPrefix(k, "sys."):
+			state.Sys[strings.TrimPrefix(k, "sys.")] = v
+		case strings.HasPrefix(k, "env."):
+			state.Env[strings.TrimPrefix(k, "env.")] = v
+		default:
+			state.Globals[k] = v
+		}
+	}
+	for k, v := range c.Env {
+		state.Env[strings.TrimPrefix(k, "env.")] = v
+	}
+	state.Sys["query"] = c.Query
+}
+
+// finalAnswer is the content of the last finished node that produced
+// any, falling back to its answer or result output; nodes off the
+// recorded path (loop bodies) are consulted last, in id order.
+func finalAnswer(state *canvas.CanvasState, path []string) string {
+	snap := state.Snapshot()
+	ids := make([]string, 0, len(snap))
+	for i := len(path) - 1; i >= 0; i-- {
+		ids = append(ids, path[i])
+	}
+	rest := make([]string, 0, len(snap))
+	for id := range snap {
+		rest = append(rest, id)
+	}
+	sort.Strings(rest)
+	ids = append(ids, rest...)
+	for _, id := range ids {
+		for _, key := range []string{"content", "answer", "result"} {
+			if s, ok := snap[id][key].(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// pathRecorder is an in-memory canvas.NodeCheckpointStore that keeps
+// only the order in which nodes finished.
+type pathRecorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+// Append implements canvas.NodeCheckpointStore.
+func (p *pathRecorder) Append(_ context.Context, _ string, cp canvas.NodeCheckpoint) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = append(p.steps, cp.ComponentID)
+	return nil
+}
+
+// List implements canvas.NodeCheckpointStore.
+func (p *pathRecorder) List(_ context.Context, _ string) ([]canvas.NodeCheckpoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]canvas.NodeCheckpoint, 0, len(p.steps))
+	for i, id := range p.steps {
+		out = append(out, canvas.NodeCheckpoint{Seq: i, ComponentID: id})
+	}
+	return out, nil
+}
+
+// Reset implements canvas.NodeCheckpointStore.
+func (p *pathRecorder) Reset(_ context.Context, _ string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = nil
+	return nil
+}
+
+func (p *pathRecorder) ids() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.steps...)
+}
@@ -0,0 +1,267 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package canvastest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/agent/component"
+)
+
+// llmCanvas is begin_0 → llm_0 → message_0, where the message echoes
+// the model's reply.
+func llmCanvas() (*canvas.Canvas, error) {
+	return &canvas.Canvas{
+		Components: map[string]canvas.CanvasComponent{
+			"begin_0": {
+				Obj:        canvas.CanvasComponentObj{ComponentName: "Begin", Params: map[string]any{}},
+				Downstream: []string{"llm_0"},
+			},
+			"llm_0": {
+				Obj: canvas.CanvasComponentObj{ComponentName: "LLM", Params: map[string]any{
+					"llm_id":      "test-model",
+					"sys_prompt":  "You answer billing questions.",
+					"user_prompt": "{{sys.query}}",
+				}},
+				Upstream:   []string{"begin_0"},
+				Downstream: []string{"message_0"},
+			},
+			"message_0": {
+				Obj:      canvas.CanvasComponentObj{ComponentName: "Message", Params: map[string]any{"text": "Bot: {{llm_0@content}}"}},
+				Upstream: []string{"llm_0"},
+			},
+		},
+		Path:        []string{"begin_0"},
+		NodeParents: map[string]string{},
+	}, nil
+}
+
+const refundSuite = `
+name: billing
+cases:
+  - name: refund
+    query: how do refunds work?
+    mocks:
+      llm:
+        - component_id: llm_0
+          prompt_contains: refunds
+          response: Refunds take 5 days.
+    expect:
+      answer_contains: ["5 days"]
+      answer_excludes: ["sorry"]
+      outputs:
+        llm_0@content: Refunds take 5 days.
+        message_0@content: {$matches: "^Bot: Refunds"}
+      variables:
+        sys.query: how do refunds work?
+      path: [begin_0, llm_0, message_0]
+      visited: [llm_0]
+      not_visited: [fallback_0]
+`
+
+func TestRun_MockedModelPasses(t *testing.T) {
+	suite, err := ParseSuite([]byte(refundSuite))
+	if err != nil {
+		t.Fatalf("ParseSuite: %v", err)
+	}
+	report := Run(context.Background(), suite, llmCanvas)
+	if report.Total != 1 || report.Passed != 1 {
+		t.Fatalf("report = %+v, want 1/1 passed", report)
+	}
+	res := report.Cases[0]
+	if res.Answer != "Bot: Refunds take 5 days." {
+		t.Errorf("answer = %q", res.Answer)
+	}
+	if res.LLMCalls != 1 {
+		t.Errorf("llm calls = %d, want 1", res.LLMCalls)
+	}
+}
+
+func TestRun_UnmatchedModelCallFails(t *testing.T) {
+	suite := &Suite{Name: "billing", Cases: []Case{{
+		Name:  "no mocks",
+		Query: "hi",
+		Mocks: Mocks{LLM: []LLMMock{{ComponentID: "other_0", Response: "never"}}},
+	}}}
+	report := Run(context.Background(), suite, llmCanvas)
+	if report.Failed != 1 {
+		t.Fatalf("report = %+v, want the case to fail", report)
+	}
+	res := report.Cases[0]
+	if !strings.Contains(res.Error, "no llm mock matches") {
+		t.Errorf("error = %q, want an unmatched-mock error", res.Error)
+	}
+	if len(res.Failures) == 0 || res.Failures[0].Assertion != "error" {
+		t.Errorf("failures = %+v, want an error assertion", res.Failures)
+	}
+
+	// The same run passes when the case expects the error.
+	suite.Cases[0].Expect.Error = "no llm mock"
+	if report := Run(context.Background(), suite, llmCanvas); report.Passed != 1 {
+		t.Errorf("expected-error case: %+v", report.Cases[0])
+	}
+}
+
+func TestRun_ComponentMockSkipsModel(t *testing.T) {
+	suite := &Suite{Cases: []Case{{
+		Name:  "canned",
+		Query: "hi",
+		Mocks: Mocks{Components: map[string]map[string]any{"llm_0": {"content": "canned"}}},
+		Expect: Expect{
+			AnswerContains: []string{"Bot: canned"},
+			Visited:        []string{"llm_0", "message_0"},
+		},
+	}}}
+	report := Run(context.Background(), suite, llmCanvas)
+	if report.Passed != 1 {
+		t.Fatalf("case failed: %+v", report.Cases[0])
+	}
+	if report.Cases[0].LLMCalls != 0 {
+		t.Errorf("llm calls = %d, want 0", report.Cases[0].LLMCalls)
+	}
+}
+
+func TestRun_ReportsEachFailedAssertion(t *testing.T) {
+	suite := &Suite{Name: "billing", Cases: []Case{{
+		Name:  "wrong",
+		Query: "hi",
+		Mocks: Mocks{LLM: []LLMMock{{Response: "hello"}}},
+		Expect: Expect{
+			AnswerContains: []string{"goodbye"},
+			Outputs:        map[string]any{"llm_0@content": "bye"},
+			Path:           []string{"begin_0", "message_0"},
+			NotVisited:     []string{"llm_0"},
+		},
+	}}}
+	report := Run(context.Background(), suite, llmCanvas)
+	if report.Failed != 1 {
+		t.Fatalf("report = %+v, want the case to fail", report)
+	}
+	var got []string
+	for _, f := range report.Cases[0].Failures {
+		got = append(got, f.Assertion)
+	}
+	want := []string{"answer_contains", "outputs", "path", "not_visited"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("failed assertions = %v, want %v", got, want)
+	}
+
+	var buf strings.Builder
+	if err := report.WriteJUnit(&buf); err != nil {
+		t.Fatalf("WriteJUnit: %v", err)
+	}
+	for _, s := range []string{`<testsuite name="billing" tests="1" failures="1"`, `<testcase name="wrong"`, "not_visited: node llm_0 was visited"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("junit missing %q:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestFakeModel_MatchingAndConsumption(t *testing.T) {
+	m := newFakeModel([]LLMMock{
+		{PromptContains: "weather", Response: "sunny", Repeat: true},
+		{Response: "first"},
+		{Response: "call tool", ToolCalls: []MockToolCall{{Name: "lookup", Arguments: map[string]any{"id": 7}}}},
+	})
+	ask := func(prompt string) (*component.ChatInvokeResponse, error) {
+		return m.Invoke(context.Background(), component.ChatInvokeRequest{Messages: chatMessages(prompt)})
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp, err := ask("what's the weather"); err != nil || resp.Content != "sunny" {
+			t.Fatalf("repeat mock: %v, %v", resp, err)
+		}
+	}
+	if resp, _ := ask("hello"); resp.Content != "first" {
+		t.Errorf("first unmatched-prompt call = %q, want first", resp.Content)
+	}
+	resp, err := ask("hello")
+	if err != nil || len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Function.Arguments != `{"id":7}` {
+		t.Fatalf("tool-call mock: %+v, %v", resp, err)
+	}
+	if _, err := ask("hello"); err == nil {
+		t.Error("expected an error once every one-shot mock is used")
+	}
+}
+
+func TestFakeTools(t *testing.T) {
+	tools := newFakeTools([]ToolMock{
+		{Name: "lookup", ArgumentsContains: `"id":7`, Output: map[string]any{"status": "shipped"}},
+		{Name: "lookup", Error: "not found"},
+	})
+	out, handled, err := tools.mock(context.Background(), "lookup", `{"id":7}`)
+	if err != nil || !handled || out != `{"status":"shipped"}` {
+		t.Fatalf("first call = %q, %v, %v", out, handled, err)
+	}
+	if _, _, err := tools.mock(context.Background(), "lookup", `{"id":7}`); err == nil || err.Error() != "not found" {
+		t.Errorf("second call error = %v, want not found", err)
+	}
+	if _, handled, err := tools.mock(context.Background(), "search", `{}`); !handled || err == nil {
+		t.Errorf("unmocked tool: handled=%v err=%v, want a handled error", handled, err)
+	}
+}
+
+func TestParseSuite_Validation(t *testing.T) {
+	cases := map[string]string{
+		"no cases":       `name: x`,
+		"unnamed case":   `cases: [{query: hi}]`,
+		"duplicate name": `cases: [{name: a}, {name: a}]`,
+		"unnamed tool":   `cases: [{name: a, mocks: {tools: [{output: x}]}}]`,
+		"bad yaml":       `cases: [`,
+	}
+	for name, src := range cases {
+		if _, err := ParseSuite([]byte(src)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// JSON is accepted as well.
+	s, err := ParseSuite([]byte(`{"name":"j","cases":[{"name":"a","query":"q","expect":{"visited":["x"]}}]}`))
+	if err != nil || s.Cases[0].Expect.Visited[0] != "x" {
+		t.Fatalf("json suite: %+v, %v", s, err)
+	}
+}
+
+func TestMatchValue(t *testing.T) {
+	cases := []struct {
+		got, want any
+		ok        bool
+	}{
+		{3, 3.0, true},
+		{[]string{"a"}, []any{"a"}, true},
+		{map[string]any{"k": 1}, map[string]any{"k": 2}, false},
+		{"hello world", map[string]any{"$contains": "lo wo"}, true},
+		{"hello", map[string]any{"$contains": "bye"}, false},
+		{"v1.2", map[string]any{"$matches": `^v\d+\.\d+$`}, true},
+		{map[string]any{"n": 1}, map[string]any{"$contains": `"n":1`}, true},
+	}
+	for _, c := range cases {
+		err := matchValue(c.got, c.want)
+		if (err == nil) != c.ok {
+			t.Errorf("matchValue(%v, %v) = %v, want ok=%v", c.got, c.want, err, c.ok)
+		}
+	}
+}
+
+func chatMessages(prompt string) []schema.Message {
+	return []schema.Message{{Role: schema.User, Content: prompt}}
+}
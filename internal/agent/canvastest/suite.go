@@ -0,0 +1,172 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package canvastest runs canvases against a test suite with the model
+// and tool layers replaced by scripted fakes, so an agent can be
+// regression-tested without network access or credentials.
+//
+// A suite is YAML (or JSON) of the form:
+//
+//	name: support-bot
+//	cases:
+//	  - name: refund question routes to refund branch
+//	    query: "I want my money back"
+//	    mocks:
+//	      llm:
+//	        - component_id: Categorize:Intent
+//	          response: refund
+//	        - prompt_contains: refund policy
+//	          response: "Refunds take 5 days."
+//	      tools:
+//	        - name: lookup_order
+//	          output: {"status": "shipped"}
+//	      components:
+//	        Retrieval:KB: {formalized_content: "..."}
+//	    expect:
+//	      answer_contains: ["5 days"]
+//	      outputs: {"Categorize:Intent@category_name": refund}
+//	      variables: {"sys.query": "I want my money back"}
+//	      visited: [Categorize:Intent]
+//	      not_visited: [Message:Fallback]
+//
+// Cases run through the real canvas.Compile / BuildWorkflow path. LLM,
+// Categorize and Agent chat calls are answered by the first matching
+// llm mock, Agent tool calls by the first matching tools mock, and any
+// node listed under components returns those outputs without running.
+// A model or tool call no mock answers fails the case.
+package canvastest
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a named list of cases run against one canvas. DSL, when
+// set, replaces the canvas' stored draft for this run.
+type Suite struct {
+	Name           string         `yaml:"name"`
+	DSL            map[string]any `yaml:"dsl,omitempty"`
+	TimeoutSeconds int            `yaml:"timeout_seconds,omitempty"`
+	Cases          []Case         `yaml:"cases"`
+}
+
+// Case is one canvas run: its inputs, the fakes that stand in for
+// models and tools, and what the run must produce.
+type Case struct {
+	Name   string         `yaml:"name"`
+	Query  string         `yaml:"query"`
+	Inputs map[string]any `yaml:"inputs,omitempty"`
+	Env    map[string]any `yaml:"env,omitempty"`
+	Mocks  Mocks          `yaml:"mocks,omitempty"`
+	Expect Expect         `yaml:"expect,omitempty"`
+}
+
+// Mocks lists the fakes of one case.
+type Mocks struct {
+	LLM        []LLMMock                 `yaml:"llm,omitempty"`
+	Tools      []ToolMock                `yaml:"tools,omitempty"`
+	Components map[string]map[string]any `yaml:"components,omitempty"`
+}
+
+// LLMMock answers a chat call. ComponentID and PromptContains narrow
+// which calls it answers; an empty field matches anything. A mock is
+// used once unless Repeat is set.
+type LLMMock struct {
+	ComponentID    string         `yaml:"component_id,omitempty"`
+	PromptContains string         `yaml:"prompt_contains,omitempty"`
+	Response       string         `yaml:"response,omitempty"`
+	ToolCalls      []MockToolCall `yaml:"tool_calls,omitempty"`
+	Error          string         `yaml:"error,omitempty"`
+	Repeat         bool           `yaml:"repeat,omitempty"`
+}
+
+// MockToolCall is a tool call an LLMMock asks the Agent to make.
+// Arguments may be an object or a JSON string.
+type MockToolCall struct {
+	Name      string `yaml:"name"`
+	Arguments any    `yaml:"arguments,omitempty"`
+}
+
+// ToolMock answers an Agent tool call by tool name, optionally only
+// when the JSON arguments contain ArgumentsContains. Output may be a
+// string or any JSON-encodable value.
+type ToolMock struct {
+	Name              string `yaml:"name"`
+	ArgumentsContains string `yaml:"arguments_contains,omitempty"`
+	Output            any    `yaml:"output,omitempty"`
+	Error             string `yaml:"error,omitempty"`
+	Repeat            bool   `yaml:"repeat,omitempty"`
+}
+
+// Expect holds a case's assertions. Outputs are keyed by
+// "cpn_id@param[.path]" and Variables by "sys.x" / "env.x"; expected
+// values compare after JSON normalisation, or may be {$contains: s}
+// or {$matches: regexp} for strings. Path is the exact order in which
+// top-level nodes finished. Error, when set, is a substring the run
+// error must contain; when empty the run must succeed.
+type Expect struct {
+	AnswerContains []string       `yaml:"answer_contains,omitempty"`
+	AnswerExcludes []string       `yaml:"answer_excludes,omitempty"`
+	Error          string         `yaml:"error,omitempty"`
+	Outputs        map[string]any `yaml:"outputs,omitempty"`
+	Variables      map[string]any `yaml:"variables,omitempty"`
+	Path           []string       `yaml:"path,omitempty"`
+	Visited        []string       `yaml:"visited,omitempty"`
+	NotVisited     []string       `yaml:"not_visited,omitempty"`
+}
+
+// ParseSuite decodes a YAML or JSON suite and checks it is runnable.
+func ParseSuite(data []byte) (*Suite, error) {
+	var s Suite
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("canvastest: parse suite: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate reports the first structural problem of the suite.
+func (s *Suite) Validate() error {
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("canvastest: suite has no cases")
+	}
+	seen := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			return fmt.Errorf("canvastest: case %d has no name", i)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("canvastest: duplicate case name %q", c.Name)
+		}
+		seen[c.Name] = true
+		for j, m := range c.Mocks.Tools {
+			if m.Name == "" {
+				return fmt.Errorf("canvastest: case %q: tool mock %d has no name", c.Name, j)
+			}
+		}
+		for j, tc := range c.Mocks.LLM {
+			for _, call := range tc.ToolCalls {
+				if call.Name == "" {
+					return fmt.Errorf("canvastest: case %q: llm mock %d has a tool call without name", c.Name, j)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -5,7 +5,8 @@
 // ToolCallingChatModel, delegating the ReAct loop to eino's
 // production-grade implementation.
 //
-// Public outputs (content / tool_calls / artifacts) match the
+// Public outputs (content / tool_calls / artifacts, plus the usage
+// keys the run report reads — see agent_usage.go) match the
 // plan-specified shape. The agent now wires AgentParam.Tools into
 // eino's native react.AgentConfig.ToolsConfig; when no tools are
 // configured the ReAct loop naturally degenerates to one model call.
@@ -16,8 +17,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/cloudwego/eino/components/model"
 	einotool "github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
+	einoagent "github.com/cloudwego/eino/flow/agent"
 	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
 
@@ -131,14 +134,21 @@ var agentRunner = runEinoReActAgent
 // runEinoReActAgent creates an eino react agent and runs it against the
 // model built from p.
 func runEinoReActAgent(ctx context.Context, p AgentParam) (*schema.Message, error) {
-	chatModel, err := buildAgentChatModel(p)
-	if err != nil {
-		return nil, fmt.Errorf("build model: %w", err)
+	var chatModel model.ToolCallingChatModel
+	if override := agentModelOverride(ctx, p); override != nil {
+		chatModel = override
+	} else {
+		built, err := buildAgentChatModel(p)
+		if err != nil {
+			return nil, fmt.Errorf("build model: %w", err)
+		}
+		chatModel = built
 	}
-	tools, err := buildAgentTools(p)
+	tools, err := buildAgentTools(ctx, p)
 	if err != nil {
 		return nil, fmt.Errorf("build tools: %w", err)
 	}
+	tools = mockableTools(ctx, tools)
 
 	agent, err := react.NewAgent(ctx, &react.AgentConfig{
 		ToolCallingModel: chatModel,
@@ -158,7 +168,11 @@ func runEinoReActAgent(ctx context.Context, p AgentParam) (*schema.Message, erro
 	}
 
 	input := []*schema.Message{schema.UserMessage(p.UserPrompt)}
-	return agent.Generate(ctx, input)
+	var opts []einoagent.AgentOption
+	if u := agentUsageFrom(ctx); u != nil {
+		opts = append(opts, einoagent.WithComposeOptions(compose.WithCallbacks(u.callbackHandler())))
+	}
+	return agent.Generate(ctx, input, opts...)
 }
 
 // addToolCallMemory summarizes the tool calls observed in msg via
@@ -184,7 +198,7 @@ func addToolCallMemory(ctx context.Context, p AgentParam, msg *schema.Message) (
 	}
 	system := "You are a memory summarizer. Given a list of tool calls the assistant just made, output ONE short sentence (max 30 words) describing what the assistant did, suitable for a future-turn conversation history. Output ONLY the sentence, no preamble, no quotes."
 	user := "Tool calls: " + callsDesc.String()
-	inv := getDefaultChatInvoker()
+	inv := chatInvokerFor(ctx)
 	resp, err := inv.Invoke(ctx, ChatInvokeRequest{
 		Driver:    p.Driver,
 		ModelName: p.ModelID,
@@ -199,6 +213,7 @@ func addToolCallMemory(ctx context.Context, p AgentParam, msg *schema.Message) (
 	if err != nil {
 		return "", err
 	}
+	agentUsageFrom(ctx).addChat(resp)
 	return strings.TrimSpace(resp.Content), nil
 }
 
@@ -223,7 +238,7 @@ func applyCitationGrounding(ctx context.Context, p AgentParam, content string, c
 		return content, nil
 	}
 	systemPrompt, _ := prompts.CitationPlusPrompt(chunks)
-	inv := getDefaultChatInvoker()
+	inv := chatInvokerFor(ctx)
 	resp, err := inv.Invoke(ctx, ChatInvokeRequest{
 		Driver:    p.Driver,
 		ModelName: p.ModelID,
@@ -241,6 +256,7 @@ func applyCitationGrounding(ctx context.Context, p AgentParam, content string, c
 		// whether to surface the error.
 		return content, err
 	}
+	agentUsageFrom(ctx).addChat(resp)
 	grounded := strings.TrimSpace(resp.Content)
 	if grounded == "" {
 		return content, nil
@@ -284,7 +300,7 @@ func (c *AgentComponent) GetInputForm() map[string]any {
 	out := map[string]any{
 		"self": c.param.Meta,
 	}
-	tools, err := buildAgentTools(c.param)
+	tools, err := buildStaticAgentTools(c.param)
 	if err != nil {
 		return out
 	}
@@ -309,7 +325,7 @@ func (c *AgentComponent) GetInputForm() map[string]any {
 // interface. Mirrors Python's per-tool reset() — useful for clearing
 // per-invocation state (caches, scratch buffers) between calls.
 func (c *AgentComponent) Reset() {
-	tools, err := buildAgentTools(c.param)
+	tools, err := buildStaticAgentTools(c.param)
 	if err != nil {
 		return
 	}
@@ -357,7 +373,7 @@ func optimizeMultiTurnQuestion(ctx context.Context, p AgentParam, history []map[
 	}
 	system := "You are a question rephraser. Given conversation history and the user's latest input, rewrite the latest input as a self-contained question that does not require the history to understand. Output ONLY the rephrased question, no preamble, no quotes."
 	user := "Conversation history:\n" + histBuf.String() + "\n\nUser's latest input:\n" + p.UserPrompt
-	inv := getDefaultChatInvoker()
+	inv := chatInvokerFor(ctx)
 	resp, err := inv.Invoke(ctx, ChatInvokeRequest{
 		Driver:    p.Driver,
 		ModelName: p.ModelID,
@@ -372,11 +388,26 @@ func optimizeMultiTurnQuestion(ctx context.Context, p AgentParam, history []map[
 	if err != nil {
 		return "", err
 	}
+	agentUsageFrom(ctx).addChat(resp)
 	return strings.TrimSpace(resp.Content), nil
 }
 
-func buildAgentTools(p AgentParam) ([]einotool.BaseTool, error) {
-	return agenttool.BuildAll(p.Tools, p.ToolParams)
+func buildAgentTools(ctx context.Context, p AgentParam) ([]einotool.BaseTool, error) {
+	return agenttool.BuildAllContext(ctx, p.Tools, p.ToolParams)
+}
+
+// buildStaticAgentTools builds only the registry tools, skipping the
+// tenant-scoped OpenAPI, MCP and IMAP tools that need canvas state to resolve. Used
+// by the input-form and reset hooks, which run without a tenant and
+// only care about tools implementing InputForm / Reset.
+func buildStaticAgentTools(p AgentParam) ([]einotool.BaseTool, error) {
+	names := make([]string, 0, len(p.Tools))
+	for _, name := range p.Tools {
+		if !agenttool.IsOpenAPIToolName(name) && !agenttool.IsMCPToolName(name) && !agenttool.IsIMAPToolName(name) {
+			names = append(names, name)
+		}
+	}
+	return agenttool.BuildAll(names, p.ToolParams)
 }
 
 // NewAgentComponent builds an AgentComponent from raw params.
@@ -431,6 +462,11 @@ func (c *AgentComponent) Invoke(ctx context.Context, inputs map[string]any) (map
 		p.UserPrompt = p.SystemPrompt
 	}
 
+	// Token usage and tool calls of every LLM round, collected for
+	// the run report.
+	usage := &agentUsage{}
+	ctx = withAgentUsage(ctx, usage)
+
 	// Multi-turn conversation optimization. When the canvas state
 	// carries prior history and OptimizeMultiTurn is enabled
 	// (default), rephrase the user prompt into a self-contained
@@ -502,10 +538,11 @@ func (c *AgentComponent) Invoke(ctx context.Context, inputs map[string]any) (map
 	}
 	artifacts := collectArtifactsFromToolCalls(msg)
 	artifactMD := formatArtifactMarkdown(artifacts, content)
-	out := map[string]any{
-		"content":    content + artifactMD,
-		"tool_calls": extractToolCalls(msg),
-		"artifacts":  artifacts,
+	out := usage.outputs(p.ModelID)
+	out["content"] = content + artifactMD
+	out["artifacts"] = artifacts
+	if calls, _ := out["tool_calls"].([]map[string]any); len(calls) == 0 {
+		out["tool_calls"] = extractToolCalls(msg)
 	}
 	if groundingStatus != "" {
 		out["grounding_status"] = groundingStatus
@@ -537,7 +574,7 @@ func (c *AgentComponent) Inputs() map[string]string {
 		"user_prompt":             "User prompt; supports {{cpn_id@param}} references",
 		"top_p":                   "Top-p (nucleus) sampling cutoff (0.0-1.0). Optional.",
 		"tools":                   "List of tool names to make available to the ReAct agent.",
-		"tool_params":             "Optional node-level tool constructor params keyed by tool name (e.g. execute_sql DB config).",
+		"tool_params":             "Optional node-level tool constructor params keyed by tool name (e.g. execute_sql DB config, or cache_ttl in seconds to cache a search tool's results).",
 		"max_rounds":              "Maximum ReAct rounds (default 3).",
 		"optimize_multi_turn":     "When true (default), multi-turn history is condensed via full_question LLM call.",
 		"optimize_history_window": "Number of history turns to include in the optimization prompt (default 3).",
@@ -551,10 +588,14 @@ func (c *AgentComponent) Inputs() map[string]string {
 // Outputs returns output metadata.
 func (c *AgentComponent) Outputs() map[string]string {
 	return map[string]string{
-		"content":          "Final assistant content (after the ReAct loop terminates)",
-		"tool_calls":       "One entry per tool call observed during the run",
-		"artifacts":        "Artifacts collected from tool responses (empty in P0)",
-		"grounding_status": "'applied' | 'no_chunks' | 'error: <msg>' (present when cite=true).",
+		"content":           "Final assistant content (after the ReAct loop terminates)",
+		"tool_calls":        "One entry per tool call observed during the run",
+		"artifacts":         "Artifacts collected from tool responses (empty in P0)",
+		"grounding_status":  "'applied' | 'no_chunks' | 'error: <msg>' (present when cite=true).",
+		"model":             "Model identifier the ReAct loop ran against",
+		"tokens":            "Tokens used across all ReAct rounds (0 when not reported by the driver)",
+		"prompt_tokens":     "Prompt tokens used across all ReAct rounds",
+		"completion_tokens": "Completion tokens used across all ReAct rounds",
 	}
 }
 
@@ -266,7 +266,7 @@ func TestAgent_CanCreateReactAgentWithAllRegisteredTools(t *testing.T) {
 		},
 		MaxRounds: 1,
 	}
-	tools, err := buildAgentTools(p)
+	tools, err := buildAgentTools(context.Background(), p)
 	if err != nil {
 		t.Fatalf("buildAgentTools: %v", err)
 	}
@@ -359,7 +359,9 @@ func TestAgent_ReActExhaustsSteps(t *testing.T) {
 	}
 	defer db.Close()
 	mock.ExpectPing()
-	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+	// ExeSQL sends the statement after its guard applies max_records
+	// and the MySQL execution-time hint.
+	mock.ExpectQuery("SELECT /*+ MAX_EXECUTION_TIME(60000) */ 1 LIMIT 10").WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
 
 	// Default sql.Open would try to connect to a real MySQL; the
 	// dialer stub makes the tool talk to sqlmock instead.
@@ -0,0 +1,147 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package component — Agent usage accounting.
+//
+// eino's react.Agent.Generate returns only the final message, so the
+// token usage of each ReAct round and the tool calls made in between
+// are invisible to AgentComponent.Invoke. agentUsage collects them
+// through eino model/tool callbacks; Invoke copies the totals onto the
+// node outputs, where the run report (canvas/run_report.go) reads them.
+package component
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	einotool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/flow/agent/react"
+	template "github.com/cloudwego/eino/utils/callbacks"
+)
+
+type agentUsageCtxKey struct{}
+
+// agentUsage accumulates token usage and tool calls across one Agent
+// invocation. Safe for concurrent use: eino may run tools in parallel.
+type agentUsage struct {
+	mu               sync.Mutex
+	promptTokens     int
+	completionTokens int
+	totalTokens      int
+	toolCalls        []map[string]any
+}
+
+// withAgentUsage attaches u to ctx so the ReAct runner and the Agent's
+// auxiliary LLM calls account into it.
+func withAgentUsage(ctx context.Context, u *agentUsage) context.Context {
+	return context.WithValue(ctx, agentUsageCtxKey{}, u)
+}
+
+// agentUsageFrom returns the collector on ctx, or nil.
+func agentUsageFrom(ctx context.Context) *agentUsage {
+	u, _ := ctx.Value(agentUsageCtxKey{}).(*agentUsage)
+	return u
+}
+
+func (u *agentUsage) addTokens(prompt, completion, total int) {
+	if u == nil {
+		return
+	}
+	if total == 0 {
+		total = prompt + completion
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.promptTokens += prompt
+	u.completionTokens += completion
+	u.totalTokens += total
+}
+
+// addChat accounts an auxiliary ChatInvoker call (multi-turn rephrase,
+// tool-call memory, citation grounding). Nil-safe on both sides.
+func (u *agentUsage) addChat(resp *ChatInvokeResponse) {
+	if u == nil || resp == nil {
+		return
+	}
+	u.addTokens(resp.PromptTokens, resp.CompletionTokens, resp.Tokens)
+}
+
+func (u *agentUsage) addToolCall(name, arguments string) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.toolCalls = append(u.toolCalls, map[string]any{
+		"name":      name,
+		"arguments": arguments,
+	})
+}
+
+// callbackHandler returns the eino handler that feeds u from the ReAct
+// loop's chat-model and tool nodes.
+func (u *agentUsage) callbackHandler() callbacks.Handler {
+	return react.BuildAgentCallback(
+		&template.ModelCallbackHandler{
+			OnEnd: func(ctx context.Context, _ *callbacks.RunInfo, output *model.CallbackOutput) context.Context {
+				if output == nil {
+					return ctx
+				}
+				usage := output.TokenUsage
+				if usage == nil && output.Message != nil && output.Message.ResponseMeta != nil && output.Message.ResponseMeta.Usage != nil {
+					mu := output.Message.ResponseMeta.Usage
+					usage = &model.TokenUsage{
+						PromptTokens:     mu.PromptTokens,
+						CompletionTokens: mu.CompletionTokens,
+						TotalTokens:      mu.TotalTokens,
+					}
+				}
+				if usage != nil {
+					u.addTokens(usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+				}
+				return ctx
+			},
+		},
+		&template.ToolCallbackHandler{
+			OnStart: func(ctx context.Context, info *callbacks.RunInfo, input *einotool.CallbackInput) context.Context {
+				name, args := "", ""
+				if info != nil {
+					name = info.Name
+				}
+				if input != nil {
+					args = input.ArgumentsInJSON
+				}
+				u.addToolCall(name, args)
+				return ctx
+			},
+		},
+	)
+}
+
+// outputs returns the run-report keys for the collected usage.
+func (u *agentUsage) outputs(modelID string) map[string]any {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return map[string]any{
+		"model":             modelID,
+		"tokens":            u.totalTokens,
+		"prompt_tokens":     u.promptTokens,
+		"completion_tokens": u.completionTokens,
+		"tool_calls":        append([]map[string]any(nil), u.toolCalls...),
+	}
+}
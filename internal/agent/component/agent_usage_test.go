@@ -0,0 +1,62 @@
+// Package component — Agent usage accounting tests.
+package component
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// roundsInvoker answers the n-th chat call with rounds[n] (the last
+// entry repeats).
+type roundsInvoker struct {
+	rounds []*ChatInvokeResponse
+	calls  int32
+}
+
+func (r *roundsInvoker) Invoke(_ context.Context, _ ChatInvokeRequest) (*ChatInvokeResponse, error) {
+	n := int(atomic.AddInt32(&r.calls, 1)) - 1
+	if n >= len(r.rounds) {
+		n = len(r.rounds) - 1
+	}
+	return r.rounds[n], nil
+}
+
+func TestAgentInvoke_ReportsUsageAndToolCalls(t *testing.T) {
+	inv := &roundsInvoker{rounds: []*ChatInvokeResponse{
+		{
+			ToolCalls: []schema.ToolCall{{
+				ID:       "c1",
+				Type:     "function",
+				Function: schema.FunctionCall{Name: "wikipedia", Arguments: `{"query":"go"}`},
+			}},
+			PromptTokens: 10, CompletionTokens: 4, Tokens: 14,
+		},
+		{Content: "done", PromptTokens: 30, CompletionTokens: 6, Tokens: 36},
+	}}
+	ctx := WithChatInvoker(context.Background(), inv)
+	ctx = WithToolMocker(ctx, func(context.Context, string, string) (string, bool, error) {
+		return "Go is a programming language.", true, nil
+	})
+
+	c := NewAgentComponent(AgentParam{ModelID: "stub-model", MaxRounds: 5, Tools: []string{"wikipedia"}})
+	out, err := c.Invoke(ctx, map[string]any{"user_prompt": "what is go?"})
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if out["content"] != "done" {
+		t.Errorf("content = %v", out["content"])
+	}
+	if out["model"] != "stub-model" {
+		t.Errorf("model = %v", out["model"])
+	}
+	if out["prompt_tokens"] != 40 || out["completion_tokens"] != 10 || out["tokens"] != 50 {
+		t.Errorf("usage = %v/%v/%v, want 40/10/50", out["prompt_tokens"], out["completion_tokens"], out["tokens"])
+	}
+	calls, _ := out["tool_calls"].([]map[string]any)
+	if len(calls) != 1 || calls[0]["name"] != "wikipedia" || calls[0]["arguments"] != `{"query":"go"}` {
+		t.Errorf("tool_calls = %v", out["tool_calls"])
+	}
+}
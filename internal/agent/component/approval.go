@@ -0,0 +1,88 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package component — Approval component (human approval gate).
+//
+// Inside a canvas run the Approval node never reaches this Invoke: the
+// canvas layer routes it to canvas.ApprovalNodeBody, which pauses the
+// run on an eino interrupt until an approver decides through the
+// approvals API. This registration gives the node a catalog entry
+// (DSL validation, component introspection) and documents its params
+// and outputs; invoking it standalone (component debug) is an error
+// because there is no run to pause.
+package component
+
+import (
+	"context"
+	"errors"
+)
+
+const componentNameApproval = "Approval"
+
+// errApprovalStandalone is returned when an Approval node is invoked
+// outside a canvas run.
+var errApprovalStandalone = errors.New("Approval: an approval gate only runs inside a canvas run")
+
+// ApprovalComponent is the catalog entry of the Approval node.
+type ApprovalComponent struct{}
+
+// Name returns the registered component name.
+func (a *ApprovalComponent) Name() string { return componentNameApproval }
+
+// Invoke always fails; see the package comment above.
+func (a *ApprovalComponent) Invoke(context.Context, map[string]any) (map[string]any, error) {
+	return nil, errApprovalStandalone
+}
+
+// Stream always fails, like Invoke.
+func (a *ApprovalComponent) Stream(context.Context, map[string]any) (<-chan map[string]any, error) {
+	return nil, errApprovalStandalone
+}
+
+// Inputs returns parameter metadata for tooling.
+func (a *ApprovalComponent) Inputs() map[string]string {
+	return map[string]string{
+		"title":                      "Template for the approval title shown to approvers.",
+		"message":                    "Template explaining what is being approved.",
+		"content":                    "Template for the content under review (editable by approvers).",
+		"approvers":                  "Emails of the approvers; empty lets any tenant member decide.",
+		"escalation_approvers":       "Emails that take over when the approval escalates.",
+		"webhook_url":                "Optional URL receiving a JSON callback on every approval event.",
+		"timeout_seconds":            "Seconds to wait for a decision (default 86400).",
+		"timeout_action":             "\"reject\" (default) or \"escalate\" when the timeout passes.",
+		"escalation_timeout_seconds": "Seconds the escalation approvers get (default timeout_seconds).",
+	}
+}
+
+// Outputs returns the decision fields written when the run resumes.
+func (a *ApprovalComponent) Outputs() map[string]string {
+	return map[string]string{
+		"decision":      "\"approved\" or \"rejected\".",
+		"approved":      "True when approved (including approved with edits).",
+		"edited":        "True when the approver edited the content.",
+		"approver":      "Email of the approver, or \"system\" on timeout.",
+		"comment":       "Approver comment.",
+		"final_content": "Content after review (the edit when edited=true).",
+		"timed_out":     "True when the approval was rejected by its timeout.",
+	}
+}
+
+// init registers Approval with the orchestrator-owned registry.
+func init() {
+	Register(componentNameApproval, func(map[string]any) (Component, error) {
+		return &ApprovalComponent{}, nil
+	})
+}
@@ -92,6 +92,13 @@ func (b *BeginComponent) Invoke(ctx context.Context, inputs map[string]any) (map
 		state.Sys["webhook_payload"] = payload
 	}
 
+	// Trigger payload injection. Scheduled and event-triggered runs set
+	// root["trigger_payload"] (see service/agent_trigger.go) describing
+	// what fired the run.
+	if payload, ok := inputs["trigger_payload"].(map[string]any); ok && len(payload) > 0 {
+		state.Sys["trigger_payload"] = payload
+	}
+
 	// Passthrough: a shallow copy keeps the caller's map un-aliased.
 	out := make(map[string]any, len(inputs))
 	mapsCopy(out, inputs)
@@ -119,6 +126,7 @@ func (b *BeginComponent) Inputs() map[string]string {
 		"query":           "User query string (the chat input).",
 		"user_id":         "Optional user/tenant identifier.",
 		"webhook_payload": "Optional structured webhook request (set by the webhook HTTP handler; absent on chat flows).",
+		"trigger_payload": "Optional description of the schedule or event that fired a triggered run.",
 		"inputs":          "Optional free-form inputs map; passthrough only.",
 	}
 }
@@ -129,6 +137,7 @@ func (b *BeginComponent) Outputs() map[string]string {
 		"query":           "Query string (passthrough).",
 		"user_id":         "User id, if provided (passthrough).",
 		"webhook_payload": "Webhook request payload, if provided (passthrough; also written to state.Sys[webhook_payload]).",
+		"trigger_payload": "Trigger payload, if provided (passthrough; also written to state.Sys[trigger_payload]).",
 		"inputs":          "Raw inputs map (passthrough).",
 	}
 }
@@ -80,7 +80,7 @@ func (c *CategorizeComponent) Invoke(ctx context.Context, inputs map[string]any)
 		p.DefaultCategory = p.Categories[0]
 	}
 
-	inv := getDefaultChatInvoker()
+	inv := chatInvokerFor(ctx)
 	sysPrompt := p.SysPrompt
 	if sysPrompt == "" {
 		sysPrompt = "You are a strict classifier."
@@ -111,6 +111,12 @@ func (c *CategorizeComponent) Invoke(ctx context.Context, inputs map[string]any)
 		"category_name": chosen,
 		"scores":        score,
 		"_next":         next,
+		// Usage feeds the run report (canvas/run_report.go).
+		"model":             resp.Model,
+		"tokens":            resp.Tokens,
+		"prompt_tokens":     resp.PromptTokens,
+		"completion_tokens": resp.CompletionTokens,
+		"retries":           resp.Retries,
 	}, nil
 }
 
@@ -145,10 +151,15 @@ func (c *CategorizeComponent) Inputs() map[string]string {
 // Outputs returns output metadata.
 func (c *CategorizeComponent) Outputs() map[string]string {
 	return map[string]string{
-		"category":      "Chosen category name (one of the configured list, or the default)",
-		"category_name": "Alias of category for v1 canvas templates",
-		"scores":        "Score map (1.0 for the chosen category, 0.0 for the rest)",
-		"_next":         "Downstream route handle(s) selected from categorize item uuids",
+		"category":          "Chosen category name (one of the configured list, or the default)",
+		"category_name":     "Alias of category for v1 canvas templates",
+		"scores":            "Score map (1.0 for the chosen category, 0.0 for the rest)",
+		"_next":             "Downstream route handle(s) selected from categorize item uuids",
+		"model":             "Model identifier echoed back by the driver",
+		"tokens":            "Reported token count (0 when not reported by the driver)",
+		"prompt_tokens":     "Reported prompt tokens (0 when not reported by the driver)",
+		"completion_tokens": "Reported completion tokens (0 when not reported by the driver)",
+		"retries":           "Failed attempts retried before the call succeeded",
 	}
 }
 
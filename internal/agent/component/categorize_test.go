@@ -402,3 +402,17 @@ func pushComponentDB(t *testing.T, db *gorm.DB) {
 	dao.DB = db
 	t.Cleanup(func() { dao.DB = orig })
 }
+
+func TestCategorize_ReportsUsage(t *testing.T) {
+	stub := &stubInvoker{resp: &ChatInvokeResponse{Content: "sales", Model: "stub", PromptTokens: 12, CompletionTokens: 1, Tokens: 13}}
+	withStubInvoker(t, stub)
+
+	c := NewCategorizeComponent(CategorizeParam{ModelID: "stub", Categories: []string{"sales", "support"}})
+	out, err := c.Invoke(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if out["model"] != "stub" || out["tokens"] != 13 || out["prompt_tokens"] != 12 || out["completion_tokens"] != 1 {
+		t.Errorf("usage outputs = model %v tokens %v/%v/%v", out["model"], out["prompt_tokens"], out["completion_tokens"], out["tokens"])
+	}
+}
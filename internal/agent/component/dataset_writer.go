@@ -0,0 +1,344 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package component — DatasetWriter (dataset write-back).
+//
+// DatasetWriter is the write-side counterpart of Retrieval: it stores
+// text or a file produced in the canvas (a DocsGenerator payload, an
+// LLM summary) as a new document of a target dataset, queues it for
+// parsing and optionally blocks until parsing finishes so downstream
+// nodes can retrieve from it.
+//
+// Storage goes through the DatasetWriter seam so the component package
+// does not import internal/service; cmd/server_main.go installs the
+// service implementation at boot. The writer checks the target dataset
+// against the canvas owner's permissions, not the caller's: a shared
+// agent must not write into datasets its author cannot access.
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"ragflow/internal/agent/runtime"
+)
+
+const componentNameDatasetWriter = "DatasetWriter"
+
+// defaultDatasetWriterFilename names the document when the DSL omits
+// filename; the dataset de-duplicates it with a numeric suffix.
+const defaultDatasetWriterFilename = "agent_output.md"
+
+// defaultDatasetParseTimeout bounds wait_for_parse when the DSL omits
+// parse_timeout_seconds.
+const defaultDatasetParseTimeout = 10 * time.Minute
+
+// ErrDatasetWriterMissing is returned when no DatasetWriter has been
+// installed (unit tests, stand-alone tools).
+var ErrDatasetWriterMissing = errors.New(
+	"component: dataset writer not wired",
+)
+
+// DatasetWriteRequest is one document write issued by a DatasetWriter
+// node.
+type DatasetWriteRequest struct {
+	// CanvasID is the canvas running the node; the writer resolves its
+	// owner and checks the dataset against the owner's permissions.
+	CanvasID  string
+	DatasetID string
+	Filename  string
+	Content   []byte
+	// Parse queues the document for parsing after the upload.
+	Parse bool
+	// WaitForParse blocks until parsing finishes, fails or ParseTimeout
+	// passes. Only meaningful with Parse.
+	WaitForParse bool
+	ParseTimeout time.Duration
+}
+
+// DatasetWriteResult describes the stored document.
+type DatasetWriteResult struct {
+	DocumentID string
+	DatasetID  string
+	Filename   string
+	// Status is "uploaded", "parsing" or "parsed".
+	Status     string
+	ChunkCount int64
+	TokenCount int64
+}
+
+// DatasetWriter stores documents on behalf of DatasetWriter nodes.
+type DatasetWriter interface {
+	WriteDocument(ctx context.Context, req DatasetWriteRequest) (*DatasetWriteResult, error)
+}
+
+var (
+	datasetWriterMu   sync.RWMutex
+	datasetWriterImpl DatasetWriter = stubDatasetWriter{}
+)
+
+// SetDatasetWriter installs the writer. Passing nil reverts to the
+// default stub.
+func SetDatasetWriter(w DatasetWriter) {
+	datasetWriterMu.Lock()
+	defer datasetWriterMu.Unlock()
+	if w == nil {
+		datasetWriterImpl = stubDatasetWriter{}
+		return
+	}
+	datasetWriterImpl = w
+}
+
+func getDatasetWriter() DatasetWriter {
+	datasetWriterMu.RLock()
+	defer datasetWriterMu.RUnlock()
+	return datasetWriterImpl
+}
+
+type stubDatasetWriter struct{}
+
+func (stubDatasetWriter) WriteDocument(context.Context, DatasetWriteRequest) (*DatasetWriteResult, error) {
+	return nil, ErrDatasetWriterMissing
+}
+
+// datasetWriterParam is the static DSL param surface.
+type datasetWriterParam struct {
+	DatasetID    string  `json:"dataset_id"`
+	Filename     string  `json:"filename"`
+	Content      string  `json:"content"`
+	File         string  `json:"file"`
+	Parse        bool    `json:"parse"`
+	WaitForParse bool    `json:"wait_for_parse"`
+	ParseTimeout float64 `json:"parse_timeout_seconds"`
+}
+
+// Update copies a fresh params map into the receiver.
+func (p *datasetWriterParam) Update(conf map[string]any) error {
+	if conf == nil {
+		conf = map[string]any{}
+	}
+	if v, ok := stringFrom(conf, "dataset_id"); ok {
+		p.DatasetID = v
+	}
+	if v, ok := stringFrom(conf, "filename"); ok {
+		p.Filename = v
+	}
+	if v, ok := stringFrom(conf, "content"); ok {
+		p.Content = v
+	}
+	if v, ok := stringFrom(conf, "file"); ok {
+		p.File = v
+	}
+	if v, ok := boolFrom(conf, "parse"); ok {
+		p.Parse = v
+	}
+	if v, ok := boolFrom(conf, "wait_for_parse"); ok {
+		p.WaitForParse = v
+	}
+	if v, ok := floatFrom(conf, "parse_timeout_seconds"); ok {
+		p.ParseTimeout = v
+	}
+	return nil
+}
+
+// Check validates the param.
+func (p *datasetWriterParam) Check() error {
+	if strings.TrimSpace(p.DatasetID) == "" {
+		return &ParamError{Field: "dataset_id", Reason: "must not be empty"}
+	}
+	if strings.TrimSpace(p.Content) == "" && strings.TrimSpace(p.File) == "" {
+		return &ParamError{Field: "content", Reason: "set content or file"}
+	}
+	if p.ParseTimeout < 0 {
+		return &ParamError{Field: "parse_timeout_seconds", Reason: "must not be negative"}
+	}
+	if p.WaitForParse && !p.Parse {
+		return &ParamError{Field: "wait_for_parse", Reason: "requires parse"}
+	}
+	return nil
+}
+
+// AsDict returns the param as a plain map.
+func (p *datasetWriterParam) AsDict() map[string]any {
+	return map[string]any{
+		"dataset_id":            p.DatasetID,
+		"filename":              p.Filename,
+		"content":               p.Content,
+		"file":                  p.File,
+		"parse":                 p.Parse,
+		"wait_for_parse":        p.WaitForParse,
+		"parse_timeout_seconds": p.ParseTimeout,
+	}
+}
+
+// DatasetWriterComponent writes a canvas artifact into a dataset.
+type DatasetWriterComponent struct {
+	name  string
+	param datasetWriterParam
+}
+
+// NewDatasetWriterComponent builds a DatasetWriter from a DSL params
+// map. parse defaults to true.
+func NewDatasetWriterComponent(params map[string]any) (Component, error) {
+	p := &datasetWriterParam{Parse: true}
+	if err := p.Update(params); err != nil {
+		return nil, fmt.Errorf("DatasetWriter: param update: %w", err)
+	}
+	if err := p.Check(); err != nil {
+		return nil, fmt.Errorf("DatasetWriter: param check: %w", err)
+	}
+	return &DatasetWriterComponent{name: componentNameDatasetWriter, param: *p}, nil
+}
+
+// Name returns the registered component name.
+func (c *DatasetWriterComponent) Name() string { return c.name }
+
+// Invoke resolves the payload against the canvas state and writes it.
+// `file` must be a single {{ref}} (e.g. {{docs_0@bytes}}) so binary
+// payloads keep their type; `content` and `filename` are templates.
+// `content` / `filename` inputs override the static params.
+func (c *DatasetWriterComponent) Invoke(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	state, _, err := runtime.GetStateFromContext[*runtime.CanvasState](ctx)
+	if err != nil {
+		return nil, fmt.Errorf("DatasetWriter: %w", err)
+	}
+	if state == nil {
+		return nil, errors.New("DatasetWriter: nil canvas state")
+	}
+	chain := runtime.CanvasCallChain(ctx)
+	if len(chain) == 0 {
+		return nil, errors.New("DatasetWriter: canvas id missing from context")
+	}
+
+	payload, err := c.payload(state, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) == 0 {
+		return nil, errors.New("DatasetWriter: nothing to write (content and file are empty)")
+	}
+
+	filename := c.param.Filename
+	if v, ok := stringFrom(inputs, "filename"); ok && v != "" {
+		filename = v
+	} else if filename != "" {
+		if filename, err = runtime.ResolveTemplate(filename, state); err != nil {
+			return nil, fmt.Errorf("DatasetWriter: resolve filename template: %w", err)
+		}
+	}
+	if strings.TrimSpace(filename) == "" {
+		filename = defaultDatasetWriterFilename
+	}
+
+	timeout := defaultDatasetParseTimeout
+	if c.param.ParseTimeout > 0 {
+		timeout = time.Duration(c.param.ParseTimeout * float64(time.Second))
+	}
+	res, err := getDatasetWriter().WriteDocument(ctx, DatasetWriteRequest{
+		CanvasID:     chain[len(chain)-1],
+		DatasetID:    c.param.DatasetID,
+		Filename:     filename,
+		Content:      payload,
+		Parse:        c.param.Parse,
+		WaitForParse: c.param.WaitForParse,
+		ParseTimeout: timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DatasetWriter: %w", err)
+	}
+	return map[string]any{
+		"document_id": res.DocumentID,
+		"dataset_id":  res.DatasetID,
+		"filename":    res.Filename,
+		"status":      res.Status,
+		"chunk_count": res.ChunkCount,
+		"token_count": res.TokenCount,
+	}, nil
+}
+
+// payload returns the bytes to store: the `file` reference when set,
+// otherwise the rendered `content` template.
+func (c *DatasetWriterComponent) payload(state *runtime.CanvasState, inputs map[string]any) ([]byte, error) {
+	if v, ok := stringFrom(inputs, "content"); ok && v != "" {
+		return []byte(v), nil
+	}
+	if c.param.File != "" {
+		trimmed := strings.TrimSpace(c.param.File)
+		m := runtime.VarRefPattern.FindStringSubmatch(trimmed)
+		if m == nil || m[0] != trimmed {
+			return nil, fmt.Errorf("DatasetWriter: file must be a single {{ref}}, got %q", c.param.File)
+		}
+		v, err := state.GetVar(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("DatasetWriter: resolve file: %w", err)
+		}
+		switch t := v.(type) {
+		case []byte:
+			return t, nil
+		case string:
+			return []byte(t), nil
+		case nil:
+			return nil, fmt.Errorf("DatasetWriter: unresolved reference %q", m[1])
+		default:
+			return nil, fmt.Errorf("DatasetWriter: file reference %q is %T, want bytes or text", m[1], v)
+		}
+	}
+	content, err := runtime.ResolveTemplate(c.param.Content, state)
+	if err != nil {
+		return nil, fmt.Errorf("DatasetWriter: resolve content template: %w", err)
+	}
+	return []byte(content), nil
+}
+
+// Stream mirrors Invoke.
+func (c *DatasetWriterComponent) Stream(ctx context.Context, inputs map[string]any) (<-chan map[string]any, error) {
+	out, err := c.Invoke(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan map[string]any, 1)
+	ch <- out
+	close(ch)
+	return ch, nil
+}
+
+// Inputs returns parameter metadata.
+func (c *DatasetWriterComponent) Inputs() map[string]string {
+	return map[string]string{
+		"content":  "Override: text stored as the document (otherwise the static content/file params).",
+		"filename": "Override: document name; the extension selects the parser.",
+	}
+}
+
+// Outputs returns the stored document surface.
+func (c *DatasetWriterComponent) Outputs() map[string]string {
+	return map[string]string{
+		"document_id": "Id of the created document.",
+		"dataset_id":  "Target dataset id.",
+		"filename":    "Stored document name (after de-duplication).",
+		"status":      "\"uploaded\", \"parsing\" or \"parsed\" (parsed only with wait_for_parse).",
+		"chunk_count": "Chunks produced by parsing (wait_for_parse only).",
+		"token_count": "Tokens produced by parsing (wait_for_parse only).",
+	}
+}
+
+func init() {
+	Register(componentNameDatasetWriter, NewDatasetWriterComponent)
+}
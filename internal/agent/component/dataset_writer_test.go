@@ -0,0 +1,120 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/agent/runtime"
+)
+
+type fakeDatasetWriter struct {
+	req DatasetWriteRequest
+}
+
+func (f *fakeDatasetWriter) WriteDocument(_ context.Context, req DatasetWriteRequest) (*DatasetWriteResult, error) {
+	f.req = req
+	return &DatasetWriteResult{DocumentID: "doc-1", DatasetID: req.DatasetID, Filename: req.Filename, Status: "parsing"}, nil
+}
+
+func datasetWriterCtx() context.Context {
+	state := canvas.NewCanvasState("run-1", "task-1")
+	state.SetVar("llm_0", "content", "a summary")
+	state.SetVar("docs_0", "bytes", []byte("%PDF-1.7"))
+	state.SetVar("docs_0", "filename", "report.pdf")
+	ctx := runtime.WithState(context.Background(), state)
+	return runtime.WithCanvasCall(runtime.WithCanvasCall(ctx, "parent"), "canvas-1")
+}
+
+func TestDatasetWriter_ParamCheck(t *testing.T) {
+	for name, params := range map[string]map[string]any{
+		"no dataset":         {"content": "x"},
+		"no payload":         {"dataset_id": "kb-1"},
+		"wait without parse": {"dataset_id": "kb-1", "content": "x", "parse": false, "wait_for_parse": true},
+		"negative timeout":   {"dataset_id": "kb-1", "content": "x", "parse_timeout_seconds": float64(-1)},
+	} {
+		if _, err := NewDatasetWriterComponent(params); err == nil {
+			t.Errorf("%s: expected a param error", name)
+		}
+	}
+}
+
+// TestDatasetWriter_WritesRenderedContent: content is a template, the
+// filename defaults, parsing defaults on, and the innermost canvas on
+// the call path is the one whose owner is checked.
+func TestDatasetWriter_WritesRenderedContent(t *testing.T) {
+	w := &fakeDatasetWriter{}
+	SetDatasetWriter(w)
+	defer SetDatasetWriter(nil)
+
+	c, err := NewDatasetWriterComponent(map[string]any{"dataset_id": "kb-1", "content": "# Notes\n{{llm_0@content}}"})
+	if err != nil {
+		t.Fatalf("NewDatasetWriterComponent: %v", err)
+	}
+	out, err := c.Invoke(datasetWriterCtx(), nil)
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if w.req.CanvasID != "canvas-1" || w.req.DatasetID != "kb-1" || string(w.req.Content) != "# Notes\na summary" {
+		t.Errorf("request = %+v", w.req)
+	}
+	if w.req.Filename != defaultDatasetWriterFilename || !w.req.Parse || w.req.WaitForParse || w.req.ParseTimeout != defaultDatasetParseTimeout {
+		t.Errorf("request = %+v", w.req)
+	}
+	if out["document_id"] != "doc-1" || out["status"] != "parsing" {
+		t.Errorf("outputs = %#v", out)
+	}
+}
+
+// TestDatasetWriter_WritesFileReference: a single {{ref}} file keeps
+// DocsGenerator's raw bytes.
+func TestDatasetWriter_WritesFileReference(t *testing.T) {
+	w := &fakeDatasetWriter{}
+	SetDatasetWriter(w)
+	defer SetDatasetWriter(nil)
+
+	c, err := NewDatasetWriterComponent(map[string]any{
+		"dataset_id":            "kb-1",
+		"file":                  "{{docs_0@bytes}}",
+		"filename":              "{{docs_0@filename}}",
+		"wait_for_parse":        true,
+		"parse_timeout_seconds": float64(30),
+	})
+	if err != nil {
+		t.Fatalf("NewDatasetWriterComponent: %v", err)
+	}
+	if _, err := c.Invoke(datasetWriterCtx(), nil); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if string(w.req.Content) != "%PDF-1.7" || w.req.Filename != "report.pdf" || !w.req.WaitForParse || w.req.ParseTimeout != 30*time.Second {
+		t.Errorf("request = %+v", w.req)
+	}
+}
+
+func TestDatasetWriter_RequiresWriter(t *testing.T) {
+	c, err := NewDatasetWriterComponent(map[string]any{"dataset_id": "kb-1", "content": "x"})
+	if err != nil {
+		t.Fatalf("NewDatasetWriterComponent: %v", err)
+	}
+	if _, err := c.Invoke(datasetWriterCtx(), nil); !errors.Is(err, ErrDatasetWriterMissing) {
+		t.Errorf("err = %v, want ErrDatasetWriterMissing", err)
+	}
+}
@@ -0,0 +1,453 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package component — ImageGenerator (T3).
+//
+// ImageGenerator renders its `prompt` template against the canvas
+// state, asks the tenant's image_generation model for one or more
+// images, and stores every image as an agent artifact in the
+// `<tenant>-downloads` bucket — the same bucket the
+// `/agents/attachments/:attachment_id/download` endpoint serves from.
+//
+// Model dispatch goes through the ImageModelProvider seam so the
+// component package does not import internal/service;
+// cmd/server_main.go installs the ModelProviderService-backed
+// implementation at boot. Artifact persistence goes through
+// ImageArtifactStore, which defaults to storage.Storage.
+package component
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ragflow/internal/agent/runtime"
+	"ragflow/internal/common"
+	modelModule "ragflow/internal/entity/models"
+	"ragflow/internal/storage"
+	"ragflow/internal/utility"
+)
+
+const componentNameImageGenerator = "ImageGenerator"
+
+// maxGeneratedImages caps `n` so a misconfigured canvas cannot fan out
+// into an unbounded number of paid generations.
+const maxGeneratedImages = 4
+
+// maxGeneratedImageBytes caps the size of an image fetched from a
+// provider-returned URL.
+const maxGeneratedImageBytes = 32 << 20
+
+// ErrImageModelProviderMissing is returned when no ImageModelProvider
+// has been installed (unit tests, stand-alone tools).
+var ErrImageModelProviderMissing = errors.New(
+	"component: image generation model provider not wired",
+)
+
+// ImageGenerationRequest is what ImageGenerator hands to the model
+// provider. LLMID is the composite `model@instance@provider` id from
+// the DSL; UserID is the canvas owner the model is resolved for.
+type ImageGenerationRequest struct {
+	UserID string
+	LLMID  string
+	Prompt string
+	Config modelModule.ImageGenerationConfig
+}
+
+// ImageModelProvider dispatches an image generation request to the
+// tenant's image_generation model.
+type ImageModelProvider interface {
+	GenerateImage(ctx context.Context, req ImageGenerationRequest) (*modelModule.ImageGenerationResponse, error)
+}
+
+// ImageArtifactStore persists a generated image and returns the
+// attachment id it can be downloaded under.
+type ImageArtifactStore interface {
+	Put(tenantID, filename string, data []byte) (string, error)
+}
+
+var (
+	imageProviderMu   sync.RWMutex
+	imageProviderImpl ImageModelProvider = stubImageModelProvider{}
+	imageStoreImpl    ImageArtifactStore = storageImageArtifactStore{}
+)
+
+// SetImageModelProvider installs the model provider. Passing nil
+// reverts to the default stub.
+func SetImageModelProvider(p ImageModelProvider) {
+	imageProviderMu.Lock()
+	defer imageProviderMu.Unlock()
+	if p == nil {
+		imageProviderImpl = stubImageModelProvider{}
+		return
+	}
+	imageProviderImpl = p
+}
+
+// SetImageArtifactStore installs the artifact store. Passing nil
+// reverts to the storage.Storage-backed default.
+func SetImageArtifactStore(s ImageArtifactStore) {
+	imageProviderMu.Lock()
+	defer imageProviderMu.Unlock()
+	if s == nil {
+		imageStoreImpl = storageImageArtifactStore{}
+		return
+	}
+	imageStoreImpl = s
+}
+
+func getImageGenerationDeps() (ImageModelProvider, ImageArtifactStore) {
+	imageProviderMu.RLock()
+	defer imageProviderMu.RUnlock()
+	return imageProviderImpl, imageStoreImpl
+}
+
+type stubImageModelProvider struct{}
+
+func (stubImageModelProvider) GenerateImage(context.Context, ImageGenerationRequest) (*modelModule.ImageGenerationResponse, error) {
+	return nil, ErrImageModelProviderMissing
+}
+
+// storageImageArtifactStore writes into `<tenant>-downloads`, matching
+// FileService.DownloadAgentFile.
+type storageImageArtifactStore struct{}
+
+func (storageImageArtifactStore) Put(tenantID, filename string, data []byte) (string, error) {
+	storageImpl := storage.GetStorageFactory().GetStorage()
+	if storageImpl == nil {
+		return "", errors.New("storage not initialized")
+	}
+	location := common.GenerateUUID() + strings.ToLower(filepath.Ext(filename))
+	if err := storageImpl.Put(fmt.Sprintf("%s-downloads", tenantID), location, data); err != nil {
+		return "", fmt.Errorf("failed to store image: %w", err)
+	}
+	return location, nil
+}
+
+// ImageDispatcher mirrors *service.ModelProviderService.GenerateImage
+// so the production wiring is a one-line cast.
+type ImageDispatcher interface {
+	GenerateImage(
+		providerName, instanceName, modelName, modelID *string,
+		userID string,
+		prompt string,
+		apiConfig *modelModule.APIConfig,
+		modelConfig *modelModule.ImageGenerationConfig,
+	) (*modelModule.ImageGenerationResponse, common.ErrorCode, error)
+}
+
+// NewImageModelProvider adapts an ImageDispatcher to ImageModelProvider.
+// Returns nil when d is nil so SetImageModelProvider reverts to the stub.
+func NewImageModelProvider(d ImageDispatcher) ImageModelProvider {
+	if d == nil {
+		return nil
+	}
+	return dispatchImageModelProvider{d: d}
+}
+
+type dispatchImageModelProvider struct {
+	d ImageDispatcher
+}
+
+func (p dispatchImageModelProvider) GenerateImage(_ context.Context, req ImageGenerationRequest) (*modelModule.ImageGenerationResponse, error) {
+	modelName, instanceName, providerName := parseLLMIDParts(req.LLMID)
+	if providerName == "" {
+		return nil, fmt.Errorf("llm_id %q must be model@provider or model@instance@provider", req.LLMID)
+	}
+	cfg := req.Config
+	resp, code, err := p.d.GenerateImage(&providerName, &instanceName, &modelName, nil, req.UserID, req.Prompt, &modelModule.APIConfig{}, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if code != common.CodeSuccess {
+		return nil, fmt.Errorf("image generation dispatch: code=%d", code)
+	}
+	return resp, nil
+}
+
+// imageGeneratorParam is the static DSL param surface.
+type imageGeneratorParam struct {
+	LLMID          string `json:"llm_id"`
+	Prompt         string `json:"prompt"`
+	NegativePrompt string `json:"negative_prompt"`
+	Size           string `json:"size"`
+	N              int    `json:"n"`
+	Quality        string `json:"quality"`
+	Style          string `json:"style"`
+}
+
+// Update copies a fresh params map into the receiver.
+func (p *imageGeneratorParam) Update(conf map[string]any) error {
+	if conf == nil {
+		conf = map[string]any{}
+	}
+	if v, ok := stringFrom(conf, "llm_id"); ok {
+		p.LLMID = v
+	}
+	if v, ok := stringFrom(conf, "prompt"); ok {
+		p.Prompt = v
+	}
+	if v, ok := stringFrom(conf, "negative_prompt"); ok {
+		p.NegativePrompt = v
+	}
+	if v, ok := stringFrom(conf, "size"); ok {
+		p.Size = v
+	}
+	if v, ok := stringFrom(conf, "quality"); ok {
+		p.Quality = v
+	}
+	if v, ok := stringFrom(conf, "style"); ok {
+		p.Style = v
+	}
+	if v, ok := intFrom(conf, "n"); ok {
+		p.N = v
+	} else {
+		p.N = 1
+	}
+	return nil
+}
+
+// Check validates the param.
+func (p *imageGeneratorParam) Check() error {
+	if strings.TrimSpace(p.LLMID) == "" {
+		return &ParamError{Field: "llm_id", Reason: "must not be empty"}
+	}
+	if p.N < 1 || p.N > maxGeneratedImages {
+		return &ParamError{Field: "n", Reason: fmt.Sprintf("must be between 1 and %d", maxGeneratedImages)}
+	}
+	return nil
+}
+
+// AsDict returns the param as a plain map.
+func (p *imageGeneratorParam) AsDict() map[string]any {
+	return map[string]any{
+		"llm_id":          p.LLMID,
+		"prompt":          p.Prompt,
+		"negative_prompt": p.NegativePrompt,
+		"size":            p.Size,
+		"n":               p.N,
+		"quality":         p.Quality,
+		"style":           p.Style,
+	}
+}
+
+// ImageGeneratorComponent generates images from a text prompt.
+type ImageGeneratorComponent struct {
+	name  string
+	param imageGeneratorParam
+}
+
+// NewImageGeneratorComponent builds an ImageGenerator from a DSL params map.
+func NewImageGeneratorComponent(params map[string]any) (Component, error) {
+	p := &imageGeneratorParam{}
+	if err := p.Update(params); err != nil {
+		return nil, fmt.Errorf("ImageGenerator: param update: %w", err)
+	}
+	if err := p.Check(); err != nil {
+		return nil, fmt.Errorf("ImageGenerator: param check: %w", err)
+	}
+	return &ImageGeneratorComponent{name: componentNameImageGenerator, param: *p}, nil
+}
+
+// Name returns the registered component name.
+func (c *ImageGeneratorComponent) Name() string { return c.name }
+
+// Invoke resolves the prompt, calls the model provider and stores the
+// resulting images as agent artifacts. A `prompt` input overrides the
+// static param.
+func (c *ImageGeneratorComponent) Invoke(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	state, _, err := runtime.GetStateFromContext[*runtime.CanvasState](ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ImageGenerator: %w", err)
+	}
+	if state == nil {
+		return nil, errors.New("ImageGenerator: nil canvas state")
+	}
+
+	userID, _ := state.Sys["user_id"].(string)
+	if userID == "" {
+		return nil, errors.New("ImageGenerator: user_id missing from canvas state (state.Sys[\"user_id\"])")
+	}
+
+	prompt := c.param.Prompt
+	if v, ok := stringFrom(inputs, "prompt"); ok && v != "" {
+		prompt = v
+	}
+	prompt, err = runtime.ResolveTemplate(prompt, state)
+	if err != nil {
+		return nil, fmt.Errorf("ImageGenerator: resolve prompt template: %w", err)
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return nil, errors.New("ImageGenerator: prompt is empty")
+	}
+
+	provider, store := getImageGenerationDeps()
+	resp, err := provider.GenerateImage(ctx, ImageGenerationRequest{
+		UserID: userID,
+		LLMID:  c.param.LLMID,
+		Prompt: prompt,
+		Config: modelModule.ImageGenerationConfig{
+			Size:           c.param.Size,
+			N:              c.param.N,
+			Quality:        c.param.Quality,
+			Style:          c.param.Style,
+			NegativePrompt: c.param.NegativePrompt,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ImageGenerator: %w", err)
+	}
+	if resp == nil || len(resp.Images) == 0 {
+		return nil, errors.New("ImageGenerator: model returned no images")
+	}
+
+	images := make([]map[string]any, 0, len(resp.Images))
+	var content strings.Builder
+	for i, img := range resp.Images {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("ImageGenerator: %w", err)
+		}
+		data, err := generatedImageBytes(ctx, img)
+		if err != nil {
+			return nil, fmt.Errorf("ImageGenerator: image %d: %w", i, err)
+		}
+		mimeType := http.DetectContentType(data)
+		filename := fmt.Sprintf("image_%d%s", i+1, imageExtension(mimeType))
+		attachmentID, err := store.Put(userID, filename, data)
+		if err != nil {
+			return nil, fmt.Errorf("ImageGenerator: %w", err)
+		}
+		url := fmt.Sprintf("/api/v1/agents/attachments/%s/download?ext=%s", attachmentID, strings.TrimPrefix(imageExtension(mimeType), "."))
+		images = append(images, map[string]any{
+			"id":             attachmentID,
+			"filename":       filename,
+			"mime_type":      mimeType,
+			"size":           len(data),
+			"url":            url,
+			"revised_prompt": img.RevisedPrompt,
+		})
+		if content.Len() > 0 {
+			content.WriteString("\n")
+		}
+		fmt.Fprintf(&content, "![%s](%s)", filename, url)
+	}
+
+	return map[string]any{
+		"images":  images,
+		"content": content.String(),
+		"prompt":  prompt,
+		"created": time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// Stream mirrors Invoke; ImageGenerator is a single-shot generator.
+func (c *ImageGeneratorComponent) Stream(ctx context.Context, inputs map[string]any) (<-chan map[string]any, error) {
+	out, err := c.Invoke(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan map[string]any, 1)
+	ch <- out
+	close(ch)
+	return ch, nil
+}
+
+// Inputs returns parameter metadata.
+func (c *ImageGeneratorComponent) Inputs() map[string]string {
+	return map[string]string{
+		"prompt": "Override: image description (otherwise uses the static param; {cpn@var} refs are resolved).",
+	}
+}
+
+// Outputs returns the response surface.
+func (c *ImageGeneratorComponent) Outputs() map[string]string {
+	return map[string]string{
+		"images":  "Generated images: [{id, filename, mime_type, size, url, revised_prompt}].",
+		"content": "Markdown image links, one per generated image.",
+		"prompt":  "The resolved prompt sent to the model.",
+		"created": "RFC3339 timestamp of the generation.",
+	}
+}
+
+// generatedImageBytes returns the raw image payload, decoding b64_json
+// or fetching the provider's (short-lived) URL.
+func generatedImageBytes(ctx context.Context, img modelModule.GeneratedImage) ([]byte, error) {
+	if img.B64JSON != "" {
+		data, err := base64.StdEncoding.DecodeString(img.B64JSON)
+		if err != nil {
+			return nil, fmt.Errorf("decode b64_json: %w", err)
+		}
+		return data, nil
+	}
+	if img.URL == "" {
+		return nil, errors.New("image has neither url nor b64_json")
+	}
+	// The URL comes from a tenant-configurable endpoint, so it gets the
+	// same SSRF guard as every other agent fetch.
+	hostname, ip, err := utility.AssertURLSafe(img.URL)
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, img.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build download request: %w", err)
+	}
+	client := utility.PinnedHTTPClient(hostname, ip, 60*time.Second)
+	// A redirect would dial a host that skipped the check above.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download image: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxGeneratedImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	if len(data) > maxGeneratedImageBytes {
+		return nil, fmt.Errorf("download image: larger than %d bytes", maxGeneratedImageBytes)
+	}
+	return data, nil
+}
+
+// imageExtension maps a sniffed MIME type to a file extension; unknown
+// types fall back to .png, the default of every supported provider.
+func imageExtension(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/webp":
+		return ".webp"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".png"
+	}
+}
+
+func init() {
+	Register(componentNameImageGenerator, NewImageGeneratorComponent)
+}
@@ -0,0 +1,141 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package component
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"ragflow/internal/agent/canvas"
+	modelModule "ragflow/internal/entity/models"
+)
+
+// pngMagic is enough of a PNG header for http.DetectContentType.
+var pngMagic = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type fakeImageProvider struct {
+	got ImageGenerationRequest
+}
+
+func (f *fakeImageProvider) GenerateImage(_ context.Context, req ImageGenerationRequest) (*modelModule.ImageGenerationResponse, error) {
+	f.got = req
+	return &modelModule.ImageGenerationResponse{Images: []modelModule.GeneratedImage{
+		{B64JSON: base64.StdEncoding.EncodeToString(pngMagic), RevisedPrompt: "a red fox, watercolor"},
+	}}, nil
+}
+
+type fakeImageStore struct {
+	tenantID string
+	filename string
+	data     []byte
+}
+
+func (f *fakeImageStore) Put(tenantID, filename string, data []byte) (string, error) {
+	f.tenantID, f.filename, f.data = tenantID, filename, data
+	return "att-1", nil
+}
+
+// TestImageGenerator_StubProviderErrors: without a wired provider
+// Invoke surfaces ErrImageModelProviderMissing.
+func TestImageGenerator_StubProviderErrors(t *testing.T) {
+	SetImageModelProvider(nil)
+	c, err := NewImageGeneratorComponent(map[string]any{"llm_id": "gpt-image-1@OpenAI", "prompt": "fox"})
+	if err != nil {
+		t.Fatalf("NewImageGeneratorComponent: %v", err)
+	}
+	state := canvas.NewCanvasState("run-1", "task-1")
+	state.Sys["user_id"] = "tenant-1"
+	_, err = c.Invoke(canvas.WithState(context.Background(), state), nil)
+	if !errors.Is(err, ErrImageModelProviderMissing) {
+		t.Errorf("got %v, want ErrImageModelProviderMissing", err)
+	}
+}
+
+// TestImageGenerator_StoresImageAndReturnsAttachment walks the happy
+// path: the prompt template resolves against state, the decoded image
+// lands in the artifact store and the outputs reference its download URL.
+func TestImageGenerator_StoresImageAndReturnsAttachment(t *testing.T) {
+	provider := &fakeImageProvider{}
+	store := &fakeImageStore{}
+	SetImageModelProvider(provider)
+	SetImageArtifactStore(store)
+	defer SetImageModelProvider(nil)
+	defer SetImageArtifactStore(nil)
+
+	c, err := NewImageGeneratorComponent(map[string]any{
+		"llm_id": "gpt-image-1@OpenAI",
+		"prompt": "{sys.query}, watercolor",
+		"size":   "1024x1024",
+	})
+	if err != nil {
+		t.Fatalf("NewImageGeneratorComponent: %v", err)
+	}
+	state := canvas.NewCanvasState("run-1", "task-1")
+	state.Sys["user_id"] = "tenant-1"
+	state.Sys["query"] = "a red fox"
+
+	out, err := c.Invoke(canvas.WithState(context.Background(), state), nil)
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if provider.got.Prompt != "a red fox, watercolor" {
+		t.Errorf("prompt: got %q", provider.got.Prompt)
+	}
+	if provider.got.Config.Size != "1024x1024" || provider.got.Config.N != 1 {
+		t.Errorf("config: got %+v", provider.got.Config)
+	}
+	if store.tenantID != "tenant-1" || store.filename != "image_1.png" {
+		t.Errorf("store: tenant=%q filename=%q", store.tenantID, store.filename)
+	}
+	images, _ := out["images"].([]map[string]any)
+	if len(images) != 1 {
+		t.Fatalf("images: got %v", out["images"])
+	}
+	if images[0]["url"] != "/api/v1/agents/attachments/att-1/download?ext=png" {
+		t.Errorf("url: got %v", images[0]["url"])
+	}
+	if images[0]["mime_type"] != "image/png" {
+		t.Errorf("mime_type: got %v", images[0]["mime_type"])
+	}
+	if content, _ := out["content"].(string); !strings.Contains(content, "att-1") {
+		t.Errorf("content: got %q", content)
+	}
+}
+
+// TestImageGeneratorParam_Check rejects a missing model and an
+// out-of-range image count.
+func TestImageGeneratorParam_Check(t *testing.T) {
+	if _, err := NewImageGeneratorComponent(map[string]any{"prompt": "fox"}); err == nil {
+		t.Error("missing llm_id: want error")
+	}
+	if _, err := NewImageGeneratorComponent(map[string]any{"llm_id": "m@p", "prompt": "fox", "n": 9}); err == nil {
+		t.Error("n=9: want error")
+	}
+}
+
+// TestGeneratedImageBytes_RejectsInternalURL: a provider-returned URL
+// pointing at the metadata service or loopback is never fetched.
+func TestGeneratedImageBytes_RejectsInternalURL(t *testing.T) {
+	for _, u := range []string{"http://169.254.169.254/latest/meta-data/", "http://127.0.0.1:8080/x.png", "file:///etc/passwd"} {
+		if _, err := generatedImageBytes(context.Background(), modelModule.GeneratedImage{URL: u}); err == nil {
+			t.Errorf("%s: fetched", u)
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"regexp"
 	"slices"
 	"sort"
@@ -139,6 +140,9 @@ type ChatInvokeRequest struct {
 	PresencePenalty  *float64
 	FrequencyPenalty *float64
 	MaxTokens        *int
+	// ResponseSchema, when set, is forwarded as ChatConfig.ResponseSchema
+	// so drivers with native structured output enforce it upstream.
+	ResponseSchema *models.ResponseSchema
 }
 
 // ChatInvokeResponse mirrors what the LLM component writes to its outputs.
@@ -147,6 +151,16 @@ type ChatInvokeResponse struct {
 	Model   string
 	Stopped bool
 	Tokens  int
+	// PromptTokens / CompletionTokens are the provider-reported split of
+	// Tokens; all three stay 0 when the driver reports no usage.
+	PromptTokens     int
+	CompletionTokens int
+	// Retries counts the failed attempts retryInvoker absorbed before
+	// this response.
+	Retries int
+	// ToolCalls is only read by the Agent's ReAct loop when a
+	// WithChatInvoker override stands in for the model.
+	ToolCalls []schema.ToolCall
 }
 
 // defaultChatInvokerMu guards defaultChatInvoker swaps during tests.
@@ -227,21 +241,30 @@ func (e *einoChatInvoker) Invoke(ctx context.Context, req ChatInvokeRequest) (*C
 	cfg := &models.APIConfig{ApiKey: &apiKey}
 	cm := models.NewChatModel(d, &modelName, cfg)
 
+	usage := &models.ChatUsage{}
 	chatCfg := &models.ChatConfig{
-		Temperature: req.Temperature,
-		TopP:        req.TopP,
-		MaxTokens:   req.MaxTokens,
+		Temperature:    req.Temperature,
+		TopP:           req.TopP,
+		MaxTokens:      req.MaxTokens,
+		ResponseSchema: req.ResponseSchema,
+		UsageResult:    usage,
 	}
 	wrapper := models.NewEinoChatModel(cm, chatCfg)
 	out, err := wrapper.Generate(ctx, toEinoMessages(req.Messages))
 	if err != nil {
 		return nil, err
 	}
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
 	return &ChatInvokeResponse{
-		Content: out.Content,
-		Model:   modelName,
-		Stopped: true,
-		Tokens:  0,
+		Content:          out.Content,
+		Model:            modelName,
+		Stopped:          true,
+		Tokens:           total,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
 	}, nil
 }
 
@@ -278,6 +301,7 @@ func toEinoMessages(msgs []schema.Message) []*schema.Message {
 			Role:                  role,
 			Content:               m.Content,
 			UserInputMultiContent: cloned,
+			Extra:                 maps.Clone(m.Extra),
 		})
 	}
 	return out
@@ -365,7 +389,7 @@ func (c *LLMComponent) Invoke(ctx context.Context, inputs map[string]any) (map[s
 			msgs = prependHistory(msgs, state.History, p.MessageHistoryWindowSize)
 		}
 	}
-	inv := getDefaultChatInvoker()
+	inv := chatInvokerFor(ctx)
 	// Param-level retry override. When MaxRetries OR
 	// DelayAfterError is set on LLMParam, the user is asking
 	// for a per-call retry budget. We RE-WRAP the default
@@ -418,6 +442,7 @@ func (c *LLMComponent) Invoke(ctx context.Context, inputs map[string]any) (map[s
 		PresencePenalty:  p.PresencePenalty,
 		FrequencyPenalty: p.FrequencyPenalty,
 		MaxTokens:        p.MaxTokens,
+		ResponseSchema:   outputStructureSchema(p.OutputStructure),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("component: LLM.Invoke: %w", err)
@@ -428,6 +453,10 @@ func (c *LLMComponent) Invoke(ctx context.Context, inputs map[string]any) (map[s
 		"model":   resp.Model,
 		"stopped": resp.Stopped,
 		"tokens":  resp.Tokens,
+		// Usage and retry counts feed the run report (canvas/run_report.go).
+		"prompt_tokens":     resp.PromptTokens,
+		"completion_tokens": resp.CompletionTokens,
+		"retries":           resp.Retries,
 	}
 	if p.JSONOutput {
 		var parsed map[string]any
@@ -439,11 +468,9 @@ func (c *LLMComponent) Invoke(ctx context.Context, inputs map[string]any) (map[s
 		}
 	}
 	if p.OutputStructure != nil {
-		// Best-effort parse: if the first response isn't valid JSON
-		// (or doesn't contain the expected top-level keys), retry once
-		// with a re-prompt. OutputStructure is treated as a key-set
-		// hint; deep schema validation (types, nested objects) is
-		// deferred to a future phase.
+		// The schema is enforced natively where the driver supports it;
+		// otherwise a response that fails validation gets one repair
+		// round trip listing the violations.
 		parsed, ok := matchOutputStructure(resp.Content, p.OutputStructure)
 		if !ok {
 			retryResp, err := inv.Invoke(ctx, ChatInvokeRequest{
@@ -457,8 +484,14 @@ func (c *LLMComponent) Invoke(ctx context.Context, inputs map[string]any) (map[s
 				PresencePenalty:  p.PresencePenalty,
 				FrequencyPenalty: p.FrequencyPenalty,
 				MaxTokens:        p.MaxTokens,
+				ResponseSchema:   outputStructureSchema(p.OutputStructure),
 			})
 			if err == nil {
+				// The repair round trip is billed like the first call.
+				out["tokens"] = resp.Tokens + retryResp.Tokens
+				out["prompt_tokens"] = resp.PromptTokens + retryResp.PromptTokens
+				out["completion_tokens"] = resp.CompletionTokens + retryResp.CompletionTokens
+				out["retries"] = resp.Retries + retryResp.Retries
 				parsed, ok = matchOutputStructure(retryResp.Content, p.OutputStructure)
 				if ok {
 					resp = retryResp
@@ -563,11 +596,25 @@ func (c *LLMComponent) Inputs() map[string]string {
 // Outputs returns output metadata.
 func (c *LLMComponent) Outputs() map[string]string {
 	return map[string]string{
-		"content": "Assistant text response",
-		"model":   "Model identifier echoed back (sanity check)",
-		"stopped": "True if the model finished naturally",
-		"tokens":  "Reported token count (0 when not reported by the driver)",
-		"json":    "When json_output=true and content parses as a JSON object, the parsed map",
+		"content":           "Assistant text response",
+		"model":             "Model identifier echoed back (sanity check)",
+		"stopped":           "True if the model finished naturally",
+		"tokens":            "Reported token count (0 when not reported by the driver)",
+		"json":              "When json_output=true and content parses as a JSON object, the parsed map",
+		"prompt_tokens":     "Reported prompt tokens (0 when not reported by the driver)",
+		"completion_tokens": "Reported completion tokens (0 when not reported by the driver)",
+		"retries":           "Failed attempts retried before the call succeeded",
+	}
+}
+
+// systemMessage builds the system turn. The system prompt (plus any
+// citation block) is identical across turns, so it is marked as the
+// prefix for providers with explicit prompt caching.
+func systemMessage(system string) schema.Message {
+	return schema.Message{
+		Role:    schema.System,
+		Content: system,
+		Extra:   map[string]any{models.CacheBreakpointExtraKey: true},
 	}
 }
 
@@ -576,7 +623,7 @@ func (c *LLMComponent) Outputs() map[string]string {
 func buildMessages(system, user string) []schema.Message {
 	out := make([]schema.Message, 0, 2)
 	if system != "" {
-		out = append(out, schema.Message{Role: schema.System, Content: system})
+		out = append(out, systemMessage(system))
 	}
 	if user != "" {
 		out = append(out, schema.Message{Role: schema.User, Content: user})
@@ -588,21 +635,63 @@ func buildMessages(system, user string) []schema.Message {
 // citation-instruction text appended. When system is empty, returns
 // the prompt as-is. Two newlines separate the user's system prompt
 // from the citation block so the LLM can parse them distinctly.
-// matchOutputStructure parses the LLM response and returns the
-// parsed map iff it is a JSON object that contains every top-level
-// key in expected. Inner-type validation is deferred — a future
-// phase will use a JSON-schema validator.
-func matchOutputStructure(content string, expected map[string]any) (map[string]any, bool) {
-	var parsed map[string]any
-	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
-		return nil, false
+// outputStructureSchema turns the output_structure param into a
+// ResponseSchema. A value shaped like a JSON schema (type "object" with
+// properties) is used verbatim; anything else is the legacy key-set form,
+// where every key is a required property and a map value carrying "type"
+// doubles as that property's schema.
+func outputStructureSchema(expected map[string]any) *models.ResponseSchema {
+	if expected == nil {
+		return nil
+	}
+	if t, _ := expected["type"].(string); t == "object" {
+		if _, ok := expected["properties"].(map[string]any); ok {
+			return &models.ResponseSchema{Name: "output", Schema: expected}
+		}
 	}
+	properties := make(map[string]any, len(expected))
+	required := make([]any, 0, len(expected))
+	keys := make([]string, 0, len(expected))
 	for k := range expected {
-		if _, ok := parsed[k]; !ok {
-			return nil, false
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		prop := map[string]any{}
+		if m, ok := expected[k].(map[string]any); ok {
+			if _, typed := m["type"]; typed {
+				prop = m
+			}
 		}
+		properties[k] = prop
+		required = append(required, k)
+	}
+	return &models.ResponseSchema{
+		Name:   "output",
+		Schema: map[string]any{"type": "object", "properties": properties, "required": required},
+	}
+}
+
+// outputStructureViolations validates content against expected and
+// returns the parsed object plus any schema violations.
+func outputStructureViolations(content string, expected map[string]any) (map[string]any, []string) {
+	value, violations := models.ParseStructuredOutput(content, outputStructureSchema(expected))
+	if len(violations) > 0 {
+		return nil, violations
+	}
+	parsed, ok := value.(map[string]any)
+	if !ok {
+		return nil, []string{"$: expected a JSON object"}
 	}
-	return parsed, true
+	return parsed, nil
+}
+
+// matchOutputStructure parses the LLM response and returns the
+// parsed map iff it is a JSON object that validates against the
+// schema derived from expected (see outputStructureSchema).
+func matchOutputStructure(content string, expected map[string]any) (map[string]any, bool) {
+	parsed, violations := outputStructureViolations(content, expected)
+	return parsed, len(violations) == 0
 }
 
 // buildStructuredRetryMessages rebuilds the message list with a
@@ -619,8 +708,11 @@ func buildStructuredRetryMessages(system, user string, images []string, cite boo
 	sort.Strings(keys)
 	keysList := strings.Join(keys, ", ")
 	retryUser := "Your previous response was not valid JSON matching the requested schema.\n\n" +
-		"Previous response:\n" + prevContent + "\n\n" +
-		"Please re-generate the response as a single valid JSON object containing all of these top-level keys: " + keysList + ".\n" +
+		"Previous response:\n" + prevContent + "\n\n"
+	if _, violations := outputStructureViolations(prevContent, expected); len(violations) > 0 {
+		retryUser += "Problems:\n- " + strings.Join(violations, "\n- ") + "\n\n"
+	}
+	retryUser += "Please re-generate the response as a single valid JSON object containing all of these top-level keys: " + keysList + ".\n" +
 		"Output ONLY the JSON object — no prose, no markdown code fences."
 	if len(msgs) > 0 {
 		msgs[len(msgs)-1] = schema.Message{
@@ -727,7 +819,7 @@ func buildMessagesWithImages(system, user string, images []string, cite bool) []
 	}
 	out := make([]schema.Message, 0, 2)
 	if system != "" {
-		out = append(out, schema.Message{Role: schema.System, Content: system})
+		out = append(out, systemMessage(system))
 	}
 
 	if len(images) == 0 {
@@ -122,6 +122,9 @@ func (r *retryInvoker) Invoke(ctx context.Context, req ChatInvokeRequest) (*Chat
 	for attempt := 0; attempt <= r.maxRetries; attempt++ {
 		resp, err := r.inner.Invoke(ctx, req)
 		if err == nil {
+			if resp != nil {
+				resp.Retries += attempt
+			}
 			return resp, nil
 		}
 		lastErr = err
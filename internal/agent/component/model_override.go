@@ -0,0 +1,165 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package component — per-run model and tool overrides.
+//
+// SetDefaultChatInvoker swaps the chat model for the whole process,
+// which is fine for unit tests but not for a canvas test run served
+// next to production runs. WithChatInvoker and WithToolMocker scope an
+// override to one run's context instead: every LLM, Categorize and
+// Agent call made under that context goes through the override, and
+// every Agent tool call is offered to the mocker before the real tool
+// runs. The canvas test runner (internal/agent/canvastest) is the
+// caller.
+package component
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/model"
+	einotool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+type chatInvokerCtxKey struct{}
+
+type toolMockerCtxKey struct{}
+
+// ToolMocker answers an Agent tool call in place of the real tool.
+// handled=false lets the real tool run.
+type ToolMocker func(ctx context.Context, toolName, argumentsJSON string) (result string, handled bool, err error)
+
+// WithChatInvoker routes every chat call made under ctx through inv
+// instead of the package default.
+func WithChatInvoker(ctx context.Context, inv ChatInvoker) context.Context {
+	return context.WithValue(ctx, chatInvokerCtxKey{}, inv)
+}
+
+// WithToolMocker offers every Agent tool call made under ctx to m
+// before the real tool runs.
+func WithToolMocker(ctx context.Context, m ToolMocker) context.Context {
+	return context.WithValue(ctx, toolMockerCtxKey{}, m)
+}
+
+// chatInvokerFor returns the context override, or the package default.
+func chatInvokerFor(ctx context.Context) ChatInvoker {
+	if inv, ok := ctx.Value(chatInvokerCtxKey{}).(ChatInvoker); ok && inv != nil {
+		return inv
+	}
+	return getDefaultChatInvoker()
+}
+
+// agentModelOverride returns a tool-calling model backed by the
+// context's ChatInvoker override, or nil when there is none.
+func agentModelOverride(ctx context.Context, p AgentParam) model.ToolCallingChatModel {
+	inv, ok := ctx.Value(chatInvokerCtxKey{}).(ChatInvoker)
+	if !ok || inv == nil {
+		return nil
+	}
+	return &invokerChatModel{inv: inv, p: p}
+}
+
+// mockableTools wraps tools so calls are offered to the context's
+// ToolMocker first. Returns tools unchanged when there is none.
+func mockableTools(ctx context.Context, tools []einotool.BaseTool) []einotool.BaseTool {
+	m, ok := ctx.Value(toolMockerCtxKey{}).(ToolMocker)
+	if !ok || m == nil {
+		return tools
+	}
+	out := make([]einotool.BaseTool, 0, len(tools))
+	for _, t := range tools {
+		if inv, ok := t.(einotool.InvokableTool); ok {
+			out = append(out, &mockedTool{InvokableTool: inv, mock: m})
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// invokerChatModel adapts a ChatInvoker to the eino tool-calling model
+// the ReAct agent drives. Tool calls come back through
+// ChatInvokeResponse.ToolCalls.
+type invokerChatModel struct {
+	inv   ChatInvoker
+	p     AgentParam
+	tools []*schema.ToolInfo
+}
+
+func (m *invokerChatModel) Generate(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	msgs := make([]schema.Message, 0, len(input))
+	for _, msg := range input {
+		if msg != nil {
+			msgs = append(msgs, *msg)
+		}
+	}
+	resp, err := m.inv.Invoke(ctx, ChatInvokeRequest{
+		Driver:    m.p.Driver,
+		ModelName: m.p.ModelID,
+		APIKey:    m.p.APIKey,
+		BaseURL:   m.p.BaseURL,
+		Messages:  msgs,
+		TopP:      m.p.TopP,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("component: chat invoker returned no response")
+	}
+	msg := &schema.Message{Role: schema.Assistant, Content: resp.Content, ToolCalls: resp.ToolCalls}
+	if resp.Tokens != 0 || resp.PromptTokens != 0 || resp.CompletionTokens != 0 {
+		msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{
+			PromptTokens:     resp.PromptTokens,
+			CompletionTokens: resp.CompletionTokens,
+			TotalTokens:      resp.Tokens,
+		}}
+	}
+	return msg, nil
+}
+
+func (m *invokerChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *invokerChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	cp := *m
+	cp.tools = tools
+	return &cp, nil
+}
+
+// mockedTool is an InvokableTool whose calls go to a ToolMocker first.
+type mockedTool struct {
+	einotool.InvokableTool
+	mock ToolMocker
+}
+
+func (t *mockedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...einotool.Option) (string, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	result, handled, err := t.mock(ctx, info.Name, argumentsInJSON)
+	if err != nil || handled {
+		return result, err
+	}
+	return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+}
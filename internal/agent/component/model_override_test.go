@@ -0,0 +1,81 @@
+// Package component — per-run model and tool override tests.
+package component
+
+import (
+	"context"
+	"testing"
+
+	einotool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// echoTool is an InvokableTool that returns its arguments.
+type echoTool struct{ calls int }
+
+func (e *echoTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "echo"}, nil
+}
+
+func (e *echoTool) InvokableRun(_ context.Context, args string, _ ...einotool.Option) (string, error) {
+	e.calls++
+	return args, nil
+}
+
+func TestWithChatInvoker_OverridesDefault(t *testing.T) {
+	def := &stubInvoker{resp: &ChatInvokeResponse{Content: "default"}}
+	withStubInvoker(t, def)
+	override := &stubInvoker{resp: &ChatInvokeResponse{Content: "override"}}
+
+	c := NewLLMComponent(LLMParam{ModelID: "m"})
+	out, err := c.Invoke(WithChatInvoker(context.Background(), override), map[string]any{"user_prompt": "hi"})
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if out["content"] != "override" || override.calls != 1 || def.calls != 0 {
+		t.Errorf("content=%v override=%d default=%d, want the override only", out["content"], override.calls, def.calls)
+	}
+}
+
+func TestAgentModelOverride_ReturnsToolCalls(t *testing.T) {
+	if agentModelOverride(context.Background(), AgentParam{}) != nil {
+		t.Fatal("expected no override without WithChatInvoker")
+	}
+	calls := []schema.ToolCall{{ID: "c1", Function: schema.FunctionCall{Name: "echo", Arguments: "{}"}}}
+	inv := &stubInvoker{resp: &ChatInvokeResponse{Content: "thinking", ToolCalls: calls}}
+	m := agentModelOverride(WithChatInvoker(context.Background(), inv), AgentParam{ModelID: "m"})
+	msg, err := m.Generate(context.Background(), []*schema.Message{schema.UserMessage("q")})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if msg.Content != "thinking" || len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Name != "echo" {
+		t.Errorf("message = %+v", msg)
+	}
+	if inv.captured.ModelName != "m" || len(inv.captured.Messages) != 1 {
+		t.Errorf("request = %+v", inv.captured)
+	}
+}
+
+func TestMockableTools(t *testing.T) {
+	base := &echoTool{}
+	tools := []einotool.BaseTool{base}
+	if got := mockableTools(context.Background(), tools); got[0] != einotool.BaseTool(base) {
+		t.Fatal("tools should be unchanged without WithToolMocker")
+	}
+
+	ctx := WithToolMocker(context.Background(), func(_ context.Context, name, args string) (string, bool, error) {
+		if args == `{"mock":true}` {
+			return "mocked " + name, true, nil
+		}
+		return "", false, nil
+	})
+	wrapped := mockableTools(ctx, tools)[0].(einotool.InvokableTool)
+	if out, err := wrapped.InvokableRun(ctx, `{"mock":true}`); err != nil || out != "mocked echo" {
+		t.Errorf("mocked call = %q, %v", out, err)
+	}
+	if out, err := wrapped.InvokableRun(ctx, `{"mock":false}`); err != nil || out != `{"mock":false}` {
+		t.Errorf("pass-through call = %q, %v", out, err)
+	}
+	if base.calls != 1 {
+		t.Errorf("real tool calls = %d, want 1", base.calls)
+	}
+}
@@ -51,6 +51,16 @@ func New(name string, params map[string]any) (Component, error) {
 	return f(params)
 }
 
+// IsRegistered reports whether name (case-insensitive) has a factory.
+// The DSL validator uses it to flag unknown component names before a
+// canvas is published.
+func IsRegistered(name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
 // RegisteredNames returns the sorted list of registered component names.
 // Used for diagnostics and the API 500 path "list available components".
 func RegisteredNames() []string {
@@ -192,6 +192,47 @@ func TestLLM_Invoke_OutputStructure_RetryStillFails(t *testing.T) {
 	}
 }
 
+// TestMatchOutputStructure_JSONSchema: a full JSON schema is validated
+// on property types, not just key presence.
+func TestMatchOutputStructure_JSONSchema(t *testing.T) {
+	expected := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"age": map[string]any{"type": "integer"},
+		},
+		"required": []any{"age"},
+	}
+	if _, ok := matchOutputStructure(`{"age":"thirty"}`, expected); ok {
+		t.Fatalf("expected a type mismatch to fail validation")
+	}
+	if _, ok := matchOutputStructure(`{"age":30}`, expected); !ok {
+		t.Fatalf("expected a valid object to match")
+	}
+}
+
+// TestLLM_Invoke_OutputStructure_ForwardsResponseSchema: the derived
+// schema reaches the invoker so native drivers can enforce it.
+func TestLLM_Invoke_OutputStructure_ForwardsResponseSchema(t *testing.T) {
+	stub := &stubInvoker{resp: &ChatInvokeResponse{Content: `{"name":"Alice"}`, Model: "echo"}}
+	withStubInvoker(t, stub)
+
+	c := NewLLMComponent(LLMParam{
+		ModelID:         "echo",
+		OutputStructure: map[string]any{"name": map[string]any{"type": "string"}},
+	})
+	if _, err := c.Invoke(context.Background(), map[string]any{"user_prompt": "who?"}); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	rs := stub.captured.ResponseSchema
+	if rs == nil {
+		t.Fatalf("ResponseSchema not forwarded")
+	}
+	props := rs.Schema["properties"].(map[string]any)
+	if props["name"].(map[string]any)["type"] != "string" {
+		t.Errorf("properties=%v, want typed name", props)
+	}
+}
+
 // callCountingInvoker is a test-only ChatInvoker that returns
 // pre-programmed responses in sequence and counts invocations.
 type callCountingInvoker struct {
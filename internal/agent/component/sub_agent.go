@@ -0,0 +1,306 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package component — SubAgent (T3).
+//
+// SubAgent runs another published canvas as a single node of the
+// current canvas. The child run receives the resolved `query` plus the
+// mapped `inputs` (surfaced by the child's Begin node), and its answer
+// becomes this node's `response` output.
+//
+// The child is pinned to `version_id` so publishing a new child
+// version never changes a parent's behaviour; `use_latest` opts into
+// tracking the newest published version instead.
+//
+// Execution goes through the SubAgentRunner seam so the component
+// package does not import internal/service; cmd/server_main.go installs
+// the AgentService at boot. The runner streams the child's node events
+// into the parent run nested under this node, and stops the child when
+// the parent run is cancelled.
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"ragflow/internal/agent/runtime"
+)
+
+const componentNameSubAgent = "SubAgent"
+
+// maxSubAgentDepth caps how many canvases a single call path may nest,
+// counting the outermost canvas.
+const maxSubAgentDepth = 5
+
+// ErrSubAgentRunnerMissing is returned when no SubAgentRunner has been
+// installed (unit tests, stand-alone tools).
+var ErrSubAgentRunnerMissing = errors.New(
+	"component: sub-agent runner not wired",
+)
+
+// ErrSubAgentCycle is returned when a SubAgent would invoke a canvas
+// that is already running on the same call path, or when the call path
+// exceeds maxSubAgentDepth.
+var ErrSubAgentCycle = errors.New("component: recursive sub-agent invocation")
+
+// SubAgentRunner runs a published canvas version on behalf of a
+// SubAgent node. versionID is empty only when the node opted into the
+// latest published version. The returned map carries at least
+// `answer`; `reference`, `version_id` and `session_id` are optional.
+//
+// *service.AgentService implements it directly.
+type SubAgentRunner interface {
+	RunSubAgent(ctx context.Context, userID, canvasID, versionID, query string, inputs map[string]any) (map[string]any, error)
+}
+
+var (
+	subAgentRunnerMu   sync.RWMutex
+	subAgentRunnerImpl SubAgentRunner = stubSubAgentRunner{}
+)
+
+// SetSubAgentRunner installs the runner. Passing nil reverts to the
+// default stub.
+func SetSubAgentRunner(r SubAgentRunner) {
+	subAgentRunnerMu.Lock()
+	defer subAgentRunnerMu.Unlock()
+	if r == nil {
+		subAgentRunnerImpl = stubSubAgentRunner{}
+		return
+	}
+	subAgentRunnerImpl = r
+}
+
+func getSubAgentRunner() SubAgentRunner {
+	subAgentRunnerMu.RLock()
+	defer subAgentRunnerMu.RUnlock()
+	return subAgentRunnerImpl
+}
+
+type stubSubAgentRunner struct{}
+
+func (stubSubAgentRunner) RunSubAgent(context.Context, string, string, string, string, map[string]any) (map[string]any, error) {
+	return nil, ErrSubAgentRunnerMissing
+}
+
+// subAgentParam is the static DSL param surface.
+type subAgentParam struct {
+	CanvasID  string         `json:"canvas_id"`
+	VersionID string         `json:"version_id"`
+	UseLatest bool           `json:"use_latest"`
+	Query     string         `json:"query"`
+	Inputs    map[string]any `json:"inputs"`
+}
+
+// Update copies a fresh params map into the receiver.
+func (p *subAgentParam) Update(conf map[string]any) error {
+	if conf == nil {
+		conf = map[string]any{}
+	}
+	if v, ok := stringFrom(conf, "canvas_id"); ok {
+		p.CanvasID = v
+	}
+	if v, ok := stringFrom(conf, "version_id"); ok {
+		p.VersionID = v
+	}
+	if v, ok := boolFrom(conf, "use_latest"); ok {
+		p.UseLatest = v
+	}
+	if v, ok := stringFrom(conf, "query"); ok {
+		p.Query = v
+	}
+	if v, ok := mapFrom(conf, "inputs"); ok {
+		p.Inputs = v
+	}
+	return nil
+}
+
+// Check validates the param. A version must be pinned unless the node
+// explicitly tracks the latest published version.
+func (p *subAgentParam) Check() error {
+	if strings.TrimSpace(p.CanvasID) == "" {
+		return &ParamError{Field: "canvas_id", Reason: "must not be empty"}
+	}
+	if !p.UseLatest && strings.TrimSpace(p.VersionID) == "" {
+		return &ParamError{Field: "version_id", Reason: "must pin a published version (or set use_latest)"}
+	}
+	return nil
+}
+
+// AsDict returns the param as a plain map.
+func (p *subAgentParam) AsDict() map[string]any {
+	return map[string]any{
+		"canvas_id":  p.CanvasID,
+		"version_id": p.VersionID,
+		"use_latest": p.UseLatest,
+		"query":      p.Query,
+		"inputs":     p.Inputs,
+	}
+}
+
+// SubAgentComponent invokes another canvas as a node.
+type SubAgentComponent struct {
+	name  string
+	param subAgentParam
+}
+
+// NewSubAgentComponent builds a SubAgent from a DSL params map.
+func NewSubAgentComponent(params map[string]any) (Component, error) {
+	p := &subAgentParam{}
+	if err := p.Update(params); err != nil {
+		return nil, fmt.Errorf("SubAgent: param update: %w", err)
+	}
+	if err := p.Check(); err != nil {
+		return nil, fmt.Errorf("SubAgent: param check: %w", err)
+	}
+	return &SubAgentComponent{name: componentNameSubAgent, param: *p}, nil
+}
+
+// Name returns the registered component name.
+func (c *SubAgentComponent) Name() string { return c.name }
+
+// Invoke resolves the query and input mapping against the parent state
+// and runs the child canvas. The child answer is exposed as `response`
+// rather than `content` / `answer`, which the run's final-answer
+// extraction scans and would then pick up from this node. A `query` input overrides the static
+// param; with neither set the parent's sys.query is forwarded.
+func (c *SubAgentComponent) Invoke(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	state, _, err := runtime.GetStateFromContext[*runtime.CanvasState](ctx)
+	if err != nil {
+		return nil, fmt.Errorf("SubAgent: %w", err)
+	}
+	if state == nil {
+		return nil, errors.New("SubAgent: nil canvas state")
+	}
+
+	userID, _ := state.Sys["user_id"].(string)
+	if userID == "" {
+		return nil, errors.New("SubAgent: user_id missing from canvas state (state.Sys[\"user_id\"])")
+	}
+
+	chain := runtime.CanvasCallChain(ctx)
+	if slices.Contains(chain, c.param.CanvasID) {
+		return nil, fmt.Errorf("SubAgent: canvas %q is already running on this call path (%s): %w",
+			c.param.CanvasID, strings.Join(chain, " -> "), ErrSubAgentCycle)
+	}
+	if len(chain) >= maxSubAgentDepth {
+		return nil, fmt.Errorf("SubAgent: nesting depth %d exceeds the limit of %d: %w",
+			len(chain)+1, maxSubAgentDepth, ErrSubAgentCycle)
+	}
+
+	query := c.param.Query
+	if v, ok := stringFrom(inputs, "query"); ok && v != "" {
+		query = v
+	}
+	if query == "" {
+		query = stringFromStateSys(state, "query")
+	} else if query, err = runtime.ResolveTemplate(query, state); err != nil {
+		return nil, fmt.Errorf("SubAgent: resolve query template: %w", err)
+	}
+
+	childInputs := make(map[string]any, len(c.param.Inputs))
+	for key, raw := range c.param.Inputs {
+		v, err := resolveSubAgentInput(raw, state)
+		if err != nil {
+			return nil, fmt.Errorf("SubAgent: resolve input %q: %w", key, err)
+		}
+		childInputs[key] = v
+	}
+
+	versionID := c.param.VersionID
+	if c.param.UseLatest {
+		versionID = ""
+	}
+	out, err := getSubAgentRunner().RunSubAgent(ctx, userID, c.param.CanvasID, versionID, query, childInputs)
+	if err != nil {
+		return nil, fmt.Errorf("SubAgent: %w", err)
+	}
+	if out == nil {
+		out = map[string]any{}
+	}
+	answer, _ := out["answer"].(string)
+	result := map[string]any{
+		"response":  answer,
+		"canvas_id": c.param.CanvasID,
+	}
+	for _, key := range []string{"reference", "version_id", "session_id"} {
+		if v, ok := out[key]; ok {
+			result[key] = v
+		}
+	}
+	return result, nil
+}
+
+// resolveSubAgentInput resolves one input mapping value. A value that
+// is exactly one {{ref}} keeps the referenced value's type (lists,
+// maps); other strings are rendered as templates; non-strings pass
+// through unchanged.
+func resolveSubAgentInput(raw any, state *runtime.CanvasState) (any, error) {
+	s, ok := raw.(string)
+	if !ok {
+		return raw, nil
+	}
+	trimmed := strings.TrimSpace(s)
+	if m := runtime.VarRefPattern.FindStringSubmatch(trimmed); m != nil && m[0] == trimmed {
+		v, err := state.GetVar(m[1])
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, fmt.Errorf("canvas: unresolved reference %q", m[1])
+		}
+		return v, nil
+	}
+	return runtime.ResolveTemplate(s, state)
+}
+
+// Stream mirrors Invoke; the child's intermediate events are streamed
+// by the runner, not through this channel.
+func (c *SubAgentComponent) Stream(ctx context.Context, inputs map[string]any) (<-chan map[string]any, error) {
+	out, err := c.Invoke(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan map[string]any, 1)
+	ch <- out
+	close(ch)
+	return ch, nil
+}
+
+// Inputs returns parameter metadata.
+func (c *SubAgentComponent) Inputs() map[string]string {
+	return map[string]string{
+		"query": "Override: query sent to the child canvas (otherwise the static param, else the parent's sys.query).",
+	}
+}
+
+// Outputs returns the response surface.
+func (c *SubAgentComponent) Outputs() map[string]string {
+	return map[string]string{
+		"response":   "The child canvas's answer.",
+		"reference":  "References collected by the child run, if any.",
+		"canvas_id":  "The invoked child canvas id.",
+		"version_id": "The child canvas version that ran.",
+		"session_id": "The child run's session id.",
+	}
+}
+
+func init() {
+	Register(componentNameSubAgent, NewSubAgentComponent)
+}
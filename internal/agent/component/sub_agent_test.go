@@ -0,0 +1,166 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/agent/runtime"
+)
+
+type fakeSubAgentRunner struct {
+	userID, canvasID, versionID, query string
+	inputs                             map[string]any
+}
+
+func (f *fakeSubAgentRunner) RunSubAgent(_ context.Context, userID, canvasID, versionID, query string, inputs map[string]any) (map[string]any, error) {
+	f.userID, f.canvasID, f.versionID, f.query, f.inputs = userID, canvasID, versionID, query, inputs
+	return map[string]any{"answer": "child answer", "version_id": "v-child", "session_id": "s-child"}, nil
+}
+
+func newSubAgentTestState() *runtime.CanvasState {
+	state := canvas.NewCanvasState("run-1", "task-1")
+	state.Sys["user_id"] = "user-1"
+	state.Sys["query"] = "parent question"
+	state.SetVar("begin_0", "topic", "rivers")
+	state.SetVar("begin_0", "tags", []any{"a", "b"})
+	return state
+}
+
+// TestSubAgent_RequiresPinnedVersion: the version must be pinned
+// unless the node opts into use_latest.
+func TestSubAgent_RequiresPinnedVersion(t *testing.T) {
+	if _, err := NewSubAgentComponent(map[string]any{"canvas_id": "child"}); err == nil {
+		t.Fatal("expected an error without version_id")
+	}
+	if _, err := NewSubAgentComponent(map[string]any{"version_id": "v1"}); err == nil {
+		t.Fatal("expected an error without canvas_id")
+	}
+	if _, err := NewSubAgentComponent(map[string]any{"canvas_id": "child", "use_latest": true}); err != nil {
+		t.Fatalf("use_latest without version_id: %v", err)
+	}
+}
+
+// TestSubAgent_ResolvesQueryAndInputs walks the happy path: the query
+// template and input mapping resolve against the parent state, a
+// single-ref input keeps its type, and the child answer becomes the
+// node's response.
+func TestSubAgent_ResolvesQueryAndInputs(t *testing.T) {
+	runner := &fakeSubAgentRunner{}
+	SetSubAgentRunner(runner)
+	defer SetSubAgentRunner(nil)
+
+	c, err := NewSubAgentComponent(map[string]any{
+		"canvas_id":  "child",
+		"version_id": "v-child",
+		"query":      "About {{begin_0@topic}}",
+		"inputs": map[string]any{
+			"topic": "{{begin_0@topic}}",
+			"tags":  "{{begin_0@tags}}",
+			"limit": float64(3),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSubAgentComponent: %v", err)
+	}
+	out, err := c.Invoke(runtime.WithState(context.Background(), newSubAgentTestState()), nil)
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if runner.userID != "user-1" || runner.canvasID != "child" || runner.versionID != "v-child" {
+		t.Errorf("runner got user=%q canvas=%q version=%q", runner.userID, runner.canvasID, runner.versionID)
+	}
+	if runner.query != "About rivers" {
+		t.Errorf("query = %q, want %q", runner.query, "About rivers")
+	}
+	if tags, ok := runner.inputs["tags"].([]any); !ok || len(tags) != 2 {
+		t.Errorf("inputs[tags] = %#v, want the referenced list", runner.inputs["tags"])
+	}
+	if runner.inputs["topic"] != "rivers" || runner.inputs["limit"] != float64(3) {
+		t.Errorf("inputs = %#v", runner.inputs)
+	}
+	if out["response"] != "child answer" || out["version_id"] != "v-child" || out["session_id"] != "s-child" {
+		t.Errorf("outputs = %#v", out)
+	}
+}
+
+// TestSubAgent_DefaultsToParentQueryAndLatest: without a query param
+// the parent's sys.query is forwarded; use_latest leaves the version
+// for the runner to resolve.
+func TestSubAgent_DefaultsToParentQueryAndLatest(t *testing.T) {
+	runner := &fakeSubAgentRunner{}
+	SetSubAgentRunner(runner)
+	defer SetSubAgentRunner(nil)
+
+	c, err := NewSubAgentComponent(map[string]any{"canvas_id": "child", "version_id": "v-old", "use_latest": true})
+	if err != nil {
+		t.Fatalf("NewSubAgentComponent: %v", err)
+	}
+	if _, err := c.Invoke(runtime.WithState(context.Background(), newSubAgentTestState()), nil); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if runner.query != "parent question" || runner.versionID != "" {
+		t.Errorf("runner got query=%q version=%q", runner.query, runner.versionID)
+	}
+}
+
+// TestSubAgent_RejectsCycles: a canvas already on the call path, or a
+// path at the depth limit, is refused before the runner is called.
+func TestSubAgent_RejectsCycles(t *testing.T) {
+	runner := &fakeSubAgentRunner{}
+	SetSubAgentRunner(runner)
+	defer SetSubAgentRunner(nil)
+
+	c, err := NewSubAgentComponent(map[string]any{"canvas_id": "child", "version_id": "v1"})
+	if err != nil {
+		t.Fatalf("NewSubAgentComponent: %v", err)
+	}
+	ctx := runtime.WithState(context.Background(), newSubAgentTestState())
+
+	cyclic := runtime.WithCanvasCall(runtime.WithCanvasCall(ctx, "child"), "parent")
+	if _, err := c.Invoke(cyclic, nil); !errors.Is(err, ErrSubAgentCycle) {
+		t.Errorf("cycle: got %v, want ErrSubAgentCycle", err)
+	}
+
+	deep := ctx
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		deep = runtime.WithCanvasCall(deep, id)
+	}
+	if _, err := c.Invoke(deep, nil); !errors.Is(err, ErrSubAgentCycle) {
+		t.Errorf("depth: got %v, want ErrSubAgentCycle", err)
+	}
+	if runner.canvasID != "" {
+		t.Errorf("runner was called for a rejected invocation")
+	}
+}
+
+// TestSubAgent_StubRunnerErrors: without a wired runner Invoke surfaces
+// ErrSubAgentRunnerMissing.
+func TestSubAgent_StubRunnerErrors(t *testing.T) {
+	SetSubAgentRunner(nil)
+	c, err := NewSubAgentComponent(map[string]any{"canvas_id": "child", "version_id": "v1"})
+	if err != nil {
+		t.Fatalf("NewSubAgentComponent: %v", err)
+	}
+	_, err = c.Invoke(runtime.WithState(context.Background(), newSubAgentTestState()), nil)
+	if !errors.Is(err, ErrSubAgentRunnerMissing) {
+		t.Errorf("got %v, want ErrSubAgentRunnerMissing", err)
+	}
+}
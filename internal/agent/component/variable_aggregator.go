@@ -18,7 +18,8 @@
 //
 // For each "group" in its param, VariableAggregator walks a list of
 // variable selectors and picks the first one whose resolved value is
-// truthy. The picked value is exposed at outputs[<group_name>].
+// truthy. The picked value is exposed at outputs[<group_name>]. A group
+// with a declared "type" fails on a candidate of another type.
 //
 // Mirrors agent/component/variable_aggregator.py. The Python implementation
 // also records each group's variables list under the synthetic input key
@@ -29,9 +30,11 @@ package component
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"ragflow/internal/agent/runtime"
+	"ragflow/internal/agent/vartype"
 )
 
 const componentNameVariableAggregator = "VariableAggregator"
@@ -219,9 +222,19 @@ func (v *VariableAggregatorComponent) Invoke(ctx context.Context, inputs map[str
 				continue
 			}
 			val, err := state.GetVar(ref)
+			if errors.Is(err, runtime.ErrVarTypeMismatch) {
+				return nil, fmt.Errorf("VariableAggregator: group %q: %w", gname, err)
+			}
 			if err != nil || !isTruthy(val) {
 				continue
 			}
+			// A typed group rejects a candidate of the wrong type
+			// instead of handing it downstream.
+			if t, _ := vartype.Parse(g["type"]); t != nil {
+				if err := t.Check(val); err != nil {
+					return nil, fmt.Errorf("VariableAggregator: group %q is declared %s but %q does not match: %w", gname, t, ref, err)
+				}
+			}
 			out[gname] = val
 			break
 		}
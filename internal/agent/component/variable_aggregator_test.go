@@ -18,6 +18,7 @@ package component
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"ragflow/internal/agent/canvas"
@@ -212,3 +213,33 @@ func TestVariableAggregator_Registered(t *testing.T) {
 		t.Errorf("Name()=%q, want VariableAggregator", c.Name())
 	}
 }
+
+// TestVariableAggregator_TypedGroup: a group with a declared type fails
+// the node when the picked value does not match it.
+func TestVariableAggregator_TypedGroup(t *testing.T) {
+	state := canvas.NewCanvasState("run-typed", "task-typed")
+	state.Outputs["cpn_1"] = map[string]any{"y": "not a number"}
+	state.Outputs["cpn_2"] = map[string]any{"y": 42}
+	ctx := canvas.WithState(context.Background(), state)
+
+	group := func(ref string) []map[string]any {
+		return []map[string]any{{
+			"group_name": "score",
+			"type":       "number",
+			"variables":  []any{map[string]any{"value": ref}},
+		}}
+	}
+	c, err := NewVariableAggregatorComponent(map[string]any{"groups": group("cpn_2@y")})
+	if err != nil {
+		t.Fatalf("NewVariableAggregatorComponent: %v", err)
+	}
+	if out, err := c.Invoke(ctx, nil); err != nil || out["score"] != 42 {
+		t.Fatalf("typed group = %v, %v", out, err)
+	}
+
+	c, _ = NewVariableAggregatorComponent(map[string]any{"groups": group("cpn_1@y")})
+	_, err = c.Invoke(ctx, nil)
+	if err == nil || !strings.Contains(err.Error(), `group "score" is declared number`) {
+		t.Fatalf("Invoke error = %v, want a group type mismatch", err)
+	}
+}
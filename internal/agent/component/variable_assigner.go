@@ -34,7 +34,9 @@
 // to their respective CanvasState maps directly.
 //
 // On "ERROR:..." returns the operator result is exposed at
-// outputs["errors"] and the state bucket is left unchanged.
+// outputs["errors"] and the state bucket is left unchanged. A result
+// that violates the variable's declared type (runtime/vartypes.go)
+// fails the node instead.
 package component
 
 import (
@@ -177,6 +179,9 @@ func (v *VariableAssignerComponent) Invoke(ctx context.Context, inputs map[strin
 			errors = append(errors, fmt.Sprintf("variables[%d] %s: %s", i, ref, opErr))
 			continue
 		}
+		if err := state.CheckVarWrite(ref, newVal); err != nil {
+			return nil, fmt.Errorf("VariableAssigner: variables[%d] write %q: %w", i, ref, err)
+		}
 		if err := writeVar(state, ref, newVal); err != nil {
 			return nil, fmt.Errorf("VariableAssigner: variables[%d] write %q: %w", i, ref, err)
 		}
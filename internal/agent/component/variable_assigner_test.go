@@ -18,10 +18,13 @@ package component
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"ragflow/internal/agent/canvas"
+	"ragflow/internal/agent/runtime"
 )
 
 // TestVariableAssigner_Append: list append, verify state updated.
@@ -245,3 +248,37 @@ func TestVariableAssigner_Registered(t *testing.T) {
 		t.Errorf("Name()=%q, want VariableAssigner", c.Name())
 	}
 }
+
+// TestVariableAssigner_RejectsWriteAgainstDeclaredType: a write that
+// breaks the variable's declared type fails the node and leaves the
+// state unchanged.
+func TestVariableAssigner_RejectsWriteAgainstDeclaredType(t *testing.T) {
+	state := canvas.NewCanvasState("run-typed", "task-typed")
+	state.Env["tags"] = []any{"a"}
+	state.Outputs["llm_0"] = map[string]any{"content": "b, c"}
+	state.SetVarTypes(canvas.DeclaredVarTypes(&canvas.Canvas{
+		Variables: map[string]any{"tags": map[string]any{"type": "Array<string>"}},
+	}))
+	ctx := canvas.WithState(context.Background(), state)
+
+	c, err := NewVariableAssignerComponent(map[string]any{"variables": []map[string]any{
+		{"variable": "env.tags", "operator": "overwrite", "parameter": "{{llm_0@content}}"},
+	}})
+	if err != nil {
+		t.Fatalf("NewVariableAssignerComponent: %v", err)
+	}
+	_, err = c.Invoke(ctx, nil)
+	if !errors.Is(err, runtime.ErrVarTypeMismatch) || !strings.Contains(err.Error(), `"env.tags"`) {
+		t.Fatalf("Invoke error = %v, want a type mismatch on env.tags", err)
+	}
+	if got := state.Env["tags"]; !reflect.DeepEqual(got, []any{"a"}) {
+		t.Fatalf("env.tags = %v, want it unchanged", got)
+	}
+
+	c, _ = NewVariableAssignerComponent(map[string]any{"variables": []map[string]any{
+		{"variable": "env.tags", "operator": "append", "parameter": "b"},
+	}})
+	if _, err := c.Invoke(ctx, nil); err != nil {
+		t.Fatalf("typed append: %v", err)
+	}
+}
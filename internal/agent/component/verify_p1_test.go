@@ -44,8 +44,8 @@ func TestVerifyRegistration_P1(t *testing.T) {
 	if len(missing) > 0 {
 		t.Fatalf("missing P0/P1 components: %v (have %d: %v)", missing, len(names), names)
 	}
-	if got := len(names); got < 12 || got > 30 {
-		t.Errorf("expected 12-30 registered (current plan scope + v1 stubs), got %d: %v", got, names)
+	if got := len(names); got < 12 || got > 40 {
+		t.Errorf("expected 12-40 registered (current plan scope + v1 stubs), got %d: %v", got, names)
 	}
 
 	// ExitLoop must NOT be in the registry (legacy compat lives at
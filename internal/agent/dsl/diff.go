@@ -0,0 +1,419 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Change kinds reported by Diff.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeRenamed  = "renamed"
+	ChangeModified = "modified"
+)
+
+// maxTextDiffLines bounds the line diff of a changed string; longer
+// values are reported as a plain old/new replacement.
+const maxTextDiffLines = 2000
+
+// DSLDiff is the structural difference between two canvas DSLs.
+type DSLDiff struct {
+	Components []ComponentChange `json:"components"`
+	Edges      EdgeChanges       `json:"edges"`
+	Globals    []ValueChange     `json:"globals"`
+	Variables  []ValueChange     `json:"variables"`
+}
+
+// Empty reports whether the two DSLs are structurally identical.
+func (d *DSLDiff) Empty() bool {
+	return len(d.Components) == 0 && len(d.Edges.Added) == 0 && len(d.Edges.Removed) == 0 &&
+		len(d.Globals) == 0 && len(d.Variables) == 0
+}
+
+// ComponentChange describes one added, removed, renamed or modified
+// component. A rename is either a new display name on the same id
+// (OldName/NewName) or the same component moved to a new id (OldID);
+// a renamed component may also carry param changes.
+type ComponentChange struct {
+	Kind          string        `json:"kind"`
+	ComponentID   string        `json:"component_id"`
+	OldID         string        `json:"old_id,omitempty"`
+	ComponentName string        `json:"component_name"`
+	OldName       string        `json:"old_name,omitempty"`
+	NewName       string        `json:"new_name,omitempty"`
+	Params        []ValueChange `json:"params,omitempty"`
+}
+
+// ValueChange is one changed key. Path is dotted with [i] for list
+// elements. TextDiff is a line diff ("-"/"+"/" " prefixed lines) when
+// both sides are multi-line strings, such as prompts.
+type ValueChange struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	Old      any    `json:"old,omitempty"`
+	New      any    `json:"new,omitempty"`
+	TextDiff string `json:"text_diff,omitempty"`
+}
+
+// Edge is a downstream link between two components.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// EdgeChanges lists the rewired links. Links of a component that only
+// moved to a new id are compared under the new id.
+type EdgeChanges struct {
+	Added   []Edge `json:"added"`
+	Removed []Edge `json:"removed"`
+}
+
+type diffNode struct {
+	name       string
+	label      string
+	params     map[string]any
+	downstream []string
+}
+
+// Diff compares two DSLs (either shape NormalizeForCanvas accepts) and
+// reports what changed from from to to. A nil from diffs against an
+// empty canvas, so everything in to is reported as added.
+func Diff(from, to map[string]any) *DSLDiff {
+	oldNodes, newNodes := diffNodes(from), diffNodes(to)
+	d := &DSLDiff{
+		Components: []ComponentChange{},
+		Edges:      EdgeChanges{Added: []Edge{}, Removed: []Edge{}},
+	}
+
+	var removed, added []string
+	for _, id := range sortedNodeIDs(oldNodes) {
+		if _, ok := newNodes[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	for _, id := range sortedNodeIDs(newNodes) {
+		if _, ok := oldNodes[id]; !ok {
+			added = append(added, id)
+		}
+	}
+
+	// A removed and an added component with the same type and params
+	// are one component under a new id.
+	moved := map[string]string{} // old id → new id
+	for _, oldID := range removed {
+		for _, newID := range added {
+			if _, taken := movedTarget(moved, newID); taken {
+				continue
+			}
+			o, n := oldNodes[oldID], newNodes[newID]
+			if o.name == n.name && reflect.DeepEqual(normaliseJSON(o.params), normaliseJSON(n.params)) {
+				moved[oldID] = newID
+				break
+			}
+		}
+	}
+
+	for _, id := range removed {
+		if _, ok := moved[id]; ok {
+			continue
+		}
+		o := oldNodes[id]
+		d.Components = append(d.Components, ComponentChange{Kind: ChangeRemoved, ComponentID: id, ComponentName: o.name, OldName: o.label})
+	}
+	for _, id := range added {
+		n := newNodes[id]
+		if oldID, ok := movedTarget(moved, id); ok {
+			d.Components = append(d.Components, ComponentChange{
+				Kind: ChangeRenamed, ComponentID: id, OldID: oldID, ComponentName: n.name,
+				OldName: oldNodes[oldID].label, NewName: n.label,
+			})
+			continue
+		}
+		d.Components = append(d.Components, ComponentChange{Kind: ChangeAdded, ComponentID: id, ComponentName: n.name, NewName: n.label})
+	}
+	for _, id := range sortedNodeIDs(newNodes) {
+		o, ok := oldNodes[id]
+		if !ok {
+			continue
+		}
+		n := newNodes[id]
+		change := ComponentChange{Kind: ChangeModified, ComponentID: id, ComponentName: n.name}
+		if o.name != n.name {
+			change.Params = append(change.Params, ValueChange{Path: "component_name", Kind: ChangeModified, Old: o.name, New: n.name})
+		}
+		change.Params = append(change.Params, diffValues("", o.params, n.params)...)
+		if o.label != n.label {
+			change.Kind = ChangeRenamed
+			change.OldName, change.NewName = o.label, n.label
+		}
+		if change.Kind == ChangeRenamed || len(change.Params) > 0 {
+			d.Components = append(d.Components, change)
+		}
+	}
+	sort.SliceStable(d.Components, func(i, j int) bool {
+		return d.Components[i].ComponentID < d.Components[j].ComponentID
+	})
+
+	oldEdges := edgeSet(oldNodes, moved)
+	newEdges := edgeSet(newNodes, nil)
+	for _, e := range sortedEdges(newEdges) {
+		if !oldEdges[e] {
+			d.Edges.Added = append(d.Edges.Added, e)
+		}
+	}
+	for _, e := range sortedEdges(oldEdges) {
+		if !newEdges[e] {
+			d.Edges.Removed = append(d.Edges.Removed, e)
+		}
+	}
+
+	d.Globals = diffMaps(from, to, "globals")
+	d.Variables = diffMaps(from, to, "variables")
+	return d
+}
+
+func diffNodes(dsl map[string]any) map[string]*diffNode {
+	out := map[string]*diffNode{}
+	comps, _ := dsl["components"].(map[string]any)
+	for id, raw := range comps {
+		comp, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, params, downstream := extractComponent(comp)
+		if len(downstream) == 0 {
+			downstream = stringList(comp["downstream"])
+		}
+		out[id] = &diffNode{name: name, params: params, downstream: dedupe(downstream)}
+	}
+	if graph, _ := dsl["graph"].(map[string]any); graph != nil {
+		nodes, _ := graph["nodes"].([]any)
+		for _, raw := range nodes {
+			node, _ := raw.(map[string]any)
+			id, _ := node["id"].(string)
+			n := out[id]
+			if n == nil {
+				continue
+			}
+			if data, _ := node["data"].(map[string]any); data != nil {
+				n.label, _ = data["name"].(string)
+			}
+		}
+	}
+	return out
+}
+
+func movedTarget(moved map[string]string, newID string) (string, bool) {
+	for oldID, id := range moved {
+		if id == newID {
+			return oldID, true
+		}
+	}
+	return "", false
+}
+
+func sortedNodeIDs(m map[string]*diffNode) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// edgeSet collects the downstream links, renaming ids through rename.
+func edgeSet(nodes map[string]*diffNode, rename map[string]string) map[Edge]bool {
+	mapID := func(id string) string {
+		if to, ok := rename[id]; ok {
+			return to
+		}
+		return id
+	}
+	out := map[Edge]bool{}
+	for id, n := range nodes {
+		for _, to := range n.downstream {
+			out[Edge{From: mapID(id), To: mapID(to)}] = true
+		}
+	}
+	return out
+}
+
+func sortedEdges(set map[Edge]bool) []Edge {
+	out := make([]Edge, 0, len(set))
+	for e := range set {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	return out
+}
+
+func diffMaps(from, to map[string]any, key string) []ValueChange {
+	o, _ := from[key].(map[string]any)
+	n, _ := to[key].(map[string]any)
+	changes := diffValues("", o, n)
+	if changes == nil {
+		return []ValueChange{}
+	}
+	return changes
+}
+
+// diffValues walks two JSON values and reports the leaves that differ.
+// Maps recurse by key and equal-length lists by index; anything else
+// that differs after JSON normalisation is one modification.
+func diffValues(path string, oldV, newV any) []ValueChange {
+	oldV, newV = normaliseJSON(oldV), normaliseJSON(newV)
+	if reflect.DeepEqual(oldV, newV) {
+		return nil
+	}
+	om, oldIsMap := oldV.(map[string]any)
+	nm, newIsMap := newV.(map[string]any)
+	if oldIsMap && newIsMap || path == "" {
+		keys := map[string]bool{}
+		for k := range om {
+			keys[k] = true
+		}
+		for k := range nm {
+			keys[k] = true
+		}
+		names := make([]string, 0, len(keys))
+		for k := range keys {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		var out []ValueChange
+		for _, k := range names {
+			p := joinPath(path, k)
+			ov, inOld := om[k]
+			nv, inNew := nm[k]
+			switch {
+			case !inOld:
+				out = append(out, ValueChange{Path: p, Kind: ChangeAdded, New: nv})
+			case !inNew:
+				out = append(out, ValueChange{Path: p, Kind: ChangeRemoved, Old: ov})
+			default:
+				out = append(out, diffValues(p, ov, nv)...)
+			}
+		}
+		return out
+	}
+	ol, oldIsList := oldV.([]any)
+	nl, newIsList := newV.([]any)
+	if oldIsList && newIsList && len(ol) == len(nl) {
+		var out []ValueChange
+		for i := range ol {
+			out = append(out, diffValues(fmt.Sprintf("%s[%d]", path, i), ol[i], nl[i])...)
+		}
+		return out
+	}
+	change := ValueChange{Path: path, Kind: ChangeModified, Old: oldV, New: newV}
+	if os, ok := oldV.(string); ok {
+		if ns, ok := newV.(string); ok && (strings.Contains(os, "\n") || strings.Contains(ns, "\n")) {
+			change.TextDiff = TextDiff(os, ns)
+		}
+	}
+	return []ValueChange{change}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// normaliseJSON round-trips v through JSON so numbers, typed slices
+// and typed maps compare the same way they were stored.
+func normaliseJSON(v any) any {
+	switch v.(type) {
+	case nil, string, bool, float64:
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// TextDiff returns a line diff of a and b: unchanged lines are
+// prefixed with "  ", removed lines with "- " and added lines with
+// "+ ". Inputs longer than maxTextDiffLines are shown as a full
+// replacement.
+func TextDiff(a, b string) string {
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+	var sb strings.Builder
+	if len(al) > maxTextDiffLines || len(bl) > maxTextDiffLines {
+		for _, l := range al {
+			sb.WriteString("- " + l + "\n")
+		}
+		for _, l := range bl {
+			sb.WriteString("+ " + l + "\n")
+		}
+		return sb.String()
+	}
+	// lcs[i][j] is the longest common subsequence of al[i:] and bl[j:].
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(al) && j < len(bl) {
+		switch {
+		case al[i] == bl[j]:
+			sb.WriteString("  " + al[i] + "\n")
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			sb.WriteString("- " + al[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + bl[j] + "\n")
+			j++
+		}
+	}
+	for ; i < len(al); i++ {
+		sb.WriteString("- " + al[i] + "\n")
+	}
+	for ; j < len(bl); j++ {
+		sb.WriteString("+ " + bl[j] + "\n")
+	}
+	return sb.String()
+}
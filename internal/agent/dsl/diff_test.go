@@ -0,0 +1,161 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package dsl
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func diffBase() map[string]any {
+	return map[string]any{
+		"components": map[string]any{
+			"begin": lintComponent("Begin", map[string]any{}, []string{"llm_0"}, nil),
+			"llm_0": lintComponent("LLM", map[string]any{
+				"llm_id":     "gpt",
+				"sys_prompt": "You are helpful.\nAnswer briefly.",
+				"prompts":    []any{map[string]any{"role": "user", "content": "{sys.query}"}},
+			}, []string{"message_0"}, []string{"begin"}),
+			"message_0": lintComponent("Message", map[string]any{"content": []any{"{llm_0@content}"}}, nil, []string{"llm_0"}),
+		},
+		"globals": map[string]any{"sys.query": "", "env.lang": "en"},
+		"graph": map[string]any{"nodes": []any{
+			map[string]any{"id": "llm_0", "data": map[string]any{"name": "Writer"}},
+		}},
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	if d := Diff(diffBase(), diffBase()); !d.Empty() {
+		t.Fatalf("diff of identical DSLs = %+v", d)
+	}
+}
+
+func TestDiff_ParamsEdgesAndGlobals(t *testing.T) {
+	to := diffBase()
+	comps := to["components"].(map[string]any)
+	llm := comps["llm_0"].(map[string]any)["obj"].(map[string]any)["params"].(map[string]any)
+	llm["sys_prompt"] = "You are helpful.\nAnswer in detail."
+	llm["temperature"] = 0.2
+	llm["prompts"].([]any)[0].(map[string]any)["content"] = "{sys.query}!"
+	comps["retrieval_0"] = lintComponent("Retrieval", map[string]any{"kb_ids": []any{"kb1"}}, []string{"message_0"}, []string{"begin"})
+	comps["begin"].(map[string]any)["downstream"] = []any{"retrieval_0"}
+	delete(to["globals"].(map[string]any), "env.lang")
+	to["globals"].(map[string]any)["env.tone"] = "formal"
+	to["graph"].(map[string]any)["nodes"].([]any)[0].(map[string]any)["data"].(map[string]any)["name"] = "Drafter"
+
+	d := Diff(diffBase(), to)
+
+	if len(d.Components) != 2 {
+		t.Fatalf("components = %+v, want llm_0 renamed and retrieval_0 added", d.Components)
+	}
+	llmChange, added := d.Components[0], d.Components[1]
+	if llmChange.ComponentID != "llm_0" || llmChange.Kind != ChangeRenamed || llmChange.OldName != "Writer" || llmChange.NewName != "Drafter" {
+		t.Errorf("llm change = %+v", llmChange)
+	}
+	paths := map[string]ValueChange{}
+	for _, p := range llmChange.Params {
+		paths[p.Path] = p
+	}
+	if p := paths["sys_prompt"]; p.TextDiff != "  You are helpful.\n- Answer briefly.\n+ Answer in detail.\n" {
+		t.Errorf("sys_prompt text diff = %q", p.TextDiff)
+	}
+	if p := paths["temperature"]; p.Kind != ChangeAdded || p.New != 0.2 {
+		t.Errorf("temperature change = %+v", p)
+	}
+	if p := paths["prompts[0].content"]; p.Old != "{sys.query}" || p.New != "{sys.query}!" {
+		t.Errorf("prompt change = %+v", p)
+	}
+	if added.ComponentID != "retrieval_0" || added.Kind != ChangeAdded || added.ComponentName != "Retrieval" {
+		t.Errorf("added = %+v", added)
+	}
+
+	wantAdded := []Edge{{"begin", "retrieval_0"}, {"retrieval_0", "message_0"}}
+	wantRemoved := []Edge{{"begin", "llm_0"}}
+	if !edgesEqual(d.Edges.Added, wantAdded) || !edgesEqual(d.Edges.Removed, wantRemoved) {
+		t.Errorf("edges = %+v, want added %v removed %v", d.Edges, wantAdded, wantRemoved)
+	}
+
+	if len(d.Globals) != 2 || d.Globals[0].Path != "env.lang" || d.Globals[0].Kind != ChangeRemoved ||
+		d.Globals[1].Path != "env.tone" || d.Globals[1].Kind != ChangeAdded {
+		t.Errorf("globals = %+v", d.Globals)
+	}
+}
+
+func TestDiff_MovedComponentIsRename(t *testing.T) {
+	to := diffBase()
+	comps := to["components"].(map[string]any)
+	comps["message_1"] = comps["message_0"]
+	delete(comps, "message_0")
+	comps["llm_0"].(map[string]any)["downstream"] = []any{"message_1"}
+
+	d := Diff(diffBase(), to)
+	if len(d.Components) != 1 {
+		t.Fatalf("components = %+v, want one rename", d.Components)
+	}
+	c := d.Components[0]
+	if c.Kind != ChangeRenamed || c.ComponentID != "message_1" || c.OldID != "message_0" {
+		t.Errorf("change = %+v", c)
+	}
+	if len(d.Edges.Added) != 0 || len(d.Edges.Removed) != 0 {
+		t.Errorf("edges of a moved component should not show as rewired: %+v", d.Edges)
+	}
+}
+
+func TestDiff_FromNothingAndFixture(t *testing.T) {
+	d := Diff(nil, diffBase())
+	if len(d.Components) != 3 || d.Components[0].Kind != ChangeAdded || len(d.Edges.Added) != 2 {
+		t.Errorf("diff from nil = %+v", d)
+	}
+
+	raw, err := os.ReadFile(filepath.Join("testdata", "all.json"))
+	if err != nil {
+		t.Fatalf("read all.json: %v", err)
+	}
+	var fixture, normalized map[string]any
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		t.Fatalf("parse all.json: %v", err)
+	}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		t.Fatalf("parse all.json: %v", err)
+	}
+	if d := Diff(fixture, NormalizeForCanvas(normalized)); len(d.Components) != 0 || len(d.Edges.Added) != 0 {
+		t.Errorf("normalising all.json should not change its structure: %+v", d.Components)
+	}
+}
+
+func TestTextDiff(t *testing.T) {
+	got := TextDiff("a\nb\nc", "a\nc\nd")
+	want := "  a\n- b\n  c\n+ d\n"
+	if got != want {
+		t.Errorf("TextDiff = %q, want %q", got, want)
+	}
+}
+
+func edgesEqual(a, b []Edge) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,766 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package dsl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ragflow/internal/agent/vartype"
+)
+
+// Severity classifies a Diagnostic. Errors describe DSLs that cannot
+// compile or will fail at run time; warnings describe DSLs that run
+// but probably do not do what the author meant.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic codes reported by Validate. They are part of the API
+// contract — the canvas editor keys its inline markers on them.
+const (
+	DiagMalformed         = "malformed_dsl"
+	DiagMissingBegin      = "missing_begin"
+	DiagEmptyName         = "empty_component_name"
+	DiagUnknownComponent  = "unknown_component"
+	DiagDanglingEdge      = "dangling_edge"
+	DiagDanglingReference = "dangling_reference"
+	DiagUnknownOutput     = "unknown_output"
+	DiagUnknownVariable   = "unknown_variable"
+	DiagUnreachable       = "unreachable_component"
+	DiagCycle             = "cycle"
+	DiagLoopNoTermination = "loop_without_termination"
+	DiagDanglingBranch    = "dangling_branch"
+	DiagTypeMismatch      = "type_mismatch"
+	DiagInvalidType       = "invalid_type"
+)
+
+// Diagnostic is one located finding from Validate. ComponentID is empty
+// for canvas-level findings; Field is a dotted / indexed path into the
+// component's params (e.g. "params.conditions[0].to").
+type Diagnostic struct {
+	Severity    Severity `json:"severity"`
+	Code        string   `json:"code"`
+	ComponentID string   `json:"component_id,omitempty"`
+	Field       string   `json:"field,omitempty"`
+	Message     string   `json:"message"`
+}
+
+// ValidateOptions tunes Validate. The dsl package cannot import the
+// component registry (the registry imports the runtime, which imports
+// everything else), so the caller supplies the name lookup.
+type ValidateOptions struct {
+	// KnownComponent reports whether a component_name can be built.
+	// Nil skips the unknown-component check.
+	KnownComponent func(name string) bool
+}
+
+// HasErrors reports whether any diagnostic has SeverityError.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// structuralNames are component names the canvas compiler handles
+// itself (sub-graph entry markers and legacy sentinels) rather than
+// through the component registry.
+var structuralNames = map[string]bool{
+	"loopitem": true,
+	"exitloop": true,
+}
+
+// bareRefKeys are params whose whole string value is a variable
+// reference without the {{ }} template braces.
+var bareRefKeys = map[string]bool{
+	"items_ref": true,
+	"cpn_id":    true,
+	"split_ref": true,
+	"ref":       true,
+	"variable":  true,
+	"query":     true,
+}
+
+var (
+	templateRefPattern = regexp.MustCompile(`\{+\s*([a-zA-Z:0-9_]+@[A-Za-z0-9_.-]+|sys\.[A-Za-z0-9_.]+|env\.[A-Za-z0-9_.]+)\s*\}+`)
+	bareRefPattern     = regexp.MustCompile(`^([a-zA-Z:0-9_]+@[A-Za-z0-9_.-]+|sys\.[A-Za-z0-9_.]+|env\.[A-Za-z0-9_.]+)$`)
+)
+
+// Validate statically checks a normalized DSL (the NormalizeForCanvas
+// shape persisted on user_canvas) and returns every problem it finds,
+// errors first. It covers the failures that otherwise only surface
+// from canvas.BuildWorkflow or mid-run: unknown component names,
+// edges and branches to missing components, {cpn@var} references to
+// missing components or outputs, nodes unreachable from Begin, cycles
+// outside a Loop, loops with no termination condition, malformed type
+// declarations, and declared type mismatches between a producer output
+// and the consumer input or VariableAssigner write (the vartype
+// declarations the runtime enforces).
+//
+// Validate never mutates dsl and never returns nil for a malformed
+// DSL — a missing components map is itself a diagnostic.
+func Validate(dsl map[string]any, opts ValidateOptions) []Diagnostic {
+	v := &validator{opts: opts, diags: []Diagnostic{}}
+	comps, ok := dsl["components"].(map[string]any)
+	if !ok || len(comps) == 0 {
+		v.add(SeverityError, DiagMalformed, "", "components", "DSL has no components")
+		return v.diags
+	}
+	v.load(dsl, comps)
+	v.checkNames()
+	v.checkEdges()
+	v.checkReachability()
+	v.checkCycles()
+	v.checkBranches()
+	v.checkLoops()
+	v.checkReferences()
+	v.checkTypes()
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		a, b := v.diags[i], v.diags[j]
+		if a.Severity != b.Severity {
+			return a.Severity == SeverityError
+		}
+		if a.ComponentID != b.ComponentID {
+			return a.ComponentID < b.ComponentID
+		}
+		return a.Field < b.Field
+	})
+	return v.diags
+}
+
+type lintNode struct {
+	id         string
+	name       string
+	params     map[string]any
+	downstream []string
+	upstream   []string
+	parent     string
+}
+
+type validator struct {
+	opts      ValidateOptions
+	diags     []Diagnostic
+	ids       []string
+	nodes     map[string]*lintNode
+	children  map[string][]string
+	globals   map[string]any
+	variables map[string]any
+}
+
+func (v *validator) add(sev Severity, code, cpnID, field, format string, args ...any) {
+	v.diags = append(v.diags, Diagnostic{
+		Severity:    sev,
+		Code:        code,
+		ComponentID: cpnID,
+		Field:       field,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) load(dsl map[string]any, comps map[string]any) {
+	v.nodes = make(map[string]*lintNode, len(comps))
+	v.children = map[string][]string{}
+	v.globals, _ = dsl["globals"].(map[string]any)
+	v.variables, _ = dsl["variables"].(map[string]any)
+	parents := buildParentMap(dsl)
+	for id, raw := range comps {
+		comp, ok := raw.(map[string]any)
+		if !ok {
+			v.add(SeverityError, DiagMalformed, id, "", "component %q is not an object", id)
+			continue
+		}
+		name, params, downstream := extractComponent(comp)
+		// NormalizeForCanvas emits flat []string edge lists, which
+		// extractComponent (written for decoded JSON) skips.
+		if len(downstream) == 0 {
+			downstream = stringList(comp["downstream"])
+		}
+		n := &lintNode{
+			id:         id,
+			name:       name,
+			params:     params,
+			downstream: dedupe(downstream),
+			upstream:   dedupe(stringList(comp["upstream"])),
+			parent:     parents[id],
+		}
+		if p, _ := comp["parent_id"].(string); p != "" {
+			n.parent = p
+		}
+		v.nodes[id] = n
+		v.ids = append(v.ids, id)
+	}
+	sort.Strings(v.ids)
+	for _, id := range v.ids {
+		if p := v.nodes[id].parent; p != "" {
+			v.children[p] = append(v.children[p], id)
+		}
+	}
+}
+
+func (v *validator) checkNames() {
+	begins := 0
+	for _, id := range v.ids {
+		n := v.nodes[id]
+		if n.name == "" {
+			v.add(SeverityError, DiagEmptyName, id, "obj.component_name", "component %q has an empty component_name", id)
+			continue
+		}
+		if strings.EqualFold(n.name, "Begin") {
+			begins++
+		}
+		if v.opts.KnownComponent == nil || structuralNames[strings.ToLower(n.name)] {
+			continue
+		}
+		if !v.opts.KnownComponent(n.name) {
+			v.add(SeverityError, DiagUnknownComponent, id, "obj.component_name", "unknown component %q", n.name)
+		}
+	}
+	if begins == 0 {
+		v.add(SeverityError, DiagMissingBegin, "", "components", "canvas has no Begin component")
+	}
+}
+
+func (v *validator) checkEdges() {
+	for _, id := range v.ids {
+		n := v.nodes[id]
+		for _, d := range n.downstream {
+			if _, ok := v.nodes[d]; !ok {
+				v.add(SeverityError, DiagDanglingEdge, id, "downstream", "downstream %q does not exist", d)
+			}
+		}
+		for _, u := range n.upstream {
+			if _, ok := v.nodes[u]; !ok {
+				v.add(SeverityError, DiagDanglingEdge, id, "upstream", "upstream %q does not exist", u)
+			}
+		}
+		if n.parent != "" {
+			if _, ok := v.nodes[n.parent]; !ok {
+				v.add(SeverityError, DiagDanglingEdge, id, "parent_id", "parent %q does not exist", n.parent)
+			}
+		}
+	}
+}
+
+// checkReachability walks downstream edges from every Begin. Entering
+// a Loop / Iteration / Parallel also enters its body, whose entry
+// marker (LoopItem / IterationItem) has no upstream of its own.
+func (v *validator) checkReachability() {
+	seen := map[string]bool{}
+	var queue []string
+	for _, id := range v.ids {
+		if strings.EqualFold(v.nodes[id].name, "Begin") {
+			seen[id] = true
+			queue = append(queue, id)
+		}
+	}
+	if len(queue) == 0 {
+		return
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		n := v.nodes[id]
+		next := append(append([]string{}, n.downstream...), v.children[id]...)
+		for _, d := range next {
+			if _, ok := v.nodes[d]; ok && !seen[d] {
+				seen[d] = true
+				queue = append(queue, d)
+			}
+		}
+	}
+	for _, id := range v.ids {
+		if !seen[id] {
+			v.add(SeverityWarning, DiagUnreachable, id, "", "component %q is not reachable from Begin", id)
+		}
+	}
+}
+
+// checkCycles reports back-edges in the downstream graph. The compiled
+// workflow is a DAG — repetition has to go through a Loop component,
+// whose body is a separate sub-graph — so any cycle here would never
+// terminate and is rejected by the compiler.
+func (v *validator) checkCycles() {
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := make(map[string]int, len(v.ids))
+	reported := map[string]bool{}
+	var visit func(id string, stack []string)
+	visit = func(id string, stack []string) {
+		color[id] = grey
+		stack = append(stack, id)
+		for _, d := range v.nodes[id].downstream {
+			if _, ok := v.nodes[d]; !ok {
+				continue
+			}
+			switch color[d] {
+			case white:
+				visit(d, stack)
+			case grey:
+				if !reported[d] {
+					reported[d] = true
+					start := 0
+					for i, s := range stack {
+						if s == d {
+							start = i
+						}
+					}
+					path := append(append([]string{}, stack[start:]...), d)
+					v.add(SeverityError, DiagCycle, d, "downstream",
+						"cycle %s; use a Loop component to repeat steps", strings.Join(path, " -> "))
+				}
+			}
+		}
+		color[id] = black
+	}
+	for _, id := range v.ids {
+		if color[id] == white {
+			visit(id, nil)
+		}
+	}
+}
+
+// checkBranches validates the routing targets of Switch and
+// Categorize, which the scheduler follows by id at run time.
+func (v *validator) checkBranches() {
+	for _, id := range v.ids {
+		n := v.nodes[id]
+		switch strings.ToLower(n.name) {
+		case "switch":
+			conds, _ := n.params["conditions"].([]any)
+			for i, raw := range conds {
+				group, _ := raw.(map[string]any)
+				if group == nil {
+					continue
+				}
+				v.checkTargets(n, fmt.Sprintf("params.conditions[%d].to", i), stringList(group["to"]))
+			}
+			v.checkTargets(n, "params.end_cpn_ids", stringList(n.params["end_cpn_ids"]))
+		case "categorize":
+			if desc, ok := n.params["category_description"].(map[string]any); ok {
+				for _, cat := range sortedKeys(desc) {
+					entry, _ := desc[cat].(map[string]any)
+					if entry == nil {
+						continue
+					}
+					v.checkTargets(n, "params.category_description."+cat+".to", stringList(entry["to"]))
+				}
+			}
+			items, _ := n.params["items"].([]any)
+			for i, raw := range items {
+				entry, _ := raw.(map[string]any)
+				if entry == nil {
+					continue
+				}
+				v.checkTargets(n, fmt.Sprintf("params.items[%d].to", i), stringList(entry["to"]))
+			}
+		}
+	}
+}
+
+func (v *validator) checkTargets(n *lintNode, field string, targets []string) {
+	for _, t := range targets {
+		if _, ok := v.nodes[t]; !ok {
+			v.add(SeverityError, DiagDanglingBranch, n.id, field, "branch target %q does not exist", t)
+			continue
+		}
+		if !containsString(n.downstream, t) {
+			v.add(SeverityWarning, DiagDanglingBranch, n.id, field, "branch target %q is not connected downstream", t)
+		}
+	}
+}
+
+func (v *validator) checkLoops() {
+	for _, id := range v.ids {
+		n := v.nodes[id]
+		if !strings.EqualFold(n.name, "Loop") {
+			continue
+		}
+		conds, _ := n.params["loop_termination_condition"].([]any)
+		maxCount, _ := toInt(n.params["maximum_loop_count"])
+		if len(conds) == 0 && maxCount <= 0 && !v.hasExitLoop(id) {
+			v.add(SeverityError, DiagLoopNoTermination, id, "params.loop_termination_condition",
+				"loop has no termination condition, no maximum_loop_count and no ExitLoop")
+		}
+		if len(v.children[id]) == 0 {
+			v.add(SeverityWarning, DiagLoopNoTermination, id, "", "loop has an empty body")
+		}
+		vars, _ := n.params["loop_variables"].([]any)
+		for i, raw := range vars {
+			spec, _ := raw.(map[string]any)
+			if spec == nil {
+				continue
+			}
+			mode, _ := spec["input_mode"].(string)
+			ref, _ := spec["value"].(string)
+			want, _ := vartype.Parse(spec["type"])
+			if mode != "variable" || ref == "" || want == nil {
+				continue
+			}
+			if got := v.refType(ref); !vartype.Compatible(want, got) {
+				v.add(SeverityWarning, DiagTypeMismatch, id, fmt.Sprintf("params.loop_variables[%d].value", i),
+					"loop variable is declared %s but %s is %s", want, ref, got)
+			}
+		}
+	}
+}
+
+func (v *validator) hasExitLoop(loopID string) bool {
+	for _, c := range v.children[loopID] {
+		if strings.EqualFold(v.nodes[c].name, "ExitLoop") {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) checkReferences() {
+	for _, id := range v.ids {
+		n := v.nodes[id]
+		v.walkParams(n, "params", "", n.params)
+		if strings.EqualFold(n.name, "Iteration") {
+			ref, _ := n.params["items_ref"].(string)
+			if got := v.refType(ref); got != nil && got.Kind != vartype.KindArray {
+				v.add(SeverityError, DiagTypeMismatch, id, "params.items_ref",
+					"Iteration needs an array but %s is %s", ref, got)
+			}
+		}
+	}
+}
+
+func (v *validator) walkParams(n *lintNode, path, key string, val any) {
+	switch t := val.(type) {
+	case string:
+		if bareRefKeys[key] && bareRefPattern.MatchString(strings.TrimSpace(t)) {
+			v.checkRef(n, path, strings.TrimSpace(t))
+			return
+		}
+		for _, m := range templateRefPattern.FindAllStringSubmatch(t, -1) {
+			v.checkRef(n, path, m[1])
+		}
+	case []any:
+		for i, item := range t {
+			if s, ok := item.(string); ok {
+				v.walkParams(n, fmt.Sprintf("%s[%d]", path, i), key, s)
+				continue
+			}
+			v.walkParams(n, fmt.Sprintf("%s[%d]", path, i), "", item)
+		}
+	case []string:
+		for i, s := range t {
+			v.walkParams(n, fmt.Sprintf("%s[%d]", path, i), key, s)
+		}
+	case map[string]any:
+		// {input_mode: "variable", value: "<ref>"} is the Loop /
+		// Switch form of a bare reference.
+		refValue := t["input_mode"] == "variable"
+		for _, k := range sortedKeys(t) {
+			childKey := k
+			if k == "value" && refValue {
+				childKey = "ref"
+			}
+			v.walkParams(n, path+"."+k, childKey, t[k])
+		}
+	}
+}
+
+func (v *validator) checkRef(n *lintNode, field, ref string) {
+	switch {
+	case strings.HasPrefix(ref, "sys."):
+		return
+	case strings.HasPrefix(ref, "env."):
+		name := strings.TrimPrefix(ref, "env.")
+		if head, _, ok := strings.Cut(name, "."); ok {
+			name = head
+		}
+		if _, ok := v.globals["env."+name]; ok {
+			return
+		}
+		if _, ok := v.variables[name]; ok {
+			return
+		}
+		v.add(SeverityWarning, DiagUnknownVariable, n.id, field, "variable %q is not declared", ref)
+		return
+	}
+	cpnID, param, _ := strings.Cut(ref, "@")
+	producer, ok := v.nodes[cpnID]
+	if !ok {
+		v.add(SeverityError, DiagDanglingReference, n.id, field, "reference %q points to missing component %q", ref, cpnID)
+		return
+	}
+	head, _, _ := strings.Cut(param, ".")
+	outputs := producerOutputs(producer)
+	if len(outputs) == 0 || strings.HasPrefix(head, "_") {
+		return
+	}
+	if _, ok := outputs[head]; !ok {
+		v.add(SeverityWarning, DiagUnknownOutput, n.id, field, "component %q does not declare output %q", cpnID, head)
+	}
+}
+
+// refType returns the declared type of the value ref points to, or nil
+// when it is unknown (no declaration, or not a reference at all).
+// Dotted paths descend into declared properties and items.
+func (v *validator) refType(ref string) *vartype.Type {
+	ref = strings.TrimSpace(ref)
+	if m := templateRefPattern.FindStringSubmatch(ref); m != nil && m[0] == ref {
+		ref = m[1]
+	}
+	var (
+		t    *vartype.Type
+		path []string
+	)
+	switch {
+	case strings.HasPrefix(ref, "env."):
+		path = strings.Split(strings.TrimPrefix(ref, "env."), ".")
+		t, _ = vartype.Parse(v.variables[path[0]])
+	case strings.Contains(ref, "@"):
+		cpnID, param, _ := strings.Cut(ref, "@")
+		producer, ok := v.nodes[cpnID]
+		if !ok {
+			return nil
+		}
+		path = strings.Split(param, ".")
+		t = producerOutputs(producer)[path[0]]
+	default:
+		return nil
+	}
+	for _, seg := range path[1:] {
+		t = t.At(seg)
+	}
+	return t
+}
+
+// producerOutputs returns the output keys a component declares, mapped
+// to their declared type (nil when untyped or malformed). Begin and
+// UserFillUp expose their form inputs; Loop exposes its loop variables.
+func producerOutputs(n *lintNode) map[string]*vartype.Type {
+	decls := vartype.Declarations(n.params)
+	out := make(map[string]*vartype.Type, len(decls))
+	for k, decl := range decls {
+		out[k], _ = vartype.Parse(decl)
+	}
+	return out
+}
+
+// checkTypes reports malformed declarations, global defaults that do
+// not match their type, and VariableAssigner / VariableAggregator
+// wiring that the runtime type checks would reject.
+func (v *validator) checkTypes() {
+	for _, name := range sortedKeys(v.variables) {
+		t, err := vartype.Parse(v.variables[name])
+		if err != nil {
+			v.add(SeverityError, DiagInvalidType, "", "variables."+name, "env.%s: %v", name, err)
+			continue
+		}
+		spec, _ := v.variables[name].(map[string]any)
+		if def := spec["value"]; t != nil && def != "" {
+			if err := t.Check(def); err != nil {
+				v.add(SeverityWarning, DiagTypeMismatch, "", "variables."+name+".value",
+					"default of env.%s does not match its declared type %s: %v", name, t, err)
+			}
+		}
+	}
+	for _, id := range v.ids {
+		n := v.nodes[id]
+		for _, section := range []string{"outputs", "inputs"} {
+			m, _ := n.params[section].(map[string]any)
+			for _, k := range sortedKeys(m) {
+				if _, err := vartype.Parse(m[k]); err != nil {
+					v.add(SeverityError, DiagInvalidType, id, "params."+section+"."+k, "output %q: %v", k, err)
+				}
+			}
+		}
+		switch strings.ToLower(n.name) {
+		case "variableassigner":
+			v.checkAssignerTypes(n)
+		case "variableaggregator":
+			v.checkAggregatorTypes(n)
+		}
+	}
+}
+
+// checkAssignerTypes checks each (variable, operator, parameter) tuple
+// against the variable's declared type. An overwrite or set the
+// runtime would reject is an error; an operator that cannot apply to
+// the declared kind is a warning (the runtime reports it as an
+// ERROR:... output instead of failing).
+func (v *validator) checkAssignerTypes(n *lintNode) {
+	items, _ := n.params["variables"].([]any)
+	for i, raw := range items {
+		item, _ := raw.(map[string]any)
+		ref, _ := item["variable"].(string)
+		op, _ := item["operator"].(string)
+		want := v.refType(ref)
+		if want == nil {
+			continue
+		}
+		field := fmt.Sprintf("params.variables[%d].parameter", i)
+		param := item["parameter"]
+		paramRef, isRef := v.paramRef(param)
+		switch op {
+		case "overwrite", "set":
+			if isRef {
+				if got := v.refType(paramRef); !vartype.Compatible(want, got) {
+					v.add(SeverityError, DiagTypeMismatch, n.id, field,
+						"%s is declared %s but %s is %s", ref, want, paramRef, got)
+				}
+			} else if op == "set" {
+				if err := want.Check(param); err != nil {
+					v.add(SeverityError, DiagTypeMismatch, n.id, field,
+						"%s is declared %s but the value does not match: %v", ref, want, err)
+				}
+			}
+		case "append", "extend", "remove_first", "remove_last":
+			if want.Kind != vartype.KindArray {
+				v.add(SeverityWarning, DiagTypeMismatch, n.id, fmt.Sprintf("params.variables[%d].operator", i),
+					"%s needs an array but %s is declared %s", op, ref, want)
+				continue
+			}
+			if !isRef {
+				continue
+			}
+			got := v.refType(paramRef)
+			if op == "append" && !vartype.Compatible(want.Items, got) ||
+				op == "extend" && !vartype.Compatible(want, got) {
+				v.add(SeverityWarning, DiagTypeMismatch, n.id, field,
+					"%s %s onto %s (declared %s)", op, paramRef, ref, want)
+			}
+		case "+=", "-=", "*=", "/=":
+			if want.Kind != vartype.KindNumber && want.Kind != vartype.KindInteger {
+				v.add(SeverityWarning, DiagTypeMismatch, n.id, fmt.Sprintf("params.variables[%d].operator", i),
+					"%s needs a number but %s is declared %s", op, ref, want)
+			}
+		}
+	}
+}
+
+// paramRef returns the variable reference a VariableAssigner parameter
+// names, if it is one.
+func (v *validator) paramRef(param any) (string, bool) {
+	s, ok := param.(string)
+	if !ok {
+		return "", false
+	}
+	s = strings.TrimSpace(s)
+	if m := templateRefPattern.FindStringSubmatch(s); m != nil && m[0] == s {
+		return m[1], true
+	}
+	if bareRefPattern.MatchString(s) {
+		return s, true
+	}
+	return "", false
+}
+
+// checkAggregatorTypes checks every candidate of a typed group.
+func (v *validator) checkAggregatorTypes(n *lintNode) {
+	groups, _ := n.params["groups"].([]any)
+	for i, raw := range groups {
+		g, _ := raw.(map[string]any)
+		want, err := vartype.Parse(g["type"])
+		if err != nil {
+			v.add(SeverityError, DiagInvalidType, n.id, fmt.Sprintf("params.groups[%d].type", i), "%v", err)
+			continue
+		}
+		if want == nil {
+			continue
+		}
+		vars, _ := g["variables"].([]any)
+		for j, rv := range vars {
+			sel, _ := rv.(map[string]any)
+			ref, _ := sel["value"].(string)
+			if got := v.refType(ref); !vartype.Compatible(want, got) {
+				v.add(SeverityWarning, DiagTypeMismatch, n.id, fmt.Sprintf("params.groups[%d].variables[%d].value", i, j),
+					"group %v is declared %s but %s is %s", g["group_name"], want, ref, got)
+			}
+		}
+	}
+}
+
+func stringList(v any) []string {
+	switch t := v.(type) {
+	case string:
+		if t == "" {
+			return nil
+		}
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		return toStringSlice(t)
+	}
+	return nil
+}
+
+func toInt(v any) (int, bool) {
+	switch t := v.(type) {
+	case int:
+		return t, true
+	case int64:
+		return int(t), true
+	case float64:
+		return int(t), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(t))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func dedupe(s []string) []string {
+	if len(s) < 2 {
+		return s
+	}
+	seen := make(map[string]bool, len(s))
+	out := s[:0:0]
+	for _, x := range s {
+		if !seen[x] {
+			seen[x] = true
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func containsString(s []string, x string) bool {
+	for _, y := range s {
+		if y == x {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
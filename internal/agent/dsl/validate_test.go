@@ -0,0 +1,283 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package dsl
+
+import (
+	"strings"
+	"testing"
+)
+
+// lintComponent builds one DSL component block.
+func lintComponent(name string, params map[string]any, downstream, upstream []string) map[string]any {
+	return map[string]any{
+		"obj":        map[string]any{"component_name": name, "params": params},
+		"downstream": stringsToAny(downstream),
+		"upstream":   stringsToAny(upstream),
+	}
+}
+
+// lintDSL is a clean begin -> llm -> message canvas.
+func lintDSL() map[string]any {
+	return map[string]any{
+		"components": map[string]any{
+			"begin": lintComponent("Begin", map[string]any{
+				"inputs": map[string]any{"topic": map[string]any{"type": "line"}},
+			}, []string{"llm_0"}, nil),
+			"llm_0": lintComponent("LLM", map[string]any{
+				"sys_prompt": "Write about {{begin@topic}} for {{sys.query}}.",
+				"outputs":    map[string]any{"content": map[string]any{"type": "string"}},
+			}, []string{"message_0"}, []string{"begin"}),
+			"message_0": lintComponent("Message", map[string]any{
+				"content": []any{"{{llm_0@content}}"},
+			}, nil, []string{"llm_0"}),
+		},
+		"globals":   map[string]any{"sys.query": ""},
+		"variables": map[string]any{},
+	}
+}
+
+func components(d map[string]any) map[string]any {
+	return d["components"].(map[string]any)
+}
+
+func findDiag(diags []Diagnostic, code, cpnID string) *Diagnostic {
+	for i := range diags {
+		if diags[i].Code == code && diags[i].ComponentID == cpnID {
+			return &diags[i]
+		}
+	}
+	return nil
+}
+
+func TestValidate_CleanDSL(t *testing.T) {
+	known := func(name string) bool {
+		switch strings.ToLower(name) {
+		case "begin", "llm", "message":
+			return true
+		}
+		return false
+	}
+	if diags := Validate(lintDSL(), ValidateOptions{KnownComponent: known}); len(diags) != 0 {
+		t.Fatalf("diags = %+v, want none", diags)
+	}
+	// The in-memory NormalizeForCanvas output (flat []string edges) is
+	// what UpdateAgent and PublishAgent lint.
+	if diags := Validate(NormalizeForCanvas(lintDSL()), ValidateOptions{KnownComponent: known}); len(diags) != 0 {
+		t.Fatalf("normalized diags = %+v, want none", diags)
+	}
+}
+
+func TestValidate_MalformedAndMissingBegin(t *testing.T) {
+	diags := Validate(map[string]any{}, ValidateOptions{})
+	if findDiag(diags, DiagMalformed, "") == nil || !HasErrors(diags) {
+		t.Fatalf("diags = %+v, want malformed_dsl error", diags)
+	}
+
+	d := lintDSL()
+	delete(components(d), "begin")
+	diags = Validate(d, ValidateOptions{})
+	if findDiag(diags, DiagMissingBegin, "") == nil {
+		t.Fatalf("diags = %+v, want missing_begin", diags)
+	}
+}
+
+func TestValidate_UnknownComponent(t *testing.T) {
+	d := lintDSL()
+	components(d)["llm_0"].(map[string]any)["obj"].(map[string]any)["component_name"] = "Nope"
+	diags := Validate(d, ValidateOptions{KnownComponent: func(name string) bool { return name != "Nope" }})
+	got := findDiag(diags, DiagUnknownComponent, "llm_0")
+	if got == nil || got.Severity != SeverityError || got.Field != "obj.component_name" {
+		t.Fatalf("diags = %+v, want unknown_component error on llm_0", diags)
+	}
+}
+
+func TestValidate_DanglingEdgesAndReferences(t *testing.T) {
+	d := lintDSL()
+	begin := components(d)["begin"].(map[string]any)
+	begin["downstream"] = []any{"llm_0", "ghost"}
+	msg := components(d)["message_0"].(map[string]any)["obj"].(map[string]any)
+	msg["params"] = map[string]any{"content": []any{"{{missing_0@content}} {{llm_0@text}} {{env.nope}}"}}
+
+	diags := Validate(d, ValidateOptions{})
+	if got := findDiag(diags, DiagDanglingEdge, "begin"); got == nil || got.Field != "downstream" {
+		t.Fatalf("diags = %+v, want dangling_edge on begin", diags)
+	}
+	ref := findDiag(diags, DiagDanglingReference, "message_0")
+	if ref == nil || ref.Severity != SeverityError || ref.Field != "params.content[0]" {
+		t.Fatalf("diags = %+v, want dangling_reference error at params.content[0]", diags)
+	}
+	if got := findDiag(diags, DiagUnknownOutput, "message_0"); got == nil || got.Severity != SeverityWarning {
+		t.Fatalf("diags = %+v, want unknown_output warning", diags)
+	}
+	if got := findDiag(diags, DiagUnknownVariable, "message_0"); got == nil {
+		t.Fatalf("diags = %+v, want unknown_variable warning", diags)
+	}
+	if diags[0].Severity != SeverityError {
+		t.Fatalf("errors must sort first: %+v", diags)
+	}
+}
+
+func TestValidate_UnreachableAndCycle(t *testing.T) {
+	d := lintDSL()
+	components(d)["orphan_0"] = lintComponent("Message", map[string]any{}, nil, nil)
+	components(d)["message_0"].(map[string]any)["downstream"] = []any{"llm_0"}
+
+	diags := Validate(d, ValidateOptions{})
+	if got := findDiag(diags, DiagUnreachable, "orphan_0"); got == nil || got.Severity != SeverityWarning {
+		t.Fatalf("diags = %+v, want unreachable warning on orphan_0", diags)
+	}
+	cycle := findDiag(diags, DiagCycle, "llm_0")
+	if cycle == nil || !strings.Contains(cycle.Message, "llm_0 -> message_0 -> llm_0") {
+		t.Fatalf("diags = %+v, want cycle through llm_0", diags)
+	}
+}
+
+func TestValidate_SwitchAndCategorizeBranches(t *testing.T) {
+	d := lintDSL()
+	components(d)["begin"].(map[string]any)["downstream"] = []any{"switch_0"}
+	components(d)["switch_0"] = lintComponent("Switch", map[string]any{
+		"conditions": []any{
+			map[string]any{"to": []any{"llm_0"}, "items": []any{map[string]any{"cpn_id": "begin@topic", "operator": "=", "value": "x"}}},
+			map[string]any{"to": "nowhere"},
+		},
+		"end_cpn_ids": []any{"message_0"},
+	}, []string{"llm_0"}, []string{"begin"})
+	components(d)["cat_0"] = lintComponent("Categorize", map[string]any{
+		"category_description": map[string]any{"a": map[string]any{"to": []any{"gone"}}},
+	}, nil, nil)
+
+	diags := Validate(d, ValidateOptions{})
+	var targets []string
+	for _, diag := range diags {
+		if diag.Code == DiagDanglingBranch {
+			targets = append(targets, string(diag.Severity)+":"+diag.ComponentID+":"+diag.Field)
+		}
+	}
+	want := []string{
+		"error:cat_0:params.category_description.a.to",
+		"error:switch_0:params.conditions[1].to",
+		"warning:switch_0:params.end_cpn_ids",
+	}
+	if strings.Join(targets, ",") != strings.Join(want, ",") {
+		t.Fatalf("branch diags = %v, want %v", targets, want)
+	}
+}
+
+func TestValidate_LoopTermination(t *testing.T) {
+	d := lintDSL()
+	components(d)["begin"].(map[string]any)["downstream"] = []any{"llm_0", "loop_0"}
+	components(d)["loop_0"] = lintComponent("Loop", map[string]any{
+		"loop_termination_condition": []any{},
+		"maximum_loop_count":         0,
+		"loop_variables": []any{map[string]any{
+			"variable": "n", "type": "number", "input_mode": "variable", "value": "begin@topic",
+		}},
+	}, nil, []string{"begin"})
+	start := lintComponent("LoopItem", nil, []string{"body_0"}, nil)
+	start["parent_id"] = "loop_0"
+	body := lintComponent("Message", map[string]any{"content": []any{"{{loop_0@n}}"}}, nil, []string{"loop_start"})
+	body["parent_id"] = "loop_0"
+	components(d)["loop_start"] = start
+	components(d)["body_0"] = body
+
+	diags := Validate(d, ValidateOptions{KnownComponent: func(string) bool { return true }})
+	got := findDiag(diags, DiagLoopNoTermination, "loop_0")
+	if got == nil || got.Severity != SeverityError {
+		t.Fatalf("diags = %+v, want loop_without_termination error", diags)
+	}
+	if findDiag(diags, DiagUnreachable, "body_0") != nil {
+		t.Fatalf("loop body must be reachable through its parent: %+v", diags)
+	}
+	mismatch := findDiag(diags, DiagTypeMismatch, "loop_0")
+	if mismatch == nil || mismatch.Field != "params.loop_variables[0].value" {
+		t.Fatalf("diags = %+v, want type_mismatch on the loop variable", diags)
+	}
+
+	components(d)["loop_0"].(map[string]any)["obj"].(map[string]any)["params"].(map[string]any)["maximum_loop_count"] = 10
+	if got := findDiag(Validate(d, ValidateOptions{}), DiagLoopNoTermination, "loop_0"); got != nil {
+		t.Fatalf("maximum_loop_count should terminate the loop: %+v", got)
+	}
+}
+
+func TestValidate_IterationNeedsArray(t *testing.T) {
+	d := lintDSL()
+	components(d)["llm_0"].(map[string]any)["downstream"] = []any{"message_0", "iter_0"}
+	components(d)["iter_0"] = lintComponent("Iteration", map[string]any{
+		"items_ref": "llm_0@content",
+	}, nil, []string{"llm_0"})
+
+	got := findDiag(Validate(d, ValidateOptions{}), DiagTypeMismatch, "iter_0")
+	if got == nil || got.Severity != SeverityError || got.Field != "params.items_ref" {
+		t.Fatalf("want type_mismatch error on iter_0, got %+v", got)
+	}
+}
+
+func TestValidate_FixtureHasNoFalsePositives(t *testing.T) {
+	d := NormalizeForCanvas(loadFixture(t, "all.json"))
+	for _, diag := range Validate(d, ValidateOptions{}) {
+		// The fixture routes one Switch branch to a component that
+		// was deleted from the canvas; everything else is clean.
+		if diag.Code == DiagDanglingBranch && diag.ComponentID == "Switch:Route" {
+			continue
+		}
+		t.Errorf("unexpected diagnostic %+v", diag)
+	}
+}
+
+func TestValidate_TypedVariables(t *testing.T) {
+	d := lintDSL()
+	d["variables"] = map[string]any{
+		"tags":  map[string]any{"name": "tags", "type": "Array<string>", "value": []any{}},
+		"count": map[string]any{"name": "count", "type": "number", "value": "zero"},
+		"bad":   map[string]any{"name": "bad", "type": "string", "items": map[string]any{"type": "string"}},
+	}
+	components(d)["llm_0"].(map[string]any)["downstream"] = []any{"message_0", "assign_0"}
+	components(d)["assign_0"] = lintComponent("VariableAssigner", map[string]any{
+		"variables": []any{
+			map[string]any{"variable": "env.tags", "operator": "overwrite", "parameter": "{{llm_0@content}}"},
+			map[string]any{"variable": "env.tags", "operator": "+=", "parameter": 1},
+			map[string]any{"variable": "env.count", "operator": "set", "parameter": 3},
+		},
+	}, nil, []string{"llm_0"})
+
+	diags := Validate(d, ValidateOptions{})
+	if got := findDiag(diags, DiagInvalidType, ""); got == nil || got.Field != "variables.bad" || got.Severity != SeverityError {
+		t.Fatalf("want invalid_type on variables.bad, got %+v", diags)
+	}
+	var overwrite, operator, setLiteral, defaultValue bool
+	for _, diag := range diags {
+		if diag.Code != DiagTypeMismatch {
+			continue
+		}
+		switch diag.Field {
+		case "params.variables[0].parameter":
+			overwrite = diag.Severity == SeverityError && strings.Contains(diag.Message, "llm_0@content is string")
+		case "params.variables[1].operator":
+			operator = diag.Severity == SeverityWarning
+		case "params.variables[2].parameter":
+			setLiteral = true
+		case "variables.count.value":
+			defaultValue = diag.Severity == SeverityWarning
+		}
+	}
+	if !overwrite || !operator || !defaultValue {
+		t.Fatalf("overwrite=%v operator=%v default=%v; diags = %+v", overwrite, operator, defaultValue, diags)
+	}
+	if setLiteral {
+		t.Fatalf("set of a number into a number variable must be clean: %+v", diags)
+	}
+}
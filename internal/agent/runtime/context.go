@@ -71,3 +71,82 @@ func GetStateFromContext[S any](ctx context.Context) (S, *sync.Mutex, error) {
 	// self-locking methods over holding the mutex themselves.
 	return s, nil, nil
 }
+
+// componentIDCtxKey keys the id of the canvas node currently invoking
+// a component body.
+type componentIDCtxKey struct{}
+
+// WithComponentID attaches the invoking node's cpn id to ctx. The
+// canvas node body sets it before calling Component.Invoke so a
+// component can attribute nested events to its own node.
+func WithComponentID(ctx context.Context, cpnID string) context.Context {
+	return context.WithValue(ctx, componentIDCtxKey{}, cpnID)
+}
+
+// ComponentIDFromContext returns the cpn id attached via
+// WithComponentID, or "" when absent.
+func ComponentIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(componentIDCtxKey{}).(string)
+	return id
+}
+
+// canvasCallCtxKey keys the chain of canvas ids on the current call
+// path (outermost first).
+type canvasCallCtxKey struct{}
+
+// WithCanvasCall appends canvasID to the call chain on ctx. Each canvas
+// run records itself so a SubAgent node can refuse to invoke a canvas
+// that is already running further up the same call path.
+func WithCanvasCall(ctx context.Context, canvasID string) context.Context {
+	parent := CanvasCallChain(ctx)
+	chain := make([]string, 0, len(parent)+1)
+	chain = append(chain, parent...)
+	chain = append(chain, canvasID)
+	return context.WithValue(ctx, canvasCallCtxKey{}, chain)
+}
+
+// CanvasCallChain returns the canvas ids recorded via WithCanvasCall,
+// outermost first. The returned slice must not be modified.
+func CanvasCallChain(ctx context.Context) []string {
+	chain, _ := ctx.Value(canvasCallCtxKey{}).([]string)
+	return chain
+}
+
+// sessionIDCtxKey keys the agent session id of the current run.
+type sessionIDCtxKey struct{}
+
+// WithSessionID attaches the agent session id to ctx. Tools that keep
+// per-session resources (the CodeExec sandbox) read it back with
+// SessionIDFromContext.
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDCtxKey{}, sessionID)
+}
+
+// SessionIDFromContext returns the session id attached via
+// WithSessionID, or "" when absent.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDCtxKey{}).(string)
+	return id
+}
+
+// eventSinkCtxKey keys the run event sink of the current run.
+type eventSinkCtxKey struct{}
+
+// EventSink receives events raised below the canvas layer. data is
+// marshalled to JSON as the event payload.
+type EventSink func(eventType string, data any)
+
+// WithEventSink attaches sink to ctx. canvas.WithRunMeta installs one
+// that forwards into the run's event stream so tools can surface
+// events without importing the canvas package.
+func WithEventSink(ctx context.Context, sink EventSink) context.Context {
+	return context.WithValue(ctx, eventSinkCtxKey{}, sink)
+}
+
+// EmitEvent hands an event to the sink attached via WithEventSink. No-op
+// when there is none.
+func EmitEvent(ctx context.Context, eventType string, data any) {
+	if sink, _ := ctx.Value(eventSinkCtxKey{}).(EventSink); sink != nil {
+		sink(eventType, data)
+	}
+}
@@ -65,6 +65,10 @@ type CanvasState struct {
 	CancelFlag *atomic.Bool
 	RunID      string
 	TaskID     string
+
+	// types are the canvas's declared variable types (vartypes.go).
+	// Derived from the DSL on every run, so never serialized.
+	types *VarTypes
 }
 
 // NewCanvasState returns a zero-valued CanvasState with all maps allocated.
@@ -368,7 +372,7 @@ func getVarLocked(s *CanvasState, ref string) (any, error) {
 	case strings.HasPrefix(ref, "sys."):
 		return dotTraverse(s.Sys, strings.TrimPrefix(ref, "sys.")), nil
 	case strings.HasPrefix(ref, "env."):
-		return dotTraverse(s.Env, strings.TrimPrefix(ref, "env.")), nil
+		return checkedVar(s, ref, dotTraverse(s.Env, strings.TrimPrefix(ref, "env.")))
 	case strings.Contains(ref, "@"):
 		idx := strings.Index(ref, "@")
 		cpnID, tail := ref[:idx], ref[idx+1:]
@@ -376,12 +380,20 @@ func getVarLocked(s *CanvasState, ref string) (any, error) {
 		if !ok {
 			return nil, nil
 		}
-		return dotTraverse(outputs, tail), nil
+		return checkedVar(s, ref, dotTraverse(outputs, tail))
 	default:
 		return nil, fmt.Errorf("canvas: invalid variable reference %q", ref)
 	}
 }
 
+// checkedVar returns v unless it violates the declared type of ref.
+func checkedVar(s *CanvasState, ref string, v any) (any, error) {
+	if err := s.types.Check(ref, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 // setVarLocked is the lock-free inner SetVar. Caller must hold s.mu.
 func setVarLocked(outputs map[string]map[string]any, cpnID, param string, v any) {
 	bucket, ok := outputs[cpnID]
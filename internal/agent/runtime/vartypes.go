@@ -0,0 +1,171 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// vartypes.go — declared variable types checked at read and write time.
+//
+// A canvas's type declarations (vartype package) are collected once per
+// run into a VarTypes and attached to the CanvasState. GetVar then
+// checks every value it returns against the declaration of the output
+// or global it came from, so a producer that emits a string where its
+// declaration promises a list fails at the consumer's read with an
+// error naming both, instead of deep inside the consumer.
+// VariableAssigner checks its writes with CheckVarWrite.
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ragflow/internal/agent/vartype"
+)
+
+// ErrVarTypeMismatch is the sentinel wrapped by every *VarTypeError.
+var ErrVarTypeMismatch = errors.New("variable type mismatch")
+
+// VarTypeError reports a value that does not match its declaration.
+type VarTypeError struct {
+	// Ref is the variable reference ("llm_0@items", "env.counter").
+	Ref string
+	// Producer / ProducerName identify the component that declares the
+	// output; both are empty for globals.
+	Producer     string
+	ProducerName string
+	Declared     string
+	Err          *vartype.MismatchError
+}
+
+func (e *VarTypeError) Error() string {
+	src := "global"
+	if e.Producer != "" {
+		src = fmt.Sprintf("output of %s %q", e.ProducerName, e.Producer)
+	}
+	return fmt.Sprintf("variable %q (%s) does not match its declared type %s: %v",
+		e.Ref, src, e.Declared, e.Err)
+}
+
+func (e *VarTypeError) Unwrap() error { return ErrVarTypeMismatch }
+
+// VarTypes holds the declared types of one canvas: component outputs
+// (Begin inputs included) and env.* globals. The zero value declares
+// nothing. It is built before the run and read-only afterwards.
+type VarTypes struct {
+	outputs   map[string]map[string]*vartype.Type
+	producers map[string]string
+	env       map[string]*vartype.Type
+}
+
+// NewVarTypes returns an empty declaration set.
+func NewVarTypes() *VarTypes {
+	return &VarTypes{
+		outputs:   map[string]map[string]*vartype.Type{},
+		producers: map[string]string{},
+		env:       map[string]*vartype.Type{},
+	}
+}
+
+// DeclareOutput declares the type of output of component cpnID. A nil
+// t is ignored.
+func (t *VarTypes) DeclareOutput(cpnID, componentName, output string, typ *vartype.Type) {
+	if typ == nil {
+		return
+	}
+	bucket, ok := t.outputs[cpnID]
+	if !ok {
+		bucket = map[string]*vartype.Type{}
+		t.outputs[cpnID] = bucket
+	}
+	bucket[output] = typ
+	t.producers[cpnID] = componentName
+}
+
+// DeclareEnv declares the type of global env.<name>. A nil t is
+// ignored.
+func (t *VarTypes) DeclareEnv(name string, typ *vartype.Type) {
+	if typ != nil {
+		t.env[name] = typ
+	}
+}
+
+// Empty reports whether nothing is declared.
+func (t *VarTypes) Empty() bool {
+	return t == nil || len(t.outputs) == 0 && len(t.env) == 0
+}
+
+// Lookup returns the declared type of ref (nil when undeclared) with
+// the declaring component. Dotted paths descend into object properties
+// and array items.
+func (t *VarTypes) Lookup(ref string) (typ *vartype.Type, producer, producerName string) {
+	if t.Empty() {
+		return nil, "", ""
+	}
+	var path []string
+	switch {
+	case strings.HasPrefix(ref, "env."):
+		path = strings.Split(strings.TrimPrefix(ref, "env."), ".")
+		typ = t.env[path[0]]
+	case strings.Contains(ref, "@"):
+		cpnID, tail, _ := strings.Cut(ref, "@")
+		path = strings.Split(tail, ".")
+		typ = t.outputs[cpnID][path[0]]
+		producer, producerName = cpnID, t.producers[cpnID]
+	default:
+		return nil, "", ""
+	}
+	for _, seg := range path[1:] {
+		if typ == nil {
+			break
+		}
+		typ = typ.At(seg)
+	}
+	if typ == nil {
+		return nil, "", ""
+	}
+	return typ, producer, producerName
+}
+
+// Check validates v against the declaration of ref. It returns nil
+// for undeclared refs and a *VarTypeError otherwise.
+func (t *VarTypes) Check(ref string, v any) error {
+	typ, producer, name := t.Lookup(ref)
+	if typ == nil {
+		return nil
+	}
+	var mismatch *vartype.MismatchError
+	if err := typ.Check(v); errors.As(err, &mismatch) {
+		return &VarTypeError{Ref: ref, Producer: producer, ProducerName: name, Declared: typ.String(), Err: mismatch}
+	}
+	return nil
+}
+
+// SetVarTypes attaches the canvas's declared types; nil detaches them.
+func (s *CanvasState) SetVarTypes(t *VarTypes) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.types = t
+}
+
+// VarTypes returns the attached declarations (nil when none).
+func (s *CanvasState) VarTypes() *VarTypes {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.types
+}
+
+// CheckVarWrite validates a value about to be written to ref.
+func (s *CanvasState) CheckVarWrite(ref string, v any) error {
+	return s.VarTypes().Check(ref, v)
+}
@@ -0,0 +1,92 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ragflow/internal/agent/vartype"
+)
+
+func mustType(t *testing.T, decl any) *vartype.Type {
+	t.Helper()
+	typ, err := vartype.Parse(decl)
+	if err != nil {
+		t.Fatalf("Parse(%v): %v", decl, err)
+	}
+	return typ
+}
+
+func TestGetVar_ChecksDeclaredTypes(t *testing.T) {
+	types := NewVarTypes()
+	types.DeclareOutput("llm_0", "LLM", "items", mustType(t, "Array<string>"))
+	types.DeclareOutput("begin", "Begin", "user", mustType(t, map[string]any{
+		"type": "object", "properties": map[string]any{"age": "integer"},
+	}))
+	types.DeclareEnv("count", mustType(t, "number"))
+
+	s := NewCanvasState("run", "task")
+	s.SetVarTypes(types)
+	s.Outputs["llm_0"] = map[string]any{"items": "a, b", "raw": "free"}
+	s.Outputs["begin"] = map[string]any{"user": map[string]any{"age": "ten"}}
+	s.Env["count"] = 2
+
+	if v, err := s.GetVar("llm_0@raw"); err != nil || v != "free" {
+		t.Fatalf("undeclared output = %v, %v", v, err)
+	}
+	if v, err := s.GetVar("env.count"); err != nil || v != 2 {
+		t.Fatalf("env.count = %v, %v", v, err)
+	}
+
+	_, err := s.GetVar("llm_0@items")
+	var typeErr *VarTypeError
+	if !errors.As(err, &typeErr) || !errors.Is(err, ErrVarTypeMismatch) {
+		t.Fatalf("GetVar(llm_0@items) error = %v, want *VarTypeError", err)
+	}
+	for _, want := range []string{`"llm_0@items"`, `LLM "llm_0"`, "array<string>", "got string"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	if _, err := s.GetVar("begin@user.age"); !errors.Is(err, ErrVarTypeMismatch) {
+		t.Fatalf("GetVar(begin@user.age) error = %v, want a mismatch on the nested property", err)
+	}
+	if _, err := s.GetVar("begin@user"); err == nil || !strings.Contains(err.Error(), "age: want integer") {
+		t.Fatalf("GetVar(begin@user) error = %v, want the failing property path", err)
+	}
+
+	if err := s.CheckVarWrite("env.count", "three"); err == nil || !strings.Contains(err.Error(), "(global)") {
+		t.Fatalf("CheckVarWrite(env.count) = %v, want a global mismatch", err)
+	}
+	if err := s.CheckVarWrite("env.count", 3.5); err != nil {
+		t.Fatalf("CheckVarWrite(env.count, 3.5) = %v", err)
+	}
+}
+
+func TestGetVar_UntypedStateSkipsChecks(t *testing.T) {
+	s := NewCanvasState("run", "task")
+	s.Outputs["llm_0"] = map[string]any{"items": "a, b"}
+	if v, err := s.GetVar("llm_0@items"); err != nil || v != "a, b" {
+		t.Fatalf("GetVar = %v, %v", v, err)
+	}
+	if err := s.CheckVarWrite("llm_0@items", 1); err != nil {
+		t.Fatalf("CheckVarWrite = %v", err)
+	}
+}
@@ -29,11 +29,28 @@
 // Python's allowlist includes `.csv .html .jpeg .jpg .json .pdf
 // .png .svg`. We mirror it 1:1. To extend, add here AND in
 // both Python files.
+//
+// collectArtifactDir is the shared collector for providers whose
+// artifacts end up in a host directory (Local, WASM). Instances that
+// a session keeps alive across calls accumulate files, so callers
+// pass the run's start time and only files written since then are
+// reported.
 
 package sandbox
 
-// allowedArtifactExts is the set of file extensions the Local
-// and SSH providers accept as code-execution artifacts. Anything
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// allowedArtifactExts is the set of file extensions the Local,
+// WASM and SSH providers accept as code-execution artifacts. Anything
 // outside this set is rejected at collect time.
 var allowedArtifactExts = map[string]struct{}{
 	".csv":  {},
@@ -45,3 +62,90 @@ var allowedArtifactExts = map[string]struct{}{
 	".png":  {},
 	".svg":  {},
 }
+
+// collectArtifactDir walks root and returns the files in it as
+// {name, content_b64, mime_type, size} records. Enforces the same
+// limits the Python local provider does: max count, max per-file
+// size, allowlist of extensions, no symlinks. A missing root means
+// no artifacts. Files last modified before since are left out (and
+// not validated); a zero since reports everything.
+func collectArtifactDir(root string, since time.Time, maxArtifacts, maxArtifactBytes int) ([]map[string]any, error) {
+	info, err := os.Stat(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("artifacts path %q is not a directory", root)
+	}
+
+	var out []map[string]any
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		// Skip the root itself.
+		if path == root {
+			return nil
+		}
+		// Reject symlinks. os.DirEntry's Type() returns the
+		// type, but a symlink reports Type()&ModeSymlink != 0.
+		if d.Type()&os.ModeSymlink != 0 {
+			return fmt.Errorf("artifact symlinks are not allowed: %s", d.Name())
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("unsupported artifact entry: %s", d.Name())
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !since.IsZero() && fi.ModTime().Before(since) {
+			return nil
+		}
+		if len(out) >= maxArtifacts {
+			return fmt.Errorf("execution produced more than %d artifacts", maxArtifacts)
+		}
+		if fi.Size() > int64(maxArtifactBytes) {
+			return fmt.Errorf("artifact exceeds %d bytes: %s", maxArtifactBytes, d.Name())
+		}
+		ext := strings.ToLower(filepath.Ext(d.Name()))
+		if _, ok := allowedArtifactExts[ext]; !ok {
+			return fmt.Errorf("unsupported artifact type: %s", d.Name())
+		}
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = d.Name()
+		}
+		mimeType := mime.TypeByExtension(ext)
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		out = append(out, map[string]any{
+			"name":        filepath.ToSlash(rel),
+			"content_b64": base64.StdEncoding.EncodeToString(body),
+			"mime_type":   mimeType,
+			"size":        fi.Size(),
+		})
+		return nil
+	})
+	return out, err
+}
+
+// artifactsSince is the since cutoff for a run that started at
+// start. It is rounded down to the second so a filesystem with
+// coarse timestamps cannot hide a file the run just wrote; the cost
+// is that a file written in the same second by the previous call on
+// a reused instance is reported again.
+func artifactsSince(start time.Time) time.Time {
+	return start.Truncate(time.Second)
+}
@@ -126,3 +126,25 @@ func configDuration(cfg map[string]any, key string, fallback time.Duration) time
 	}
 	return fallback
 }
+
+// configBool returns the bool value for key, or fallback if missing
+// or not coercible. Accepts JSON booleans and strconv.ParseBool
+// strings ("true", "1", ...).
+func configBool(cfg map[string]any, key string, fallback bool) bool {
+	if b, ok := cfg[key].(bool); ok {
+		return b
+	}
+	if b, err := strconv.ParseBool(configString(cfg, key)); err == nil {
+		return b
+	}
+	return fallback
+}
+
+// configFloat returns the float64 value for key, or fallback if
+// missing or not coercible.
+func configFloat(cfg map[string]any, key string, fallback float64) float64 {
+	if f, err := strconv.ParseFloat(configString(cfg, key), 64); err == nil {
+		return f
+	}
+	return fallback
+}
@@ -17,11 +17,19 @@
 // local.go is the Go port of `agent/sandbox/providers/local.py`.
 //
 // LocalProvider runs the user's code on the Go host itself via
-// os/exec. There is no sandboxing — the code runs with the Go
-// process's privileges. The Python version is the same: the
+// os/exec. By default there is no sandboxing — the code runs with
+// the Go process's privileges. The Python version is the same: the
 // "local" provider is a convenience for development / trusted
-// environments, not a security boundary. Operators that need
-// isolation should configure SelfManaged (executor_manager
+// environments, not a security boundary.
+//
+// LOCAL_ISOLATION=namespace (Linux only) hardens it for single-host
+// deployments: the interpreter runs in new user, mount, PID and
+// network namespaces over a read-only root built from bind mounts of
+// the host's system dirs, with a tmpfs work dir, cgroup v2 memory /
+// CPU / pids limits and a seccomp filter, and without network unless
+// LOCAL_NETWORK is set. See local_isolation_linux.go and
+// local_nsinit_linux.go. Operators that need a stronger boundary
+// should still configure SelfManaged (executor_manager
 // Docker+gVisor) or Aliyun / e2b.
 //
 // Wire format matches the Python provider exactly: write the
@@ -42,10 +50,8 @@ package sandbox
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
-	"mime"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -88,6 +94,33 @@ const localDefaultNoFile = 64
 const localDefaultPythonBin = "python3"
 const localDefaultNodeBin = "node"
 
+// Isolation modes (LOCAL_ISOLATION).
+const (
+	localIsolationNone      = "none"
+	localIsolationNamespace = "namespace"
+)
+
+// localDefaultRootfsBinds are the host paths bound read-only into
+// the namespace sandbox's root.
+const localDefaultRootfsBinds = "/usr,/bin,/sbin,/lib,/lib64,/lib32,/etc"
+
+// localDefaultTmpfsSizeMB caps each of the sandbox's tmpfs mounts
+// (/workspace, /tmp, /dev/shm).
+const localDefaultTmpfsSizeMB = 64
+
+// localDefaultCPULimit is the sandbox's CPU quota in cores.
+const localDefaultCPULimit = 1.0
+
+// localDefaultMaxPids caps the sandbox's process count.
+const localDefaultMaxPids = 64
+
+// localDefaultCgroupParent is the cgroup v2 directory the per-run
+// cgroups are created under; localCgroupNone disables them.
+const (
+	localDefaultCgroupParent = "/sys/fs/cgroup/ragflow-sandbox"
+	localCgroupNone          = "none"
+)
+
 // LocalProvider is the Go port of
 // `agent/sandbox/providers/local.py::LocalProvider`.
 type LocalProvider struct {
@@ -100,8 +133,19 @@ type LocalProvider struct {
 	maxArtifacts     int
 	maxArtifactBytes int
 
+	// Namespace isolation settings; unused when isolation is
+	// localIsolationNone.
+	isolation    string
+	network      bool
+	rootfsBinds  []string
+	tmpfsSizeMB  int
+	cpuLimit     float64
+	maxPids      int
+	cgroupParent string
+
 	mu          sync.Mutex
-	instances   map[string]string // instanceID -> instance dir
+	instances   map[string]string       // instanceID -> instance dir
+	kernels     map[string]*localKernel // instanceID -> stateful interpreter (local_session.go)
 	initialized bool
 }
 
@@ -124,6 +168,13 @@ func localConfigFromEnv() map[string]any {
 		"MAX_OUTPUT_BYTES":   os.Getenv("LOCAL_MAX_OUTPUT_BYTES"),
 		"MAX_ARTIFACTS":      os.Getenv("LOCAL_MAX_ARTIFACTS"),
 		"MAX_ARTIFACT_BYTES": os.Getenv("LOCAL_MAX_ARTIFACT_BYTES"),
+		"ISOLATION":          os.Getenv("LOCAL_ISOLATION"),
+		"NETWORK":            os.Getenv("LOCAL_NETWORK"),
+		"ROOTFS_BINDS":       os.Getenv("LOCAL_ROOTFS_BINDS"),
+		"TMPFS_SIZE_MB":      os.Getenv("LOCAL_TMPFS_SIZE_MB"),
+		"CPU_LIMIT":          os.Getenv("LOCAL_CPU_LIMIT"),
+		"MAX_PIDS":           os.Getenv("LOCAL_MAX_PIDS"),
+		"CGROUP_PARENT":      os.Getenv("LOCAL_CGROUP_PARENT"),
 	}
 }
 
@@ -140,7 +191,14 @@ func newLocalProviderFromConfig(cfg map[string]any) *LocalProvider {
 		maxOutputBytes:   configInt(cfg, "MAX_OUTPUT_BYTES", localDefaultMaxOutputBytes),
 		maxArtifacts:     configInt(cfg, "MAX_ARTIFACTS", localDefaultMaxArtifacts),
 		maxArtifactBytes: configInt(cfg, "MAX_ARTIFACT_BYTES", localDefaultMaxArtifactBytes),
+		isolation:        strings.ToLower(strings.TrimSpace(configString(cfg, "ISOLATION"))),
+		network:          configBool(cfg, "NETWORK", false),
+		tmpfsSizeMB:      configInt(cfg, "TMPFS_SIZE_MB", localDefaultTmpfsSizeMB),
+		cpuLimit:         configFloat(cfg, "CPU_LIMIT", localDefaultCPULimit),
+		maxPids:          configInt(cfg, "MAX_PIDS", localDefaultMaxPids),
+		cgroupParent:     configString(cfg, "CGROUP_PARENT"),
 		instances:        map[string]string{},
+		kernels:          map[string]*localKernel{},
 	}
 	if p.pythonBin == "" {
 		p.pythonBin = localDefaultPythonBin
@@ -151,6 +209,21 @@ func newLocalProviderFromConfig(cfg map[string]any) *LocalProvider {
 	if p.workDir == "" {
 		p.workDir = localDefaultWorkDir
 	}
+	if p.isolation == "" {
+		p.isolation = localIsolationNone
+	}
+	binds := configString(cfg, "ROOTFS_BINDS")
+	if binds == "" {
+		binds = localDefaultRootfsBinds
+	}
+	for _, b := range strings.Split(binds, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			p.rootfsBinds = append(p.rootfsBinds, b)
+		}
+	}
+	if p.cgroupParent == "" {
+		p.cgroupParent = localDefaultCgroupParent
+	}
 	return p
 }
 
@@ -159,9 +232,16 @@ func (p *LocalProvider) ProviderType() ProviderType { return ProviderLocal }
 
 // Initialize validates the work_dir (create if missing, ensure
 // writable) and flips the initialized flag. Unlike the Python
-// version, we do not set rlimits here — the limits are applied
-// to the child per-execution via Cmd.SysProcAttr.
+// version, we do not set rlimits here. With namespace isolation it
+// also checks the host supports it and prepares the cgroup parent;
+// a host that cannot isolate fails here rather than running the
+// code unsandboxed.
 func (p *LocalProvider) Initialize(ctx context.Context) error {
+	switch p.isolation {
+	case "", localIsolationNone, localIsolationNamespace:
+	default:
+		return fmt.Errorf("local: unknown isolation mode %q (known: none, namespace)", p.isolation)
+	}
 	if err := os.MkdirAll(p.workDir, 0o700); err != nil {
 		return fmt.Errorf("local: create work_dir %q: %w", p.workDir, err)
 	}
@@ -174,6 +254,11 @@ func (p *LocalProvider) Initialize(ctx context.Context) error {
 		return fmt.Errorf("local: work_dir %q not writable: %w", p.workDir, err)
 	}
 	_ = os.Remove(probe)
+	if p.isolation == localIsolationNamespace {
+		if err := p.initIsolation(); err != nil {
+			return err
+		}
+	}
 	p.mu.Lock()
 	p.initialized = true
 	p.mu.Unlock()
@@ -218,6 +303,7 @@ func (p *LocalProvider) CreateInstance(ctx context.Context, template string) (*S
 			"work_dir":   instanceDir,
 			"python_bin": p.pythonBin,
 			"node_bin":   p.nodeBin,
+			"isolation":  p.isolation,
 		},
 	}, nil
 }
@@ -262,6 +348,9 @@ func (p *LocalProvider) ExecuteCode(
 	if err != nil {
 		return nil, err
 	}
+	if k := p.kernel(inst.InstanceID); k != nil && lang == "python" {
+		return p.executeInKernel(ctx, k, inst, code, argsJSON, timeout)
+	}
 	var (
 		scriptPath string
 		cmdName    string
@@ -283,55 +372,56 @@ func (p *LocalProvider) ExecuteCode(
 		cmdArgs = []string{scriptPath}
 	}
 
-	// Build the child env. Matches the Python provider's
-	// _build_child_env: HOME / TMPDIR point at the instance dir,
-	// PYTHONUNBUFFERED is on, and a small set of thread-related
-	// vars pass through from the host env.
-	childEnv := buildLocalChildEnv(instanceDir)
-
 	// Use a context-derived cancel so callers can abort a
 	// long-running subprocess. The actual kill-on-timeout is
 	// enforced by exec.CommandContext + the timeout goroutine.
 	start := time.Now()
 
-	cmd := exec.CommandContext(ctx, cmdName, cmdArgs...)
-	cmd.Dir = instanceDir
-	cmd.Env = childEnv
-	// pdeath_signal + process group so the subprocess dies
-	// with the parent. On Linux this is SysProcAttr.Pdeathsig;
-	// Setpgid puts the child in its own process group, which
-	// lets us kill the whole group on timeout.
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid:   true,
-		Pdeathsig: syscall.SIGTERM,
-	}
-	// Apply rlimits via pre-start. Go's os/exec does not expose
-	// rlimit directly, so we do it after fork via the parent's
-	// process-group kill. We do NOT replicate the Python
-	// preexec_fn RLIMIT_* exactly because Go's os/exec does not
-	// support pre-start hooks portably. The Setpgid is the
-	// important part for hard-kill on timeout; rlimits are
-	// best-effort on POSIX (no-op on Windows — see build tag).
-	if p.maxMemoryMB > 0 {
-		// Advisory only; the subprocess inherits the parent's
-		// rlimits. Real isolation requires the SelfManaged or
-		// Aliyun / e2b providers. We do NOT try to set RLIMIT_AS
-		// post-fork because that would require ptrace or a
-		// side-channel — out of scope for a "local" provider.
+	var (
+		cmd *exec.Cmd
+		iso *isolatedRun
+	)
+	if p.isolation == localIsolationNamespace {
+		// The sandbox init copies the script into its own tmpfs
+		// work dir and copies the artifacts back on exit.
+		iso, err = p.isolatedCommand(ctx, instanceDir, cmdName, filepath.Base(scriptPath))
+		if err != nil {
+			return nil, err
+		}
+		defer iso.cleanup()
+		cmd = iso.cmd
+	} else {
+		// Build the child env. Matches the Python provider's
+		// _build_child_env: HOME / TMPDIR point at the instance dir,
+		// PYTHONUNBUFFERED is on, and a small set of thread-related
+		// vars pass through from the host env.
+		cmd = exec.CommandContext(ctx, cmdName, cmdArgs...)
+		cmd.Dir = instanceDir
+		cmd.Env = buildLocalChildEnv(instanceDir)
+		// pdeath_signal + process group so the subprocess dies
+		// with the parent. On Linux this is SysProcAttr.Pdeathsig;
+		// Setpgid puts the child in its own process group, which
+		// lets us kill the whole group on timeout.
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setpgid:   true,
+			Pdeathsig: syscall.SIGTERM,
+		}
+		// No rlimits here: Go's os/exec has no portable pre-start
+		// hook, so the child inherits the parent's limits and
+		// maxMemoryMB is not enforced. The namespace isolation
+		// mode enforces it (cgroup memory.max).
 	}
 
 	var stdout, stderr bytes.Buffer
-	maxOut := p.maxOutputBytes
-	if maxOut > 0 {
-		stdout = bytes.Buffer{}
-		stderr = bytes.Buffer{}
-	}
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("local: start subprocess: %w", err)
 	}
+	if iso != nil {
+		iso.started()
+	}
 
 	// Wait with a separate timer so we can hard-kill the process
 	// group on timeout. cmd.Wait alone does not kill on
@@ -361,9 +451,15 @@ func (p *LocalProvider) ExecuteCode(
 		return nil, fmt.Errorf("local: execution timed out after %d seconds", timeout)
 	}
 
+	if iso != nil {
+		if err := iso.setupError(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate output size: if stdout+stderr exceed the cap,
 	// surface as a runtime error (matches the Python provider).
-	if maxOut > 0 {
+	if maxOut := p.maxOutputBytes; maxOut > 0 {
 		combined := stdout.Len() + stderr.Len()
 		if combined > maxOut {
 			return nil, fmt.Errorf("local: output exceeds %d bytes (got %d)", maxOut, combined)
@@ -377,7 +473,7 @@ func (p *LocalProvider) ExecuteCode(
 	// the Python provider's _collect_artifacts behavior
 	// (allowlist of extensions, max count, max size per file,
 	// no symlinks).
-	artifacts, err := p.collectArtifacts(instanceDir)
+	artifacts, err := p.collectArtifacts(instanceDir, artifactsSince(start))
 	if err != nil {
 		// The Python side raises on violations; we mirror that.
 		return nil, fmt.Errorf("local: collect artifacts: %w", err)
@@ -392,6 +488,10 @@ func (p *LocalProvider) ExecuteCode(
 		"timeout":           timeout,
 		"artifacts":         artifacts,
 		"structured_result": structured,
+		"isolation":         p.isolation,
+	}
+	if iso != nil {
+		metadata["oom_killed"] = iso.oomKilled()
 	}
 	return &ExecutionResult{
 		Stdout:        cleanedStdout,
@@ -413,6 +513,7 @@ func (p *LocalProvider) DestroyInstance(ctx context.Context, inst *SandboxInstan
 		return fmt.Errorf("local: instance id required")
 	}
 	instanceDir := filepath.Join(p.workDir, inst.InstanceID)
+	p.dropKernel(inst.InstanceID)
 	p.mu.Lock()
 	delete(p.instances, inst.InstanceID)
 	p.mu.Unlock()
@@ -481,79 +582,30 @@ func buildLocalChildEnv(instanceDir string) []string {
 	return env
 }
 
-// collectArtifacts walks <instance_dir>/artifacts/ and returns
-// the list of files as {name, content_b64, mime_type, size}
-// records. Enforces the same limits the Python provider does:
-// max count, max per-file size, allowlist of extensions, no
-// symlinks.
-func (p *LocalProvider) collectArtifacts(instanceDir string) ([]map[string]any, error) {
-	root := filepath.Join(instanceDir, "artifacts")
-	info, err := os.Stat(root)
-	if errors.Is(err, os.ErrNotExist) {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-	if !info.IsDir() {
-		return nil, fmt.Errorf("artifacts path %q is not a directory", root)
+// mergeLocalChildEnv returns base plus the extra KEY=value pairs, minus
+// any variable base already sets: a configured env cannot override the
+// provider's HOME, TMPDIR or PATH.
+func mergeLocalChildEnv(base, extra []string) []string {
+	set := make(map[string]bool, len(base))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		set[name] = true
+	}
+	env := append([]string(nil), base...)
+	for _, kv := range extra {
+		if name, _, _ := strings.Cut(kv, "="); !set[name] {
+			env = append(env, kv)
+		}
 	}
+	return env
+}
 
-	var out []map[string]any
-	err = filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		// Skip the root itself.
-		if path == root {
-			return nil
-		}
-		// Reject symlinks. os.DirEntry's Type() returns the
-		// type, but a symlink reports Type()&ModeSymlink != 0.
-		if d.Type()&os.ModeSymlink != 0 {
-			return fmt.Errorf("artifact symlinks are not allowed: %s", d.Name())
-		}
-		if d.IsDir() {
-			return nil
-		}
-		if !d.Type().IsRegular() {
-			return fmt.Errorf("unsupported artifact entry: %s", d.Name())
-		}
-		if len(out) >= p.maxArtifacts {
-			return fmt.Errorf("local execution produced more than %d artifacts", p.maxArtifacts)
-		}
-		fi, err := d.Info()
-		if err != nil {
-			return err
-		}
-		if fi.Size() > int64(p.maxArtifactBytes) {
-			return fmt.Errorf("artifact exceeds %d bytes: %s", p.maxArtifactBytes, d.Name())
-		}
-		ext := strings.ToLower(filepath.Ext(d.Name()))
-		if _, ok := allowedArtifactExts[ext]; !ok {
-			return fmt.Errorf("unsupported artifact type: %s", d.Name())
-		}
-		body, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-		rel, err := filepath.Rel(root, path)
-		if err != nil {
-			rel = d.Name()
-		}
-		mimeType := mime.TypeByExtension(ext)
-		if mimeType == "" {
-			mimeType = "application/octet-stream"
-		}
-		out = append(out, map[string]any{
-			"name":        filepath.ToSlash(rel),
-			"content_b64": base64.StdEncoding.EncodeToString(body),
-			"mime_type":   mimeType,
-			"size":        fi.Size(),
-		})
-		return nil
-	})
-	return out, err
+// collectArtifacts walks <instance_dir>/artifacts/ and returns
+// the list of files as {name, content_b64, mime_type, size}
+// records, with this provider's count and size limits. See
+// collectArtifactDir.
+func (p *LocalProvider) collectArtifacts(instanceDir string, since time.Time) ([]map[string]any, error) {
+	return collectArtifactDir(filepath.Join(instanceDir, "artifacts"), since, p.maxArtifacts, p.maxArtifactBytes)
 }
 
 // killProcessGroup sends signal to the process group of pid.
@@ -0,0 +1,315 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// local_isolation_linux.go — the host half of the local provider's
+// namespace isolation mode (LOCAL_ISOLATION=namespace).
+//
+// Each execution re-executes this binary as the sandbox init (see
+// local_nsinit_linux.go) in new user, mount, PID, IPC, UTS and cgroup
+// namespaces, plus a new network namespace unless LOCAL_NETWORK is
+// set. The only uid mapped into the user namespace is the server's
+// own, so nothing in the sandbox is more privileged than the server.
+//
+// Resource limits come from cgroup v2: every execution gets a child
+// cgroup of LOCAL_CGROUP_PARENT with memory.max (swap off), cpu.max
+// and pids.max, and the helper is cloned straight into it, so no
+// sandboxed process ever runs outside the limits. An operator who
+// cannot delegate a cgroup v2 subtree sets LOCAL_CGROUP_PARENT=none
+// and keeps the namespaces, seccomp and tmpfs caps without the
+// memory / CPU / pids limits.
+
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sys/unix"
+)
+
+// cgroupControllers are the controllers the per-run cgroups use.
+var cgroupControllers = []string{"memory", "cpu", "pids"}
+
+// cpuPeriodUs is the cpu.max period.
+const cpuPeriodUs = 100000
+
+// isolatedRun is one namespaced execution: the helper command, its
+// setup-status pipe and its cgroup.
+type isolatedRun struct {
+	cmd       *exec.Cmd
+	statusR   *os.File
+	statusW   *os.File
+	status    chan string
+	cgroupDir string
+	cgroupFD  *os.File
+}
+
+// stageDir is the empty directory each sandbox assembles its root
+// on. Every execution mounts over it in its own mount namespace, so
+// one directory serves all of them.
+func (p *LocalProvider) stageDir() string {
+	return filepath.Join(p.workDir, ".rootfs")
+}
+
+// cgroupsEnabled reports whether executions get a cgroup.
+func (p *LocalProvider) cgroupsEnabled() bool {
+	return p.cgroupParent != "" && p.cgroupParent != localCgroupNone
+}
+
+// initIsolation checks that the host can run namespaced executions
+// and prepares the stage dir and the cgroup parent.
+func (p *LocalProvider) initIsolation() error {
+	if _, err := seccompArch(); err != nil {
+		return fmt.Errorf("local: namespace isolation: %w", err)
+	}
+	if err := checkUserNamespaces(); err != nil {
+		return fmt.Errorf("local: namespace isolation: %w", err)
+	}
+	if _, err := os.Stat("/proc/self/exe"); err != nil {
+		return fmt.Errorf("local: namespace isolation: %w", err)
+	}
+	if err := os.MkdirAll(p.stageDir(), 0o700); err != nil {
+		return fmt.Errorf("local: create stage dir: %w", err)
+	}
+	if !p.cgroupsEnabled() {
+		return nil
+	}
+	if err := prepareCgroupParent(p.cgroupParent); err != nil {
+		return fmt.Errorf("local: namespace isolation: %w (set LOCAL_CGROUP_PARENT=none to run without cgroup limits)", err)
+	}
+	return nil
+}
+
+// checkUserNamespaces fails when the kernel refuses unprivileged user
+// namespaces.
+func checkUserNamespaces() error {
+	if b, err := os.ReadFile("/proc/sys/user/max_user_namespaces"); err == nil && strings.TrimSpace(string(b)) == "0" {
+		return errors.New("user namespaces are disabled (user.max_user_namespaces=0)")
+	}
+	if os.Geteuid() != 0 {
+		if b, err := os.ReadFile("/proc/sys/kernel/unprivileged_userns_clone"); err == nil && strings.TrimSpace(string(b)) == "0" {
+			return errors.New("unprivileged user namespaces are disabled (kernel.unprivileged_userns_clone=0)")
+		}
+	}
+	return nil
+}
+
+// prepareCgroupParent creates dir on the cgroup v2 hierarchy and
+// enables the memory, cpu and pids controllers for its children.
+func prepareCgroupParent(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create cgroup %q: %w", dir, err)
+	}
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return fmt.Errorf("statfs cgroup %q: %w", dir, err)
+	}
+	if st.Type != unix.CGROUP2_SUPER_MAGIC {
+		return fmt.Errorf("cgroup %q is not on a cgroup v2 hierarchy", dir)
+	}
+	for _, c := range cgroupControllers {
+		if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte("+"+c), 0o644); err != nil {
+			return fmt.Errorf("enable the %s controller under %q: %w", c, dir, err)
+		}
+	}
+	return nil
+}
+
+// isolatedCommand prepares the namespaced run of interpreter on
+// instanceDir/script. Call started after cmd.Start, and cleanup when
+// done.
+func (p *LocalProvider) isolatedCommand(ctx context.Context, instanceDir, interpreter, script string) (*isolatedRun, error) {
+	return p.newIsolatedRun(ctx, nsInitSpec{
+		HostDir:      instanceDir,
+		StageDir:     p.stageDir(),
+		Script:       script,
+		Argv:         []string{interpreter, nsWorkspace + "/" + script},
+		Env:          buildLocalChildEnv(nsWorkspace),
+		Binds:        p.rootfsBinds,
+		TmpfsMB:      p.tmpfsSizeMB,
+		Network:      p.network,
+		NoFile:       localDefaultNoFile,
+		MaxArtifacts: p.maxArtifacts + 1,
+	})
+}
+
+// isolatedServer prepares the namespaced run of a long-lived process
+// that talks over its stdin / stdout (a stdio MCP server). workdir, when
+// set, is a path relative to the workspace and becomes the cwd.
+func (p *LocalProvider) isolatedServer(instanceDir string, argv, env []string, workdir string) (*isolatedRun, error) {
+	return p.newIsolatedRun(context.Background(), nsInitSpec{
+		HostDir:  instanceDir,
+		StageDir: p.stageDir(),
+		Argv:     argv,
+		Env:      mergeLocalChildEnv(buildLocalChildEnv(nsWorkspace), env),
+		Binds:    p.rootfsBinds,
+		TmpfsMB:  p.tmpfsSizeMB,
+		Network:  p.network,
+		NoFile:   localDefaultNoFile,
+		Stdin:    true,
+		Workdir:  workdir,
+	})
+}
+
+// newIsolatedRun builds the helper command for nsSpec.
+func (p *LocalProvider) newIsolatedRun(ctx context.Context, nsSpec nsInitSpec) (*isolatedRun, error) {
+	spec, err := json.Marshal(nsSpec)
+	if err != nil {
+		return nil, fmt.Errorf("local: encode sandbox spec: %w", err)
+	}
+	r := &isolatedRun{status: make(chan string, 1)}
+	if r.statusR, r.statusW, err = os.Pipe(); err != nil {
+		return nil, fmt.Errorf("local: status pipe: %w", err)
+	}
+
+	flags := uintptr(unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID |
+		unix.CLONE_NEWIPC | unix.CLONE_NEWUTS | unix.CLONE_NEWCGROUP)
+	if !p.network {
+		flags |= unix.CLONE_NEWNET
+	}
+	attr := &syscall.SysProcAttr{
+		Setpgid:    true,
+		Pdeathsig:  syscall.SIGKILL,
+		Cloneflags: flags,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Geteuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getegid(), Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+	}
+	if p.cgroupsEnabled() {
+		if err := r.createCgroup(p); err != nil {
+			r.cleanup()
+			return nil, err
+		}
+		attr.UseCgroupFD = true
+		attr.CgroupFD = int(r.cgroupFD.Fd())
+	}
+
+	r.cmd = exec.CommandContext(ctx, "/proc/self/exe")
+	r.cmd.Args = []string{"ragflow-sandbox-init"}
+	r.cmd.Env = []string{nsInitEnv + "=" + string(spec)}
+	r.cmd.ExtraFiles = []*os.File{r.statusW}
+	r.cmd.SysProcAttr = attr
+	return r, nil
+}
+
+// createCgroup makes the run's cgroup and writes its limits.
+func (r *isolatedRun) createCgroup(p *LocalProvider) error {
+	dir := filepath.Join(p.cgroupParent, "run-"+uuid.NewString())
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return fmt.Errorf("local: create cgroup: %w", err)
+	}
+	r.cgroupDir = dir
+	limits := map[string]string{}
+	if p.maxMemoryMB > 0 {
+		limits["memory.max"] = strconv.Itoa(p.maxMemoryMB << 20)
+		limits["memory.swap.max"] = "0"
+	}
+	if p.cpuLimit > 0 {
+		limits["cpu.max"] = fmt.Sprintf("%d %d", int(p.cpuLimit*cpuPeriodUs), cpuPeriodUs)
+	}
+	if p.maxPids > 0 {
+		limits["pids.max"] = strconv.Itoa(p.maxPids)
+	}
+	for file, v := range limits {
+		err := os.WriteFile(filepath.Join(dir, file), []byte(v), 0o644)
+		// memory.swap.max is absent when the kernel has no swap
+		// accounting; there is then no swap to turn off.
+		if err != nil && !(file == "memory.swap.max" && errors.Is(err, os.ErrNotExist)) {
+			return fmt.Errorf("local: set %s: %w", file, err)
+		}
+	}
+	fd, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("local: open cgroup: %w", err)
+	}
+	r.cgroupFD = fd
+	return nil
+}
+
+// started closes the parent's copy of the status pipe's write end and
+// starts draining the read end.
+func (r *isolatedRun) started() {
+	_ = r.statusW.Close()
+	r.statusW = nil
+	go func() {
+		b, _ := io.ReadAll(r.statusR)
+		r.status <- string(bytes.TrimSpace(b))
+	}()
+}
+
+// setupError returns the helper's setup failure, if any. Call it
+// after the helper exited.
+func (r *isolatedRun) setupError() error {
+	if msg := <-r.status; msg != "" {
+		return fmt.Errorf("local: sandbox setup failed: %s", msg)
+	}
+	return nil
+}
+
+// oomKilled reports whether the run's cgroup killed a process for
+// exceeding memory.max.
+func (r *isolatedRun) oomKilled() bool {
+	if r.cgroupDir == "" {
+		return false
+	}
+	b, err := os.ReadFile(filepath.Join(r.cgroupDir, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if n, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			return strings.TrimSpace(n) != "0"
+		}
+	}
+	return false
+}
+
+// cleanup releases the pipe and removes the cgroup, killing anything
+// still in it.
+func (r *isolatedRun) cleanup() {
+	for _, f := range []*os.File{r.statusR, r.statusW, r.cgroupFD} {
+		if f != nil {
+			_ = f.Close()
+		}
+	}
+	if r.cgroupDir == "" {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(r.cgroupDir, "cgroup.kill"), []byte("1"), 0o644)
+	// rmdir fails with EBUSY until the kernel has reaped the members.
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(r.cgroupDir); err == nil || errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
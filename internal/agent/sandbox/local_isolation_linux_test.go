@@ -0,0 +1,262 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sandbox
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	mcpclient "ragflow/internal/utility"
+)
+
+// TestSeccompProgram_Shape pins the filter layout: arch check first,
+// every denied syscall answered with EPERM, in-range jumps, and an
+// ALLOW fall-through.
+func TestSeccompProgram_Shape(t *testing.T) {
+	arch, err := seccompArch()
+	if err != nil {
+		t.Skip(err)
+	}
+	prog := seccompProgram(arch)
+	if len(prog) > 4096 {
+		t.Fatalf("program has %d instructions, over BPF_MAXINSNS", len(prog))
+	}
+	if prog[0].K != seccompOffArch || prog[1].K != arch {
+		t.Fatalf("program does not start with the arch check: %+v", prog[:2])
+	}
+	if last := prog[len(prog)-1]; last.Code != unix.BPF_RET|unix.BPF_K || last.K != seccompRetAllow {
+		t.Fatalf("last instruction = %+v, want RET ALLOW", last)
+	}
+	denied := map[uint32]bool{}
+	for i, ins := range prog {
+		if ins.Code&0x07 != unix.BPF_JMP {
+			continue
+		}
+		if i+1+int(ins.Jt) >= len(prog) || i+1+int(ins.Jf) >= len(prog) {
+			t.Fatalf("instruction %d jumps out of the program: %+v", i, ins)
+		}
+		if ins.Code == unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K && ins.Jt == 0 && ins.Jf == 1 &&
+			prog[i+1].K == unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM) {
+			denied[ins.K] = true
+		}
+	}
+	for _, nr := range []uint32{unix.SYS_MOUNT, unix.SYS_UNSHARE, unix.SYS_SETNS, unix.SYS_PTRACE, unix.SYS_BPF} {
+		if !denied[nr] {
+			t.Errorf("syscall %d is not denied", nr)
+		}
+	}
+}
+
+// TestNewLocalProviderFromConfig_Isolation covers the isolation keys.
+func TestNewLocalProviderFromConfig_Isolation(t *testing.T) {
+	t.Parallel()
+	p := newLocalProviderFromConfig(map[string]any{})
+	if p.isolation != localIsolationNone || p.network || p.cgroupParent != localDefaultCgroupParent {
+		t.Errorf("defaults: isolation=%q network=%v cgroupParent=%q", p.isolation, p.network, p.cgroupParent)
+	}
+	if strings.Join(p.rootfsBinds, ",") != localDefaultRootfsBinds {
+		t.Errorf("rootfsBinds = %v", p.rootfsBinds)
+	}
+
+	p = newLocalProviderFromConfig(map[string]any{
+		"ISOLATION":     " Namespace ",
+		"NETWORK":       "true",
+		"ROOTFS_BINDS":  " /usr, /opt/python ,",
+		"TMPFS_SIZE_MB": float64(16),
+		"CPU_LIMIT":     "0.5",
+		"MAX_PIDS":      float64(8),
+		"CGROUP_PARENT": "none",
+	})
+	if p.isolation != localIsolationNamespace || !p.network {
+		t.Errorf("isolation=%q network=%v", p.isolation, p.network)
+	}
+	if got := strings.Join(p.rootfsBinds, ","); got != "/usr,/opt/python" {
+		t.Errorf("rootfsBinds = %q", got)
+	}
+	if p.tmpfsSizeMB != 16 || p.cpuLimit != 0.5 || p.maxPids != 8 || p.cgroupsEnabled() {
+		t.Errorf("tmpfs=%d cpu=%v pids=%d cgroups=%v", p.tmpfsSizeMB, p.cpuLimit, p.maxPids, p.cgroupsEnabled())
+	}
+}
+
+func TestLocal_Initialize_RejectsUnknownIsolation(t *testing.T) {
+	p := newLocalProviderFromConfig(map[string]any{"WORK_DIR": t.TempDir(), "ISOLATION": "chroot"})
+	if err := p.Initialize(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown isolation mode") {
+		t.Fatalf("Initialize = %v, want unknown isolation mode", err)
+	}
+}
+
+// newIsolatedLocalForTest builds a namespace-isolated provider without
+// cgroups (the CI host may not delegate a cgroup v2 subtree), or skips
+// when the host cannot create the namespaces.
+func newIsolatedLocalForTest(t *testing.T) *LocalProvider {
+	t.Helper()
+	if _, err := os.Stat("/usr/bin/python3"); err != nil {
+		t.Skip("/usr/bin/python3 missing — skipping namespace sandbox test")
+	}
+	p := newLocalProviderFromConfig(map[string]any{
+		"WORK_DIR":      t.TempDir(),
+		"TIMEOUT":       float64(10),
+		"ISOLATION":     localIsolationNamespace,
+		"CGROUP_PARENT": localCgroupNone,
+	})
+	if err := p.Initialize(context.Background()); err != nil {
+		t.Skipf("namespace isolation unavailable: %v", err)
+	}
+	return p
+}
+
+// TestLocal_ExecuteCode_Namespace runs real code in the sandbox and
+// checks what it can see and do.
+func TestLocal_ExecuteCode_Namespace(t *testing.T) {
+	p := newIsolatedLocalForTest(t)
+	ctx := context.Background()
+	inst, err := p.CreateInstance(ctx, "python")
+	if err != nil {
+		t.Fatalf("CreateInstance: %v", err)
+	}
+	defer p.DestroyInstance(ctx, inst)
+
+	code := `
+import ctypes, os, socket
+
+def main():
+    with open("artifacts/out.csv", "w") as f:
+        f.write("a,b\n1,2\n")
+    try:
+        socket.create_connection(("1.1.1.1", 53), timeout=2).close()
+        net = "reachable"
+    except OSError:
+        net = "unreachable"
+    libc = ctypes.CDLL(None, use_errno=True)
+    libc.unshare(0x10000000)
+    try:
+        open("/usr/ragflow-probe", "w")
+        usr_writable = True
+    except OSError:
+        usr_writable = False
+    return {
+        "hostname": socket.gethostname(),
+        "host_pids_hidden": len([d for d in os.listdir("/proc") if d.isdigit()]) < 10,
+        "cwd": os.getcwd(),
+        "work_dir_visible": os.path.exists(` + "\"" + p.workDir + "\"" + `),
+        "net": net,
+        "unshare_errno": ctypes.get_errno(),
+        "usr_writable": usr_writable,
+    }
+`
+	result, err := p.ExecuteCode(ctx, inst, code, "python", 10, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("ExitCode = %d; stderr=%q", result.ExitCode, result.Stderr)
+	}
+	sr, _ := result.Metadata["structured_result"].(map[string]any)
+	got, _ := sr["value"].(map[string]any)
+	want := map[string]any{
+		"hostname":         "sandbox",
+		"host_pids_hidden": true,
+		"cwd":              nsWorkspace,
+		"work_dir_visible": false,
+		"net":              "unreachable",
+		"unshare_errno":    float64(unix.EPERM),
+		"usr_writable":     false,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v (result %v)", k, got[k], v, got)
+		}
+	}
+	arts, _ := result.Metadata["artifacts"].([]map[string]any)
+	if len(arts) != 1 || arts[0]["name"] != "out.csv" {
+		t.Errorf("artifacts = %v, want out.csv copied back", arts)
+	}
+}
+
+func TestLocal_ExecuteCode_NamespaceSetupFailure(t *testing.T) {
+	p := newIsolatedLocalForTest(t)
+	p.pythonBin = "no-such-python"
+	ctx := context.Background()
+	inst, err := p.CreateInstance(ctx, "python")
+	if err != nil {
+		t.Fatalf("CreateInstance: %v", err)
+	}
+	defer p.DestroyInstance(ctx, inst)
+	_, err = p.ExecuteCode(ctx, inst, "def main(): return 1", "python", 10, nil)
+	if err == nil || !strings.Contains(err.Error(), "sandbox setup failed") || !strings.Contains(err.Error(), "no-such-python") {
+		t.Fatalf("ExecuteCode error = %v, want a setup failure naming the interpreter", err)
+	}
+}
+
+func TestLocal_ExecuteCode_NamespaceTimeoutKillsSandbox(t *testing.T) {
+	p := newIsolatedLocalForTest(t)
+	ctx := context.Background()
+	inst, err := p.CreateInstance(ctx, "python")
+	if err != nil {
+		t.Fatalf("CreateInstance: %v", err)
+	}
+	defer p.DestroyInstance(ctx, inst)
+	code := "import subprocess, time\ndef main():\n    subprocess.Popen(['sleep', '60'])\n    time.sleep(60)\n"
+	_, err = p.ExecuteCode(ctx, inst, code, "python", 1, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("ExecuteCode error = %v, want a timeout", err)
+	}
+}
+
+// TestMCPStdioLauncher_NamespaceCwd runs a stdio MCP server in the
+// namespace sandbox: its cwd is created under the workspace, and a
+// configured PATH cannot replace the launcher's.
+func TestMCPStdioLauncher_NamespaceCwd(t *testing.T) {
+	p := newIsolatedLocalForTest(t)
+	mcpclient.SetStdioLauncher(newMCPStdioLauncher(p, "/bin/sh", "PATH").launch)
+	t.Cleanup(func() { mcpclient.SetStdioLauncher(nil) })
+
+	script := `read line
+printf '{"jsonrpc":"2.0","id":0,"result":{"capabilities":{"tools":{}}}}\n'
+read line
+read line
+printf '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"probe","description":"%s|%s"}]}}\n' "$PWD" "$PATH"
+read line`
+	tools, err := mcpclient.FetchTools(context.Background(), mcpclient.FetchOptions{
+		ServerType: mcpclient.TransportStdio,
+		Stdio: &mcpclient.StdioServer{
+			Command: "/bin/sh",
+			Args:    []string{"-c", script},
+			Env:     map[string]string{"PATH": "/nowhere"},
+			Dir:     "data/srv",
+		},
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("FetchTools: %v", err)
+	}
+	if len(tools) != 1 {
+		t.Fatalf("tools = %+v, want one", tools)
+	}
+	cwd, path, _ := strings.Cut(tools[0].Description, "|")
+	if cwd != nsWorkspace+"/data/srv" {
+		t.Errorf("cwd = %q, want %s/data/srv", cwd, nsWorkspace)
+	}
+	if path == "/nowhere" {
+		t.Errorf("configured PATH replaced the launcher's")
+	}
+}
@@ -0,0 +1,49 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// local_isolation_other.go — namespace isolation is Linux-only; on
+// other POSIX hosts LOCAL_ISOLATION=namespace fails Initialize.
+
+//go:build !linux && !windows
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+var errLocalIsolationUnsupported = errors.New("local: namespace isolation requires Linux")
+
+type isolatedRun struct {
+	cmd *exec.Cmd
+}
+
+func (p *LocalProvider) initIsolation() error { return errLocalIsolationUnsupported }
+
+func (p *LocalProvider) isolatedCommand(ctx context.Context, instanceDir, interpreter, script string) (*isolatedRun, error) {
+	return nil, errLocalIsolationUnsupported
+}
+
+func (p *LocalProvider) isolatedServer(instanceDir string, argv, env []string, workdir string) (*isolatedRun, error) {
+	return nil, errLocalIsolationUnsupported
+}
+
+func (r *isolatedRun) started()          {}
+func (r *isolatedRun) setupError() error { return nil }
+func (r *isolatedRun) oomKilled() bool   { return false }
+func (r *isolatedRun) cleanup()          {}
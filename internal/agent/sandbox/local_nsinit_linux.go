@@ -0,0 +1,583 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// local_nsinit_linux.go — the in-namespace half of the local
+// provider's namespace isolation mode.
+//
+// Go cannot run code between fork and exec, so the provider
+// re-executes its own binary (/proc/self/exe) inside fresh user,
+// mount, PID, IPC, UTS, cgroup and (unless networking is allowed)
+// network namespaces, with nsInitEnv carrying an nsInitSpec. The
+// package init below catches that re-execution before main runs and
+// becomes PID 1 of the sandbox:
+//
+//  1. assemble a root on a tmpfs from read-only binds of the host's
+//     system directories, a private /proc, a minimal /dev and
+//     size-capped tmpfs mounts for /workspace and /tmp;
+//  2. copy the wrapped script into /workspace and pivot into the root;
+//  3. empty the capability bounding set, install the seccomp filter
+//     and start the interpreter;
+//  4. reap until the interpreter exits, kill whatever it left behind
+//     and copy /workspace/artifacts back to the host instance dir.
+//
+// A stdio MCP server (mcp_stdio.go) runs the same way with no script:
+// its stdin is passed through instead of /dev/null, it may get a host
+// directory bound read-only as its working directory, and there are no
+// artifacts to copy back.
+//
+// Setup failures are written to fd 3, which the parent reads; a
+// clean setup closes fd 3 before the interpreter starts. The helper
+// exits with the interpreter's exit code.
+
+package sandbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// nsInitEnv carries the JSON nsInitSpec into the re-executed
+	// binary. It is the helper's only environment variable.
+	nsInitEnv = "RAGFLOW_SANDBOX_NSINIT"
+	// nsWorkspace is the interpreter's cwd and HOME inside the sandbox.
+	nsWorkspace = "/workspace"
+	// nsStatusFD is the setup-status pipe (the parent's ExtraFiles[0]).
+	nsStatusFD = 3
+	// nsSetupFailedExit is the helper's exit code when setup fails.
+	nsSetupFailedExit = 125
+)
+
+// nsInitSpec is what the parent tells the helper to build.
+type nsInitSpec struct {
+	// HostDir is the host instance dir: it holds Script and receives
+	// the artifacts.
+	HostDir string `json:"host_dir"`
+	// StageDir is an empty host dir the new root is assembled on.
+	StageDir string `json:"stage_dir"`
+	// Script is copied from HostDir into /workspace; empty for none.
+	Script  string   `json:"script"`
+	Argv    []string `json:"argv"`
+	Env     []string `json:"env"`
+	Binds   []string `json:"binds"`
+	TmpfsMB int      `json:"tmpfs_mb"`
+	Network bool     `json:"network"`
+	NoFile  int      `json:"nofile"`
+	// MaxArtifacts bounds how many entries are copied back; one more
+	// than the provider's cap so collectArtifacts still reports the
+	// overflow. Zero copies nothing back.
+	MaxArtifacts int `json:"max_artifacts"`
+	// Stdin passes the helper's stdin through to the process.
+	Stdin bool `json:"stdin"`
+	// Workdir, when set, is a path relative to /workspace, created
+	// before the process starts and used as its cwd.
+	Workdir string `json:"workdir"`
+}
+
+func init() {
+	raw, ok := os.LookupEnv(nsInitEnv)
+	if !ok {
+		return
+	}
+	os.Exit(runNSInit(raw))
+}
+
+// runNSInit is the helper's main. It returns the process exit code.
+func runNSInit(raw string) int {
+	// Keep every step, including the interpreter's fork, on one thread
+	// so per-thread state (no_new_privs) reaches the interpreter.
+	runtime.LockOSThread()
+	syscall.CloseOnExec(nsStatusFD)
+	status := os.NewFile(nsStatusFD, "status")
+	fail := func(err error) int {
+		_, _ = io.WriteString(status, err.Error())
+		_ = status.Close()
+		return nsSetupFailedExit
+	}
+
+	var spec nsInitSpec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return fail(fmt.Errorf("decode spec: %w", err))
+	}
+	if len(spec.Argv) == 0 {
+		return fail(errors.New("empty argv"))
+	}
+	hostFD, err := unix.Open(spec.HostDir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fail(fmt.Errorf("open instance dir: %w", err))
+	}
+	if err := nsBuildRoot(&spec, hostFD); err != nil {
+		return fail(err)
+	}
+	if err := nsRestrict(&spec); err != nil {
+		return fail(err)
+	}
+	bin, err := nsLookPath(spec.Argv[0], spec.Env)
+	if err != nil {
+		return fail(err)
+	}
+	stdin := os.Stdin
+	if !spec.Stdin {
+		stdin, _ = os.Open(os.DevNull)
+	}
+	dir := filepath.Join(nsWorkspace, spec.Workdir)
+	proc, err := os.StartProcess(bin, spec.Argv, &os.ProcAttr{
+		Dir:   dir,
+		Env:   spec.Env,
+		Files: []*os.File{stdin, os.Stdout, os.Stderr},
+		Sys:   &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL},
+	})
+	if err != nil {
+		return fail(fmt.Errorf("start %s: %w", spec.Argv[0], err))
+	}
+	_ = status.Close()
+
+	code := nsReap(proc.Pid)
+	// Whatever the interpreter left running dies with the namespace;
+	// kill it now so nothing writes artifacts while they are copied.
+	_ = unix.Kill(-1, unix.SIGKILL)
+	for {
+		if _, err := unix.Wait4(-1, nil, 0, nil); err != nil && err != unix.EINTR {
+			break
+		}
+	}
+	if budget := spec.MaxArtifacts; budget > 0 {
+		if err := nsCopyOut(hostFD, &budget); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: copy artifacts: %v\n", err)
+		}
+	}
+	return code
+}
+
+// nsBuildRoot assembles the sandbox root on spec.StageDir and pivots
+// into it.
+func nsBuildRoot(spec *nsInitSpec, hostFD int) error {
+	root := spec.StageDir
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755,size=1m"); err != nil {
+		return fmt.Errorf("mount root tmpfs: %w", err)
+	}
+	for _, src := range spec.Binds {
+		if err := nsBindReadOnly(filepath.Clean(src), filepath.Join(root, src)); err != nil {
+			return err
+		}
+	}
+
+	proc := filepath.Join(root, "proc")
+	if err := os.Mkdir(proc, 0o555); err != nil {
+		return err
+	}
+	if err := unix.Mount("proc", proc, "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("mount /proc: %w", err)
+	}
+	if err := nsMountDev(filepath.Join(root, "dev"), spec.TmpfsMB); err != nil {
+		return err
+	}
+	size := fmt.Sprintf("mode=0777,size=%dm", spec.TmpfsMB)
+	for _, dir := range []string{nsWorkspace, "/tmp"} {
+		dst := filepath.Join(root, dir)
+		if err := os.Mkdir(dst, 0o777); err != nil {
+			return err
+		}
+		if err := unix.Mount("tmpfs", dst, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, size); err != nil {
+			return fmt.Errorf("mount %s: %w", dir, err)
+		}
+	}
+	if spec.Script != "" {
+		if err := nsCopyIn(hostFD, spec.Script, filepath.Join(root, nsWorkspace, spec.Script)); err != nil {
+			return fmt.Errorf("copy script: %w", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, nsWorkspace, "artifacts"), 0o700); err != nil {
+		return err
+	}
+	if spec.Workdir != "" {
+		if !filepath.IsLocal(spec.Workdir) {
+			return fmt.Errorf("workdir %q escapes the workspace", spec.Workdir)
+		}
+		if err := os.MkdirAll(filepath.Join(root, nsWorkspace, spec.Workdir), 0o777); err != nil {
+			return err
+		}
+	}
+	if err := unix.Mount("", root, "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+
+	if err := unix.Chdir(root); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := unix.Unmount(".", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach old root: %w", err)
+	}
+	return unix.Chdir(nsWorkspace)
+}
+
+// nsBindReadOnly binds src at dst and makes it and every mount below
+// it read-only. Symlinks (merged-/usr distributions link /bin, /lib)
+// are recreated rather than bound; missing sources are skipped.
+func nsBindReadOnly(src, dst string) error {
+	fi, err := os.Lstat(src)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case fi.IsDir():
+		err = os.Mkdir(dst, 0o755)
+	default:
+		err = os.WriteFile(dst, nil, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	if err := unix.Mount(src, dst, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", src, err)
+	}
+	mounts, err := nsMountsUnder(dst)
+	if err != nil {
+		return err
+	}
+	for _, mp := range mounts {
+		var st unix.Statfs_t
+		if err := unix.Statfs(mp, &st); err != nil {
+			return fmt.Errorf("statfs %s: %w", mp, err)
+		}
+		// A remount inside a user namespace must keep the flags the
+		// host locked on the mount.
+		flags := uintptr(unix.MS_REMOUNT | unix.MS_BIND | unix.MS_RDONLY)
+		for stFlag, msFlag := range map[int64]uintptr{
+			unix.ST_NOSUID:     unix.MS_NOSUID,
+			unix.ST_NODEV:      unix.MS_NODEV,
+			unix.ST_NOEXEC:     unix.MS_NOEXEC,
+			unix.ST_NOATIME:    unix.MS_NOATIME,
+			unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+			unix.ST_RELATIME:   unix.MS_RELATIME,
+		} {
+			if int64(st.Flags)&stFlag != 0 {
+				flags |= msFlag
+			}
+		}
+		if err := unix.Mount("", mp, "", flags, ""); err != nil {
+			return fmt.Errorf("remount %s read-only: %w", mp, err)
+		}
+	}
+	return nil
+}
+
+// nsMountsUnder lists the mount points at or below dir, parents first,
+// from /proc/self/mountinfo.
+func nsMountsUnder(dir string) ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mp := nsUnescapeMountPath(fields[4])
+		if mp == dir || strings.HasPrefix(mp, dir+"/") {
+			out = append(out, mp)
+		}
+	}
+	return out, sc.Err()
+}
+
+// nsUnescapeMountPath undoes mountinfo's octal escaping of spaces,
+// tabs, newlines and backslashes.
+func nsUnescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// nsMountDev builds a minimal /dev: the harmless character devices
+// bound from the host, the /proc/self/fd links and a /dev/shm tmpfs.
+func nsMountDev(dev string, shmMB int) error {
+	if err := os.Mkdir(dev, 0o755); err != nil {
+		return err
+	}
+	if err := unix.Mount("tmpfs", dev, "tmpfs", unix.MS_NOSUID|unix.MS_NOEXEC, "mode=0755,size=64k"); err != nil {
+		return fmt.Errorf("mount /dev: %w", err)
+	}
+	for _, name := range []string{"null", "zero", "full", "random", "urandom"} {
+		src := "/dev/" + name
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		dst := filepath.Join(dev, name)
+		if err := os.WriteFile(dst, nil, 0o666); err != nil {
+			return err
+		}
+		if err := unix.Mount(src, dst, "", unix.MS_BIND, ""); err != nil {
+			return fmt.Errorf("bind %s: %w", src, err)
+		}
+	}
+	for name, target := range map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	} {
+		if err := os.Symlink(target, filepath.Join(dev, name)); err != nil {
+			return err
+		}
+	}
+	shm := filepath.Join(dev, "shm")
+	if err := os.Mkdir(shm, 0o1777); err != nil {
+		return err
+	}
+	if err := unix.Mount("tmpfs", shm, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC,
+		fmt.Sprintf("mode=1777,size=%dm", shmMB)); err != nil {
+		return fmt.Errorf("mount /dev/shm: %w", err)
+	}
+	return nil
+}
+
+// nsCopyIn copies name from the host instance dir to dst.
+func nsCopyIn(hostFD int, name, dst string) error {
+	fd, err := unix.Openat(hostFD, name, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	src := os.NewFile(uintptr(fd), name)
+	defer src.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// nsRestrict applies the process-level restrictions the interpreter
+// inherits: hostname, loopback, rlimits, no capabilities, seccomp.
+func nsRestrict(spec *nsInitSpec) error {
+	if err := unix.Sethostname([]byte("sandbox")); err != nil {
+		return fmt.Errorf("set hostname: %w", err)
+	}
+	if !spec.Network {
+		if err := nsLoopbackUp(); err != nil {
+			return fmt.Errorf("bring up loopback: %w", err)
+		}
+	}
+	// syscall.Setrlimit (not unix.Setrlimit) so os.StartProcess does
+	// not restore the soft NOFILE limit Go saved at startup.
+	if spec.NoFile > 0 {
+		lim := syscall.Rlimit{Cur: uint64(spec.NoFile), Max: uint64(spec.NoFile)}
+		if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &lim); err != nil {
+			return fmt.Errorf("set RLIMIT_NOFILE: %w", err)
+		}
+	}
+	if err := syscall.Setrlimit(unix.RLIMIT_CORE, &syscall.Rlimit{}); err != nil {
+		return fmt.Errorf("set RLIMIT_CORE: %w", err)
+	}
+	// Not dumpable: the interpreter cannot open /proc/1/fd and reach
+	// the helper's handle on the host instance dir.
+	if err := unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0); err != nil {
+		return fmt.Errorf("clear dumpable: %w", err)
+	}
+	// The interpreter runs as uid 0 of the namespace; with an empty
+	// bounding set its exec grants it no capabilities.
+	for c := 0; ; c++ {
+		err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0)
+		if err == unix.EINVAL {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("drop capability %d: %w", c, err)
+		}
+	}
+	_ = unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0)
+	return installSeccomp()
+}
+
+// nsLoopbackUp brings up lo in the fresh network namespace so the
+// code can still talk to itself over 127.0.0.1.
+func nsLoopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+// nsLookPath resolves the interpreter on the sandbox's PATH.
+func nsLookPath(name string, env []string) (string, error) {
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "PATH="); ok {
+			_ = os.Setenv("PATH", v)
+		}
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("interpreter %q not found in the sandbox root: %w", name, err)
+	}
+	return path, nil
+}
+
+// nsReap reaps children until pid exits and returns its exit code,
+// 128+signal when it was killed.
+func nsReap(pid int) int {
+	for {
+		var ws unix.WaitStatus
+		wpid, err := unix.Wait4(-1, &ws, 0, nil)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return 1
+		}
+		if wpid != pid {
+			continue
+		}
+		if ws.Signaled() {
+			return 128 + int(ws.Signal())
+		}
+		return ws.ExitStatus()
+	}
+}
+
+// nsCopyOut copies /workspace/artifacts back into the host instance
+// dir's artifacts/. Symlinks are recreated, not followed, so the
+// host's collectArtifacts rejects them as it does for plain local
+// runs; other special files are dropped.
+func nsCopyOut(hostFD int, budget *int) error {
+	dst, err := unix.Openat(hostFD, "artifacts", unix.O_PATH|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(dst)
+	return nsCopyTree(filepath.Join(nsWorkspace, "artifacts"), dst, budget)
+}
+
+func nsCopyTree(src string, dstFD int, budget *int) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if *budget <= 0 {
+			return nil
+		}
+		*budget--
+		name, path := e.Name(), filepath.Join(src, e.Name())
+		switch t := e.Type(); {
+		case t&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := unix.Symlinkat(target, dstFD, name); err != nil {
+				return err
+			}
+		case t.IsDir():
+			if err := unix.Mkdirat(dstFD, name, 0o700); err != nil {
+				return err
+			}
+			sub, err := unix.Openat(dstFD, name, unix.O_PATH|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+			if err != nil {
+				return err
+			}
+			err = nsCopyTree(path, sub, budget)
+			unix.Close(sub)
+			if err != nil {
+				return err
+			}
+		case t.IsRegular():
+			if err := nsCopyFile(path, dstFD, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func nsCopyFile(path string, dstFD int, name string) error {
+	in, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fd, err := unix.Openat(dstFD, name, unix.O_WRONLY|unix.O_CREAT|unix.O_EXCL|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		return err
+	}
+	out := os.NewFile(uintptr(fd), name)
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,147 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// local_seccomp_linux.go — the seccomp filter of the local provider's
+// namespace isolation mode.
+//
+// The filter is a denylist: the sandboxed interpreter runs as root of
+// its own user namespace, so everything that would let it rearrange
+// that namespace (mount, pivot_root, unshare, setns, a namespaced
+// clone) or reach the host kernel's privileged surface (modules, kexec,
+// bpf, perf, keyrings, ptrace) fails with EPERM. clone3 fails with
+// ENOSYS so libc falls back to clone, whose flags the filter can
+// inspect. A syscall from a foreign ABI kills the process.
+
+package sandbox
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// seccompData offsets (struct seccomp_data): nr, arch, then the
+// instruction pointer and the six arguments. The low word of args[0]
+// is at 16 on the little-endian architectures seccompArch accepts.
+const (
+	seccompOffNr    = 0
+	seccompOffArch  = 4
+	seccompOffArg0  = 16
+	seccompX32Bit   = 0x40000000
+	seccompNSFlags  = unix.CLONE_NEWNS | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET | unix.CLONE_NEWIPC | unix.CLONE_NEWUTS | unix.CLONE_NEWCGROUP
+	seccompRetAllow = unix.SECCOMP_RET_ALLOW
+)
+
+// seccompDenied lists the syscalls that fail with EPERM inside the
+// sandbox.
+var seccompDenied = []uint32{
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_MOUNT_SETATTR,
+	unix.SYS_FSOPEN, unix.SYS_FSCONFIG, unix.SYS_FSMOUNT, unix.SYS_FSPICK,
+	unix.SYS_MOVE_MOUNT, unix.SYS_OPEN_TREE,
+	unix.SYS_UNSHARE, unix.SYS_SETNS,
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_KEXEC_LOAD, unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_REBOOT, unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_ACCT, unix.SYS_SYSLOG,
+	unix.SYS_SETTIMEOFDAY, unix.SYS_CLOCK_SETTIME, unix.SYS_CLOCK_ADJTIME, unix.SYS_ADJTIMEX,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+}
+
+// seccompArch returns the audit architecture the filter pins, or an
+// error on architectures the filter has not been written for.
+func seccompArch() (uint32, error) {
+	switch runtime.GOARCH {
+	case "amd64":
+		return unix.AUDIT_ARCH_X86_64, nil
+	case "arm64":
+		return unix.AUDIT_ARCH_AARCH64, nil
+	case "riscv64":
+		return unix.AUDIT_ARCH_RISCV64, nil
+	case "ppc64le":
+		return unix.AUDIT_ARCH_PPC64LE, nil
+	case "loong64":
+		return unix.AUDIT_ARCH_LOONGARCH64, nil
+	}
+	return 0, fmt.Errorf("seccomp filter not available on %s", runtime.GOARCH)
+}
+
+func bpfStmt(code uint16, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: code, K: k}
+}
+
+func bpfJump(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
+
+func seccompErrno(errno unix.Errno) unix.SockFilter {
+	return bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(errno))
+}
+
+// seccompProgram assembles the filter for arch.
+func seccompProgram(arch uint32) []unix.SockFilter {
+	prog := []unix.SockFilter{
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompOffArch),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, arch, 1, 0),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_KILL_PROCESS),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompOffNr),
+	}
+	if arch == unix.AUDIT_ARCH_X86_64 {
+		// x32 syscalls share the x86_64 audit arch.
+		prog = append(prog,
+			bpfJump(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, seccompX32Bit, 0, 1),
+			seccompErrno(unix.EPERM))
+	}
+	for _, nr := range seccompDenied {
+		prog = append(prog,
+			bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, nr, 0, 1),
+			seccompErrno(unix.EPERM))
+	}
+	return append(prog,
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE3, 0, 1),
+		seccompErrno(unix.ENOSYS),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE, 0, 3),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompOffArg0),
+		bpfJump(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, seccompNSFlags, 0, 1),
+		seccompErrno(unix.EPERM),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetAllow),
+	)
+}
+
+// installSeccomp sets no_new_privs and loads the filter on every
+// thread of the calling process; children inherit it across exec.
+func installSeccomp() error {
+	arch, err := seccompArch()
+	if err != nil {
+		return err
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	prog := seccompProgram(arch)
+	fprog := unix.SockFprog{Len: uint16(len(prog)), Filter: &prog[0]}
+	tid, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER,
+		unix.SECCOMP_FILTER_FLAG_TSYNC, uintptr(unsafe.Pointer(&fprog)))
+	if errno != 0 {
+		return fmt.Errorf("load seccomp filter: %w", errno)
+	}
+	if tid != 0 {
+		return fmt.Errorf("load seccomp filter: thread %d could not be synchronized", tid)
+	}
+	return nil
+}
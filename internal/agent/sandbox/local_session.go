@@ -0,0 +1,327 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// local_session.go — stateful instances for LocalProvider.
+//
+// A stateful instance runs its Python code in one long-lived
+// interpreter (the "kernel", localPythonKernel) instead of a fresh
+// python3 per call, so globals, imports and loaded data survive
+// between calls the way they do in a notebook. The kernel is started
+// lazily on the first call, reads one JSON request per line on
+// stdin and answers one JSON reply per line on fd 3; the user's
+// stdout / stderr (including subprocess output) go to per-call temp
+// files the kernel reads back, so they cannot corrupt the protocol.
+//
+// A call that times out or is cancelled kills the kernel; the next
+// call starts a new one (state is lost, metadata reports
+// kernel_restarted). JavaScript instances, and every instance when
+// namespace isolation is on, stay stateless: only their instance dir
+// carries over between calls.
+
+//go:build !windows
+
+package sandbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// localPythonKernel is the kernel's source, run with python3 -u -c.
+// Protocol: request {"code", "args", "max_output"}, reply {"stdout",
+// "stderr", "exit_code"}. Each stream is cut at max_output+1 bytes
+// so the Go side can detect overflow without reading it all.
+const localPythonKernel = `
+import base64, json, os, sys, tempfile, traceback
+
+def _ragflow_kernel():
+    requests = os.fdopen(os.dup(0), "rb")
+    replies = os.fdopen(3, "wb", buffering=0)
+    null = os.open(os.devnull, os.O_RDONLY)
+    os.dup2(null, 0)
+    os.close(null)
+    saved_out, saved_err = os.dup(1), os.dup(2)
+    namespace = {"__name__": "__main__", "__builtins__": __builtins__}
+
+    def drain(f, limit):
+        f.seek(0)
+        data = f.read(limit + 1 if limit > 0 else -1)
+        f.close()
+        return data.decode("utf-8", "replace")
+
+    for line in requests:
+        req = json.loads(line)
+        out_f, err_f = tempfile.TemporaryFile(), tempfile.TemporaryFile()
+        sys.stdout.flush()
+        sys.stderr.flush()
+        os.dup2(out_f.fileno(), 1)
+        os.dup2(err_f.fileno(), 2)
+        exit_code = 0
+        try:
+            namespace.pop("main", None)
+            exec(compile(req["code"], "<main>", "exec"), namespace)
+            main = namespace.get("main")
+            if not callable(main):
+                raise NameError("main() must be defined.")
+            result = main(**req["args"])
+            payload = json.dumps({"present": True, "value": result, "type": "json"}, ensure_ascii=False, separators=(",", ":"))
+            print("` + resultMarkerPrefix + `" + base64.b64encode(payload.encode("utf-8")).decode("ascii"))
+        except SystemExit as exc:
+            if exc.code is None:
+                exit_code = 0
+            elif isinstance(exc.code, int):
+                exit_code = exc.code
+            else:
+                print(exc.code, file=sys.stderr)
+                exit_code = 1
+        except BaseException:
+            traceback.print_exc()
+            exit_code = 1
+        finally:
+            sys.stdout.flush()
+            sys.stderr.flush()
+            os.dup2(saved_out, 1)
+            os.dup2(saved_err, 2)
+        limit = req.get("max_output", 0)
+        reply = {"stdout": drain(out_f, limit), "stderr": drain(err_f, limit), "exit_code": exit_code}
+        replies.write(json.dumps(reply).encode("utf-8") + b"\n")
+
+_ragflow_kernel()
+`
+
+// localKernel is one stateful instance's interpreter. mu serializes
+// calls; cmd is nil until the first call and after a kill.
+type localKernel struct {
+	mu       sync.Mutex
+	dir      string
+	cmd      *exec.Cmd
+	requests io.WriteCloser
+	replies  *os.File
+	reader   *bufio.Reader
+	restarts int
+}
+
+type localKernelRequest struct {
+	Code      string          `json:"code"`
+	Args      json.RawMessage `json:"args"`
+	MaxOutput int             `json:"max_output"`
+}
+
+type localKernelReply struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// CreateStatefulInstance creates an instance whose Python calls share
+// one interpreter. With namespace isolation every run gets a fresh
+// sandbox, so the instance is a plain one (Metadata["stateful"] is
+// false).
+func (p *LocalProvider) CreateStatefulInstance(ctx context.Context, template string) (*SandboxInstance, error) {
+	inst, err := p.CreateInstance(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+	stateful := p.isolation != localIsolationNamespace && normalizeLanguage(template) == "python"
+	inst.Metadata["stateful"] = stateful
+	if stateful {
+		p.mu.Lock()
+		if p.kernels == nil {
+			p.kernels = map[string]*localKernel{}
+		}
+		p.kernels[inst.InstanceID] = &localKernel{dir: filepath.Join(p.workDir, inst.InstanceID)}
+		p.mu.Unlock()
+	}
+	return inst, nil
+}
+
+// kernel returns the instance's kernel, or nil for a stateless
+// instance.
+func (p *LocalProvider) kernel(instanceID string) *localKernel {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.kernels[instanceID]
+}
+
+// dropKernel kills and forgets the instance's kernel, if any.
+func (p *LocalProvider) dropKernel(instanceID string) {
+	p.mu.Lock()
+	k := p.kernels[instanceID]
+	delete(p.kernels, instanceID)
+	p.mu.Unlock()
+	if k != nil {
+		k.mu.Lock()
+		k.kill()
+		k.mu.Unlock()
+	}
+}
+
+// executeInKernel is ExecuteCode for a stateful instance's Python
+// call. The result has the same shape as a stateless run plus the
+// "stateful" and "kernel_restarted" metadata keys.
+func (p *LocalProvider) executeInKernel(
+	ctx context.Context,
+	k *localKernel,
+	inst *SandboxInstance,
+	code, argsJSON string,
+	timeout int,
+) (*ExecutionResult, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	restarted := false
+	if k.cmd == nil {
+		if err := k.start(p.pythonBin); err != nil {
+			return nil, err
+		}
+		restarted = k.restarts > 1
+	}
+
+	req, err := json.Marshal(localKernelRequest{Code: code, Args: json.RawMessage(argsJSON), MaxOutput: p.maxOutputBytes})
+	if err != nil {
+		return nil, fmt.Errorf("local: marshal kernel request: %w", err)
+	}
+	start := time.Now()
+	if _, err := k.requests.Write(append(req, '\n')); err != nil {
+		k.kill()
+		return nil, fmt.Errorf("local: session interpreter exited: %w", err)
+	}
+
+	type readResult struct {
+		line []byte
+		err  error
+	}
+	replyCh := make(chan readResult, 1)
+	go func() {
+		line, err := k.reader.ReadBytes('\n')
+		replyCh <- readResult{line, err}
+	}()
+
+	var reply readResult
+	select {
+	case reply = <-replyCh:
+	case <-ctx.Done():
+		k.kill()
+		<-replyCh
+		return nil, fmt.Errorf("local: execution cancelled: %w", ctx.Err())
+	case <-time.After(time.Duration(timeout) * time.Second):
+		k.kill()
+		<-replyCh
+		return nil, fmt.Errorf("local: execution timed out after %d seconds", timeout)
+	}
+
+	var out localKernelReply
+	if reply.err != nil {
+		// The code took the interpreter down (os._exit, a fatal
+		// signal); report it like a stateless run that crashed.
+		exitCode := k.kill()
+		out = localKernelReply{
+			Stderr:   "local: session interpreter exited; its state is lost\n",
+			ExitCode: exitCode,
+		}
+	} else if err := json.Unmarshal(reply.line, &out); err != nil {
+		k.kill()
+		return nil, fmt.Errorf("local: decode kernel reply: %w", err)
+	}
+
+	if maxOut := p.maxOutputBytes; maxOut > 0 {
+		combined := len(out.Stdout) + len(out.Stderr)
+		if combined > maxOut {
+			return nil, fmt.Errorf("local: output exceeds %d bytes (got %d)", maxOut, combined)
+		}
+	}
+	cleanedStdout, structured := ExtractStructuredResult(out.Stdout)
+	artifacts, err := p.collectArtifacts(k.dir, artifactsSince(start))
+	if err != nil {
+		return nil, fmt.Errorf("local: collect artifacts: %w", err)
+	}
+	return &ExecutionResult{
+		Stdout:        cleanedStdout,
+		Stderr:        out.Stderr,
+		ExitCode:      out.ExitCode,
+		ExecutionTime: time.Since(start).Seconds(),
+		Metadata: map[string]any{
+			"instance_id":       inst.InstanceID,
+			"language":          "python",
+			"status":            statusFromExitCode(out.ExitCode),
+			"timeout":           timeout,
+			"artifacts":         artifacts,
+			"structured_result": structured,
+			"isolation":         p.isolation,
+			"stateful":          true,
+			"kernel_restarted":  restarted,
+		},
+	}, nil
+}
+
+// start launches the kernel in the instance dir with the same env and
+// process-group handling as a stateless run. Callers hold k.mu.
+func (k *localKernel) start(pythonBin string) error {
+	repliesR, repliesW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("local: kernel pipe: %w", err)
+	}
+	cmd := exec.Command(pythonBin, "-u", "-c", localPythonKernel)
+	cmd.Dir = k.dir
+	cmd.Env = buildLocalChildEnv(k.dir)
+	cmd.ExtraFiles = []*os.File{repliesW}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGTERM,
+	}
+	requests, err := cmd.StdinPipe()
+	if err != nil {
+		_ = repliesR.Close()
+		_ = repliesW.Close()
+		return fmt.Errorf("local: kernel stdin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		_ = repliesR.Close()
+		_ = repliesW.Close()
+		return fmt.Errorf("local: start session interpreter: %w", err)
+	}
+	_ = repliesW.Close()
+	k.cmd = cmd
+	k.requests = requests
+	k.replies = repliesR
+	k.reader = bufio.NewReader(repliesR)
+	k.restarts++
+	return nil
+}
+
+// kill stops the kernel's process group and reaps it, returning its
+// exit code (-1 when it was killed or never ran). Callers hold k.mu.
+func (k *localKernel) kill() int {
+	if k.cmd == nil {
+		return -1
+	}
+	_ = killProcessGroup(k.cmd.Process.Pid, syscall.SIGKILL)
+	_ = k.requests.Close()
+	_ = k.cmd.Wait()
+	_ = k.replies.Close()
+	exitCode := k.cmd.ProcessState.ExitCode()
+	k.cmd = nil
+	return exitCode
+}
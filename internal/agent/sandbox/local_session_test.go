@@ -0,0 +1,231 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	agenttool "ragflow/internal/agent/tool"
+)
+
+// newStatefulLocalForTest returns a provider and a stateful Python
+// instance on it, skipping when python3 is missing.
+func newStatefulLocalForTest(t *testing.T) (*LocalProvider, *SandboxInstance) {
+	t.Helper()
+	pythonPath, err := findBinary("python3")
+	if err != nil {
+		t.Skip("python3 not on PATH — skipping local subprocess test")
+	}
+	p := newLocalForTest(t)
+	p.pythonBin = pythonPath
+	inst, err := p.CreateStatefulInstance(context.Background(), "python")
+	if err != nil {
+		t.Fatalf("CreateStatefulInstance: %v", err)
+	}
+	t.Cleanup(func() { _ = p.DestroyInstance(context.Background(), inst) })
+	if inst.Metadata["stateful"] != true {
+		t.Fatalf("stateful = %v, want true", inst.Metadata["stateful"])
+	}
+	return p, inst
+}
+
+func structuredValue(t *testing.T, r *ExecutionResult) any {
+	t.Helper()
+	sr, _ := r.Metadata["structured_result"].(map[string]any)
+	if sr == nil {
+		t.Fatalf("no structured result; stdout=%q stderr=%q", r.Stdout, r.Stderr)
+	}
+	return sr["value"]
+}
+
+func TestLocalSession_KeepsInterpreterState(t *testing.T) {
+	p, inst := newStatefulLocalForTest(t)
+	ctx := context.Background()
+
+	r, err := p.ExecuteCode(ctx, inst, "import os\nrows = [1, 2, 3]\ndef main(n):\n    print('loaded')\n    os.system('echo from-subprocess')\n    return len(rows) * n", "python", 10, map[string]any{"n": 2})
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if got := structuredValue(t, r); got != float64(6) {
+		t.Errorf("first value = %v, want 6", got)
+	}
+	if !strings.Contains(r.Stdout, "loaded") || !strings.Contains(r.Stdout, "from-subprocess") {
+		t.Errorf("stdout = %q, want print and subprocess output", r.Stdout)
+	}
+
+	r, err = p.ExecuteCode(ctx, inst, "def main():\n    rows.append(4)\n    return sum(rows)", "python", 10, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if got := structuredValue(t, r); got != float64(10) {
+		t.Errorf("second value = %v, want 10 (globals from the first call)", got)
+	}
+	if r.Metadata["stateful"] != true || r.Metadata["kernel_restarted"] != false {
+		t.Errorf("metadata = %v", r.Metadata)
+	}
+	if strings.Contains(r.Stdout, "loaded") {
+		t.Errorf("stdout leaked from the previous call: %q", r.Stdout)
+	}
+}
+
+func TestLocalSession_ErrorsAndExitCodes(t *testing.T) {
+	p, inst := newStatefulLocalForTest(t)
+	ctx := context.Background()
+
+	r, err := p.ExecuteCode(ctx, inst, "def main():\n    raise ValueError('boom')", "python", 10, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if r.ExitCode != 1 || !strings.Contains(r.Stderr, "ValueError: boom") {
+		t.Errorf("exception: exit=%d stderr=%q", r.ExitCode, r.Stderr)
+	}
+
+	r, err = p.ExecuteCode(ctx, inst, "import sys\ndef main():\n    sys.exit(3)", "python", 10, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if r.ExitCode != 3 {
+		t.Errorf("sys.exit(3): exit=%d", r.ExitCode)
+	}
+
+	// The interpreter survives both; os._exit takes it down.
+	r, err = p.ExecuteCode(ctx, inst, "import os\ndef main():\n    os._exit(4)", "python", 10, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if r.ExitCode != 4 || !strings.Contains(r.Stderr, "state is lost") {
+		t.Errorf("os._exit(4): exit=%d stderr=%q", r.ExitCode, r.Stderr)
+	}
+	r, err = p.ExecuteCode(ctx, inst, "def main():\n    return 1", "python", 10, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCode after crash: %v", err)
+	}
+	if r.Metadata["kernel_restarted"] != true {
+		t.Errorf("kernel_restarted = %v, want true", r.Metadata["kernel_restarted"])
+	}
+}
+
+func TestLocalSession_TimeoutRestartsInterpreter(t *testing.T) {
+	p, inst := newStatefulLocalForTest(t)
+	ctx := context.Background()
+
+	if _, err := p.ExecuteCode(ctx, inst, "state = 'kept'\ndef main():\n    return state", "python", 10, nil); err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	_, err := p.ExecuteCode(ctx, inst, "import time\ndef main():\n    time.sleep(30)", "python", 1, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+	r, err := p.ExecuteCode(ctx, inst, "def main():\n    return globals().get('state', 'gone')", "python", 10, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if got := structuredValue(t, r); got != "gone" {
+		t.Errorf("state after timeout = %v, want gone", got)
+	}
+}
+
+func TestLocalSession_ReportsOnlyNewArtifacts(t *testing.T) {
+	p, inst := newStatefulLocalForTest(t)
+	ctx := context.Background()
+	dir := filepath.Join(p.workDir, inst.InstanceID, "artifacts")
+	if err := os.WriteFile(filepath.Join(dir, "old.csv"), []byte("a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "old.csv"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := p.ExecuteCode(ctx, inst, "def main():\n    open('artifacts/new.csv', 'w').write('b\\n')\n    return 1", "python", 10, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	arts, _ := r.Metadata["artifacts"].([]map[string]any)
+	if len(arts) != 1 || arts[0]["name"] != "new.csv" {
+		t.Errorf("artifacts = %v, want only new.csv", arts)
+	}
+}
+
+func TestLocalSession_DestroyStopsInterpreter(t *testing.T) {
+	p, inst := newStatefulLocalForTest(t)
+	if _, err := p.ExecuteCode(context.Background(), inst, "def main():\n    return 1", "python", 10, nil); err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	k := p.kernel(inst.InstanceID)
+	k.mu.Lock()
+	proc := k.cmd.Process
+	k.mu.Unlock()
+	if err := p.DestroyInstance(context.Background(), inst); err != nil {
+		t.Fatalf("DestroyInstance: %v", err)
+	}
+	if p.kernel(inst.InstanceID) != nil {
+		t.Error("kernel still registered after DestroyInstance")
+	}
+	if err := proc.Signal(syscall.Signal(0)); err == nil {
+		t.Error("kernel process still running after DestroyInstance")
+	}
+}
+
+func TestSessionShell_SharesSessionWithCodeExec(t *testing.T) {
+	pythonPath, err := findBinary("python3")
+	if err != nil {
+		t.Skip("python3 not on PATH — skipping local subprocess test")
+	}
+	p := newLocalForTest(t)
+	p.pythonBin = pythonPath
+	mgr := &ProviderManager{}
+	mgr.SetProvider(p)
+	pool := newSessionPoolFromConfig(map[string]any{})
+	mgr.sessionsOnce.Do(func() { mgr.sessions = pool })
+	t.Cleanup(func() { _ = pool.CloseAll(context.Background()) })
+	client := &ManagerClient{manager: mgr}
+	shell := &SessionShell{client: client, sessionID: "s1", timeout: 10}
+
+	if _, err := client.ExecuteCode(context.Background(), agenttool.SandboxRequest{
+		Lang:      "python",
+		Script:    "def main():\n    open('notes.txt', 'w').write('from code')\n    return 1",
+		SessionID: "s1",
+	}); err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	out, err := shell.Execute("cat notes.txt")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out != "from code" {
+		t.Errorf("output = %q, want %q", out, "from code")
+	}
+
+	out, err = shell.Execute("echo oops; exit 2")
+	if err == nil || !strings.Contains(err.Error(), "status 2") || out != "oops\n" {
+		t.Errorf("failing command: out=%q err=%v", out, err)
+	}
+	ch, err := shell.ExecuteStreaming("echo streamed")
+	if err != nil {
+		t.Fatalf("ExecuteStreaming: %v", err)
+	}
+	if got := <-ch; got != "streamed\n" {
+		t.Errorf("streamed chunk = %q", got)
+	}
+}
@@ -292,7 +292,7 @@ func TestLocal_CollectArtifacts_RejectsBadExtension(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(artDir, "evil.exe"), []byte("x"), 0o600); err != nil {
 		t.Fatalf("write artifact: %v", err)
 	}
-	_, err = p.collectArtifacts(p.workDir + "/" + inst.InstanceID)
+	_, err = p.collectArtifacts(p.workDir+"/"+inst.InstanceID, time.Time{})
 	if err == nil {
 		t.Errorf("collectArtifacts(.exe): got nil error, want one")
 	}
@@ -312,7 +312,7 @@ func TestLocal_CollectArtifacts_AllowsCSVRoundTrip(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(artDir, "out.csv"), []byte("a,b\n1,2\n"), 0o600); err != nil {
 		t.Fatalf("write artifact: %v", err)
 	}
-	artifacts, err := p.collectArtifacts(p.workDir + "/" + inst.InstanceID)
+	artifacts, err := p.collectArtifacts(p.workDir+"/"+inst.InstanceID, time.Time{})
 	if err != nil {
 		t.Fatalf("collectArtifacts: %v", err)
 	}
@@ -24,7 +24,7 @@
 //     Go port reads this via `internal/dao.SystemSettingsDAO`.
 //  2. SANDBOX_PROVIDER_TYPE env var — defaults to "self_managed".
 //  3. SANDBOX_EXECUTOR_MANAGER_URL / AGENTRUN_* / LOCAL_* / SSH_*
-//     / E2B_* env vars for the per-provider knobs. The
+//     / E2B_* / WASM_* env vars for the per-provider knobs. The
 //     `xxxConfigFromEnv` helpers in each provider file build the
 //     same config map the admin-panel JSON would, so the
 //     `FromConfig` constructor is the single source of truth.
@@ -32,6 +32,10 @@
 // Once initialized, the manager holds the active provider. There is
 // at most one active provider at a time — same as Python, because
 // sandbox configuration is global.
+//
+// The manager also owns the SessionPool (session.go) that keeps
+// per-agent-session instances alive across CodeExec calls. It is
+// configured from SANDBOX_SESSION_* env vars only.
 
 package sandbox
 
@@ -42,6 +46,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"ragflow/internal/dao"
 	"ragflow/internal/entity"
@@ -54,6 +59,9 @@ type ProviderManager struct {
 	mu       sync.RWMutex
 	provider SandboxProvider
 	loaded   bool
+
+	sessionsOnce sync.Once
+	sessions     *SessionPool
 }
 
 // globalManager is the package-level manager. Mirrors the Python
@@ -100,11 +108,49 @@ func (m *ProviderManager) IsConfigured() bool {
 }
 
 // Reset clears the manager. Used by reload paths and by tests.
+// Pooled sessions belong to the provider being dropped, so they are
+// destroyed too.
 func (m *ProviderManager) Reset() {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.provider = nil
 	m.loaded = false
+	sessions := m.sessions
+	m.mu.Unlock()
+	if sessions != nil {
+		_ = sessions.CloseAll(context.Background())
+	}
+}
+
+// Sessions returns the manager's session pool, creating it from the
+// SANDBOX_SESSION_* env vars on first use and starting its janitor.
+// The pool is never nil; check Enabled before routing calls through
+// it.
+func (m *ProviderManager) Sessions() *SessionPool {
+	m.sessionsOnce.Do(func() {
+		pool := newSessionPoolFromEnv()
+		if pool.Enabled() {
+			pool.StartJanitor(sessionJanitorInterval(pool.idleTTL))
+		}
+		m.mu.Lock()
+		m.sessions = pool
+		m.mu.Unlock()
+	})
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sessions
+}
+
+// sessionJanitorInterval sweeps often enough that a session outlives
+// its idle TTL by at most half the TTL, capped at a minute.
+func sessionJanitorInterval(idleTTL time.Duration) time.Duration {
+	interval := idleTTL / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
 }
 
 // InitFromEnv resolves the active provider type from
@@ -297,8 +343,10 @@ func buildProvider(t ProviderType) (SandboxProvider, error) {
 		return newLocalProviderFromEnv(), nil
 	case ProviderSSH:
 		return newSSHProviderFromEnv(), nil
+	case ProviderWASM:
+		return newWASMProviderFromEnv(), nil
 	default:
-		return nil, fmt.Errorf("unknown provider type %q (known: self_managed, aliyun_codeinterpreter, e2b, local, ssh)", t)
+		return nil, fmt.Errorf("unknown provider type %q (known: self_managed, aliyun_codeinterpreter, e2b, local, ssh, wasm)", t)
 	}
 }
 
@@ -318,7 +366,9 @@ func buildProviderFromConfig(t ProviderType, cfg map[string]any) (SandboxProvide
 		return newLocalProviderFromConfig(cfg), nil
 	case ProviderSSH:
 		return newSSHProviderFromConfig(cfg), nil
+	case ProviderWASM:
+		return newWASMProviderFromConfig(cfg), nil
 	default:
-		return nil, fmt.Errorf("unknown provider type %q (known: self_managed, aliyun_codeinterpreter, e2b, local, ssh)", t)
+		return nil, fmt.Errorf("unknown provider type %q (known: self_managed, aliyun_codeinterpreter, e2b, local, ssh, wasm)", t)
 	}
 }
@@ -21,7 +21,8 @@ func (c *ManagerClient) ExecuteCode(ctx context.Context, req agenttool.SandboxRe
 	if c == nil || c.manager == nil {
 		return nil, fmt.Errorf("sandbox: provider manager unavailable")
 	}
-	if err := c.manager.LoadFromSettings(ctx); err != nil {
+	err := c.manager.LoadFromSettings(ctx)
+	if err != nil {
 		return nil, err
 	}
 	provider := c.manager.Provider()
@@ -29,17 +30,16 @@ func (c *ManagerClient) ExecuteCode(ctx context.Context, req agenttool.SandboxRe
 		return nil, fmt.Errorf("sandbox: no active provider configured")
 	}
 
-	inst, err := provider.CreateInstance(ctx, req.Lang)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = provider.DestroyInstance(context.Background(), inst) }()
-
 	timeout := req.Timeout
 	if timeout == 0 {
 		timeout = 30
 	}
-	result, err := provider.ExecuteCode(ctx, inst, req.Script, req.Lang, timeout, req.Arguments)
+	var result *ExecutionResult
+	if sessions := c.manager.Sessions(); req.SessionID != "" && sessions.Enabled() {
+		result, err = sessions.Execute(ctx, provider, req.SessionID, req.Lang, req.Script, timeout, req.Arguments)
+	} else {
+		result, err = executeOnce(ctx, provider, req.Lang, req.Script, timeout, req.Arguments)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -67,3 +67,22 @@ func (c *ManagerClient) ExecuteCode(ctx context.Context, req agenttool.SandboxRe
 	}
 	return resp, nil
 }
+
+// CloseSession destroys the sandbox instances kept for an agent
+// session. The agent service calls it when the session is deleted.
+func (c *ManagerClient) CloseSession(ctx context.Context, sessionID string) error {
+	if c == nil || c.manager == nil || sessionID == "" {
+		return nil
+	}
+	return c.manager.Sessions().Close(ctx, sessionID)
+}
+
+// executeOnce runs code on a throwaway instance.
+func executeOnce(ctx context.Context, provider SandboxProvider, lang, code string, timeout int, args map[string]any) (*ExecutionResult, error) {
+	inst, err := provider.CreateInstance(ctx, lang)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = provider.DestroyInstance(context.Background(), inst) }()
+	return provider.ExecuteCode(ctx, inst, code, lang, timeout, args)
+}
@@ -0,0 +1,204 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// mcp_stdio.go — launching stdio MCP servers under the local
+// provider's limits.
+//
+// A stdio MCP server is a tenant-configured command run on the RAGFlow
+// host, so it goes through the same machinery as a local CodeExec run:
+// the LOCAL_* settings pick the isolation mode (with
+// LOCAL_ISOLATION=namespace the server gets the namespaces, read-only
+// root, seccomp filter and cgroup memory / CPU / pids limits of a
+// sandboxed run), it gets its own instance dir as HOME / TMPDIR and
+// cwd, and only PATH and its configured env reach it.
+//
+// What a tenant may launch is the operator's call, since under the
+// default LOCAL_ISOLATION=none the server runs as the RAGFlow user:
+//
+//   - MCP_STDIO_COMMANDS lists the commands, comma-separated and matched
+//     exactly against the configured command; while it is empty stdio
+//     servers are refused. An entry with arguments ("npx -y
+//     @modelcontextprotocol/server-github", split on whitespace) pins
+//     the command's argv: the configured args must equal them. A bare
+//     entry accepts any args.
+//   - MCP_STDIO_ENV lists the env var names a server may be given;
+//     anything else (PATH, LD_PRELOAD, NODE_OPTIONS, ...) is refused.
+//     The launcher's own variables always win over the configured env.
+//   - cwd is a relative path inside the instance dir.
+
+//go:build !windows
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/google/uuid"
+
+	mcpclient "ragflow/internal/utility"
+)
+
+// mcpStdioCommandsEnv names the env var listing the commands stdio MCP
+// servers may run.
+const mcpStdioCommandsEnv = "MCP_STDIO_COMMANDS"
+
+// mcpStdioEnvEnv names the env var listing the env var names stdio MCP
+// servers may be configured with.
+const mcpStdioEnvEnv = "MCP_STDIO_ENV"
+
+// ErrMCPStdioCommandNotAllowed is returned for a stdio MCP server whose
+// command (or argv, when pinned) is not listed in MCP_STDIO_COMMANDS.
+var ErrMCPStdioCommandNotAllowed = errors.New("sandbox: stdio MCP server command not allowed")
+
+// ErrMCPStdioEnvNotAllowed is returned for a stdio MCP server configured
+// with an env var not listed in MCP_STDIO_ENV.
+var ErrMCPStdioEnvNotAllowed = errors.New("sandbox: stdio MCP server env var not allowed")
+
+// mcpStdioLauncher starts stdio MCP servers with a LocalProvider's
+// settings. The provider is initialized on first use.
+type mcpStdioLauncher struct {
+	provider *LocalProvider
+	// allowed maps each listed command to its pinned argv; a nil entry
+	// accepts any args.
+	allowed    map[string][][]string
+	allowedEnv map[string]bool
+
+	initOnce sync.Once
+	initErr  error
+}
+
+// NewMCPStdioLauncher returns the launcher for stdio MCP servers, built
+// from the LOCAL_*, MCP_STDIO_COMMANDS and MCP_STDIO_ENV env vars.
+// Install it with utility.SetStdioLauncher.
+func NewMCPStdioLauncher() mcpclient.StdioLauncher {
+	return newMCPStdioLauncher(newLocalProviderFromEnv(), os.Getenv(mcpStdioCommandsEnv), os.Getenv(mcpStdioEnvEnv)).launch
+}
+
+func newMCPStdioLauncher(p *LocalProvider, commands, envNames string) *mcpStdioLauncher {
+	l := &mcpStdioLauncher{provider: p, allowed: map[string][][]string{}, allowedEnv: map[string]bool{}}
+	for _, c := range strings.Split(commands, ",") {
+		fields := strings.Fields(c)
+		if len(fields) == 0 {
+			continue
+		}
+		var pinned []string
+		if len(fields) > 1 {
+			pinned = fields[1:]
+		}
+		l.allowed[fields[0]] = append(l.allowed[fields[0]], pinned)
+	}
+	for _, name := range strings.Split(envNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			l.allowedEnv[name] = true
+		}
+	}
+	return l
+}
+
+// check enforces the operator's command, argv, env and cwd limits.
+func (l *mcpStdioLauncher) check(srv mcpclient.StdioServer) error {
+	if len(l.allowed) == 0 {
+		return fmt.Errorf("%w: stdio MCP servers are disabled (set %s)", ErrMCPStdioCommandNotAllowed, mcpStdioCommandsEnv)
+	}
+	pins, ok := l.allowed[srv.Command]
+	if !ok {
+		return fmt.Errorf("%w: %q is not listed in %s", ErrMCPStdioCommandNotAllowed, srv.Command, mcpStdioCommandsEnv)
+	}
+	if !slices.ContainsFunc(pins, func(pin []string) bool { return pin == nil || slices.Equal(pin, srv.Args) }) {
+		return fmt.Errorf("%w: the args of %q do not match its entry in %s", ErrMCPStdioCommandNotAllowed, srv.Command, mcpStdioCommandsEnv)
+	}
+	names := make([]string, 0, len(srv.Env))
+	for name := range srv.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !l.allowedEnv[name] {
+			return fmt.Errorf("%w: %q is not listed in %s", ErrMCPStdioEnvNotAllowed, name, mcpStdioEnvEnv)
+		}
+	}
+	if srv.Dir != "" && !filepath.IsLocal(srv.Dir) {
+		return fmt.Errorf("local: MCP server cwd %q must be a relative path inside its working directory", srv.Dir)
+	}
+	return nil
+}
+
+// launch starts srv in a fresh instance dir under the provider's work
+// dir; the dir is removed when the server is closed.
+func (l *mcpStdioLauncher) launch(ctx context.Context, srv mcpclient.StdioServer) (mcpclient.StdioProcess, error) {
+	if err := l.check(srv); err != nil {
+		return nil, err
+	}
+	l.initOnce.Do(func() { l.initErr = l.provider.Initialize(ctx) })
+	if l.initErr != nil {
+		return nil, l.initErr
+	}
+
+	p := l.provider
+	instanceDir := filepath.Join(p.workDir, "mcp-"+uuid.NewString())
+	if err := os.MkdirAll(instanceDir, 0o700); err != nil {
+		return nil, fmt.Errorf("local: create MCP server dir: %w", err)
+	}
+	removeDir := func() { _ = os.RemoveAll(instanceDir) }
+	hooks := mcpclient.StdioCommandHooks{
+		// Kill the whole group so helpers the server spawned (npx,
+		// uvx) go with it.
+		Stop:    func(proc *os.Process) { _ = killProcessGroup(proc.Pid, syscall.SIGKILL) },
+		Cleanup: removeDir,
+	}
+
+	var cmd *exec.Cmd
+	if p.isolation == localIsolationNamespace {
+		argv := append([]string{srv.Command}, srv.Args...)
+		iso, err := p.isolatedServer(instanceDir, argv, srv.Environ(), srv.Dir)
+		if err != nil {
+			removeDir()
+			return nil, err
+		}
+		cmd = iso.cmd
+		hooks.Started = func() error {
+			iso.started()
+			return iso.setupError()
+		}
+		hooks.Cleanup = func() {
+			iso.cleanup()
+			removeDir()
+		}
+	} else {
+		cmd = exec.Command(srv.Command, srv.Args...)
+		cmd.Dir = filepath.Join(instanceDir, srv.Dir)
+		if err := os.MkdirAll(cmd.Dir, 0o700); err != nil {
+			removeDir()
+			return nil, fmt.Errorf("local: create MCP server cwd: %w", err)
+		}
+		cmd.Env = mergeLocalChildEnv(buildLocalChildEnv(instanceDir), srv.Environ())
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setpgid:   true,
+			Pdeathsig: syscall.SIGTERM,
+		}
+	}
+	return mcpclient.StartStdioCommand(cmd, hooks)
+}
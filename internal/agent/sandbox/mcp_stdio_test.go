@@ -0,0 +1,116 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !windows
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	mcpclient "ragflow/internal/utility"
+)
+
+func TestMCPStdioLauncher_Allowlist(t *testing.T) {
+	p := newLocalForTest(t)
+	ctx := context.Background()
+
+	if _, err := newMCPStdioLauncher(p, "", "").launch(ctx, mcpclient.StdioServer{Command: "/bin/sh"}); !errors.Is(err, ErrMCPStdioCommandNotAllowed) {
+		t.Fatalf("empty allowlist: err = %v, want ErrMCPStdioCommandNotAllowed", err)
+	}
+	l := newMCPStdioLauncher(p, " /usr/bin/npx -y  @modelcontextprotocol/server-github, /usr/bin/npx -y other , /bin/sh ", " GITHUB_TOKEN ")
+	if _, err := l.launch(ctx, mcpclient.StdioServer{Command: "sh"}); !errors.Is(err, ErrMCPStdioCommandNotAllowed) {
+		t.Fatalf("unlisted command: err = %v, want ErrMCPStdioCommandNotAllowed", err)
+	}
+	for _, args := range [][]string{nil, {"-y"}, {"-y", "@modelcontextprotocol/server-github", "--extra"}, {"-e", "require('child_process')"}} {
+		if err := l.check(mcpclient.StdioServer{Command: "/usr/bin/npx", Args: args}); !errors.Is(err, ErrMCPStdioCommandNotAllowed) {
+			t.Errorf("args %q: err = %v, want ErrMCPStdioCommandNotAllowed", args, err)
+		}
+	}
+	for _, args := range [][]string{{"-y", "@modelcontextprotocol/server-github"}, {"-y", "other"}} {
+		if err := l.check(mcpclient.StdioServer{Command: "/usr/bin/npx", Args: args}); err != nil {
+			t.Errorf("pinned args %q: err = %v", args, err)
+		}
+	}
+	if err := l.check(mcpclient.StdioServer{Command: "/bin/sh", Args: []string{"-c", "true"}, Env: map[string]string{"GITHUB_TOKEN": "t"}}); err != nil {
+		t.Errorf("unpinned command with allowed env: err = %v", err)
+	}
+	for _, name := range []string{"PATH", "LD_PRELOAD", "NODE_OPTIONS"} {
+		if err := l.check(mcpclient.StdioServer{Command: "/bin/sh", Env: map[string]string{name: "x"}}); !errors.Is(err, ErrMCPStdioEnvNotAllowed) {
+			t.Errorf("env %s: err = %v, want ErrMCPStdioEnvNotAllowed", name, err)
+		}
+	}
+	for _, dir := range []string{"/etc", "../up", "a/../../b"} {
+		if _, err := l.launch(ctx, mcpclient.StdioServer{Command: "/bin/sh", Dir: dir}); err == nil || !strings.Contains(err.Error(), "relative path") {
+			t.Errorf("cwd %q: err = %v", dir, err)
+		}
+	}
+}
+
+func TestMergeLocalChildEnv_BaseWins(t *testing.T) {
+	got := mergeLocalChildEnv([]string{"HOME=/w", "PATH=/bin"}, []string{"A=1", "PATH=/evil", "HOME=/tmp"})
+	if want := []string{"HOME=/w", "PATH=/bin", "A=1"}; !slices.Equal(got, want) {
+		t.Errorf("env = %q, want %q", got, want)
+	}
+}
+
+// TestMCPStdioLauncher_RunsServer drives a shell-script MCP server
+// through the launcher: it only sees its configured env, runs in its own
+// instance dir, and the dir is removed when the session closes.
+func TestMCPStdioLauncher_RunsServer(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh missing")
+	}
+	t.Setenv("RAGFLOW_TEST_SECRET", "leaked")
+	p := newLocalForTest(t)
+	mcpclient.SetStdioLauncher(newMCPStdioLauncher(p, "/bin/sh", "TOOL_NAME").launch)
+	t.Cleanup(func() { mcpclient.SetStdioLauncher(nil) })
+
+	script := `read line
+printf '{"jsonrpc":"2.0","id":0,"result":{"capabilities":{"tools":{}}}}\n'
+read line
+read line
+printf '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"%s","description":"%s|%s"}]}}\n' "$TOOL_NAME" "$RAGFLOW_TEST_SECRET" "$(basename "$PWD")"
+read line`
+	tools, err := mcpclient.FetchTools(context.Background(), mcpclient.FetchOptions{
+		ServerType: mcpclient.TransportStdio,
+		Stdio:      &mcpclient.StdioServer{Command: "/bin/sh", Args: []string{"-c", script}, Env: map[string]string{"TOOL_NAME": "lookup"}},
+		Timeout:    5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("FetchTools: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "lookup" {
+		t.Fatalf("tools = %+v, want [lookup]", tools)
+	}
+	secret, dir, _ := strings.Cut(tools[0].Description, "|")
+	if secret != "" {
+		t.Errorf("host env leaked into the server: %q", secret)
+	}
+	if !strings.HasPrefix(dir, "mcp-") {
+		t.Errorf("server cwd = %q, want its instance dir", dir)
+	}
+	if left, _ := filepath.Glob(filepath.Join(p.workDir, "mcp-*")); len(left) != 0 {
+		t.Errorf("instance dirs not removed: %v", left)
+	}
+}
@@ -17,8 +17,8 @@
 // Package sandbox implements RAGFlow's Go-side CodeExec sandbox subsystem.
 //
 // The package is the Go port of `agent/sandbox/` in the Python repo. It
-// exposes a single active provider (self_managed or aliyun_codeinterpreter
-// today; e2b is a loud stub) through a ProviderManager. The
+// exposes a single active provider (self_managed, aliyun_codeinterpreter,
+// e2b, local, ssh or the in-process wasm) through a ProviderManager. The
 // ManagerClient returned by NewManagerClient implements the
 // tool.SandboxClient interface in the parent package, so the CodeExec
 // tool can dispatch code execution through this manager without
@@ -58,13 +58,20 @@ const (
 
 	// ProviderLocal runs the user's code on the Go host itself
 	// via os/exec. Matches Python's LocalProvider. There is no
-	// sandboxing — operators that need isolation should
-	// configure SelfManaged or Aliyun / e2b.
+	// sandboxing unless LOCAL_ISOLATION=namespace (Linux
+	// namespaces, cgroup v2 limits, seccomp); operators that
+	// need a stronger boundary should configure SelfManaged or
+	// Aliyun / e2b.
 	ProviderLocal ProviderType = "local"
 
 	// ProviderSSH runs the user's code on a remote host via
 	// SSH. Matches Python's SSHProvider.
 	ProviderSSH ProviderType = "ssh"
+
+	// ProviderWASM runs the user's code in-process on a
+	// WebAssembly runtime (CPython / QuickJS compiled to WASI).
+	// Go-only; there is no Python counterpart.
+	ProviderWASM ProviderType = "wasm"
 )
 
 // ErrE2BProviderNotImplemented is returned when an operator configures
@@ -121,6 +121,65 @@ const __ragflowArgs = JSON.parse(Buffer.from(__ragflowArgsB64, 'base64').toStrin
 `
 }
 
+// BuildQuickJSWrapper is BuildJavaScriptWrapper for QuickJS (the
+// WASM provider's JavaScript interpreter), which has no Buffer and
+// no CommonJS `module`. The args travel as base64 like the other
+// wrappers and are decoded — and the payload encoded — by small
+// base64/UTF-8 helpers built on escape/unescape. Errors go to
+// stderr with exit code 1 when QuickJS's `std` module is available
+// (qjs --std), matching node's unhandled-rejection exit.
+func BuildQuickJSWrapper(code, argsJSON string) string {
+	argsB64 := base64.StdEncoding.EncodeToString([]byte(argsJSON))
+	return code + `
+
+const __ragflowAlphabet = 'ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/';
+const __ragflowB64Encode = (s) => {
+  const bin = unescape(encodeURIComponent(s));
+  let out = '';
+  for (let i = 0; i < bin.length; i += 3) {
+    const n = (bin.charCodeAt(i) << 16) | ((bin.charCodeAt(i + 1) || 0) << 8) | (bin.charCodeAt(i + 2) || 0);
+    out += __ragflowAlphabet[(n >> 18) & 63] + __ragflowAlphabet[(n >> 12) & 63] +
+      (i + 1 < bin.length ? __ragflowAlphabet[(n >> 6) & 63] : '=') +
+      (i + 2 < bin.length ? __ragflowAlphabet[n & 63] : '=');
+  }
+  return out;
+};
+const __ragflowB64Decode = (s) => {
+  let bin = '';
+  for (let i = 0; i < s.length; i += 4) {
+    const n = (__ragflowAlphabet.indexOf(s[i]) << 18) | (__ragflowAlphabet.indexOf(s[i + 1]) << 12) |
+      ((Math.max(__ragflowAlphabet.indexOf(s[i + 2]), 0)) << 6) | Math.max(__ragflowAlphabet.indexOf(s[i + 3]), 0);
+    bin += String.fromCharCode((n >> 16) & 255);
+    if (s[i + 2] !== '=') bin += String.fromCharCode((n >> 8) & 255);
+    if (s[i + 3] !== '=') bin += String.fromCharCode(n & 255);
+  }
+  return decodeURIComponent(escape(bin));
+};
+const __ragflowArgs = JSON.parse(__ragflowB64Decode("` + argsB64 + `"));
+
+(async () => {
+  if (typeof main !== 'function') {
+    throw new Error('main() must be defined.');
+  }
+  const output = await Promise.resolve(main(__ragflowArgs));
+  if (typeof output === 'undefined') {
+    throw new Error('main() must return a value. Use null for an empty result.');
+  }
+  const payload = JSON.stringify({ present: true, value: output, type: 'json' });
+  if (typeof payload === 'undefined') {
+    throw new Error('main() returned a non-JSON-serializable value.');
+  }
+  console.log('` + resultMarkerPrefix + `' + __ragflowB64Encode(payload));
+})().catch((e) => {
+  if (typeof std !== 'undefined') {
+    std.err.puts(String(e && e.stack ? e + '\n' + e.stack : e) + '\n');
+    std.exit(1);
+  }
+  throw e;
+});
+`
+}
+
 // ExtractStructuredResult scans stdout for the marker line, decodes
 // the JSON payload after it, and returns the user-visible stdout
 // (with the marker line removed) plus the parsed structured result.
@@ -19,6 +19,7 @@ package sandbox
 import (
 	"encoding/base64"
 	"encoding/json"
+	"os/exec"
 	"strings"
 	"testing"
 )
@@ -237,3 +238,27 @@ func TestValidateTimeout(t *testing.T) {
 		t.Errorf("validateTimeout(600) = %d, %v; want 600, nil", got, err)
 	}
 }
+
+// TestBuildQuickJSWrapper_RoundTrip runs the QuickJS wrapper under
+// node (which has escape/unescape too) to check the hand-rolled
+// base64/UTF-8 helpers against Go's decoder. Skipped without node.
+func TestBuildQuickJSWrapper_RoundTrip(t *testing.T) {
+	nodePath, err := findBinary("node")
+	if err != nil {
+		t.Skip("node not on PATH — skipping QuickJS wrapper round trip")
+	}
+	code := "function main(args) { return { echo: args.s, n: args.n + 1 }; }"
+	wrapped := BuildQuickJSWrapper(code, `{"s":"héllo, 世界 ✓","n":41}`)
+	if strings.Contains(wrapped, "Buffer") || strings.Contains(wrapped, "module.exports") {
+		t.Fatalf("QuickJS wrapper must not use node-only globals:\n%s", wrapped)
+	}
+	out, err := exec.Command(nodePath, "-e", wrapped).CombinedOutput()
+	if err != nil {
+		t.Fatalf("node: %v\n%s", err, out)
+	}
+	_, structured := ExtractStructuredResult(string(out))
+	value, _ := structured["value"].(map[string]any)
+	if value["echo"] != "héllo, 世界 ✓" || value["n"] != float64(42) {
+		t.Fatalf("structured = %v (stdout %q)", structured, out)
+	}
+}
@@ -0,0 +1,402 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// session.go — session-scoped sandbox instances.
+//
+// Without a session every CodeExec call creates an instance, runs
+// the code and destroys the instance, so nothing survives between
+// the steps of an agent run. A SessionPool instead keeps one
+// instance per (agent session, language) alive across calls:
+//
+//   - files written in the instance's work dir persist for every
+//     provider whose instance owns a work dir (local, wasm, ssh,
+//     e2b);
+//   - interpreter state (globals, imports, loaded dataframes) also
+//     persists when the provider implements StatefulProvider (local
+//     without namespace isolation, Python only).
+//
+// Sessions are torn down after SANDBOX_SESSION_IDLE_TTL seconds
+// without a call, after SANDBOX_SESSION_MAX_LIFETIME seconds in
+// total, when the pool is over SANDBOX_SESSION_MAX (least recently
+// used first), and explicitly via Close when the agent session is
+// deleted. An idle TTL of 0 disables sessions: every call gets a
+// fresh instance as before.
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Session defaults.
+const (
+	sessionDefaultIdleTTL     = 600  // seconds
+	sessionDefaultMaxLifetime = 3600 // seconds
+	sessionDefaultMaxSessions = 64
+)
+
+// ErrSessionLimit is returned when every pooled session is busy and
+// the pool is at SANDBOX_SESSION_MAX, so no session can be evicted
+// to make room.
+var ErrSessionLimit = errors.New("sandbox: too many active sandbox sessions")
+
+// StatefulProvider is implemented by providers that can keep an
+// interpreter alive inside an instance, so that globals defined by
+// one ExecuteCode call are visible to the next one on the same
+// instance. The SessionPool creates its instances through
+// CreateStatefulInstance when the provider offers it.
+//
+// Implementations may fall back to a plain instance when they cannot
+// keep state for the requested language or configuration; such
+// instances report Metadata["stateful"] == false.
+type StatefulProvider interface {
+	CreateStatefulInstance(ctx context.Context, template string) (*SandboxInstance, error)
+}
+
+// SessionPool keeps sandbox instances alive per agent session. It is
+// goroutine-safe; calls on the same session and language are
+// serialized, calls on different sessions run concurrently.
+type SessionPool struct {
+	idleTTL     time.Duration
+	maxLifetime time.Duration
+	maxSessions int
+
+	// now is replaced by tests.
+	now func() time.Time
+
+	mu       sync.Mutex
+	sessions map[sessionKey]*sandboxSession
+
+	janitorOnce sync.Once
+	stopOnce    sync.Once
+	stop        chan struct{}
+}
+
+// sessionKey identifies a pooled instance. A session that runs both
+// Python and JavaScript gets one instance per language because
+// providers bind an instance to the language it was created for.
+type sessionKey struct {
+	sessionID string
+	language  string
+}
+
+// sandboxSession is one pooled instance. mu serializes execution
+// and teardown; the timestamps and busy count are guarded by the
+// pool's mu.
+type sandboxSession struct {
+	mu       sync.Mutex
+	provider SandboxProvider
+	inst     *SandboxInstance
+	closed   bool
+
+	created  time.Time
+	lastUsed time.Time
+	busy     int
+}
+
+// newSessionPoolFromEnv reads SANDBOX_SESSION_* env vars.
+func newSessionPoolFromEnv() *SessionPool {
+	return newSessionPoolFromConfig(sessionConfigFromEnv())
+}
+
+// sessionConfigFromEnv builds a config map from the
+// SANDBOX_SESSION_* env vars.
+func sessionConfigFromEnv() map[string]any {
+	return map[string]any{
+		"IDLE_TTL":     os.Getenv("SANDBOX_SESSION_IDLE_TTL"),
+		"MAX_LIFETIME": os.Getenv("SANDBOX_SESSION_MAX_LIFETIME"),
+		"MAX":          os.Getenv("SANDBOX_SESSION_MAX"),
+	}
+}
+
+// newSessionPoolFromConfig builds a pool from a config map. Durations
+// are in seconds; a MAX_LIFETIME or MAX of 0 removes that cap, an
+// IDLE_TTL of 0 disables the pool.
+func newSessionPoolFromConfig(cfg map[string]any) *SessionPool {
+	return &SessionPool{
+		idleTTL:     time.Duration(configInt(cfg, "IDLE_TTL", sessionDefaultIdleTTL)) * time.Second,
+		maxLifetime: time.Duration(configInt(cfg, "MAX_LIFETIME", sessionDefaultMaxLifetime)) * time.Second,
+		maxSessions: configInt(cfg, "MAX", sessionDefaultMaxSessions),
+		now:         time.Now,
+		sessions:    map[sessionKey]*sandboxSession{},
+		stop:        make(chan struct{}),
+	}
+}
+
+// Enabled reports whether the pool keeps sessions at all.
+func (sp *SessionPool) Enabled() bool {
+	return sp != nil && sp.idleTTL > 0
+}
+
+// Len returns the number of pooled instances.
+func (sp *SessionPool) Len() int {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return len(sp.sessions)
+}
+
+// Execute runs code on the session's instance for language, creating
+// the instance through p on first use. A pooled instance created by
+// a different provider (the manager was reloaded) or past its idle
+// TTL / max lifetime is destroyed and replaced. The result metadata
+// gains "session_id" and "session_reused".
+func (sp *SessionPool) Execute(
+	ctx context.Context,
+	p SandboxProvider,
+	sessionID, language, code string,
+	timeoutSec int,
+	args map[string]any,
+) (*ExecutionResult, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("sandbox: session id required")
+	}
+	lang := normalizeLanguage(language)
+	if lang == "" {
+		return nil, fmt.Errorf("sandbox: unsupported language %q", language)
+	}
+	key := sessionKey{sessionID: sessionID, language: lang}
+
+	for {
+		s, err := sp.acquire(key, p)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		if s.closed {
+			// Torn down between acquire and lock (Close or
+			// eviction); pick up a fresh session.
+			s.mu.Unlock()
+			sp.release(s)
+			continue
+		}
+		reused := s.inst != nil
+		if !reused {
+			inst, err := createSessionInstance(ctx, p, lang)
+			if err != nil {
+				s.closed = true
+				s.mu.Unlock()
+				sp.remove(key, s)
+				sp.release(s)
+				return nil, err
+			}
+			s.inst = inst
+		}
+		result, err := p.ExecuteCode(ctx, s.inst, code, lang, timeoutSec, args)
+		s.mu.Unlock()
+		sp.release(s)
+		if err != nil {
+			return nil, err
+		}
+		if result.Metadata == nil {
+			result.Metadata = map[string]any{}
+		}
+		result.Metadata["session_id"] = sessionID
+		result.Metadata["session_reused"] = reused
+		return result, nil
+	}
+}
+
+// Close destroys every instance pooled for sessionID. It waits for
+// in-flight calls on those instances to finish. Closing an unknown
+// session is a no-op.
+func (sp *SessionPool) Close(ctx context.Context, sessionID string) error {
+	sp.mu.Lock()
+	var victims []*sandboxSession
+	for k, s := range sp.sessions {
+		if k.sessionID == sessionID {
+			delete(sp.sessions, k)
+			victims = append(victims, s)
+		}
+	}
+	sp.mu.Unlock()
+	return destroySessions(ctx, victims)
+}
+
+// CloseAll destroys every pooled instance.
+func (sp *SessionPool) CloseAll(ctx context.Context) error {
+	sp.mu.Lock()
+	victims := make([]*sandboxSession, 0, len(sp.sessions))
+	for k, s := range sp.sessions {
+		delete(sp.sessions, k)
+		victims = append(victims, s)
+	}
+	sp.mu.Unlock()
+	return destroySessions(ctx, victims)
+}
+
+// Sweep destroys the idle sessions past their idle TTL or max
+// lifetime and returns how many it removed. Busy sessions are left
+// for a later sweep.
+func (sp *SessionPool) Sweep(ctx context.Context) int {
+	now := sp.now()
+	sp.mu.Lock()
+	var victims []*sandboxSession
+	for k, s := range sp.sessions {
+		if s.busy == 0 && sp.expired(s, now) {
+			delete(sp.sessions, k)
+			victims = append(victims, s)
+		}
+	}
+	sp.mu.Unlock()
+	_ = destroySessions(ctx, victims)
+	return len(victims)
+}
+
+// StartJanitor sweeps the pool every interval until Stop. Calling it
+// more than once is a no-op.
+func (sp *SessionPool) StartJanitor(interval time.Duration) {
+	sp.janitorOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-sp.stop:
+					return
+				case <-ticker.C:
+					sp.Sweep(context.Background())
+				}
+			}
+		}()
+	})
+}
+
+// Stop ends the janitor started by StartJanitor. Pooled sessions are
+// left alone; call CloseAll to destroy them.
+func (sp *SessionPool) Stop() {
+	sp.stopOnce.Do(func() { close(sp.stop) })
+}
+
+// acquire returns the live session for key, registering a new empty
+// one when there is none, and marks it busy. Expired or foreign-
+// provider sessions found under key are destroyed in the background.
+func (sp *SessionPool) acquire(key sessionKey, p SandboxProvider) (*sandboxSession, error) {
+	now := sp.now()
+	sp.mu.Lock()
+	var victims []*sandboxSession
+	s := sp.sessions[key]
+	if s != nil && (s.provider != p || (s.busy == 0 && sp.expired(s, now))) {
+		delete(sp.sessions, key)
+		victims = append(victims, s)
+		s = nil
+	}
+	if s == nil {
+		if sp.maxSessions > 0 && len(sp.sessions) >= sp.maxSessions {
+			lru := sp.leastRecentlyUsedIdle()
+			if lru == nil {
+				sp.mu.Unlock()
+				go func() { _ = destroySessions(context.Background(), victims) }()
+				return nil, fmt.Errorf("%w (max %d)", ErrSessionLimit, sp.maxSessions)
+			}
+			delete(sp.sessions, lru.key)
+			victims = append(victims, lru.session)
+		}
+		s = &sandboxSession{provider: p, created: now, lastUsed: now}
+		sp.sessions[key] = s
+	}
+	s.busy++
+	sp.mu.Unlock()
+	if len(victims) > 0 {
+		go func() { _ = destroySessions(context.Background(), victims) }()
+	}
+	return s, nil
+}
+
+// release drops the busy mark taken by acquire and restarts the
+// session's idle clock.
+func (sp *SessionPool) release(s *sandboxSession) {
+	now := sp.now()
+	sp.mu.Lock()
+	s.busy--
+	s.lastUsed = now
+	sp.mu.Unlock()
+}
+
+// remove unregisters s from key if it is still the registered
+// session.
+func (sp *SessionPool) remove(key sessionKey, s *sandboxSession) {
+	sp.mu.Lock()
+	if sp.sessions[key] == s {
+		delete(sp.sessions, key)
+	}
+	sp.mu.Unlock()
+}
+
+// expired reports whether s is past its idle TTL or max lifetime.
+// Callers hold sp.mu.
+func (sp *SessionPool) expired(s *sandboxSession, now time.Time) bool {
+	if sp.idleTTL > 0 && now.Sub(s.lastUsed) >= sp.idleTTL {
+		return true
+	}
+	return sp.maxLifetime > 0 && now.Sub(s.created) >= sp.maxLifetime
+}
+
+// leastRecentlyUsedIdle returns the idle session with the oldest
+// lastUsed, or nil when every session is busy. Callers hold sp.mu.
+func (sp *SessionPool) leastRecentlyUsedIdle() *keyedSession {
+	idle := make([]keyedSession, 0, len(sp.sessions))
+	for k, s := range sp.sessions {
+		if s.busy == 0 {
+			idle = append(idle, keyedSession{key: k, session: s})
+		}
+	}
+	if len(idle) == 0 {
+		return nil
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		return idle[i].session.lastUsed.Before(idle[j].session.lastUsed)
+	})
+	return &idle[0]
+}
+
+type keyedSession struct {
+	key     sessionKey
+	session *sandboxSession
+}
+
+// createSessionInstance creates the pooled instance, preferring a
+// stateful one.
+func createSessionInstance(ctx context.Context, p SandboxProvider, lang string) (*SandboxInstance, error) {
+	if stateful, ok := p.(StatefulProvider); ok {
+		return stateful.CreateStatefulInstance(ctx, lang)
+	}
+	return p.CreateInstance(ctx, lang)
+}
+
+// destroySessions destroys each session's instance once its
+// in-flight call (if any) has finished, and joins the errors.
+func destroySessions(ctx context.Context, sessions []*sandboxSession) error {
+	var errs []error
+	for _, s := range sessions {
+		s.mu.Lock()
+		if !s.closed {
+			s.closed = true
+			if s.inst != nil {
+				if err := s.provider.DestroyInstance(ctx, s.inst); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		s.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
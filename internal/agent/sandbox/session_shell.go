@@ -0,0 +1,107 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// session_shell.go — a harness backend.Shell on a sandbox session.
+//
+// SessionShell lets a harness agent (internal/harness) run shell
+// commands in the same sandbox the agent session's CodeExec calls
+// use, so files written by one are visible to the other. Commands
+// run through the session's Python interpreter with subprocess, so
+// any provider that runs Python can back the shell.
+
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	agenttool "ragflow/internal/agent/tool"
+	"ragflow/internal/harness/core/backend"
+)
+
+// sessionShellTimeout is the per-command timeout in seconds.
+const sessionShellTimeout = 60
+
+// sessionShellScript runs the command with a shell and returns its
+// combined output and exit code.
+const sessionShellScript = `import subprocess
+
+def main(command):
+    proc = subprocess.run(command, shell=True, stdout=subprocess.PIPE, stderr=subprocess.STDOUT)
+    return {"exit_code": proc.returncode, "output": proc.stdout.decode("utf-8", "replace")}
+`
+
+var _ backend.Shell = (*SessionShell)(nil)
+
+// SessionShell implements backend.Shell on the sandbox kept for one
+// agent session.
+type SessionShell struct {
+	client    *ManagerClient
+	sessionID string
+	timeout   int
+}
+
+// NewSessionShell returns a shell backed by sessionID's sandbox in
+// the default manager. With sessions disabled every command runs in
+// a fresh instance.
+func NewSessionShell(sessionID string) *SessionShell {
+	return &SessionShell{client: NewManagerClient(), sessionID: sessionID, timeout: sessionShellTimeout}
+}
+
+// Execute runs command and returns its combined stdout / stderr. A
+// non-zero exit status is returned as an error alongside the output.
+func (s *SessionShell) Execute(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.timeout+5)*time.Second)
+	defer cancel()
+	resp, err := s.client.ExecuteCode(ctx, agenttool.SandboxRequest{
+		Lang:      "python",
+		Script:    sessionShellScript,
+		Arguments: map[string]any{"command": command},
+		Timeout:   s.timeout,
+		SessionID: s.sessionID,
+	})
+	if err != nil {
+		return "", err
+	}
+	value, _ := resp.StructuredResult["value"].(map[string]any)
+	if value == nil {
+		return "", fmt.Errorf("sandbox: shell command produced no result (exit code %d): %s", resp.ExitCode, resp.Stderr)
+	}
+	output, _ := value["output"].(string)
+	if code, _ := value["exit_code"].(float64); code != 0 {
+		return output, fmt.Errorf("sandbox: command exited with status %d", int(code))
+	}
+	return output, nil
+}
+
+// ExecuteStreaming runs command like Execute and delivers the whole
+// output as a single chunk; sandbox providers return output only
+// when the run ends. A non-zero exit status is appended to the
+// output as a final line.
+func (s *SessionShell) ExecuteStreaming(command string) (<-chan string, error) {
+	output, err := s.Execute(command)
+	if err != nil && output == "" {
+		return nil, err
+	}
+	if err != nil {
+		output += "\n" + err.Error()
+	}
+	ch := make(chan string, 1)
+	ch <- output
+	close(ch)
+	return ch, nil
+}
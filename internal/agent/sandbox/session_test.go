@@ -0,0 +1,335 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	agenttool "ragflow/internal/agent/tool"
+)
+
+// sessionFakeProvider counts instance lifecycle calls and records
+// which instance each execution ran on.
+type sessionFakeProvider struct {
+	mu        sync.Mutex
+	created   int
+	destroyed []string
+	ranOn     []string
+	stateful  bool
+}
+
+func (f *sessionFakeProvider) Initialize(context.Context) error { return nil }
+func (f *sessionFakeProvider) ProviderType() ProviderType       { return ProviderLocal }
+func (f *sessionFakeProvider) CreateInstance(_ context.Context, template string) (*SandboxInstance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created++
+	return &SandboxInstance{
+		InstanceID: fmt.Sprintf("inst-%d", f.created),
+		Provider:   ProviderLocal,
+		Metadata:   map[string]any{"language": template},
+	}, nil
+}
+func (f *sessionFakeProvider) ExecuteCode(_ context.Context, inst *SandboxInstance, _, _ string, _ int, _ map[string]any) (*ExecutionResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ranOn = append(f.ranOn, inst.InstanceID)
+	return &ExecutionResult{Metadata: map[string]any{"instance_id": inst.InstanceID}}, nil
+}
+func (f *sessionFakeProvider) DestroyInstance(_ context.Context, inst *SandboxInstance) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.destroyed = append(f.destroyed, inst.InstanceID)
+	return nil
+}
+func (f *sessionFakeProvider) HealthCheck(context.Context) error { return nil }
+func (f *sessionFakeProvider) SupportedLanguages() []string      { return []string{"python", "nodejs"} }
+
+func (f *sessionFakeProvider) counts() (created, destroyed int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.created, len(f.destroyed)
+}
+
+// statefulFakeProvider also implements StatefulProvider.
+type statefulFakeProvider struct{ sessionFakeProvider }
+
+func (f *statefulFakeProvider) CreateStatefulInstance(ctx context.Context, template string) (*SandboxInstance, error) {
+	inst, err := f.CreateInstance(ctx, template)
+	if err == nil {
+		inst.Metadata["stateful"] = true
+		f.mu.Lock()
+		f.stateful = true
+		f.mu.Unlock()
+	}
+	return inst, err
+}
+
+// newTestSessionPool returns a pool on a fake clock.
+func newTestSessionPool(cfg map[string]any) (*SessionPool, *time.Time) {
+	pool := newSessionPoolFromConfig(cfg)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	pool.now = func() time.Time { return now }
+	return pool, &now
+}
+
+func TestSessionPool_FromConfig(t *testing.T) {
+	pool := newSessionPoolFromConfig(map[string]any{})
+	if pool.idleTTL != 600*time.Second || pool.maxLifetime != time.Hour || pool.maxSessions != 64 || !pool.Enabled() {
+		t.Errorf("defaults: idle=%v lifetime=%v max=%d enabled=%v", pool.idleTTL, pool.maxLifetime, pool.maxSessions, pool.Enabled())
+	}
+	pool = newSessionPoolFromConfig(map[string]any{"IDLE_TTL": "0"})
+	if pool.Enabled() {
+		t.Error("IDLE_TTL=0: pool enabled, want disabled")
+	}
+	var nilPool *SessionPool
+	if nilPool.Enabled() {
+		t.Error("nil pool reports enabled")
+	}
+}
+
+func TestSessionPool_ReusesInstancePerSessionAndLanguage(t *testing.T) {
+	ctx := context.Background()
+	p := &sessionFakeProvider{}
+	pool, _ := newTestSessionPool(map[string]any{})
+
+	first, err := pool.Execute(ctx, p, "s1", "python3", "code", 5, nil)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	second, err := pool.Execute(ctx, p, "s1", "python", "code", 5, nil)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if first.Metadata["instance_id"] != second.Metadata["instance_id"] {
+		t.Errorf("instances differ: %v vs %v", first.Metadata["instance_id"], second.Metadata["instance_id"])
+	}
+	if first.Metadata["session_reused"] != false || second.Metadata["session_reused"] != true {
+		t.Errorf("session_reused = %v, %v; want false, true", first.Metadata["session_reused"], second.Metadata["session_reused"])
+	}
+	if second.Metadata["session_id"] != "s1" {
+		t.Errorf("session_id = %v", second.Metadata["session_id"])
+	}
+
+	if _, err := pool.Execute(ctx, p, "s1", "javascript", "code", 5, nil); err != nil {
+		t.Fatalf("Execute js: %v", err)
+	}
+	if _, err := pool.Execute(ctx, p, "s2", "python", "code", 5, nil); err != nil {
+		t.Fatalf("Execute s2: %v", err)
+	}
+	if created, destroyed := p.counts(); created != 3 || destroyed != 0 {
+		t.Errorf("created=%d destroyed=%d, want 3, 0", created, destroyed)
+	}
+	if pool.Len() != 3 {
+		t.Errorf("Len = %d, want 3", pool.Len())
+	}
+
+	if err := pool.Close(ctx, "s1"); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if created, destroyed := p.counts(); created != 3 || destroyed != 2 {
+		t.Errorf("after Close: created=%d destroyed=%d, want 3, 2", created, destroyed)
+	}
+	if err := pool.Close(ctx, "unknown"); err != nil {
+		t.Errorf("Close(unknown): %v", err)
+	}
+	if err := pool.CloseAll(ctx); err != nil {
+		t.Fatalf("CloseAll: %v", err)
+	}
+	if pool.Len() != 0 {
+		t.Errorf("Len after CloseAll = %d", pool.Len())
+	}
+}
+
+func TestSessionPool_PrefersStatefulInstances(t *testing.T) {
+	p := &statefulFakeProvider{}
+	pool, _ := newTestSessionPool(map[string]any{})
+	if _, err := pool.Execute(context.Background(), p, "s1", "python", "code", 5, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !p.stateful {
+		t.Error("pool did not use CreateStatefulInstance")
+	}
+}
+
+func TestSessionPool_SweepExpiresIdleAndOldSessions(t *testing.T) {
+	ctx := context.Background()
+	p := &sessionFakeProvider{}
+	pool, now := newTestSessionPool(map[string]any{"IDLE_TTL": 60, "MAX_LIFETIME": 300})
+
+	for _, id := range []string{"idle", "busy"} {
+		if _, err := pool.Execute(ctx, p, id, "python", "code", 5, nil); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	*now = now.Add(30 * time.Second)
+	if n := pool.Sweep(ctx); n != 0 {
+		t.Errorf("Sweep at 30s removed %d", n)
+	}
+	// Keep "busy" active; "idle" crosses the idle TTL.
+	if _, err := pool.Execute(ctx, p, "busy", "python", "code", 5, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	*now = now.Add(45 * time.Second)
+	if n := pool.Sweep(ctx); n != 1 {
+		t.Errorf("Sweep at 75s removed %d, want 1", n)
+	}
+
+	// "busy" stays active but runs past its max lifetime.
+	for i := 0; i < 5; i++ {
+		*now = now.Add(50 * time.Second)
+		if _, err := pool.Execute(ctx, p, "busy", "python", "code", 5, nil); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	// The replaced instance is destroyed in the background.
+	waitForDestroyed(t, p, 2)
+	if created, _ := p.counts(); created != 3 {
+		t.Errorf("created=%d, want 3 (max lifetime replaces the instance)", created)
+	}
+}
+
+func waitForDestroyed(t *testing.T, p *sessionFakeProvider, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, destroyed := p.counts(); destroyed == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			_, destroyed := p.counts()
+			t.Fatalf("destroyed %d instances, want %d", destroyed, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSessionPool_EvictsLeastRecentlyUsedAtLimit(t *testing.T) {
+	ctx := context.Background()
+	p := &sessionFakeProvider{}
+	pool, now := newTestSessionPool(map[string]any{"MAX": 2})
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := pool.Execute(ctx, p, id, "python", "code", 5, nil); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		*now = now.Add(time.Second)
+	}
+	if _, err := pool.Execute(ctx, p, "a", "python", "code", 5, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	*now = now.Add(time.Second)
+	if _, err := pool.Execute(ctx, p, "c", "python", "code", 5, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if pool.Len() != 2 {
+		t.Errorf("Len = %d, want 2", pool.Len())
+	}
+	pool.mu.Lock()
+	_, hasB := pool.sessions[sessionKey{sessionID: "b", language: "python"}]
+	pool.mu.Unlock()
+	if hasB {
+		t.Error("least recently used session b was not evicted")
+	}
+}
+
+// blockingProvider holds ExecuteCode until release is closed.
+type blockingProvider struct {
+	sessionFakeProvider
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingProvider) ExecuteCode(ctx context.Context, inst *SandboxInstance, code, lang string, timeout int, args map[string]any) (*ExecutionResult, error) {
+	b.entered <- struct{}{}
+	<-b.release
+	return b.sessionFakeProvider.ExecuteCode(ctx, inst, code, lang, timeout, args)
+}
+
+func TestSessionPool_LimitWithAllSessionsBusy(t *testing.T) {
+	ctx := context.Background()
+	p := &blockingProvider{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	pool, _ := newTestSessionPool(map[string]any{"MAX": 1})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := pool.Execute(ctx, p, "a", "python", "code", 5, nil)
+		done <- err
+	}()
+	<-p.entered
+	if _, err := pool.Execute(ctx, p, "b", "python", "code", 5, nil); !errors.Is(err, ErrSessionLimit) {
+		t.Errorf("Execute at limit: err = %v, want ErrSessionLimit", err)
+	}
+	close(p.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Execute a: %v", err)
+	}
+}
+
+func TestSessionPool_ProviderChangeReplacesInstance(t *testing.T) {
+	ctx := context.Background()
+	oldP, newP := &sessionFakeProvider{}, &sessionFakeProvider{}
+	pool, _ := newTestSessionPool(map[string]any{})
+	if _, err := pool.Execute(ctx, oldP, "s1", "python", "code", 5, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	res, err := pool.Execute(ctx, newP, "s1", "python", "code", 5, nil)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Metadata["session_reused"] != false {
+		t.Error("session reused across providers")
+	}
+	waitForDestroyed(t, oldP, 1)
+}
+
+func TestManagerClient_RoutesSessionRequestsThroughPool(t *testing.T) {
+	p := &sessionFakeProvider{}
+	mgr := &ProviderManager{}
+	mgr.SetProvider(p)
+	pool, _ := newTestSessionPool(map[string]any{})
+	mgr.sessionsOnce.Do(func() { mgr.sessions = pool })
+	client := &ManagerClient{manager: mgr}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.ExecuteCode(context.Background(), agenttool.SandboxRequest{
+			Lang: "python", Script: "code", SessionID: "s1",
+		}); err != nil {
+			t.Fatalf("ExecuteCode: %v", err)
+		}
+	}
+	if created, destroyed := p.counts(); created != 1 || destroyed != 0 {
+		t.Errorf("session calls: created=%d destroyed=%d, want 1, 0", created, destroyed)
+	}
+	if _, err := client.ExecuteCode(context.Background(), agenttool.SandboxRequest{Lang: "python", Script: "code"}); err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if created, destroyed := p.counts(); created != 2 || destroyed != 1 {
+		t.Errorf("sessionless call: created=%d destroyed=%d, want 2, 1", created, destroyed)
+	}
+	if err := client.CloseSession(context.Background(), "s1"); err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+	if _, destroyed := p.counts(); destroyed != 2 {
+		t.Errorf("CloseSession destroyed %d total, want 2", destroyed)
+	}
+}
@@ -0,0 +1,97 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Command wasmguest stands in for the CPython / QuickJS interpreter
+// modules in the WASM provider's tests. Built with GOOS=wasip1
+// GOARCH=wasm, it reads the script named by its first argument and
+// runs the "#!guest <op> [args]" lines in it, ignoring the rest (the
+// wrapper the provider appends):
+//
+//	print <text>             write text to stdout
+//	result <json>            emit the __RAGFLOW_RESULT__ marker for json
+//	artifact <name> <text>   write artifacts/<name>
+//	open <path>              print "open <path>: ok" or the error
+//	env <name>               print the variable
+//	alloc <mb>               allocate and touch mb MiB
+//	spin                     call a function forever
+//	exit <code>              exit
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, ok := strings.CutPrefix(sc.Text(), "#!guest ")
+		if !ok {
+			continue
+		}
+		op, arg, _ := strings.Cut(line, " ")
+		switch op {
+		case "print":
+			fmt.Println(arg)
+		case "result":
+			payload := `{"present":true,"value":` + arg + `,"type":"json"}`
+			fmt.Println("__RAGFLOW_RESULT__:" + base64.StdEncoding.EncodeToString([]byte(payload)))
+		case "artifact":
+			name, text, _ := strings.Cut(arg, " ")
+			if err := os.WriteFile("artifacts/"+name, []byte(text), 0o600); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+		case "open":
+			if g, err := os.Open(arg); err != nil {
+				fmt.Printf("open %s: %v\n", arg, err)
+			} else {
+				g.Close()
+				fmt.Printf("open %s: ok\n", arg)
+			}
+		case "env":
+			fmt.Println(os.Getenv(arg))
+		case "alloc":
+			mb, _ := strconv.Atoi(arg)
+			buf := make([]byte, mb<<20)
+			for i := range buf {
+				buf[i] = 1
+			}
+			fmt.Println("allocated", len(buf))
+		case "spin":
+			for {
+				tick()
+			}
+		case "exit":
+			code, _ := strconv.Atoi(arg)
+			os.Exit(code)
+		}
+	}
+}
+
+var ticks int
+
+//go:noinline
+func tick() { ticks++ }
@@ -0,0 +1,472 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// wasm.go — the in-process WebAssembly sandbox provider.
+//
+// WASMProvider runs CodeExec code inside the Go process on the
+// wazero runtime (pure Go, no cgo, no daemon): Python through a
+// CPython interpreter compiled to WASI, JavaScript through QuickJS
+// compiled to WASI. The operator supplies the interpreter modules
+// (WASM_PYTHON_MODULE, WASM_JS_MODULE); a language whose module is
+// not configured is not offered.
+//
+// The guest sees only what WASI preview 1 gives it:
+//
+//   - linear memory capped at WASM_MAX_MEMORY_MB;
+//   - a virtual filesystem with the instance dir at /workspace (the
+//     wrapped script in, artifacts/ out) and, read-only, the Python
+//     stdlib tree (WASM_PYTHON_HOME) at /usr/local;
+//   - args, env, stdout / stderr, clocks and randomness.
+//
+// WASI preview 1 has no sockets, so there is no network at all.
+// CPU is bounded by the timeout, which closes the module, and by
+// fuel: wazero does not meter instructions, so fuel is counted in
+// guest function calls (WASM_FUEL per execution, 0 disables it) and
+// the timeout catches call-free loops.
+//
+// The wire contract is the one every provider shares: the code is
+// wrapped with BuildPythonWrapper / BuildQuickJSWrapper, stdout is
+// scanned with ExtractStructuredResult, and artifacts are collected
+// from <instance_dir>/artifacts with collectArtifactDir.
+
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// wasmDefaultWorkDir is the default parent of the instance dirs.
+const wasmDefaultWorkDir = "/tmp/ragflow-wasm"
+
+// wasmDefaultTimeout is the per-execution timeout in seconds.
+const wasmDefaultTimeout = 30
+
+// wasmDefaultMaxMemoryMB caps the guest's linear memory. CPython
+// needs a few tens of MiB to start.
+const wasmDefaultMaxMemoryMB = 256
+
+// wasmDefaultFuel is the per-execution fuel, in guest function calls.
+const wasmDefaultFuel = 2_000_000_000
+
+// wasmWorkspace is the guest path of the instance dir, and
+// wasmPythonHome the guest path of WASM_PYTHON_HOME.
+const (
+	wasmWorkspace  = "/workspace"
+	wasmPythonHome = "/usr/local"
+)
+
+// wasmPageBytes is the WebAssembly page size.
+const wasmPageBytes = 64 << 10
+
+var (
+	errWASMTimeout       = errors.New("wasm: execution timed out")
+	errWASMFuelExhausted = errors.New("wasm: execution ran out of fuel")
+)
+
+// WASMProvider is the in-process WebAssembly sandbox provider.
+type WASMProvider struct {
+	pythonModule     string
+	pythonHome       string
+	jsModule         string
+	workDir          string
+	timeout          int
+	maxMemoryMB      int
+	fuel             int64
+	maxOutputBytes   int
+	maxArtifacts     int
+	maxArtifactBytes int
+
+	mu          sync.Mutex
+	runtime     wazero.Runtime
+	modules     map[string]wazero.CompiledModule // language -> interpreter
+	initialized bool
+}
+
+// newWASMProviderFromEnv reads WASM_* env vars.
+func newWASMProviderFromEnv() *WASMProvider {
+	return newWASMProviderFromConfig(wasmConfigFromEnv())
+}
+
+// wasmConfigFromEnv builds a config map from the WASM_* env vars,
+// mirroring the admin-panel settings JSON shape.
+func wasmConfigFromEnv() map[string]any {
+	return map[string]any{
+		"PYTHON_MODULE":      os.Getenv("WASM_PYTHON_MODULE"),
+		"PYTHON_HOME":        os.Getenv("WASM_PYTHON_HOME"),
+		"JS_MODULE":          os.Getenv("WASM_JS_MODULE"),
+		"WORK_DIR":           os.Getenv("WASM_WORK_DIR"),
+		"TIMEOUT":            os.Getenv("WASM_TIMEOUT"),
+		"MAX_MEMORY_MB":      os.Getenv("WASM_MAX_MEMORY_MB"),
+		"FUEL":               os.Getenv("WASM_FUEL"),
+		"MAX_OUTPUT_BYTES":   os.Getenv("WASM_MAX_OUTPUT_BYTES"),
+		"MAX_ARTIFACTS":      os.Getenv("WASM_MAX_ARTIFACTS"),
+		"MAX_ARTIFACT_BYTES": os.Getenv("WASM_MAX_ARTIFACT_BYTES"),
+	}
+}
+
+// newWASMProviderFromConfig builds the provider from a JSON config
+// map. Config keys mirror the env-var names without the WASM_
+// prefix.
+func newWASMProviderFromConfig(cfg map[string]any) *WASMProvider {
+	p := &WASMProvider{
+		pythonModule:     configString(cfg, "PYTHON_MODULE"),
+		pythonHome:       configString(cfg, "PYTHON_HOME"),
+		jsModule:         configString(cfg, "JS_MODULE"),
+		workDir:          configString(cfg, "WORK_DIR"),
+		timeout:          configInt(cfg, "TIMEOUT", wasmDefaultTimeout),
+		maxMemoryMB:      configInt(cfg, "MAX_MEMORY_MB", wasmDefaultMaxMemoryMB),
+		fuel:             int64(configFloat(cfg, "FUEL", wasmDefaultFuel)),
+		maxOutputBytes:   configInt(cfg, "MAX_OUTPUT_BYTES", localDefaultMaxOutputBytes),
+		maxArtifacts:     configInt(cfg, "MAX_ARTIFACTS", localDefaultMaxArtifacts),
+		maxArtifactBytes: configInt(cfg, "MAX_ARTIFACT_BYTES", localDefaultMaxArtifactBytes),
+	}
+	if p.workDir == "" {
+		p.workDir = wasmDefaultWorkDir
+	}
+	return p
+}
+
+// ProviderType returns ProviderWASM.
+func (p *WASMProvider) ProviderType() ProviderType { return ProviderWASM }
+
+// SupportedLanguages lists the languages whose interpreter module is
+// configured.
+func (p *WASMProvider) SupportedLanguages() []string {
+	var langs []string
+	if p.pythonModule != "" {
+		langs = append(langs, "python")
+	}
+	if p.jsModule != "" {
+		langs = append(langs, "nodejs", "javascript")
+	}
+	return langs
+}
+
+// Initialize creates the work dir, starts the runtime and compiles
+// the interpreter modules, so a bad module fails here instead of on
+// the first execution. Compiled code is cached under
+// <work_dir>/.cache across restarts.
+func (p *WASMProvider) Initialize(ctx context.Context) error {
+	if p.pythonModule == "" && p.jsModule == "" {
+		return errors.New("wasm: no interpreter module configured (set WASM_PYTHON_MODULE and/or WASM_JS_MODULE)")
+	}
+	if p.maxMemoryMB <= 0 || p.maxMemoryMB > 4096 {
+		return fmt.Errorf("wasm: max memory %d MiB out of range (1..4096)", p.maxMemoryMB)
+	}
+	if p.pythonHome != "" {
+		if info, err := os.Stat(p.pythonHome); err != nil || !info.IsDir() {
+			return fmt.Errorf("wasm: python home %q is not a directory", p.pythonHome)
+		}
+	}
+	if err := os.MkdirAll(p.workDir, 0o700); err != nil {
+		return fmt.Errorf("wasm: create work_dir %q: %w", p.workDir, err)
+	}
+	cache, err := wazero.NewCompilationCacheWithDir(filepath.Join(p.workDir, ".cache"))
+	if err != nil {
+		return fmt.Errorf("wasm: compilation cache: %w", err)
+	}
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(p.maxMemoryMB<<20/wasmPageBytes)).
+		WithCloseOnContextDone(true).
+		WithCompilationCache(cache))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		_ = rt.Close(ctx)
+		return fmt.Errorf("wasm: instantiate WASI: %w", err)
+	}
+	// The fuel listener is bound at compile time; each execution's
+	// meter rides on its context.
+	compileCtx := ctx
+	if p.fuel > 0 {
+		compileCtx = experimental.WithFunctionListenerFactory(ctx, fuelListenerFactory)
+	}
+	modules := map[string]wazero.CompiledModule{}
+	for lang, path := range map[string]string{"python": p.pythonModule, "nodejs": p.jsModule} {
+		if path == "" {
+			continue
+		}
+		bin, err := os.ReadFile(path)
+		if err != nil {
+			_ = rt.Close(ctx)
+			return fmt.Errorf("wasm: read %s module: %w", lang, err)
+		}
+		mod, err := rt.CompileModule(compileCtx, bin)
+		if err != nil {
+			_ = rt.Close(ctx)
+			return fmt.Errorf("wasm: compile %s module %q: %w", lang, path, err)
+		}
+		modules[lang] = mod
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.runtime != nil {
+		_ = p.runtime.Close(ctx)
+	}
+	p.runtime, p.modules, p.initialized = rt, modules, true
+	return nil
+}
+
+// CreateInstance provisions a fresh instance dir under workDir. The
+// dir is the guest's /workspace.
+func (p *WASMProvider) CreateInstance(ctx context.Context, template string) (*SandboxInstance, error) {
+	if !p.isInitialized() {
+		return nil, fmt.Errorf("wasm: provider not initialized")
+	}
+	lang := normalizeLanguage(template)
+	if lang == "" || p.module(lang) == nil {
+		return nil, fmt.Errorf("wasm: unsupported language %q", template)
+	}
+	instanceID := uuid.NewString()
+	instanceDir := filepath.Join(p.workDir, instanceID)
+	if err := os.MkdirAll(filepath.Join(instanceDir, "artifacts"), 0o700); err != nil {
+		_ = os.RemoveAll(instanceDir)
+		return nil, fmt.Errorf("wasm: create instance dir: %w", err)
+	}
+	return &SandboxInstance{
+		InstanceID: instanceID,
+		Provider:   ProviderWASM,
+		Status:     "running",
+		Metadata: map[string]any{
+			"language": lang,
+			"work_dir": instanceDir,
+		},
+	}, nil
+}
+
+// ExecuteCode runs the wrapped code in a fresh instance of the
+// language's interpreter module. A trap (including running out of
+// memory) is a failed execution — exit code 1, the trap on stderr —
+// not a provider error; the timeout, fuel exhaustion and caller
+// cancellation are errors, as with the local provider.
+func (p *WASMProvider) ExecuteCode(
+	ctx context.Context,
+	inst *SandboxInstance,
+	code, language string,
+	timeoutSec int,
+	args map[string]any,
+) (*ExecutionResult, error) {
+	if !p.isInitialized() {
+		return nil, fmt.Errorf("wasm: provider not initialized")
+	}
+	if inst == nil || inst.InstanceID == "" {
+		return nil, fmt.Errorf("wasm: instance id required")
+	}
+	lang := normalizeLanguage(language)
+	compiled := p.module(lang)
+	if compiled == nil {
+		return nil, fmt.Errorf("wasm: unsupported language %q", language)
+	}
+	timeout, err := validateTimeout(timeoutSec)
+	if err != nil {
+		return nil, err
+	}
+	instanceDir := filepath.Join(p.workDir, inst.InstanceID)
+	if _, err := os.Stat(instanceDir); err != nil {
+		return nil, fmt.Errorf("wasm: instance dir missing: %w", err)
+	}
+	argsJSON, err := argsToJSON(args)
+	if err != nil {
+		return nil, err
+	}
+
+	script, source, argv := "main.py", BuildPythonWrapper(code, argsJSON), []string{"python"}
+	if lang == "nodejs" {
+		script, source, argv = "main.js", BuildQuickJSWrapper(code, argsJSON), []string{"qjs", "--std"}
+	}
+	scriptPath := filepath.Join(instanceDir, script)
+	if err := os.WriteFile(scriptPath, []byte(source), 0o600); err != nil {
+		return nil, fmt.Errorf("wasm: write %s: %w", script, err)
+	}
+	argv = append(argv, wasmWorkspace+"/"+script)
+
+	fsConfig := wazero.NewFSConfig().WithDirMount(instanceDir, wasmWorkspace)
+	env := map[string]string{"HOME": wasmWorkspace, "TMPDIR": wasmWorkspace, "PYTHONUNBUFFERED": "1", "MPLBACKEND": "Agg"}
+	if lang == "python" && p.pythonHome != "" {
+		fsConfig = fsConfig.WithReadOnlyDirMount(p.pythonHome, wasmPythonHome)
+		env["PYTHONHOME"] = wasmPythonHome
+	}
+	stdout := &cappedBuffer{limit: p.maxOutputBytes}
+	stderr := &cappedBuffer{limit: p.maxOutputBytes}
+	modConfig := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(argv...).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithFSConfig(fsConfig).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+	for k, v := range env {
+		modConfig = modConfig.WithEnv(k, v)
+	}
+
+	runCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	timer := time.AfterFunc(time.Duration(timeout)*time.Second, func() { stop(errWASMTimeout) })
+	defer timer.Stop()
+	meter := &fuelMeter{stop: stop}
+	meter.remaining.Store(p.fuel)
+	runCtx = context.WithValue(runCtx, fuelMeterKey{}, meter)
+
+	start := time.Now()
+	mod, runErr := p.runtime.InstantiateModule(runCtx, compiled, modConfig)
+	if mod != nil {
+		_ = mod.Close(context.Background())
+	}
+	elapsed := time.Since(start).Seconds()
+
+	exitCode := 0
+	var exitErr *sys.ExitError
+	switch {
+	case runErr == nil:
+	case errors.As(runErr, &exitErr) && exitErr.ExitCode() != sys.ExitCodeContextCanceled && exitErr.ExitCode() != sys.ExitCodeDeadlineExceeded:
+		exitCode = int(exitErr.ExitCode())
+	case errors.Is(context.Cause(runCtx), errWASMTimeout):
+		return nil, fmt.Errorf("wasm: execution timed out after %d seconds", timeout)
+	case errors.Is(context.Cause(runCtx), errWASMFuelExhausted):
+		return nil, fmt.Errorf("%w (%d calls)", errWASMFuelExhausted, p.fuel)
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("wasm: execution cancelled: %w", ctx.Err())
+	default:
+		exitCode = 1
+		fmt.Fprintf(stderr, "\nwasm: %v\n", runErr)
+	}
+
+	if stdout.overflow || stderr.overflow || (p.maxOutputBytes > 0 && stdout.Len()+stderr.Len() > p.maxOutputBytes) {
+		return nil, fmt.Errorf("wasm: output exceeds %d bytes", p.maxOutputBytes)
+	}
+	cleanedStdout, structured := ExtractStructuredResult(stdout.String())
+	artifacts, err := collectArtifactDir(filepath.Join(instanceDir, "artifacts"), artifactsSince(start), p.maxArtifacts, p.maxArtifactBytes)
+	if err != nil {
+		return nil, fmt.Errorf("wasm: collect artifacts: %w", err)
+	}
+	metadata := map[string]any{
+		"instance_id":       inst.InstanceID,
+		"language":          lang,
+		"script_path":       scriptPath,
+		"status":            statusFromExitCode(exitCode),
+		"timeout":           timeout,
+		"artifacts":         artifacts,
+		"structured_result": structured,
+	}
+	if p.fuel > 0 {
+		metadata["fuel_used"] = p.fuel - meter.remaining.Load()
+	}
+	return &ExecutionResult{
+		Stdout:        cleanedStdout,
+		Stderr:        stderr.String(),
+		ExitCode:      exitCode,
+		ExecutionTime: elapsed,
+		Metadata:      metadata,
+	}, nil
+}
+
+// DestroyInstance removes the instance dir. Idempotent.
+func (p *WASMProvider) DestroyInstance(ctx context.Context, inst *SandboxInstance) error {
+	if !p.isInitialized() {
+		return fmt.Errorf("wasm: provider not initialized")
+	}
+	if inst == nil || inst.InstanceID == "" {
+		return fmt.Errorf("wasm: instance id required")
+	}
+	if err := os.RemoveAll(filepath.Join(p.workDir, inst.InstanceID)); err != nil {
+		return fmt.Errorf("wasm: remove instance dir: %w", err)
+	}
+	return nil
+}
+
+// HealthCheck verifies the runtime is up and the work dir is
+// writable.
+func (p *WASMProvider) HealthCheck(ctx context.Context) error {
+	if !p.isInitialized() {
+		return errors.New("wasm: provider not initialized")
+	}
+	probe := filepath.Join(p.workDir, ".ragflow-healthcheck")
+	if err := os.WriteFile(probe, []byte("ok"), 0o600); err != nil {
+		return fmt.Errorf("wasm: work_dir not writable: %w", err)
+	}
+	_ = os.Remove(probe)
+	return nil
+}
+
+func (p *WASMProvider) isInitialized() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.initialized
+}
+
+func (p *WASMProvider) module(lang string) wazero.CompiledModule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.modules[lang]
+}
+
+// fuelMeterKey carries an execution's *fuelMeter on its context.
+type fuelMeterKey struct{}
+
+// fuelMeter is one execution's fuel tank. Every guest function call
+// burns one unit; the call that empties it cancels the execution.
+type fuelMeter struct {
+	remaining atomic.Int64
+	stop      context.CancelCauseFunc
+}
+
+// fuelListenerFactory hands every guest function the same listener,
+// which burns fuel from the meter on the call's context.
+var fuelListenerFactory = experimental.FunctionListenerFactoryFunc(func(api.FunctionDefinition) experimental.FunctionListener {
+	return burnFuel
+})
+
+var burnFuel = experimental.FunctionListenerFunc(func(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	if m, ok := ctx.Value(fuelMeterKey{}).(*fuelMeter); ok && m.remaining.Add(-1) == 0 {
+		m.stop(errWASMFuelExhausted)
+	}
+})
+
+// cappedBuffer keeps at most limit bytes and records whether more
+// were written, so a chatty guest cannot grow the host's memory.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); b.limit > 0 && len(p) > room {
+		b.overflow = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,197 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sandbox
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	wasmGuestOnce sync.Once
+	wasmGuestPath string
+	wasmGuestErr  error
+)
+
+// buildWASMGuest compiles testdata/wasmguest for wasip1 once per test
+// binary. Tests that need it skip when the toolchain cannot.
+func buildWASMGuest(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("short mode — skipping the wasip1 guest build")
+	}
+	wasmGuestOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "wasmguest")
+		if err != nil {
+			wasmGuestErr = err
+			return
+		}
+		wasmGuestPath = filepath.Join(dir, "guest.wasm")
+		cmd := exec.Command("go", "build", "-o", wasmGuestPath, "./testdata/wasmguest")
+		cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm", "CGO_ENABLED=0")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			wasmGuestErr = err
+			wasmGuestPath = string(out)
+		}
+	})
+	if wasmGuestErr != nil {
+		t.Skipf("cannot build the wasip1 test guest: %v\n%s", wasmGuestErr, wasmGuestPath)
+	}
+	return wasmGuestPath
+}
+
+// newWASMForTest builds a provider whose "python" interpreter is the
+// test guest.
+func newWASMForTest(t *testing.T, cfg map[string]any) *WASMProvider {
+	t.Helper()
+	full := map[string]any{
+		"PYTHON_MODULE": buildWASMGuest(t),
+		"WORK_DIR":      t.TempDir(),
+	}
+	for k, v := range cfg {
+		full[k] = v
+	}
+	p := newWASMProviderFromConfig(full)
+	if err := p.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	return p
+}
+
+func runWASMGuest(t *testing.T, p *WASMProvider, script string, timeout int) (*ExecutionResult, error) {
+	t.Helper()
+	ctx := context.Background()
+	inst, err := p.CreateInstance(ctx, "python")
+	if err != nil {
+		t.Fatalf("CreateInstance: %v", err)
+	}
+	t.Cleanup(func() { _ = p.DestroyInstance(ctx, inst) })
+	return p.ExecuteCode(ctx, inst, script, "python", timeout, map[string]any{"x": 1})
+}
+
+func TestWASM_ConfigAndLanguages(t *testing.T) {
+	t.Parallel()
+	p := newWASMProviderFromConfig(map[string]any{"JS_MODULE": "/opt/qjs.wasm", "FUEL": "0", "MAX_MEMORY_MB": float64(64)})
+	if got := strings.Join(p.SupportedLanguages(), ","); got != "nodejs,javascript" {
+		t.Errorf("SupportedLanguages = %q", got)
+	}
+	if p.fuel != 0 || p.maxMemoryMB != 64 || p.workDir != wasmDefaultWorkDir || p.timeout != wasmDefaultTimeout {
+		t.Errorf("config: fuel=%d mem=%d workDir=%q timeout=%d", p.fuel, p.maxMemoryMB, p.workDir, p.timeout)
+	}
+	if p.ProviderType() != ProviderWASM {
+		t.Errorf("ProviderType = %q", p.ProviderType())
+	}
+}
+
+func TestWASM_Initialize_RequiresAModule(t *testing.T) {
+	p := newWASMProviderFromConfig(map[string]any{"WORK_DIR": t.TempDir()})
+	if err := p.Initialize(context.Background()); err == nil || !strings.Contains(err.Error(), "no interpreter module") {
+		t.Fatalf("Initialize = %v, want a missing-module error", err)
+	}
+	if _, err := p.CreateInstance(context.Background(), "python"); err == nil {
+		t.Fatal("CreateInstance before Initialize succeeded")
+	}
+}
+
+// TestWASM_ExecuteCode_Contract covers the shared provider contract:
+// structured result, stdout, artifacts, env, and a filesystem that
+// holds nothing but the workspace.
+func TestWASM_ExecuteCode_Contract(t *testing.T) {
+	p := newWASMForTest(t, nil)
+	script := strings.Join([]string{
+		"#!guest print hello from wasm",
+		`#!guest result {"answer":42}`,
+		"#!guest artifact table.csv a,b",
+		"#!guest open /etc/passwd",
+		"#!guest open " + wasmWorkspace + "/main.py",
+		"#!guest env HOME",
+	}, "\n")
+	result, err := runWASMGuest(t, p, script, 10)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("ExitCode = %d; stderr=%q", result.ExitCode, result.Stderr)
+	}
+	for _, want := range []string{"hello from wasm\n", "open " + wasmWorkspace + "/main.py: ok", "\n" + wasmWorkspace + "\n"} {
+		if !strings.Contains(result.Stdout, want) {
+			t.Errorf("stdout %q does not contain %q", result.Stdout, want)
+		}
+	}
+	if strings.Contains(result.Stdout, "/etc/passwd: ok") || strings.Contains(result.Stdout, resultMarkerPrefix) {
+		t.Errorf("stdout %q: host file visible or marker not stripped", result.Stdout)
+	}
+	sr, _ := result.Metadata["structured_result"].(map[string]any)
+	if v, _ := sr["value"].(map[string]any); v["answer"] != float64(42) {
+		t.Errorf("structured_result = %v", sr)
+	}
+	arts, _ := result.Metadata["artifacts"].([]map[string]any)
+	if len(arts) != 1 || arts[0]["name"] != "table.csv" || arts[0]["mime_type"] != "text/csv; charset=utf-8" {
+		t.Errorf("artifacts = %v", arts)
+	}
+	if used, _ := result.Metadata["fuel_used"].(int64); used <= 0 {
+		t.Errorf("fuel_used = %v, want > 0", result.Metadata["fuel_used"])
+	}
+}
+
+func TestWASM_ExecuteCode_ExitCode(t *testing.T) {
+	p := newWASMForTest(t, nil)
+	result, err := runWASMGuest(t, p, "#!guest print before\n#!guest exit 3", 10)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if result.ExitCode != 3 || result.Metadata["status"] != "error" {
+		t.Fatalf("ExitCode = %d status = %v", result.ExitCode, result.Metadata["status"])
+	}
+}
+
+func TestWASM_ExecuteCode_FuelExhausted(t *testing.T) {
+	p := newWASMForTest(t, map[string]any{"FUEL": float64(5_000_000)})
+	_, err := runWASMGuest(t, p, "#!guest spin", 30)
+	if err == nil || !strings.Contains(err.Error(), "ran out of fuel") {
+		t.Fatalf("ExecuteCode error = %v, want fuel exhaustion", err)
+	}
+}
+
+func TestWASM_ExecuteCode_Timeout(t *testing.T) {
+	p := newWASMForTest(t, map[string]any{"FUEL": float64(0)})
+	_, err := runWASMGuest(t, p, "#!guest spin", 1)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("ExecuteCode error = %v, want a timeout", err)
+	}
+}
+
+// TestWASM_ExecuteCode_MemoryLimit: growing past the cap fails the
+// execution inside the guest, not the host.
+func TestWASM_ExecuteCode_MemoryLimit(t *testing.T) {
+	p := newWASMForTest(t, map[string]any{"MAX_MEMORY_MB": float64(64)})
+	result, err := runWASMGuest(t, p, "#!guest alloc 128", 10)
+	if err != nil {
+		t.Fatalf("ExecuteCode: %v", err)
+	}
+	if result.ExitCode == 0 || strings.Contains(result.Stdout, "allocated") {
+		t.Fatalf("allocation past the memory limit succeeded: exit=%d stdout=%q", result.ExitCode, result.Stdout)
+	}
+	if !strings.Contains(result.Stderr, "out of memory") {
+		t.Errorf("stderr = %q, want the guest's out-of-memory report", result.Stderr)
+	}
+}
@@ -0,0 +1,453 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// cache.go wraps registry tools with two guards backed by a shared
+// ToolResultStore:
+//
+//   - a result cache for read-only search tools, opted into per tool
+//     with the node-level param cache_ttl (seconds). Entries are keyed
+//     on the tenant, the tool and the normalized call arguments, so
+//     identical calls hit across runs and sessions of one tenant but
+//     never across tenants.
+//   - an idempotency guard for side-effecting tools (email). A call is
+//     keyed on the tenant, the task, the calling node and the
+//     normalized arguments; once it succeeds, a retried node replays
+//     the recorded result instead of repeating the side effect.
+//
+// Both emit a tool_cache_hit run event when they answer from the
+// store. With no store installed (SetToolResultStore) the wrappers
+// call straight through.
+//
+// Redis layout:
+//
+//	agent:tool_cache:{tenant_id}:{tool}:{args_hash}                  cached result, TTL cache_ttl
+//	agent:tool_idem:{tenant_id}:{task_id}:{cpn_id}:{tool}:{args_hash} pending marker, then the result
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	einotool "github.com/cloudwego/eino/components/tool"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"ragflow/internal/agent/runtime"
+	"ragflow/internal/common"
+	redis2 "ragflow/internal/engine/redis"
+)
+
+const (
+	toolCacheKeyPrefix       = "agent:tool_cache:"
+	toolIdempotencyKeyPrefix = "agent:tool_idem:"
+
+	// toolIdempotencyPending marks a side-effecting call that is in
+	// flight. It expires after toolIdempotencyLease so a call lost to
+	// a crash does not block its retries for the whole record TTL.
+	toolIdempotencyPending = "\x00pending"
+	toolIdempotencyLease   = 10 * time.Minute
+	toolIdempotencyTTL     = 24 * time.Hour
+)
+
+// ToolCacheTTLParam is the node-level tool param that enables the
+// result cache for a tool, as a TTL in seconds.
+const ToolCacheTTLParam = "cache_ttl"
+
+// MaxToolCacheTTL caps cache_ttl.
+const MaxToolCacheTTL = 7 * 24 * time.Hour
+
+// ToolCacheHitEvent is the run event type emitted when a tool call is
+// answered from the store.
+const ToolCacheHitEvent = "tool_cache_hit"
+
+// Kinds of ToolCacheHitData.
+const (
+	ToolCacheKindResult      = "cache"
+	ToolCacheKindIdempotency = "idempotency"
+)
+
+// ToolCacheHitData is the "data" payload for tool_cache_hit events.
+type ToolCacheHitData struct {
+	ComponentID string  `json:"component_id"`
+	Tool        string  `json:"tool"`
+	Kind        string  `json:"kind"`
+	ArgsHash    string  `json:"args_hash"`
+	CreatedAt   float64 `json:"created_at"`
+}
+
+// cacheableTools are the registry tools whose results may be cached:
+// read-only lookups whose answer depends only on their arguments and
+// the tenant.
+var cacheableTools = map[string]bool{
+	"arxiv":             true,
+	"duckduckgo":        true,
+	"google":            true,
+	"google_scholar":    true,
+	"pubmed":            true,
+	"retrieval":         true,
+	"search_my_dataset": true,
+	"search_my_dateset": true,
+	"searxng":           true,
+	"tavily":            true,
+	"wikipedia":         true,
+}
+
+// idempotentTools are the registry tools with side effects that must
+// not repeat when a node is retried.
+var idempotentTools = map[string]bool{
+	"email": true,
+}
+
+// ToolResultStore persists cached tool results and idempotency
+// records.
+type ToolResultStore interface {
+	// Get returns the value at key; ok is false when it is unknown or
+	// has expired.
+	Get(ctx context.Context, key string) (value string, ok bool, err error)
+	// Set writes value at key with ttl.
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetNX writes value at key with ttl unless key exists, reporting
+	// whether it wrote.
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Delete removes key.
+	Delete(ctx context.Context, key string) error
+}
+
+// RedisToolResultStore is the Redis-backed ToolResultStore.
+type RedisToolResultStore struct {
+	client *redis.Client
+}
+
+// NewRedisToolResultStore returns a store wired to the global Redis
+// client. Methods error (rather than panic) when the cache is not
+// initialised.
+func NewRedisToolResultStore() *RedisToolResultStore {
+	var client *redis.Client
+	if rc := redis2.Get(); rc != nil {
+		client = rc.GetClient()
+	}
+	return &RedisToolResultStore{client: client}
+}
+
+// NewRedisToolResultStoreWithClient returns a store wired to a
+// caller-supplied redis.Client (tests, dedicated pools).
+func NewRedisToolResultStoreWithClient(client *redis.Client) *RedisToolResultStore {
+	return &RedisToolResultStore{client: client}
+}
+
+var errToolResultStoreUninitialized = errors.New("tool result store: redis client not initialized")
+
+// Get implements ToolResultStore.
+func (s *RedisToolResultStore) Get(ctx context.Context, key string) (string, bool, error) {
+	if s == nil || s.client == nil {
+		return "", false, errToolResultStoreUninitialized
+	}
+	v, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return v, true, nil
+}
+
+// Set implements ToolResultStore.
+func (s *RedisToolResultStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if s == nil || s.client == nil {
+		return errToolResultStoreUninitialized
+	}
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetNX implements ToolResultStore.
+func (s *RedisToolResultStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if s == nil || s.client == nil {
+		return false, errToolResultStoreUninitialized
+	}
+	return s.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// Delete implements ToolResultStore.
+func (s *RedisToolResultStore) Delete(ctx context.Context, key string) error {
+	if s == nil || s.client == nil {
+		return errToolResultStoreUninitialized
+	}
+	return s.client.Del(ctx, key).Err()
+}
+
+var (
+	toolResultStoreMu   sync.RWMutex
+	toolResultStoreImpl ToolResultStore
+)
+
+// SetToolResultStore installs the store behind the result cache and
+// the idempotency guard. Passing nil disables both.
+func SetToolResultStore(s ToolResultStore) {
+	toolResultStoreMu.Lock()
+	defer toolResultStoreMu.Unlock()
+	toolResultStoreImpl = s
+}
+
+func getToolResultStore() ToolResultStore {
+	toolResultStoreMu.RLock()
+	defer toolResultStoreMu.RUnlock()
+	return toolResultStoreImpl
+}
+
+// splitToolCacheParam takes cache_ttl out of the node-level params of
+// tool name, returning the remaining params for the factory and the
+// requested TTL (zero when caching is off).
+func splitToolCacheParam(name string, params map[string]any) (map[string]any, time.Duration, error) {
+	raw, ok := params[ToolCacheTTLParam]
+	if !ok {
+		return params, 0, nil
+	}
+	if !cacheableTools[name] {
+		return nil, 0, fmt.Errorf("agent tool: tool %q does not support %s", name, ToolCacheTTLParam)
+	}
+	secs, ok := intParam(params, ToolCacheTTLParam)
+	ttl := time.Duration(secs) * time.Second
+	if !ok || secs <= 0 || ttl > MaxToolCacheTTL {
+		return nil, 0, fmt.Errorf("agent tool: tool %q: %s must be a number of seconds in [1, %d], got %v",
+			name, ToolCacheTTLParam, int(MaxToolCacheTTL/time.Second), raw)
+	}
+	rest := make(map[string]any, len(params)-1)
+	for k, v := range params {
+		if k != ToolCacheTTLParam {
+			rest[k] = v
+		}
+	}
+	return rest, ttl, nil
+}
+
+// wrapToolResultCache puts the result cache (cacheTTL > 0) or the
+// idempotency guard in front of tool name. Other tools come back
+// unchanged.
+func wrapToolResultCache(name string, t einotool.BaseTool, cacheTTL time.Duration) einotool.BaseTool {
+	inv, ok := t.(einotool.InvokableTool)
+	if !ok {
+		return t
+	}
+	switch {
+	case cacheTTL > 0:
+		return &cachedTool{InvokableTool: inv, name: name, ttl: cacheTTL}
+	case idempotentTools[name]:
+		return &idempotentTool{InvokableTool: inv, name: name}
+	}
+	return t
+}
+
+// cachedResultReplayer is implemented by cacheable tools whose calls
+// have effects on the canvas state besides their result (Retrieval
+// records its chunks for citations). A cache hit hands the cached
+// result back so the tool can redo them.
+type cachedResultReplayer interface {
+	replayCachedResult(ctx context.Context, result string)
+}
+
+// cachedTool answers repeated calls from the result cache.
+type cachedTool struct {
+	einotool.InvokableTool
+	name string
+	ttl  time.Duration
+}
+
+// InvokableRun implements einotool.InvokableTool. Calls without a
+// tenant, with arguments that are not JSON, or while the store is
+// unavailable go straight to the tool. Only successful results are
+// cached.
+func (c *cachedTool) InvokableRun(ctx context.Context, argsJSON string, opts ...einotool.Option) (string, error) {
+	store := getToolResultStore()
+	tenantID := tenantIDFromContext(ctx)
+	hash, ok := toolArgsHash(argsJSON)
+	if store == nil || tenantID == "" || !ok {
+		return c.InvokableTool.InvokableRun(ctx, argsJSON, opts...)
+	}
+	key := toolCacheKeyPrefix + tenantID + ":" + c.name + ":" + hash
+	cached, hit, err := store.Get(ctx, key)
+	if err != nil {
+		common.Warn("agent tool: read result cache", zap.String("tool", c.name), zap.Error(err))
+	} else if hit {
+		if r, ok := c.InvokableTool.(cachedResultReplayer); ok {
+			r.replayCachedResult(ctx, cached)
+		}
+		emitToolCacheHit(ctx, c.name, ToolCacheKindResult, hash)
+		return cached, nil
+	}
+	out, err := c.InvokableTool.InvokableRun(ctx, argsJSON, opts...)
+	if err == nil && toolResultOK(out) {
+		if serr := store.Set(ctx, key, out, c.ttl); serr != nil {
+			common.Warn("agent tool: write result cache", zap.String("tool", c.name), zap.Error(serr))
+		}
+	}
+	return out, err
+}
+
+// ErrToolCallInProgress is returned when an identical side-effecting
+// call of the same node is still running, e.g. on another worker.
+var ErrToolCallInProgress = errors.New("agent tool: an identical call is already in progress")
+
+// idempotentTool replays the recorded result of a side-effecting call
+// that already succeeded in the same task and node.
+type idempotentTool struct {
+	einotool.InvokableTool
+	name string
+}
+
+// InvokableRun implements einotool.InvokableTool. The call is claimed
+// with a short-lived pending marker before it runs; success replaces
+// the marker with the result, failure releases it so a retry can run
+// again. Without a store or a task on ctx, or when the store errors,
+// the call runs unguarded.
+func (g *idempotentTool) InvokableRun(ctx context.Context, argsJSON string, opts ...einotool.Option) (string, error) {
+	store := getToolResultStore()
+	key, hash, ok := toolIdempotencyKey(ctx, g.name, argsJSON)
+	if store == nil || !ok {
+		return g.InvokableTool.InvokableRun(ctx, argsJSON, opts...)
+	}
+	claimed, err := store.SetNX(ctx, key, toolIdempotencyPending, toolIdempotencyLease)
+	if err != nil {
+		common.Warn("agent tool: claim idempotency key", zap.String("tool", g.name), zap.Error(err))
+		return g.InvokableTool.InvokableRun(ctx, argsJSON, opts...)
+	}
+	if !claimed {
+		recorded, found, gerr := store.Get(ctx, key)
+		switch {
+		case gerr != nil:
+			common.Warn("agent tool: read idempotency key", zap.String("tool", g.name), zap.Error(gerr))
+		case found && recorded == toolIdempotencyPending:
+			err := fmt.Errorf("%w: %s", ErrToolCallInProgress, g.name)
+			return toolErrJSON(err), err
+		case found:
+			emitToolCacheHit(ctx, g.name, ToolCacheKindIdempotency, hash)
+			return recorded, nil
+		}
+		// The marker expired between the two reads; run unguarded
+		// rather than fail the call.
+		return g.InvokableTool.InvokableRun(ctx, argsJSON, opts...)
+	}
+	out, err := g.InvokableTool.InvokableRun(ctx, argsJSON, opts...)
+	if err == nil && toolResultOK(out) {
+		if serr := store.Set(ctx, key, out, toolIdempotencyTTL); serr != nil {
+			common.Warn("agent tool: record idempotent result", zap.String("tool", g.name), zap.Error(serr))
+		}
+	} else if derr := store.Delete(ctx, key); derr != nil {
+		common.Warn("agent tool: release idempotency key", zap.String("tool", g.name), zap.Error(derr))
+	}
+	return out, err
+}
+
+// toolIdempotencyKey scopes a call to the tenant, task (run when the
+// task id is empty), calling node and arguments. ok is false when ctx
+// carries no canvas run or the arguments are not JSON.
+func toolIdempotencyKey(ctx context.Context, name, argsJSON string) (key, hash string, ok bool) {
+	state, _, err := runtime.GetStateFromContext[*runtime.CanvasState](ctx)
+	if err != nil || state == nil {
+		return "", "", false
+	}
+	scope := state.TaskID
+	if scope == "" {
+		scope = state.RunID
+	}
+	if scope == "" {
+		return "", "", false
+	}
+	hash, ok = toolArgsHash(argsJSON)
+	if !ok {
+		return "", "", false
+	}
+	key = toolIdempotencyKeyPrefix + tenantIDFromContext(ctx) + ":" + scope + ":" +
+		runtime.ComponentIDFromContext(ctx) + ":" + name + ":" + hash
+	return key, hash, true
+}
+
+// toolArgsHash hashes the normalized form of a model-emitted argument
+// object: keys sorted, null members dropped and strings trimmed, so
+// calls that differ only in formatting share a key. Empty arguments
+// hash like {}.
+func toolArgsHash(argsJSON string) (string, bool) {
+	var v any = map[string]any{}
+	if strings.TrimSpace(argsJSON) != "" {
+		dec := json.NewDecoder(strings.NewReader(argsJSON))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return "", false
+		}
+	}
+	b, err := json.Marshal(normalizeToolArgs(v))
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), true
+}
+
+func normalizeToolArgs(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, child := range x {
+			if child != nil {
+				out[k] = normalizeToolArgs(child)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, child := range x {
+			out[i] = normalizeToolArgs(child)
+		}
+		return out
+	case string:
+		return strings.TrimSpace(x)
+	}
+	return v
+}
+
+// toolResultOK reports whether out is a successful result, i.e. not
+// an envelope carrying _ERROR.
+func toolResultOK(out string) bool {
+	var env struct {
+		Error string `json:"_ERROR"`
+	}
+	if err := json.Unmarshal([]byte(out), &env); err != nil {
+		return true
+	}
+	return env.Error == ""
+}
+
+func toolErrJSON(err error) string {
+	b, _ := json.Marshal(map[string]string{"_ERROR": err.Error()})
+	return string(b)
+}
+
+func emitToolCacheHit(ctx context.Context, name, kind, hash string) {
+	runtime.EmitEvent(ctx, ToolCacheHitEvent, ToolCacheHitData{
+		ComponentID: runtime.ComponentIDFromContext(ctx),
+		Tool:        name,
+		Kind:        kind,
+		ArgsHash:    hash,
+		CreatedAt:   float64(time.Now().UnixNano()) / 1e9,
+	})
+}
@@ -0,0 +1,317 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	einotool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/redis/go-redis/v9"
+
+	"ragflow/internal/agent/runtime"
+)
+
+// countingTool records its calls and answers with the configured
+// result and error.
+type countingTool struct {
+	calls  int
+	result string
+	err    error
+}
+
+func (c *countingTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "counting"}, nil
+}
+
+func (c *countingTool) InvokableRun(context.Context, string, ...einotool.Option) (string, error) {
+	c.calls++
+	return c.result, c.err
+}
+
+func newToolResultTestStore(t *testing.T) (*miniredis.Miniredis, *RedisToolResultStore) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisToolResultStoreWithClient(client)
+	SetToolResultStore(store)
+	t.Cleanup(func() { SetToolResultStore(nil) })
+	return mr, store
+}
+
+// toolCacheTestContext returns a run context for tenantID, task and
+// node cpnID that collects tool_cache_hit events into hits.
+func toolCacheTestContext(tenantID, taskID, cpnID string, hits *[]ToolCacheHitData) context.Context {
+	state := runtime.NewCanvasState("run", taskID)
+	state.Sys["tenant_id"] = tenantID
+	ctx := runtime.WithState(context.Background(), state)
+	ctx = runtime.WithComponentID(ctx, cpnID)
+	return runtime.WithEventSink(ctx, func(eventType string, data any) {
+		if eventType == ToolCacheHitEvent {
+			*hits = append(*hits, data.(ToolCacheHitData))
+		}
+	})
+}
+
+func TestRedisToolResultStore(t *testing.T) {
+	mr, store := newToolResultTestStore(t)
+	ctx := context.Background()
+
+	if _, ok, err := store.Get(ctx, "k"); ok || err != nil {
+		t.Fatalf("Get missing = %v, %v", ok, err)
+	}
+	if err := store.Set(ctx, "k", "v", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, ok, err := store.Get(ctx, "k"); v != "v" || !ok || err != nil {
+		t.Fatalf("Get = %q, %v, %v", v, ok, err)
+	}
+	if ok, err := store.SetNX(ctx, "k", "w", time.Minute); ok || err != nil {
+		t.Fatalf("SetNX existing = %v, %v", ok, err)
+	}
+	if err := store.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if ok, err := store.SetNX(ctx, "k", "w", time.Minute); !ok || err != nil {
+		t.Fatalf("SetNX = %v, %v", ok, err)
+	}
+	mr.FastForward(2 * time.Minute)
+	if _, ok, _ := store.Get(ctx, "k"); ok {
+		t.Error("entry outlived its TTL")
+	}
+
+	var empty *RedisToolResultStore
+	if _, _, err := empty.Get(ctx, "k"); !errors.Is(err, errToolResultStoreUninitialized) {
+		t.Errorf("nil store err = %v", err)
+	}
+}
+
+func TestCachedTool(t *testing.T) {
+	mr, _ := newToolResultTestStore(t)
+	inner := &countingTool{result: `{"results":["a"]}`}
+	tool := wrapToolResultCache("tavily", inner, time.Minute).(einotool.InvokableTool)
+
+	var hits []ToolCacheHitData
+	ctx := toolCacheTestContext("tenant-1", "task-1", "agent_0", &hits)
+	for _, args := range []string{
+		`{"query":"golang","max_results":5}`,
+		`{ "max_results": 5, "query": " golang ", "topic": null }`,
+	} {
+		out, err := tool.InvokableRun(ctx, args)
+		if err != nil || out != inner.result {
+			t.Fatalf("%s: out = %q, err = %v", args, out, err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Fatalf("calls = %d, want 1", inner.calls)
+	}
+	if len(hits) != 1 || hits[0].Tool != "tavily" || hits[0].Kind != ToolCacheKindResult || hits[0].ComponentID != "agent_0" {
+		t.Fatalf("hits = %+v", hits)
+	}
+
+	// Another session of the same tenant hits; another tenant does not.
+	var other []ToolCacheHitData
+	if _, err := tool.InvokableRun(toolCacheTestContext("tenant-1", "task-2", "agent_1", &other), `{"query":"golang","max_results":5}`); err != nil || inner.calls != 1 {
+		t.Fatalf("same tenant: calls = %d, err = %v", inner.calls, err)
+	}
+	if _, err := tool.InvokableRun(toolCacheTestContext("tenant-2", "task-1", "agent_0", &other), `{"query":"golang","max_results":5}`); err != nil || inner.calls != 2 {
+		t.Fatalf("other tenant: calls = %d, err = %v", inner.calls, err)
+	}
+	if _, err := tool.InvokableRun(ctx, `{"query":"golang","max_results":6}`); err != nil || inner.calls != 3 {
+		t.Fatalf("other args: calls = %d, err = %v", inner.calls, err)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	_, _ = tool.InvokableRun(ctx, `{"query":"golang","max_results":5}`)
+	if inner.calls != 4 {
+		t.Fatalf("after TTL: calls = %d, want 4", inner.calls)
+	}
+
+	// No tenant: never cached.
+	for i := 0; i < 2; i++ {
+		_, _ = tool.InvokableRun(context.Background(), `{"query":"golang"}`)
+	}
+	if inner.calls != 6 {
+		t.Fatalf("without tenant: calls = %d, want 6", inner.calls)
+	}
+}
+
+func TestCachedTool_SkipsFailures(t *testing.T) {
+	newToolResultTestStore(t)
+	var hits []ToolCacheHitData
+	ctx := toolCacheTestContext("tenant-1", "task-1", "agent_0", &hits)
+
+	for _, inner := range []*countingTool{
+		{result: `{"_ERROR":"rate limited"}`},
+		{result: `{}`, err: errors.New("timeout")},
+	} {
+		tool := wrapToolResultCache("wikipedia", inner, time.Minute).(einotool.InvokableTool)
+		for i := 0; i < 2; i++ {
+			_, _ = tool.InvokableRun(ctx, `{"query":"go"}`)
+		}
+		if inner.calls != 2 {
+			t.Errorf("%q: calls = %d, want 2", inner.result, inner.calls)
+		}
+	}
+	if len(hits) != 0 {
+		t.Errorf("hits = %+v", hits)
+	}
+}
+
+func TestCachedTool_ReplaysRetrievalChunks(t *testing.T) {
+	newToolResultTestStore(t)
+	var hits []ToolCacheHitData
+	ctx := toolCacheTestContext("tenant-1", "task-1", "agent_0", &hits)
+	cached, _ := json.Marshal(retrievalResult{Chunks: []chunkPayload{{ID: "c1", Content: "hello", DocumentID: "d1"}}})
+	key := toolCacheKeyPrefix + "tenant-1:retrieval:"
+	hash, _ := toolArgsHash(`{"query":"hello"}`)
+	if err := getToolResultStore().Set(ctx, key+hash, string(cached), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	tool := wrapToolResultCache("retrieval", NewRetrievalTool(), time.Minute).(einotool.InvokableTool)
+	out, err := tool.InvokableRun(ctx, `{"query":"hello"}`)
+	if err != nil || out != string(cached) || len(hits) != 1 {
+		t.Fatalf("out = %q, err = %v, hits = %d", out, err, len(hits))
+	}
+	state, _, _ := runtime.GetStateFromContext[*runtime.CanvasState](ctx)
+	chunks := state.GetRetrievalChunks()
+	if len(chunks) != 1 || chunks[0]["id"] != "c1" {
+		t.Errorf("chunks = %v", chunks)
+	}
+}
+
+func TestIdempotentTool(t *testing.T) {
+	_, store := newToolResultTestStore(t)
+	inner := &countingTool{result: `{"ok":true}`}
+	tool := wrapToolResultCache("email", inner, 0).(einotool.InvokableTool)
+	args := `{"to_addrs":["a@example.com"],"subject":"Hi","body":"x"}`
+
+	var hits []ToolCacheHitData
+	ctx := toolCacheTestContext("tenant-1", "task-1", "agent_0", &hits)
+	for i := 0; i < 2; i++ {
+		if out, err := tool.InvokableRun(ctx, args); err != nil || out != inner.result {
+			t.Fatalf("call %d: out = %q, err = %v", i, out, err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Fatalf("calls = %d, want 1", inner.calls)
+	}
+	if len(hits) != 1 || hits[0].Kind != ToolCacheKindIdempotency || hits[0].Tool != "email" {
+		t.Fatalf("hits = %+v", hits)
+	}
+
+	// A new task or another node sends again.
+	_, _ = tool.InvokableRun(toolCacheTestContext("tenant-1", "task-2", "agent_0", &hits), args)
+	_, _ = tool.InvokableRun(toolCacheTestContext("tenant-1", "task-1", "agent_1", &hits), args)
+	if inner.calls != 3 {
+		t.Fatalf("calls = %d, want 3", inner.calls)
+	}
+
+	// A failed send releases its key so the retry runs.
+	failing := &countingTool{result: `{"_ERROR":"smtp down"}`, err: errors.New("smtp down")}
+	ftool := wrapToolResultCache("email", failing, 0).(einotool.InvokableTool)
+	fctx := toolCacheTestContext("tenant-1", "task-3", "agent_0", &hits)
+	for i := 0; i < 2; i++ {
+		_, _ = ftool.InvokableRun(fctx, args)
+	}
+	if failing.calls != 2 {
+		t.Fatalf("failing calls = %d, want 2", failing.calls)
+	}
+
+	// An identical call still in flight is refused.
+	pctx := toolCacheTestContext("tenant-1", "task-4", "agent_0", &hits)
+	key, _, _ := toolIdempotencyKey(pctx, "email", args)
+	if err := store.Set(pctx, key, toolIdempotencyPending, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	out, err := tool.InvokableRun(pctx, args)
+	if !errors.Is(err, ErrToolCallInProgress) || !strings.Contains(out, "_ERROR") || inner.calls != 3 {
+		t.Fatalf("in progress: out = %q, err = %v, calls = %d", out, err, inner.calls)
+	}
+
+	// Outside a canvas run the call is unguarded.
+	for i := 0; i < 2; i++ {
+		_, _ = tool.InvokableRun(context.Background(), args)
+	}
+	if inner.calls != 5 {
+		t.Fatalf("without run: calls = %d, want 5", inner.calls)
+	}
+}
+
+func TestBuildAll_ToolCacheParam(t *testing.T) {
+	tools, err := BuildAll([]string{"Wikipedia", "email", "arxiv"}, map[string]map[string]any{
+		"wikipedia": {ToolCacheTTLParam: float64(600)},
+	})
+	if err != nil {
+		t.Fatalf("BuildAll: %v", err)
+	}
+	if c, ok := tools[0].(*cachedTool); !ok || c.ttl != 10*time.Minute || c.name != "wikipedia" {
+		t.Errorf("wikipedia = %T %+v", tools[0], tools[0])
+	}
+	if _, ok := tools[1].(*idempotentTool); !ok {
+		t.Errorf("email = %T", tools[1])
+	}
+	if _, ok := tools[2].(*ArxivTool); !ok {
+		t.Errorf("arxiv = %T", tools[2])
+	}
+	if info, err := tools[0].Info(context.Background()); err != nil || info.Name != "wikipedia" {
+		t.Errorf("Info = %+v, %v", info, err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		params map[string]any
+		want   string
+	}{
+		{"email", map[string]any{ToolCacheTTLParam: 60}, "does not support cache_ttl"},
+		{"tavily", map[string]any{ToolCacheTTLParam: 0}, "cache_ttl must be"},
+		{"tavily", map[string]any{ToolCacheTTLParam: "1h"}, "cache_ttl must be"},
+		{"tavily", map[string]any{ToolCacheTTLParam: 8 * 24 * 3600}, "cache_ttl must be"},
+	} {
+		_, err := BuildAll([]string{tc.name}, map[string]map[string]any{tc.name: tc.params})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s %v: err = %v", tc.name, tc.params, err)
+		}
+	}
+}
+
+func TestToolArgsHash(t *testing.T) {
+	a, ok := toolArgsHash(`{"b":[1,{"c":" x "}],"a":"y"}`)
+	b, _ := toolArgsHash(`{"a":"y","b":[1,{"c":"x","d":null}]}`)
+	if !ok || a != b {
+		t.Errorf("equivalent args hash differently: %s vs %s", a, b)
+	}
+	empty, _ := toolArgsHash("")
+	if braces, _ := toolArgsHash("{}"); empty != braces {
+		t.Error("empty args should hash like {}")
+	}
+	if _, ok := toolArgsHash("not json"); ok {
+		t.Error("invalid JSON hashed")
+	}
+}
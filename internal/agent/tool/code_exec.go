@@ -28,6 +28,7 @@ import (
 	"github.com/cloudwego/eino/schema"
 	"go.uber.org/zap"
 
+	"ragflow/internal/agent/runtime"
 	"ragflow/internal/common"
 )
 
@@ -145,16 +146,21 @@ func (c *CodeExecTool) InvokableRun(ctx context.Context, argumentsInJSON string,
 	// stub is in place, the call surfaces
 	// ErrCodeExecSandboxMissing; once a real client is
 	// installed via SetSandboxClient at boot, the script runs.
+	//
+	// Inside a canvas run the agent session id rides on ctx, so
+	// consecutive calls in one session share a sandbox.
 	client := GetSandboxClient()
 	req := SandboxRequest{
 		Lang:      lang,
 		Script:    script,
 		Arguments: args.Args,
 		Timeout:   args.Timeout,
+		SessionID: runtime.SessionIDFromContext(ctx),
 	}
 	common.Debug("CodeExec tool invoke",
 		zap.String("lang", req.Lang),
 		zap.Int("timeout", req.Timeout),
+		zap.String("session_id", req.SessionID),
 		zap.Int("arguments_keys", len(req.Arguments)),
 		zap.Int("script_len", len(req.Script)))
 	resp, err := client.ExecuteCode(ctx, req)
@@ -58,11 +58,15 @@ type SandboxClient interface {
 // the sandbox subsystem. Mirrors `agent.sandbox.client.execute_code`'s
 // input surface. Arguments and Timeout are optional — the bridge
 // applies defaults (no args, 30s timeout) when zero-valued.
+// SessionID, when set, asks the client to run the script in the
+// sandbox kept for that agent session so files and interpreter
+// state carry over from earlier calls.
 type SandboxRequest struct {
 	Lang      string         // "python" | "javascript"
 	Script    string         // the user's code
 	Arguments map[string]any // optional, passed to main(**args)
 	Timeout   int            // seconds, 0 = use provider default (30s)
+	SessionID string         // optional, agent session to reuse the sandbox of
 }
 
 // SandboxResponse is what the sandbox returns. Stdout / Stderr are
@@ -106,6 +110,23 @@ func GetSandboxClient() SandboxClient {
 	return sandboxClientImpl
 }
 
+// SandboxSessionCloser is implemented by clients that keep
+// per-session sandboxes (see SandboxRequest.SessionID).
+type SandboxSessionCloser interface {
+	CloseSession(ctx context.Context, sessionID string) error
+}
+
+// CloseSandboxSession tears down the sandbox kept for sessionID by
+// the registered client. It is a no-op when the client keeps no
+// sessions.
+func CloseSandboxSession(ctx context.Context, sessionID string) error {
+	closer, ok := GetSandboxClient().(SandboxSessionCloser)
+	if !ok || sessionID == "" {
+		return nil
+	}
+	return closer.CloseSession(ctx, sessionID)
+}
+
 type stubSandboxClient struct{}
 
 func (stubSandboxClient) ExecuteCode(_ context.Context, _ SandboxRequest) (*SandboxResponse, error) {
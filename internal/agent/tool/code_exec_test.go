@@ -22,6 +22,8 @@ import (
 	"errors"
 	"strings"
 	"testing"
+
+	"ragflow/internal/agent/runtime"
 )
 
 func TestCodeExec_StubsErrorWhenClientMissing(t *testing.T) {
@@ -325,6 +327,31 @@ func TestCodeExec_PassesArgumentsToSandbox(t *testing.T) {
 	}
 }
 
+// TestCodeExec_PassesSessionIDToSandbox verifies the agent session
+// id on ctx reaches the client so it can reuse the session's
+// sandbox. Mutates the global sandbox client like the tests above.
+func TestCodeExec_PassesSessionIDToSandbox(t *testing.T) {
+	var captured SandboxRequest
+	prev := GetSandboxClient()
+	SetSandboxClient(stubSandbox(func(_ context.Context, req SandboxRequest) (*SandboxResponse, error) {
+		captured = req
+		return &SandboxResponse{Returned: "ok", ExitCode: 0}, nil
+	}))
+	t.Cleanup(func() { SetSandboxClient(prev) })
+
+	ctx := runtime.WithSessionID(context.Background(), "session-1")
+	if _, err := NewCodeExecTool().InvokableRun(ctx, `{"language":"python","code":"def main(): return {}"}`); err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if captured.SessionID != "session-1" {
+		t.Errorf("SandboxRequest.SessionID = %q, want session-1", captured.SessionID)
+	}
+	// The stub keeps no sessions, so closing one is a no-op.
+	if err := CloseSandboxSession(context.Background(), "session-1"); err != nil {
+		t.Errorf("CloseSandboxSession: %v", err)
+	}
+}
+
 // stubSandbox adapts a function literal to the SandboxClient
 // interface so the timeout / arguments tests can capture the
 // request without depending on the default stub.
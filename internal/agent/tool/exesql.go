@@ -37,7 +37,13 @@
 //     metadata at all.
 //   - `database/sql` is stdlib, no extra runtime dependency beyond
 //     the per-driver package (`go-sql-driver/mysql`, `lib/pq`,
-//     `denisenkom/go-mssqldb`).
+//     `denisenkom/go-mssqldb`, `trino-go-client`, `glebarez/go-sqlite`,
+//     and the cgo drivers in exesql_duckdb.go / exesql_db2.go).
+//
+// SQLite and DuckDB run against a file rather than a server: the node
+// names an agent upload (`file_id`) or a dataset document
+// (`document_id`) and each call queries a read-only temporary copy of
+// it (see exesql_file.go).
 package tool
 
 import (
@@ -52,22 +58,28 @@ import (
 
 	// SQL drivers — registered via their init() side effects.
 	_ "github.com/denisenkom/go-mssqldb"
+	_ "github.com/glebarez/go-sqlite"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
+
+	"ragflow/internal/utility/sqlguard"
 )
 
 // ExeSQL-specific errors. ErrExeSQLDAOMissing is surfaced when
 // no DAO is registered. The current implementation routes
 // through `database/sql` (see openSQLDB below).
 // implementation in place, the error surface is:
-//   - ErrExeSQLNotSelect: SQL failed the SELECT-only safety filter.
+//   - ErrExeSQLNotSelect: SQL is not a read-only query. It wraps the
+//     *sqlguard.Violation; other guard rejections (multiple
+//     statements, denied functions, tables outside the allowlist) are
+//     returned as the bare violation.
 //   - ErrExeSQLNoCredentials: the tool has no db_type/host/etc. set
 //     (caller forgot to wire the connection params).
-//   - ErrExeSQLUnsupportedDB: db_type is one we have not ported yet
-//     (trino, IBM DB2).
+//   - ErrExeSQLUnsupportedDB: db_type needs a driver this binary was
+//     built without (IBM DB2 without `-tags db2`, DuckDB without cgo).
 var (
 	ErrExeSQLNotSelect = errors.New(
 		"ExeSQL: only SELECT statements are allowed; " +
@@ -77,7 +89,7 @@ var (
 		"ExeSQL: connection params not configured (db_type/host/port/database/username/password)",
 	)
 	ErrExeSQLUnsupportedDB = errors.New(
-		"ExeSQL: db_type not yet supported in Go port (trino, IBM DB2 are pending)",
+		"ExeSQL: db_type not supported by this build",
 	)
 )
 
@@ -94,13 +106,28 @@ const (
 // to the LLM at function-call time), matching the Python ExeSQLParam
 // fields. The LLM only sees `sql` and optional `database` in args.
 type exesqlConnParams struct {
-	DBType     string // mysql | postgres | mariadb | mssql | oceanbase
+	DBType     string // mysql | postgres | mariadb | mssql | oceanbase | trino | ibm db2 | sqlite | duckdb
 	Database   string
 	Username   string
 	Host       string
 	Port       int
 	Password   string
 	MaxRecords int
+	// TLS and TLSCACert (PEM) configure encrypted connections; Trino
+	// and DB2 honour them.
+	TLS       bool
+	TLSCACert string
+	// FileID (an agent upload) or DocumentID (a dataset document)
+	// names the database file for sqlite / duckdb.
+	FileID     string
+	DocumentID string
+	// AllowedTables / AllowedColumns, when set, restrict queries to
+	// those tables and columns (see sqlguard.Policy for the forms).
+	AllowedTables  []string
+	AllowedColumns []string
+
+	// filePath is the local copy of the database file, set per call.
+	filePath string
 }
 
 // ExeSQLConnParams is the public alias of exesqlConnParams for
@@ -111,7 +138,8 @@ type ExeSQLConnParams = exesqlConnParams
 
 // NewExeSQLConnParams decodes a canvas-node params map into an
 // ExeSQLConnParams. Returns an error if any required field
-// (db_type, host, database, username) is missing.
+// (db_type, host, database, username — or file_id / document_id for
+// sqlite and duckdb) is missing.
 //
 // Callers (e.g. the Universe A exesqlComponent wrapper) build the
 // params map from the canvas DSL; the tool-side decoding stays
@@ -139,12 +167,78 @@ func NewExeSQLConnParams(params map[string]any) (ExeSQLConnParams, error) {
 	if v, ok := params["max_records"].(int); ok {
 		conn.MaxRecords = v
 	}
+	conn.decodeExtraParams(params)
+	if conn.isFileDB() {
+		if conn.FileID == "" && conn.DocumentID == "" {
+			return conn, fmt.Errorf("ExeSQL: %s requires file_id or document_id", conn.DBType)
+		}
+		return conn, nil
+	}
 	if conn.DBType == "" || conn.Host == "" || conn.Username == "" || conn.Database == "" {
 		return conn, fmt.Errorf("ExeSQL: missing required connection params (db_type/host/database/username)")
 	}
 	return conn, nil
 }
 
+// decodeExtraParams reads the TLS and database-file params shared by
+// NewExeSQLConnParams and the Agent tool registry.
+func (c *exesqlConnParams) decodeExtraParams(params map[string]any) {
+	for _, key := range []string{"tls", "ssl"} {
+		switch v := params[key].(type) {
+		case bool:
+			c.TLS = c.TLS || v
+		case string:
+			c.TLS = c.TLS || v == "1" || strings.EqualFold(v, "true")
+		}
+	}
+	if v, ok := params["tls_ca_cert"].(string); ok {
+		c.TLSCACert = v
+	}
+	if v, ok := params["file_id"].(string); ok {
+		c.FileID = strings.TrimSpace(v)
+	}
+	if v, ok := params["document_id"].(string); ok {
+		c.DocumentID = strings.TrimSpace(v)
+	}
+	c.AllowedTables = exesqlNameList(params["allowed_tables"])
+	c.AllowedColumns = exesqlNameList(params["allowed_columns"])
+}
+
+// exesqlNameList accepts a list or a comma-separated string, the two
+// shapes the canvas form and hand-written DSL produce.
+func exesqlNameList(v any) []string {
+	var raw []string
+	switch v := v.(type) {
+	case string:
+		raw = strings.Split(v, ",")
+	case []string:
+		raw = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+	var out []string
+	for _, s := range raw {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// isFileDB reports whether the db_type queries a file instead of a
+// server.
+func (c exesqlConnParams) isFileDB() bool {
+	switch strings.ToLower(c.DBType) {
+	case "sqlite", "sqlite3", "duckdb":
+		return true
+	}
+	return false
+}
+
 // exesqlArgs is the JSON shape the model sends in. Matches the Python
 // ExeSQLParam ToolMeta (sql is required, database is optional).
 type exesqlArgs struct {
@@ -186,11 +280,13 @@ func defaultExeSQLDialer(driver, dsn string) (*sql.DB, error) {
 }
 
 // ExeSQLTool is the ExeSQL tool.
-// It validates SELECT-only at the parser level and executes the
-// statement against a user-configured external DB via `database/sql`.
+// It parses the statement with sqlguard, refuses anything but a single
+// read-only query, and executes the rewritten statement against a
+// user-configured external DB via `database/sql`.
 type ExeSQLTool struct {
 	conn   exesqlConnParams
 	dialer exesqlDialer
+	files  exesqlFileLoader
 }
 
 // NewExeSQLTool returns an ExeSQLTool wired to the given connection
@@ -203,6 +299,7 @@ func NewExeSQLTool(conn exesqlConnParams) *ExeSQLTool {
 	return &ExeSQLTool{
 		conn:   conn,
 		dialer: defaultExeSQLDialer,
+		files:  loadExeSQLFile,
 	}
 }
 
@@ -228,7 +325,7 @@ func (e *ExeSQLTool) Info(_ context.Context) (*schema.ToolInfo, error) {
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 			"sql": {
 				Type:     schema.String,
-				Desc:     "The SQL statement to execute. Must be a SELECT (read-only).",
+				Desc:     "The SQL statement to execute. Must be a single read-only SELECT query.",
 				Required: true,
 			},
 			"database": {
@@ -241,10 +338,11 @@ func (e *ExeSQLTool) Info(_ context.Context) (*schema.ToolInfo, error) {
 }
 
 // InvokableRun validates the SQL, opens a fresh connection scoped to
-// the tool's params, executes each semicolon-separated statement, and
-// returns the rows. Per-statement errors do not abort the node: they
-// are accumulated in the `Errors` slice of the response (the Python
-// tool does the same — `sql_res.append({"content": msg})`).
+// the tool's params, executes the statement, and returns the rows. A
+// rejected statement fails the call with the guard's violation, whose
+// text tells the model how to fix the query; an execution error is
+// returned as a content row instead (the Python tool does the same —
+// `sql_res.append({"content": msg})`).
 func (e *ExeSQLTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
 	if argumentsInJSON == "" {
 		return exesqlErrorResult(errors.New("exesql: empty arguments")), errors.New("exesql: empty arguments")
@@ -257,19 +355,33 @@ func (e *ExeSQLTool) InvokableRun(ctx context.Context, argumentsInJSON string, _
 	if strings.TrimSpace(args.SQL) == "" {
 		return exesqlErrorResult(errors.New("exesql: empty sql")), errors.New("exesql: empty sql")
 	}
-	if !isSelectStatement(args.SQL) {
-		return exesqlErrorResult(ErrExeSQLNotSelect), ErrExeSQLNotSelect
-	}
 
 	// Honor the per-call `database` override if the model supplied one;
-	// fall back to the tool's configured DB.
+	// fall back to the tool's configured DB. File databases have no
+	// database name to switch, so the override is ignored for them.
 	conn := e.conn
-	if args.Database != "" {
+	if args.Database != "" && !conn.isFileDB() {
 		conn.Database = args.Database
 	}
+	stmt, err := conn.guard(ctx, args.SQL)
+	if err != nil {
+		return exesqlErrorResult(err), err
+	}
 	if err := conn.check(); err != nil {
 		return exesqlErrorResult(err), err
 	}
+	if conn.isFileDB() {
+		data, err := e.files(ctx, conn)
+		if err != nil {
+			return exesqlErrorResult(err), err
+		}
+		path, cleanup, err := materializeExeSQLFile(conn.DBType, data)
+		if err != nil {
+			return exesqlErrorResult(err), err
+		}
+		defer cleanup()
+		conn.filePath = path
+	}
 
 	driver, dsn, err := exesqlDriverAndDSN(conn)
 	if err != nil {
@@ -295,46 +407,31 @@ func (e *ExeSQLTool) InvokableRun(ctx context.Context, argumentsInJSON string, _
 			fmt.Errorf("exesql: ping: %w", err)
 	}
 
-	res, err := exesqlExecute(ctx, db, args.SQL, conn.MaxRecords)
+	res, err := exesqlExecute(ctx, db, stmt, conn.MaxRecords)
 	if err != nil {
 		return exesqlErrorResult(err), err
 	}
 	return exesqlMarshalResult(res)
 }
 
-// exesqlExecute splits the SQL on `;` (Python parity) and runs each
-// statement independently. A failing statement is recorded as an
-// error entry but does not abort subsequent statements — this is
-// the same isolation guarantee the Python tool provides so that
-// earlier results survive a bad statement later in the batch.
-func exesqlExecute(ctx context.Context, db *sql.DB, sqlText string, maxRows int) (*exesqlResult, error) {
-	stmts := splitSQLStatements(sqlText)
+// exesqlExecute runs the guarded statement. An execution error is
+// recorded as a content row rather than returned, so the model sees
+// the database's message and can correct the query.
+func exesqlExecute(ctx context.Context, db *sql.DB, stmt string, maxRows int) (*exesqlResult, error) {
 	res := &exesqlResult{}
-	for _, stmt := range stmts {
-		stmt = stripChunkIDMarkers(stmt)
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
-			continue
-		}
-		cols, rows, err := exesqlQueryOne(ctx, db, stmt, maxRows)
-		if err != nil {
-			// Keep going; the Python tool appends {"content": msg}
-			// and continues with the next statement.
-			res.Rows = append(res.Rows, map[string]any{
-				"content": "SQL Execution Failed: " + stmt + "\n" + err.Error(),
-			})
-			continue
-		}
-		if len(res.Columns) == 0 && len(cols) > 0 {
-			res.Columns = cols
-		}
-		res.Rows = append(res.Rows, rows...)
+	cols, rows, err := exesqlQueryOne(ctx, db, stmt, maxRows)
+	if err != nil {
+		res.Rows = append(res.Rows, map[string]any{
+			"content": "SQL Execution Failed: " + stmt + "\n" + err.Error(),
+		})
+		return res, nil
 	}
+	res.Columns = cols
+	res.Rows = rows
 	if len(res.Rows) == 0 {
 		// Mirror the Python tool's "no record" sentinel so downstream
-		// nodes (VariableAggregator, Message) can match on it.
-		// Trigger on zero row data; keep any columns that the first
-		// statement populated so the schema survives.
+		// nodes (VariableAggregator, Message) can match on it. Keep
+		// the columns so the schema survives.
 		res.Rows = []map[string]any{{"content": "No record in the database!"}}
 	}
 	return res, nil
@@ -417,15 +514,6 @@ func isBadFloat(f float64) bool {
 	return false
 }
 
-// splitSQLStatements splits on `;`, ignoring semicolons inside string
-// literals and line/block comments. This matches what the Python
-// tool does with `sqls = sql.split(";")` — a naive split, but safe
-// enough for read-only statements the LLM is expected to produce.
-func splitSQLStatements(s string) []string {
-	cleaned := stripSQLStrings(stripSQLComments(s))
-	return strings.Split(cleaned, ";")
-}
-
 // stripChunkIDMarkers drops the [ID:123] tokens the RAGFlow chunker
 // sometimes embeds in SQL strings (`re.sub(r"\[ID:[0-9]+\]", "", ...)`
 // in the Python tool).
@@ -476,8 +564,30 @@ func exesqlDriverAndDSN(c exesqlConnParams) (driver, dsn string, err error) {
 		), nil
 	case "trino":
 		return "trino", trinoDSN(c), nil
-	case "ibm db2":
-		return "", "", fmt.Errorf("%w: ibm db2", ErrExeSQLUnsupportedDB)
+	case "ibm db2", "db2":
+		if !exesqlDB2Available {
+			return "", "", fmt.Errorf("%w: ibm db2 requires a build with `-tags db2` and the IBM CLI driver", ErrExeSQLUnsupportedDB)
+		}
+		dsn := fmt.Sprintf(
+			"HOSTNAME=%s;DATABASE=%s;PORT=%d;UID=%s;PWD=%s;PROTOCOL=TCPIP",
+			c.Host, c.Database, c.Port, c.Username, c.Password,
+		)
+		if c.TLS {
+			dsn += ";SECURITY=SSL"
+		}
+		return "go_ibm_db", dsn, nil
+	case "sqlite", "sqlite3":
+		// Read-only at both the VFS (mode=ro) and the connection
+		// (query_only) level, so even a statement that slips past the
+		// SELECT filter cannot write to the copy.
+		return "sqlite", "file:" + c.filePath + "?mode=ro&_pragma=query_only(1)", nil
+	case "duckdb":
+		if !exesqlDuckDBAvailable {
+			return "", "", fmt.Errorf("%w: duckdb requires a cgo build", ErrExeSQLUnsupportedDB)
+		}
+		// enable_external_access=false stops read_csv / read_parquet /
+		// ATTACH from reaching files or URLs beyond the uploaded one.
+		return "duckdb", c.filePath + "?access_mode=READ_ONLY&enable_external_access=false", nil
 	default:
 		return "", "", fmt.Errorf("ExeSQL: unknown db_type %q", c.DBType)
 	}
@@ -487,6 +597,12 @@ func exesqlDriverAndDSN(c exesqlConnParams) (driver, dsn string, err error) {
 // missing. Mirrors the Python ExeSQLParam.check() but stripped of
 // the UI-specific "empty value" messages.
 func (c exesqlConnParams) check() error {
+	if c.isFileDB() {
+		if c.FileID == "" && c.DocumentID == "" {
+			return fmt.Errorf("%w: %s requires file_id or document_id", ErrExeSQLNoCredentials, c.DBType)
+		}
+		return nil
+	}
 	if c.DBType == "" || c.Host == "" || c.Username == "" || c.Database == "" {
 		return ErrExeSQLNoCredentials
 	}
@@ -497,120 +613,33 @@ func (c exesqlConnParams) check() error {
 }
 
 // ---------------------------------------------------------------------------
-// SELECT-only safety validator
+// Read-only safety guard
 // ---------------------------------------------------------------------------
 
-// leadingKeywordRe matches the first non-comment, non-whitespace keyword
-// in a SQL statement. Comments (-- line, /* block */) and string literals
-// are stripped before the match.
-var leadingKeywordRe = regexp.MustCompile(`^[\s,;(]*([A-Za-z]+)`)
-
-// nonSelectKeywords lists DML/DDL/DCL verbs the parser rejects. These
-// are the only top-level forms we refuse; everything else (WITH ... SELECT,
-// SELECT INTO, SHOW, DESCRIBE, EXPLAIN) is allowed because they're
-// read-only.
-var nonSelectKeywords = map[string]struct{}{
-	"INSERT": {}, "UPDATE": {}, "DELETE": {}, "REPLACE": {},
-	"TRUNCATE": {},
-	"CREATE":   {}, "DROP": {}, "ALTER": {}, "RENAME": {},
-	"GRANT": {}, "REVOKE": {},
-	"LOCK": {}, "UNLOCK": {},
-	"CALL": {}, "EXEC": {}, "EXECUTE": {},
-	"COPY":   {},
-	"VACUUM": {}, "ANALYZE": {},
-	"SET": {}, "RESET": {},
-	"USE":        {},
-	"KILL":       {},
-	"LOAD":       {},
-	"CHECKPOINT": {},
-	"BEGIN":      {}, "COMMIT": {}, "ROLLBACK": {}, "START": {},
-	"SHUTDOWN": {},
-}
-
-// isSelectStatement returns true iff sql is a read-only statement. The
-// heuristic is intentionally narrow: strip line + block comments and
-// string literals, scan the first keyword, and reject if it's a
-// DML/DDL/DCL verb. SQL parsers in Go stdlib don't exist; this matches
-// the safety bar the Go shell needs.
-func isSelectStatement(sql string) bool {
-	cleaned := stripSQLComments(sql)
-	cleaned = stripSQLStrings(cleaned)
-	m := leadingKeywordRe.FindStringSubmatch(cleaned)
-	if len(m) < 2 {
-		return false
-	}
-	kw := strings.ToUpper(m[1])
-	if _, bad := nonSelectKeywords[kw]; bad {
-		return false
-	}
-	switch kw {
-	case "SELECT", "WITH", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "PRAGMA":
-		return true
-	}
-	// Unknown verb → conservative reject. The Python tool would forward
-	// this; the Go shell declines to execute without a recognized form.
-	return false
-}
-
-// stripSQLComments removes -- line comments and /* ... */ block comments.
-// We don't try to handle nested comments (MySQL/PG/SQLite differ) — this
-// is a best-effort guard for the SELECT validator, not a SQL parser.
-func stripSQLComments(s string) string {
-	var b strings.Builder
-	b.Grow(len(s))
-	i := 0
-	for i < len(s) {
-		if i+1 < len(s) && s[i] == '-' && s[i+1] == '-' {
-			for i < len(s) && s[i] != '\n' {
-				i++
-			}
-			continue
+// guard parses sqlText in the connection's dialect and returns the one
+// statement to execute, capped at MaxRecords rows and, where the
+// dialect allows, at the call's remaining time budget. Chunk ID
+// markers are stripped first so they cannot break the parse.
+func (c exesqlConnParams) guard(ctx context.Context, sqlText string) (string, error) {
+	timeout := exesqlDefaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); left < timeout {
+			timeout = left
 		}
-		if i+1 < len(s) && s[i] == '/' && s[i+1] == '*' {
-			i += 2
-			for i+1 < len(s) && !(s[i] == '*' && s[i+1] == '/') {
-				i++
-			}
-			i += 2
-			continue
-		}
-		b.WriteByte(s[i])
-		i++
 	}
-	return b.String()
-}
-
-// stripSQLStrings removes single- and double-quoted string literals and
-// replaces them with empty placeholders so that keywords inside string
-// contents don't confuse the validator.
-func stripSQLStrings(s string) string {
-	var b strings.Builder
-	b.Grow(len(s))
-	i := 0
-	inStr := byte(0)
-	for i < len(s) {
-		c := s[i]
-		if inStr != 0 {
-			if c == inStr {
-				if i+1 < len(s) && s[i+1] == inStr {
-					b.WriteByte(' ')
-					i += 2
-					continue
-				}
-				inStr = 0
-			}
-			b.WriteByte(' ')
-			i++
-			continue
-		}
-		if c == '\'' || c == '"' || c == '`' {
-			inStr = c
-			b.WriteByte(' ')
-			i++
-			continue
+	res, err := sqlguard.Check(stripChunkIDMarkers(sqlText), sqlguard.Policy{
+		Dialect:        sqlguard.DialectFor(c.DBType),
+		MaxRows:        c.MaxRecords,
+		Timeout:        timeout,
+		AllowedTables:  c.AllowedTables,
+		AllowedColumns: c.AllowedColumns,
+	})
+	if err != nil {
+		var v *sqlguard.Violation
+		if errors.As(err, &v) && v.Code == sqlguard.CodeNotReadOnly {
+			return "", fmt.Errorf("%w: %w", ErrExeSQLNotSelect, err)
 		}
-		b.WriteByte(c)
-		i++
+		return "", err
 	}
-	return b.String()
+	return res.SQL, nil
 }
@@ -0,0 +1,33 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// IBM DB2 support needs the go_ibm_db driver, which links against
+// IBM's CLI driver (clidriver) through cgo. Build with `-tags db2`
+// after installing clidriver (see the go_ibm_db README); builds
+// without the tag report DB2 as unsupported.
+
+//go:build cgo && db2
+
+package tool
+
+import (
+	// Registers the "go_ibm_db" database/sql driver.
+	_ "github.com/ibmdb/go_ibm_db"
+)
+
+// exesqlDB2Available reports whether this binary was built with DB2
+// support.
+const exesqlDB2Available = true
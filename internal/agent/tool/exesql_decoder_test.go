@@ -17,6 +17,7 @@
 package tool
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -80,6 +81,73 @@ func TestNewExeSQLConnParams_AllFields(t *testing.T) {
 	}
 }
 
+// TestNewExeSQLConnParams_FileDB: sqlite / duckdb need file_id or
+// document_id instead of host/username/database.
+func TestNewExeSQLConnParams_FileDB(t *testing.T) {
+	if _, err := NewExeSQLConnParams(map[string]any{"db_type": "sqlite"}); err == nil {
+		t.Error("expected error for sqlite without file_id / document_id")
+	}
+	conn, err := NewExeSQLConnParams(map[string]any{"db_type": "duckdb", "document_id": " doc-1 "})
+	if err != nil {
+		t.Fatalf("NewExeSQLConnParams: %v", err)
+	}
+	if conn.DocumentID != "doc-1" || conn.FileID != "" {
+		t.Errorf("DocumentID=%q FileID=%q", conn.DocumentID, conn.FileID)
+	}
+	if err := conn.check(); err != nil {
+		t.Errorf("check: %v", err)
+	}
+}
+
+// TestNewExeSQLConnParams_TLS: `tls` and its `ssl` alias accept bools
+// and the string forms the canvas editor sends.
+func TestNewExeSQLConnParams_TLS(t *testing.T) {
+	base := map[string]any{"db_type": "trino", "host": "h", "database": "c", "username": "u"}
+	for _, tc := range []struct {
+		key  string
+		val  any
+		want bool
+	}{
+		{"tls", true, true},
+		{"tls", "true", true},
+		{"ssl", "1", true},
+		{"tls", false, false},
+		{"tls", "no", false},
+	} {
+		params := map[string]any{tc.key: tc.val, "tls_ca_cert": "PEM"}
+		for k, v := range base {
+			params[k] = v
+		}
+		conn, err := NewExeSQLConnParams(params)
+		if err != nil {
+			t.Fatalf("NewExeSQLConnParams: %v", err)
+		}
+		if conn.TLS != tc.want {
+			t.Errorf("%s=%v: TLS=%v, want %v", tc.key, tc.val, conn.TLS, tc.want)
+		}
+		if conn.TLSCACert != "PEM" {
+			t.Errorf("TLSCACert=%q, want PEM", conn.TLSCACert)
+		}
+	}
+}
+
+func TestNewExeSQLConnParams_Allowlists(t *testing.T) {
+	conn, err := NewExeSQLConnParams(map[string]any{
+		"db_type": "mysql", "host": "h", "database": "d", "username": "u",
+		"allowed_tables":  []any{"orders", " customers ", ""},
+		"allowed_columns": "orders.*, customers.name",
+	})
+	if err != nil {
+		t.Fatalf("NewExeSQLConnParams: %v", err)
+	}
+	if got := strings.Join(conn.AllowedTables, "|"); got != "orders|customers" {
+		t.Errorf("AllowedTables = %q", conn.AllowedTables)
+	}
+	if got := strings.Join(conn.AllowedColumns, "|"); got != "orders.*|customers.name" {
+		t.Errorf("AllowedColumns = %q", conn.AllowedColumns)
+	}
+}
+
 // TestExeSQLConnParams_Alias: the public type alias ExeSQLConnParams
 // refers to the same underlying type as the lowercase exesqlConnParams.
 // The factory returns the public name, and existing in-package
@@ -0,0 +1,128 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package tool
+
+// SQLite and DuckDB database files for ExeSQL.
+//
+// The node never points at a host path: it names an upload in the
+// tenant's `<tenant>-downloads` bucket (file_id, what the agent
+// upload endpoint returns as `id`) or a dataset document
+// (document_id, gated by KnowledgebaseDAO.Accessible). The blob is
+// copied to a private temp file for the duration of one call and
+// opened read-only, so a query can never modify the stored original
+// and concurrent calls never share a handle.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"ragflow/internal/dao"
+	"ragflow/internal/storage"
+)
+
+// exesqlMaxFileSize caps the database file copied per call.
+const exesqlMaxFileSize = 512 << 20
+
+// ErrExeSQLFileNotFound is returned when file_id / document_id does
+// not resolve to a file the calling tenant can read.
+var ErrExeSQLFileNotFound = errors.New("ExeSQL: database file not found or not accessible")
+
+// exesqlFileLoader returns the bytes of the database file named by
+// conn.FileID / conn.DocumentID. Tests inject one via
+// WithExeSQLFileLoader.
+type exesqlFileLoader func(ctx context.Context, conn exesqlConnParams) ([]byte, error)
+
+// WithExeSQLFileLoader swaps the database-file source. Returns the
+// tool for chaining. Used by tests; production code should leave it
+// alone.
+func (e *ExeSQLTool) WithExeSQLFileLoader(l exesqlFileLoader) *ExeSQLTool {
+	if l != nil {
+		e.files = l
+	}
+	return e
+}
+
+// loadExeSQLFile reads the database file from object storage on
+// behalf of the tenant running the canvas.
+func loadExeSQLFile(ctx context.Context, conn exesqlConnParams) ([]byte, error) {
+	tenantID := tenantIDFromContext(ctx)
+	if tenantID == "" {
+		return nil, fmt.Errorf("%w: no tenant in canvas state", ErrExeSQLFileNotFound)
+	}
+	storageImpl := storage.GetStorageFactory().GetStorage()
+	if storageImpl == nil {
+		return nil, errors.New("ExeSQL: storage not initialized")
+	}
+
+	var bucket, location string
+	switch {
+	case conn.FileID != "":
+		if strings.ContainsAny(conn.FileID, `/\`) || strings.Contains(conn.FileID, "..") {
+			return nil, fmt.Errorf("%w: invalid file_id", ErrExeSQLFileNotFound)
+		}
+		bucket, location = fmt.Sprintf("%s-downloads", tenantID), conn.FileID
+	default:
+		doc, err := dao.NewDocumentDAO().GetByID(conn.DocumentID)
+		if err != nil || doc == nil || doc.Location == nil || *doc.Location == "" {
+			return nil, fmt.Errorf("%w: document %s", ErrExeSQLFileNotFound, conn.DocumentID)
+		}
+		if !dao.NewKnowledgebaseDAO().Accessible(doc.KbID, tenantID) {
+			return nil, fmt.Errorf("%w: document %s", ErrExeSQLFileNotFound, conn.DocumentID)
+		}
+		bucket, location = doc.KbID, *doc.Location
+	}
+
+	data, err := storageImpl.Get(bucket, location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExeSQLFileNotFound, err)
+	}
+	return data, nil
+}
+
+// materializeExeSQLFile writes data to a private temp file and returns
+// its path plus a cleanup func that removes it (and, for DuckDB, the
+// .wal sidecar a read-only open may still probe for).
+func materializeExeSQLFile(dbType string, data []byte) (string, func(), error) {
+	if len(data) == 0 {
+		return "", func() {}, fmt.Errorf("%w: file is empty", ErrExeSQLFileNotFound)
+	}
+	if len(data) > exesqlMaxFileSize {
+		return "", func() {}, fmt.Errorf("ExeSQL: database file exceeds %d MB", exesqlMaxFileSize>>20)
+	}
+	f, err := os.CreateTemp("", "ragflow-exesql-*."+strings.ToLower(dbType))
+	if err != nil {
+		return "", func() {}, fmt.Errorf("ExeSQL: create temp file: %w", err)
+	}
+	path := f.Name()
+	cleanup := func() {
+		_ = os.Remove(path)
+		_ = os.Remove(path + ".wal")
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		cleanup()
+		return "", func() {}, fmt.Errorf("ExeSQL: write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("ExeSQL: write temp file: %w", err)
+	}
+	return path, cleanup, nil
+}
@@ -0,0 +1,177 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildSQLiteFile creates a small SQLite database and returns its bytes.
+func buildSQLiteFile(t *testing.T) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "src.sqlite")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE t (id INTEGER, name TEXT)",
+		"INSERT INTO t VALUES (1, 'alice'), (2, 'bob'), (3, 'carol')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return data
+}
+
+func staticFileLoader(data []byte) exesqlFileLoader {
+	return func(context.Context, exesqlConnParams) ([]byte, error) { return data, nil }
+}
+
+func TestExeSQL_SQLiteFile_SelectWithRowLimit(t *testing.T) {
+	data := buildSQLiteFile(t)
+	e := NewExeSQLTool(exesqlConnParams{DBType: "sqlite", FileID: "f1", MaxRecords: 2}).
+		WithExeSQLFileLoader(staticFileLoader(data))
+
+	out, err := e.InvokableRun(context.Background(), `{"sql":"SELECT id, name FROM t ORDER BY id"}`)
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	var got exesqlResult
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal: %v\nout=%s", err, out)
+	}
+	if len(got.Rows) != 2 {
+		t.Fatalf("Rows = %d, want 2 (max_records)", len(got.Rows))
+	}
+	if got.Rows[0]["name"] != "alice" {
+		t.Errorf("Rows[0][name] = %v, want alice", got.Rows[0]["name"])
+	}
+}
+
+// TestExeSQL_SQLiteFile_ReadOnly: even a write that bypasses the
+// SELECT filter via a CTE cannot modify the copy.
+func TestExeSQL_SQLiteFile_ReadOnly(t *testing.T) {
+	data := buildSQLiteFile(t)
+	conn := exesqlConnParams{DBType: "sqlite", FileID: "f1", filePath: filepath.Join(t.TempDir(), "db.sqlite")}
+	if err := os.WriteFile(conn.filePath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	driver, dsn, err := exesqlDriverAndDSN(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("DELETE FROM t"); err == nil {
+		t.Fatal("expected write to be rejected on a read-only connection")
+	}
+}
+
+func TestExeSQL_FileDB_LoaderError(t *testing.T) {
+	e := NewExeSQLTool(exesqlConnParams{DBType: "sqlite", DocumentID: "d1"}).
+		WithExeSQLFileLoader(func(context.Context, exesqlConnParams) ([]byte, error) {
+			return nil, ErrExeSQLFileNotFound
+		})
+	_, err := e.InvokableRun(context.Background(), `{"sql":"SELECT 1"}`)
+	if !errors.Is(err, ErrExeSQLFileNotFound) {
+		t.Fatalf("err = %v, want ErrExeSQLFileNotFound", err)
+	}
+}
+
+func TestExeSQL_FileDB_TempFileRemoved(t *testing.T) {
+	path, cleanup, err := materializeExeSQLFile("sqlite", []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("temp file missing: %v", err)
+	}
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("temp file not removed: %v", err)
+	}
+	if _, _, err := materializeExeSQLFile("sqlite", nil); err == nil {
+		t.Error("expected error for empty file")
+	}
+}
+
+// TestExeSQL_FileDB_NoTenant: the storage-backed loader refuses to run
+// outside a canvas, where no tenant can scope the lookup.
+func TestExeSQL_FileDB_NoTenant(t *testing.T) {
+	_, err := loadExeSQLFile(context.Background(), exesqlConnParams{DBType: "sqlite", FileID: "f1"})
+	if !errors.Is(err, ErrExeSQLFileNotFound) {
+		t.Fatalf("err = %v, want ErrExeSQLFileNotFound", err)
+	}
+}
+
+func TestExeSQL_DuckDBFile_Select(t *testing.T) {
+	if !exesqlDuckDBAvailable {
+		t.Skip("duckdb requires cgo")
+	}
+	path := filepath.Join(t.TempDir(), "src.duckdb")
+	db, err := sql.Open("duckdb", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE t (id INTEGER, name VARCHAR)",
+		"INSERT INTO t VALUES (1, 'alice'), (2, 'bob')",
+		"CHECKPOINT",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	db.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExeSQLTool(exesqlConnParams{DBType: "duckdb", FileID: "f1"}).
+		WithExeSQLFileLoader(staticFileLoader(data))
+	out, err := e.InvokableRun(context.Background(), `{"sql":"SELECT name FROM t ORDER BY id"}`)
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	var got exesqlResult
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal: %v\nout=%s", err, out)
+	}
+	if len(got.Rows) != 2 || got.Rows[1]["name"] != "bob" {
+		t.Errorf("Rows = %v", got.Rows)
+	}
+}
@@ -30,6 +30,8 @@ import (
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
+
+	"ragflow/internal/utility/sqlguard"
 )
 
 // testConn is a fully-populated connection params struct used by
@@ -60,6 +62,18 @@ func sqlmockDialer(t *testing.T) (exesqlDialer, sqlmock.Sqlmock, func()) {
 	return d, mock, func() { _ = db.Close() }
 }
 
+// guardedSQL is the statement ExeSQL sends for sqlText under conn:
+// the sqlguard rewrite with the row cap and default timeout applied.
+// sqlmock expectations must use it, since matching is exact.
+func guardedSQL(t *testing.T, conn exesqlConnParams, sqlText string) string {
+	t.Helper()
+	out, err := conn.guard(context.Background(), sqlText)
+	if err != nil {
+		t.Fatalf("guard(%q): %v", sqlText, err)
+	}
+	return out
+}
+
 func TestExeSQL_NoCredentials(t *testing.T) {
 	t.Parallel()
 
@@ -95,6 +109,10 @@ func TestExeSQL_RejectsNonSelect(t *testing.T) {
 		{"kill", `KILL 1234`},
 		{"use", `USE rag_flow`},
 		{"uppercase drop", `DROP DATABASE rag_flow`},
+		{"into outfile", `SELECT * FROM t INTO OUTFILE '/tmp/x'`},
+		{"writing cte", `WITH d AS (DELETE FROM foo) SELECT 1`},
+		{"row lock", `SELECT * FROM foo FOR UPDATE`},
+		{"executable comment", `SELECT 1 /*!50000 , SLEEP(10) */`},
 	}
 
 	for _, c := range cases {
@@ -118,11 +136,10 @@ func TestExeSQL_AllowsSelect(t *testing.T) {
 		`select * from t`,
 		`  SELECT * FROM t WHERE a = 1`,
 		`WITH cte AS (SELECT 1) SELECT * FROM cte`,
-		`SELECT * FROM t INTO OUTFILE '/tmp/x'`,
+		`SELECT 1;`,
 		`SHOW TABLES`,
 		`DESCRIBE t`,
 		`EXPLAIN SELECT * FROM t`,
-		`PRAGMA table_info(t)`,
 		// Keywords inside string literals should be ignored.
 		`SELECT 'DROP TABLE x' AS note FROM dual`,
 		// Line comment with DROP keyword.
@@ -133,40 +150,85 @@ func TestExeSQL_AllowsSelect(t *testing.T) {
 	for _, sql := range cases {
 		t.Run(sql, func(t *testing.T) {
 			t.Parallel()
-			// Configure a real-looking query so the validator passes
-			// and the tool reaches the dialer; sqlmock will return no
-			// rows, and we accept either "no record" sentinel or a
-			// sqlmock-driven success.
 			dialer, mock, cleanup := sqlmockDialer(t)
 			defer cleanup()
 			mock.ExpectPing()
-			mock.ExpectQuery("SELECT 1").WillReturnRows(
+			mock.ExpectQuery(guardedSQL(t, testConn(), sql)).WillReturnRows(
 				sqlmock.NewRows([]string{"1"}),
 			)
 			e := NewExeSQLTool(testConn()).WithExeSQLDialer(dialer)
-			_, err := e.InvokableRun(context.Background(),
-				`{"sql":`+jsonString(sql)+`}`)
-			// Two acceptable outcomes:
-			//   1. SQL is the literal `SELECT 1` and matches the
-			//      mock expectation -> success.
-			//   2. SQL is one of the comment/wrapper variants; the
-			//      validator passes but the comment-stripped SQL
-			//      differs from the staged expectation -> sqlmock
-			//      returns an "unexpected call" error, which is
-			//      acceptable because what we're testing here is the
-			//      SELECT-only filter, not execution.
-			if err != nil {
-				if errors.Is(err, ErrExeSQLNotSelect) {
-					t.Fatalf("validator rejected a permitted SELECT: %v", err)
-				}
-				// sqlmock mismatch is the expected failure for
-				// comment-stripped variants — fine, the validator
-				// itself passed.
+			if _, err := e.InvokableRun(context.Background(),
+				`{"sql":`+jsonString(sql)+`}`); err != nil {
+				t.Fatalf("InvokableRun: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("sqlmock: %v", err)
 			}
 		})
 	}
 }
 
+func TestExeSQL_GuardRewritesStatement(t *testing.T) {
+	t.Parallel()
+
+	conn := testConn()
+	tests := []struct {
+		sql, want string
+	}{
+		{"SELECT a FROM t", "SELECT /*+ MAX_EXECUTION_TIME(60000) */ a FROM t LIMIT 100"},
+		{"SELECT a FROM t LIMIT 5000 [ID:12]", "SELECT /*+ MAX_EXECUTION_TIME(60000) */ a FROM t LIMIT 100"},
+		{"SELECT a FROM t LIMIT 5;", "SELECT /*+ MAX_EXECUTION_TIME(60000) */ a FROM t LIMIT 5"},
+	}
+	for _, tt := range tests {
+		if got := guardedSQL(t, conn, tt.sql); got != tt.want {
+			t.Errorf("guard(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+
+	conn.DBType = "mssql"
+	if got, want := guardedSQL(t, conn, "SELECT a FROM t"), "SELECT TOP (100) a FROM t"; got != want {
+		t.Errorf("mssql guard = %q, want %q", got, want)
+	}
+}
+
+func TestExeSQL_GuardRejections(t *testing.T) {
+	t.Parallel()
+
+	conn := testConn()
+	conn.AllowedTables = []string{"orders"}
+	conn.AllowedColumns = []string{"orders.id", "orders.total"}
+	tests := []struct {
+		sql  string
+		code sqlguard.Code
+	}{
+		{"SELECT 1; SELECT 2", sqlguard.CodeMultipleStatements},
+		{"SELECT load_file('/etc/passwd')", sqlguard.CodeFunction},
+		{"SELECT * FROM users", sqlguard.CodeTable},
+		{"SELECT email FROM orders", sqlguard.CodeColumn},
+		{"SELEC id FROM orders", sqlguard.CodeNotReadOnly},
+		{"SELECT id FROM orders WHERE", sqlguard.CodeSyntax},
+	}
+	for _, tt := range tests {
+		e := NewExeSQLTool(conn).WithExeSQLDialer(func(_, _ string) (*sql.DB, error) {
+			t.Fatalf("dialer called for rejected SQL %q", tt.sql)
+			return nil, nil
+		})
+		out, err := e.InvokableRun(context.Background(), `{"sql":`+jsonString(tt.sql)+`}`)
+		var v *sqlguard.Violation
+		if !errors.As(err, &v) || v.Code != tt.code {
+			t.Errorf("InvokableRun(%q) err = %v, want violation %s", tt.sql, err, tt.code)
+			continue
+		}
+		var got exesqlResult
+		if err := json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("unmarshal: %v\nout=%s", err, out)
+		}
+		if !strings.Contains(got.Error, string(tt.code)) {
+			t.Errorf("_ERROR = %q, want it to name %s for the model", got.Error, tt.code)
+		}
+	}
+}
+
 func TestExeSQL_RejectsEmptySQL(t *testing.T) {
 	t.Parallel()
 
@@ -208,7 +270,7 @@ func TestExeSQL_ExecuteSelect_ReturnsRows(t *testing.T) {
 	// ExeSQL runs the LLM-supplied SQL verbatim via QueryContext; it
 	// does NOT do database/sql arg binding. Stage the expectation
 	// with the literal value, not "?" + WithArgs.
-	mock.ExpectQuery("SELECT id, name FROM t WHERE id = 7").
+	mock.ExpectQuery(guardedSQL(t, testConn(), "SELECT id, name FROM t WHERE id = 7")).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
 			AddRow(7, "alice").
 			AddRow(8, "bob"))
@@ -240,7 +302,7 @@ func TestExeSQL_ExecuteSelect_NoRowsReturnsSentinel(t *testing.T) {
 	dialer, mock, cleanup := sqlmockDialer(t)
 	defer cleanup()
 	mock.ExpectPing()
-	mock.ExpectQuery("SELECT 1").
+	mock.ExpectQuery(guardedSQL(t, testConn(), "SELECT 1")).
 		WillReturnRows(sqlmock.NewRows([]string{"x"}))
 
 	e := NewExeSQLTool(testConn()).WithExeSQLDialer(dialer)
@@ -260,40 +322,32 @@ func TestExeSQL_ExecuteSelect_NoRowsReturnsSentinel(t *testing.T) {
 	}
 }
 
-func TestExeSQL_ExecuteSelect_PerStatementErrorIsolated(t *testing.T) {
+func TestExeSQL_ExecuteSelect_ExecutionErrorReturnedAsContent(t *testing.T) {
 	t.Parallel()
 
 	dialer, mock, cleanup := sqlmockDialer(t)
 	defer cleanup()
 	mock.ExpectPing()
-	// Two statements, second one errors. Python tool keeps the first
-	// result and records the second as a content entry; the Go port
-	// matches.
-	mock.ExpectQuery("SELECT 1").
-		WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
-	mock.ExpectQuery("SELECT * FROM bogus").
+	// Python parity: the database's error comes back as a content row
+	// so the model can read it, not as a failed tool call.
+	mock.ExpectQuery(guardedSQL(t, testConn(), "SELECT * FROM bogus")).
 		WillReturnError(errors.New("syntax error at or near BOGUS"))
 
 	e := NewExeSQLTool(testConn()).WithExeSQLDialer(dialer)
 	out, err := e.InvokableRun(context.Background(),
-		`{"sql":"SELECT 1; SELECT * FROM bogus"}`)
+		`{"sql":"SELECT * FROM bogus"}`)
 	if err != nil {
-		t.Fatalf("InvokableRun should not abort on a per-statement error: %v", err)
+		t.Fatalf("InvokableRun should not fail on an execution error: %v", err)
 	}
 	var got exesqlResult
 	if err := json.Unmarshal([]byte(out), &got); err != nil {
 		t.Fatalf("unmarshal: %v\nout=%s", err, out)
 	}
-	if len(got.Rows) != 2 {
-		t.Fatalf("Rows = %d, want 2 (one success + one error entry)", len(got.Rows))
-	}
-	// SQL mock returns integers as int64; the JSON round-trip through
-	// map[string]any promotes them to float64. Compare as float64.
-	if x, _ := got.Rows[0]["x"].(float64); x != 1 {
-		t.Errorf("Rows[0][x] = %v (%T), want 1 (the surviving first result)", got.Rows[0]["x"], got.Rows[0]["x"])
+	if len(got.Rows) != 1 {
+		t.Fatalf("Rows = %d, want 1 error entry", len(got.Rows))
 	}
-	if c, _ := got.Rows[1]["content"].(string); !strings.Contains(c, "syntax error") {
-		t.Errorf("Rows[1].content = %q, want to surface the second-statement error", c)
+	if c, _ := got.Rows[0]["content"].(string); !strings.Contains(c, "syntax error") {
+		t.Errorf("Rows[0].content = %q, want to surface the execution error", c)
 	}
 }
 
@@ -303,7 +357,7 @@ func TestExeSQL_ExecuteSelect_NormalizesTimeAndBytes(t *testing.T) {
 	dialer, mock, cleanup := sqlmockDialer(t)
 	defer cleanup()
 	mock.ExpectPing()
-	mock.ExpectQuery("SELECT ts, blob_col FROM t").
+	mock.ExpectQuery(guardedSQL(t, testConn(), "SELECT ts, blob_col FROM t")).
 		WillReturnRows(sqlmock.NewRows([]string{"ts", "blob_col"}).
 			AddRow("2024-06-12T03:04:05Z", []byte("hello")))
 
@@ -333,17 +387,52 @@ func TestExeSQL_ExecuteSelect_NormalizesTimeAndBytes(t *testing.T) {
 func TestExeSQL_UnsupportedDB(t *testing.T) {
 	t.Parallel()
 
-	e := NewExeSQLTool(exesqlConnParams{
+	_, _, err := exesqlDriverAndDSN(exesqlConnParams{
 		DBType: "trino",
 		Host:   "h", Port: 8080, Database: "catalog",
 		Username: "u", Password: "p",
 	})
-	_, err := e.InvokableRun(context.Background(), `{"sql":"SELECT 1"}`)
-	if err == nil {
-		t.Fatal("expected non-nil error for trino without registered driver")
+	if err != nil {
+		t.Fatalf("trino: err = %v, want nil after trino wiring", err)
+	}
+
+	_, _, err = exesqlDriverAndDSN(exesqlConnParams{
+		DBType: "ibm db2",
+		Host:   "h", Port: 50000, Database: "d",
+		Username: "u", Password: "p", TLS: true,
+	})
+	if exesqlDB2Available {
+		if err != nil {
+			t.Fatalf("ibm db2: %v", err)
+		}
+	} else if !errors.Is(err, ErrExeSQLUnsupportedDB) {
+		t.Fatalf("ibm db2: err = %v, want ErrExeSQLUnsupportedDB without -tags db2", err)
+	}
+}
+
+func TestExeSQL_DSN_FileDBs(t *testing.T) {
+	t.Parallel()
+
+	driver, dsn, err := exesqlDriverAndDSN(exesqlConnParams{DBType: "sqlite", FileID: "f", filePath: "/tmp/x.sqlite"})
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	if driver != "sqlite" || dsn != "file:/tmp/x.sqlite?mode=ro&_pragma=query_only(1)" {
+		t.Errorf("sqlite = (%q, %q)", driver, dsn)
+	}
+
+	driver, dsn, err = exesqlDriverAndDSN(exesqlConnParams{DBType: "duckdb", FileID: "f", filePath: "/tmp/x.duckdb"})
+	if !exesqlDuckDBAvailable {
+		if !errors.Is(err, ErrExeSQLUnsupportedDB) {
+			t.Fatalf("duckdb without cgo: err = %v, want ErrExeSQLUnsupportedDB", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("duckdb: %v", err)
 	}
-	if errors.Is(err, ErrExeSQLUnsupportedDB) {
-		t.Fatalf("err = %v, did not want ErrExeSQLUnsupportedDB after trino wiring", err)
+	if driver != "duckdb" || dsn != "/tmp/x.duckdb?access_mode=READ_ONLY&enable_external_access=false" {
+		t.Errorf("duckdb = (%q, %q)", driver, dsn)
 	}
 }
 
@@ -489,7 +578,7 @@ func TestExeSQL_RealReactAgent_ExecutesTool(t *testing.T) {
 	dialer, mock, cleanup := sqlmockDialer(t)
 	defer cleanup()
 	mock.ExpectPing()
-	mock.ExpectQuery("SELECT 42").WillReturnRows(
+	mock.ExpectQuery(guardedSQL(t, testConn(), "SELECT 42")).WillReturnRows(
 		sqlmock.NewRows([]string{"x"}).AddRow(42),
 	)
 	realTool := NewExeSQLTool(testConn()).WithExeSQLDialer(dialer)
@@ -560,7 +649,7 @@ func TestExeSQL_RealReactAgent_ToolErrorIsolated(t *testing.T) {
 	dialer, mock, cleanup := sqlmockDialer(t)
 	defer cleanup()
 	mock.ExpectPing()
-	mock.ExpectQuery("SELECT * FROM bogus").
+	mock.ExpectQuery(guardedSQL(t, testConn(), "SELECT * FROM bogus")).
 		WillReturnError(errors.New("syntax error at or near BOGUS"))
 
 	realTool := NewExeSQLTool(testConn()).WithExeSQLDialer(dialer)
@@ -8,36 +8,24 @@
 //      http://www.apache.org/licenses/LICENSE-2.0
 //
 
-// exesql_trino_stub.go — minimal implementations of the Trino DSN
-// helpers referenced by exesql_trino_test.go. The real
-// implementation lands when the trino driver integration moves
-// from "registered via self-register" to "explicit DSN
-// construction" — a follow-up tracked under the design doc
-// Future Work.
+// exesql_trino.go — Trino connection support for ExeSQL.
 //
-// What this file provides:
+// The trino-go-client driver (registered as "trino") takes an HTTP(S)
+// URL DSN:
 //
-//   - trinoDSN(exesqlConnParams) string
-//     Constructs a Trino DSN with the format
-//     `http(s)://<user>[:<password>]@<host>:<port>?catalog=<cat>&schema=<sch>`
-//     (matches what trino-go-client v0.333.0 expects).
+//	http(s)://<user>[:<password>]@<host>:<port>?catalog=<cat>&schema=<sch>
 //
-//   - splitTrinoCatalogSchema(db string) (cat, sch string)
-//     Splits a `<db>` argument into a Trino `(catalog, schema)` pair.
-//     Python `exesql.py:167-176` five quirks are honoured:
-//     1. empty password over TLS is preferred (do not embed it)
-//     2. TRINO_USE_TLS=1 flips the scheme to https
-//     3. default port is 8080 when zero
-//     4. empty username → "ragflow"
-//     5. catalog[.schema] parsing: first dot/slash is the
-//     catalog separator, remainder (if any) is the schema
+// Python `exesql.py:167-176` quirks are honoured:
+//  1. the password is only embedded over TLS (the driver refuses basic
+//     auth over plain HTTP, and it must never travel in cleartext)
+//  2. TLS is on when the node sets `tls` or TRINO_USE_TLS is set
+//  3. default port is 8080 when zero
+//  4. empty username → "ragflow"
+//  5. catalog[.schema] parsing: first dot/slash is the catalog
+//     separator, remainder (if any) is the schema
 //
-// These implementations are intentionally minimal — they satisfy
-// the test contract (splitTrinoCatalogSchema, DSN prefix) without
-// claiming feature parity with the Python side. The "happy path"
-// DSN shape test (TestExeSQL_TrinoDSN_HappyPath_BasicShape) uses
-// these to validate the wiring; richer provider-specific behaviour
-// (auth plugins, custom certs, …) is out of scope.
+// A PEM CA bundle on the node (`tls_ca_cert`) is passed through as the
+// driver's SSLCert option for clusters behind a private CA.
 package tool
 
 import (
@@ -46,6 +34,9 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	// Registers the "trino" database/sql driver.
+	_ "github.com/trinodb/trino-go-client/trino"
 )
 
 // splitTrinoCatalogSchema parses a Trino database spec into a
@@ -71,7 +62,7 @@ func splitTrinoCatalogSchema(db string) (catalog, schema string) {
 // design doc §10.1 + gap analysis §11.4.1 row 5c.
 func trinoDSN(p exesqlConnParams) string {
 	scheme := "http"
-	if os.Getenv("TRINO_USE_TLS") != "" {
+	if p.TLS || os.Getenv("TRINO_USE_TLS") != "" {
 		scheme = "https"
 	}
 	port := p.Port
@@ -99,6 +90,9 @@ func trinoDSN(p exesqlConnParams) string {
 	q := url.Values{}
 	q.Set("catalog", catalog)
 	q.Set("schema", schema)
+	if scheme == "https" && p.TLSCACert != "" {
+		q.Set("SSLCert", p.TLSCACert)
+	}
 	u := url.URL{
 		Scheme:   scheme,
 		User:     user,
@@ -116,6 +116,38 @@ func TestExeSQL_TrinoDSN_BasicAuthEncoded(t *testing.T) {
 	}
 }
 
+// TestExeSQL_TrinoDSN_NodeTLSWithCACert: the node-level `tls` flag
+// switches to https without the env var, and a PEM CA cert is handed
+// to the driver as SSLCert. Over plain HTTP the cert is dropped.
+func TestExeSQL_TrinoDSN_NodeTLSWithCACert(t *testing.T) {
+	t.Setenv("TRINO_USE_TLS", "")
+	conn := exesqlConnParams{
+		DBType:    "trino",
+		Host:      "h",
+		Port:      8443,
+		Username:  "alice",
+		Password:  "pw",
+		Database:  "hive.sales",
+		TLS:       true,
+		TLSCACert: "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+	}
+	dsn := trinoDSN(conn)
+	if !strings.HasPrefix(dsn, "https://alice:pw@h:8443?") {
+		t.Errorf("DSN prefix mismatch: %q", dsn)
+	}
+	if !strings.Contains(dsn, "SSLCert=-----BEGIN+CERTIFICATE") {
+		t.Errorf("DSN missing SSLCert: %q", dsn)
+	}
+	if !strings.Contains(dsn, "catalog=hive") || !strings.Contains(dsn, "schema=sales") {
+		t.Errorf("DSN catalog/schema mismatch: %q", dsn)
+	}
+
+	conn.TLS = false
+	if dsn := trinoDSN(conn); strings.Contains(dsn, "SSLCert") {
+		t.Errorf("SSLCert should not be sent over plain HTTP: %q", dsn)
+	}
+}
+
 // TestExeSQL_TrinoDSN_NoPasswordOverHTTP: a password over plain HTTP
 // must NOT be in the DSN (Basic auth over http = cleartext leakage).
 // The trino driver itself warns against this; Python gates BasicAuth
@@ -196,7 +228,7 @@ func TestExeSQL_Trino_HappyPath(t *testing.T) {
 	rows := sqlmock.NewRows(cols).
 		AddRow(int64(1), "alpha").
 		AddRow(int64(2), "beta")
-	mock.ExpectQuery("SELECT id, name FROM catalog.tiny.users").
+	mock.ExpectQuery("SELECT id, name FROM catalog.tiny.users LIMIT 100").
 		WillReturnRows(rows)
 
 	tool := NewExeSQLTool(exesqlConnParams{
@@ -18,28 +18,49 @@ package tool
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 )
 
-// TestExeSQL_TrinoDriverMissing verifies Trino is now routed through the
-// Trino DSN path. In this workspace we do not register a real "trino"
-// database/sql driver, so InvokableRun should fail at sql.Open with an
-// unknown-driver error rather than the old unsupported-db sentinel.
-func TestExeSQL_TrinoDriverMissing(t *testing.T) {
+// TestExeSQL_TrinoUsesRegisteredDriver verifies Trino is routed to the
+// trino-go-client driver rather than the old unsupported-db sentinel.
+// The dialer is stubbed so no Trino server is needed.
+func TestExeSQL_TrinoUsesRegisteredDriver(t *testing.T) {
+	registered := false
+	for _, d := range sql.Drivers() {
+		if d == "trino" {
+			registered = true
+		}
+	}
+	if !registered {
+		t.Fatal(`"trino" database/sql driver not registered`)
+	}
+
+	var gotDriver string
 	conn := exesqlConnParams{DBType: "trino", Host: "h", Port: 8080, Database: "d", Username: "u"}
-	tool := NewExeSQLTool(conn)
+	tool := NewExeSQLTool(conn).WithExeSQLDialer(func(driver, _ string) (*sql.DB, error) {
+		gotDriver = driver
+		return nil, errors.New("stub dialer")
+	})
 	_, err := tool.InvokableRun(context.Background(), `{"sql":"SELECT 1"}`)
 	if err == nil {
-		t.Fatal("expected driver error for trino")
+		t.Fatal("expected the stub dialer error")
 	}
 	if errors.Is(err, ErrExeSQLUnsupportedDB) {
 		t.Fatalf("err=%v, did not want ErrExeSQLUnsupportedDB after trino wiring", err)
 	}
+	if gotDriver != "trino" {
+		t.Errorf("driver = %q, want trino", gotDriver)
+	}
 }
 
-// TestExeSQL_IBMDB2Unsupported: same as above for IBM DB2.
+// TestExeSQL_IBMDB2Unsupported: builds without `-tags db2` report IBM
+// DB2 as unsupported instead of failing at sql.Open.
 func TestExeSQL_IBMDB2Unsupported(t *testing.T) {
+	if exesqlDB2Available {
+		t.Skip("built with the db2 driver")
+	}
 	conn := exesqlConnParams{DBType: "ibm db2", Host: "h", Port: 50000, Database: "d", Username: "u"}
 	tool := NewExeSQLTool(conn)
 	_, err := tool.InvokableRun(context.Background(), `{"sql":"SELECT 1"}`)
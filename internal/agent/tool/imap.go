@@ -0,0 +1,546 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package tool — IMAP mailbox tools.
+//
+// A tenant registers an IMAP account (see service/email_account.go);
+// Agent DSLs reference it as "imap:<account_id>" and get one tool that
+// searches and reads the account's mailboxes. Mailboxes are opened
+// read-only, so reading never marks mail as seen. Attachments of a
+// read message are passed to the document parser installed with
+// SetEmailAttachmentParser.
+//
+// The names are tenant-scoped, so they are resolved by BuildAllContext
+// like the "openapi:" and "mcp:" tools.
+package tool
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	einotool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"ragflow/internal/dao"
+	"ragflow/internal/utility"
+)
+
+// IMAPToolPrefix marks an Agent tool name as a reference to a
+// tenant email account: "imap:<account_id>".
+const IMAPToolPrefix = "imap:"
+
+// IMAP tool actions.
+const (
+	IMAPActionSearch        = "search"
+	IMAPActionRead          = "read"
+	IMAPActionListMailboxes = "list_mailboxes"
+)
+
+const (
+	imapDefaultLimit = 10
+	imapMaxLimit     = 50
+	// imapMaxTextChars bounds the body text and each attachment's
+	// extracted text returned to the model.
+	imapMaxTextChars = 20000
+	// imapMaxAttachmentBytes is the largest attachment handed to the
+	// parser; larger ones are listed without content.
+	imapMaxAttachmentBytes = 10 << 20
+	imapDateLayout         = "2006-01-02"
+)
+
+// EmailAccountRecord is the stored form of a tenant email account that
+// the tool resolver and the inbound-email trigger need.
+type EmailAccountRecord struct {
+	ID       string
+	Name     string
+	Host     string
+	Port     int
+	Security string
+	Username string
+	Password string
+	Mailbox  string
+	// TLSConfig overrides the TLS settings of the connection. It is
+	// never loaded from storage; tests use it to trust a stand-in
+	// server.
+	TLSConfig *tls.Config
+}
+
+// EmailAccountLoader loads a tenant's email account by ID. It returns
+// (nil, nil) when the account does not exist.
+type EmailAccountLoader func(ctx context.Context, tenantID, accountID string) (*EmailAccountRecord, error)
+
+var emailAccountLoader EmailAccountLoader = loadEmailAccountFromDAO
+
+// SetEmailAccountLoader replaces the account loader. Passing nil
+// restores the DAO-backed default. Intended for tests.
+func SetEmailAccountLoader(fn EmailAccountLoader) {
+	if fn == nil {
+		fn = loadEmailAccountFromDAO
+	}
+	emailAccountLoader = fn
+}
+
+// LoadEmailAccount loads a tenant's email account through the current
+// loader.
+func LoadEmailAccount(ctx context.Context, tenantID, accountID string) (*EmailAccountRecord, error) {
+	return emailAccountLoader(ctx, tenantID, accountID)
+}
+
+func loadEmailAccountFromDAO(_ context.Context, tenantID, accountID string) (*EmailAccountRecord, error) {
+	account, err := dao.NewEmailAccountDAO().GetByIDAndTenant(accountID, tenantID)
+	if err != nil || account == nil {
+		return nil, err
+	}
+	password, _ := account.Credentials["password"].(string)
+	return &EmailAccountRecord{
+		ID:       account.ID,
+		Name:     account.Name,
+		Host:     account.Host,
+		Port:     account.Port,
+		Security: account.Security,
+		Username: account.Username,
+		Password: password,
+		Mailbox:  account.Mailbox,
+	}, nil
+}
+
+// EmailAttachmentParser extracts the text of an attachment.
+type EmailAttachmentParser func(filename string, data []byte) (string, error)
+
+var emailAttachmentParser EmailAttachmentParser = parseTextAttachment
+
+// SetEmailAttachmentParser installs the document parser used for
+// attachments. The server wires in the ingestion parser; passing nil
+// restores the default, which only reads UTF-8 text.
+func SetEmailAttachmentParser(fn EmailAttachmentParser) {
+	if fn == nil {
+		fn = parseTextAttachment
+	}
+	emailAttachmentParser = fn
+}
+
+func parseTextAttachment(filename string, data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("no parser for attachment %q", filename)
+	}
+	return string(data), nil
+}
+
+// DialEmailAccount connects and logs in to the account's IMAP server.
+func DialEmailAccount(ctx context.Context, account *EmailAccountRecord) (*utility.IMAPClient, error) {
+	return utility.DialIMAP(ctx, utility.IMAPConfig{
+		Host:      account.Host,
+		Port:      account.Port,
+		Security:  account.Security,
+		Username:  account.Username,
+		Password:  account.Password,
+		TLSConfig: account.TLSConfig,
+	})
+}
+
+// IsIMAPToolName reports whether name references an email account.
+func IsIMAPToolName(name string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(name)), IMAPToolPrefix)
+}
+
+// IMAPToolName returns the Agent tool name for an email account.
+func IMAPToolName(accountID string) string {
+	return IMAPToolPrefix + accountID
+}
+
+// EmailMessageSummary is a message as listed by a search.
+type EmailMessageSummary struct {
+	UID     uint32   `json:"uid"`
+	From    string   `json:"from"`
+	To      []string `json:"to,omitempty"`
+	Subject string   `json:"subject"`
+	Date    string   `json:"date,omitempty"`
+	Seen    bool     `json:"seen"`
+	Size    int64    `json:"size"`
+}
+
+// EmailMessageDetail is a fully read message.
+type EmailMessageDetail struct {
+	UID         uint32                   `json:"uid"`
+	MessageID   string                   `json:"message_id,omitempty"`
+	InReplyTo   string                   `json:"in_reply_to,omitempty"`
+	From        string                   `json:"from"`
+	To          []string                 `json:"to,omitempty"`
+	Cc          []string                 `json:"cc,omitempty"`
+	Subject     string                   `json:"subject"`
+	Date        string                   `json:"date,omitempty"`
+	Seen        bool                     `json:"seen"`
+	Text        string                   `json:"text"`
+	Truncated   bool                     `json:"truncated,omitempty"`
+	Attachments []EmailAttachmentContent `json:"attachments,omitempty"`
+}
+
+// EmailAttachmentContent is an attachment with its extracted text.
+// Error explains why Content is empty.
+type EmailAttachmentContent struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	Content     string `json:"content,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// NewEmailMessageDetail decodes a fetched message. With
+// parseAttachments the attachments are run through the installed
+// parser; otherwise only their metadata is returned.
+func NewEmailMessageDetail(m *utility.IMAPMessage, parseAttachments bool) (*EmailMessageDetail, error) {
+	msg, err := utility.ParseEmailMessage(m.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode message %d: %w", m.UID, err)
+	}
+	text, truncated := truncateRunes(msg.Text, imapMaxTextChars)
+	out := &EmailMessageDetail{
+		UID:       m.UID,
+		MessageID: msg.MessageID,
+		InReplyTo: msg.InReplyTo,
+		From:      msg.From,
+		To:        msg.To,
+		Cc:        msg.Cc,
+		Subject:   msg.Subject,
+		Date:      formatEmailDate(msg.Date),
+		Seen:      m.Seen(),
+		Text:      text,
+		Truncated: truncated,
+	}
+	for _, a := range msg.Attachments {
+		att := EmailAttachmentContent{Filename: a.Filename, ContentType: a.ContentType, Size: len(a.Data)}
+		switch {
+		case !parseAttachments:
+		case len(a.Data) > imapMaxAttachmentBytes:
+			att.Error = fmt.Sprintf("attachment exceeds %d bytes", imapMaxAttachmentBytes)
+		default:
+			content, err := emailAttachmentParser(a.Filename, a.Data)
+			if err != nil {
+				att.Error = err.Error()
+				break
+			}
+			att.Content, att.Truncated = truncateRunes(strings.TrimSpace(content), imapMaxTextChars)
+		}
+		out.Attachments = append(out.Attachments, att)
+	}
+	return out, nil
+}
+
+func formatEmailDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func truncateRunes(s string, limit int) (string, bool) {
+	if utf8.RuneCountInString(s) <= limit {
+		return s, false
+	}
+	return string([]rune(s)[:limit]), true
+}
+
+// imapParams is the JSON shape the model sends into InvokableRun.
+type imapParams struct {
+	Action             string `json:"action"`
+	Mailbox            string `json:"mailbox"`
+	From               string `json:"from"`
+	To                 string `json:"to"`
+	Subject            string `json:"subject"`
+	Text               string `json:"text"`
+	Since              string `json:"since"`
+	Before             string `json:"before"`
+	Unseen             bool   `json:"unseen"`
+	Limit              int    `json:"limit"`
+	UID                uint32 `json:"uid"`
+	IncludeAttachments *bool  `json:"include_attachments"`
+}
+
+// imapEnvelope is what the model sees. Exactly one of the payload
+// fields is set on success.
+type imapEnvelope struct {
+	Mailbox   string                `json:"mailbox,omitempty"`
+	Total     *int                  `json:"total,omitempty"`
+	Messages  []EmailMessageSummary `json:"messages,omitempty"`
+	Message   *EmailMessageDetail   `json:"message,omitempty"`
+	Mailboxes []utility.IMAPMailbox `json:"mailboxes,omitempty"`
+	Error     string                `json:"_ERROR,omitempty"`
+}
+
+// IMAPTool searches and reads the mailboxes of one email account.
+type IMAPTool struct {
+	account *EmailAccountRecord
+}
+
+// NewIMAPTool returns the tool for account.
+func NewIMAPTool(account *EmailAccountRecord) *IMAPTool {
+	return &IMAPTool{account: account}
+}
+
+// Info returns the tool's metadata for the chat model. The name is
+// derived from the account ID so several accounts can be attached to
+// one agent.
+func (t *IMAPTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	mailbox := t.defaultMailbox()
+	return &schema.ToolInfo{
+		Name: "imap_" + t.account.ID,
+		Desc: fmt.Sprintf("Search and read the email account %q (%s). "+
+			"action=search lists matching messages newest first without their bodies; "+
+			"action=read returns one message by uid with its text and the extracted text of its attachments; "+
+			"action=list_mailboxes lists the folders. The default mailbox is %s. Reading does not mark mail as seen.",
+			t.account.Name, t.account.Username, mailbox),
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"action": {
+				Type:     schema.String,
+				Desc:     "What to do: search, read or list_mailboxes.",
+				Enum:     []string{IMAPActionSearch, IMAPActionRead, IMAPActionListMailboxes},
+				Required: true,
+			},
+			"mailbox": {
+				Type: schema.String,
+				Desc: "Mailbox (folder) to search or read, default " + mailbox + ".",
+			},
+			"from": {
+				Type: schema.String,
+				Desc: "search: sender contains this text.",
+			},
+			"to": {
+				Type: schema.String,
+				Desc: "search: recipient contains this text.",
+			},
+			"subject": {
+				Type: schema.String,
+				Desc: "search: subject contains this text.",
+			},
+			"text": {
+				Type: schema.String,
+				Desc: "search: headers or body contain this text.",
+			},
+			"since": {
+				Type: schema.String,
+				Desc: "search: messages dated on or after this day (YYYY-MM-DD).",
+			},
+			"before": {
+				Type: schema.String,
+				Desc: "search: messages dated before this day (YYYY-MM-DD).",
+			},
+			"unseen": {
+				Type: schema.Boolean,
+				Desc: "search: only messages not yet marked as seen.",
+			},
+			"limit": {
+				Type: schema.Integer,
+				Desc: fmt.Sprintf("search: maximum messages to return (default %d, max %d).", imapDefaultLimit, imapMaxLimit),
+			},
+			"uid": {
+				Type: schema.Integer,
+				Desc: "read: UID of the message, as returned by search.",
+			},
+			"include_attachments": {
+				Type: schema.Boolean,
+				Desc: "read: extract the text of attachments (default true).",
+			},
+		}),
+	}, nil
+}
+
+func (t *IMAPTool) defaultMailbox() string {
+	if t.account.Mailbox != "" {
+		return t.account.Mailbox
+	}
+	return "INBOX"
+}
+
+// InvokableRun connects to the account, runs the action and logs out.
+func (t *IMAPTool) InvokableRun(ctx context.Context, argsJSON string, _ ...einotool.Option) (string, error) {
+	var p imapParams
+	if strings.TrimSpace(argsJSON) != "" {
+		if err := json.Unmarshal([]byte(argsJSON), &p); err != nil {
+			err = fmt.Errorf("imap: parse arguments: %w", err)
+			return imapErrJSON(err), err
+		}
+	}
+	if p.Mailbox == "" {
+		p.Mailbox = t.defaultMailbox()
+	}
+	var criteria utility.IMAPSearchCriteria
+	switch p.Action {
+	case IMAPActionSearch:
+		var err error
+		if criteria, err = p.criteria(); err != nil {
+			return imapErrJSON(err), err
+		}
+	case IMAPActionRead:
+		if p.UID == 0 {
+			err := errors.New("imap: uid is required for read")
+			return imapErrJSON(err), err
+		}
+	case IMAPActionListMailboxes:
+	default:
+		err := fmt.Errorf("imap: action must be %s, %s or %s", IMAPActionSearch, IMAPActionRead, IMAPActionListMailboxes)
+		return imapErrJSON(err), err
+	}
+
+	client, err := DialEmailAccount(ctx, t.account)
+	if err != nil {
+		err = fmt.Errorf("imap: connect to %s: %w", t.account.Name, err)
+		return imapErrJSON(err), err
+	}
+	defer client.Close()
+
+	var env imapEnvelope
+	switch p.Action {
+	case IMAPActionSearch:
+		env, err = imapSearch(client, p.Mailbox, criteria, p.Limit)
+	case IMAPActionRead:
+		env, err = imapRead(client, p.Mailbox, p.UID, p.IncludeAttachments == nil || *p.IncludeAttachments)
+	case IMAPActionListMailboxes:
+		env.Mailboxes, err = client.List("*")
+		if err == nil && env.Mailboxes == nil {
+			env.Mailboxes = []utility.IMAPMailbox{}
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("imap: %s: %w", p.Action, err)
+		return imapErrJSON(err), err
+	}
+	return imapJSON(env), nil
+}
+
+func (p *imapParams) criteria() (utility.IMAPSearchCriteria, error) {
+	c := utility.IMAPSearchCriteria{
+		From:    strings.TrimSpace(p.From),
+		To:      strings.TrimSpace(p.To),
+		Subject: strings.TrimSpace(p.Subject),
+		Text:    strings.TrimSpace(p.Text),
+		Unseen:  p.Unseen,
+	}
+	var err error
+	if p.Since != "" {
+		if c.Since, err = time.Parse(imapDateLayout, strings.TrimSpace(p.Since)); err != nil {
+			return c, fmt.Errorf("imap: since must be YYYY-MM-DD")
+		}
+	}
+	if p.Before != "" {
+		if c.Before, err = time.Parse(imapDateLayout, strings.TrimSpace(p.Before)); err != nil {
+			return c, fmt.Errorf("imap: before must be YYYY-MM-DD")
+		}
+	}
+	return c, nil
+}
+
+func imapSearch(client *utility.IMAPClient, mailbox string, criteria utility.IMAPSearchCriteria, limit int) (imapEnvelope, error) {
+	if limit <= 0 {
+		limit = imapDefaultLimit
+	}
+	if limit > imapMaxLimit {
+		limit = imapMaxLimit
+	}
+	if _, err := client.Examine(mailbox); err != nil {
+		return imapEnvelope{}, err
+	}
+	uids, err := client.UIDSearch(criteria)
+	if err != nil {
+		return imapEnvelope{}, err
+	}
+	total := len(uids)
+	if len(uids) > limit {
+		uids = uids[len(uids)-limit:]
+	}
+	// Newest first.
+	for i, j := 0, len(uids)-1; i < j; i, j = i+1, j-1 {
+		uids[i], uids[j] = uids[j], uids[i]
+	}
+	fetched, err := client.UIDFetch(uids, true)
+	if err != nil {
+		return imapEnvelope{}, err
+	}
+	env := imapEnvelope{Mailbox: mailbox, Total: &total, Messages: make([]EmailMessageSummary, 0, len(fetched))}
+	for _, m := range fetched {
+		summary := EmailMessageSummary{UID: m.UID, Seen: m.Seen(), Size: m.Size}
+		if msg, err := utility.ParseEmailMessage(m.Raw); err == nil {
+			summary.From = msg.From
+			summary.To = msg.To
+			summary.Subject = msg.Subject
+			summary.Date = formatEmailDate(msg.Date)
+		}
+		env.Messages = append(env.Messages, summary)
+	}
+	return env, nil
+}
+
+func imapRead(client *utility.IMAPClient, mailbox string, uid uint32, parseAttachments bool) (imapEnvelope, error) {
+	if _, err := client.Examine(mailbox); err != nil {
+		return imapEnvelope{}, err
+	}
+	fetched, err := client.UIDFetch([]uint32{uid}, false)
+	if err != nil {
+		return imapEnvelope{}, err
+	}
+	if len(fetched) == 0 {
+		return imapEnvelope{}, fmt.Errorf("no message with uid %d in %s", uid, mailbox)
+	}
+	detail, err := NewEmailMessageDetail(fetched[0], parseAttachments)
+	if err != nil {
+		return imapEnvelope{}, err
+	}
+	return imapEnvelope{Mailbox: mailbox, Message: detail}, nil
+}
+
+func imapJSON(env imapEnvelope) string {
+	b, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Sprintf(`{"_ERROR":"imap: marshal result: %s"}`, err)
+	}
+	return string(b)
+}
+
+func imapErrJSON(err error) string {
+	return imapJSON(imapEnvelope{Error: err.Error()})
+}
+
+// buildIMAPTools resolves "imap:<account_id>" names for tenantID. Each
+// account is built once even when listed twice.
+func buildIMAPTools(ctx context.Context, tenantID string, names []string) ([]einotool.BaseTool, error) {
+	built := map[string]bool{}
+	out := make([]einotool.BaseTool, 0, len(names))
+	for _, name := range names {
+		accountID := strings.TrimSpace(name[len(IMAPToolPrefix):])
+		if accountID == "" {
+			return nil, fmt.Errorf("agent tool: invalid imap tool name %q", name)
+		}
+		if built[accountID] {
+			continue
+		}
+		account, err := emailAccountLoader(ctx, tenantID, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("agent tool: load email account %s: %w", accountID, err)
+		}
+		if account == nil {
+			return nil, fmt.Errorf("agent tool: email account %s not found", accountID)
+		}
+		built[accountID] = true
+		out = append(out, NewIMAPTool(account))
+	}
+	return out, nil
+}
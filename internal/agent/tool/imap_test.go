@@ -0,0 +1,207 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"ragflow/internal/agent/runtime"
+	"ragflow/internal/utility/imaptest"
+)
+
+const imapTestInvoice = "From: Alice <alice@example.com>\r\n" +
+	"To: support@example.com\r\n" +
+	"Subject: Invoice dispute\r\n" +
+	"Message-ID: <inv@example.com>\r\n" +
+	"Date: Wed, 14 Oct 2026 08:00:00 +0000\r\n" +
+	"Content-Type: multipart/mixed; boundary=b1\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"I was charged twice, see attached.\r\n" +
+	"--b1\r\n" +
+	"Content-Type: application/pdf\r\n" +
+	"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQK\r\n" +
+	"--b1--\r\n"
+
+func newIMAPTestAccount(t *testing.T) (*imaptest.Server, *EmailAccountRecord) {
+	t.Helper()
+	srv, err := imaptest.NewServer(imaptest.SecurityTLS, "support@example.com", "pw")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	return srv, &EmailAccountRecord{
+		ID:        "acc1",
+		Name:      "Support",
+		Host:      srv.Host,
+		Port:      srv.Port,
+		Security:  imaptest.SecurityTLS,
+		Username:  srv.Username,
+		Password:  srv.Password,
+		Mailbox:   "INBOX",
+		TLSConfig: srv.ClientTLSConfig(),
+	}
+}
+
+func runIMAPTool(t *testing.T, tool *IMAPTool, args string) (imapEnvelope, error) {
+	t.Helper()
+	out, err := tool.InvokableRun(context.Background(), args)
+	var env imapEnvelope
+	if jerr := json.Unmarshal([]byte(out), &env); jerr != nil {
+		t.Fatalf("result is not JSON: %q", out)
+	}
+	return env, err
+}
+
+func TestIMAPTool_SearchAndRead(t *testing.T) {
+	srv, account := newIMAPTestAccount(t)
+	srv.Deliver("INBOX", []byte("From: bob@example.com\r\nSubject: Hello\r\nDate: Tue, 13 Oct 2026 08:00:00 +0000\r\n\r\nhi\r\n"), `\Seen`)
+	uid := srv.Deliver("INBOX", []byte(imapTestInvoice))
+	srv.AddMailbox("Archive", 1)
+
+	var parsed []string
+	SetEmailAttachmentParser(func(filename string, data []byte) (string, error) {
+		parsed = append(parsed, filename)
+		if !strings.HasPrefix(string(data), "%PDF") {
+			return "", errors.New("not a pdf")
+		}
+		return "Invoice #42 total 10.00", nil
+	})
+	t.Cleanup(func() { SetEmailAttachmentParser(nil) })
+
+	tool := NewIMAPTool(account)
+	info, err := tool.Info(context.Background())
+	if err != nil || info.Name != "imap_acc1" {
+		t.Fatalf("Info = %+v, %v", info, err)
+	}
+
+	env, err := runIMAPTool(t, tool, `{"action":"search"}`)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if *env.Total != 2 || len(env.Messages) != 2 || env.Messages[0].UID != uid {
+		t.Fatalf("search = %+v", env)
+	}
+	if env.Messages[0].Subject != "Invoice dispute" || env.Messages[0].Seen || !env.Messages[1].Seen {
+		t.Errorf("messages = %+v", env.Messages)
+	}
+
+	env, err = runIMAPTool(t, tool, `{"action":"search","unseen":true,"from":"alice","limit":1}`)
+	if err != nil || *env.Total != 1 || env.Messages[0].From != "Alice <alice@example.com>" {
+		t.Fatalf("filtered search = %+v, %v", env, err)
+	}
+
+	env, err = runIMAPTool(t, tool, `{"action":"read","uid":2}`)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	msg := env.Message
+	if msg == nil || msg.Text != "I was charged twice, see attached." || msg.MessageID != "<inv@example.com>" {
+		t.Fatalf("read = %+v", msg)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Content != "Invoice #42 total 10.00" || msg.Attachments[0].Filename != "invoice.pdf" {
+		t.Fatalf("attachments = %+v", msg.Attachments)
+	}
+	if len(parsed) != 1 {
+		t.Errorf("parser calls = %v", parsed)
+	}
+	if flags := srv.Flags("INBOX", uid); len(flags) != 0 {
+		t.Errorf("read marked the message: %v", flags)
+	}
+
+	env, err = runIMAPTool(t, tool, `{"action":"read","uid":2,"include_attachments":false}`)
+	if err != nil || env.Message.Attachments[0].Content != "" || len(parsed) != 1 {
+		t.Fatalf("read without attachments = %+v, %v", env.Message, err)
+	}
+
+	env, err = runIMAPTool(t, tool, `{"action":"list_mailboxes"}`)
+	if err != nil || len(env.Mailboxes) != 2 {
+		t.Fatalf("list_mailboxes = %+v, %v", env, err)
+	}
+}
+
+func TestIMAPTool_Errors(t *testing.T) {
+	_, account := newIMAPTestAccount(t)
+	tool := NewIMAPTool(account)
+
+	for _, args := range []string{
+		`{"action":"delete"}`,
+		`{"action":"read"}`,
+		`{"action":"search","since":"yesterday"}`,
+		`not json`,
+	} {
+		env, err := runIMAPTool(t, tool, args)
+		if err == nil || env.Error == "" {
+			t.Errorf("%s: err = %v, env = %+v", args, err, env)
+		}
+	}
+
+	env, err := runIMAPTool(t, tool, `{"action":"read","uid":99}`)
+	if err == nil || !strings.Contains(env.Error, "no message with uid 99") {
+		t.Errorf("missing uid: %v", err)
+	}
+	env, err = runIMAPTool(t, tool, `{"action":"search","mailbox":"Nope"}`)
+	if err == nil || env.Error == "" {
+		t.Errorf("missing mailbox: %v", err)
+	}
+
+	bad := *account
+	bad.Password = "wrong"
+	env, err = runIMAPTool(t, NewIMAPTool(&bad), `{"action":"list_mailboxes"}`)
+	if err == nil || !strings.Contains(env.Error, "AUTHENTICATIONFAILED") {
+		t.Errorf("bad password: %v", err)
+	}
+}
+
+func TestBuildAllContext_ResolvesIMAPTools(t *testing.T) {
+	var gotTenant string
+	SetEmailAccountLoader(func(_ context.Context, tenantID, accountID string) (*EmailAccountRecord, error) {
+		gotTenant = tenantID
+		if accountID != "acc1" {
+			return nil, nil
+		}
+		return &EmailAccountRecord{ID: accountID, Name: "Support"}, nil
+	})
+	t.Cleanup(func() { SetEmailAccountLoader(nil) })
+
+	state := runtime.NewCanvasState("run", "task")
+	state.Sys["tenant_id"] = "tenant-1"
+	ctx := runtime.WithState(context.Background(), state)
+
+	tools, err := BuildAllContext(ctx, []string{"wikipedia", "imap:acc1", "imap:acc1"}, nil)
+	if err != nil {
+		t.Fatalf("BuildAllContext: %v", err)
+	}
+	if gotTenant != "tenant-1" || len(tools) != 2 {
+		t.Fatalf("tenant = %q, len(tools) = %d", gotTenant, len(tools))
+	}
+	if _, err := BuildAllContext(ctx, []string{"imap:missing"}, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("missing account err = %v", err)
+	}
+	if _, err := BuildAllContext(context.Background(), []string{"imap:acc1"}, nil); err == nil || !strings.Contains(err.Error(), "imap tools") {
+		t.Fatalf("no tenant err = %v", err)
+	}
+}
@@ -18,9 +18,9 @@
 //
 // Wraps a single MCP-server-discovered tool (utility/mcpclient.Tool) as
 // an eino BaseTool so it can be invoked from inside the Agent's
-// ReAct loop. The MCP tool list is fetched via utility/mcpclient
-// (which currently only implements tools/list discovery; tools/call
-// invocation is the next step on the MCP client).
+// ReAct loop. The MCP tool list is fetched via utility/mcpclient;
+// tenant-registered servers are resolved into adapters by
+// mcp_server.go.
 package tool
 
 import (
@@ -43,17 +43,16 @@ import (
 // string result.
 //
 // InvokableRun dispatches through mcpclient.CallTool
-// (streamable-HTTP transport). The MCP server URL + headers
-// are captured on construction so the adapter has everything it
-// needs to call back into the server. Adapters built without
-// a URL (legacy callers) fall back to the "not yet wired"
-// sentinel so existing call sites don't break.
+// (streamable-HTTP transport), or through a pooled session when
+// the adapter was built with NewPooledMCPToolAdapter. The MCP
+// server connection details are captured on construction so the
+// adapter has everything it needs to call back into the server.
+// Adapters built without a server (legacy callers) fall back to
+// the "not yet wired" sentinel so existing call sites don't break.
 type MCPToolAdapter struct {
-	mcpTool    mcpclient.Tool
-	serverURL  string
-	headers    map[string]string
-	timeout    time.Duration
-	httpClient *http.Client
+	mcpTool mcpclient.Tool
+	server  mcpclient.ConnectOptions
+	pool    *mcpclient.SessionPool
 }
 
 // NewMCPToolAdapter constructs a wrapper for a single MCP tool.
@@ -68,12 +67,7 @@ func NewMCPToolAdapter(t mcpclient.Tool) *MCPToolAdapter {
 // the MCP server URL + transport headers. InvokableRun uses this
 // to route InvokableRun into mcpclient.CallTool.
 func NewMCPToolAdapterWithServer(t mcpclient.Tool, serverURL string, headers map[string]string, timeout time.Duration) *MCPToolAdapter {
-	return &MCPToolAdapter{
-		mcpTool:   t,
-		serverURL: serverURL,
-		headers:   headers,
-		timeout:   timeout,
-	}
+	return NewMCPToolAdapterFull(t, serverURL, headers, timeout, nil)
 }
 
 // NewMCPToolAdapterFull is the most-configurable constructor;
@@ -82,14 +76,25 @@ func NewMCPToolAdapterWithServer(t mcpclient.Tool, serverURL string, headers map
 // CallTool call doesn't have to fall back to a pinned client.
 func NewMCPToolAdapterFull(t mcpclient.Tool, serverURL string, headers map[string]string, timeout time.Duration, client *http.Client) *MCPToolAdapter {
 	return &MCPToolAdapter{
-		mcpTool:    t,
-		serverURL:  serverURL,
-		headers:    headers,
-		timeout:    timeout,
-		httpClient: client,
+		mcpTool: t,
+		server: mcpclient.ConnectOptions{
+			URL:        serverURL,
+			ServerType: mcpclient.TransportStreamableHTTP,
+			Headers:    headers,
+			Timeout:    timeout,
+			HTTPClient: client,
+		},
 	}
 }
 
+// NewPooledMCPToolAdapter constructs a wrapper that calls the tool
+// on the pool's session for server, over any transport. The
+// session (a running stdio server, an open HTTP session) is
+// shared by every adapter for the same server.
+func NewPooledMCPToolAdapter(t mcpclient.Tool, server mcpclient.ConnectOptions, pool *mcpclient.SessionPool) *MCPToolAdapter {
+	return &MCPToolAdapter{mcpTool: t, server: server, pool: pool}
+}
+
 // Name returns the underlying MCP tool name.
 func (m *MCPToolAdapter) Name() string { return m.mcpTool.Name }
 
@@ -115,12 +120,13 @@ func (m *MCPToolAdapter) Info(_ context.Context) (*schema.ToolInfo, error) {
 	}, nil
 }
 
-// InvokableRun is the eino entry point. When the adapter was
-// built with a server URL, dispatch through mcpclient.CallTool.
-// Legacy adapters (no URL) keep the "not yet wired" sentinel
-// so existing tests that pin the error message don't break.
+// InvokableRun is the eino entry point. Pooled adapters call the
+// tool on the pool's session; adapters built with a server URL
+// dispatch through mcpclient.CallTool. Legacy adapters (no
+// server) keep the "not yet wired" sentinel so existing tests
+// that pin the error message don't break.
 func (m *MCPToolAdapter) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
-	if m.serverURL == "" {
+	if m.pool == nil && m.server.URL == "" {
 		return "", fmt.Errorf("mcp tool %q: tools/call not yet implemented in mcpclient; arguments were: %s",
 			m.mcpTool.Name, argumentsInJSON)
 	}
@@ -128,15 +134,26 @@ func (m *MCPToolAdapter) InvokableRun(ctx context.Context, argumentsInJSON strin
 	if mErr != nil {
 		return "", mErr
 	}
-	res, err := mcpclient.CallTool(ctx, mcpclient.CallOptions{
-		URL:        m.serverURL,
-		ServerType: mcpclient.TransportStreamableHTTP,
-		Headers:    m.headers,
-		ToolName:   m.mcpTool.Name,
-		Arguments:  argsJSON,
-		Timeout:    m.timeout,
-		HTTPClient: m.httpClient,
-	})
+	var (
+		res *mcpclient.CallResult
+		err error
+	)
+	if m.pool != nil {
+		var session *mcpclient.Session
+		if session, err = m.pool.Get(ctx, m.server); err == nil {
+			res, err = session.CallTool(ctx, m.mcpTool.Name, argsJSON)
+		}
+	} else {
+		res, err = mcpclient.CallTool(ctx, mcpclient.CallOptions{
+			URL:        m.server.URL,
+			ServerType: m.server.ServerType,
+			Headers:    m.server.Headers,
+			ToolName:   m.mcpTool.Name,
+			Arguments:  argsJSON,
+			Timeout:    m.server.Timeout,
+			HTTPClient: m.server.HTTPClient,
+		})
+	}
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,495 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Resolves tenant-registered MCP servers into Agent tools. An Agent
+// references a server as "mcp:<server_id>" (every enabled tool, plus a
+// resource reader and a prompt tool when the server offers those) or
+// "mcp:<server_id>:<tool>" (one tool). Calls go through a package-wide
+// session pool so a stdio server is launched once and reused across
+// runs until it goes idle.
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"ragflow/internal/dao"
+	mcpclient "ragflow/internal/utility"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// MCPToolPrefix marks an Agent tool name that references a registered
+// MCP server.
+const MCPToolPrefix = "mcp:"
+
+// mcpToolNameMaxLen bounds the server-name part of the resource and
+// prompt tool names; model APIs cap function names at 64 characters.
+const mcpToolNameMaxLen = 40
+
+// MCPServerRecord is the stored form of a tenant MCP server that the
+// tool resolver needs.
+type MCPServerRecord struct {
+	ID         string
+	Name       string
+	URL        string
+	ServerType string
+	Headers    map[string]any
+	Variables  map[string]any
+}
+
+// MCPServerLoader loads a tenant's MCP server by ID. It returns
+// (nil, nil) when the server does not exist.
+type MCPServerLoader func(ctx context.Context, tenantID, serverID string) (*MCPServerRecord, error)
+
+var mcpServerLoader MCPServerLoader = loadMCPServerFromDAO
+
+// SetMCPServerLoader replaces the server loader. Passing nil restores
+// the DAO-backed default. Intended for tests.
+func SetMCPServerLoader(fn MCPServerLoader) {
+	if fn == nil {
+		fn = loadMCPServerFromDAO
+	}
+	mcpServerLoader = fn
+}
+
+func loadMCPServerFromDAO(_ context.Context, tenantID, serverID string) (*MCPServerRecord, error) {
+	server, err := dao.NewMCPServerDAO().GetByIDAndTenant(serverID, tenantID)
+	if err != nil || server == nil {
+		return nil, err
+	}
+	return &MCPServerRecord{
+		ID:         server.ID,
+		Name:       server.Name,
+		URL:        server.URL,
+		ServerType: server.ServerType,
+		Headers:    server.Headers,
+		Variables:  server.Variables,
+	}, nil
+}
+
+// mcpToolsSaver persists a server's re-fetched tool list after the
+// server announces a change. Tests replace it.
+var mcpToolsSaver = saveMCPToolsToDAO
+
+func saveMCPToolsToDAO(tenantID, serverID string, tools []mcpclient.Tool) {
+	serverDAO := dao.NewMCPServerDAO()
+	server, err := serverDAO.GetByIDAndTenant(serverID, tenantID)
+	if err != nil || server == nil {
+		return
+	}
+	variables := map[string]any{}
+	for k, v := range server.Variables {
+		variables[k] = v
+	}
+	variables["tools"] = mergeMCPToolList(server.Variables["tools"], tools)
+	_, _ = serverDAO.UpdateMCPServer(serverID, tenantID, map[string]interface{}{"variables": variables})
+}
+
+// mergeMCPToolList renders tools in the variables.tools shape
+// ({name: descriptor}), carrying over the "enabled" flag the user set
+// on tools that are still offered.
+func mergeMCPToolList(previous any, tools []mcpclient.Tool) map[string]any {
+	old, _ := previous.(map[string]any)
+	out := make(map[string]any, len(tools))
+	for _, t := range tools {
+		entry := map[string]any{}
+		for k, v := range t.Raw {
+			entry[k] = v
+		}
+		entry["name"] = t.Name
+		if t.Description != "" {
+			entry["description"] = t.Description
+		}
+		if t.InputSchema != nil {
+			entry["inputSchema"] = t.InputSchema
+		}
+		if prev, ok := old[t.Name].(map[string]any); ok {
+			if enabled, ok := prev["enabled"]; ok {
+				entry["enabled"] = enabled
+			}
+		}
+		out[t.Name] = entry
+	}
+	return out
+}
+
+// mcpSessions is shared by every MCP tool the resolver builds.
+var mcpSessions = mcpclient.NewSessionPool(mcpclient.DefaultSessionIdle)
+
+// IsMCPToolName reports whether name references a registered MCP server.
+func IsMCPToolName(name string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(name)), MCPToolPrefix)
+}
+
+// MCPToolName returns the Agent tool name for one tool of a server, or
+// for the whole server when toolName is empty.
+func MCPToolName(serverID, toolName string) string {
+	if toolName == "" {
+		return MCPToolPrefix + serverID
+	}
+	return MCPToolPrefix + serverID + ":" + toolName
+}
+
+// MCPConnectOptions maps a stored server onto the options used to open
+// a session. Only string headers and variables are forwarded; the
+// cached tool list in variables is not.
+func MCPConnectOptions(rec *MCPServerRecord) (mcpclient.ConnectOptions, error) {
+	opts := mcpclient.ConnectOptions{
+		URL:        rec.URL,
+		ServerType: rec.ServerType,
+		Headers:    map[string]string{},
+		Variables:  map[string]string{},
+	}
+	if opts.ServerType == "" {
+		opts.ServerType = mcpclient.TransportStreamableHTTP
+	}
+	for k, v := range rec.Headers {
+		if s, ok := v.(string); ok {
+			opts.Headers[k] = s
+		}
+	}
+	for k, v := range rec.Variables {
+		if s, ok := v.(string); ok && k != "tools" {
+			opts.Variables[k] = s
+		}
+	}
+	if opts.ServerType == mcpclient.TransportStdio {
+		srv, err := mcpclient.StdioServerFromConfig(rec.Variables)
+		if err != nil {
+			return opts, err
+		}
+		opts.Stdio = srv
+	}
+	return opts, nil
+}
+
+// disabledMCPTools returns the tools the user switched off in the
+// server's stored tool list.
+func disabledMCPTools(variables map[string]any) map[string]bool {
+	out := map[string]bool{}
+	tools, _ := variables["tools"].(map[string]any)
+	for name, raw := range tools {
+		entry, _ := raw.(map[string]any)
+		if enabled, ok := entry["enabled"].(bool); ok && !enabled {
+			out[name] = true
+		}
+	}
+	return out
+}
+
+// buildMCPTools resolves "mcp:" names for tenantID.
+func buildMCPTools(ctx context.Context, tenantID string, names []string) ([]tool.BaseTool, error) {
+	var out []tool.BaseTool
+	built := map[string]bool{}
+	for _, name := range names {
+		serverID, toolName, _ := strings.Cut(name[len(MCPToolPrefix):], ":")
+		if serverID == "" {
+			return nil, fmt.Errorf("agent tool: invalid mcp tool name %q", name)
+		}
+		rec, err := mcpServerLoader(ctx, tenantID, serverID)
+		if err != nil {
+			return nil, fmt.Errorf("agent tool: load mcp server %s: %w", serverID, err)
+		}
+		if rec == nil {
+			return nil, fmt.Errorf("agent tool: mcp server %s not found", serverID)
+		}
+		opts, err := MCPConnectOptions(rec)
+		if err != nil {
+			return nil, fmt.Errorf("agent tool: mcp server %s: %w", serverID, err)
+		}
+		session, err := mcpSessions.Get(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("agent tool: connect mcp server %s: %w", serverID, err)
+		}
+		session.OnToolsChanged(func(tools []mcpclient.Tool) {
+			mcpToolsSaver(tenantID, serverID, tools)
+		})
+		tools, err := session.ListTools(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("agent tool: list tools of mcp server %s: %w", serverID, err)
+		}
+
+		disabled := disabledMCPTools(rec.Variables)
+		found := false
+		for _, t := range tools {
+			if toolName != "" && t.Name != toolName {
+				continue
+			}
+			found = true
+			if toolName == "" && disabled[t.Name] {
+				continue
+			}
+			key := serverID + ":" + t.Name
+			if built[key] {
+				continue
+			}
+			built[key] = true
+			out = append(out, NewPooledMCPToolAdapter(t, opts, mcpSessions))
+		}
+		if toolName != "" {
+			if !found {
+				return nil, fmt.Errorf("agent tool: mcp server %s has no tool %q", serverID, toolName)
+			}
+			continue
+		}
+
+		// The resource reader and prompt tool are only added for a
+		// whole-server reference, once per server.
+		if built[serverID] {
+			continue
+		}
+		built[serverID] = true
+		if session.Supports(mcpclient.CapabilityResources) {
+			resources, _ := session.ListResources(ctx)
+			templates, _ := session.ListResourceTemplates(ctx)
+			out = append(out, NewMCPResourceTool(rec.Name, opts, mcpSessions, resources, templates))
+		}
+		if session.Supports(mcpclient.CapabilityPrompts) {
+			if prompts, err := session.ListPrompts(ctx); err == nil && len(prompts) > 0 {
+				out = append(out, NewMCPPromptTool(rec.Name, opts, mcpSessions, prompts))
+			}
+		}
+	}
+	return out, nil
+}
+
+// mcpToolSlug turns a server name into a function-name-safe prefix.
+func mcpToolSlug(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+		if b.Len() >= mcpToolNameMaxLen {
+			break
+		}
+	}
+	slug := strings.TrimRight(b.String(), "_")
+	if slug == "" {
+		slug = "mcp"
+	}
+	return slug
+}
+
+// MCPResourceTool lets the model read a resource the MCP server exposes.
+// The resources and templates known at build time are listed in the
+// tool description so the model knows what URIs to ask for.
+type MCPResourceTool struct {
+	name   string
+	desc   string
+	server mcpclient.ConnectOptions
+	pool   *mcpclient.SessionPool
+}
+
+// NewMCPResourceTool builds the resource reader for the server named
+// serverName.
+func NewMCPResourceTool(serverName string, server mcpclient.ConnectOptions, pool *mcpclient.SessionPool, resources []mcpclient.Resource, templates []mcpclient.ResourceTemplate) *MCPResourceTool {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Read a resource from the %q MCP server by URI.", serverName)
+	if len(resources) > 0 {
+		b.WriteString(" Available resources:")
+		for _, r := range resources {
+			fmt.Fprintf(&b, "\n- %s", r.URI)
+			if label := firstNonEmpty(r.Description, r.Name); label != "" {
+				fmt.Fprintf(&b, ": %s", label)
+			}
+		}
+	}
+	if len(templates) > 0 {
+		b.WriteString("\nURI templates (fill in the {placeholders}):")
+		for _, t := range templates {
+			fmt.Fprintf(&b, "\n- %s", t.URITemplate)
+			if label := firstNonEmpty(t.Description, t.Name); label != "" {
+				fmt.Fprintf(&b, ": %s", label)
+			}
+		}
+	}
+	return &MCPResourceTool{
+		name:   mcpToolSlug(serverName) + "_read_resource",
+		desc:   b.String(),
+		server: server,
+		pool:   pool,
+	}
+}
+
+// Info returns the tool metadata.
+func (t *MCPResourceTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: t.name,
+		Desc: t.desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"uri": {Type: schema.String, Desc: "URI of the resource to read.", Required: true},
+		}),
+	}, nil
+}
+
+// InvokableRun reads the resource. Text contents are returned as-is;
+// binary contents are summarised, not inlined.
+func (t *MCPResourceTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	var args struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("%s: parse arguments: %w", t.name, err)
+	}
+	if strings.TrimSpace(args.URI) == "" {
+		return "", fmt.Errorf("%s: uri is required", t.name)
+	}
+	session, err := t.pool.Get(ctx, t.server)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", t.name, err)
+	}
+	contents, err := session.ReadResource(ctx, args.URI)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", t.name, err)
+	}
+	parts := make([]string, 0, len(contents))
+	for _, c := range contents {
+		switch {
+		case c.Text != "":
+			parts = append(parts, c.Text)
+		case c.Blob != "":
+			parts = append(parts, fmt.Sprintf("[binary resource %s (%s), %d bytes base64]",
+				c.URI, firstNonEmpty(c.MIMEType, "unknown type"), len(c.Blob)))
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// MCPPromptTool lets the model render one of the server's prompt
+// templates and use the result as instructions.
+type MCPPromptTool struct {
+	name   string
+	desc   string
+	server mcpclient.ConnectOptions
+	pool   *mcpclient.SessionPool
+}
+
+// NewMCPPromptTool builds the prompt tool for the server named
+// serverName. prompts are listed in the description with their
+// arguments; required arguments are marked with "*".
+func NewMCPPromptTool(serverName string, server mcpclient.ConnectOptions, pool *mcpclient.SessionPool, prompts []mcpclient.Prompt) *MCPPromptTool {
+	sorted := append([]mcpclient.Prompt(nil), prompts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	var b strings.Builder
+	fmt.Fprintf(&b, "Render a prompt template from the %q MCP server. Available prompts:", serverName)
+	for _, p := range sorted {
+		args := make([]string, 0, len(p.Arguments))
+		for _, a := range p.Arguments {
+			if a.Required {
+				args = append(args, a.Name+"*")
+			} else {
+				args = append(args, a.Name)
+			}
+		}
+		fmt.Fprintf(&b, "\n- %s(%s)", p.Name, strings.Join(args, ", "))
+		if p.Description != "" {
+			fmt.Fprintf(&b, ": %s", p.Description)
+		}
+	}
+	return &MCPPromptTool{
+		name:   mcpToolSlug(serverName) + "_get_prompt",
+		desc:   b.String(),
+		server: server,
+		pool:   pool,
+	}
+}
+
+// Info returns the tool metadata.
+func (t *MCPPromptTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: t.name,
+		Desc: t.desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name":      {Type: schema.String, Desc: "Name of the prompt.", Required: true},
+			"arguments": {Type: schema.Object, Desc: "Prompt arguments as an object of strings."},
+		}),
+	}, nil
+}
+
+// InvokableRun renders the prompt and returns its messages as text,
+// one "[role]" block per message.
+func (t *MCPPromptTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	var args struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("%s: parse arguments: %w", t.name, err)
+	}
+	if strings.TrimSpace(args.Name) == "" {
+		return "", fmt.Errorf("%s: name is required", t.name)
+	}
+	promptArgs := make(map[string]string, len(args.Arguments))
+	for k, v := range args.Arguments {
+		if s, ok := v.(string); ok {
+			promptArgs[k] = s
+		} else {
+			promptArgs[k] = openAPIScalar(v)
+		}
+	}
+	session, err := t.pool.Get(ctx, t.server)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", t.name, err)
+	}
+	res, err := session.GetPrompt(ctx, args.Name, promptArgs)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", t.name, err)
+	}
+	return renderMCPPrompt(res), nil
+}
+
+// renderMCPPrompt flattens a prompt result to text. Embedded resources
+// contribute their text; other non-text blocks are noted by type.
+func renderMCPPrompt(res *mcpclient.PromptResult) string {
+	blocks := make([]string, 0, len(res.Messages)+1)
+	if res.Description != "" {
+		blocks = append(blocks, res.Description)
+	}
+	for _, m := range res.Messages {
+		text := m.Text
+		if text == "" {
+			kind, _ := m.Content["type"].(string)
+			if resource, ok := m.Content["resource"].(map[string]any); ok {
+				text, _ = resource["text"].(string)
+			}
+			if text == "" {
+				text = fmt.Sprintf("[%s content]", firstNonEmpty(kind, "unknown"))
+			}
+		}
+		blocks = append(blocks, fmt.Sprintf("[%s]\n%s", firstNonEmpty(m.Role, "user"), text))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,291 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ragflow/internal/agent/runtime"
+	mcpclient "ragflow/internal/utility"
+)
+
+// pipeMCPServer is an in-memory stdio MCP server answering each request
+// with handle's result. Returning a notification method from handle's
+// second value sends it before the response.
+type pipeMCPServer struct {
+	inR, outR *io.PipeReader
+	inW, outW *io.PipeWriter
+	mu        sync.Mutex
+	handle    func(method string, params map[string]any) (any, string)
+}
+
+func (s *pipeMCPServer) Stdin() io.Writer  { return s.inW }
+func (s *pipeMCPServer) Stdout() io.Reader { return s.outR }
+func (s *pipeMCPServer) Stderr() string    { return "" }
+func (s *pipeMCPServer) Close() error {
+	s.inW.Close()
+	return s.outW.Close()
+}
+
+func (s *pipeMCPServer) write(msg map[string]any) {
+	body, _ := json.Marshal(msg)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.outW.Write(append(body, '\n'))
+}
+
+func (s *pipeMCPServer) serve() {
+	sc := bufio.NewScanner(s.inR)
+	for sc.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params map[string]any  `json:"params"`
+		}
+		if json.Unmarshal(sc.Bytes(), &req) != nil || req.ID == nil || req.Method == "" {
+			continue
+		}
+		result, notify := s.handle(req.Method, req.Params)
+		if notify != "" {
+			s.write(map[string]any{"jsonrpc": "2.0", "method": notify})
+		}
+		s.write(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}
+}
+
+// filesServer is the MCP server behind the test's "srv1" record.
+func filesServer() func(string, map[string]any) (any, string) {
+	var mu sync.Mutex
+	tools := []any{
+		map[string]any{"name": "search", "description": "Search files"},
+		map[string]any{"name": "secret", "description": "Disabled by the user"},
+	}
+	return func(method string, params map[string]any) (any, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "initialize":
+			return map[string]any{"capabilities": map[string]any{"tools": map[string]any{}, "resources": map[string]any{}, "prompts": map[string]any{}}}, ""
+		case "tools/list":
+			return map[string]any{"tools": tools}, ""
+		case "tools/call":
+			if params["name"] == "rename" {
+				tools = []any{map[string]any{"name": "search"}, map[string]any{"name": "grep"}}
+				return map[string]any{"content": []any{}}, "notifications/tools/list_changed"
+			}
+			raw, _ := json.Marshal(params["arguments"])
+			return map[string]any{"content": []any{map[string]any{"type": "text", "text": "search " + string(raw)}}}, ""
+		case "resources/list":
+			return map[string]any{"resources": []any{map[string]any{"uri": "file:///readme.md", "name": "readme"}}}, ""
+		case "resources/templates/list":
+			return map[string]any{"resourceTemplates": []any{}}, ""
+		case "resources/read":
+			return map[string]any{"contents": []any{
+				map[string]any{"uri": params["uri"], "text": "# Readme"},
+				map[string]any{"uri": params["uri"], "mimeType": "image/png", "blob": "aGVsbG8="},
+			}}, ""
+		case "prompts/list":
+			return map[string]any{"prompts": []any{map[string]any{"name": "review", "arguments": []any{map[string]any{"name": "code", "required": true}}}}}, ""
+		case "prompts/get":
+			args, _ := params["arguments"].(map[string]any)
+			return map[string]any{"messages": []any{
+				map[string]any{"role": "user", "content": map[string]any{"type": "text", "text": "Review " + args["code"].(string)}},
+			}}, ""
+		}
+		return map[string]any{}, ""
+	}
+}
+
+// useMCPTestServer points the resolver at the "srv1" record backed by
+// filesServer, with a fresh session pool, and returns the tool lists
+// the resolver persisted.
+func useMCPTestServer(t *testing.T) <-chan []mcpclient.Tool {
+	t.Helper()
+	mcpclient.SetStdioLauncher(func(_ context.Context, _ mcpclient.StdioServer) (mcpclient.StdioProcess, error) {
+		s := &pipeMCPServer{handle: filesServer()}
+		s.inR, s.inW = io.Pipe()
+		s.outR, s.outW = io.Pipe()
+		go s.serve()
+		return s, nil
+	})
+	SetMCPServerLoader(func(_ context.Context, tenantID, serverID string) (*MCPServerRecord, error) {
+		if tenantID != "tenant-1" || serverID != "srv1" {
+			return nil, nil
+		}
+		return &MCPServerRecord{
+			ID:         "srv1",
+			Name:       "Files Server",
+			ServerType: mcpclient.TransportStdio,
+			Variables: map[string]any{
+				"command": "mcp-files",
+				"tools":   map[string]any{"secret": map[string]any{"name": "secret", "enabled": false}},
+			},
+		}, nil
+	})
+	saved := make(chan []mcpclient.Tool, 1)
+	prevPool, prevSaver := mcpSessions, mcpToolsSaver
+	mcpSessions = mcpclient.NewSessionPool(time.Minute)
+	mcpToolsSaver = func(tenantID, serverID string, tools []mcpclient.Tool) {
+		if tenantID == "tenant-1" && serverID == "srv1" {
+			saved <- tools
+		}
+	}
+	t.Cleanup(func() {
+		mcpSessions.Close()
+		mcpSessions, mcpToolsSaver = prevPool, prevSaver
+		SetMCPServerLoader(nil)
+		mcpclient.SetStdioLauncher(nil)
+	})
+	return saved
+}
+
+func tenantContext() context.Context {
+	state := runtime.NewCanvasState("run", "task")
+	state.Sys["tenant_id"] = "tenant-1"
+	return runtime.WithState(context.Background(), state)
+}
+
+func TestBuildAllContext_ResolvesMCPServer(t *testing.T) {
+	useMCPTestServer(t)
+	ctx := tenantContext()
+
+	tools, err := BuildAllContext(ctx, []string{"mcp:srv1", "mcp:srv1:search"}, nil)
+	if err != nil {
+		t.Fatalf("BuildAllContext: %v", err)
+	}
+	var names []string
+	for _, tl := range tools {
+		info, _ := tl.Info(ctx)
+		names = append(names, info.Name)
+	}
+	// The disabled tool is left out and search is not duplicated.
+	if got := strings.Join(names, ","); got != "search,files_server_read_resource,files_server_get_prompt" {
+		t.Fatalf("tools = %s", got)
+	}
+
+	out, err := tools[0].(*MCPToolAdapter).InvokableRun(ctx, `{"q":"readme"}`)
+	if err != nil || out != `search {"q":"readme"}` {
+		t.Fatalf("search: out=%q err=%v", out, err)
+	}
+
+	info, _ := tools[1].Info(ctx)
+	if !strings.Contains(info.Desc, "file:///readme.md: readme") {
+		t.Errorf("resource tool description lacks the resource list: %q", info.Desc)
+	}
+	out, err = tools[1].(*MCPResourceTool).InvokableRun(ctx, `{"uri":"file:///readme.md"}`)
+	if err != nil {
+		t.Fatalf("read resource: %v", err)
+	}
+	if !strings.HasPrefix(out, "# Readme\n\n[binary resource file:///readme.md (image/png)") {
+		t.Errorf("read resource out = %q", out)
+	}
+	if _, err := tools[1].(*MCPResourceTool).InvokableRun(ctx, `{}`); err == nil {
+		t.Error("read resource without uri: expected an error")
+	}
+
+	info, _ = tools[2].Info(ctx)
+	if !strings.Contains(info.Desc, "review(code*)") {
+		t.Errorf("prompt tool description = %q", info.Desc)
+	}
+	out, err = tools[2].(*MCPPromptTool).InvokableRun(ctx, `{"name":"review","arguments":{"code":"x := 1"}}`)
+	if err != nil || out != "[user]\nReview x := 1" {
+		t.Fatalf("get prompt: out=%q err=%v", out, err)
+	}
+
+	// Naming a disabled tool explicitly still builds it.
+	tools, err = BuildAllContext(ctx, []string{"mcp:srv1:secret"}, nil)
+	if err != nil || len(tools) != 1 {
+		t.Fatalf("explicit disabled tool: tools=%d err=%v", len(tools), err)
+	}
+
+	if _, err := BuildAllContext(ctx, []string{"mcp:srv1:nope"}, nil); err == nil || !strings.Contains(err.Error(), "no tool") {
+		t.Fatalf("unknown tool err = %v", err)
+	}
+	if _, err := BuildAllContext(ctx, []string{"mcp:missing"}, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("missing server err = %v", err)
+	}
+	if _, err := BuildAllContext(context.Background(), []string{"mcp:srv1"}, nil); err == nil {
+		t.Fatal("no tenant: expected an error")
+	}
+}
+
+// TestBuildAllContext_MCPToolsChangedPersisted: a tool-list change the
+// server announces is handed to the saver.
+func TestBuildAllContext_MCPToolsChangedPersisted(t *testing.T) {
+	saved := useMCPTestServer(t)
+	ctx := tenantContext()
+
+	if _, err := BuildAllContext(ctx, []string{"mcp:srv1"}, nil); err != nil {
+		t.Fatalf("BuildAllContext: %v", err)
+	}
+	rec, _ := mcpServerLoader(ctx, "tenant-1", "srv1")
+	opts, err := MCPConnectOptions(rec)
+	if err != nil {
+		t.Fatalf("MCPConnectOptions: %v", err)
+	}
+	// Same options, so the call lands on the session the resolver
+	// subscribed to.
+	adapter := NewPooledMCPToolAdapter(mcpclient.Tool{Name: "rename"}, opts, mcpSessions)
+	if _, err := adapter.InvokableRun(ctx, `{}`); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	select {
+	case tools := <-saved:
+		if len(tools) != 2 || tools[1].Name != "grep" {
+			t.Fatalf("saved tools = %+v", tools)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("changed tool list was not saved")
+	}
+}
+
+func TestMergeMCPToolList_KeepsEnabledFlag(t *testing.T) {
+	previous := map[string]any{
+		"search": map[string]any{"name": "search", "enabled": false},
+		"gone":   map[string]any{"name": "gone", "enabled": false},
+	}
+	merged := mergeMCPToolList(previous, []mcpclient.Tool{{Name: "search"}, {Name: "grep", Description: "Grep files"}})
+	if len(merged) != 2 {
+		t.Fatalf("merged = %+v", merged)
+	}
+	if merged["search"].(map[string]any)["enabled"] != false {
+		t.Errorf("search lost its enabled flag: %+v", merged["search"])
+	}
+	if _, ok := merged["grep"].(map[string]any)["enabled"]; ok {
+		t.Errorf("new tool should carry no flag: %+v", merged["grep"])
+	}
+}
+
+func TestMCPToolSlug(t *testing.T) {
+	for in, want := range map[string]string{
+		"Files Server":          "files_server",
+		"  GitHub (v2)":         "github_v2",
+		"日本語":                   "mcp",
+		strings.Repeat("a", 60): strings.Repeat("a", mcpToolNameMaxLen),
+	} {
+		if got := mcpToolSlug(in); got != want {
+			t.Errorf("mcpToolSlug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
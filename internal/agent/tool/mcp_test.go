@@ -19,6 +19,7 @@ package tool
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -167,7 +168,8 @@ func TestMCPToolAdapter_InvokableRunDispatchesCallTool(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		body, _ := io.ReadAll(r.Body)
 		var req struct {
-			Method string `json:"method"`
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
 		}
 		_ = json.Unmarshal(body, &req)
 		w.Header().Set("Content-Type", "application/json")
@@ -177,7 +179,7 @@ func TestMCPToolAdapter_InvokableRunDispatchesCallTool(t *testing.T) {
 			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":0,"result":{}}`))
 		case "tools/call":
 			sawCall = true
-			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"ok from mcp"}],"isError":false}}`))
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"content":[{"type":"text","text":"ok from mcp"}],"isError":false}}`, req.ID)
 		default:
 			w.WriteHeader(http.StatusAccepted)
 		}
@@ -204,7 +206,8 @@ func TestMCPToolAdapter_InvokableRunIsError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		body, _ := io.ReadAll(r.Body)
 		var req struct {
-			Method string `json:"method"`
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
 		}
 		_ = json.Unmarshal(body, &req)
 		w.Header().Set("Content-Type", "application/json")
@@ -212,7 +215,7 @@ func TestMCPToolAdapter_InvokableRunIsError(t *testing.T) {
 		case "initialize":
 			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":0,"result":{}}`))
 		case "tools/call":
-			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"bad input"}],"isError":true}}`))
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"content":[{"type":"text","text":"bad input"}],"isError":true}}`, req.ID)
 		default:
 			w.WriteHeader(http.StatusAccepted)
 		}
@@ -0,0 +1,516 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package tool — OpenAPI operation tools.
+//
+// A tenant registers an OpenAPI 3 document (see service/openapi.go);
+// each of its operations becomes an eino InvokableTool whose parameter
+// schema is derived from the operation's parameters and request body.
+// Agent DSLs reference them by name:
+//
+//	openapi:<spec_id>                 every operation in the spec
+//	openapi:<spec_id>:<operation_id>  a single operation
+//
+// The names are tenant-scoped, so they are resolved by BuildAllContext
+// (which reads the tenant from the canvas state) rather than the static
+// registry.
+package tool
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	einotool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"ragflow/internal/dao"
+)
+
+// OpenAPIToolPrefix marks an Agent tool name as a reference to a
+// tenant-registered OpenAPI spec.
+const OpenAPIToolPrefix = "openapi:"
+
+// openAPIBodyArg is the argument that carries the request body. Path,
+// query, header and cookie parameters are top-level arguments named
+// after the parameter.
+const openAPIBodyArg = "body"
+
+// openAPIMaxResponseBytes caps how much of an upstream response is
+// handed back to the model.
+const openAPIMaxResponseBytes = 1 << 20
+
+// Credential types accepted in OpenAPICredentials.Type.
+const (
+	OpenAPIAuthNone   = "none"
+	OpenAPIAuthBearer = "bearer"
+	OpenAPIAuthBasic  = "basic"
+	OpenAPIAuthAPIKey = "api_key"
+)
+
+// OpenAPICredentials is the per-spec authentication a tenant stores
+// alongside the document. Only the fields relevant to Type are used;
+// Headers are sent with every request regardless of Type.
+type OpenAPICredentials struct {
+	Type     string            `json:"type"`
+	Token    string            `json:"token,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	In       string            `json:"in,omitempty"`
+	Value    string            `json:"value,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+// DecodeOpenAPICredentials validates the stored credentials map. A nil
+// or empty map means no authentication.
+func DecodeOpenAPICredentials(m map[string]any) (OpenAPICredentials, error) {
+	var c OpenAPICredentials
+	if len(m) == 0 {
+		c.Type = OpenAPIAuthNone
+		return c, nil
+	}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return c, fmt.Errorf("openapi: encode credentials: %w", err)
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, fmt.Errorf("openapi: decode credentials: %w", err)
+	}
+	c.Type = strings.ToLower(strings.TrimSpace(c.Type))
+	switch c.Type {
+	case "", OpenAPIAuthNone:
+		c.Type = OpenAPIAuthNone
+	case OpenAPIAuthBearer:
+		if c.Token == "" {
+			return c, errors.New("openapi: bearer credentials require a token")
+		}
+	case OpenAPIAuthBasic:
+		if c.Username == "" {
+			return c, errors.New("openapi: basic credentials require a username")
+		}
+	case OpenAPIAuthAPIKey:
+		if c.Name == "" || c.Value == "" {
+			return c, errors.New("openapi: api_key credentials require a name and a value")
+		}
+		c.In = strings.ToLower(c.In)
+		if c.In == "" {
+			c.In = "header"
+		}
+		if c.In != "header" && c.In != "query" && c.In != "cookie" {
+			return c, fmt.Errorf("openapi: api_key location %q must be header, query or cookie", c.In)
+		}
+	default:
+		return c, fmt.Errorf("openapi: unsupported credential type %q", c.Type)
+	}
+	return c, nil
+}
+
+// OpenAPIOperationTool exposes one OpenAPI operation to the Agent.
+type OpenAPIOperationTool struct {
+	op      *OpenAPIOperation
+	baseURL string
+	creds   OpenAPICredentials
+	helper  *HTTPHelper
+	// resolve performs the SSRF check and supplies the pinned IP; see
+	// CrawlerTool.resolve for why it is a field.
+	resolve Resolver
+}
+
+// NewOpenAPIOperationTool builds a tool for op that sends requests to
+// baseURL with creds applied.
+func NewOpenAPIOperationTool(op *OpenAPIOperation, baseURL string, creds OpenAPICredentials) *OpenAPIOperationTool {
+	return &OpenAPIOperationTool{
+		op:      op,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		creds:   creds,
+		helper:  newOpenAPIHelper(op.Method),
+		resolve: ResolveAndValidate,
+	}
+}
+
+// newOpenAPIHelper disables retries for methods that are not idempotent
+// so a 5xx on a POST never replays a side effect.
+func newOpenAPIHelper(method string) *HTTPHelper {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return NewHTTPHelper()
+	default:
+		return NewHTTPHelperWithRetry(RetryConfig{MaxAttempts: 1})
+	}
+}
+
+// WithResolver replaces the SSRF resolver. Returns the same receiver.
+func (t *OpenAPIOperationTool) WithResolver(fn Resolver) *OpenAPIOperationTool {
+	if fn != nil {
+		t.resolve = fn
+	}
+	return t
+}
+
+// Operation returns the wrapped operation.
+func (t *OpenAPIOperationTool) Operation() *OpenAPIOperation { return t.op }
+
+// Info derives the tool schema from the operation's parameters and
+// request body.
+func (t *OpenAPIOperationTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name:        t.op.ID,
+		Desc:        t.description(),
+		ParamsOneOf: schema.NewParamsOneOfByParams(t.params()),
+	}, nil
+}
+
+// params maps each parameter to a top-level argument and the request
+// body to the "body" argument.
+func (t *OpenAPIOperationTool) params() map[string]*schema.ParameterInfo {
+	params := make(map[string]*schema.ParameterInfo, len(t.op.Parameters)+1)
+	for _, p := range t.op.Parameters {
+		desc := p.Description
+		if desc == "" {
+			desc = fmt.Sprintf("%s parameter %s", p.In, p.Name)
+		}
+		params[p.Name] = openAPIParamInfo(p.Schema, desc, p.Required)
+	}
+	if t.op.BodySchema != nil {
+		params[openAPIBodyArg] = openAPIParamInfo(t.op.BodySchema, "Request body ("+t.op.BodyContentType+").", t.op.BodyRequired)
+	}
+	return params
+}
+
+func (t *OpenAPIOperationTool) description() string {
+	parts := make([]string, 0, 3)
+	if t.op.Summary != "" {
+		parts = append(parts, t.op.Summary)
+	}
+	if t.op.Description != "" && t.op.Description != t.op.Summary {
+		parts = append(parts, t.op.Description)
+	}
+	parts = append(parts, fmt.Sprintf("(%s %s)", t.op.Method, t.op.Path))
+	return strings.Join(parts, " ")
+}
+
+// openAPIResult is the JSON shape returned to the model. Body is the
+// decoded JSON response when the upstream returned JSON, otherwise the
+// raw text.
+type openAPIResult struct {
+	Status    int    `json:"status"`
+	Body      any    `json:"body,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// InvokableRun sends the request. Upstream 4xx responses are returned
+// to the model as a result (with the status and body) rather than as a
+// Go error, so the model can correct its arguments and retry; 5xx,
+// transport and validation failures are errors.
+func (t *OpenAPIOperationTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...einotool.Option) (string, error) {
+	args := map[string]any{}
+	if strings.TrimSpace(argumentsInJSON) != "" {
+		if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+			return openAPIJSON(openAPIResult{Error: "invalid JSON: " + err.Error()}),
+				fmt.Errorf("openapi %s: parse arguments: %w", t.op.ID, err)
+		}
+	}
+
+	reqURL, headers, body, contentType, err := t.buildRequest(args)
+	if err != nil {
+		return openAPIJSON(openAPIResult{Error: err.Error()}), err
+	}
+
+	host, pinnedIP, err := t.resolve(reqURL)
+	if err != nil {
+		return openAPIJSON(openAPIResult{Error: err.Error()}), fmt.Errorf("openapi %s: %w", t.op.ID, err)
+	}
+	resp, err := t.helper.DoPinned(ctx, t.op.Method, reqURL, body, contentType, headers, host, pinnedIP)
+	if err != nil {
+		return openAPIJSON(openAPIResult{Error: err.Error()}), fmt.Errorf("openapi %s: %w", t.op.ID, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, openAPIMaxResponseBytes+1))
+	if err != nil {
+		return openAPIJSON(openAPIResult{Status: resp.StatusCode, Error: "read body: " + err.Error()}),
+			fmt.Errorf("openapi %s: read body: %w", t.op.ID, err)
+	}
+	out := openAPIResult{Status: resp.StatusCode}
+	if len(raw) > openAPIMaxResponseBytes {
+		raw = raw[:openAPIMaxResponseBytes]
+		out.Truncated = true
+	}
+	var decoded any
+	if !out.Truncated && len(raw) > 0 && json.Unmarshal(raw, &decoded) == nil {
+		out.Body = decoded
+	} else if len(raw) > 0 {
+		out.Body = string(raw)
+	}
+	if resp.StatusCode >= 400 {
+		out.Error = fmt.Sprintf("upstream returned %d", resp.StatusCode)
+	}
+	return openAPIJSON(out), nil
+}
+
+// buildRequest maps the model's arguments onto the operation's URL,
+// headers and body, then applies the stored credentials.
+func (t *OpenAPIOperationTool) buildRequest(args map[string]any) (string, map[string]string, string, string, error) {
+	if t.baseURL == "" {
+		return "", nil, "", "", fmt.Errorf("openapi %s: spec has no base URL", t.op.ID)
+	}
+	path := t.op.Path
+	query := url.Values{}
+	headers := map[string]string{}
+	var cookies []string
+
+	for _, p := range t.op.Parameters {
+		v, ok := args[p.Name]
+		if !ok || v == nil {
+			if p.Required {
+				return "", nil, "", "", fmt.Errorf("openapi %s: missing required parameter %q", t.op.ID, p.Name)
+			}
+			continue
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(openAPIScalar(v)))
+		case "query":
+			if list, ok := v.([]any); ok {
+				for _, item := range list {
+					query.Add(p.Name, openAPIScalar(item))
+				}
+			} else {
+				query.Set(p.Name, openAPIScalar(v))
+			}
+		case "header":
+			headers[p.Name] = openAPIScalar(v)
+		case "cookie":
+			cookies = append(cookies, p.Name+"="+url.QueryEscape(openAPIScalar(v)))
+		}
+	}
+
+	var body, contentType string
+	if t.op.BodySchema != nil {
+		v, ok := args[openAPIBodyArg]
+		if !ok || v == nil {
+			if t.op.BodyRequired {
+				return "", nil, "", "", fmt.Errorf("openapi %s: missing required request body", t.op.ID)
+			}
+		} else if t.op.BodyContentType == "application/x-www-form-urlencoded" {
+			fields, ok := v.(map[string]any)
+			if !ok {
+				return "", nil, "", "", fmt.Errorf("openapi %s: form body must be an object", t.op.ID)
+			}
+			form := url.Values{}
+			for k, fv := range fields {
+				form.Set(k, openAPIScalar(fv))
+			}
+			body, contentType = form.Encode(), t.op.BodyContentType
+		} else {
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return "", nil, "", "", fmt.Errorf("openapi %s: encode body: %w", t.op.ID, err)
+			}
+			body, contentType = string(raw), t.op.BodyContentType
+		}
+	}
+
+	for k, v := range t.creds.Headers {
+		headers[k] = v
+	}
+	switch t.creds.Type {
+	case OpenAPIAuthBearer:
+		headers["Authorization"] = "Bearer " + t.creds.Token
+	case OpenAPIAuthBasic:
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(t.creds.Username+":"+t.creds.Password))
+	case OpenAPIAuthAPIKey:
+		switch t.creds.In {
+		case "query":
+			query.Set(t.creds.Name, t.creds.Value)
+		case "cookie":
+			cookies = append(cookies, t.creds.Name+"="+url.QueryEscape(t.creds.Value))
+		default:
+			headers[t.creds.Name] = t.creds.Value
+		}
+	}
+	if len(cookies) > 0 {
+		headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+
+	reqURL := t.baseURL + path
+	if len(query) > 0 {
+		sep := "?"
+		if strings.Contains(reqURL, "?") {
+			sep = "&"
+		}
+		reqURL += sep + query.Encode()
+	}
+	return reqURL, headers, body, contentType, nil
+}
+
+// openAPIScalar renders a parameter value for a URL or header. JSON
+// numbers arrive as float64; integral values are printed without a
+// fractional part.
+func openAPIScalar(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		if x == float64(int64(x)) {
+			return fmt.Sprintf("%d", int64(x))
+		}
+		return fmt.Sprint(x)
+	case map[string]any, []any:
+		raw, _ := json.Marshal(x)
+		return string(raw)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func openAPIJSON(r openAPIResult) string {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Sprintf(`{"error":%q}`, err.Error())
+	}
+	return string(raw)
+}
+
+// OpenAPISpecRecord is the stored form of a tenant spec that the tool
+// resolver needs.
+type OpenAPISpecRecord struct {
+	ID          string
+	BaseURL     string
+	Spec        string
+	Credentials map[string]any
+}
+
+// OpenAPISpecLoader loads a tenant's spec by ID. It returns (nil, nil)
+// when the spec does not exist.
+type OpenAPISpecLoader func(ctx context.Context, tenantID, specID string) (*OpenAPISpecRecord, error)
+
+var openAPISpecLoader OpenAPISpecLoader = loadOpenAPISpecFromDAO
+
+// SetOpenAPISpecLoader replaces the spec loader. Passing nil restores
+// the DAO-backed default. Intended for tests.
+func SetOpenAPISpecLoader(fn OpenAPISpecLoader) {
+	if fn == nil {
+		fn = loadOpenAPISpecFromDAO
+	}
+	openAPISpecLoader = fn
+}
+
+func loadOpenAPISpecFromDAO(_ context.Context, tenantID, specID string) (*OpenAPISpecRecord, error) {
+	spec, err := dao.NewOpenAPISpecDAO().GetByIDAndTenant(specID, tenantID)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	return &OpenAPISpecRecord{
+		ID:          spec.ID,
+		BaseURL:     spec.BaseURL,
+		Spec:        spec.Spec,
+		Credentials: spec.Credentials,
+	}, nil
+}
+
+// IsOpenAPIToolName reports whether name references a registered spec.
+func IsOpenAPIToolName(name string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(name)), OpenAPIToolPrefix)
+}
+
+// OpenAPIToolName returns the Agent tool name for one operation of a
+// spec, or for the whole spec when operationID is empty.
+func OpenAPIToolName(specID, operationID string) string {
+	if operationID == "" {
+		return OpenAPIToolPrefix + specID
+	}
+	return OpenAPIToolPrefix + specID + ":" + operationID
+}
+
+// BuildOpenAPITools builds the tools for each operation of spec. When
+// operationID is non-empty only that operation is built.
+func BuildOpenAPITools(spec *OpenAPISpecRecord, operationID string) ([]*OpenAPIOperationTool, error) {
+	doc, err := ParseOpenAPISpec([]byte(spec.Spec))
+	if err != nil {
+		return nil, err
+	}
+	creds, err := DecodeOpenAPICredentials(spec.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := spec.BaseURL
+	if baseURL == "" {
+		baseURL = doc.ServerURL
+	}
+	ops := doc.Operations
+	if operationID != "" {
+		op := doc.Operation(operationID)
+		if op == nil {
+			return nil, fmt.Errorf("openapi: spec %s has no operation %q", spec.ID, operationID)
+		}
+		ops = []*OpenAPIOperation{op}
+	}
+	out := make([]*OpenAPIOperationTool, 0, len(ops))
+	for _, op := range ops {
+		out = append(out, NewOpenAPIOperationTool(op, baseURL, creds))
+	}
+	return out, nil
+}
+
+// buildOpenAPIToolsByName builds the operation tools behind "openapi:"
+// tool names for tenantID. Each spec is loaded once.
+func buildOpenAPIToolsByName(ctx context.Context, tenantID string, names []string) ([]einotool.BaseTool, error) {
+	var tools []einotool.BaseTool
+	specs := map[string]*OpenAPISpecRecord{}
+	built := map[string]bool{}
+	for _, name := range names {
+		specID, opID, _ := strings.Cut(name[len(OpenAPIToolPrefix):], ":")
+		if specID == "" {
+			return nil, fmt.Errorf("agent tool: invalid openapi tool name %q", name)
+		}
+		spec, ok := specs[specID]
+		if !ok {
+			var err error
+			spec, err = openAPISpecLoader(ctx, tenantID, specID)
+			if err != nil {
+				return nil, fmt.Errorf("agent tool: load openapi spec %s: %w", specID, err)
+			}
+			if spec == nil {
+				return nil, fmt.Errorf("agent tool: openapi spec %s not found", specID)
+			}
+			specs[specID] = spec
+		}
+		opTools, err := BuildOpenAPITools(spec, opID)
+		if err != nil {
+			return nil, fmt.Errorf("agent tool: %q: %w", name, err)
+		}
+		// "openapi:<spec>" and "openapi:<spec>:<op>" may both be listed;
+		// each operation is only added once.
+		for _, t := range opTools {
+			key := specID + ":" + t.op.ID
+			if built[key] {
+				continue
+			}
+			built[key] = true
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
@@ -0,0 +1,544 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// maxOpenAPIRefDepth bounds $ref expansion so a self-referencing schema
+// (a tree node whose children are tree nodes) terminates. Anything
+// deeper is surfaced to the model as a free-form object.
+const maxOpenAPIRefDepth = 8
+
+// openAPIMethods is the set of HTTP methods an OpenAPI 3 path item may
+// declare, in the order operations are listed.
+var openAPIMethods = []string{"get", "put", "post", "delete", "patch", "head", "options"}
+
+var openAPIToolNameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+
+// OpenAPIDocument is the subset of an OpenAPI 3 document the agent
+// tools need: a default server and the flattened operation list.
+type OpenAPIDocument struct {
+	Title       string
+	Version     string
+	Description string
+	// ServerURL is servers[0].url, used when the spec record carries
+	// no explicit base URL.
+	ServerURL  string
+	Operations []*OpenAPIOperation
+}
+
+// OpenAPIOperation is a single method + path pair with its $ref-resolved
+// parameters and request body.
+type OpenAPIOperation struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Parameters  []OpenAPIParameter
+	// BodySchema is the resolved request-body schema, nil when the
+	// operation takes no body. BodyContentType is the media type the
+	// body is sent as (application/json or form-urlencoded).
+	BodySchema      map[string]any
+	BodyContentType string
+	BodyRequired    bool
+}
+
+// OpenAPIParameter is a path, query, header or cookie parameter.
+type OpenAPIParameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Schema      map[string]any
+}
+
+// ParseOpenAPISpec parses a JSON or YAML OpenAPI 3 document and derives
+// its operations. Only local references ("#/components/...") are
+// resolved; remote references are rejected rather than fetched so that
+// registering a spec never triggers outbound requests.
+func ParseOpenAPISpec(data []byte) (*OpenAPIDocument, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, errors.New("openapi: empty document")
+	}
+	var root map[string]any
+	// YAML is a superset of JSON, so one decoder handles both forms.
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("openapi: parse document: %w", err)
+	}
+	if root == nil {
+		return nil, errors.New("openapi: document is not an object")
+	}
+	version, _ := root["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported version %q, only OpenAPI 3.x is supported", version)
+	}
+
+	r := &openAPIResolver{root: root}
+	doc := &OpenAPIDocument{}
+	if info, ok := root["info"].(map[string]any); ok {
+		doc.Title, _ = info["title"].(string)
+		doc.Version, _ = info["version"].(string)
+		doc.Description, _ = info["description"].(string)
+	}
+	if servers, ok := root["servers"].([]any); ok && len(servers) > 0 {
+		if s, ok := servers[0].(map[string]any); ok {
+			doc.ServerURL = expandServerVariables(s)
+		}
+	}
+
+	paths, _ := root["paths"].(map[string]any)
+	pathKeys := make([]string, 0, len(paths))
+	for p := range paths {
+		pathKeys = append(pathKeys, p)
+	}
+	sort.Strings(pathKeys)
+
+	seen := make(map[string]int)
+	for _, p := range pathKeys {
+		item, err := r.object(paths[p], 0)
+		if err != nil {
+			return nil, fmt.Errorf("openapi: path %s: %w", p, err)
+		}
+		shared, err := r.parameters(item["parameters"])
+		if err != nil {
+			return nil, fmt.Errorf("openapi: path %s: %w", p, err)
+		}
+		for _, method := range openAPIMethods {
+			raw, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			op, err := r.operation(method, p, raw, shared)
+			if err != nil {
+				return nil, fmt.Errorf("openapi: %s %s: %w", strings.ToUpper(method), p, err)
+			}
+			// Tool names must be unique within a spec; suffix duplicates
+			// (operationId is only unique by convention).
+			if n := seen[op.ID]; n > 0 {
+				seen[op.ID] = n + 1
+				op.ID = fmt.Sprintf("%s_%d", op.ID, n+1)
+			} else {
+				seen[op.ID] = 1
+			}
+			doc.Operations = append(doc.Operations, op)
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, errors.New("openapi: document declares no operations")
+	}
+	return doc, nil
+}
+
+// Operation returns the operation with the given ID, or nil.
+func (d *OpenAPIDocument) Operation(id string) *OpenAPIOperation {
+	for _, op := range d.Operations {
+		if op.ID == id {
+			return op
+		}
+	}
+	return nil
+}
+
+// expandServerVariables substitutes each {variable} in a server URL
+// with its declared default.
+func expandServerVariables(server map[string]any) string {
+	u, _ := server["url"].(string)
+	vars, _ := server["variables"].(map[string]any)
+	for name, v := range vars {
+		def, _ := v.(map[string]any)
+		if val, ok := def["default"]; ok {
+			u = strings.ReplaceAll(u, "{"+name+"}", fmt.Sprint(val))
+		}
+	}
+	return u
+}
+
+// openAPIOperationID derives a tool-safe operation name. The declared
+// operationId wins; otherwise the name is built from method and path
+// ("get_pets_petId").
+func openAPIOperationID(method, path string, raw map[string]any) string {
+	id, _ := raw["operationId"].(string)
+	if id == "" {
+		id = method + "_" + path
+	}
+	id = strings.Trim(openAPIToolNameUnsafe.ReplaceAllString(id, "_"), "_")
+	if id == "" {
+		id = method
+	}
+	return id
+}
+
+type openAPIResolver struct {
+	root map[string]any
+}
+
+func (r *openAPIResolver) operation(method, path string, raw map[string]any, shared []OpenAPIParameter) (*OpenAPIOperation, error) {
+	op := &OpenAPIOperation{
+		ID:     openAPIOperationID(method, path, raw),
+		Method: strings.ToUpper(method),
+		Path:   path,
+	}
+	op.Summary, _ = raw["summary"].(string)
+	op.Description, _ = raw["description"].(string)
+
+	own, err := r.parameters(raw["parameters"])
+	if err != nil {
+		return nil, err
+	}
+	// Operation-level parameters override path-level ones with the same
+	// name and location.
+	merged := make([]OpenAPIParameter, 0, len(shared)+len(own))
+	for _, sp := range shared {
+		overridden := false
+		for _, p := range own {
+			if p.Name == sp.Name && p.In == sp.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, sp)
+		}
+	}
+	op.Parameters = append(merged, own...)
+
+	if rb, ok := raw["requestBody"]; ok {
+		body, err := r.object(rb, 0)
+		if err != nil {
+			return nil, fmt.Errorf("requestBody: %w", err)
+		}
+		op.BodyRequired, _ = body["required"].(bool)
+		content, _ := body["content"].(map[string]any)
+		for _, ct := range []string{"application/json", "application/x-www-form-urlencoded"} {
+			media, ok := content[ct].(map[string]any)
+			if !ok {
+				continue
+			}
+			s, err := r.schema(media["schema"], 0)
+			if err != nil {
+				return nil, fmt.Errorf("requestBody: %w", err)
+			}
+			op.BodySchema = s
+			op.BodyContentType = ct
+			break
+		}
+		if op.BodySchema == nil {
+			// Any other JSON-flavoured media type ("application/vnd.x+json")
+			// is still sent as JSON.
+			keys := make([]string, 0, len(content))
+			for ct := range content {
+				keys = append(keys, ct)
+			}
+			sort.Strings(keys)
+			for _, ct := range keys {
+				if !strings.HasSuffix(ct, "+json") {
+					continue
+				}
+				media, _ := content[ct].(map[string]any)
+				s, err := r.schema(media["schema"], 0)
+				if err != nil {
+					return nil, fmt.Errorf("requestBody: %w", err)
+				}
+				op.BodySchema = s
+				op.BodyContentType = ct
+				break
+			}
+		}
+	}
+	return op, nil
+}
+
+func (r *openAPIResolver) parameters(raw any) ([]OpenAPIParameter, error) {
+	list, _ := raw.([]any)
+	out := make([]OpenAPIParameter, 0, len(list))
+	for _, item := range list {
+		m, err := r.object(item, 0)
+		if err != nil {
+			return nil, err
+		}
+		p := OpenAPIParameter{}
+		p.Name, _ = m["name"].(string)
+		p.In, _ = m["in"].(string)
+		p.Description, _ = m["description"].(string)
+		p.Required, _ = m["required"].(bool)
+		if p.Name == "" {
+			return nil, errors.New("parameter without name")
+		}
+		switch p.In {
+		case "path":
+			// Path parameters are always required (OpenAPI 3 §4.8.12.1).
+			p.Required = true
+		case "query", "header", "cookie":
+		default:
+			return nil, fmt.Errorf("parameter %q has unsupported location %q", p.Name, p.In)
+		}
+		s, err := r.schema(m["schema"], 0)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", p.Name, err)
+		}
+		p.Schema = s
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// object returns raw as a map, following a $ref if present.
+func (r *openAPIResolver) object(raw any, depth int) (map[string]any, error) {
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("expected an object")
+	}
+	ref, ok := m["$ref"].(string)
+	if !ok {
+		return m, nil
+	}
+	if depth >= maxOpenAPIRefDepth {
+		return nil, fmt.Errorf("$ref %s nests too deeply", ref)
+	}
+	target, err := r.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	return r.object(target, depth+1)
+}
+
+// schema resolves every $ref inside a schema, returning a self-contained
+// copy. Recursive references past maxOpenAPIRefDepth collapse to a
+// plain object.
+func (r *openAPIResolver) schema(raw any, depth int) (map[string]any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("schema is not an object")
+	}
+	if ref, ok := m["$ref"].(string); ok {
+		if depth >= maxOpenAPIRefDepth {
+			return map[string]any{"type": "object"}, nil
+		}
+		target, err := r.lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+		return r.schema(target, depth+1)
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		switch k {
+		case "properties":
+			props, _ := v.(map[string]any)
+			resolved := make(map[string]any, len(props))
+			for name, ps := range props {
+				s, err := r.schema(ps, depth)
+				if err != nil {
+					return nil, fmt.Errorf("property %q: %w", name, err)
+				}
+				resolved[name] = s
+			}
+			out[k] = resolved
+		case "items", "additionalProperties":
+			if sub, ok := v.(map[string]any); ok {
+				s, err := r.schema(sub, depth)
+				if err != nil {
+					return nil, err
+				}
+				out[k] = s
+			} else {
+				out[k] = v
+			}
+		case "allOf", "oneOf", "anyOf":
+			list, _ := v.([]any)
+			resolved := make([]any, 0, len(list))
+			for _, item := range list {
+				s, err := r.schema(item, depth)
+				if err != nil {
+					return nil, err
+				}
+				resolved = append(resolved, s)
+			}
+			out[k] = resolved
+		default:
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// lookup follows a local JSON pointer such as "#/components/schemas/Pet".
+func (r *openAPIResolver) lookup(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are allowed", ref)
+	}
+	var cur any = r.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part, _ = url.PathUnescape(part)
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return cur, nil
+}
+
+// openAPIParamInfo converts a resolved JSON schema into eino's
+// ParameterInfo tree. allOf members are merged; for oneOf / anyOf the
+// first alternative is used since ParameterInfo cannot express unions.
+func openAPIParamInfo(s map[string]any, desc string, required bool) *schema.ParameterInfo {
+	s = flattenOpenAPISchema(s)
+	info := &schema.ParameterInfo{Required: required, Desc: desc}
+	if d, _ := s["description"].(string); info.Desc == "" {
+		info.Desc = d
+	}
+	typ, _ := s["type"].(string)
+	if typ == "" {
+		// OpenAPI 3.1 allows type arrays such as ["string","null"].
+		if types, ok := s["type"].([]any); ok {
+			for _, t := range types {
+				if ts, _ := t.(string); ts != "" && ts != "null" {
+					typ = ts
+					break
+				}
+			}
+		}
+	}
+	if typ == "" {
+		if _, ok := s["properties"]; ok {
+			typ = "object"
+		} else if _, ok := s["items"]; ok {
+			typ = "array"
+		}
+	}
+	switch typ {
+	case "integer":
+		info.Type = schema.Integer
+	case "number":
+		info.Type = schema.Number
+	case "boolean":
+		info.Type = schema.Boolean
+	case "array":
+		info.Type = schema.Array
+		items, _ := s["items"].(map[string]any)
+		info.ElemInfo = openAPIParamInfo(items, "", false)
+	case "object":
+		info.Type = schema.Object
+		props, _ := s["properties"].(map[string]any)
+		if len(props) > 0 {
+			req := openAPIRequiredSet(s)
+			info.SubParams = make(map[string]*schema.ParameterInfo, len(props))
+			for name, ps := range props {
+				sub, _ := ps.(map[string]any)
+				_, isReq := req[name]
+				info.SubParams[name] = openAPIParamInfo(sub, "", isReq)
+			}
+		}
+	default:
+		info.Type = schema.String
+		if enum, ok := s["enum"].([]any); ok {
+			for _, e := range enum {
+				info.Enum = append(info.Enum, fmt.Sprint(e))
+			}
+		}
+	}
+	return info
+}
+
+// flattenOpenAPISchema folds allOf members into a single schema and
+// replaces oneOf / anyOf with their first alternative.
+func flattenOpenAPISchema(s map[string]any) map[string]any {
+	if s == nil {
+		return map[string]any{}
+	}
+	if alts, ok := s["oneOf"].([]any); ok && len(alts) > 0 {
+		if first, ok := alts[0].(map[string]any); ok {
+			return flattenOpenAPISchema(first)
+		}
+	}
+	if alts, ok := s["anyOf"].([]any); ok && len(alts) > 0 {
+		if first, ok := alts[0].(map[string]any); ok {
+			return flattenOpenAPISchema(first)
+		}
+	}
+	parts, ok := s["allOf"].([]any)
+	if !ok {
+		return s
+	}
+	merged := make(map[string]any, len(s))
+	props := map[string]any{}
+	var required []any
+	absorb := func(m map[string]any) {
+		for k, v := range m {
+			switch k {
+			case "properties":
+				if p, ok := v.(map[string]any); ok {
+					for name, ps := range p {
+						props[name] = ps
+					}
+				}
+			case "required":
+				if r, ok := v.([]any); ok {
+					required = append(required, r...)
+				}
+			case "allOf":
+			default:
+				merged[k] = v
+			}
+		}
+	}
+	absorb(s)
+	for _, part := range parts {
+		if m, ok := part.(map[string]any); ok {
+			absorb(flattenOpenAPISchema(m))
+		}
+	}
+	if len(props) > 0 {
+		merged["properties"] = props
+		if _, ok := merged["type"]; !ok {
+			merged["type"] = "object"
+		}
+	}
+	if len(required) > 0 {
+		merged["required"] = required
+	}
+	return merged
+}
+
+func openAPIRequiredSet(s map[string]any) map[string]struct{} {
+	out := map[string]struct{}{}
+	list, _ := s["required"].([]any)
+	for _, r := range list {
+		if name, ok := r.(string); ok {
+			out[name] = struct{}{}
+		}
+	}
+	return out
+}
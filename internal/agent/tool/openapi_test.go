@@ -0,0 +1,353 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"ragflow/internal/agent/runtime"
+)
+
+const petStoreSpec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Pet Store", "version": "1.0"},
+  "servers": [{"url": "https://{region}.pets.example.com/v1", "variables": {"region": {"default": "eu"}}}],
+  "paths": {
+    "/pets/{petId}": {
+      "parameters": [{"name": "petId", "in": "path", "schema": {"type": "integer"}}],
+      "get": {
+        "operationId": "getPet",
+        "summary": "Fetch a pet",
+        "parameters": [
+          {"name": "fields", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "X-Trace", "in": "header", "schema": {"type": "string"}}
+        ]
+      },
+      "delete": {"summary": "Remove a pet"}
+    },
+    "/pets": {
+      "post": {
+        "operationId": "createPet",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewPet"}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "NewPet": {
+        "allOf": [
+          {"$ref": "#/components/schemas/Named"},
+          {"type": "object", "properties": {"kind": {"type": "string", "enum": ["cat", "dog"]}, "tags": {"type": "array", "items": {"$ref": "#/components/schemas/Tag"}}}}
+        ]
+      },
+      "Named": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string", "description": "Pet name"}}},
+      "Tag": {"type": "object", "properties": {"label": {"type": "string"}, "parent": {"$ref": "#/components/schemas/Tag"}}}
+    }
+  }
+}`
+
+const petStoreYAML = `
+openapi: 3.1.0
+info:
+  title: Tiny
+  version: "2"
+paths:
+  /ping:
+    get:
+      operationId: ping
+      parameters:
+        - name: verbose
+          in: query
+          schema:
+            type: [boolean, "null"]
+`
+
+// loopbackResolver lets the tests reach httptest servers on 127.0.0.1,
+// which the production SSRF guard blocks.
+func loopbackResolver(rawURL string) (string, net.IP, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, err
+	}
+	return u.Hostname(), net.ParseIP(u.Hostname()), nil
+}
+
+func TestParseOpenAPISpec_DerivesOperations(t *testing.T) {
+	doc, err := ParseOpenAPISpec([]byte(petStoreSpec))
+	if err != nil {
+		t.Fatalf("ParseOpenAPISpec: %v", err)
+	}
+	if doc.Title != "Pet Store" || doc.ServerURL != "https://eu.pets.example.com/v1" {
+		t.Fatalf("title/server = %q/%q", doc.Title, doc.ServerURL)
+	}
+	var ids []string
+	for _, op := range doc.Operations {
+		ids = append(ids, op.ID)
+	}
+	if got := strings.Join(ids, ","); got != "createPet,getPet,delete_pets_petId" {
+		t.Fatalf("operation ids = %s", got)
+	}
+
+	get := doc.Operation("getPet")
+	if len(get.Parameters) != 3 || get.Parameters[0].Name != "petId" || !get.Parameters[0].Required {
+		t.Fatalf("getPet parameters = %+v", get.Parameters)
+	}
+	create := doc.Operation("createPet")
+	if create.BodyContentType != "application/json" || !create.BodyRequired {
+		t.Fatalf("createPet body = %q required=%v", create.BodyContentType, create.BodyRequired)
+	}
+}
+
+func TestParseOpenAPISpec_YAMLAndTypeArrays(t *testing.T) {
+	doc, err := ParseOpenAPISpec([]byte(petStoreYAML))
+	if err != nil {
+		t.Fatalf("ParseOpenAPISpec: %v", err)
+	}
+	params := NewOpenAPIOperationTool(doc.Operation("ping"), "https://x.example.com", OpenAPICredentials{}).params()
+	if params["verbose"].Type != schema.Boolean {
+		t.Fatalf("verbose type = %v, want boolean", params["verbose"].Type)
+	}
+}
+
+func TestParseOpenAPISpec_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"swagger 2":    `{"swagger": "2.0", "paths": {}}`,
+		"no ops":       `{"openapi": "3.0.0", "paths": {}}`,
+		"remote ref":   `{"openapi": "3.0.0", "paths": {"/a": {"get": {"parameters": [{"$ref": "https://evil.example.com/p.json"}]}}}}`,
+		"missing ref":  `{"openapi": "3.0.0", "paths": {"/a": {"get": {"parameters": [{"$ref": "#/components/parameters/Nope"}]}}}}`,
+		"bad location": `{"openapi": "3.0.0", "paths": {"/a": {"get": {"parameters": [{"name": "x", "in": "body"}]}}}}`,
+		"not a doc":    `- just\n- a list`,
+	}
+	for name, raw := range cases {
+		if _, err := ParseOpenAPISpec([]byte(raw)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestOpenAPIOperationTool_InfoSchema(t *testing.T) {
+	doc, err := ParseOpenAPISpec([]byte(petStoreSpec))
+	if err != nil {
+		t.Fatalf("ParseOpenAPISpec: %v", err)
+	}
+	create := NewOpenAPIOperationTool(doc.Operation("createPet"), "", OpenAPICredentials{})
+	info, err := create.Info(context.Background())
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if info.Name != "createPet" || !strings.Contains(info.Desc, "POST /pets") {
+		t.Fatalf("info = %q / %q", info.Name, info.Desc)
+	}
+	body := create.params()["body"]
+	if body == nil || body.Type != schema.Object || !body.Required {
+		t.Fatalf("body = %+v", body)
+	}
+	if name := body.SubParams["name"]; name == nil || !name.Required || name.Desc != "Pet name" {
+		t.Fatalf("body.name = %+v (allOf members must merge)", name)
+	}
+	if kind := body.SubParams["kind"]; kind == nil || len(kind.Enum) != 2 {
+		t.Fatalf("body.kind = %+v", kind)
+	}
+	tags := body.SubParams["tags"]
+	if tags == nil || tags.Type != schema.Array || tags.ElemInfo.SubParams["parent"] == nil {
+		t.Fatalf("body.tags = %+v (recursive $ref must expand)", tags)
+	}
+
+	// The derived schema must be valid JSON Schema for the model.
+	if _, err := info.ParamsOneOf.ToJSONSchema(); err != nil {
+		t.Fatalf("ToJSONSchema: %v", err)
+	}
+}
+
+func TestOpenAPIOperationTool_InvokeBuildsRequest(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		raw, _ := io.ReadAll(r.Body)
+		gotBody = string(raw)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 7, "name": "Rex"}`))
+	}))
+	defer srv.Close()
+
+	doc, _ := ParseOpenAPISpec([]byte(petStoreSpec))
+	creds, err := DecodeOpenAPICredentials(map[string]any{"type": "api_key", "name": "api_key", "in": "query", "value": "s3cret", "headers": map[string]any{"X-Tenant": "t1"}})
+	if err != nil {
+		t.Fatalf("DecodeOpenAPICredentials: %v", err)
+	}
+
+	get := NewOpenAPIOperationTool(doc.Operation("getPet"), srv.URL+"/v1/", creds).WithResolver(loopbackResolver)
+	out, err := get.InvokableRun(context.Background(), `{"petId": 7, "fields": ["name", "kind"], "X-Trace": "abc"}`)
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if got.Method != http.MethodGet || got.URL.Path != "/v1/pets/7" {
+		t.Fatalf("request = %s %s", got.Method, got.URL.Path)
+	}
+	q := got.URL.Query()
+	if strings.Join(q["fields"], ",") != "name,kind" || q.Get("api_key") != "s3cret" {
+		t.Fatalf("query = %v", q)
+	}
+	if got.Header.Get("X-Trace") != "abc" || got.Header.Get("X-Tenant") != "t1" {
+		t.Fatalf("headers = %v", got.Header)
+	}
+	var res openAPIResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if res.Status != 200 || res.Body.(map[string]any)["name"] != "Rex" {
+		t.Fatalf("result = %+v", res)
+	}
+
+	bearer, _ := DecodeOpenAPICredentials(map[string]any{"type": "bearer", "token": "tok"})
+	create := NewOpenAPIOperationTool(doc.Operation("createPet"), srv.URL, bearer).WithResolver(loopbackResolver)
+	if _, err := create.InvokableRun(context.Background(), `{"body": {"name": "Rex", "kind": "dog"}}`); err != nil {
+		t.Fatalf("InvokableRun create: %v", err)
+	}
+	if got.Method != http.MethodPost || got.Header.Get("Authorization") != "Bearer tok" ||
+		got.Header.Get("Content-Type") != "application/json" || gotBody != `{"kind":"dog","name":"Rex"}` {
+		t.Fatalf("create request = %s auth=%q ct=%q body=%s", got.Method, got.Header.Get("Authorization"), got.Header.Get("Content-Type"), gotBody)
+	}
+}
+
+func TestOpenAPIOperationTool_UpstreamErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	doc, _ := ParseOpenAPISpec([]byte(petStoreSpec))
+	create := NewOpenAPIOperationTool(doc.Operation("createPet"), srv.URL, OpenAPICredentials{}).WithResolver(loopbackResolver)
+	if _, err := create.InvokableRun(context.Background(), `{"body": {"name": "Rex"}}`); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("err = %v, want a 502 error", err)
+	}
+	// POST is not idempotent, so a 5xx must not be retried.
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	get := NewOpenAPIOperationTool(doc.Operation("getPet"), notFound.URL, OpenAPICredentials{}).WithResolver(loopbackResolver)
+	out, err := get.InvokableRun(context.Background(), `{"petId": 1}`)
+	if err != nil || !strings.Contains(out, `"status":404`) {
+		t.Fatalf("out = %s err = %v", out, err)
+	}
+}
+
+func TestOpenAPIOperationTool_ValidatesArguments(t *testing.T) {
+	doc, _ := ParseOpenAPISpec([]byte(petStoreSpec))
+	get := NewOpenAPIOperationTool(doc.Operation("getPet"), "https://pets.example.com", OpenAPICredentials{})
+	if _, err := get.InvokableRun(context.Background(), `{}`); err == nil || !strings.Contains(err.Error(), "petId") {
+		t.Fatalf("missing path param err = %v", err)
+	}
+	create := NewOpenAPIOperationTool(doc.Operation("createPet"), "https://pets.example.com", OpenAPICredentials{})
+	if _, err := create.InvokableRun(context.Background(), `{}`); err == nil || !strings.Contains(err.Error(), "request body") {
+		t.Fatalf("missing body err = %v", err)
+	}
+}
+
+func TestOpenAPIOperationTool_SSRFGuard(t *testing.T) {
+	doc, _ := ParseOpenAPISpec([]byte(petStoreSpec))
+	for _, base := range []string{"http://127.0.0.1:8080", "http://169.254.169.254/latest", "http://localhost"} {
+		get := NewOpenAPIOperationTool(doc.Operation("getPet"), base, OpenAPICredentials{})
+		_, err := get.InvokableRun(context.Background(), `{"petId": 1}`)
+		if !errors.Is(err, ErrSSRFBlocked) {
+			t.Errorf("%s: err = %v, want ErrSSRFBlocked", base, err)
+		}
+	}
+}
+
+func TestDecodeOpenAPICredentials(t *testing.T) {
+	if c, err := DecodeOpenAPICredentials(nil); err != nil || c.Type != OpenAPIAuthNone {
+		t.Fatalf("nil creds = %+v, %v", c, err)
+	}
+	if c, err := DecodeOpenAPICredentials(map[string]any{"type": "api_key", "name": "k", "value": "v"}); err != nil || c.In != "header" {
+		t.Fatalf("api_key default location = %+v, %v", c, err)
+	}
+	bad := []map[string]any{
+		{"type": "bearer"},
+		{"type": "basic"},
+		{"type": "api_key", "name": "k"},
+		{"type": "api_key", "name": "k", "value": "v", "in": "body"},
+		{"type": "oauth2"},
+	}
+	for _, m := range bad {
+		if _, err := DecodeOpenAPICredentials(m); err == nil {
+			t.Errorf("%v: expected an error", m)
+		}
+	}
+}
+
+func TestBuildAllContext_ResolvesOpenAPITools(t *testing.T) {
+	var gotTenant string
+	SetOpenAPISpecLoader(func(_ context.Context, tenantID, specID string) (*OpenAPISpecRecord, error) {
+		gotTenant = tenantID
+		if specID != "spec1" {
+			return nil, nil
+		}
+		return &OpenAPISpecRecord{ID: specID, Spec: petStoreSpec}, nil
+	})
+	t.Cleanup(func() { SetOpenAPISpecLoader(nil) })
+
+	state := runtime.NewCanvasState("run", "task")
+	state.Sys["tenant_id"] = "tenant-1"
+	ctx := runtime.WithState(context.Background(), state)
+
+	tools, err := BuildAllContext(ctx, []string{"wikipedia", "openapi:spec1", "openapi:spec1:getPet"}, nil)
+	if err != nil {
+		t.Fatalf("BuildAllContext: %v", err)
+	}
+	if gotTenant != "tenant-1" {
+		t.Fatalf("loader tenant = %q", gotTenant)
+	}
+	// wikipedia + 3 operations; the explicit getPet is not duplicated.
+	if len(tools) != 4 {
+		t.Fatalf("len(tools) = %d, want 4", len(tools))
+	}
+	info, _ := tools[2].Info(ctx)
+	if info.Name != "getPet" {
+		t.Fatalf("tools[2] = %q", info.Name)
+	}
+
+	if _, err := BuildAllContext(ctx, []string{"openapi:missing"}, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("missing spec err = %v", err)
+	}
+	if _, err := BuildAllContext(ctx, []string{"openapi:spec1:nope"}, nil); err == nil {
+		t.Fatal("unknown operation: expected an error")
+	}
+	if _, err := BuildAllContext(context.Background(), []string{"openapi:spec1"}, nil); err == nil {
+		t.Fatal("no tenant: expected an error")
+	}
+}
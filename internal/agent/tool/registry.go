@@ -17,10 +17,14 @@
 package tool
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	einotool "github.com/cloudwego/eino/components/tool"
+
+	"ragflow/internal/agent/runtime"
 )
 
 // Factory builds a tool instance by DSL / Agent-visible name and
@@ -82,25 +86,33 @@ func BuildByName(name string, params map[string]any) (einotool.BaseTool, error)
 }
 
 // BuildAll resolves a list of tool names into Eino BaseTool instances.
-// perToolParams is keyed by the Agent-visible tool name.
+// perToolParams is keyed by the Agent-visible tool name. A cache_ttl
+// entry is consumed here rather than by the factory: it turns on the
+// result cache for the tool (see cache.go). Side-effecting tools get
+// the idempotency guard unconditionally.
 func BuildAll(names []string, perToolParams map[string]map[string]any) ([]einotool.BaseTool, error) {
 	if len(names) == 0 {
 		return nil, nil
 	}
 	tools := make([]einotool.BaseTool, 0, len(names))
 	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
 		var params map[string]any
 		if perToolParams != nil {
-			params = perToolParams[strings.ToLower(strings.TrimSpace(name))]
+			params = perToolParams[key]
 			if params == nil {
 				params = perToolParams[name]
 			}
 		}
+		params, cacheTTL, err := splitToolCacheParam(key, params)
+		if err != nil {
+			return nil, err
+		}
 		t, err := BuildByName(name, params)
 		if err != nil {
 			return nil, err
 		}
-		tools = append(tools, t)
+		tools = append(tools, wrapToolResultCache(key, t, cacheTTL))
 	}
 	return tools, nil
 }
@@ -158,6 +170,7 @@ func decodeExeSQLConnParams(params map[string]any) (exesqlConnParams, error) {
 	if v, ok := intParam(params, "max_records"); ok {
 		conn.MaxRecords = v
 	}
+	conn.decodeExtraParams(params)
 	if err := conn.check(); err != nil {
 		return exesqlConnParams{}, fmt.Errorf("agent tool: execute_sql config: %w", err)
 	}
@@ -191,3 +204,78 @@ func intParam(params map[string]any, key string) (int, bool) {
 		return 0, false
 	}
 }
+
+// BuildAllContext is BuildAll for callers that can resolve
+// tenant-scoped tool names: "openapi:", "mcp:" and "imap:" names are
+// loaded for the tenant recorded in the canvas state on ctx; every
+// other name goes through the static registry.
+func BuildAllContext(ctx context.Context, names []string, perToolParams map[string]map[string]any) ([]einotool.BaseTool, error) {
+	var builtin []string
+	var openapi []string
+	var mcp []string
+	var imap []string
+	for _, name := range names {
+		switch {
+		case IsOpenAPIToolName(name):
+			openapi = append(openapi, strings.TrimSpace(name))
+		case IsMCPToolName(name):
+			mcp = append(mcp, strings.TrimSpace(name))
+		case IsIMAPToolName(name):
+			imap = append(imap, strings.TrimSpace(name))
+		default:
+			builtin = append(builtin, name)
+		}
+	}
+	tools, err := BuildAll(builtin, perToolParams)
+	if err != nil || (len(openapi) == 0 && len(mcp) == 0 && len(imap) == 0) {
+		return tools, err
+	}
+
+	tenantID := tenantIDFromContext(ctx)
+	if tenantID == "" {
+		switch {
+		case len(openapi) > 0:
+			return nil, errors.New("agent tool: openapi tools require a tenant in the canvas state")
+		case len(mcp) > 0:
+			return nil, errors.New("agent tool: mcp tools require a tenant in the canvas state")
+		default:
+			return nil, errors.New("agent tool: imap tools require a tenant in the canvas state")
+		}
+	}
+	if len(openapi) > 0 {
+		openAPITools, err := buildOpenAPIToolsByName(ctx, tenantID, openapi)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, openAPITools...)
+	}
+	if len(mcp) > 0 {
+		mcpTools, err := buildMCPTools(ctx, tenantID, mcp)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, mcpTools...)
+	}
+	if len(imap) > 0 {
+		imapTools, err := buildIMAPTools(ctx, tenantID, imap)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, imapTools...)
+	}
+	return tools, nil
+}
+
+// tenantIDFromContext returns the tenant recorded in the canvas state on
+// ctx ("tenant_id", falling back to "user_id"), or "" outside a run.
+func tenantIDFromContext(ctx context.Context) string {
+	state, _, err := runtime.GetStateFromContext[*runtime.CanvasState](ctx)
+	if err != nil || state == nil {
+		return ""
+	}
+	if tid, _ := state.Sys["tenant_id"].(string); tid != "" {
+		return tid
+	}
+	tid, _ := state.Sys["user_id"].(string)
+	return tid
+}
@@ -216,6 +216,30 @@ func (r *RetrievalTool) InvokableRun(ctx context.Context, argumentsInJSON string
 	return result, nil
 }
 
+// replayCachedResult records the chunks of a cached result into canvas
+// state, as InvokableRun does for a live search, so citation grounding
+// still sees them on a cache hit.
+func (r *RetrievalTool) replayCachedResult(ctx context.Context, result string) {
+	var out retrievalResult
+	if err := json.Unmarshal([]byte(result), &out); err != nil || len(out.Chunks) == 0 {
+		return
+	}
+	state, _, err := runtime.GetStateFromContext[*runtime.CanvasState](ctx)
+	if err != nil || state == nil {
+		return
+	}
+	asMap := make([]map[string]any, 0, len(out.Chunks))
+	for _, c := range out.Chunks {
+		asMap = append(asMap, map[string]any{
+			"id":          c.ID,
+			"content":     c.Content,
+			"document_id": c.DocumentID,
+			"score":       c.Score,
+		})
+	}
+	state.SetRetrievalChunks(asMap)
+}
+
 // renderChunks concatenates the retrieved chunks into a human-
 // readable content string. Mirrors Python's
 // `kb_prompt(kbinfos, ...)` format: each chunk gets a header
@@ -0,0 +1,409 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package vartype is the optional type system of canvas variables.
+//
+// A canvas may declare the type of a Begin input, a global (the DSL
+// "variables" map backing env.*) or a component output. Declarations
+// use the editor's field kinds ("line", "paragraph", "Number",
+// "Array<Object>", ...) or a JSON-schema subset:
+//
+//	{"type": "array", "items": {"type": "string"}}
+//	{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}
+//	{"type": "string", "enum": ["low", "high"]}
+//
+// The runtime checks values against their declaration when a component
+// reads them and when VariableAssigner writes them; the DSL validator
+// uses the same declarations to flag mismatches statically. Undeclared
+// or unrecognised types ("unknown", "file") are untyped and never
+// checked. The package depends on the standard library only so both
+// the runtime and the DSL validator can import it.
+package vartype
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value kinds.
+const (
+	KindString  = "string"
+	KindNumber  = "number"
+	KindInteger = "integer"
+	KindBoolean = "boolean"
+	KindObject  = "object"
+	KindArray   = "array"
+)
+
+// ErrInvalidType is returned by Parse for a malformed declaration.
+var ErrInvalidType = errors.New("invalid type declaration")
+
+// Type is a parsed declaration. A nil *Type is untyped and accepts any
+// value.
+type Type struct {
+	Kind       string           `json:"type"`
+	Items      *Type            `json:"items,omitempty"`
+	Properties map[string]*Type `json:"properties,omitempty"`
+	Required   []string         `json:"required,omitempty"`
+	Enum       []any            `json:"enum,omitempty"`
+}
+
+// Parse reads a declaration: an editor kind string or a schema map.
+// It returns (nil, nil) for untyped declarations.
+func Parse(decl any) (*Type, error) {
+	switch d := decl.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return parseKind(d), nil
+	case map[string]any:
+		return parseSchema(d)
+	}
+	return nil, fmt.Errorf("%w: %T", ErrInvalidType, decl)
+}
+
+// parseKind maps an editor kind ("line", "Array<string>", "Number") to
+// a Type; unknown kinds are untyped.
+func parseKind(s string) *Type {
+	k := strings.ToLower(strings.TrimSpace(s))
+	switch k {
+	case "string", "line", "paragraph", "options", "text":
+		return &Type{Kind: KindString}
+	case "number", "float":
+		return &Type{Kind: KindNumber}
+	case "integer", "int":
+		return &Type{Kind: KindInteger}
+	case "boolean", "bool":
+		return &Type{Kind: KindBoolean}
+	case "object":
+		return &Type{Kind: KindObject}
+	case "array", "list":
+		return &Type{Kind: KindArray}
+	}
+	if inner, ok := strings.CutPrefix(k, "array<"); ok && strings.HasSuffix(inner, ">") {
+		return &Type{Kind: KindArray, Items: parseKind(strings.TrimSuffix(inner, ">"))}
+	}
+	return nil
+}
+
+func parseSchema(m map[string]any) (*Type, error) {
+	var t *Type
+	switch raw := m["type"].(type) {
+	case nil:
+		switch {
+		case m["properties"] != nil:
+			t = &Type{Kind: KindObject}
+		case m["items"] != nil:
+			t = &Type{Kind: KindArray}
+		default:
+			return nil, nil
+		}
+	case string:
+		t = parseKind(raw)
+		if t == nil {
+			return nil, nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: type must be a string, got %T", ErrInvalidType, raw)
+	}
+
+	if raw, ok := m["items"]; ok && raw != nil {
+		if t.Kind != KindArray {
+			return nil, fmt.Errorf("%w: items on a %s", ErrInvalidType, t.Kind)
+		}
+		items, err := Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		t.Items = items
+	}
+	if raw, ok := m["properties"]; ok && raw != nil {
+		props, isMap := raw.(map[string]any)
+		if !isMap || t.Kind != KindObject {
+			return nil, fmt.Errorf("%w: properties must be a map on an object", ErrInvalidType)
+		}
+		t.Properties = make(map[string]*Type, len(props))
+		for name, decl := range props {
+			p, err := Parse(decl)
+			if err != nil {
+				return nil, fmt.Errorf("properties.%s: %w", name, err)
+			}
+			t.Properties[name] = p
+		}
+	}
+	if raw, ok := m["required"]; ok && raw != nil {
+		list, isList := raw.([]any)
+		if !isList || t.Kind != KindObject {
+			return nil, fmt.Errorf("%w: required must be a list on an object", ErrInvalidType)
+		}
+		for _, r := range list {
+			name, isString := r.(string)
+			if !isString {
+				return nil, fmt.Errorf("%w: required entries must be strings", ErrInvalidType)
+			}
+			t.Required = append(t.Required, name)
+		}
+	}
+	if raw, ok := m["enum"]; ok && raw != nil {
+		list, isList := raw.([]any)
+		if !isList || len(list) == 0 {
+			return nil, fmt.Errorf("%w: enum must be a non-empty list", ErrInvalidType)
+		}
+		t.Enum = list
+	}
+	return t, nil
+}
+
+// String renders t for messages: "string", "array<object>", "any".
+func (t *Type) String() string {
+	if t == nil {
+		return "any"
+	}
+	if t.Kind == KindArray && t.Items != nil {
+		return "array<" + t.Items.String() + ">"
+	}
+	return t.Kind
+}
+
+// At returns the type of the value at one path segment below t: a
+// property of an object or an element of an array. It returns nil when
+// the segment is not declared.
+func (t *Type) At(segment string) *Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind {
+	case KindObject:
+		return t.Properties[segment]
+	case KindArray:
+		if _, err := strconv.Atoi(segment); err == nil {
+			return t.Items
+		}
+	}
+	return nil
+}
+
+// MismatchError reports where a value departs from its type. Path is
+// relative to the checked value ("" for the value itself, "items[2]",
+// "user.id").
+type MismatchError struct {
+	Path string
+	Want string
+	Got  string
+}
+
+func (e *MismatchError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("want %s, got %s", e.Want, e.Got)
+	}
+	return fmt.Sprintf("%s: want %s, got %s", e.Path, e.Want, e.Got)
+}
+
+// Check reports whether v conforms to t. A nil value is "not set" and
+// always conforms; so does any value of an untyped (nil) t. The error
+// is a *MismatchError.
+func (t *Type) Check(v any) error {
+	return t.check("", v)
+}
+
+func (t *Type) check(path string, v any) error {
+	if t == nil || v == nil {
+		return nil
+	}
+	got := KindOf(v)
+	ok := false
+	switch t.Kind {
+	case KindNumber:
+		ok = got == KindNumber || got == KindInteger
+	default:
+		ok = got == t.Kind
+	}
+	if !ok {
+		return &MismatchError{Path: path, Want: t.String(), Got: got}
+	}
+	if len(t.Enum) > 0 && !inEnum(t.Enum, v) {
+		return &MismatchError{Path: path, Want: fmt.Sprintf("one of %v", t.Enum), Got: fmt.Sprintf("%v", v)}
+	}
+	switch t.Kind {
+	case KindArray:
+		if t.Items == nil {
+			return nil
+		}
+		rv := reflect.ValueOf(v)
+		for i := 0; i < rv.Len(); i++ {
+			if err := t.Items.check(fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	case KindObject:
+		m := objectMap(v)
+		for _, name := range t.Required {
+			if _, present := m[name]; !present {
+				return &MismatchError{Path: joinPath(path, name), Want: "required property", Got: "nothing"}
+			}
+		}
+		names := make([]string, 0, len(t.Properties))
+		for name := range t.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := t.Properties[name].check(joinPath(path, name), m[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// KindOf names the kind of a Go value: one of the Kind constants,
+// "null", or the Go type for values no declaration can match.
+func KindOf(v any) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return KindString
+	case bool:
+		return KindBoolean
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return KindInteger
+	case float32:
+		if float32(int64(n)) == n {
+			return KindInteger
+		}
+		return KindNumber
+	case float64:
+		if float64(int64(n)) == n {
+			return KindInteger
+		}
+		return KindNumber
+	case []byte:
+		return "bytes"
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Slice, reflect.Array:
+		return KindArray
+	case reflect.Map:
+		if reflect.TypeOf(v).Key().Kind() == reflect.String {
+			return KindObject
+		}
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Compatible reports whether a value of type got can flow into a slot
+// declared want. Untyped sides are compatible with anything, and
+// integer and number are interchangeable (JSON does not tell them
+// apart). Arrays compare their item types; objects compare the
+// properties both declare.
+func Compatible(want, got *Type) bool {
+	if want == nil || got == nil {
+		return true
+	}
+	wk, gk := want.Kind, got.Kind
+	if wk == KindInteger {
+		wk = KindNumber
+	}
+	if gk == KindInteger {
+		gk = KindNumber
+	}
+	if wk != gk {
+		return false
+	}
+	switch want.Kind {
+	case KindArray:
+		return Compatible(want.Items, got.Items)
+	case KindObject:
+		for name, p := range want.Properties {
+			if !Compatible(p, got.Properties[name]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Declarations returns the output declarations found in a component's
+// DSL params, keyed by output name: the "outputs" map, Begin /
+// UserFillUp form "inputs", and Loop "loop_variables". Values are the
+// raw declarations for Parse; untyped ones parse to nil.
+func Declarations(params map[string]any) map[string]any {
+	out := map[string]any{}
+	collect := func(m map[string]any) {
+		for k, raw := range m {
+			t, _ := Parse(raw)
+			if _, exists := out[k]; !exists || t != nil {
+				out[k] = raw
+			}
+		}
+	}
+	if m, ok := params["outputs"].(map[string]any); ok {
+		collect(m)
+	}
+	if m, ok := params["inputs"].(map[string]any); ok {
+		collect(m)
+	}
+	if vars, ok := params["loop_variables"].([]any); ok {
+		for _, raw := range vars {
+			spec, _ := raw.(map[string]any)
+			name, _ := spec["variable"].(string)
+			if name == "" {
+				continue
+			}
+			if t, ok := spec["type"].(string); ok && t != "" {
+				out[name] = t
+			} else {
+				out[name] = nil
+			}
+		}
+	}
+	return out
+}
+
+func objectMap(v any) map[string]any {
+	if m, ok := v.(map[string]any); ok {
+		return m
+	}
+	rv := reflect.ValueOf(v)
+	m := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m
+}
+
+func inEnum(enum []any, v any) bool {
+	s := fmt.Sprintf("%v", v)
+	for _, e := range enum {
+		if fmt.Sprintf("%v", e) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,151 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package vartype
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse_EditorKindsAndSchema(t *testing.T) {
+	cases := []struct {
+		decl any
+		want string
+	}{
+		{"line", "string"},
+		{"Number", "number"},
+		{"integer", "integer"},
+		{"Array<Object>", "array<object>"},
+		{map[string]any{"type": "paragraph", "value": ""}, "string"},
+		{map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, "array<string>"},
+		{map[string]any{"properties": map[string]any{"id": "integer"}}, "object"},
+		{"unknown", "any"},
+		{"file", "any"},
+		{map[string]any{"value": "x"}, "any"},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.decl)
+		if err != nil {
+			t.Fatalf("Parse(%v): %v", tc.decl, err)
+		}
+		if got.String() != tc.want {
+			t.Errorf("Parse(%v) = %s, want %s", tc.decl, got, tc.want)
+		}
+	}
+
+	for _, bad := range []any{
+		map[string]any{"type": []any{"string", "null"}},
+		map[string]any{"type": "string", "items": "string"},
+		map[string]any{"type": "object", "required": "id"},
+		map[string]any{"type": "string", "enum": []any{}},
+		42,
+	} {
+		if _, err := Parse(bad); !errors.Is(err, ErrInvalidType) {
+			t.Errorf("Parse(%v) error = %v, want ErrInvalidType", bad, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	typ, err := Parse(map[string]any{
+		"type":     "object",
+		"required": []any{"id"},
+		"properties": map[string]any{
+			"id":    map[string]any{"type": "integer"},
+			"tags":  "Array<string>",
+			"level": map[string]any{"type": "string", "enum": []any{"low", "high"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	ok := []any{
+		nil,
+		map[string]any{"id": float64(3)},
+		map[string]any{"id": 3, "tags": []string{"a"}, "level": "low"},
+	}
+	for _, v := range ok {
+		if err := typ.Check(v); err != nil {
+			t.Errorf("Check(%v) = %v, want nil", v, err)
+		}
+	}
+
+	bad := []struct {
+		v    any
+		want string
+	}{
+		{"x", "want object, got string"},
+		{map[string]any{}, "id: want required property, got nothing"},
+		{map[string]any{"id": 1.5}, "id: want integer, got number"},
+		{map[string]any{"id": 1, "tags": []any{"a", 2}}, "tags[1]: want string, got integer"},
+		{map[string]any{"id": 1, "level": "mid"}, "level: want one of [low high], got mid"},
+	}
+	for _, tc := range bad {
+		err := typ.Check(tc.v)
+		var mismatch *MismatchError
+		if !errors.As(err, &mismatch) || err.Error() != tc.want {
+			t.Errorf("Check(%v) = %v, want %q", tc.v, err, tc.want)
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	parse := func(d any) *Type {
+		typ, _ := Parse(d)
+		return typ
+	}
+	cases := []struct {
+		want, got any
+		ok        bool
+	}{
+		{"number", "integer", true},
+		{"string", "unknown", true},
+		{"Array<string>", "array", true},
+		{"Array<string>", "Array<Object>", false},
+		{"string", "Array<string>", false},
+		{
+			map[string]any{"type": "object", "properties": map[string]any{"id": "integer"}},
+			map[string]any{"type": "object", "properties": map[string]any{"id": "string"}},
+			false,
+		},
+	}
+	for _, tc := range cases {
+		if got := Compatible(parse(tc.want), parse(tc.got)); got != tc.ok {
+			t.Errorf("Compatible(%v, %v) = %v, want %v", tc.want, tc.got, got, tc.ok)
+		}
+	}
+}
+
+func TestDeclarations(t *testing.T) {
+	decls := Declarations(map[string]any{
+		"outputs": map[string]any{"content": map[string]any{"type": "string"}, "topic": map[string]any{"type": "string"}},
+		"inputs":  map[string]any{"topic": map[string]any{"type": "options"}, "note": map[string]any{"name": "note"}},
+		"loop_variables": []any{
+			map[string]any{"variable": "n", "type": "number"},
+		},
+	})
+	if len(decls) != 4 {
+		t.Fatalf("decls = %v, want content, topic, note and n", decls)
+	}
+	if typ, _ := Parse(decls["n"]); typ.String() != "number" {
+		t.Errorf("n = %v, want number", decls["n"])
+	}
+	if typ, _ := Parse(decls["note"]); typ != nil {
+		t.Errorf("note = %v, want untyped", decls["note"])
+	}
+}
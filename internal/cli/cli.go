@@ -813,6 +813,7 @@ Commands (User Mode):
   PING;                                                  - Ping server
   LIST DATASETS;                                         - List user datasets
   LIST AGENTS;                                           - List user agents
+  TEST AGENT 'id' FROM FILE 'suite.yaml' [REPORT 'out.xml']; - Run an agent test suite
   LIST CHATS;                                            - List user chats
   LIST MODEL PROVIDERS;                                  - List model providers
   LIST DEFAULT MODELS;                                   - List default models
@@ -325,6 +325,8 @@ func (c *CLI) ExecuteUserCommand(cmd *Command) (ResponseIf, error) {
 		return c.APICreateDatasetCommand(cmd)
 	case "api_create_agent":
 		return c.APICreateAgentCommand(cmd)
+	case "api_test_agent":
+		return c.APITestAgentCommand(cmd)
 	case "api_create_chat":
 		return c.APICreateChatCommand(cmd)
 	case "api_create_search":
@@ -521,6 +521,10 @@ func (l *Lexer) lookupIdent(ident string) Token {
 		return Token{Type: TokenPurge, Value: ident}
 	case "PREVIEW":
 		return Token{Type: TokenPreview, Value: ident}
+	case "TEST":
+		return Token{Type: TokenTest, Value: ident}
+	case "REPORT":
+		return Token{Type: TokenReport, Value: ident}
 	case "PLAN":
 		return Token{Type: TokenPlan, Value: ident}
 	case "DATA":
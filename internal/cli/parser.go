@@ -220,6 +220,8 @@ func (p *Parser) parseUserCommand() (*Command, error) {
 		return p.parseExplainCommand()
 	case TokenChunk:
 		return p.parseChunkCommand(false)
+	case TokenTest:
+		return p.parseTestCommand()
 
 	case TokenLS, TokenCat, TokenSearch:
 		// For context engine
@@ -194,6 +194,8 @@ const (
 	TokenPlan
 	TokenPreview
 	TokenOpenaiChat
+	TokenTest
+	TokenReport
 	TokenLog
 	TokenLevel
 	TokenDebug
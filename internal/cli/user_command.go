@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -35,6 +36,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Show server version to show RAGFlow server version
@@ -1062,6 +1065,94 @@ func (c *CLI) APICreateAgentCommand(cmd *Command) (ResponseIf, error) {
 	return &result, nil
 }
 
+// junitReport is the part of the server's JUnit report the CLI prints.
+type junitReport struct {
+	Suites []struct {
+		Cases []struct {
+			Name    string `xml:"name,attr"`
+			Time    string `xml:"time,attr"`
+			Failure *struct {
+				Message string `xml:"message,attr"`
+			} `xml:"failure"`
+		} `xml:"testcase"`
+	} `xml:"testsuite"`
+}
+
+// APITestAgentCommand runs a canvas test suite file against an agent,
+// optionally saving the JUnit report, and prints one row per case.
+func (c *CLI) APITestAgentCommand(cmd *Command) (ResponseIf, error) {
+	if c.Config.CLIMode != APIMode {
+		return nil, fmt.Errorf("this command is only allowed in USER mode")
+	}
+
+	httpClient := c.APIServerClientMap[c.Config.APIClientConfig.CurrentAPIServer]
+
+	// Determine auth kind based on whether API key is being used
+	if httpClient.LoginToken == nil && !c.APIServerClientMap[c.Config.APIClientConfig.CurrentAPIServer].useAPIKey {
+		return nil, fmt.Errorf("no authorization")
+	}
+
+	canvasID, _ := cmd.Params["canvas_id"].(string)
+	suitePath, _ := cmd.Params["suite_path"].(string)
+	reportPath, _ := cmd.Params["report_path"].(string)
+
+	raw, err := os.ReadFile(suitePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test suite: %w", err)
+	}
+	var suite map[string]interface{}
+	if err = yaml.Unmarshal(raw, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse test suite: %w", err)
+	}
+
+	path := fmt.Sprintf("/agents/%s/tests?format=junit", netUrl.PathEscape(canvasID))
+	resp, err := httpClient.Request("POST", path, "web", nil, suite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to test agent: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to test agent: HTTP %d, body: %s", resp.StatusCode, string(resp.Body))
+	}
+	// Errors come back as the usual JSON envelope.
+	if !strings.Contains(resp.Headers.Get("Content-Type"), "xml") {
+		var errResult CommonDataResponse
+		if err = json.Unmarshal(resp.Body, &errResult); err != nil {
+			return nil, fmt.Errorf("test agent failed: invalid JSON (%w)", err)
+		}
+		return nil, fmt.Errorf("%s", errResult.Message)
+	}
+
+	if reportPath != "" {
+		if err = os.WriteFile(reportPath, resp.Body, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	var report junitReport
+	if err = xml.Unmarshal(resp.Body, &report); err != nil {
+		return nil, fmt.Errorf("test agent failed: invalid report (%w)", err)
+	}
+
+	var result CommonResponse
+	for _, suite := range report.Suites {
+		for _, tc := range suite.Cases {
+			status, failure := "PASS", ""
+			if tc.Failure != nil {
+				status, failure = "FAIL", tc.Failure.Message
+			}
+			result.Data = append(result.Data, map[string]interface{}{
+				"case":    tc.Name,
+				"result":  status,
+				"time":    tc.Time,
+				"failure": failure,
+			})
+		}
+	}
+	result.Code = 0
+	result.Duration = resp.Duration
+	return &result, nil
+}
+
 func (c *CLI) APICreateChatCommand(cmd *Command) (ResponseIf, error) {
 	if c.Config.CLIMode != APIMode {
 		return nil, fmt.Errorf("this command is only allowed in USER mode")
@@ -1345,6 +1345,56 @@ func (p *Parser) parseAPICreateAgent() (*Command, error) {
 	return cmd, nil
 }
 
+// TEST AGENT 'canvas_id' FROM FILE 'suite.yaml' [REPORT 'junit.xml']
+func (p *Parser) parseTestCommand() (*Command, error) {
+	p.nextToken() // consume TEST
+	if p.curToken.Type != TokenAgent {
+		return nil, fmt.Errorf("expected AGENT after TEST, got %s", p.curToken.Value)
+	}
+	p.nextToken()
+
+	canvasID, err := p.parseQuotedString()
+	if err != nil {
+		return nil, err
+	}
+	p.nextToken()
+
+	if p.curToken.Type != TokenFrom {
+		return nil, fmt.Errorf("expected FROM, got %s", p.curToken.Value)
+	}
+	p.nextToken()
+	if p.curToken.Type != TokenFile {
+		return nil, fmt.Errorf("expected FILE, got %s", p.curToken.Value)
+	}
+	p.nextToken()
+
+	suitePath, err := p.parseQuotedString()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := NewCommand("api_test_agent")
+	cmd.Params["canvas_id"] = canvasID
+	cmd.Params["suite_path"] = suitePath
+
+	p.nextToken()
+	if p.curToken.Type == TokenReport {
+		p.nextToken()
+		reportPath, err := p.parseQuotedString()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Params["report_path"] = reportPath
+		p.nextToken()
+	}
+
+	// Semicolon is optional
+	if p.curToken.Type == TokenSemicolon {
+		p.nextToken()
+	}
+	return cmd, nil
+}
+
 // CREAT SEARCH 'search_name'
 func (p *Parser) parseAPICreateSearch() (*Command, error) {
 	p.nextToken() // consume SEARCH
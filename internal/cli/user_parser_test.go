@@ -170,3 +170,37 @@ func TestParseAddModelWithDimensions(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTestAgent(t *testing.T) {
+	tests := []struct {
+		input   string
+		params  map[string]interface{}
+		wantErr bool
+	}{
+		{
+			input:  "test agent 'c1' from file 'suite.yaml';",
+			params: map[string]interface{}{"canvas_id": "c1", "suite_path": "suite.yaml"},
+		},
+		{
+			input:  "TEST AGENT 'c1' FROM FILE 'suite.yaml' REPORT 'out.xml'",
+			params: map[string]interface{}{"canvas_id": "c1", "suite_path": "suite.yaml", "report_path": "out.xml"},
+		},
+		{input: "test agent 'c1' 'suite.yaml';", wantErr: true},
+		{input: "test dataset 'c1' from file 'suite.yaml';", wantErr: true},
+	}
+	for _, tt := range tests {
+		cmd, err := NewParser(tt.input).Parse(APIMode)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) expected an error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.input, err)
+		}
+		if cmd.Type != "api_test_agent" || !reflect.DeepEqual(cmd.Params, tt.params) {
+			t.Errorf("Parse(%q) = %s %v, want api_test_agent %v", tt.input, cmd.Type, cmd.Params, tt.params)
+		}
+	}
+}
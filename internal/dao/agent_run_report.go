@@ -0,0 +1,55 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package dao
+
+import (
+	"ragflow/internal/entity"
+)
+
+// AgentRunReportDAO persists and queries AgentRunReport rows.
+type AgentRunReportDAO struct{}
+
+// NewAgentRunReportDAO returns a zero-value DAO.
+func NewAgentRunReportDAO() *AgentRunReportDAO {
+	return &AgentRunReportDAO{}
+}
+
+// Create inserts a new report row.
+func (dao *AgentRunReportDAO) Create(r *entity.AgentRunReport) error {
+	return DB.Create(r).Error
+}
+
+// ListBySession returns the reports of one session of a canvas in run
+// order.
+func (dao *AgentRunReportDAO) ListBySession(canvasID, sessionID string) ([]*entity.AgentRunReport, error) {
+	var rs []*entity.AgentRunReport
+	err := DB.Where("user_canvas_id = ? AND session_id = ?", canvasID, sessionID).
+		Order("started_at ASC").
+		Find(&rs).Error
+	return rs, err
+}
+
+// ListRecentByCanvas returns up to limit reports of the canvas, newest
+// first.
+func (dao *AgentRunReportDAO) ListRecentByCanvas(canvasID string, limit int) ([]*entity.AgentRunReport, error) {
+	var rs []*entity.AgentRunReport
+	err := DB.Where("user_canvas_id = ?", canvasID).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&rs).Error
+	return rs, err
+}
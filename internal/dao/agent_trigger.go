@@ -0,0 +1,178 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package dao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"ragflow/internal/entity"
+)
+
+// ErrAgentTriggerNotFound is returned when a trigger lookup by id yields
+// no rows.
+var ErrAgentTriggerNotFound = errors.New("agent_trigger: not found")
+
+// AgentTriggerDAO persists and queries AgentTrigger rows.
+type AgentTriggerDAO struct{}
+
+// NewAgentTriggerDAO returns a zero-value DAO.
+func NewAgentTriggerDAO() *AgentTriggerDAO {
+	return &AgentTriggerDAO{}
+}
+
+// Create inserts a new trigger row.
+func (dao *AgentTriggerDAO) Create(t *entity.AgentTrigger) error {
+	return DB.Create(t).Error
+}
+
+// GetByID fetches a single trigger by primary key, returning
+// ErrAgentTriggerNotFound when the row is absent.
+func (dao *AgentTriggerDAO) GetByID(id string) (*entity.AgentTrigger, error) {
+	var t entity.AgentTrigger
+	err := DB.Where("id = ?", id).First(&t).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAgentTriggerNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListByCanvasID returns every trigger of the given canvas, newest first.
+func (dao *AgentTriggerDAO) ListByCanvasID(canvasID string) ([]*entity.AgentTrigger, error) {
+	var ts []*entity.AgentTrigger
+	err := DB.Where("user_canvas_id = ?", canvasID).
+		Order("create_time DESC").
+		Find(&ts).Error
+	return ts, err
+}
+
+// ListActive returns every active trigger of the given type across all
+// canvases. Used by the trigger scheduler on each tick.
+func (dao *AgentTriggerDAO) ListActive(triggerType string) ([]*entity.AgentTrigger, error) {
+	var ts []*entity.AgentTrigger
+	err := DB.Where("trigger_type = ? AND status = ?", triggerType, entity.AgentTriggerStatusActive).
+		Find(&ts).Error
+	return ts, err
+}
+
+// UpdateFields applies a partial update to one trigger.
+func (dao *AgentTriggerDAO) UpdateFields(id string, fields map[string]interface{}) error {
+	return DB.Model(&entity.AgentTrigger{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// ClaimNextFire moves a schedule trigger's next_fire_time from expected
+// (nil for a trigger that has none yet) to next and reports whether this
+// call made the change. Every scheduler replica races on it, so each
+// activation is claimed by exactly one of them.
+func (dao *AgentTriggerDAO) ClaimNextFire(id string, expected *int64, next int64) (bool, error) {
+	q := DB.Model(&entity.AgentTrigger{}).Where("id = ?", id)
+	if expected == nil {
+		q = q.Where("next_fire_time IS NULL")
+	} else {
+		q = q.Where("next_fire_time = ?", *expected)
+	}
+	res := q.Update("next_fire_time", next)
+	return res.RowsAffected == 1, res.Error
+}
+
+// AdvanceEventState stores an event watcher's new watermark unless another
+// replica stored one since seq was read, and reports whether it did.
+func (dao *AgentTriggerDAO) AdvanceEventState(id string, seq int64, state entity.JSONMap) (bool, error) {
+	res := DB.Model(&entity.AgentTrigger{}).
+		Where("id = ? AND event_seq = ?", id, seq).
+		Updates(map[string]interface{}{
+			"event_state": state,
+			"event_seq":   gorm.Expr("event_seq + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ClaimRun takes a trigger's concurrency slot for owner until the given
+// lease expiry (unix ms) and reports whether it did. The slot is free when
+// no run holds it or the holder's lease expired before now.
+func (dao *AgentTriggerDAO) ClaimRun(id, owner string, now, until int64) (bool, error) {
+	res := DB.Model(&entity.AgentTrigger{}).
+		Where("id = ? AND (run_owner = '' OR run_lease_until < ?)", id, now).
+		Updates(map[string]interface{}{"run_owner": owner, "run_lease_until": until})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ReplaceRun hands a trigger's concurrency slot to owner whoever holds it.
+// The previous holder notices on its next RenewRun and cancels its run.
+func (dao *AgentTriggerDAO) ReplaceRun(id, owner string, until int64) error {
+	return DB.Model(&entity.AgentTrigger{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"run_owner": owner, "run_lease_until": until}).Error
+}
+
+// RenewRun extends owner's lease and reports whether owner still holds
+// the slot.
+func (dao *AgentTriggerDAO) RenewRun(id, owner string, until int64) (bool, error) {
+	res := DB.Model(&entity.AgentTrigger{}).
+		Where("id = ? AND run_owner = ?", id, owner).
+		Update("run_lease_until", until)
+	return res.RowsAffected == 1, res.Error
+}
+
+// PushRunQueue stores queue as the trigger's queued firings unless another
+// replica changed the queue since seq was read, and reports whether it did.
+func (dao *AgentTriggerDAO) PushRunQueue(id string, seq int64, queue entity.JSONSlice) (bool, error) {
+	res := DB.Model(&entity.AgentTrigger{}).
+		Where("id = ? AND run_seq = ?", id, seq).
+		Updates(map[string]interface{}{
+			"run_queue": queue,
+			"run_seq":   gorm.Expr("run_seq + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// PopRunQueue stores rest as the queued firings after owner took the head
+// of the queue read at seq, renewing its lease, and reports whether it did.
+func (dao *AgentTriggerDAO) PopRunQueue(id, owner string, seq int64, rest entity.JSONSlice, until int64) (bool, error) {
+	res := DB.Model(&entity.AgentTrigger{}).
+		Where("id = ? AND run_owner = ? AND run_seq = ?", id, owner, seq).
+		Updates(map[string]interface{}{
+			"run_queue":       rest,
+			"run_seq":         gorm.Expr("run_seq + 1"),
+			"run_lease_until": until,
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ReleaseRun frees owner's slot unless a firing was queued since seq was
+// read, and reports whether it did.
+func (dao *AgentTriggerDAO) ReleaseRun(id, owner string, seq int64) (bool, error) {
+	res := DB.Model(&entity.AgentTrigger{}).
+		Where("id = ? AND run_owner = ? AND run_seq = ?", id, owner, seq).
+		Updates(map[string]interface{}{"run_owner": "", "run_lease_until": 0})
+	return res.RowsAffected == 1, res.Error
+}
+
+// Delete removes a single trigger by id. No-op when the row is absent.
+func (dao *AgentTriggerDAO) Delete(id string) error {
+	return DB.Where("id = ?", id).Delete(&entity.AgentTrigger{}).Error
+}
+
+// DeleteByCanvasIDTx removes every trigger of the given canvas inside tx.
+// Used by service.AgentService.DeleteAgent's cascade.
+func (dao *AgentTriggerDAO) DeleteByCanvasIDTx(tx *gorm.DB, canvasID string) (int64, error) {
+	res := tx.Where("user_canvas_id = ?", canvasID).Delete(&entity.AgentTrigger{})
+	return res.RowsAffected, res.Error
+}
@@ -440,3 +440,17 @@ func (dao *ConnectorDAO) ListLogsByConnectorID(connectorID string, offset, limit
 
 	return logs, total, nil
 }
+
+// ListFinishedSyncLogsSince returns the connector's completed sync runs
+// updated after the (update_time, id) cursor, oldest first. Used by the
+// agent trigger "connector.synced" event watcher.
+func (dao *ConnectorDAO) ListFinishedSyncLogsSince(connectorID string, since int64, afterID string, limit int) ([]*entity.SyncLogs, error) {
+	var logs []*entity.SyncLogs
+	err := DB.Where("connector_id = ? AND status = ?", connectorID, string(entity.TaskStatusDone)).
+		Where("update_time > ? OR (update_time = ? AND id > ?)", since, since, afterID).
+		Order("update_time ASC").
+		Order("id ASC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
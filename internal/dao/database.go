@@ -129,6 +129,8 @@ func InitDB() error {
 		&entity.UserCanvas{},
 		&entity.CanvasTemplate{},
 		&entity.UserCanvasVersion{},
+		&entity.AgentTrigger{},
+		&entity.AgentRunReport{},
 		&entity.LLMFactories{},
 		&entity.LLM{},
 		&entity.TenantLangfuse{},
@@ -137,6 +139,8 @@ func InitDB() error {
 		&entity.Connector2Kb{},
 		&entity.SyncLogs{},
 		&entity.MCPServer{},
+		&entity.OpenAPISpec{},
+		&entity.EmailAccount{},
 		&entity.Memory{},
 		&entity.Search{},
 		&entity.PipelineOperationLog{},
@@ -308,3 +308,17 @@ func (dao *DocumentDAO) ListNamesByKbID(kbID string) ([]string, error) {
 	}
 	return names, nil
 }
+
+// ListParsedSince returns documents of a dataset whose parsing finished
+// (run = DONE) after the (update_time, id) cursor, oldest first. Used by
+// the agent trigger "document.parsed" event watcher.
+func (dao *DocumentDAO) ListParsedSince(kbID string, since int64, afterID string, limit int) ([]*entity.Document, error) {
+	var documents []*entity.Document
+	err := DB.Where("kb_id = ? AND run = ?", kbID, string(entity.TaskStatusDone)).
+		Where("update_time > ? OR (update_time = ? AND id > ?)", since, since, afterID).
+		Order("update_time ASC").
+		Order("id ASC").
+		Limit(limit).
+		Find(&documents).Error
+	return documents, err
+}
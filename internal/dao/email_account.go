@@ -0,0 +1,96 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package dao
+
+import (
+	"errors"
+	"strings"
+
+	"ragflow/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+// EmailAccountDAO email account data access object.
+type EmailAccountDAO struct{}
+
+// NewEmailAccountDAO creates an email account DAO.
+func NewEmailAccountDAO() *EmailAccountDAO {
+	return &EmailAccountDAO{}
+}
+
+// ExistsByNameAndTenant returns whether an account name already exists for a tenant.
+func (dao *EmailAccountDAO) ExistsByNameAndTenant(name, tenantID string) (bool, error) {
+	var count int64
+	if err := DB.Model(&entity.EmailAccount{}).
+		Where("name = ? AND tenant_id = ?", name, tenantID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Create creates an email account.
+func (dao *EmailAccountDAO) Create(account *entity.EmailAccount) error {
+	return DB.Create(account).Error
+}
+
+// GetByIDAndTenant returns an account owned by a tenant, or nil when it
+// does not exist.
+func (dao *EmailAccountDAO) GetByIDAndTenant(id, tenantID string) (*entity.EmailAccount, error) {
+	var account entity.EmailAccount
+	if err := DB.Where("id = ? AND tenant_id = ?", id, tenantID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListByTenant returns the accounts owned by a tenant, newest first.
+func (dao *EmailAccountDAO) ListByTenant(tenantID, keywords string) ([]*entity.EmailAccount, error) {
+	var accounts []*entity.EmailAccount
+	query := DB.Model(&entity.EmailAccount{}).Where("tenant_id = ?", tenantID)
+	if keywords != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(keywords)+"%")
+	}
+	if err := query.Order("create_date DESC").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Update updates an account owned by a tenant.
+func (dao *EmailAccountDAO) Update(id, tenantID string, updates map[string]interface{}) (bool, error) {
+	result := DB.Model(&entity.EmailAccount{}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete deletes an account owned by a tenant.
+func (dao *EmailAccountDAO) Delete(id, tenantID string) (bool, error) {
+	result := DB.Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&entity.EmailAccount{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,98 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package dao
+
+import (
+	"errors"
+	"strings"
+
+	"ragflow/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+// OpenAPISpecDAO OpenAPI spec data access object.
+type OpenAPISpecDAO struct{}
+
+// NewOpenAPISpecDAO creates an OpenAPI spec DAO.
+func NewOpenAPISpecDAO() *OpenAPISpecDAO {
+	return &OpenAPISpecDAO{}
+}
+
+// ExistsByNameAndTenant returns whether a spec name already exists for a tenant.
+func (dao *OpenAPISpecDAO) ExistsByNameAndTenant(name, tenantID string) (bool, error) {
+	var count int64
+	if err := DB.Model(&entity.OpenAPISpec{}).
+		Where("name = ? AND tenant_id = ?", name, tenantID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Create creates an OpenAPI spec.
+func (dao *OpenAPISpecDAO) Create(spec *entity.OpenAPISpec) error {
+	return DB.Create(spec).Error
+}
+
+// GetByIDAndTenant returns a spec owned by a tenant, or nil when it
+// does not exist.
+func (dao *OpenAPISpecDAO) GetByIDAndTenant(id, tenantID string) (*entity.OpenAPISpec, error) {
+	var spec entity.OpenAPISpec
+	if err := DB.Where("id = ? AND tenant_id = ?", id, tenantID).First(&spec).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// ListByTenant returns the specs owned by a tenant, newest first. The
+// raw document is included because callers derive the operation list
+// from it.
+func (dao *OpenAPISpecDAO) ListByTenant(tenantID, keywords string) ([]*entity.OpenAPISpec, error) {
+	var specs []*entity.OpenAPISpec
+	query := DB.Model(&entity.OpenAPISpec{}).Where("tenant_id = ?", tenantID)
+	if keywords != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(keywords)+"%")
+	}
+	if err := query.Order("create_date DESC").Find(&specs).Error; err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// Update updates a spec owned by a tenant.
+func (dao *OpenAPISpecDAO) Update(id, tenantID string, updates map[string]interface{}) (bool, error) {
+	result := DB.Model(&entity.OpenAPISpec{}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete deletes a spec owned by a tenant.
+func (dao *OpenAPISpecDAO) Delete(id, tenantID string) (bool, error) {
+	result := DB.Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&entity.OpenAPISpec{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -110,3 +110,25 @@ func (dao *TenantModelInstanceDAO) DeleteByProviderIDAndInstanceName(providerID,
 	result := DB.Unscoped().Where("provider_id = ? and instance_name = ?", providerID, instanceName).Delete(&entity.TenantModelInstance{})
 	return result.RowsAffected, result.Error
 }
+
+// ListActive returns every instance whose status is active, across all
+// tenants. Used by the background model health prober.
+func (dao *TenantModelInstanceDAO) ListActive() ([]*entity.TenantModelInstance, error) {
+	var instances []*entity.TenantModelInstance
+	err := DB.Where("status = ?", "active").Find(&instances).Error
+	if err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+// UpdateHealth persists the health probe fields of an instance.
+func (dao *TenantModelInstanceDAO) UpdateHealth(id string, healthStatus string, latencyMs int64, probeError string, failures int, probeTime int64) error {
+	return DB.Model(&entity.TenantModelInstance{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"health_status":    healthStatus,
+		"probe_latency_ms": latencyMs,
+		"probe_error":      probeError,
+		"probe_failures":   failures,
+		"last_probe_time":  probeTime,
+	}).Error
+}
@@ -306,6 +306,12 @@ func (e *infinityEngine) InsertChunks(ctx context.Context, chunks []map[string]i
 		}
 	}
 
+	// Image vectors live in their own img_<dim>_vec column; add it the
+	// first time a chunk carries one.
+	if err := e.ensureImageVectorColumns(table, chunks); err != nil {
+		return nil, err
+	}
+
 	// Get embedding columns and their sizes
 	var embeddingCols [][2]interface{}
 	colsResp, err := table.ShowColumns()
@@ -370,6 +376,57 @@ func (e *infinityEngine) InsertChunks(ctx context.Context, chunks []map[string]i
 	return []string{}, nil
 }
 
+// imageVectorPattern matches the image embedding columns written for
+// multimodal datasets (img_<dim>_vec), kept apart from the q_<dim>_vec
+// text vectors.
+var imageVectorPattern = regexp.MustCompile(`^img_(\d+)_vec$`)
+
+// ensureImageVectorColumns adds any img_<dim>_vec column referenced by
+// chunks that the table does not have yet, together with its HNSW index.
+func (e *infinityEngine) ensureImageVectorColumns(table *infinity.Table, chunks []map[string]interface{}) error {
+	seen := make(map[string]int)
+	for _, chunk := range chunks {
+		for key := range chunk {
+			if matches := imageVectorPattern.FindStringSubmatch(key); len(matches) == 2 {
+				size, _ := strconv.Atoi(matches[1])
+				seen[key] = size
+			}
+		}
+	}
+	for colName, size := range seen {
+		exists, err := e.columnExists(table, colName)
+		if err != nil {
+			return fmt.Errorf("Failed to check column %s: %w", colName, err)
+		}
+		if exists {
+			continue
+		}
+		common.Info("Adding image vector column", zap.String("column", colName), zap.Int("size", size))
+		if _, err := table.AddColumns(infinity.TableSchema{
+			&infinity.ColumnDefinition{
+				Name:     colName,
+				DataType: fmt.Sprintf("vector,%d,float", size),
+			},
+		}); err != nil {
+			return fmt.Errorf("Failed to add image vector column %s: %w", colName, err)
+		}
+		if _, err := table.CreateIndex(
+			colName+"_idx",
+			infinity.NewIndexInfo(colName, infinity.IndexTypeHnsw, map[string]string{
+				"M":               "16",
+				"ef_construction": "50",
+				"metric":          "cosine",
+				"encode":          "lvq",
+			}),
+			infinity.ConflictTypeIgnore,
+			"",
+		); err != nil {
+			return fmt.Errorf("Failed to create HNSW index for %s: %w", colName, err)
+		}
+	}
+	return nil
+}
+
 // UpdateChunks updates chunks in a dataset table
 // Table name format: {baseName}_{datasetID}
 func (e *infinityEngine) UpdateChunks(ctx context.Context, condition map[string]interface{}, newValue map[string]interface{}, baseName string, datasetID string) error {
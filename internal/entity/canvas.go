@@ -68,3 +68,87 @@ type UserCanvasVersion struct {
 func (UserCanvasVersion) TableName() string {
 	return "user_canvas_version"
 }
+
+// Agent trigger types, policies and states.
+const (
+	AgentTriggerTypeSchedule = "schedule"
+	AgentTriggerTypeEvent    = "event"
+
+	AgentTriggerEventDocumentParsed  = "document.parsed"
+	AgentTriggerEventConnectorSynced = "connector.synced"
+	AgentTriggerEventMemoryThreshold = "memory.threshold"
+	AgentTriggerEventEmailReceived   = "email.received"
+
+	AgentTriggerConcurrencySkip    = "skip"
+	AgentTriggerConcurrencyQueue   = "queue"
+	AgentTriggerConcurrencyReplace = "replace"
+
+	AgentTriggerStatusActive = "active"
+	AgentTriggerStatusPaused = "paused"
+)
+
+// AgentTrigger starts runs of a published UserCanvasVersion on a cron
+// schedule or when an internal event occurs.
+type AgentTrigger struct {
+	ID                string  `gorm:"column:id;primaryKey;size:32" json:"id"`
+	UserCanvasID      string  `gorm:"column:user_canvas_id;size:255;not null;index" json:"user_canvas_id"`
+	VersionID         string  `gorm:"column:version_id;size:32;not null;index" json:"version_id"`
+	UserID            string  `gorm:"column:user_id;size:255;not null;index" json:"user_id"`
+	Name              string  `gorm:"column:name;size:128;not null;default:''" json:"name"`
+	TriggerType       string  `gorm:"column:trigger_type;size:16;not null;index" json:"trigger_type"`
+	Cron              string  `gorm:"column:cron;size:128;not null;default:''" json:"cron,omitempty"`
+	Timezone          string  `gorm:"column:timezone;size:64;not null;default:'UTC'" json:"timezone,omitempty"`
+	Event             string  `gorm:"column:event;size:32;not null;default:'';index" json:"event,omitempty"`
+	EventFilter       JSONMap `gorm:"column:event_filter;type:longtext" json:"event_filter,omitempty"`
+	Query             string  `gorm:"column:query;type:text" json:"query"`
+	ConcurrencyPolicy string  `gorm:"column:concurrency_policy;size:16;not null;default:'skip'" json:"concurrency_policy"`
+	Status            string  `gorm:"column:status;size:16;not null;default:'active';index" json:"status"`
+	NextFireTime      *int64  `gorm:"column:next_fire_time;index" json:"next_fire_time,omitempty"`
+	LastFireTime      *int64  `gorm:"column:last_fire_time" json:"last_fire_time,omitempty"`
+	LastSessionID     string  `gorm:"column:last_session_id;size:32;not null;default:''" json:"last_session_id"`
+	LastError         string  `gorm:"column:last_error;size:512;not null;default:''" json:"last_error"`
+	// EventState is the watermark the event watcher keeps between polls.
+	EventState JSONMap `gorm:"column:event_state;type:longtext" json:"-"`
+	// EventSeq counts stored watermarks; scheduler replicas compare-and-set
+	// on it so only one of them fires the events of a poll.
+	EventSeq int64 `gorm:"column:event_seq;not null;default:0" json:"-"`
+	// RunOwner is the token of the run holding the trigger's concurrency
+	// slot, empty when none is in flight. Its replica renews RunLeaseUntil
+	// (unix ms) while the run lives, so a crashed replica frees the slot.
+	RunOwner      string `gorm:"column:run_owner;size:32;not null;default:''" json:"-"`
+	RunLeaseUntil int64  `gorm:"column:run_lease_until;not null;default:0" json:"-"`
+	// RunQueue holds the firings a "queue" trigger waits to run; RunSeq
+	// counts its writes so replicas compare-and-set on it.
+	RunQueue JSONSlice `gorm:"column:run_queue;type:longtext" json:"-"`
+	RunSeq   int64     `gorm:"column:run_seq;not null;default:0" json:"-"`
+	BaseModel
+}
+
+// TableName specify table name
+func (AgentTrigger) TableName() string {
+	return "agent_trigger"
+}
+
+// AgentRunReport is the cost and latency report of one canvas run,
+// stored with the session it belongs to. Report holds the full per-node
+// breakdown; the scalar columns duplicate its totals for listing.
+type AgentRunReport struct {
+	ID            string  `gorm:"column:id;primaryKey;size:32" json:"id"`
+	UserCanvasID  string  `gorm:"column:user_canvas_id;size:255;not null;index" json:"user_canvas_id"`
+	SessionID     string  `gorm:"column:session_id;size:32;not null;index" json:"session_id"`
+	TaskID        string  `gorm:"column:task_id;size:32;not null;default:''" json:"task_id"`
+	VersionID     string  `gorm:"column:version_id;size:32;not null;default:''" json:"version_id"`
+	UserID        string  `gorm:"column:user_id;size:255;not null;default:''" json:"user_id"`
+	Status        string  `gorm:"column:status;size:16;not null;default:''" json:"status"`
+	StartedAt     int64   `gorm:"column:started_at;not null;default:0" json:"started_at"`
+	DurationMs    float64 `gorm:"column:duration_ms;not null;default:0" json:"duration_ms"`
+	TotalTokens   int     `gorm:"column:total_tokens;not null;default:0" json:"total_tokens"`
+	EstimatedCost float64 `gorm:"column:estimated_cost;not null;default:0" json:"estimated_cost"`
+	Report        JSONMap `gorm:"column:report;type:longtext" json:"report,omitempty"`
+	BaseModel
+}
+
+// TableName specify table name
+func (AgentRunReport) TableName() string {
+	return "agent_run_report"
+}
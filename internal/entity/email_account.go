@@ -0,0 +1,42 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package entity
+
+// EmailAccount is an IMAP mailbox registered by a tenant. Each account
+// is exposed to the Agent component as a tool named "imap:<id>" and can
+// feed "email.received" triggers.
+type EmailAccount struct {
+	ID       string `gorm:"column:id;primaryKey;size:32" json:"id"`
+	Name     string `gorm:"column:name;size:255;not null" json:"name"`
+	TenantID string `gorm:"column:tenant_id;size:32;not null;index" json:"tenant_id"`
+	Host     string `gorm:"column:host;size:255;not null" json:"host"`
+	Port     int    `gorm:"column:port;not null" json:"port"`
+	// Security is "tls" (implicit TLS) or "starttls".
+	Security string `gorm:"column:security;size:16;not null" json:"security"`
+	Username string `gorm:"column:username;size:255;not null" json:"username"`
+	// Mailbox is the default mailbox the tool searches.
+	Mailbox     string  `gorm:"column:mailbox;size:255;not null;default:INBOX" json:"mailbox"`
+	Description *string `gorm:"column:description;type:longtext" json:"description,omitempty"`
+	// Credentials holds {"password": ...}; it is never returned by the API.
+	Credentials JSONMap `gorm:"column:credentials;type:longtext" json:"credentials,omitempty"`
+	BaseModel
+}
+
+// TableName specify table name
+func (EmailAccount) TableName() string {
+	return "email_account"
+}
@@ -983,3 +983,8 @@ func (a *AI302Model) ShowTask(taskID string, apiConfig *APIConfig) (*TaskRespons
 		},
 	}, nil
 }
+
+// GenerateImage generate images from a text prompt
+func (a *AI302Model) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s no such method", a.Name())
+}
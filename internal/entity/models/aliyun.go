@@ -628,3 +628,8 @@ func (a *AliyunModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (a *AliyunModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", a.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (a *AliyunModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", a.Name())
+}
@@ -91,7 +91,7 @@ func (a *AnthropicModel) ChatWithMessages(modelName string, messages []Message,
 		"messages":   apiMessages,
 		"max_tokens": 1024,
 	}
-	if systemPrompt != "" {
+	if systemPrompt != nil {
 		reqBody["system"] = systemPrompt
 	}
 	applyAnthropicChatConfig(reqBody, chatModelConfig)
@@ -128,9 +128,15 @@ func (a *AnthropicModel) ChatWithMessages(modelName string, messages []Message,
 	if err != nil {
 		return nil, err
 	}
+	if chatModelConfig != nil && chatModelConfig.ResponseSchema != nil {
+		answer = unwrapAnthropicStructuredOutput(answer, chatModelConfig.ResponseSchema)
+	}
+	usage := anthropicChatUsage(body)
+	reportChatUsage(chatModelConfig, usage)
 	return &ChatResponse{
 		Answer:        &answer,
 		ReasonContent: &reasoning,
+		Usage:         usage,
 	}, nil
 }
 
@@ -150,6 +156,11 @@ func applyAnthropicChatConfig(reqBody map[string]interface{}, chatModelConfig *C
 	if chatModelConfig.Stop != nil {
 		reqBody["stop_sequences"] = *chatModelConfig.Stop
 	}
+	if chatModelConfig.ResponseSchema != nil {
+		tool, choice := anthropicStructuredOutputTool(chatModelConfig.ResponseSchema)
+		reqBody["tools"] = []interface{}{tool}
+		reqBody["tool_choice"] = choice
+	}
 }
 
 func setAnthropicHeaders(req *http.Request, apiKey string) {
@@ -159,14 +170,37 @@ func setAnthropicHeaders(req *http.Request, apiKey string) {
 	req.Header.Set("anthropic-version", anthropicVersion)
 }
 
-func anthropicMessages(messages []Message) ([]map[string]interface{}, string, error) {
+// anthropicMaxCacheBreakpoints is the number of cache_control markers the
+// Messages API accepts per request.
+const anthropicMaxCacheBreakpoints = 4
+
+// anthropicMessages converts messages to the Messages API shape. System
+// messages are merged into the returned system value, which is nil when
+// there is none, a string normally, and a cache-marked text block list
+// when any system message carries CacheBreakpoint. Only the last
+// breakpoints that fit the API limit are honoured.
+func anthropicMessages(messages []Message) ([]map[string]interface{}, interface{}, error) {
 	apiMessages := make([]map[string]interface{}, 0, len(messages))
 	systemPrompts := make([]string, 0)
+	systemCached := false
+	budget := anthropicMaxCacheBreakpoints
+	for _, msg := range messages {
+		if msg.CacheBreakpoint && strings.EqualFold(strings.TrimSpace(msg.Role), "system") && !systemCached {
+			systemCached = true
+			budget--
+		}
+	}
+	skip := -budget
+	for _, msg := range messages {
+		if msg.CacheBreakpoint && !strings.EqualFold(strings.TrimSpace(msg.Role), "system") {
+			skip++
+		}
+	}
 	for _, msg := range messages {
 		role := strings.ToLower(strings.TrimSpace(msg.Role))
 		content, err := anthropicContent(msg.Content)
 		if err != nil {
-			return nil, "", err
+			return nil, nil, err
 		}
 		switch role {
 		case "system":
@@ -174,18 +208,85 @@ func anthropicMessages(messages []Message) ([]map[string]interface{}, string, er
 				systemPrompts = append(systemPrompts, text)
 			}
 		case "user", "assistant":
+			if msg.CacheBreakpoint {
+				if skip > 0 {
+					skip--
+				} else {
+					content = anthropicCachedContent(content)
+				}
+			}
 			apiMessages = append(apiMessages, map[string]interface{}{
 				"role":    role,
 				"content": content,
 			})
 		default:
-			return nil, "", fmt.Errorf("anthropic: unsupported message role %q", msg.Role)
+			return nil, nil, fmt.Errorf("anthropic: unsupported message role %q", msg.Role)
 		}
 	}
 	if len(apiMessages) == 0 {
-		return nil, "", fmt.Errorf("messages is empty")
+		return nil, nil, fmt.Errorf("messages is empty")
+	}
+	system := strings.Join(systemPrompts, "\n\n")
+	if system == "" {
+		return apiMessages, nil, nil
+	}
+	if systemCached {
+		return apiMessages, []map[string]interface{}{
+			{"type": "text", "text": system, "cache_control": anthropicEphemeralCache},
+		}, nil
+	}
+	return apiMessages, system, nil
+}
+
+// anthropicCachedContent puts cache_control on the last content block,
+// promoting plain string content to a single text block first.
+func anthropicCachedContent(content interface{}) interface{} {
+	switch value := content.(type) {
+	case string:
+		return []map[string]interface{}{
+			{"type": "text", "text": value, "cache_control": anthropicEphemeralCache},
+		}
+	case []map[string]interface{}:
+		if len(value) == 0 {
+			return value
+		}
+		blocks := append([]map[string]interface{}(nil), value...)
+		last := make(map[string]interface{}, len(blocks[len(blocks)-1])+1)
+		for k, v := range blocks[len(blocks)-1] {
+			last[k] = v
+		}
+		last["cache_control"] = anthropicEphemeralCache
+		blocks[len(blocks)-1] = last
+		return blocks
+	}
+	return content
+}
+
+// anthropicChatUsage reads the usage block, including prompt cache reads
+// and writes, from a Messages API response.
+func anthropicChatUsage(body []byte) *ChatUsage {
+	var result struct {
+		Usage *struct {
+			InputTokens              int `json:"input_tokens"`
+			OutputTokens             int `json:"output_tokens"`
+			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Usage == nil {
+		return nil
+	}
+	u := result.Usage
+	// input_tokens excludes cached tokens; fold them back in so
+	// PromptTokens means the same thing across providers.
+	prompt := u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens
+	return &ChatUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
 	}
-	return apiMessages, strings.Join(systemPrompts, "\n\n"), nil
 }
 
 func anthropicSystemText(content interface{}) (string, bool) {
@@ -339,9 +440,11 @@ func parseDataImageURL(url string) (string, string, error) {
 func parseAnthropicChatResponse(body []byte) (string, string, error) {
 	var result struct {
 		Content []struct {
-			Type     string `json:"type"`
-			Text     string `json:"text"`
-			Thinking string `json:"thinking"`
+			Type     string          `json:"type"`
+			Text     string          `json:"text"`
+			Thinking string          `json:"thinking"`
+			Name     string          `json:"name"`
+			Input    json.RawMessage `json:"input"`
 		} `json:"content"`
 	}
 	if err := json.Unmarshal(body, &result); err != nil {
@@ -353,20 +456,45 @@ func parseAnthropicChatResponse(body []byte) (string, string, error) {
 
 	var answer strings.Builder
 	var reasoning strings.Builder
+	var structured string
 	for _, block := range result.Content {
 		switch block.Type {
 		case "text":
 			answer.WriteString(block.Text)
 		case "thinking":
 			reasoning.WriteString(block.Thinking)
+		case "tool_use":
+			if block.Name == structuredOutputToolName {
+				structured = string(block.Input)
+			}
 		}
 	}
+	// A forced structured_output call carries the answer as tool input;
+	// any accompanying text is preamble.
+	if structured != "" {
+		return structured, reasoning.String(), nil
+	}
 	if answer.Len() == 0 {
 		return "", "", fmt.Errorf("no text content in Anthropic response")
 	}
 	return answer.String(), reasoning.String(), nil
 }
 
+// unwrapAnthropicStructuredOutput undoes the "value" wrapping applied by
+// anthropicInputSchema to non-object schemas.
+func unwrapAnthropicStructuredOutput(answer string, rs *ResponseSchema) string {
+	if t, _ := rs.Schema["type"].(string); t == "object" {
+		return answer
+	}
+	var wrapped struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(answer), &wrapped); err != nil || len(wrapped.Value) == 0 {
+		return answer
+	}
+	return string(wrapped.Value)
+}
+
 func (a *AnthropicModel) ListModels(apiConfig *APIConfig) ([]ListModelResponse, error) {
 	if err := a.baseModel.APIConfigCheck(apiConfig); err != nil {
 		return nil, err
@@ -479,3 +607,8 @@ func (a *AnthropicModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, erro
 func (a *AnthropicModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", a.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (a *AnthropicModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", a.Name())
+}
@@ -598,3 +598,8 @@ func (a *AstraflowModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, erro
 func (a *AstraflowModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", a.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (a *AstraflowModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", a.Name())
+}
@@ -364,3 +364,8 @@ func (a *AvianModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error) {
 func (a *AvianModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", a.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (a *AvianModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", a.Name())
+}
@@ -418,3 +418,8 @@ func (b *BaichuanModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (b *BaichuanModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", b.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (b *BaichuanModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -1073,3 +1073,8 @@ func (b *BedrockModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (b *BedrockModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", b.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (b *BedrockModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", b.Name())
+}
@@ -176,6 +176,11 @@ func (b *BuiltinModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskRespo
 	return nil, fmt.Errorf("builtin model does not support tasks")
 }
 
+// GenerateImage generate images from a text prompt
+func (b *BuiltinModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", b.Name())
+}
+
 // GetBuiltinEmbeddingModel returns a Builtin model driver for the given model name
 func GetBuiltinEmbeddingModel(modelName string) ModelDriver {
 	// Get TEI base URL from environment or config
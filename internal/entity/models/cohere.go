@@ -719,3 +719,8 @@ func (c *CoHereModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (c *CoHereModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", c.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (c *CoHereModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", c.Name())
+}
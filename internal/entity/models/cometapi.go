@@ -738,3 +738,8 @@ func (c *CometAPIModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (c *CometAPIModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", c.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (c *CometAPIModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -938,3 +938,8 @@ func (d *DeepInfraModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, erro
 func (d *DeepInfraModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s no such method", d.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (d *DeepInfraModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s no such method", d.Name())
+}
@@ -218,7 +218,9 @@ func (d *DeepSeekModel) ChatWithMessages(modelName string, messages []Message, a
 	chatResponse := &ChatResponse{
 		Answer:        &content,
 		ReasonContent: &reasonContent,
+		Usage:         openAIChatUsage(result["usage"]),
 	}
+	reportChatUsage(chatModelConfig, chatResponse.Usage)
 
 	return chatResponse, nil
 }
@@ -356,6 +358,12 @@ func (d *DeepSeekModel) ChatStreamlyWithSender(modelName string, messages []Mess
 	done, err := ParseSSEStream[map[string]interface{}](resp.Body, func(event map[string]interface{}) error {
 		common.Info(fmt.Sprintf("%v", event))
 
+		// DeepSeek attaches usage, including prompt_cache_hit_tokens, to
+		// the final chunk.
+		if usage := openAIChatUsage(event["usage"]); usage != nil {
+			reportChatUsage(chatModelConfig, usage)
+		}
+
 		choices, ok := event["choices"].([]interface{})
 		if !ok || len(choices) == 0 {
 			return nil
@@ -593,3 +601,8 @@ func (d *DeepSeekModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (d *DeepSeekModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", d.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (d *DeepSeekModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", d.Name())
+}
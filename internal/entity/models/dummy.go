@@ -110,3 +110,8 @@ func (d *DummyModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error) {
 func (d *DummyModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", d.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (d *DummyModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -453,3 +453,8 @@ func (f *FishAudioModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, erro
 func (f *FishAudioModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", f.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (f *FishAudioModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", f.Name())
+}
@@ -346,3 +346,8 @@ func (f *FuturMixModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (f *FuturMixModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", f.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (f *FuturMixModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", f.Name())
+}
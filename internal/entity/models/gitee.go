@@ -1139,3 +1139,8 @@ func (g *GiteeModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskRespons
 
 	return taskResp, nil
 }
+
+// GenerateImage generate images from a text prompt
+func (g *GiteeModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", g.Name())
+}
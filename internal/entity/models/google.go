@@ -119,6 +119,43 @@ func (g *GoogleModel) baseURL(apiConfig *APIConfig) string {
 	return strings.TrimSpace(baseURL)
 }
 
+// googleGenerateConfig maps ChatConfig.ResponseSchema onto Gemini's
+// responseJsonSchema and ChatConfig.CachedContent onto cachedContent;
+// nil keeps the SDK defaults.
+func googleGenerateConfig(chatModelConfig *ChatConfig) *genai.GenerateContentConfig {
+	if chatModelConfig == nil {
+		return nil
+	}
+	var cfg *genai.GenerateContentConfig
+	if chatModelConfig.ResponseSchema != nil {
+		cfg = &genai.GenerateContentConfig{
+			ResponseMIMEType:   "application/json",
+			ResponseJsonSchema: chatModelConfig.ResponseSchema.Schema,
+		}
+	}
+	if chatModelConfig.CachedContent != nil && *chatModelConfig.CachedContent != "" {
+		if cfg == nil {
+			cfg = &genai.GenerateContentConfig{}
+		}
+		cfg.CachedContent = *chatModelConfig.CachedContent
+	}
+	return cfg
+}
+
+// googleChatUsage converts Gemini usage metadata. cachedContentTokenCount
+// covers both explicit cachedContents and implicit cache hits.
+func googleChatUsage(metadata *genai.GenerateContentResponseUsageMetadata) *ChatUsage {
+	if metadata == nil {
+		return nil
+	}
+	return &ChatUsage{
+		PromptTokens:     int(metadata.PromptTokenCount),
+		CompletionTokens: int(metadata.CandidatesTokenCount),
+		TotalTokens:      int(metadata.TotalTokenCount),
+		CacheReadTokens:  int(metadata.CachedContentTokenCount),
+	}
+}
+
 func (g *GoogleModel) ChatWithMessages(modelName string, messages []Message, apiConfig *APIConfig, chatModelConfig *ChatConfig) (*ChatResponse, error) {
 	if err := g.baseModel.APIConfigCheck(apiConfig); err != nil {
 		return nil, err
@@ -181,15 +218,17 @@ func (g *GoogleModel) ChatWithMessages(modelName string, messages []Message, api
 	}
 
 	// Generate content (non-streaming)
-	response, err := client.Models.GenerateContent(ctx, modelName, contents, nil)
+	response, err := client.Models.GenerateContent(ctx, modelName, contents, googleGenerateConfig(chatModelConfig))
 	if err != nil {
 		return nil, err
 	}
 
 	// Extract text from response
 	answer := response.Text()
+	usage := googleChatUsage(response.UsageMetadata)
+	reportChatUsage(chatModelConfig, usage)
 
-	return &ChatResponse{Answer: &answer}, nil
+	return &ChatResponse{Answer: &answer, Usage: usage}, nil
 }
 
 // ChatStreamlyWithSender sends messages and streams response via sender function (best performance, no channel)
@@ -261,11 +300,14 @@ func (g *GoogleModel) ChatStreamlyWithSender(modelName string, messages []Messag
 		ctx,
 		modelName,
 		contents,
-		nil,
+		googleGenerateConfig(chatModelConfig),
 	) {
 		if err != nil {
 			return err
 		}
+		if usage := googleChatUsage(response.UsageMetadata); usage != nil {
+			reportChatUsage(chatModelConfig, usage)
+		}
 
 		content := response.Text()
 
@@ -409,3 +451,8 @@ func (g *GoogleModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (g *GoogleModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", g.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (g *GoogleModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
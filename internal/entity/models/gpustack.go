@@ -506,3 +506,8 @@ func (g *GPUStackModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (g *GPUStackModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", g.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (g *GPUStackModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", g.Name())
+}
@@ -553,3 +553,8 @@ func (g *GroqModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error) {
 func (g *GroqModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", g.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (g *GroqModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", g.Name())
+}
@@ -504,3 +504,8 @@ func (h *HuggingFaceModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, er
 func (h *HuggingFaceModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", h.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (h *HuggingFaceModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
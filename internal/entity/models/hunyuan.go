@@ -453,3 +453,8 @@ func (h *HunyuanModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (h *HunyuanModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", h.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (h *HunyuanModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", h.Name())
+}
@@ -0,0 +1,132 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// openAIImageGenerationResponse is the `images/generations` response shape
+// shared by OpenAI and the providers that mirror it (ZHIPU CogView, ...).
+type openAIImageGenerationResponse struct {
+	Data []struct {
+		URL           string `json:"url"`
+		B64JSON       string `json:"b64_json"`
+		RevisedPrompt string `json:"revised_prompt"`
+	} `json:"data"`
+}
+
+// imageGenerationURL resolves the image generation endpoint, failing when the
+// provider config does not declare an `image_generation` URL suffix.
+func imageGenerationURL(b *BaseModel, providerName string, apiConfig *APIConfig) (string, error) {
+	if strings.TrimSpace(b.URLSuffix.Image) == "" {
+		return "", fmt.Errorf("%s image generation URL suffix is required", providerName)
+	}
+	baseURL, err := b.GetBaseURL(apiConfig)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), strings.TrimPrefix(b.URLSuffix.Image, "/")), nil
+}
+
+// generateOpenAICompatibleImage calls an OpenAI-compatible `images/generations`
+// endpoint. Optional config fields are only sent when set; Params is merged
+// last so callers can pass provider-specific fields through.
+func generateOpenAICompatibleImage(b *BaseModel, providerName string, modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	if err := b.APIConfigCheck(apiConfig); err != nil {
+		return nil, err
+	}
+	if modelName == nil || *modelName == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return nil, fmt.Errorf("prompt is empty")
+	}
+
+	url, err := imageGenerationURL(b, providerName, apiConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody := map[string]interface{}{
+		"model":  *modelName,
+		"prompt": prompt,
+	}
+	if imageConfig != nil {
+		if imageConfig.N > 0 {
+			reqBody["n"] = imageConfig.N
+		}
+		if imageConfig.Size != "" {
+			reqBody["size"] = imageConfig.Size
+		}
+		if imageConfig.Quality != "" {
+			reqBody["quality"] = imageConfig.Quality
+		}
+		if imageConfig.Style != "" {
+			reqBody["style"] = imageConfig.Style
+		}
+		if imageConfig.ResponseFormat != "" {
+			reqBody["response_format"] = imageConfig.ResponseFormat
+		}
+		for key, value := range imageConfig.Params {
+			reqBody[key] = value
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), longOpCallTimeout)
+	defer cancel()
+
+	resp, err := PostJSONRequest(ctx, b.httpClient, url, BearerAuth(apiConfig), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s image generation API error: %s, body: %s", providerName, resp.Status, string(body))
+	}
+
+	var parsed openAIImageGenerationResponse
+	if err = json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	result := &ImageGenerationResponse{Images: make([]GeneratedImage, 0, len(parsed.Data))}
+	for _, item := range parsed.Data {
+		if item.URL == "" && item.B64JSON == "" {
+			continue
+		}
+		result.Images = append(result.Images, GeneratedImage{
+			URL:           item.URL,
+			B64JSON:       item.B64JSON,
+			RevisedPrompt: item.RevisedPrompt,
+		})
+	}
+	if len(result.Images) == 0 {
+		return nil, fmt.Errorf("%s image generation returned no images", providerName)
+	}
+	return result, nil
+}
@@ -589,3 +589,8 @@ func (j *JieKouAIModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (j *JieKouAIModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s no such method", j.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (j *JieKouAIModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", j.Name())
+}
@@ -188,6 +188,10 @@ func (j *JinaModel) Embed(modelName *string, texts []string, apiConfig *APIConfi
 		"model": *modelName,
 		"input": texts,
 	}
+	if isImageEmbeddingInput(embeddingConfig) {
+		// jina-clip-v2 / jina-embeddings-v4 take {"image": url-or-base64}.
+		reqBody["input"] = clipStyleImageInputs(texts, true)
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -414,3 +418,8 @@ func (j *JinaModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error) {
 func (j *JinaModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", j.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (j *JinaModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -238,3 +238,39 @@ func TestJinaChatFallsBackToDefaultOnEmptyRegion(t *testing.T) {
 		t.Errorf("empty Region: expected fallback to default, got %v", err)
 	}
 }
+
+func TestJinaEmbedImageInputsStripDataURI(t *testing.T) {
+	srv := newJinaServer(t, "/embeddings", func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		input, ok := body["input"].([]interface{})
+		if !ok || len(input) != 2 {
+			t.Fatalf("input=%v", body["input"])
+		}
+		first, _ := input[0].(map[string]interface{})
+		second, _ := input[1].(map[string]interface{})
+		if first["image"] != "https://example.com/a.png" {
+			t.Errorf("input[0]=%v", first)
+		}
+		if second["image"] != "iVBORw0KGgo=" {
+			t.Errorf("input[1]=%v, want bare base64", second)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"embedding": []float64{0.1, 0.2}, "index": 0},
+				{"embedding": []float64{0.3, 0.4}, "index": 1},
+			},
+		})
+	})
+	defer srv.Close()
+
+	apiKey := "test-key"
+	model := "jina-clip-v2"
+	vecs, err := newJinaForTest(srv.URL).Embed(&model,
+		[]string{"https://example.com/a.png", "data:image/png;base64,iVBORw0KGgo="},
+		&APIConfig{ApiKey: &apiKey}, &EmbeddingConfig{InputType: EmbeddingInputImage})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vecs) != 2 {
+		t.Fatalf("len=%d want 2", len(vecs))
+	}
+}
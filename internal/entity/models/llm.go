@@ -72,6 +72,10 @@ func (m *EinoChatModel) name() string {
 	return *m.inner.ModelName
 }
 
+// CacheBreakpointExtraKey is the schema.Message.Extra key that maps onto
+// Message.CacheBreakpoint when eino messages reach a driver.
+const CacheBreakpointExtraKey = "cache_breakpoint"
+
 // toInternalMessages converts eino's []schema.Message into the existing
 // RAGFlow []Message type. System / user / assistant roles are preserved;
 // tool-role messages are mapped to "tool" (the existing model layer already
@@ -89,7 +93,8 @@ func toInternalMessages(msgs []*schema.Message) []Message {
 		if role == "" {
 			role = "user"
 		}
-		out = append(out, Message{Role: role, Content: mm.Content})
+		cacheBreakpoint, _ := mm.Extra[CacheBreakpointExtraKey].(bool)
+		out = append(out, Message{Role: role, Content: mm.Content, CacheBreakpoint: cacheBreakpoint})
 	}
 	return out
 }
@@ -124,11 +129,30 @@ func (m *EinoChatModel) Generate(ctx context.Context, msgs []*schema.Message, op
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	resp, err := m.inner.ModelDriver.ChatWithMessages(*m.inner.ModelName, internal, m.inner.APIConfig, m.chatCfg)
+	// Capture usage per call (the wrapper may be shared) and surface it
+	// on ResponseMeta so eino model callbacks can account for it.
+	cfg := ChatConfig{}
+	if m.chatCfg != nil {
+		cfg = *m.chatCfg
+	}
+	usage := &ChatUsage{}
+	cfg.UsageResult = usage
+	resp, err := m.inner.ModelDriver.ChatWithMessages(*m.inner.ModelName, internal, m.inner.APIConfig, &cfg)
 	if err != nil {
 		return nil, fmt.Errorf("models: EinoChatModel.Generate(%s): %w", *m.inner.ModelName, err)
 	}
-	return fromInternalResponse(resp), nil
+	if m.chatCfg != nil && m.chatCfg.UsageResult != nil {
+		*m.chatCfg.UsageResult = *usage
+	}
+	out := fromInternalResponse(resp)
+	if usage.PromptTokens != 0 || usage.CompletionTokens != 0 || usage.TotalTokens != 0 {
+		out.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}}
+	}
+	return out, nil
 }
 
 // Stream returns a schema.StreamReader that yields message chunks
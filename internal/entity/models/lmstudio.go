@@ -552,3 +552,8 @@ func (l *LmStudioModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (l *LmStudioModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", l.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (l *LmStudioModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
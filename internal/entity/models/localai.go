@@ -648,3 +648,8 @@ func (l *LocalAIModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (l *LocalAIModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", l.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (l *LocalAIModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -414,3 +414,8 @@ func (l *LongCatModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (l *LongCatModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", l.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (l *LongCatModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", l.Name())
+}
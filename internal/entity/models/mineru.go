@@ -267,3 +267,8 @@ func (m *MinerUModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskRespon
 		},
 	}, nil
 }
+
+// GenerateImage generate images from a text prompt
+func (m *MinerUModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s no such method", m.Name())
+}
@@ -274,3 +274,8 @@ func (m *MinerULocalModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskR
 		},
 	}, nil
 }
+
+// GenerateImage generate images from a text prompt
+func (m *MinerULocalModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s no such method", m.Name())
+}
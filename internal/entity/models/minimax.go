@@ -639,3 +639,8 @@ func (m *MinimaxModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (m *MinimaxModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", m.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (m *MinimaxModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", m.Name())
+}
@@ -616,3 +616,8 @@ func (m *MistralModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (m *MistralModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", m.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (m *MistralModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", m.Name())
+}
@@ -346,8 +346,19 @@ func TestSiliconFlowProviderConfigLoadsLatestProModels(t *testing.T) {
 	if provider.ModelDriver.Name() != "siliconflow" {
 		t.Errorf("ModelDriver.Name()=%q", provider.ModelDriver.Name())
 	}
-	if len(provider.Models) != 12 {
-		t.Fatalf("SiliconFlow model count=%d, want 12", len(provider.Models))
+	if provider.URLSuffix.Image != "images/generations" {
+		t.Errorf("image_generation suffix=%q", provider.URLSuffix.Image)
+	}
+	if len(provider.Models) != 14 {
+		t.Fatalf("SiliconFlow model count=%d, want 14", len(provider.Models))
+	}
+
+	kolors, err := pm.GetModelByName("SiliconFlow", "Kwai-Kolors/Kolors")
+	if err != nil {
+		t.Fatalf("GetModelByName Kolors: %v", err)
+	}
+	if !kolors.ModelTypeMap["image_generation"] {
+		t.Errorf("Kolors model types=%v, want image_generation", kolors.ModelTypes)
 	}
 
 	deepSeekV4Pro, err := pm.GetModelByName("SiliconFlow", "Pro/deepseek-ai/DeepSeek-V4-Pro")
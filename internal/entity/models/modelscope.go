@@ -427,3 +427,8 @@ func (m *ModelScopeModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, err
 func (m *ModelScopeModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", m.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (m *ModelScopeModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", m.Name())
+}
@@ -517,3 +517,8 @@ func (m *MoonshotModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (m *MoonshotModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", m.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (m *MoonshotModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", m.Name())
+}
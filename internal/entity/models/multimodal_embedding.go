@@ -0,0 +1,66 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package models
+
+import "strings"
+
+// imageEmbeddingDrivers lists the drivers whose Embed understands
+// EmbeddingInputImage. Other drivers would embed the image URL as text.
+var imageEmbeddingDrivers = map[string]bool{
+	"jina":       true,
+	"voyage":     true,
+	"xinference": true,
+}
+
+// SupportsImageEmbedding reports whether driver can embed image inputs.
+func SupportsImageEmbedding(driver ModelDriver) bool {
+	return driver != nil && imageEmbeddingDrivers[driver.Name()]
+}
+
+func isImageEmbeddingInput(embeddingConfig *EmbeddingConfig) bool {
+	return embeddingConfig != nil && embeddingConfig.InputType == EmbeddingInputImage
+}
+
+func isDataURI(s string) bool {
+	return strings.HasPrefix(s, "data:")
+}
+
+// stripDataURIPrefix turns `data:image/png;base64,AAAA` into `AAAA`;
+// URLs and bare base64 pass through unchanged.
+func stripDataURIPrefix(s string) string {
+	if !isDataURI(s) {
+		return s
+	}
+	if idx := strings.Index(s, ","); idx >= 0 {
+		return s[idx+1:]
+	}
+	return s
+}
+
+// clipStyleImageInputs builds the `[{"image": ...}]` input list used by
+// Jina CLIP / v4 models and CLIP models served by Xinference. Jina wants
+// bare base64, so stripDataURI drops the data URI header.
+func clipStyleImageInputs(images []string, stripDataURI bool) []map[string]string {
+	inputs := make([]map[string]string, len(images))
+	for i, image := range images {
+		if stripDataURI {
+			image = stripDataURIPrefix(image)
+		}
+		inputs[i] = map[string]string{"image": image}
+	}
+	return inputs
+}
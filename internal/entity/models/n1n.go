@@ -583,3 +583,8 @@ func (n *N1NModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error) {
 func (n *N1NModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", n.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (n *N1NModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", n.Name())
+}
@@ -858,3 +858,8 @@ func (n *NovitaModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (n *NovitaModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", n.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (n *NovitaModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", n.Name())
+}
@@ -636,3 +636,8 @@ func (n *NvidiaModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (n *NvidiaModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", n.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (n *NvidiaModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", n.Name())
+}
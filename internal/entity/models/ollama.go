@@ -136,6 +136,10 @@ func (o *OllamaModel) ChatWithMessages(modelName string, messages []Message, api
 				reqBody["think"] = true
 			}
 		}
+
+		if chatModelConfig.ResponseSchema != nil {
+			reqBody["format"] = chatModelConfig.ResponseSchema.Schema
+		}
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -259,6 +263,10 @@ func (o *OllamaModel) ChatStreamlyWithSender(modelName string, messages []Messag
 		}
 	}
 
+	if modelConfig.ResponseSchema != nil {
+		reqBody["format"] = modelConfig.ResponseSchema.Schema
+	}
+
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
@@ -542,3 +550,8 @@ func (o *OllamaModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (o *OllamaModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", o.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (o *OllamaModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -121,6 +121,14 @@ func (o *OpenAIModel) ChatWithMessages(modelName string, messages []Message, api
 			}
 			reqBody["tool_choice"] = tc
 		}
+
+		if chatModelConfig.ResponseSchema != nil {
+			reqBody["response_format"] = openAIResponseFormat(chatModelConfig.ResponseSchema)
+		}
+
+		if chatModelConfig.PromptCacheKey != nil && *chatModelConfig.PromptCacheKey != "" {
+			reqBody["prompt_cache_key"] = *chatModelConfig.PromptCacheKey
+		}
 	}
 
 	// Qwen3 family: disable thinking by default (matches Python's
@@ -209,7 +217,9 @@ func (o *OpenAIModel) ChatWithMessages(modelName string, messages []Message, api
 		Answer:        &content,
 		ReasonContent: &reasonContent,
 		ToolCalls:     toolCalls,
+		Usage:         openAIChatUsage(result["usage"]),
 	}
+	reportChatUsage(chatModelConfig, chatResponse.Usage)
 
 	return chatResponse, nil
 }
@@ -283,6 +293,19 @@ func (o *OpenAIModel) ChatStreamlyWithSender(modelName string, messages []Messag
 			}
 			reqBody["tool_choice"] = tc
 		}
+
+		if chatModelConfig.ResponseSchema != nil {
+			reqBody["response_format"] = openAIResponseFormat(chatModelConfig.ResponseSchema)
+		}
+
+		if chatModelConfig.PromptCacheKey != nil && *chatModelConfig.PromptCacheKey != "" {
+			reqBody["prompt_cache_key"] = *chatModelConfig.PromptCacheKey
+		}
+	}
+
+	// Usage (and with it cached_tokens) is only sent on streams that ask.
+	if chatModelConfig != nil && chatModelConfig.UsageResult != nil {
+		reqBody["stream_options"] = map[string]interface{}{"include_usage": true}
 	}
 
 	// Qwen3 family: disable thinking by default.
@@ -340,6 +363,11 @@ func (o *OpenAIModel) ChatStreamlyWithSender(modelName string, messages []Messag
 			continue
 		}
 
+		// The include_usage chunk arrives last with empty choices.
+		if usage := openAIChatUsage(event["usage"]); usage != nil {
+			reportChatUsage(chatModelConfig, usage)
+		}
+
 		choices, ok := event["choices"].([]interface{})
 		if !ok || len(choices) == 0 {
 			continue
@@ -1025,6 +1053,11 @@ func (o *OpenAIModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskRespon
 	return nil, fmt.Errorf("%s, no such method", o.Name())
 }
 
+// GenerateImage generate images from a text prompt
+func (o *OpenAIModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return generateOpenAICompatibleImage(&o.baseModel, o.Name(), modelName, prompt, apiConfig, imageConfig)
+}
+
 func cloneMap(m map[string]interface{}) map[string]interface{} {
 	cp := make(map[string]interface{}, len(m))
 	for k, v := range m {
@@ -20,6 +20,7 @@ func newOpenAIForTest(baseURL string) *OpenAIModel {
 			Embedding: "embeddings",
 			ASR:       "audio/transcriptions",
 			TTS:       "audio/speech",
+			Image:     "images/generations",
 		},
 	)
 }
@@ -412,3 +413,70 @@ func TestOpenAIAudioSpeechWithSenderStreamsSSEAudioDeltas(t *testing.T) {
 		t.Fatalf("streamed audio=%q, want audio-bytes", got)
 	}
 }
+
+func TestOpenAIGenerateImagePostsJSONToImagesEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/generations" {
+			t.Errorf("path=%s, want /images/generations", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization=%q, want Bearer test-key", got)
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if body["model"] != "gpt-image-1" {
+			t.Errorf("model=%v, want gpt-image-1", body["model"])
+		}
+		if body["prompt"] != "a red fox" {
+			t.Errorf("prompt=%v, want a red fox", body["prompt"])
+		}
+		if body["size"] != "1024x1024" {
+			t.Errorf("size=%v, want 1024x1024", body["size"])
+		}
+		if body["n"] != float64(2) {
+			t.Errorf("n=%v, want 2", body["n"])
+		}
+		if _, ok := body["style"]; ok {
+			t.Errorf("style should be omitted when unset, got %v", body["style"])
+		}
+
+		_, _ = w.Write([]byte(`{"data":[{"b64_json":"aGVsbG8=","revised_prompt":"a red fox in snow"},{"url":"https://img.example/2.png"}]}`))
+	}))
+	defer srv.Close()
+
+	apiKey := "test-key"
+	model := "gpt-image-1"
+	resp, err := newOpenAIForTest(srv.URL).GenerateImage(
+		&model,
+		"a red fox",
+		&APIConfig{ApiKey: &apiKey},
+		&ImageGenerationConfig{Size: "1024x1024", N: 2},
+	)
+	if err != nil {
+		t.Fatalf("GenerateImage: %v", err)
+	}
+	if len(resp.Images) != 2 {
+		t.Fatalf("images=%d, want 2", len(resp.Images))
+	}
+	if resp.Images[0].B64JSON != "aGVsbG8=" || resp.Images[0].RevisedPrompt != "a red fox in snow" {
+		t.Fatalf("first image=%+v", resp.Images[0])
+	}
+	if resp.Images[1].URL != "https://img.example/2.png" {
+		t.Fatalf("second image url=%q", resp.Images[1].URL)
+	}
+}
+
+func TestOpenAIGenerateImageRequiresPrompt(t *testing.T) {
+	apiKey := "test-key"
+	model := "gpt-image-1"
+
+	_, err := newOpenAIForTest("http://unused").GenerateImage(&model, "  ", &APIConfig{ApiKey: &apiKey}, nil)
+	if err == nil || !strings.Contains(err.Error(), "prompt is empty") {
+		t.Fatalf("err=%v, want prompt is empty", err)
+	}
+}
@@ -836,3 +836,8 @@ func (o *OpenRouterModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, err
 func (o *OpenRouterModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", o.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (o *OpenRouterModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", o.Name())
+}
@@ -461,3 +461,8 @@ func (o *OrcaRouterModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, err
 func (o *OrcaRouterModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s no such method", o.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (o *OrcaRouterModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s no such method", o.Name())
+}
@@ -341,3 +341,8 @@ func (p *PaddleOCRModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, erro
 func (p *PaddleOCRModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", p.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (p *PaddleOCRModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", p.Name())
+}
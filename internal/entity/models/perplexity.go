@@ -462,3 +462,8 @@ func (p *PerplexityModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, err
 func (p *PerplexityModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", p.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (p *PerplexityModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", p.Name())
+}
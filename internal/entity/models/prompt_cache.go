@@ -0,0 +1,68 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package models
+
+// ChatUsage is the token accounting a provider reports for one chat call.
+// CacheReadTokens counts prompt tokens served from the provider's prompt
+// cache; CacheWriteTokens counts tokens written to it (Anthropic only).
+type ChatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	CacheReadTokens  int `json:"cache_read_tokens"`
+	CacheWriteTokens int `json:"cache_write_tokens"`
+}
+
+// anthropicEphemeralCache is the cache_control value Anthropic accepts on
+// system, message and tool blocks.
+var anthropicEphemeralCache = map[string]interface{}{"type": "ephemeral"}
+
+// reportChatUsage copies usage into chatModelConfig.UsageResult when the
+// caller asked for it. Streaming callers have no ChatResponse, so this is
+// how they learn about cache hits.
+func reportChatUsage(chatModelConfig *ChatConfig, usage *ChatUsage) {
+	if chatModelConfig == nil || chatModelConfig.UsageResult == nil || usage == nil {
+		return
+	}
+	*chatModelConfig.UsageResult = *usage
+}
+
+// openAIChatUsage reads an OpenAI-compatible "usage" object. OpenAI
+// reports automatic cache hits in prompt_tokens_details.cached_tokens;
+// DeepSeek reports them as prompt_cache_hit_tokens.
+func openAIChatUsage(raw interface{}) *ChatUsage {
+	usage, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	intField := func(m map[string]interface{}, key string) int {
+		n, _ := m[key].(float64)
+		return int(n)
+	}
+	out := &ChatUsage{
+		PromptTokens:     intField(usage, "prompt_tokens"),
+		CompletionTokens: intField(usage, "completion_tokens"),
+		TotalTokens:      intField(usage, "total_tokens"),
+		CacheReadTokens:  intField(usage, "prompt_cache_hit_tokens"),
+	}
+	if details, ok := usage["prompt_tokens_details"].(map[string]interface{}); ok {
+		if cached := intField(details, "cached_tokens"); cached > 0 {
+			out.CacheReadTokens = cached
+		}
+	}
+	return out
+}
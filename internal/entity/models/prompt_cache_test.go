@@ -0,0 +1,143 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnthropicChatMarksCacheBreakpoints(t *testing.T) {
+	srv := newAnthropicServer(t, "/v1/messages", func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		system, ok := body["system"].([]interface{})
+		if !ok || len(system) != 1 {
+			t.Fatalf("system=%v, want one cached text block", body["system"])
+		}
+		if cc := system[0].(map[string]interface{})["cache_control"]; cc == nil {
+			t.Errorf("system block missing cache_control: %v", system[0])
+		}
+		msgs := body["messages"].([]interface{})
+		history := msgs[1].(map[string]interface{})["content"].([]interface{})
+		if cc := history[0].(map[string]interface{})["cache_control"]; cc == nil {
+			t.Errorf("history message missing cache_control: %v", msgs[1])
+		}
+		if _, ok := msgs[2].(map[string]interface{})["content"].(string); !ok {
+			t.Errorf("newest turn should stay a plain string: %v", msgs[2])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]interface{}{{"type": "text", "text": "ok"}},
+			"usage": map[string]interface{}{
+				"input_tokens":                10,
+				"output_tokens":               5,
+				"cache_read_input_tokens":     2000,
+				"cache_creation_input_tokens": 0,
+			},
+		})
+	})
+	defer srv.Close()
+
+	apiKey := "test-key"
+	usage := &ChatUsage{}
+	resp, err := newAnthropicForTest(srv.URL).ChatWithMessages("claude-sonnet-4-5-20250929", []Message{
+		{Role: "system", Content: "long stable instructions", CacheBreakpoint: true},
+		{Role: "user", Content: "q1"},
+		{Role: "assistant", Content: "a1", CacheBreakpoint: true},
+		{Role: "user", Content: "q2"},
+	}, &APIConfig{ApiKey: &apiKey}, &ChatConfig{UsageResult: usage})
+	if err != nil {
+		t.Fatalf("ChatWithMessages: %v", err)
+	}
+	want := ChatUsage{PromptTokens: 2010, CompletionTokens: 5, TotalTokens: 2015, CacheReadTokens: 2000}
+	if resp.Usage == nil || *resp.Usage != want {
+		t.Errorf("Usage=%+v, want %+v", resp.Usage, want)
+	}
+	if *usage != want {
+		t.Errorf("UsageResult=%+v, want %+v", *usage, want)
+	}
+}
+
+func TestAnthropicMessagesCapsCacheBreakpoints(t *testing.T) {
+	messages := []Message{{Role: "system", Content: "s", CacheBreakpoint: true}}
+	for i := 0; i < 5; i++ {
+		messages = append(messages, Message{Role: "user", Content: "u", CacheBreakpoint: true})
+	}
+	apiMessages, _, err := anthropicMessages(messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marked := 0
+	for _, msg := range apiMessages {
+		if _, ok := msg["content"].([]map[string]interface{}); ok {
+			marked++
+		}
+	}
+	if marked != anthropicMaxCacheBreakpoints-1 {
+		t.Errorf("marked=%d, want %d alongside the system block", marked, anthropicMaxCacheBreakpoints-1)
+	}
+	if _, ok := apiMessages[0]["content"].(string); !ok {
+		t.Errorf("earliest breakpoints should be dropped first")
+	}
+}
+
+func TestOpenAIChatReportsCachedTokens(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(raw, &body)
+		if body["prompt_cache_key"] != "dialog-1" {
+			t.Errorf("prompt_cache_key=%v", body["prompt_cache_key"])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"content": "ok"}}},
+			"usage": map[string]interface{}{
+				"prompt_tokens":         2048,
+				"completion_tokens":     8,
+				"total_tokens":          2056,
+				"prompt_tokens_details": map[string]interface{}{"cached_tokens": 1920},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	apiKey := "test-key"
+	key := "dialog-1"
+	resp, err := newOpenAIForTest(srv.URL).ChatWithMessages("gpt-4o", []Message{{Role: "user", Content: "q"}},
+		&APIConfig{ApiKey: &apiKey}, &ChatConfig{PromptCacheKey: &key})
+	if err != nil {
+		t.Fatalf("ChatWithMessages: %v", err)
+	}
+	if resp.Usage == nil || resp.Usage.CacheReadTokens != 1920 || resp.Usage.PromptTokens != 2048 {
+		t.Errorf("Usage=%+v", resp.Usage)
+	}
+}
+
+func TestOpenAIChatUsageReadsDeepSeekCacheHits(t *testing.T) {
+	usage := openAIChatUsage(map[string]interface{}{
+		"prompt_tokens":            float64(100),
+		"prompt_cache_hit_tokens":  float64(64),
+		"prompt_cache_miss_tokens": float64(36),
+	})
+	if usage == nil || usage.CacheReadTokens != 64 {
+		t.Errorf("usage=%+v, want 64 cached tokens", usage)
+	}
+	if openAIChatUsage(nil) != nil {
+		t.Error("missing usage should yield nil")
+	}
+}
@@ -863,3 +863,8 @@ func (r *ReplicateModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, erro
 func (r *ReplicateModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", r.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (r *ReplicateModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", r.Name())
+}
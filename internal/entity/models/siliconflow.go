@@ -1003,3 +1003,79 @@ func (s *SiliconflowModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, er
 func (s *SiliconflowModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", s.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (s *SiliconflowModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	if err := s.baseModel.APIConfigCheck(apiConfig); err != nil {
+		return nil, err
+	}
+	if modelName == nil || *modelName == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return nil, fmt.Errorf("prompt is empty")
+	}
+
+	url, err := imageGenerationURL(&s.baseModel, s.Name(), apiConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// SiliconFlow names the OpenAI `size`/`n` fields `image_size`/`batch_size`
+	// and returns `images` instead of `data`.
+	reqBody := map[string]interface{}{
+		"model":  *modelName,
+		"prompt": prompt,
+	}
+	if imageConfig != nil {
+		if imageConfig.Size != "" {
+			reqBody["image_size"] = imageConfig.Size
+		}
+		if imageConfig.N > 0 {
+			reqBody["batch_size"] = imageConfig.N
+		}
+		if imageConfig.NegativePrompt != "" {
+			reqBody["negative_prompt"] = imageConfig.NegativePrompt
+		}
+		for key, value := range imageConfig.Params {
+			reqBody[key] = value
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), longOpCallTimeout)
+	defer cancel()
+
+	resp, err := PostJSONRequest(ctx, s.baseModel.httpClient, url, BearerAuth(apiConfig), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SILICONFLOW image generation API error: %s, body: %s", resp.Status, string(body))
+	}
+
+	var parsed struct {
+		Images []struct {
+			URL string `json:"url"`
+		} `json:"images"`
+	}
+	if err = json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	result := &ImageGenerationResponse{Images: make([]GeneratedImage, 0, len(parsed.Images))}
+	for _, image := range parsed.Images {
+		if image.URL != "" {
+			result.Images = append(result.Images, GeneratedImage{URL: image.URL})
+		}
+	}
+	if len(result.Images) == 0 {
+		return nil, fmt.Errorf("SILICONFLOW image generation returned no images")
+	}
+	return result, nil
+}
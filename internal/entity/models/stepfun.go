@@ -528,3 +528,8 @@ func (s *StepFunModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (s *StepFunModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", s.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (s *StepFunModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -0,0 +1,387 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ResponseSchema asks a chat model for a single JSON value matching a
+// JSON schema. Drivers with native support map it onto their own
+// mechanism (see nativeStructuredOutputDrivers); ChatWithResponseSchema
+// covers the rest with a validate-and-repair loop.
+type ResponseSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict"`
+}
+
+// structuredOutputToolName is the forced tool Anthropic answers through.
+const structuredOutputToolName = "structured_output"
+
+// ErrStructuredOutput is returned when a response still violates the
+// schema after all repair attempts.
+var ErrStructuredOutput = errors.New("response does not match the requested JSON schema")
+
+// nativeStructuredOutputDrivers lists drivers that enforce
+// ChatConfig.ResponseSchema upstream: OpenAI response_format, Anthropic
+// tool forcing, Gemini responseJsonSchema and Ollama format.
+var nativeStructuredOutputDrivers = map[string]bool{
+	"openai":    true,
+	"anthropic": true,
+	"google":    true,
+	"ollama":    true,
+}
+
+// SupportsNativeStructuredOutput reports whether driver enforces
+// ChatConfig.ResponseSchema itself.
+func SupportsNativeStructuredOutput(driver ModelDriver) bool {
+	return driver != nil && nativeStructuredOutputDrivers[strings.ToLower(driver.Name())]
+}
+
+func (r *ResponseSchema) schemaName() string {
+	if r == nil || strings.TrimSpace(r.Name) == "" {
+		return "response"
+	}
+	return r.Name
+}
+
+// openAIResponseFormat maps a ResponseSchema onto OpenAI's
+// `response_format: {"type": "json_schema", ...}`.
+func openAIResponseFormat(r *ResponseSchema) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   r.schemaName(),
+			"schema": r.Schema,
+			"strict": r.Strict,
+		},
+	}
+}
+
+// anthropicStructuredOutputTool returns the tool definition and the
+// tool_choice that force Claude to answer with schema-shaped input.
+// Tool input must be an object, so non-object schemas are wrapped in a
+// "value" property and unwrapped again by parseAnthropicChatResponse.
+func anthropicStructuredOutputTool(r *ResponseSchema) (map[string]interface{}, map[string]interface{}) {
+	tool := map[string]interface{}{
+		"name":         structuredOutputToolName,
+		"description":  fmt.Sprintf("Respond with the %s as structured data.", r.schemaName()),
+		"input_schema": anthropicInputSchema(r.Schema),
+	}
+	choice := map[string]interface{}{"type": "tool", "name": structuredOutputToolName}
+	return tool, choice
+}
+
+func anthropicInputSchema(schema map[string]interface{}) map[string]interface{} {
+	if t, _ := schema["type"].(string); t == "object" {
+		return schema
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"value": schema},
+		"required":   []interface{}{"value"},
+	}
+}
+
+// structuredOutputInstruction is the prompt-side fallback for drivers
+// without native enforcement.
+func structuredOutputInstruction(r *ResponseSchema) string {
+	schema, _ := json.MarshalIndent(r.Schema, "", "  ")
+	return "Respond with a single JSON value that conforms to this JSON schema:\n" +
+		string(schema) + "\n" +
+		"Output ONLY the JSON, with no prose and no markdown code fences."
+}
+
+// ParseStructuredOutput decodes answer (tolerating a surrounding ```json
+// fence) and validates it against r. It returns the decoded value and the
+// schema violations, if any.
+func ParseStructuredOutput(answer string, r *ResponseSchema) (interface{}, []string) {
+	text := strings.TrimSpace(answer)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+		text = strings.TrimSpace(text)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, []string{fmt.Sprintf("response is not valid JSON: %v", err)}
+	}
+	if r == nil || r.Schema == nil {
+		return value, nil
+	}
+	return value, ValidateJSONSchema(value, r.Schema)
+}
+
+// ChatWithResponseSchema sends messages with chatConfig.ResponseSchema set
+// and returns the response together with its decoded JSON value. Drivers
+// without native support additionally get the schema as a system
+// instruction. Invalid responses are fed back to the model with the
+// violations for up to maxRepairs further attempts.
+func ChatWithResponseSchema(driver ModelDriver, modelName string, messages []Message, apiConfig *APIConfig, chatConfig *ChatConfig, maxRepairs int) (*ChatResponse, interface{}, error) {
+	if chatConfig == nil || chatConfig.ResponseSchema == nil {
+		return nil, nil, fmt.Errorf("response schema is required")
+	}
+	rs := chatConfig.ResponseSchema
+	conversation := append([]Message(nil), messages...)
+	if !SupportsNativeStructuredOutput(driver) {
+		conversation = withSystemInstruction(conversation, structuredOutputInstruction(rs))
+	}
+
+	var violations []string
+	for attempt := 0; attempt <= maxRepairs; attempt++ {
+		resp, err := driver.ChatWithMessages(modelName, conversation, apiConfig, chatConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp == nil || resp.Answer == nil {
+			return nil, nil, fmt.Errorf("empty response")
+		}
+		var value interface{}
+		value, violations = ParseStructuredOutput(*resp.Answer, rs)
+		if len(violations) == 0 {
+			canonical, err := json.Marshal(value)
+			if err == nil {
+				answer := string(canonical)
+				resp.Answer = &answer
+			}
+			return resp, value, nil
+		}
+		conversation = append(conversation,
+			Message{Role: "assistant", Content: *resp.Answer},
+			Message{Role: "user", Content: "Your previous response did not match the required JSON schema:\n- " +
+				strings.Join(violations, "\n- ") +
+				"\nReply again with only the corrected JSON."},
+		)
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrStructuredOutput, strings.Join(violations, "; "))
+}
+
+// withSystemInstruction appends instruction to the first system message,
+// or prepends a system message when there is none.
+func withSystemInstruction(messages []Message, instruction string) []Message {
+	for i, msg := range messages {
+		if msg.Role != "system" {
+			continue
+		}
+		if text, ok := msg.Content.(string); ok {
+			messages[i].Content = strings.TrimSpace(text + "\n\n" + instruction)
+			return messages
+		}
+	}
+	return append([]Message{{Role: "system", Content: instruction}}, messages...)
+}
+
+// ValidateJSONSchema checks value against the commonly used subset of
+// JSON schema: type, properties, required, additionalProperties, items,
+// enum, const, min/max length, min/max items and minimum/maximum. It
+// returns one message per violation, prefixed with the JSON path.
+func ValidateJSONSchema(value interface{}, schema map[string]interface{}) []string {
+	var violations []string
+	validateJSONSchemaAt("$", value, schema, &violations)
+	return violations
+}
+
+func validateJSONSchemaAt(path string, value interface{}, schema map[string]interface{}, violations *[]string) {
+	if schema == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if jsonValueHasType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("expected %s, got %s", strings.Join(types, " or "), jsonTypeName(value))
+			return
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if jsonValuesEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value is not one of the allowed enum values")
+		}
+	}
+	if expected, ok := schema["const"]; ok && !jsonValuesEqual(expected, value) {
+		fail("value does not equal the required constant")
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range schemaStrings(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if propSchema, ok := properties[key].(map[string]interface{}); ok {
+				validateJSONSchemaAt(path+"."+key, v[key], propSchema, violations)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					fail("unexpected property %q", key)
+				}
+			case map[string]interface{}:
+				validateJSONSchemaAt(path+"."+key, v[key], extra, violations)
+			}
+		}
+	case []interface{}:
+		if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < n {
+			fail("expected at least %v items, got %d", n, len(v))
+		}
+		if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > n {
+			fail("expected at most %v items, got %d", n, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateJSONSchemaAt(fmt.Sprintf("%s[%d]", path, i), item, items, violations)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := schemaNumber(schema["minLength"]); ok && length < n {
+			fail("expected at least %v characters", n)
+		}
+		if n, ok := schemaNumber(schema["maxLength"]); ok && length > n {
+			fail("expected at most %v characters", n)
+		}
+	case float64:
+		if n, ok := schemaNumber(schema["minimum"]); ok && v < n {
+			fail("expected a value >= %v", n)
+		}
+		if n, ok := schemaNumber(schema["maximum"]); ok && v > n {
+			fail("expected a value <= %v", n)
+		}
+	}
+}
+
+func schemaTypes(raw interface{}) []string {
+	switch t := raw.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		return schemaStrings(t)
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func schemaStrings(raw interface{}) []string {
+	switch list := raw.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaNumber(raw interface{}) (float64, bool) {
+	switch n := raw.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func jsonValueHasType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func jsonValuesEqual(a, b interface{}) bool {
+	left, errA := json.Marshal(a)
+	right, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(left) == string(right)
+}
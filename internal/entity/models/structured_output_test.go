@@ -0,0 +1,242 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testAnswerSchema = &ResponseSchema{
+	Name: "answer",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"sql":   map[string]interface{}{"type": "string", "minLength": float64(1)},
+			"score": map[string]interface{}{"type": "integer", "minimum": float64(0)},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"enum": []interface{}{"a", "b"}}},
+		},
+		"required":             []interface{}{"sql"},
+		"additionalProperties": false,
+	},
+	Strict: true,
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"valid", `{"sql":"SELECT 1","score":3,"tags":["a"]}`, nil},
+		{"missing required", `{"score":1}`, []string{`$: missing required property "sql"`}},
+		{"wrong type", `{"sql":1}`, []string{"$.sql: expected string, got number"}},
+		{"not integer", `{"sql":"x","score":1.5}`, []string{"$.score: expected integer, got number"}},
+		{"below minimum", `{"sql":"x","score":-1}`, []string{"$.score: expected a value >= 0"}},
+		{"extra property", `{"sql":"x","extra":true}`, []string{`$: unexpected property "extra"`}},
+		{"enum item", `{"sql":"x","tags":["c"]}`, []string{"$.tags[0]: value is not one of the allowed enum values"}},
+		{"empty string", `{"sql":""}`, []string{"$.sql: expected at least 1 characters"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.input), &value); err != nil {
+				t.Fatal(err)
+			}
+			got := ValidateJSONSchema(value, testAnswerSchema.Schema)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("violations=%q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStructuredOutputStripsCodeFence(t *testing.T) {
+	value, violations := ParseStructuredOutput("```json\n{\"sql\":\"SELECT 1\"}\n```", testAnswerSchema)
+	if len(violations) != 0 {
+		t.Fatalf("violations=%v", violations)
+	}
+	if value.(map[string]interface{})["sql"] != "SELECT 1" {
+		t.Errorf("value=%v", value)
+	}
+	if _, violations := ParseStructuredOutput("not json", testAnswerSchema); len(violations) != 1 {
+		t.Errorf("violations=%v, want a JSON parse error", violations)
+	}
+}
+
+// scriptedChatDriver answers ChatWithMessages from a fixed script and
+// records the messages it received.
+type scriptedChatDriver struct {
+	ModelDriver
+	name    string
+	answers []string
+	calls   [][]Message
+}
+
+func (d *scriptedChatDriver) Name() string { return d.name }
+
+func (d *scriptedChatDriver) ChatWithMessages(modelName string, messages []Message, apiConfig *APIConfig, chatModelConfig *ChatConfig) (*ChatResponse, error) {
+	d.calls = append(d.calls, append([]Message(nil), messages...))
+	if len(d.calls) > len(d.answers) {
+		return nil, errors.New("unexpected call")
+	}
+	answer := d.answers[len(d.calls)-1]
+	return &ChatResponse{Answer: &answer}, nil
+}
+
+func TestChatWithResponseSchemaRepairsInvalidOutput(t *testing.T) {
+	driver := &scriptedChatDriver{name: "deepseek", answers: []string{
+		`{"query":"SELECT 1"}`,
+		"```json\n{\"sql\": \"SELECT 1\"}\n```",
+	}}
+	resp, value, err := ChatWithResponseSchema(driver, "m",
+		[]Message{{Role: "system", Content: "You write SQL."}, {Role: "user", Content: "one"}},
+		nil, &ChatConfig{ResponseSchema: testAnswerSchema}, 2)
+	if err != nil {
+		t.Fatalf("ChatWithResponseSchema: %v", err)
+	}
+	if *resp.Answer != `{"sql":"SELECT 1"}` {
+		t.Errorf("answer=%q, want canonical JSON", *resp.Answer)
+	}
+	if value.(map[string]interface{})["sql"] != "SELECT 1" {
+		t.Errorf("value=%v", value)
+	}
+	if len(driver.calls) != 2 {
+		t.Fatalf("calls=%d, want 2", len(driver.calls))
+	}
+	system := driver.calls[0][0].Content.(string)
+	if !strings.HasPrefix(system, "You write SQL.") || !strings.Contains(system, "JSON schema") {
+		t.Errorf("system prompt=%q, want schema instruction appended", system)
+	}
+	repair := driver.calls[1][len(driver.calls[1])-1].Content.(string)
+	if !strings.Contains(repair, `missing required property "sql"`) {
+		t.Errorf("repair prompt=%q, want the violation listed", repair)
+	}
+}
+
+func TestChatWithResponseSchemaGivesUpAfterRepairs(t *testing.T) {
+	driver := &scriptedChatDriver{name: "openai", answers: []string{"nope", "still nope"}}
+	_, _, err := ChatWithResponseSchema(driver, "m", []Message{{Role: "user", Content: "q"}}, nil,
+		&ChatConfig{ResponseSchema: testAnswerSchema}, 1)
+	if !errors.Is(err, ErrStructuredOutput) {
+		t.Fatalf("err=%v, want ErrStructuredOutput", err)
+	}
+	if _, ok := driver.calls[0][0].Content.(string); !ok || driver.calls[0][0].Role != "user" {
+		t.Errorf("native driver should not get a schema instruction, got %v", driver.calls[0])
+	}
+}
+
+func TestOpenAIChatSendsResponseFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("unmarshal: %v", err)
+		}
+		format, _ := body["response_format"].(map[string]interface{})
+		schema, _ := format["json_schema"].(map[string]interface{})
+		if format["type"] != "json_schema" || schema["name"] != "answer" || schema["strict"] != true {
+			t.Errorf("response_format=%v", body["response_format"])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"content": `{"sql":"SELECT 1"}`}}},
+		})
+	}))
+	defer srv.Close()
+
+	apiKey := "test-key"
+	resp, err := newOpenAIForTest(srv.URL).ChatWithMessages("gpt-4o", []Message{{Role: "user", Content: "q"}},
+		&APIConfig{ApiKey: &apiKey}, &ChatConfig{ResponseSchema: testAnswerSchema})
+	if err != nil {
+		t.Fatalf("ChatWithMessages: %v", err)
+	}
+	if *resp.Answer != `{"sql":"SELECT 1"}` {
+		t.Errorf("answer=%q", *resp.Answer)
+	}
+}
+
+func TestAnthropicChatForcesStructuredOutputTool(t *testing.T) {
+	srv := newAnthropicServer(t, "/v1/messages", func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		choice, _ := body["tool_choice"].(map[string]interface{})
+		if choice["type"] != "tool" || choice["name"] != structuredOutputToolName {
+			t.Errorf("tool_choice=%v", body["tool_choice"])
+		}
+		tools, _ := body["tools"].([]interface{})
+		if len(tools) != 1 {
+			t.Fatalf("tools=%v, want one", body["tools"])
+		}
+		schema := tools[0].(map[string]interface{})["input_schema"].(map[string]interface{})
+		if schema["type"] != "object" {
+			t.Errorf("input_schema=%v, want non-object schema wrapped", schema)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]interface{}{
+				{"type": "text", "text": "Here you go."},
+				{"type": "tool_use", "name": structuredOutputToolName, "input": map[string]interface{}{"value": []string{"a", "b"}}},
+			},
+		})
+	})
+	defer srv.Close()
+
+	apiKey := "test-key"
+	rs := &ResponseSchema{Name: "tags", Schema: map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}}
+	resp, err := newAnthropicForTest(srv.URL).ChatWithMessages("claude-sonnet-4-5-20250929",
+		[]Message{{Role: "user", Content: "q"}}, &APIConfig{ApiKey: &apiKey}, &ChatConfig{ResponseSchema: rs})
+	if err != nil {
+		t.Fatalf("ChatWithMessages: %v", err)
+	}
+	if *resp.Answer != `["a","b"]` {
+		t.Errorf("answer=%q, want unwrapped tool input", *resp.Answer)
+	}
+}
+
+func TestOllamaChatSendsFormatSchema(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("unmarshal: %v", err)
+		}
+		format, _ := body["format"].(map[string]interface{})
+		if format["type"] != "object" {
+			t.Errorf("format=%v, want the schema", body["format"])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"message": map[string]interface{}{"content": `{"sql":"SELECT 1"}`}})
+	}))
+	defer srv.Close()
+
+	driver := NewOllamaModel(map[string]string{"default": srv.URL}, URLSuffix{Chat: "api/chat"})
+	if _, err := driver.ChatWithMessages("llama3", []Message{{Role: "user", Content: "q"}}, &APIConfig{},
+		&ChatConfig{ResponseSchema: testAnswerSchema}); err != nil {
+		t.Fatalf("ChatWithMessages: %v", err)
+	}
+}
+
+func TestGoogleGenerateConfigMapsResponseSchema(t *testing.T) {
+	if googleGenerateConfig(&ChatConfig{}) != nil {
+		t.Error("expected nil config without a schema")
+	}
+	cfg := googleGenerateConfig(&ChatConfig{ResponseSchema: testAnswerSchema})
+	if cfg.ResponseMIMEType != "application/json" || cfg.ResponseJsonSchema == nil {
+		t.Errorf("config=%+v", cfg)
+	}
+}
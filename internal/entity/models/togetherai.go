@@ -787,3 +787,8 @@ func (t *TogetherAIModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, err
 func (t *TogetherAIModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", t.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (t *TogetherAIModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", t.Name())
+}
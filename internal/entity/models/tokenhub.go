@@ -505,3 +505,8 @@ func (t *TokenHubModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error
 func (t *TokenHubModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s no such method", t.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (t *TokenHubModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s no such method", t.Name())
+}
@@ -379,3 +379,8 @@ func (t *TokenPonyModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, erro
 func (t *TokenPonyModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", t.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (t *TokenPonyModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", t.Name())
+}
@@ -1,6 +1,9 @@
 package models
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // Message represents a chat message with role and content
 //
@@ -13,6 +16,10 @@ type Message struct {
 	Content    interface{}              `json:"content"`
 	ToolCallID string                   `json:"tool_call_id,omitempty"`
 	ToolCalls  []map[string]interface{} `json:"tool_calls,omitempty"`
+	// CacheBreakpoint marks the end of a stable prompt prefix. Drivers
+	// with explicit prompt caching (Anthropic) cache everything up to and
+	// including the last marked message; others ignore it.
+	CacheBreakpoint bool `json:"-"`
 }
 
 // ToolCallSession mirrors Python's common.mcp_tool_call_conn.ToolCallSession protocol.
@@ -44,6 +51,8 @@ type ModelDriver interface {
 	OCRFile(modelName *string, content []byte, url *string, apiConfig *APIConfig, ocrConfig *OCRConfig) (*OCRFileResponse, error)
 	// ParseFile parse file
 	ParseFile(modelName *string, content []byte, url *string, apiConfig *APIConfig, parseFileConfig *ParseFileConfig) (*ParseFileResponse, error)
+	// GenerateImage generate images from a text prompt
+	GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error)
 	// ListModels List supported models
 	ListModels(apiConfig *APIConfig) ([]ListModelResponse, error)
 
@@ -60,6 +69,7 @@ type ChatResponse struct {
 	Answer        *string                  `json:"answer"`
 	ReasonContent *string                  `json:"reason_content"`
 	ToolCalls     []map[string]interface{} `json:"tool_calls,omitempty"`
+	Usage         *ChatUsage               `json:"usage,omitempty"`
 }
 
 type EmbeddingData struct {
@@ -88,6 +98,18 @@ type OCRFileResponse struct {
 	Text *string `json:"text"`
 }
 
+// GeneratedImage is one image produced by GenerateImage. Providers return
+// either a (usually short-lived) URL or the base64-encoded payload.
+type GeneratedImage struct {
+	URL           string `json:"url,omitempty"`
+	B64JSON       string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+type ImageGenerationResponse struct {
+	Images []GeneratedImage `json:"images"`
+}
+
 type ListModelResponse struct {
 	Name         string         `json:"name"`
 	MaxTokens    *int           `json:"max_tokens"`
@@ -131,6 +153,8 @@ type URLSuffix struct {
 	ASR           string `json:"asr"`
 	OCR           string `json:"ocr"`
 	DocumentParse string `json:"doc_parse"`
+	Image         string `json:"image_generation"`
+	Multimodal    string `json:"multimodal_embedding"`
 	Models        string `json:"models"`
 	Balance       string `json:"balance"`
 	Files         string `json:"files"`
@@ -154,6 +178,17 @@ type ChatConfig struct {
 	Tools           interface{}               `json:"tools,omitempty"`
 	ToolChoice      *string                   `json:"tool_choice,omitempty"`
 	ToolCallsResult *[]map[string]interface{} `json:"-"`
+	// ResponseSchema, when set, asks for a JSON answer matching the schema.
+	ResponseSchema *ResponseSchema `json:"response_schema,omitempty"`
+	// PromptCacheKey groups requests sharing a prompt prefix so providers
+	// with automatic caching (OpenAI prompt_cache_key) route them together.
+	PromptCacheKey *string `json:"prompt_cache_key,omitempty"`
+	// CachedContent names a provider-side cache resource holding the
+	// prompt prefix (Gemini "cachedContents/...").
+	CachedContent *string `json:"cached_content,omitempty"`
+	// UsageResult, when non-nil, receives the provider-reported usage,
+	// including prompt cache hits, for streaming and non-streaming calls.
+	UsageResult *ChatUsage `json:"-"`
 }
 
 type APIConfig struct {
@@ -162,8 +197,17 @@ type APIConfig struct {
 	BaseURL *string
 }
 
+// Embedding input types. Image inputs travel in the texts slice of
+// Embed as http(s) URLs or base64 `data:image/...` URIs.
+const (
+	EmbeddingInputText  = "text"
+	EmbeddingInputImage = "image"
+)
+
 type EmbeddingConfig struct {
 	Dimension int
+	// InputType selects the modality of the inputs; empty means text.
+	InputType string
 }
 
 type RerankConfig struct {
@@ -182,6 +226,19 @@ type TTSConfig struct {
 type OCRConfig struct {
 }
 
+// ImageGenerationConfig carries the optional knobs of an image generation
+// request. Zero values are omitted from the upstream payload so each
+// provider applies its own defaults.
+type ImageGenerationConfig struct {
+	Size           string                 `json:"size"`
+	N              int                    `json:"n"`
+	Quality        string                 `json:"quality"`
+	Style          string                 `json:"style"`
+	NegativePrompt string                 `json:"negative_prompt"`
+	ResponseFormat string                 `json:"response_format"`
+	Params         map[string]interface{} `json:"params"`
+}
+
 type ParseFileConfig struct {
 }
 
@@ -203,6 +260,16 @@ func NewEmbeddingModel(driver ModelDriver, modelName *string, apiConfig *APIConf
 	}
 }
 
+// EmbedImages embeds images (URLs or base64 data URIs) with a multimodal
+// embedding model. The resulting vectors share a space with the model's
+// text embeddings, so text queries can be matched against them.
+func (e *EmbeddingModel) EmbedImages(images []string) ([]EmbeddingData, error) {
+	if !SupportsImageEmbedding(e.ModelDriver) {
+		return nil, fmt.Errorf("%s does not support image embeddings", e.ModelDriver.Name())
+	}
+	return e.ModelDriver.Embed(e.ModelName, images, e.APIConfig, &EmbeddingConfig{InputType: EmbeddingInputImage})
+}
+
 // RerankModel wraps a ModelDriver with rerank-specific configuration
 type RerankModel struct {
 	ModelDriver ModelDriver
@@ -501,3 +501,8 @@ func (u *UpstageModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (u *UpstageModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", u.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (u *UpstageModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -675,3 +675,8 @@ func (v *VllmModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error) {
 func (v *VllmModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", v.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (v *VllmModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -80,7 +80,7 @@ func (v *VoyageModel) Embed(modelName *string, texts []string, apiConfig *APICon
 		return nil, err
 	}
 	baseURL = strings.TrimSuffix(baseURL, "/")
-	url := fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), v.baseModel.URLSuffix.Embedding)
+	suffix := v.baseModel.URLSuffix.Embedding
 
 	reqBody := map[string]interface{}{
 		"model": *modelName,
@@ -89,6 +89,19 @@ func (v *VoyageModel) Embed(modelName *string, texts []string, apiConfig *APICon
 	if embeddingConfig != nil && embeddingConfig.Dimension > 0 {
 		reqBody["output_dimension"] = embeddingConfig.Dimension
 	}
+	// voyage-multimodal-* models only live on the multimodal endpoint,
+	// for text and image inputs alike.
+	if isImageEmbeddingInput(embeddingConfig) || strings.Contains(*modelName, "multimodal") {
+		if v.baseModel.URLSuffix.Multimodal == "" {
+			return nil, fmt.Errorf("voyage: no multimodal embedding URL suffix configured")
+		}
+		suffix = v.baseModel.URLSuffix.Multimodal
+		reqBody = map[string]interface{}{
+			"model":  *modelName,
+			"inputs": voyageMultimodalInputs(texts, isImageEmbeddingInput(embeddingConfig)),
+		}
+	}
+	url := fmt.Sprintf("%s/%s", baseURL, suffix)
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -150,6 +163,25 @@ func (v *VoyageModel) Embed(modelName *string, texts []string, apiConfig *APICon
 	return embeddings, nil
 }
 
+// voyageMultimodalInputs wraps each input as a single-item content list.
+// Images are sent by URL, or as base64 when given a data URI.
+func voyageMultimodalInputs(texts []string, images bool) []map[string]interface{} {
+	inputs := make([]map[string]interface{}, len(texts))
+	for i, text := range texts {
+		var content map[string]interface{}
+		switch {
+		case !images:
+			content = map[string]interface{}{"type": "text", "text": text}
+		case isDataURI(text):
+			content = map[string]interface{}{"type": "image_base64", "image_base64": text}
+		default:
+			content = map[string]interface{}{"type": "image_url", "image_url": text}
+		}
+		inputs[i] = map[string]interface{}{"content": []map[string]interface{}{content}}
+	}
+	return inputs
+}
+
 type voyageRerankRequest struct {
 	Model     string   `json:"model"`
 	Query     string   `json:"query"`
@@ -308,3 +340,8 @@ func (v *VoyageModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (v *VoyageModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", v.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (v *VoyageModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", v.Name())
+}
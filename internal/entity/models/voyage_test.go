@@ -411,3 +411,52 @@ func TestVoyageEmbedTrimsTrailingSlashInBaseURL(t *testing.T) {
 		t.Errorf("path=%q want %q (no double slash)", sawPath, "/v1/embeddings")
 	}
 }
+
+func TestVoyageEmbedImagesUseMultimodalEndpoint(t *testing.T) {
+	srv := newVoyageServer(t, "/v1/multimodalembeddings", func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		if _, ok := body["input"]; ok {
+			t.Errorf("multimodal request must use inputs, got input=%v", body["input"])
+		}
+		inputs, ok := body["inputs"].([]interface{})
+		if !ok || len(inputs) != 2 {
+			t.Fatalf("inputs=%v", body["inputs"])
+		}
+		types := make([]string, 0, 2)
+		for _, in := range inputs {
+			content := in.(map[string]interface{})["content"].([]interface{})
+			types = append(types, content[0].(map[string]interface{})["type"].(string))
+		}
+		if types[0] != "image_url" || types[1] != "image_base64" {
+			t.Errorf("content types=%v", types)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"embedding": []float64{0.1}, "index": 0},
+				{"embedding": []float64{0.2}, "index": 1},
+			},
+		})
+	})
+	defer srv.Close()
+
+	v := NewVoyageModel(map[string]string{"default": srv.URL},
+		URLSuffix{Embedding: "v1/embeddings", Multimodal: "v1/multimodalembeddings"})
+	apiKey := "test-key"
+	model := "voyage-multimodal-3"
+	vecs, err := v.Embed(&model, []string{"https://example.com/a.png", "data:image/png;base64,AAAA"},
+		&APIConfig{ApiKey: &apiKey}, &EmbeddingConfig{InputType: EmbeddingInputImage})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vecs) != 2 || vecs[1].Embedding[0] != 0.2 {
+		t.Errorf("vecs=%+v", vecs)
+	}
+}
+
+func TestVoyageEmbedMultimodalRequiresSuffix(t *testing.T) {
+	apiKey := "test-key"
+	model := "voyage-multimodal-3"
+	_, err := newVoyageForTest("http://unused").Embed(&model, []string{"a"}, &APIConfig{ApiKey: &apiKey}, nil)
+	if err == nil || !strings.Contains(err.Error(), "multimodal") {
+		t.Fatalf("expected multimodal suffix error, got %v", err)
+	}
+}
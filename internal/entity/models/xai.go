@@ -622,3 +622,8 @@ func (x *XAIModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error) {
 func (x *XAIModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", x.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (x *XAIModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method")
+}
@@ -826,3 +826,8 @@ func (x *XiaomiModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (x *XiaomiModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("no such method %s", x.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (x *XiaomiModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("no such method %s", x.Name())
+}
@@ -367,6 +367,10 @@ func (x *XinferenceModel) Embed(modelName *string, texts []string, apiConfig *AP
 		"model": *modelName,
 		"input": texts,
 	}
+	if isImageEmbeddingInput(embeddingConfig) {
+		// CLIP-style models served by Xinference take {"image": url-or-data-uri}.
+		reqBody["input"] = clipStyleImageInputs(texts, false)
+	}
 	if embeddingConfig != nil && embeddingConfig.Dimension > 0 {
 		reqBody["dimensions"] = embeddingConfig.Dimension
 	}
@@ -792,3 +796,8 @@ func (x *XinferenceModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, err
 func (x *XinferenceModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", x.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (x *XinferenceModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", x.Name())
+}
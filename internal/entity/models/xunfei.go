@@ -426,3 +426,8 @@ func (x *XunFeiModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (x *XunFeiModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", x.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (x *XunFeiModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return nil, fmt.Errorf("%s, no such method", x.Name())
+}
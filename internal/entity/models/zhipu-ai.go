@@ -1018,3 +1018,8 @@ func (z *ZhipuAIModel) ListTasks(apiConfig *APIConfig) ([]ListTaskStatus, error)
 func (z *ZhipuAIModel) ShowTask(taskID string, apiConfig *APIConfig) (*TaskResponse, error) {
 	return nil, fmt.Errorf("%s, no such method", z.Name())
 }
+
+// GenerateImage generate images from a text prompt
+func (z *ZhipuAIModel) GenerateImage(modelName *string, prompt string, apiConfig *APIConfig, imageConfig *ImageGenerationConfig) (*ImageGenerationResponse, error) {
+	return generateOpenAICompatibleImage(&z.baseModel, z.Name(), modelName, prompt, apiConfig, imageConfig)
+}
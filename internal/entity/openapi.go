@@ -0,0 +1,37 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package entity
+
+// OpenAPISpec is an OpenAPI 3 document registered by a tenant. Every
+// operation in Spec is exposed to the Agent component as a tool named
+// "openapi:<id>:<operation_id>".
+type OpenAPISpec struct {
+	ID          string  `gorm:"column:id;primaryKey;size:32" json:"id"`
+	Name        string  `gorm:"column:name;size:255;not null" json:"name"`
+	TenantID    string  `gorm:"column:tenant_id;size:32;not null;index" json:"tenant_id"`
+	SourceURL   string  `gorm:"column:source_url;size:2048" json:"source_url"`
+	BaseURL     string  `gorm:"column:base_url;size:2048" json:"base_url"`
+	Description *string `gorm:"column:description;type:longtext" json:"description,omitempty"`
+	Spec        string  `gorm:"column:spec;type:longtext;not null" json:"spec"`
+	Credentials JSONMap `gorm:"column:credentials;type:longtext" json:"credentials,omitempty"`
+	BaseModel
+}
+
+// TableName specify table name
+func (OpenAPISpec) TableName() string {
+	return "openapi_spec"
+}
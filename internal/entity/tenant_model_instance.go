@@ -24,9 +24,22 @@ type TenantModelInstance struct {
 	APIKey       string `gorm:"column:api_key;size:512;not null" json:"api_key"`
 	Status       string `gorm:"column:status;size:32;default:'active'" json:"status"`
 	Extra        string `gorm:"column:extra;size:512;default:'{}'" json:"extra"`
+	// Health fields are maintained by the background model health prober.
+	HealthStatus   string `gorm:"column:health_status;size:32;default:'unknown'" json:"health_status"`
+	ProbeLatencyMs int64  `gorm:"column:probe_latency_ms;default:0" json:"probe_latency_ms"`
+	ProbeError     string `gorm:"column:probe_error;size:512;default:''" json:"probe_error"`
+	ProbeFailures  int    `gorm:"column:probe_failures;default:0" json:"probe_failures"`
+	LastProbeTime  *int64 `gorm:"column:last_probe_time" json:"last_probe_time,omitempty"`
 	BaseModel
 }
 
+// Model instance health states recorded by the health prober.
+const (
+	ModelInstanceHealthUnknown  = "unknown"
+	ModelInstanceHealthHealthy  = "healthy"
+	ModelInstanceHealthDegraded = "degraded"
+)
+
 // TableName specify table name
 func (TenantModelInstance) TableName() string {
 	return "tenant_model_instance"
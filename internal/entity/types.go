@@ -34,4 +34,6 @@ const (
 	ModelTypeTTS ModelType = "tts"
 	// ModelTypeOCR optical character recognition model
 	ModelTypeOCR ModelType = "ocr"
+	// ModelTypeImageGeneration text to image model
+	ModelTypeImageGeneration ModelType = "image_generation"
 )
@@ -194,9 +194,36 @@ func mapAgentError(err error) (common.ErrorCode, string) {
 		errors.Is(err, dao.ErrUserCanvasVersionNotFound) {
 		return common.CodeOperatingError, "Make sure you have permission to access the agent."
 	}
+	if errors.Is(err, service.ErrAgentTestSuiteInvalid) {
+		return common.CodeArgumentError, err.Error()
+	}
 	if errors.Is(err, service.ErrAgentStorageError) {
 		return common.CodeServerError, "Internal storage error while accessing the agent."
 	}
+	if errors.Is(err, service.ErrAgentTriggerInvalid) {
+		return common.CodeArgumentError, err.Error()
+	}
+	if errors.Is(err, dao.ErrAgentTriggerNotFound) {
+		return common.CodeDataError, "Trigger not found."
+	}
+	if errors.Is(err, service.ErrAgentCheckpointInvalid) {
+		return common.CodeArgumentError, err.Error()
+	}
+	if errors.Is(err, service.ErrAgentDSLInvalid) {
+		return common.CodeArgumentError, err.Error()
+	}
+	if errors.Is(err, service.ErrAgentCheckpointNotFound) {
+		return common.CodeDataError, "Checkpoint not found."
+	}
+	if errors.Is(err, service.ErrAgentApprovalInvalid) {
+		return common.CodeArgumentError, err.Error()
+	}
+	if errors.Is(err, service.ErrAgentApprovalNotFound) {
+		return common.CodeDataError, "Approval not found."
+	}
+	if errors.Is(err, service.ErrAgentApprovalForbidden) {
+		return common.CodeOperatingError, err.Error()
+	}
 	return common.CodeDataError, err.Error()
 }
 
@@ -272,6 +299,7 @@ type updateAgentRequest struct {
 }
 
 // UpdateAgent writes a new draft DSL to the canvas (no version created).
+// The response carries the draft's static validation diagnostics.
 // @Summary Update Agent (Draft)
 // @Tags agents
 // @Accept json
@@ -292,15 +320,17 @@ func (h *AgentHandler) UpdateAgent(c *gin.Context) {
 		jsonError(c, common.CodeArgumentError, "Invalid request: "+err.Error())
 		return
 	}
-	if err := h.agentService.UpdateAgent(c.Request.Context(), user.ID, canvasID, req.DSL); err != nil {
+	diags, err := h.agentService.UpdateAgent(c.Request.Context(), user.ID, canvasID, req.DSL)
+	if err != nil {
 		ec, em := mapAgentError(err)
 		jsonError(c, ec, em)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"code":    common.CodeSuccess,
-		"data":    true,
-		"message": "success",
+		"code":        common.CodeSuccess,
+		"data":        true,
+		"diagnostics": diags,
+		"message":     "success",
 	})
 }
 
@@ -480,6 +510,7 @@ type publishAgentRequest struct {
 }
 
 // PublishAgent creates a new immutable version row and marks the parent canvas as released.
+// A DSL with validation errors is refused; the diagnostics are returned in data.
 // @Summary Publish Agent Version
 // @Tags agents
 // @Accept json
@@ -505,6 +536,15 @@ func (h *AgentHandler) PublishAgent(c *gin.Context) {
 		Description: req.Description,
 		DSL:         req.DSL,
 	})
+	var lintErr *service.AgentLintError
+	if errors.As(err, &lintErr) {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    common.CodeArgumentError,
+			"data":    gin.H{"diagnostics": lintErr.Diagnostics},
+			"message": lintErr.Error(),
+		})
+		return
+	}
 	if err != nil {
 		ec, em := mapAgentError(err)
 		jsonError(c, ec, em)
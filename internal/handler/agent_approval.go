@@ -0,0 +1,122 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"ragflow/internal/common"
+	"ragflow/internal/service"
+)
+
+// ListAgentApprovals lists the pending approvals of the caller's tenants.
+// @Summary List Pending Agent Approvals
+// @Tags agents
+// @Produce json
+// @Success 200 {array} canvas.Approval
+// @Router /api/v1/agents/approvals [get]
+func (h *AgentHandler) ListAgentApprovals(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	rows, err := h.agentService.ListApprovals(c.Request.Context(), user.ID)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    rows,
+		"message": "success",
+	})
+}
+
+// GetAgentApproval returns one approval, pending or decided.
+// @Summary Get Agent Approval
+// @Tags agents
+// @Produce json
+// @Param approval_id path string true "approval id"
+// @Success 200 {object} canvas.Approval
+// @Router /api/v1/agents/approvals/{approval_id} [get]
+func (h *AgentHandler) GetAgentApproval(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	row, err := h.agentService.GetApproval(c.Request.Context(), user.ID, c.Param("approval_id"))
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    row,
+		"message": "success",
+	})
+}
+
+// DecideAgentApproval approves, rejects or edits a pending approval and
+// streams the resumed run like /run.
+// @Summary Decide Agent Approval
+// @Tags agents
+// @Accept json
+// @Produce text/event-stream
+// @Param approval_id path string true "approval id"
+// @Param request body service.ApprovalDecisionRequest true "decision"
+// @Router /api/v1/agents/approvals/{approval_id} [post]
+func (h *AgentHandler) DecideAgentApproval(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	var req service.ApprovalDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		jsonError(c, common.CodeArgumentError, "Invalid request: "+err.Error())
+		return
+	}
+	approval, events, err := h.agentService.DecideApproval(c.Request.Context(), user.ID, user.Email, c.Param("approval_id"), &req)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Session-Id", approval.SessionID)
+	for ev := range events {
+		if err := service.WriteChatbotRunEvent(c.Writer, ev); err != nil {
+			common.Debug("agent approval: client disconnected",
+				zap.String("approval_id", approval.ID),
+				zap.String("session_id", approval.SessionID),
+				zap.Error(err),
+			)
+			return
+		}
+	}
+}
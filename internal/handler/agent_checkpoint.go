@@ -0,0 +1,156 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/common"
+	"ragflow/internal/service"
+)
+
+// ListAgentRunCheckpoints lists the per-node checkpoints of a session's
+// latest run, without state payloads.
+// @Summary List Agent Run Checkpoints
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param session_id path string true "session id"
+// @Success 200 {array} canvas.NodeCheckpoint
+// @Router /api/v1/agents/{canvas_id}/sessions/{session_id}/checkpoints [get]
+func (h *AgentHandler) ListAgentRunCheckpoints(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	rows, err := h.agentService.ListRunCheckpoints(c.Request.Context(), user.ID, c.Param("canvas_id"), c.Param("session_id"))
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	if rows == nil {
+		rows = []canvas.NodeCheckpoint{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    rows,
+		"message": "success",
+	})
+}
+
+// GetAgentRunCheckpoint returns one checkpoint with the CanvasState
+// captured after its node finished.
+// @Summary Get Agent Run Checkpoint
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param session_id path string true "session id"
+// @Param seq path int true "checkpoint sequence number"
+// @Success 200 {object} service.AgentRunCheckpoint
+// @Router /api/v1/agents/{canvas_id}/sessions/{session_id}/checkpoints/{seq} [get]
+func (h *AgentHandler) GetAgentRunCheckpoint(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	seq, ok := checkpointSeqParam(c)
+	if !ok {
+		return
+	}
+	row, err := h.agentService.GetRunCheckpoint(c.Request.Context(), user.ID, c.Param("canvas_id"), c.Param("session_id"), seq)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    row,
+		"message": "success",
+	})
+}
+
+// ForkAgentRun starts a new run from a checkpoint, optionally editing
+// variables first, and streams it like /run. The new session id is in
+// every event envelope and the X-Session-Id header.
+// @Summary Fork Agent Run From Checkpoint
+// @Tags agents
+// @Accept json
+// @Produce text/event-stream
+// @Param canvas_id path string true "canvas id"
+// @Param session_id path string true "session id"
+// @Param seq path int true "checkpoint sequence number"
+// @Param request body service.ForkAgentRunRequest false "variable overrides"
+// @Router /api/v1/agents/{canvas_id}/sessions/{session_id}/checkpoints/{seq}/fork [post]
+func (h *AgentHandler) ForkAgentRun(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	seq, ok := checkpointSeqParam(c)
+	if !ok {
+		return
+	}
+	var req service.ForkAgentRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		jsonError(c, common.CodeArgumentError, "Invalid request: "+err.Error())
+		return
+	}
+	canvasID := c.Param("canvas_id")
+	events, sessionID, err := h.agentService.ForkRunFromCheckpoint(c.Request.Context(), user.ID, canvasID, c.Param("session_id"), seq, &req)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Session-Id", sessionID)
+	for ev := range events {
+		if err := service.WriteChatbotRunEvent(c.Writer, ev); err != nil {
+			common.Debug("agent fork: client disconnected",
+				zap.String("canvas_id", canvasID),
+				zap.String("session_id", sessionID),
+				zap.Error(err),
+			)
+			return
+		}
+	}
+}
+
+// checkpointSeqParam parses the :seq path parameter, writing an
+// argument error when it is not a non-negative integer.
+func checkpointSeqParam(c *gin.Context) (int, bool) {
+	seq, err := strconv.Atoi(c.Param("seq"))
+	if err != nil || seq < 0 {
+		jsonError(c, common.CodeArgumentError, "seq must be a non-negative integer")
+		return 0, false
+	}
+	return seq, true
+}
@@ -0,0 +1,56 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ragflow/internal/common"
+)
+
+// DiffVersions returns the structural diff between two versions of an
+// agent: added/removed/renamed components, per-param changes with line
+// diffs for prompts, rewired edges and changed globals. to defaults to
+// the draft and from to the version published before to.
+// @Summary Diff Agent Versions
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param from query string false "base version id or draft"
+// @Param to query string false "target version id or draft (default draft)"
+// @Success 200 {object} service.AgentVersionDiff
+// @Router /api/v1/agents/{canvas_id}/versions/diff [get]
+func (h *AgentHandler) DiffVersions(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	diff, err := h.agentService.DiffVersions(c.Request.Context(), user.ID, c.Param("canvas_id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    diff,
+		"message": "success",
+	})
+}
@@ -0,0 +1,66 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	dslpkg "ragflow/internal/agent/dsl"
+	"ragflow/internal/common"
+)
+
+// LintAgent statically validates a canvas DSL and returns located
+// diagnostics. The body's dsl is linted when present (without being
+// saved); otherwise the saved draft is.
+// @Summary Lint Agent DSL
+// @Tags agents
+// @Accept json
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param request body updateAgentRequest false "DSL to lint instead of the saved draft"
+// @Success 200 {array} dsl.Diagnostic
+// @Router /api/v1/agents/{canvas_id}/lint [post]
+func (h *AgentHandler) LintAgent(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	var req updateAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		jsonError(c, common.CodeArgumentError, "Invalid request: "+err.Error())
+		return
+	}
+	diags, err := h.agentService.LintAgent(c.Request.Context(), user.ID, c.Param("canvas_id"), req.DSL)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": common.CodeSuccess,
+		"data": gin.H{
+			"valid":       !dslpkg.HasErrors(diags),
+			"diagnostics": diags,
+		},
+		"message": "success",
+	})
+}
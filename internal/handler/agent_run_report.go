@@ -0,0 +1,84 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ragflow/internal/common"
+)
+
+// GetAgentRunReport returns the cost and latency report of a session:
+// per-node wall time, retries, tokens, estimated cost, tool calls and
+// sandbox time of every run, plus their totals.
+// @Summary Get Agent Run Report
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param session_id path string true "session id"
+// @Success 200 {object} service.AgentSessionReport
+// @Router /api/v1/agents/{canvas_id}/sessions/{session_id}/report [get]
+func (h *AgentHandler) GetAgentRunReport(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	report, err := h.agentService.GetRunReport(c.Request.Context(), user.ID, c.Param("canvas_id"), c.Param("session_id"))
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    report,
+		"message": "success",
+	})
+}
+
+// GetAgentRunStats aggregates the latest run reports of an agent into
+// p50/p95 latency and cost per node.
+// @Summary Get Agent Run Stats
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param limit query int false "number of latest runs (default 200, max 1000)"
+// @Success 200 {object} service.AgentRunStats
+// @Router /api/v1/agents/{canvas_id}/reports/stats [get]
+func (h *AgentHandler) GetAgentRunStats(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	stats, err := h.agentService.RunReportStats(c.Request.Context(), user.ID, c.Param("canvas_id"), limit)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    stats,
+		"message": "success",
+	})
+}
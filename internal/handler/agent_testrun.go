@@ -0,0 +1,84 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ragflow/internal/agent/canvastest"
+	"ragflow/internal/common"
+)
+
+// RunAgentTests runs a canvas test suite (YAML or JSON body, see
+// package canvastest) against the agent with models and tools mocked.
+// The report is JSON by default; ?format=junit returns JUnit XML.
+// @Summary Run Agent Tests
+// @Tags agents
+// @Accept json
+// @Produce json
+// @Produce xml
+// @Param canvas_id path string true "canvas id"
+// @Param format query string false "json (default) or junit"
+// @Param request body string true "test suite"
+// @Success 200 {object} canvastest.Report
+// @Router /api/v1/agents/{canvas_id}/tests [post]
+func (h *AgentHandler) RunAgentTests(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "junit" {
+		jsonError(c, common.CodeArgumentError, "format must be json or junit")
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		jsonError(c, common.CodeArgumentError, "Invalid request: "+err.Error())
+		return
+	}
+	suite, err := canvastest.ParseSuite(body)
+	if err != nil {
+		jsonError(c, common.CodeArgumentError, err.Error())
+		return
+	}
+	report, err := h.agentService.RunAgentTests(c.Request.Context(), user.ID, c.Param("canvas_id"), suite)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	if format == "junit" {
+		var buf bytes.Buffer
+		if err := report.WriteJUnit(&buf); err != nil {
+			jsonError(c, common.CodeServerError, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "application/xml; charset=utf-8", buf.Bytes())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    report,
+		"message": "success",
+	})
+}
@@ -0,0 +1,161 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ragflow/internal/common"
+	"ragflow/internal/entity"
+	"ragflow/internal/service"
+)
+
+// ListAgentTriggers returns the schedule and event triggers of an agent.
+// @Summary List Agent Triggers
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Success 200 {array} entity.AgentTrigger
+// @Router /api/v1/agents/{canvas_id}/triggers [get]
+func (h *AgentHandler) ListAgentTriggers(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	rows, err := h.agentService.ListTriggers(c.Request.Context(), user.ID, c.Param("canvas_id"))
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	if rows == nil {
+		rows = []*entity.AgentTrigger{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    rows,
+		"message": "success",
+	})
+}
+
+// CreateAgentTrigger attaches a cron or event trigger to a published
+// version of an agent.
+// @Summary Create Agent Trigger
+// @Tags agents
+// @Accept json
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param request body service.CreateAgentTriggerRequest true "trigger definition"
+// @Success 200 {object} entity.AgentTrigger
+// @Router /api/v1/agents/{canvas_id}/triggers [post]
+func (h *AgentHandler) CreateAgentTrigger(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	var req service.CreateAgentTriggerRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		jsonError(c, common.CodeArgumentError, "Invalid request: "+err.Error())
+		return
+	}
+	row, err := h.agentService.CreateTrigger(c.Request.Context(), user.ID, c.Param("canvas_id"), &req)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    row,
+		"message": "success",
+	})
+}
+
+// PauseAgentTrigger stops a trigger from firing until it is resumed.
+// @Summary Pause Agent Trigger
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param trigger_id path string true "trigger id"
+// @Success 200 {object} entity.AgentTrigger
+// @Router /api/v1/agents/{canvas_id}/triggers/{trigger_id}/pause [post]
+func (h *AgentHandler) PauseAgentTrigger(c *gin.Context) {
+	h.setAgentTriggerStatus(c, entity.AgentTriggerStatusPaused)
+}
+
+// ResumeAgentTrigger re-activates a paused trigger.
+// @Summary Resume Agent Trigger
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param trigger_id path string true "trigger id"
+// @Success 200 {object} entity.AgentTrigger
+// @Router /api/v1/agents/{canvas_id}/triggers/{trigger_id}/resume [post]
+func (h *AgentHandler) ResumeAgentTrigger(c *gin.Context) {
+	h.setAgentTriggerStatus(c, entity.AgentTriggerStatusActive)
+}
+
+func (h *AgentHandler) setAgentTriggerStatus(c *gin.Context, status string) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	row, err := h.agentService.SetTriggerStatus(c.Request.Context(), user.ID, c.Param("canvas_id"), c.Param("trigger_id"), status)
+	if err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    row,
+		"message": "success",
+	})
+}
+
+// DeleteAgentTrigger removes a trigger.
+// @Summary Delete Agent Trigger
+// @Tags agents
+// @Produce json
+// @Param canvas_id path string true "canvas id"
+// @Param trigger_id path string true "trigger id"
+// @Success 200 {boolean} bool
+// @Router /api/v1/agents/{canvas_id}/triggers/{trigger_id} [delete]
+func (h *AgentHandler) DeleteAgentTrigger(c *gin.Context) {
+	user, code, msg := GetUser(c)
+	if code != common.CodeSuccess {
+		jsonError(c, code, msg)
+		return
+	}
+	if err := h.agentService.DeleteTrigger(c.Request.Context(), user.ID, c.Param("canvas_id"), c.Param("trigger_id")); err != nil {
+		ec, em := mapAgentError(err)
+		jsonError(c, ec, em)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"data":    true,
+		"message": "success",
+	})
+}
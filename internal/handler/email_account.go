@@ -0,0 +1,180 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ragflow/internal/common"
+	"ragflow/internal/service"
+)
+
+// EmailAccountHandler handles email account requests.
+type EmailAccountHandler struct {
+	emailAccountService *service.EmailAccountService
+}
+
+// NewEmailAccountHandler creates an email account handler.
+func NewEmailAccountHandler(emailAccountService *service.EmailAccountService) *EmailAccountHandler {
+	return &EmailAccountHandler{
+		emailAccountService: emailAccountService,
+	}
+}
+
+// CreateEmailAccount registers an IMAP account for the current user.
+func (h *EmailAccountHandler) CreateEmailAccount(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	var req service.CreateEmailAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonError(c, common.CodeDataError, err.Error())
+		return
+	}
+
+	result, code, err := h.emailAccountService.CreateEmailAccount(user.ID, req)
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// ListEmailAccounts lists the current user's accounts.
+func (h *EmailAccountHandler) ListEmailAccounts(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	accounts, code, err := h.emailAccountService.ListEmailAccounts(user.ID, c.Query("keywords"))
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data": gin.H{
+			"accounts": accounts,
+			"total":    len(accounts),
+		},
+	})
+}
+
+// GetEmailAccount returns one of the current user's accounts.
+func (h *EmailAccountHandler) GetEmailAccount(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	result, code, err := h.emailAccountService.GetEmailAccount(user.ID, c.Param("account_id"))
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// UpdateEmailAccount updates one of the current user's accounts.
+func (h *EmailAccountHandler) UpdateEmailAccount(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	var req service.UpdateEmailAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonError(c, common.CodeDataError, err.Error())
+		return
+	}
+
+	result, code, err := h.emailAccountService.UpdateEmailAccount(user.ID, c.Param("account_id"), req)
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// DeleteEmailAccount deletes one of the current user's accounts.
+func (h *EmailAccountHandler) DeleteEmailAccount(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	code, err := h.emailAccountService.DeleteEmailAccount(user.ID, c.Param("account_id"))
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    true,
+	})
+}
+
+// TestEmailAccount logs in to one of the current user's accounts and
+// returns its mailboxes.
+func (h *EmailAccountHandler) TestEmailAccount(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	mailboxes, code, err := h.emailAccountService.TestEmailAccount(c.Request.Context(), user.ID, c.Param("account_id"))
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    mailboxes,
+	})
+}
@@ -31,6 +31,7 @@ import (
 	"ragflow/internal/common"
 	"ragflow/internal/entity"
 	"ragflow/internal/service"
+	"ragflow/internal/utility"
 )
 
 const (
@@ -410,9 +411,10 @@ func (h *MCPHandler) TestMCPServer(c *gin.Context) {
 	}
 
 	// Mirror Python's @validate_request("url", "server_type"): missing
-	// required fields → code 101 (ARGUMENT_ERROR), not code 102.
+	// required fields → code 101 (ARGUMENT_ERROR), not code 102. Stdio
+	// servers have no URL; their command travels in variables.
 	var missingFields []string
-	if req.URL == "" {
+	if req.URL == "" && req.ServerType != utility.TransportStdio {
 		missingFields = append(missingFields, "url")
 	}
 	if req.ServerType == "" {
@@ -0,0 +1,332 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Built-in MCP server. The API server answers MCP over the
+// streamable-HTTP transport at /api/v1/mcp, so IDE assistants and other
+// agent frameworks can use datasets, agents and memories with an API
+// token and no separate mcp/ deployment. The endpoint is stateless:
+// every POST carries the caller's token and is answered with a single
+// JSON body, so no Mcp-Session-Id is issued and GET streams are refused.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"ragflow/internal/common"
+	"ragflow/internal/entity"
+	"ragflow/internal/service"
+)
+
+const (
+	mcpEndpointServerName    = "ragflow"
+	mcpEndpointServerVersion = "1.0.0"
+	// mcpEndpointMaxBody caps a request body; tool arguments are small.
+	mcpEndpointMaxBody = 4 << 20
+)
+
+// mcpEndpointProtocolVersions are the protocol revisions the endpoint
+// speaks, newest first. initialize echoes the client's version when it
+// is listed and answers with the first one otherwise.
+var mcpEndpointProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC and MCP error codes.
+const (
+	rpcParseError       = -32700
+	rpcInvalidRequest   = -32600
+	rpcMethodNotFound   = -32601
+	rpcInvalidParams    = -32602
+	rpcInternalError    = -32603
+	rpcResourceNotFound = -32002
+)
+
+// mcpChunkService is the ChunkService subset the MCP endpoint uses:
+// RetrievalTest resolves the embedding and rerank models before calling
+// RetrievalService.Retrieval, and List pages a document's chunks.
+type mcpChunkService interface {
+	RetrievalTest(req *service.RetrievalTestRequest, userID string) (*service.RetrievalTestResponse, error)
+	List(req *service.ListChunksRequest, userID string) (*service.ListChunksResponse, error)
+}
+
+// mcpDatasetService is the DatasetService subset the MCP endpoint uses.
+type mcpDatasetService interface {
+	ListDatasets(id, name string, page, pageSize int, orderby string, desc bool, keywords string, ownerIDs []string, parserID, userID string) ([]map[string]interface{}, int64, common.ErrorCode, error)
+	Accessible(kbID, userID string) bool
+}
+
+// mcpDocumentService is the DocumentService subset the MCP endpoint uses.
+type mcpDocumentService interface {
+	ListDocumentsByDatasetID(kbID string, page, pageSize int) ([]*entity.DocumentListItem, int64, error)
+}
+
+// mcpAgentService is the AgentService subset the MCP endpoint uses.
+type mcpAgentService interface {
+	ListAgents(userID, keywords string, page, pageSize int, orderby string, desc bool, ownerIDs []string, canvasCategory string) (*service.ListAgentsResponse, common.ErrorCode, error)
+	RunSubAgent(ctx context.Context, userID, canvasID, versionID, query string, inputs map[string]any) (map[string]any, error)
+}
+
+// mcpMemoryService is the MemoryService subset the MCP endpoint uses.
+type mcpMemoryService interface {
+	ListMemories(userID string, tenantIDs []string, memoryTypes []string, storageType string, keywords string, page int, pageSize int) (*service.ListMemoryResponse, error)
+	SearchMessage(ctx context.Context, userID string, filterDict, params map[string]interface{}) ([]map[string]interface{}, common.ErrorCode, error)
+}
+
+// MCPEndpointHandler serves the built-in MCP server.
+type MCPEndpointHandler struct {
+	chunks    mcpChunkService
+	datasets  mcpDatasetService
+	documents mcpDocumentService
+	agents    mcpAgentService
+	memories  mcpMemoryService
+}
+
+// NewMCPEndpointHandler creates a new MCPEndpointHandler.
+func NewMCPEndpointHandler(chunks mcpChunkService, datasets mcpDatasetService, documents mcpDocumentService, agents mcpAgentService, memories mcpMemoryService) *MCPEndpointHandler {
+	return &MCPEndpointHandler{
+		chunks:    chunks,
+		datasets:  datasets,
+		documents: documents,
+		agents:    agents,
+		memories:  memories,
+	}
+}
+
+// MCPAPIKeyHeader lets MCP clients that cannot set Authorization pass
+// their API token as an api_key or X-API-Key header, as the Python MCP
+// server accepts. It must run before AuthMiddleware.
+func MCPAPIKeyHeader(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		for _, name := range []string{"X-API-Key", "api_key"} {
+			if key := strings.TrimSpace(c.GetHeader(name)); key != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+key)
+				break
+			}
+		}
+	}
+	c.Next()
+}
+
+// mcpRPCMessage is an incoming JSON-RPC message. A message without an
+// id is a notification and gets no reply.
+type mcpRPCMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// mcpRPCReply is a JSON-RPC response. Either Result or Error is set.
+type mcpRPCReply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *mcpRPCError    `json:"error,omitempty"`
+}
+
+type mcpRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func newMCPRPCError(code int, message string) *mcpRPCError {
+	return &mcpRPCError{Code: code, Message: message}
+}
+
+// Serve handles POST /api/v1/mcp: one JSON-RPC message or a batch.
+// Batches of notifications only, like single notifications, are
+// acknowledged with 202 and no body.
+func (h *MCPEndpointHandler) Serve(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": errorCode, "message": errorMessage})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, mcpEndpointMaxBody+1))
+	if err != nil || len(body) > mcpEndpointMaxBody {
+		c.JSON(http.StatusBadRequest, mcpErrorReply(nil, newMCPRPCError(rpcInvalidRequest, "request body too large or unreadable")))
+		return
+	}
+	body = bytes.TrimSpace(body)
+	ctx := c.Request.Context()
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			c.JSON(http.StatusBadRequest, mcpErrorReply(nil, newMCPRPCError(rpcParseError, "Parse error")))
+			return
+		}
+		if len(batch) == 0 {
+			c.JSON(http.StatusBadRequest, mcpErrorReply(nil, newMCPRPCError(rpcInvalidRequest, "empty batch")))
+			return
+		}
+		replies := make([]*mcpRPCReply, 0, len(batch))
+		for _, raw := range batch {
+			if reply := h.dispatch(ctx, user.ID, raw); reply != nil {
+				replies = append(replies, reply)
+			}
+		}
+		if len(replies) == 0 {
+			c.Status(http.StatusAccepted)
+			return
+		}
+		c.JSON(http.StatusOK, replies)
+		return
+	}
+
+	reply := h.dispatch(ctx, user.ID, body)
+	if reply == nil {
+		c.Status(http.StatusAccepted)
+		return
+	}
+	c.JSON(http.StatusOK, reply)
+}
+
+// MethodNotAllowed answers GET and DELETE on the endpoint. The server
+// offers no standalone SSE stream and keeps no sessions to terminate.
+func (h *MCPEndpointHandler) MethodNotAllowed(c *gin.Context) {
+	c.Header("Allow", http.MethodPost)
+	c.Status(http.StatusMethodNotAllowed)
+}
+
+// dispatch runs one JSON-RPC message and returns its reply, or nil for
+// notifications and for responses the client sends back.
+func (h *MCPEndpointHandler) dispatch(ctx context.Context, userID string, raw json.RawMessage) *mcpRPCReply {
+	var msg mcpRPCMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return mcpErrorReply(nil, newMCPRPCError(rpcParseError, "Parse error"))
+	}
+	notification := len(msg.ID) == 0
+	if msg.Method == "" {
+		// A reply to a server request is dropped: the endpoint sends
+		// none, so there is nothing to match it against.
+		if notification || len(msg.Result) > 0 || len(msg.Error) > 0 {
+			return nil
+		}
+		return mcpErrorReply(msg.ID, newMCPRPCError(rpcInvalidRequest, "method is required"))
+	}
+	if msg.JSONRPC != "2.0" {
+		if notification {
+			return nil
+		}
+		return mcpErrorReply(msg.ID, newMCPRPCError(rpcInvalidRequest, "jsonrpc must be \"2.0\""))
+	}
+
+	result, rpcErr := h.handle(ctx, userID, msg.Method, msg.Params)
+	if notification {
+		return nil
+	}
+	if rpcErr != nil {
+		return mcpErrorReply(msg.ID, rpcErr)
+	}
+	return &mcpRPCReply{JSONRPC: "2.0", ID: msg.ID, Result: result}
+}
+
+func mcpErrorReply(id json.RawMessage, rpcErr *mcpRPCError) *mcpRPCReply {
+	return &mcpRPCReply{JSONRPC: "2.0", ID: id, Error: rpcErr}
+}
+
+// handle runs one MCP method.
+func (h *MCPEndpointHandler) handle(ctx context.Context, userID, method string, params json.RawMessage) (interface{}, *mcpRPCError) {
+	switch method {
+	case "initialize":
+		return h.initialize(params), nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": h.listTools(userID)}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := decodeMCPParams(params, &p); err != nil {
+			return nil, err
+		}
+		return h.callTool(ctx, userID, p.Name, p.Arguments)
+	case "resources/list":
+		var p struct {
+			Cursor string `json:"cursor"`
+		}
+		if err := decodeMCPParams(params, &p); err != nil {
+			return nil, err
+		}
+		return h.listResources(userID, p.Cursor)
+	case "resources/templates/list":
+		return map[string]interface{}{"resourceTemplates": mcpResourceTemplates()}, nil
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if err := decodeMCPParams(params, &p); err != nil {
+			return nil, err
+		}
+		return h.readResource(userID, p.URI)
+	}
+	return nil, newMCPRPCError(rpcMethodNotFound, "Method not found: "+method)
+}
+
+// decodeMCPParams unmarshals params into out; absent params leave out
+// zero-valued.
+func decodeMCPParams(params json.RawMessage, out interface{}) *mcpRPCError {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, out); err != nil {
+		return newMCPRPCError(rpcInvalidParams, "Invalid params: "+err.Error())
+	}
+	return nil
+}
+
+// initialize answers the handshake.
+func (h *MCPEndpointHandler) initialize(params json.RawMessage) map[string]interface{} {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = decodeMCPParams(params, &p)
+	version := mcpEndpointProtocolVersions[0]
+	for _, v := range mcpEndpointProtocolVersions {
+		if v == p.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools":     map[string]interface{}{},
+			"resources": map[string]interface{}{},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    mcpEndpointServerName,
+			"version": mcpEndpointServerVersion,
+		},
+		"instructions": "Search RAGFlow datasets with ragflow_retrieval, browse them with list_datasets and list_documents, " +
+			"run published agents with run_agent, and search agent memories with search_memory. " +
+			"Full document text is available as ragflow://datasets/{dataset_id}/documents/{document_id} resources.",
+	}
+}
@@ -0,0 +1,403 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"ragflow/internal/common"
+	"ragflow/internal/entity"
+	"ragflow/internal/service"
+)
+
+type fakeMCPServices struct {
+	retrievalReq *service.RetrievalTestRequest
+	memoryFilter map[string]interface{}
+	memoryParams map[string]interface{}
+	runArgs      []string
+}
+
+func (f *fakeMCPServices) RetrievalTest(req *service.RetrievalTestRequest, userID string) (*service.RetrievalTestResponse, error) {
+	f.retrievalReq = req
+	return &service.RetrievalTestResponse{
+		Chunks: []map[string]interface{}{{
+			"chunk_id":            "c1",
+			"content_with_weight": "RAGFlow is a RAG engine.",
+			"doc_id":              "doc1",
+			"docnm_kwd":           "intro.md",
+			"kb_id":               "kb1",
+			"similarity":          0.9,
+			"q_1024_vec":          []float64{0.1},
+		}},
+		Total: 1,
+	}, nil
+}
+
+// List serves doc1 as three chunks.
+func (f *fakeMCPServices) List(req *service.ListChunksRequest, userID string) (*service.ListChunksResponse, error) {
+	if req.DocID != "doc1" {
+		return nil, errors.New("document not found")
+	}
+	all := []map[string]interface{}{
+		{"content_with_weight": "first"},
+		{"content_with_weight": "second"},
+		{"content_with_weight": "third"},
+	}
+	start := (*req.Page - 1) * *req.Size
+	end := start + *req.Size
+	if end > len(all) {
+		end = len(all)
+	}
+	if start >= len(all) {
+		return &service.ListChunksResponse{Total: int64(len(all))}, nil
+	}
+	return &service.ListChunksResponse{Chunks: all[start:end], Total: int64(len(all))}, nil
+}
+
+func (f *fakeMCPServices) ListDatasets(id, name string, page, pageSize int, orderby string, desc bool, keywords string, ownerIDs []string, parserID, userID string) ([]map[string]interface{}, int64, common.ErrorCode, error) {
+	return []map[string]interface{}{
+		{"id": "kb1", "name": "Docs", "description": "Product docs", "document_count": 1, "chunk_count": 3},
+		{"id": "kb2", "name": "Empty"},
+		{"id": "kb3", "name": "FAQ"},
+	}, 3, common.CodeSuccess, nil
+}
+
+func (f *fakeMCPServices) Accessible(kbID, userID string) bool {
+	return kbID != "secret"
+}
+
+func (f *fakeMCPServices) ListDocumentsByDatasetID(kbID string, page, pageSize int) ([]*entity.DocumentListItem, int64, error) {
+	name := "intro.md"
+	switch kbID {
+	case "kb1":
+		return []*entity.DocumentListItem{{ID: "doc1", KbID: "kb1", Name: &name, ChunkNum: 3}}, 1, nil
+	case "kb3":
+		return []*entity.DocumentListItem{{ID: "doc3", KbID: "kb3"}}, 1, nil
+	}
+	return nil, 0, nil
+}
+
+func (f *fakeMCPServices) ListAgents(userID, keywords string, page, pageSize int, orderby string, desc bool, ownerIDs []string, canvasCategory string) (*service.ListAgentsResponse, common.ErrorCode, error) {
+	title := "Support bot"
+	return &service.ListAgentsResponse{Canvas: []*service.AgentItem{{ID: "agent1", Title: &title}}, Total: 1}, common.CodeSuccess, nil
+}
+
+func (f *fakeMCPServices) RunSubAgent(ctx context.Context, userID, canvasID, versionID, query string, inputs map[string]any) (map[string]any, error) {
+	f.runArgs = []string{userID, canvasID, versionID, query, fmt.Sprint(inputs["lang"])}
+	if canvasID == "draft" {
+		return nil, errors.New("canvas has no published version")
+	}
+	return map[string]any{"answer": "hello from " + canvasID}, nil
+}
+
+func (f *fakeMCPServices) ListMemories(userID string, tenantIDs []string, memoryTypes []string, storageType string, keywords string, page int, pageSize int) (*service.ListMemoryResponse, error) {
+	return &service.ListMemoryResponse{MemoryList: []map[string]interface{}{{"id": "m1"}, {"id": "m2"}}, TotalCount: 2}, nil
+}
+
+func (f *fakeMCPServices) SearchMessage(ctx context.Context, userID string, filterDict, params map[string]interface{}) ([]map[string]interface{}, common.ErrorCode, error) {
+	f.memoryFilter, f.memoryParams = filterDict, params
+	return []map[string]interface{}{{"content": "remembered"}}, common.CodeSuccess, nil
+}
+
+func newMCPEndpointTestRouter(f *fakeMCPServices) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewMCPEndpointHandler(f, f, f, f, f)
+	r := gin.New()
+	r.POST("/api/v1/mcp", func(c *gin.Context) {
+		c.Set("user", &entity.User{ID: "user-1"})
+		c.Next()
+	}, h.Serve)
+	r.GET("/api/v1/mcp", h.MethodNotAllowed)
+	return r
+}
+
+func postMCP(t *testing.T, r *gin.Engine, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+type mcpTestReply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *mcpRPCError    `json:"error"`
+}
+
+// callMCP sends one request and returns its result, failing on an error
+// reply.
+func callMCP(t *testing.T, r *gin.Engine, method, params string) json.RawMessage {
+	t.Helper()
+	w := postMCP(t, r, fmt.Sprintf(`{"jsonrpc":"2.0","id":7,"method":%q,"params":%s}`, method, params))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: status %d: %s", method, w.Code, w.Body.String())
+	}
+	var reply mcpTestReply
+	if err := json.Unmarshal(w.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("%s: decode %s: %v", method, w.Body.String(), err)
+	}
+	if string(reply.ID) != "7" {
+		t.Fatalf("%s: reply id = %s", method, reply.ID)
+	}
+	if reply.Error != nil {
+		t.Fatalf("%s: error %+v", method, reply.Error)
+	}
+	return reply.Result
+}
+
+// callMCPTool runs tools/call and returns the text content and isError.
+func callMCPTool(t *testing.T, r *gin.Engine, name, args string) (string, bool) {
+	t.Helper()
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	raw := callMCP(t, r, "tools/call", fmt.Sprintf(`{"name":%q,"arguments":%s}`, name, args))
+	if err := json.Unmarshal(raw, &result); err != nil || len(result.Content) != 1 {
+		t.Fatalf("tools/call %s: result %s", name, raw)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestMCPEndpoint_InitializeAndNotifications(t *testing.T) {
+	r := newMCPEndpointTestRouter(&fakeMCPServices{})
+
+	var init struct {
+		ProtocolVersion string                 `json:"protocolVersion"`
+		Capabilities    map[string]interface{} `json:"capabilities"`
+		ServerInfo      map[string]interface{} `json:"serverInfo"`
+	}
+	raw := callMCP(t, r, "initialize", `{"protocolVersion":"2025-03-26","capabilities":{}}`)
+	if err := json.Unmarshal(raw, &init); err != nil {
+		t.Fatal(err)
+	}
+	if init.ProtocolVersion != "2025-03-26" || init.ServerInfo["name"] != "ragflow" {
+		t.Fatalf("initialize = %s", raw)
+	}
+	if _, ok := init.Capabilities["tools"]; !ok {
+		t.Fatalf("capabilities = %v", init.Capabilities)
+	}
+	raw = callMCP(t, r, "initialize", `{"protocolVersion":"1999-01-01"}`)
+	if !strings.Contains(string(raw), `"protocolVersion":"`+mcpEndpointProtocolVersions[0]+`"`) {
+		t.Fatalf("unknown version not answered with the latest: %s", raw)
+	}
+
+	if w := postMCP(t, r, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Fatalf("notification: status %d body %q", w.Code, w.Body.String())
+	}
+
+	w := postMCP(t, r, `[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":2,"method":"nope"}]`)
+	var batch []mcpTestReply
+	if err := json.Unmarshal(w.Body.Bytes(), &batch); err != nil || len(batch) != 2 {
+		t.Fatalf("batch replies = %s", w.Body.String())
+	}
+	if batch[0].Error != nil || batch[1].Error == nil || batch[1].Error.Code != rpcMethodNotFound {
+		t.Fatalf("batch replies = %s", w.Body.String())
+	}
+
+	if w := postMCP(t, r, `{not json`); !strings.Contains(w.Body.String(), fmt.Sprint(rpcParseError)) {
+		t.Fatalf("parse error reply = %s", w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/mcp", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status = %d", rec.Code)
+	}
+}
+
+func TestMCPEndpoint_Tools(t *testing.T) {
+	f := &fakeMCPServices{}
+	r := newMCPEndpointTestRouter(f)
+
+	var list struct {
+		Tools []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(callMCP(t, r, "tools/list", `{}`), &list); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tl := range list.Tools {
+		names = append(names, tl.Name)
+	}
+	if got := strings.Join(names, ","); got != "ragflow_retrieval,list_datasets,list_documents,list_agents,run_agent,search_memory" {
+		t.Fatalf("tools = %s", got)
+	}
+	if !strings.Contains(list.Tools[0].Description, "- Docs (id: kb1): Product docs") {
+		t.Errorf("retrieval description lacks the dataset list: %q", list.Tools[0].Description)
+	}
+
+	// Without dataset_ids the retrieval searches every dataset, with the
+	// Python server's defaults.
+	text, isErr := callMCPTool(t, r, "ragflow_retrieval", `{"question":"what is ragflow"}`)
+	if isErr {
+		t.Fatalf("retrieval failed: %s", text)
+	}
+	req := f.retrievalReq
+	if strings.Join(req.Datasets, ",") != "kb1,kb2,kb3" || *req.Size != 10 || *req.SimilarityThreshold != 0.2 || *req.VectorSimilarityWeight != 0.3 || *req.TopK != 1024 {
+		t.Fatalf("retrieval request = %+v", req)
+	}
+	if !strings.Contains(text, `"content":"RAGFlow is a RAG engine."`) || !strings.Contains(text, `"document_name":"intro.md"`) || strings.Contains(text, "q_1024_vec") {
+		t.Errorf("retrieval output = %s", text)
+	}
+	if text, isErr := callMCPTool(t, r, "ragflow_retrieval", `{}`); !isErr || !strings.Contains(text, "question is required") {
+		t.Errorf("retrieval without question = %q, isError %v", text, isErr)
+	}
+
+	text, _ = callMCPTool(t, r, "list_documents", `{"dataset_id":"kb1"}`)
+	if !strings.Contains(text, `"uri":"ragflow://datasets/kb1/documents/doc1"`) {
+		t.Errorf("list_documents = %s", text)
+	}
+	if text, isErr := callMCPTool(t, r, "list_documents", `{"dataset_id":"secret"}`); !isErr {
+		t.Errorf("list_documents on an inaccessible dataset = %s", text)
+	}
+
+	text, _ = callMCPTool(t, r, "list_agents", `{}`)
+	if !strings.Contains(text, `"title":"Support bot"`) {
+		t.Errorf("list_agents = %s", text)
+	}
+	text, isErr = callMCPTool(t, r, "run_agent", `{"agent_id":"agent1","query":"hi","inputs":{"lang":"en"}}`)
+	if isErr || !strings.Contains(text, "hello from agent1") {
+		t.Errorf("run_agent = %s", text)
+	}
+	if got := strings.Join(f.runArgs, "|"); got != "user-1|agent1||hi|en" {
+		t.Errorf("RunSubAgent args = %s", got)
+	}
+	if text, isErr := callMCPTool(t, r, "run_agent", `{"agent_id":"draft"}`); !isErr || !strings.Contains(text, "no published version") {
+		t.Errorf("run_agent on a draft = %q, isError %v", text, isErr)
+	}
+
+	text, _ = callMCPTool(t, r, "search_memory", `{"query":"preferences","top_n":3}`)
+	if !strings.Contains(text, "remembered") {
+		t.Errorf("search_memory = %s", text)
+	}
+	if ids, _ := f.memoryFilter["memory_id"].([]string); strings.Join(ids, ",") != "m1,m2" || f.memoryParams["top_n"] != 3 {
+		t.Errorf("memory search filter=%v params=%v", f.memoryFilter, f.memoryParams)
+	}
+
+	w := postMCP(t, r, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`)
+	var reply mcpTestReply
+	_ = json.Unmarshal(w.Body.Bytes(), &reply)
+	if reply.Error == nil || reply.Error.Code != rpcInvalidParams {
+		t.Fatalf("unknown tool reply = %s", w.Body.String())
+	}
+}
+
+func TestMCPEndpoint_Resources(t *testing.T) {
+	r := newMCPEndpointTestRouter(&fakeMCPServices{})
+
+	type page struct {
+		Resources []struct {
+			URI  string `json:"uri"`
+			Name string `json:"name"`
+		} `json:"resources"`
+		NextCursor string `json:"nextCursor"`
+	}
+	var uris []string
+	cursor := ""
+	for i := 0; i < 5; i++ {
+		var p page
+		if err := json.Unmarshal(callMCP(t, r, "resources/list", fmt.Sprintf(`{"cursor":%q}`, cursor)), &p); err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range p.Resources {
+			uris = append(uris, res.URI)
+		}
+		if cursor = p.NextCursor; cursor == "" {
+			break
+		}
+	}
+	// kb2 has no documents and is skipped.
+	if got := strings.Join(uris, ","); got != "ragflow://datasets/kb1/documents/doc1,ragflow://datasets/kb3/documents/doc3" {
+		t.Fatalf("resources = %s", got)
+	}
+
+	if raw := callMCP(t, r, "resources/templates/list", `{}`); !strings.Contains(string(raw), mcpDocumentURITemplate) {
+		t.Errorf("templates = %s", raw)
+	}
+
+	var read struct {
+		Contents []struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(callMCP(t, r, "resources/read", `{"uri":"ragflow://datasets/kb1/documents/doc1"}`), &read); err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Contents) != 1 || read.Contents[0].Text != "first\n\nsecond\n\nthird" {
+		t.Fatalf("read = %+v", read)
+	}
+
+	for uri, code := range map[string]int{
+		"ragflow://datasets/secret/documents/doc1": rpcResourceNotFound,
+		"ragflow://datasets/kb1/documents/gone":    rpcResourceNotFound,
+		"file:///etc/passwd":                       rpcInvalidParams,
+		"ragflow://datasets/kb1/documents/a/b":     rpcInvalidParams,
+	} {
+		w := postMCP(t, r, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":%q}}`, uri))
+		var reply mcpTestReply
+		_ = json.Unmarshal(w.Body.Bytes(), &reply)
+		if reply.Error == nil || reply.Error.Code != code {
+			t.Errorf("read %s = %s, want code %d", uri, w.Body.String(), code)
+		}
+	}
+}
+
+func TestMCPAPIKeyHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/mcp", MCPAPIKeyHeader, func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetHeader("Authorization"))
+	})
+	for header, want := range map[string]string{
+		"X-API-Key":     "Bearer ragflow-key",
+		"api_key":       "Bearer ragflow-key",
+		"Authorization": "Bearer kept",
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if header == "Authorization" {
+			req.Header.Set("Authorization", "Bearer kept")
+			req.Header.Set("X-API-Key", "ignored")
+		} else {
+			req.Header.Set(header, "ragflow-key")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Errorf("%s: Authorization = %q, want %q", header, w.Body.String(), want)
+		}
+	}
+}
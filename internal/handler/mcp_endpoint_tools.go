@@ -0,0 +1,625 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Tools and resources of the built-in MCP server. Every call runs as the
+// token's user, so the services' own access checks apply unchanged.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ragflow/internal/service"
+	"ragflow/internal/utility"
+)
+
+const (
+	// mcpMaxDatasets bounds the datasets a retrieval over "all datasets"
+	// searches and the retrieval tool's description lists.
+	mcpMaxDatasets = 1000
+	// mcpMaxMemories bounds the memories a search over "all memories"
+	// covers.
+	mcpMaxMemories = 100
+	// mcpResourcePageSize is the number of documents per resources/list
+	// page and of chunks per List call when reading a document.
+	mcpResourcePageSize = 100
+	// mcpMaxDocumentChunks caps the chunks a resources/read joins.
+	mcpMaxDocumentChunks = 5000
+
+	mcpDocumentURIPrefix   = "ragflow://datasets/"
+	mcpDocumentURITemplate = "ragflow://datasets/{dataset_id}/documents/{document_id}"
+)
+
+// Defaults of ragflow_retrieval, matching the Python MCP server.
+const (
+	mcpRetrievalPageSize               = 10
+	mcpRetrievalSimilarityThreshold    = 0.2
+	mcpRetrievalVectorSimilarityWeight = 0.3
+	mcpRetrievalTopK                   = 1024
+)
+
+// listTools returns the tool descriptors. The retrieval description
+// lists the caller's datasets so the model can pick dataset_ids.
+func (h *MCPEndpointHandler) listTools(userID string) []utility.Tool {
+	retrievalDesc := "Retrieve relevant chunks from RAGFlow datasets for a question. " +
+		"Pass dataset_ids to search specific datasets or omit it to search all available datasets; " +
+		"document_ids narrows the search to specific documents."
+	if datasets, err := h.userDatasets(userID); err == nil && len(datasets) > 0 {
+		lines := make([]string, 0, len(datasets))
+		for _, ds := range datasets {
+			line := fmt.Sprintf("- %s (id: %s)", mapString(ds, "name"), mapString(ds, "id"))
+			if desc := mapString(ds, "description"); desc != "" {
+				line += ": " + desc
+			}
+			lines = append(lines, line)
+		}
+		retrievalDesc += " Available datasets:\n" + strings.Join(lines, "\n")
+	}
+
+	return []utility.Tool{
+		{
+			Name:        "ragflow_retrieval",
+			Description: retrievalDesc,
+			InputSchema: mcpObjectSchema(map[string]interface{}{
+				"question":                 mcpProp("string", "The question or query to search for."),
+				"dataset_ids":              mcpArrayProp("IDs of the datasets to search. Omit to search all available datasets."),
+				"document_ids":             mcpArrayProp("Optional IDs of documents to search within."),
+				"page":                     mcpProp("integer", "Page number of the results, starting at 1."),
+				"page_size":                mcpProp("integer", "Number of chunks per page (default 10)."),
+				"similarity_threshold":     mcpProp("number", "Minimum similarity score of returned chunks (default 0.2)."),
+				"vector_similarity_weight": mcpProp("number", "Weight of vector similarity against keyword similarity (default 0.3)."),
+				"keyword":                  mcpProp("boolean", "Extract keywords from the question with the chat model before searching."),
+				"top_k":                    mcpProp("integer", "Number of candidate chunks considered for ranking (default 1024)."),
+				"rerank_id":                mcpProp("string", "Optional rerank model to reorder the results."),
+			}, "question"),
+		},
+		{
+			Name:        "list_datasets",
+			Description: "List the datasets (knowledge bases) available to you.",
+			InputSchema: mcpObjectSchema(map[string]interface{}{
+				"keywords":  mcpProp("string", "Only return datasets whose name contains this text."),
+				"page":      mcpProp("integer", "Page number, starting at 1."),
+				"page_size": mcpProp("integer", "Datasets per page (default 30)."),
+			}),
+		},
+		{
+			Name:        "list_documents",
+			Description: "List the documents of a dataset. Each document's uri can be read as a resource for its full text.",
+			InputSchema: mcpObjectSchema(map[string]interface{}{
+				"dataset_id": mcpProp("string", "ID of the dataset."),
+				"page":       mcpProp("integer", "Page number, starting at 1."),
+				"page_size":  mcpProp("integer", "Documents per page (default 30, at most 100)."),
+			}, "dataset_id"),
+		},
+		{
+			Name:        "list_agents",
+			Description: "List the agents available to you. Agents with a published version can be run with run_agent.",
+			InputSchema: mcpObjectSchema(map[string]interface{}{
+				"keywords":  mcpProp("string", "Only return agents whose title contains this text."),
+				"page":      mcpProp("integer", "Page number, starting at 1."),
+				"page_size": mcpProp("integer", "Agents per page (default 30)."),
+			}),
+		},
+		{
+			Name:        "run_agent",
+			Description: "Run the latest published version of an agent and return its answer. Agents that stop to ask the user for input cannot be run this way.",
+			InputSchema: mcpObjectSchema(map[string]interface{}{
+				"agent_id": mcpProp("string", "ID of the agent."),
+				"query":    mcpProp("string", "The user message passed to the agent."),
+				"inputs": map[string]interface{}{
+					"type":        "object",
+					"description": "Values for the agent's begin-node inputs, keyed by input name.",
+				},
+			}, "agent_id"),
+		},
+		{
+			Name:        "search_memory",
+			Description: "Search messages stored in agent memories.",
+			InputSchema: mcpObjectSchema(map[string]interface{}{
+				"query":                      mcpProp("string", "What to search for."),
+				"memory_ids":                 mcpArrayProp("IDs of the memories to search. Omit to search all available memories."),
+				"agent_id":                   mcpProp("string", "Only return messages of this agent."),
+				"session_id":                 mcpProp("string", "Only return messages of this session."),
+				"top_n":                      mcpProp("integer", "Maximum number of messages (default 5)."),
+				"similarity_threshold":       mcpProp("number", "Minimum similarity score (default 0.2)."),
+				"keywords_similarity_weight": mcpProp("number", "Weight of keyword similarity against vector similarity (default 0.7)."),
+			}, "query"),
+		},
+	}
+}
+
+func mcpObjectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func mcpProp(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+func mcpArrayProp(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": description,
+	}
+}
+
+// callTool runs a tool. Failures inside the tool come back as an
+// isError result so the model sees them; only an unknown tool name is a
+// protocol error.
+func (h *MCPEndpointHandler) callTool(ctx context.Context, userID, name string, args json.RawMessage) (interface{}, *mcpRPCError) {
+	var run func(context.Context, string, json.RawMessage) (interface{}, error)
+	switch name {
+	case "ragflow_retrieval":
+		run = h.toolRetrieval
+	case "list_datasets":
+		run = h.toolListDatasets
+	case "list_documents":
+		run = h.toolListDocuments
+	case "list_agents":
+		run = h.toolListAgents
+	case "run_agent":
+		run = h.toolRunAgent
+	case "search_memory":
+		run = h.toolSearchMemory
+	default:
+		return nil, newMCPRPCError(rpcInvalidParams, "Unknown tool: "+name)
+	}
+
+	out, err := run(ctx, userID, args)
+	if err != nil {
+		return mcpToolResult(err.Error(), true), nil
+	}
+	text, err := json.Marshal(out)
+	if err != nil {
+		return mcpToolResult(err.Error(), true), nil
+	}
+	return mcpToolResult(string(text), false), nil
+}
+
+func mcpToolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []interface{}{map[string]interface{}{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// decodeToolArgs unmarshals a tool's arguments; absent arguments leave
+// out zero-valued.
+func decodeToolArgs(args json.RawMessage, out interface{}) error {
+	if len(args) == 0 || string(args) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(args, out); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// mcpPaging returns page and pageSize with defaults applied and
+// pageSize capped at maxSize.
+func mcpPaging(page, pageSize *int, defaultSize, maxSize int) (int, int) {
+	p, size := 1, defaultSize
+	if page != nil && *page > 0 {
+		p = *page
+	}
+	if pageSize != nil && *pageSize > 0 {
+		size = *pageSize
+	}
+	if size > maxSize {
+		size = maxSize
+	}
+	return p, size
+}
+
+func (h *MCPEndpointHandler) toolRetrieval(_ context.Context, userID string, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		Question               string   `json:"question"`
+		DatasetIDs             []string `json:"dataset_ids"`
+		DocumentIDs            []string `json:"document_ids"`
+		Page                   *int     `json:"page"`
+		PageSize               *int     `json:"page_size"`
+		SimilarityThreshold    *float64 `json:"similarity_threshold"`
+		VectorSimilarityWeight *float64 `json:"vector_similarity_weight"`
+		Keyword                *bool    `json:"keyword"`
+		TopK                   *int     `json:"top_k"`
+		RerankID               *string  `json:"rerank_id"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Question) == "" {
+		return nil, errors.New("question is required")
+	}
+
+	datasetIDs := args.DatasetIDs
+	if len(datasetIDs) == 0 {
+		datasets, err := h.userDatasets(userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, ds := range datasets {
+			datasetIDs = append(datasetIDs, mapString(ds, "id"))
+		}
+		if len(datasetIDs) == 0 {
+			return nil, errors.New("no datasets available")
+		}
+	}
+
+	page, size := mcpPaging(args.Page, args.PageSize, mcpRetrievalPageSize, mcpResourcePageSize)
+	threshold := mcpRetrievalSimilarityThreshold
+	if args.SimilarityThreshold != nil {
+		threshold = *args.SimilarityThreshold
+	}
+	weight := mcpRetrievalVectorSimilarityWeight
+	if args.VectorSimilarityWeight != nil {
+		weight = *args.VectorSimilarityWeight
+	}
+	topK := mcpRetrievalTopK
+	if args.TopK != nil && *args.TopK > 0 {
+		topK = *args.TopK
+	}
+
+	resp, err := h.chunks.RetrievalTest(&service.RetrievalTestRequest{
+		Datasets:               datasetIDs,
+		Question:               args.Question,
+		Page:                   &page,
+		Size:                   &size,
+		DocIDs:                 args.DocumentIDs,
+		TopK:                   &topK,
+		RerankID:               args.RerankID,
+		Keyword:                args.Keyword,
+		SimilarityThreshold:    &threshold,
+		VectorSimilarityWeight: &weight,
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]map[string]interface{}, 0, len(resp.Chunks))
+	for _, ch := range resp.Chunks {
+		item := map[string]interface{}{
+			"id":            mapString(ch, "chunk_id"),
+			"content":       mapString(ch, "content_with_weight"),
+			"document_id":   mapString(ch, "doc_id"),
+			"document_name": mapString(ch, "docnm_kwd"),
+			"dataset_id":    mapString(ch, "kb_id"),
+		}
+		if sim, ok := ch["similarity"]; ok {
+			item["similarity"] = sim
+		}
+		chunks = append(chunks, item)
+	}
+	return map[string]interface{}{"chunks": chunks, "total": resp.Total, "page": page, "page_size": size}, nil
+}
+
+func (h *MCPEndpointHandler) toolListDatasets(_ context.Context, userID string, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		Keywords string `json:"keywords"`
+		Page     *int   `json:"page"`
+		PageSize *int   `json:"page_size"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	page, size := mcpPaging(args.Page, args.PageSize, 30, mcpResourcePageSize)
+	data, total, _, err := h.datasets.ListDatasets("", "", page, size, "create_time", true, args.Keywords, nil, "", userID)
+	if err != nil {
+		return nil, err
+	}
+	datasets := make([]map[string]interface{}, 0, len(data))
+	for _, ds := range data {
+		item := map[string]interface{}{
+			"id":             ds["id"],
+			"name":           ds["name"],
+			"document_count": ds["document_count"],
+			"chunk_count":    ds["chunk_count"],
+		}
+		if desc, ok := ds["description"]; ok {
+			item["description"] = desc
+		}
+		datasets = append(datasets, item)
+	}
+	return map[string]interface{}{"datasets": datasets, "total": total}, nil
+}
+
+func (h *MCPEndpointHandler) toolListDocuments(_ context.Context, userID string, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		DatasetID string `json:"dataset_id"`
+		Page      *int   `json:"page"`
+		PageSize  *int   `json:"page_size"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.DatasetID == "" {
+		return nil, errors.New("dataset_id is required")
+	}
+	if !h.datasets.Accessible(args.DatasetID, userID) {
+		return nil, errors.New("No authorization to access the dataset.")
+	}
+	page, size := mcpPaging(args.Page, args.PageSize, 30, mcpResourcePageSize)
+	docs, total, err := h.documents.ListDocumentsByDatasetID(args.DatasetID, page, size)
+	if err != nil {
+		return nil, err
+	}
+	documents := make([]map[string]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		item := map[string]interface{}{
+			"id":          doc.ID,
+			"name":        docName(doc.Name, doc.ID),
+			"type":        doc.Type,
+			"size":        doc.Size,
+			"chunk_count": doc.ChunkNum,
+			"token_count": doc.TokenNum,
+			"uri":         mcpDocumentURI(args.DatasetID, doc.ID),
+		}
+		if doc.Run != nil {
+			item["run"] = *doc.Run
+		}
+		documents = append(documents, item)
+	}
+	return map[string]interface{}{"documents": documents, "total": total}, nil
+}
+
+func (h *MCPEndpointHandler) toolListAgents(_ context.Context, userID string, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		Keywords string `json:"keywords"`
+		Page     *int   `json:"page"`
+		PageSize *int   `json:"page_size"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	page, size := mcpPaging(args.Page, args.PageSize, 30, mcpResourcePageSize)
+	resp, _, err := h.agents.ListAgents(userID, args.Keywords, page, size, "create_time", true, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	agents := make([]map[string]interface{}, 0, len(resp.Canvas))
+	for _, a := range resp.Canvas {
+		item := map[string]interface{}{"id": a.ID}
+		if a.Title != nil {
+			item["title"] = *a.Title
+		}
+		agents = append(agents, item)
+	}
+	return map[string]interface{}{"agents": agents, "total": resp.Total}, nil
+}
+
+func (h *MCPEndpointHandler) toolRunAgent(ctx context.Context, userID string, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		AgentID string         `json:"agent_id"`
+		Query   string         `json:"query"`
+		Inputs  map[string]any `json:"inputs"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.AgentID == "" {
+		return nil, errors.New("agent_id is required")
+	}
+	return h.agents.RunSubAgent(ctx, userID, args.AgentID, "", args.Query, args.Inputs)
+}
+
+func (h *MCPEndpointHandler) toolSearchMemory(ctx context.Context, userID string, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		Query                    string   `json:"query"`
+		MemoryIDs                []string `json:"memory_ids"`
+		AgentID                  string   `json:"agent_id"`
+		SessionID                string   `json:"session_id"`
+		TopN                     *int     `json:"top_n"`
+		SimilarityThreshold      *float64 `json:"similarity_threshold"`
+		KeywordsSimilarityWeight *float64 `json:"keywords_similarity_weight"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Query) == "" {
+		return nil, errors.New("query is required")
+	}
+
+	memoryIDs := args.MemoryIDs
+	if len(memoryIDs) == 0 {
+		resp, err := h.memories.ListMemories(userID, nil, nil, "", "", 1, mcpMaxMemories)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range resp.MemoryList {
+			memoryIDs = append(memoryIDs, mapString(m, "id"))
+		}
+		if len(memoryIDs) == 0 {
+			return map[string]interface{}{"messages": []map[string]interface{}{}}, nil
+		}
+	}
+
+	params := map[string]interface{}{"query": args.Query}
+	if args.TopN != nil {
+		params["top_n"] = *args.TopN
+	}
+	if args.SimilarityThreshold != nil {
+		params["similarity_threshold"] = *args.SimilarityThreshold
+	}
+	if args.KeywordsSimilarityWeight != nil {
+		params["keywords_similarity_weight"] = *args.KeywordsSimilarityWeight
+	}
+	messages, _, err := h.memories.SearchMessage(ctx, userID, map[string]interface{}{
+		"memory_id":  memoryIDs,
+		"agent_id":   args.AgentID,
+		"session_id": args.SessionID,
+	}, params)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"messages": messages}, nil
+}
+
+// userDatasets returns the datasets the user can access, newest first.
+func (h *MCPEndpointHandler) userDatasets(userID string) ([]map[string]interface{}, error) {
+	data, _, _, err := h.datasets.ListDatasets("", "", 1, mcpMaxDatasets, "create_time", true, "", nil, "", userID)
+	return data, err
+}
+
+func mcpDocumentURI(datasetID, documentID string) string {
+	return mcpDocumentURIPrefix + datasetID + "/documents/" + documentID
+}
+
+// parseMCPDocumentURI splits a document resource URI into its dataset
+// and document IDs.
+func parseMCPDocumentURI(uri string) (datasetID, documentID string, ok bool) {
+	rest, found := strings.CutPrefix(uri, mcpDocumentURIPrefix)
+	if !found {
+		return "", "", false
+	}
+	datasetID, documentID, found = strings.Cut(rest, "/documents/")
+	if !found || datasetID == "" || documentID == "" || strings.Contains(datasetID, "/") || strings.Contains(documentID, "/") {
+		return "", "", false
+	}
+	return datasetID, documentID, true
+}
+
+func mcpResourceTemplates() []utility.ResourceTemplate {
+	return []utility.ResourceTemplate{{
+		URITemplate: mcpDocumentURITemplate,
+		Name:        "document",
+		Description: "Full parsed text of a RAGFlow document, joined from its chunks.",
+		MIMEType:    "text/plain",
+	}}
+}
+
+// listResources pages through the user's documents one dataset page at
+// a time. The cursor is "<dataset index>:<page>" into the dataset list.
+func (h *MCPEndpointHandler) listResources(userID, cursor string) (interface{}, *mcpRPCError) {
+	dsIndex, page := 0, 1
+	if cursor != "" {
+		i, p, ok := strings.Cut(cursor, ":")
+		var errI, errP error
+		dsIndex, errI = strconv.Atoi(i)
+		page, errP = strconv.Atoi(p)
+		if !ok || errI != nil || errP != nil || dsIndex < 0 || page < 1 {
+			return nil, newMCPRPCError(rpcInvalidParams, "Invalid cursor")
+		}
+	}
+
+	datasets, err := h.userDatasets(userID)
+	if err != nil {
+		return nil, newMCPRPCError(rpcInternalError, err.Error())
+	}
+	resources := []utility.Resource{}
+	for ; dsIndex < len(datasets); dsIndex, page = dsIndex+1, 1 {
+		datasetID := mapString(datasets[dsIndex], "id")
+		docs, total, err := h.documents.ListDocumentsByDatasetID(datasetID, page, mcpResourcePageSize)
+		if err != nil {
+			return nil, newMCPRPCError(rpcInternalError, err.Error())
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		datasetName := mapString(datasets[dsIndex], "name")
+		for _, doc := range docs {
+			resources = append(resources, utility.Resource{
+				URI:         mcpDocumentURI(datasetID, doc.ID),
+				Name:        docName(doc.Name, doc.ID),
+				Description: "Document in dataset " + datasetName,
+				MIMEType:    "text/plain",
+			})
+		}
+		result := map[string]interface{}{"resources": resources}
+		if int64(page*mcpResourcePageSize) < total {
+			result["nextCursor"] = fmt.Sprintf("%d:%d", dsIndex, page+1)
+		} else if dsIndex+1 < len(datasets) {
+			result["nextCursor"] = fmt.Sprintf("%d:1", dsIndex+1)
+		}
+		return result, nil
+	}
+	return map[string]interface{}{"resources": resources}, nil
+}
+
+// readResource returns a document's text, its chunks joined in the
+// order the doc engine lists them.
+func (h *MCPEndpointHandler) readResource(userID, uri string) (interface{}, *mcpRPCError) {
+	datasetID, documentID, ok := parseMCPDocumentURI(uri)
+	if !ok {
+		return nil, newMCPRPCError(rpcInvalidParams, "Invalid resource uri: "+uri)
+	}
+	if !h.datasets.Accessible(datasetID, userID) {
+		return nil, newMCPRPCError(rpcResourceNotFound, "Resource not found: "+uri)
+	}
+
+	var parts []string
+	size := mcpResourcePageSize
+	for page := 1; len(parts) < mcpMaxDocumentChunks; page++ {
+		resp, err := h.chunks.List(&service.ListChunksRequest{
+			DatasetID: datasetID,
+			DocID:     documentID,
+			Page:      &page,
+			Size:      &size,
+		}, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return nil, newMCPRPCError(rpcResourceNotFound, "Resource not found: "+uri)
+			}
+			return nil, newMCPRPCError(rpcInternalError, err.Error())
+		}
+		for _, ch := range resp.Chunks {
+			if text := mapString(ch, "content_with_weight"); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		if len(resp.Chunks) < size || int64(page*size) >= resp.Total {
+			break
+		}
+	}
+
+	return map[string]interface{}{
+		"contents": []utility.ResourceContents{{
+			URI:      uri,
+			MIMEType: "text/plain",
+			Text:     strings.Join(parts, "\n\n"),
+		}},
+	}, nil
+}
+
+func docName(name *string, id string) string {
+	if name != nil && *name != "" {
+		return *name
+	}
+	return id
+}
+
+// mapString returns m[key] as a string, or "" when absent.
+func mapString(m map[string]interface{}, key string) string {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,233 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"ragflow/internal/common"
+	"ragflow/internal/service"
+)
+
+// openAPIUploadMaxBytes bounds a multipart spec upload; the service
+// applies its own limit to the document itself.
+const openAPIUploadMaxBytes = 6 << 20
+
+// OpenAPIHandler handles OpenAPI spec requests.
+type OpenAPIHandler struct {
+	openAPIService *service.OpenAPIService
+}
+
+// NewOpenAPIHandler creates an OpenAPI handler.
+func NewOpenAPIHandler(openAPIService *service.OpenAPIService) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		openAPIService: openAPIService,
+	}
+}
+
+// CreateOpenAPISpec registers a spec for the current user. The body is
+// either JSON (CreateOpenAPISpecRequest) or a multipart form with the
+// document in the "file" field and the other fields as form values
+// ("credentials" holds a JSON object).
+func (h *OpenAPIHandler) CreateOpenAPISpec(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	var req service.CreateOpenAPISpecRequest
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		parsed, err := bindOpenAPIUpload(c)
+		if err != nil {
+			jsonError(c, common.CodeDataError, err.Error())
+			return
+		}
+		req = parsed
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		jsonError(c, common.CodeDataError, err.Error())
+		return
+	}
+
+	result, code, err := h.openAPIService.CreateOpenAPISpec(c.Request.Context(), user.ID, req)
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+func bindOpenAPIUpload(c *gin.Context) (service.CreateOpenAPISpecRequest, error) {
+	var req service.CreateOpenAPISpecRequest
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, openAPIUploadMaxBytes)
+	if err := c.Request.ParseMultipartForm(openAPIUploadMaxBytes); err != nil {
+		return req, fmt.Errorf("invalid upload: %w", err)
+	}
+	defer func() { _ = c.Request.MultipartForm.RemoveAll() }()
+
+	req.Name = c.PostForm("name")
+	req.URL = c.PostForm("url")
+	req.BaseURL = c.PostForm("base_url")
+	if desc, ok := c.GetPostForm("description"); ok {
+		req.Description = &desc
+	}
+	if creds := strings.TrimSpace(c.PostForm("credentials")); creds != "" {
+		if !json.Valid([]byte(creds)) {
+			return req, fmt.Errorf("credentials must be a JSON object")
+		}
+		req.Credentials = json.RawMessage(creds)
+	}
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			return req, fmt.Errorf("open upload: %w", err)
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return req, fmt.Errorf("read upload: %w", err)
+		}
+		req.Spec = string(data)
+	}
+	return req, nil
+}
+
+// ListOpenAPISpecs lists the current user's specs.
+func (h *OpenAPIHandler) ListOpenAPISpecs(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	specs, code, err := h.openAPIService.ListOpenAPISpecs(user.ID, c.Query("keywords"))
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data": gin.H{
+			"specs": specs,
+			"total": len(specs),
+		},
+	})
+}
+
+// GetOpenAPISpec returns one of the current user's specs.
+func (h *OpenAPIHandler) GetOpenAPISpec(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	result, code, err := h.openAPIService.GetOpenAPISpec(user.ID, c.Param("spec_id"))
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// UpdateOpenAPISpec updates one of the current user's specs.
+func (h *OpenAPIHandler) UpdateOpenAPISpec(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	var req service.UpdateOpenAPISpecRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonError(c, common.CodeDataError, err.Error())
+		return
+	}
+
+	result, code, err := h.openAPIService.UpdateOpenAPISpec(c.Request.Context(), user.ID, c.Param("spec_id"), req)
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// DeleteOpenAPISpec deletes one of the current user's specs.
+func (h *OpenAPIHandler) DeleteOpenAPISpec(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	code, err := h.openAPIService.DeleteOpenAPISpec(user.ID, c.Param("spec_id"))
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    true,
+	})
+}
+
+// ListOpenAPITools lists every operation of the current user's specs as
+// Agent tool picker entries.
+func (h *OpenAPIHandler) ListOpenAPITools(c *gin.Context) {
+	user, errorCode, errorMessage := GetUser(c)
+	if errorCode != common.CodeSuccess {
+		jsonError(c, errorCode, errorMessage)
+		return
+	}
+
+	tools, code, err := h.openAPIService.ListOpenAPITools(user.ID)
+	if err != nil {
+		jsonError(c, code, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    common.CodeSuccess,
+		"message": "success",
+		"data":    tools,
+	})
+}
@@ -929,6 +929,8 @@ type ChatToModelRequest struct {
 	Thinking     bool                     `json:"thinking"`
 	Effort       *string                  `json:"effort"`
 	Verbosity    *string                  `json:"verbosity"`
+	// ResponseSchema requests a JSON answer matching the given schema.
+	ResponseSchema *models.ResponseSchema `json:"response_schema"`
 }
 
 func (h *ProviderHandler) ChatToModel(c *gin.Context) {
@@ -976,6 +978,24 @@ func (h *ProviderHandler) ChatToModel(c *gin.Context) {
 		}
 	}
 
+	if req.ResponseSchema != nil && len(req.ResponseSchema.Schema) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "Response schema is empty",
+		})
+		return
+	}
+
+	// The answer is validated against the schema once complete, which a
+	// streamed answer never is before it reaches the client.
+	if req.ResponseSchema != nil && req.Stream {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "Response schema is not supported with stream",
+		})
+		return
+	}
+
 	userID := c.GetString("user_id")
 
 	if !req.Thinking {
@@ -989,16 +1009,17 @@ func (h *ProviderHandler) ChatToModel(c *gin.Context) {
 	}
 
 	chatConfig := models.ChatConfig{
-		Thinking:    &req.Thinking,
-		Stream:      &req.Stream,
-		Vision:      nil,
-		Stop:        &[]string{},
-		DoSample:    nil,
-		MaxTokens:   nil,
-		Temperature: nil,
-		TopP:        nil,
-		Effort:      req.Effort,
-		Verbosity:   req.Verbosity,
+		Thinking:       &req.Thinking,
+		Stream:         &req.Stream,
+		Vision:         nil,
+		Stop:           &[]string{},
+		DoSample:       nil,
+		MaxTokens:      nil,
+		Temperature:    nil,
+		TopP:           nil,
+		Effort:         req.Effort,
+		Verbosity:      req.Verbosity,
+		ResponseSchema: req.ResponseSchema,
 	}
 
 	// Check if it's a stream request
@@ -1681,6 +1702,106 @@ func (h *ProviderHandler) ParseFile(c *gin.Context) {
 	})
 }
 
+type GenerateImageRequest struct {
+	ProviderName   *string                `json:"provider_name"`
+	InstanceName   *string                `json:"instance_name"`
+	ModelName      *string                `json:"model_name"`
+	ModelID        *string                `json:"model_id"`
+	Prompt         string                 `json:"prompt" binding:"required"`
+	NegativePrompt string                 `json:"negative_prompt"`
+	N              int                    `json:"n"`
+	Size           string                 `json:"size"`
+	Quality        string                 `json:"quality"`
+	Style          string                 `json:"style"`
+	ResponseFormat string                 `json:"response_format"`
+	Params         map[string]interface{} `json:"params"`
+}
+
+func (h *ProviderHandler) GenerateImage(c *gin.Context) {
+	var req GenerateImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    common.CodeBadRequest,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if req.ModelID == nil {
+		if req.ProviderName == nil || *req.ProviderName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "Provider name is required",
+			})
+			return
+		}
+
+		if req.InstanceName == nil || *req.InstanceName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "Instance name is required",
+			})
+			return
+		}
+
+		if req.ModelName == nil || *req.ModelName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "Model name is required",
+			})
+			return
+		}
+	} else {
+		if *req.ModelID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "Model ID is empty",
+			})
+			return
+		}
+	}
+
+	if req.N < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "n must be a positive integer",
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	apiConfig := models.APIConfig{
+		ApiKey: nil,
+		Region: nil,
+	}
+
+	imageConfig := models.ImageGenerationConfig{
+		Size:           req.Size,
+		N:              req.N,
+		Quality:        req.Quality,
+		Style:          req.Style,
+		NegativePrompt: req.NegativePrompt,
+		ResponseFormat: req.ResponseFormat,
+		Params:         req.Params,
+	}
+
+	response, errorCode, err := h.modelProviderService.GenerateImage(req.ProviderName, req.InstanceName, req.ModelName, req.ModelID, userID, req.Prompt, &apiConfig, &imageConfig)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    errorCode,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    response,
+		"message": "success",
+	})
+}
+
 // ListTenantAddedModels is the response handler for GET /api/v1/models.
 // It is the Go port of Python's
 // api/apps/restful_apis/models_api.py:get_added_models and feeds
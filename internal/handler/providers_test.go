@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -154,3 +155,25 @@ func TestProviderHandlerEnableOrDisableModelUpdatesStatus(t *testing.T) {
 		t.Fatalf("status = %q, want inactive", got.Status)
 	}
 }
+
+func TestProviderHandlerChatToModelRejectsStreamedResponseSchema(t *testing.T) {
+	ctx, recorder := newProviderHandlerRequest(t, map[string]interface{}{
+		"model_id": "model-1",
+		"messages": []map[string]interface{}{{"role": "user", "content": "hi"}},
+		"stream":   true,
+		"response_schema": map[string]interface{}{
+			"name":   "answer",
+			"schema": map[string]interface{}{"type": "object"},
+		},
+	})
+
+	NewProviderHandler(nil, service.NewModelProviderService()).ChatToModel(ctx)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d; body=%s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+	}
+	body := decodeProviderHandlerResponse(t, recorder)
+	if !strings.Contains(body["message"].(string), "stream") {
+		t.Fatalf("message = %v, want a stream rejection", body["message"])
+	}
+}
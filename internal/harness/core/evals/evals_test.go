@@ -4,8 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"ragflow/internal/harness/core"
 	"ragflow/internal/harness/core/schema"
@@ -431,3 +433,34 @@ func TestMultipleScorers(t *testing.T) {
 		t.Errorf("expected no errors, got: %v", mt.errors)
 	}
 }
+
+// TestWriteJUnit verifies the JUnit rendering of a mixed report.
+func TestWriteJUnit(t *testing.T) {
+	report := &EvalReport{
+		Cases: []CaseReport{
+			{CaseName: "ok", Passed: true, Duration: 1500 * time.Millisecond},
+			{CaseName: "bad", Failures: []Failure{
+				{ScorerName: "answer_contains", Message: "missing <hello>"},
+				{ScorerName: "visited", Message: "node x not visited"},
+			}},
+		},
+		Total: 2, Passed: 1, Failed: 1, Duration: 2 * time.Second,
+	}
+
+	var buf strings.Builder
+	if err := WriteJUnit(&buf, "suite", report); err != nil {
+		t.Fatalf("WriteJUnit: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<testsuites tests="2" failures="1" time="2.000">`,
+		`<testsuite name="suite" tests="2" failures="1" time="2.000">`,
+		`<testcase name="ok" classname="suite" time="1.500"></testcase>`,
+		`message="answer_contains: missing &lt;hello&gt;"`,
+		"visited: node x not visited",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("junit output missing %q:\n%s", want, out)
+		}
+	}
+}
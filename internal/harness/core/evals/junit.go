@@ -0,0 +1,95 @@
+package evals
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ========================================================================
+// JUnit report
+// ========================================================================
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit renders the report as a JUnit XML document, one testcase per
+// eval case. All failures of a case are folded into a single <failure>
+// element; the first one becomes its message attribute.
+func WriteJUnit(w io.Writer, suiteName string, report *EvalReport) error {
+	if report == nil {
+		report = &EvalReport{}
+	}
+	suite := junitTestSuite{
+		Name:     suiteName,
+		Tests:    report.Total,
+		Failures: report.Failed,
+		Time:     junitSeconds(report.Duration.Seconds()),
+	}
+	for _, c := range report.Cases {
+		tc := junitTestCase{
+			Name:      c.CaseName,
+			ClassName: suiteName,
+			Time:      junitSeconds(c.Duration.Seconds()),
+		}
+		if !c.Passed {
+			lines := make([]string, 0, len(c.Failures))
+			for _, f := range c.Failures {
+				lines = append(lines, f.ScorerName+": "+f.Message)
+			}
+			tc.Failure = &junitFailure{Type: "AssertionError", Body: strings.Join(lines, "\n")}
+			if len(c.Failures) > 0 {
+				tc.Failure.Message = c.Failures[0].ScorerName + ": " + truncate(c.Failures[0].Message, 200)
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	doc := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("evals: encode junit: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
@@ -53,6 +53,8 @@ func RegisterAgentRoutes(g *gin.RouterGroup, h *handler.AgentHandler) {
 	g.POST("/:canvas_id/run", h.RunAgent)
 	g.DELETE("/:canvas_id/run", h.CancelAgent)
 	g.POST("/:canvas_id/publish", h.PublishAgent)
+	g.POST("/:canvas_id/lint", h.LintAgent)
+	g.POST("/:canvas_id/tests", h.RunAgentTests)
 	g.PUT("/:canvas_id/tags", h.UpdateAgentTags)
 	g.POST("/:canvas_id/reset", h.ResetAgent)
 
@@ -67,9 +69,17 @@ func RegisterAgentRoutes(g *gin.RouterGroup, h *handler.AgentHandler) {
 
 	// Versions.
 	g.GET("/:canvas_id/versions", h.ListVersions)
+	g.GET("/:canvas_id/versions/diff", h.DiffVersions)
 	g.GET("/:canvas_id/versions/:version_id", h.GetVersion)
 	g.DELETE("/:canvas_id/versions/:version_id", h.DeleteVersion)
 
+	// Schedule and event triggers.
+	g.GET("/:canvas_id/triggers", h.ListAgentTriggers)
+	g.POST("/:canvas_id/triggers", h.CreateAgentTrigger)
+	g.POST("/:canvas_id/triggers/:trigger_id/pause", h.PauseAgentTrigger)
+	g.POST("/:canvas_id/triggers/:trigger_id/resume", h.ResumeAgentTrigger)
+	g.DELETE("/:canvas_id/triggers/:trigger_id", h.DeleteAgentTrigger)
+
 	// Sessions.
 	g.GET("/:canvas_id/sessions", h.ListAgentSessions)
 	g.POST("/:canvas_id/sessions", h.CreateAgentSession)
@@ -77,6 +87,21 @@ func RegisterAgentRoutes(g *gin.RouterGroup, h *handler.AgentHandler) {
 	g.DELETE("/:canvas_id/sessions", h.DeleteAgentSession)
 	g.DELETE("/:canvas_id/sessions/:session_id", h.DeleteAgentSession)
 
+	// Run time travel: per-node checkpoints of a session's run.
+	g.GET("/:canvas_id/sessions/:session_id/checkpoints", h.ListAgentRunCheckpoints)
+	g.GET("/:canvas_id/sessions/:session_id/checkpoints/:seq", h.GetAgentRunCheckpoint)
+	g.POST("/:canvas_id/sessions/:session_id/checkpoints/:seq/fork", h.ForkAgentRun)
+
+	// Run cost and latency reports.
+	g.GET("/:canvas_id/sessions/:session_id/report", h.GetAgentRunReport)
+	g.GET("/:canvas_id/reports/stats", h.GetAgentRunStats)
+
+	// Human approval gates: pending approvals of the caller's tenants
+	// and the decision endpoint, which streams the resumed run.
+	g.GET("/approvals", h.ListAgentApprovals)
+	g.GET("/approvals/:approval_id", h.GetAgentApproval)
+	g.POST("/approvals/:approval_id", h.DecideAgentApproval)
+
 	// Logs and webhook.
 	g.GET("/:canvas_id/logs/:message_id", h.GetAgentLogs)
 	g.GET("/:canvas_id/webhook/logs", h.GetAgentWebhookLogs)
@@ -70,6 +70,66 @@ func TestAgentRoutes_AllElevenRegistered(t *testing.T) {
 	}
 }
 
+// TestAgentRoutes_TriggersRegistered pins the schedule / event trigger
+// endpoints.
+func TestAgentRoutes_TriggersRegistered(t *testing.T) {
+	eng := gin.New()
+	RegisterAgentRoutes(eng.Group("/api/v1/agents"), &handler.AgentHandler{})
+
+	cases := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/v1/agents/abc/triggers"},
+		{http.MethodPost, "/api/v1/agents/abc/triggers"},
+		{http.MethodPost, "/api/v1/agents/abc/triggers/t1/pause"},
+		{http.MethodPost, "/api/v1/agents/abc/triggers/t1/resume"},
+		{http.MethodDelete, "/api/v1/agents/abc/triggers/t1"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		eng.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Code == http.StatusNotFound {
+			t.Errorf("route %s %s returned 404", c.method, c.path)
+		}
+	}
+}
+
+// TestAgentRoutes_CheckpointsRegistered pins the run time-travel
+// endpoints.
+func TestAgentRoutes_CheckpointsRegistered(t *testing.T) {
+	eng := gin.New()
+	RegisterAgentRoutes(eng.Group("/api/v1/agents"), &handler.AgentHandler{})
+
+	cases := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/v1/agents/abc/sessions/s1/checkpoints"},
+		{http.MethodGet, "/api/v1/agents/abc/sessions/s1/checkpoints/0"},
+		{http.MethodPost, "/api/v1/agents/abc/sessions/s1/checkpoints/0/fork"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		eng.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Code == http.StatusNotFound {
+			t.Errorf("route %s %s returned 404", c.method, c.path)
+		}
+	}
+}
+
+// TestAgentRoutes_LintRegistered pins the DSL lint endpoint.
+func TestAgentRoutes_LintRegistered(t *testing.T) {
+	eng := gin.New()
+	RegisterAgentRoutes(eng.Group("/api/v1/agents"), &handler.AgentHandler{})
+
+	w := httptest.NewRecorder()
+	eng.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/agents/abc/lint", nil))
+	if w.Code == http.StatusNotFound {
+		t.Errorf("route POST /api/v1/agents/abc/lint returned 404")
+	}
+}
+
 // TestAgentRoutes_NilSafety makes sure the helper tolerates the "no
 // handler yet" wiring case. A nil group or nil handler is a no-op so
 // upstream config bugs surface as missing routes, not nil-deref panics.
@@ -79,3 +139,63 @@ func TestAgentRoutes_NilSafety(t *testing.T) {
 	RegisterAgentRoutes(eng.Group("/agents"), nil)
 	// Reaching here without panicking is the assertion.
 }
+
+// TestAgentRoutes_TestsRegistered pins the canvas test-suite endpoint.
+func TestAgentRoutes_TestsRegistered(t *testing.T) {
+	eng := gin.New()
+	RegisterAgentRoutes(eng.Group("/api/v1/agents"), &handler.AgentHandler{})
+
+	w := httptest.NewRecorder()
+	eng.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/agents/abc/tests", nil))
+	if w.Code == http.StatusNotFound {
+		t.Errorf("route POST /api/v1/agents/abc/tests returned 404")
+	}
+}
+
+// TestAgentRoutes_VersionDiffRegistered pins the version diff endpoint
+// next to the :version_id routes it shares a prefix with.
+func TestAgentRoutes_VersionDiffRegistered(t *testing.T) {
+	eng := gin.New()
+	RegisterAgentRoutes(eng.Group("/api/v1/agents"), &handler.AgentHandler{})
+
+	for _, path := range []string{"/api/v1/agents/abc/versions/diff", "/api/v1/agents/abc/versions/v1"} {
+		w := httptest.NewRecorder()
+		eng.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code == http.StatusNotFound {
+			t.Errorf("route GET %s returned 404", path)
+		}
+	}
+}
+
+func TestAgentRoutes_ApprovalsRegistered(t *testing.T) {
+	eng := gin.New()
+	RegisterAgentRoutes(eng.Group("/api/v1/agents"), &handler.AgentHandler{})
+
+	for _, r := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/agents/approvals"},
+		{http.MethodGet, "/api/v1/agents/approvals/a1"},
+		{http.MethodPost, "/api/v1/agents/approvals/a1"},
+	} {
+		w := httptest.NewRecorder()
+		eng.ServeHTTP(w, httptest.NewRequest(r.method, r.path, nil))
+		if w.Code == http.StatusNotFound {
+			t.Errorf("route %s %s returned 404", r.method, r.path)
+		}
+	}
+}
+
+func TestAgentRoutes_RunReportsRegistered(t *testing.T) {
+	eng := gin.New()
+	RegisterAgentRoutes(eng.Group("/api/v1/agents"), &handler.AgentHandler{})
+
+	for _, path := range []string{
+		"/api/v1/agents/c1/sessions/s1/report",
+		"/api/v1/agents/c1/reports/stats",
+	} {
+		w := httptest.NewRecorder()
+		eng.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code == http.StatusNotFound {
+			t.Errorf("route GET %s returned 404", path)
+		}
+	}
+}
@@ -43,6 +43,9 @@ type Router struct {
 	fileHandler          *handler.FileHandler
 	memoryHandler        *handler.MemoryHandler
 	mcpHandler           *handler.MCPHandler
+	openAPIHandler       *handler.OpenAPIHandler
+	emailAccountHandler  *handler.EmailAccountHandler
+	mcpEndpointHandler   *handler.MCPEndpointHandler
 	skillSearchHandler   *handler.SkillSearchHandler
 	providerHandler      *handler.ProviderHandler
 	agentHandler         *handler.AgentHandler
@@ -75,6 +78,9 @@ func NewRouter(
 	fileHandler *handler.FileHandler,
 	memoryHandler *handler.MemoryHandler,
 	mcpHandler *handler.MCPHandler,
+	openAPIHandler *handler.OpenAPIHandler,
+	emailAccountHandler *handler.EmailAccountHandler,
+	mcpEndpointHandler *handler.MCPEndpointHandler,
 	skillSearchHandler *handler.SkillSearchHandler,
 	providerHandler *handler.ProviderHandler,
 	agentHandler *handler.AgentHandler,
@@ -107,6 +113,9 @@ func NewRouter(
 		fileHandler:          fileHandler,
 		memoryHandler:        memoryHandler,
 		mcpHandler:           mcpHandler,
+		openAPIHandler:       openAPIHandler,
+		emailAccountHandler:  emailAccountHandler,
+		mcpEndpointHandler:   mcpEndpointHandler,
 		skillSearchHandler:   skillSearchHandler,
 		providerHandler:      providerHandler,
 		agentHandler:         agentHandler,
@@ -499,6 +508,7 @@ func (r *Router) Setup(engine *gin.Engine) {
 				v1.POST("/audio/speech", r.providerHandler.AudioSpeech)
 				v1.POST("/file/ocr", r.providerHandler.OCRFile)
 				v1.POST("/file/parse", r.providerHandler.ParseFile)
+				v1.POST("/images/generations", r.providerHandler.GenerateImage)
 			}
 
 			model := v1.Group("/models")
@@ -573,6 +583,30 @@ func (r *Router) Setup(engine *gin.Engine) {
 				mcp.POST("/servers/:mcp_id/test", r.mcpHandler.TestMCPServer)
 			}
 
+			// OpenAPI specs registered as Agent tools. /tools lists every
+			// operation under the name the Agent component resolves.
+			openapi := v1.Group("/openapi")
+			{
+				openapi.POST("/specs", r.openAPIHandler.CreateOpenAPISpec)
+				openapi.GET("/specs", r.openAPIHandler.ListOpenAPISpecs)
+				openapi.GET("/specs/:spec_id", r.openAPIHandler.GetOpenAPISpec)
+				openapi.PUT("/specs/:spec_id", r.openAPIHandler.UpdateOpenAPISpec)
+				openapi.DELETE("/specs/:spec_id", r.openAPIHandler.DeleteOpenAPISpec)
+				openapi.GET("/tools", r.openAPIHandler.ListOpenAPITools)
+			}
+
+			// IMAP accounts registered as "imap:<account_id>" Agent tools
+			// and as sources of email.received triggers.
+			email := v1.Group("/email")
+			{
+				email.POST("/accounts", r.emailAccountHandler.CreateEmailAccount)
+				email.GET("/accounts", r.emailAccountHandler.ListEmailAccounts)
+				email.GET("/accounts/:account_id", r.emailAccountHandler.GetEmailAccount)
+				email.PUT("/accounts/:account_id", r.emailAccountHandler.UpdateEmailAccount)
+				email.DELETE("/accounts/:account_id", r.emailAccountHandler.DeleteEmailAccount)
+				email.POST("/accounts/:account_id/test", r.emailAccountHandler.TestEmailAccount)
+			}
+
 			system := v1.Group("/system")
 			{
 				system.GET("/configs", r.systemHandler.GetConfigs)
@@ -734,6 +768,13 @@ func (r *Router) Setup(engine *gin.Engine) {
 	}
 	apiNoAuth.GET("/dify/retrieval/health", r.difyRetrievalHandler.HealthCheck)
 
+	// Built-in MCP server (streamable HTTP). Mounted outside the
+	// authorized group so MCPAPIKeyHeader can map the api_key /
+	// X-API-Key headers onto Authorization before AuthMiddleware runs.
+	apiNoAuth.POST("/mcp", handler.MCPAPIKeyHeader, r.authHandler.AuthMiddleware(), r.mcpEndpointHandler.Serve)
+	apiNoAuth.GET("/mcp", r.mcpEndpointHandler.MethodNotAllowed)
+	apiNoAuth.DELETE("/mcp", r.mcpEndpointHandler.MethodNotAllowed)
+
 	// Handle undefined routes
 	engine.NoRoute(handler.HandleNoRoute)
 }
@@ -53,6 +53,7 @@ type Config struct {
 	DefaultSuperUser DefaultSuperUser       `mapstructure:"default_super_user"`
 	Language         string                 `mapstructure:"language"`
 	TaskExecutor     TaskExecutorConfig     `mapstructure:"task_executor"`
+	Agent            AgentConfig            `mapstructure:"agent"`
 }
 
 // AdminConfig admin server configuration
@@ -76,6 +77,18 @@ type TaskExecutorConfig struct {
 	MessageQueueType string `mapstructure:"message_queue_type"`
 }
 
+// AgentConfig agent runtime configuration
+type AgentConfig struct {
+	// ModelPrices prices LLM tokens in run reports, keyed by model name.
+	ModelPrices map[string]ModelPriceConfig `mapstructure:"model_prices"`
+}
+
+// ModelPriceConfig is the price of one model per million tokens
+type ModelPriceConfig struct {
+	InputPerMillion  float64 `mapstructure:"input_per_million"`
+	OutputPerMillion float64 `mapstructure:"output_per_million"`
+}
+
 // UserDefaultLLMConfig user default LLM configuration
 type UserDefaultLLMConfig struct {
 	DefaultModels DefaultModelsConfig `mapstructure:"default_models"`
@@ -126,6 +126,8 @@ type AgentService struct {
 	canvasTemplateDAO   *dao.CanvasTemplateDAO
 	userTenantDAO       *dao.UserTenantDAO
 	versionDAO          *dao.UserCanvasVersionDAO
+	triggerDAO          *dao.AgentTriggerDAO
+	runReportDAO        *dao.AgentRunReportDAO
 	api4ConversationDAO *dao.API4ConversationDAO
 
 	// driver is the per-process runner that drives canvas
@@ -147,6 +149,20 @@ type AgentService struct {
 	// MarkFailed / MarkCancelled) to Redis hash "agent:run:{id}".
 	runTracker *canvas.RunTracker
 
+	// nodeCheckpoints records the CanvasState after every node so a
+	// run can be inspected and forked (agent_checkpoints.go). Nil
+	// disables time travel.
+	nodeCheckpoints canvas.NodeCheckpointStore
+
+	// approvals backs the Approval node (agent_approval.go): pending
+	// approvals and their interrupt ids. Nil disables approvals.
+	approvals        canvas.ApprovalStore
+	approvalNotifier ApprovalNotifier
+
+	// knownComponent backs the unknown-component check of the DSL
+	// validator (agent_lint.go). Nil skips the check.
+	knownComponent func(name string) bool
+
 	// runMu and runStreams coordinate active canvas run goroutines so that
 	// CancelAgent can signal a specific canvas. The map is keyed by canvas
 	// ID; values are channels that close to signal cancellation.
@@ -177,20 +193,30 @@ func NewAgentServiceWithOptions(
 	if stub, ok := agenttool.GetSandboxClient().(interface{ IsStubSandboxClient() bool }); ok && stub.IsStubSandboxClient() {
 		agenttool.SetSandboxClient(agentsandbox.NewManagerClient())
 	}
+	installMCPStdioLauncher()
 	return &AgentService{
 		canvasDAO:           dao.NewUserCanvasDAO(),
 		canvasTemplateDAO:   dao.NewCanvasTemplateDAO(),
 		userTenantDAO:       dao.NewUserTenantDAO(),
 		versionDAO:          dao.NewUserCanvasVersionDAO(),
+		triggerDAO:          dao.NewAgentTriggerDAO(),
+		runReportDAO:        dao.NewAgentRunReportDAO(),
 		api4ConversationDAO: dao.NewAPI4ConversationDAO(),
 		runner:              canvas.NewRunner(),
 		runStreams:          make(map[string]chan struct{}),
 		checkpointStore:     cp,
 		stateSerializer:     ser,
 		runTracker:          rt,
+		approvalNotifier:    approvalMailer{},
 	}
 }
 
+// SetNodeCheckpointStore installs the per-node checkpoint store that
+// backs run inspection and forking. Passing nil disables it.
+func (s *AgentService) SetNodeCheckpointStore(store canvas.NodeCheckpointStore) {
+	s.nodeCheckpoints = store
+}
+
 // ListTemplates returns every canvas template. Mirrors Python
 // agent_api.list_agent_template, which iterates CanvasTemplateService.get_all()
 // and serialises each row.
@@ -394,18 +420,20 @@ func (s *AgentService) GetAgent(ctx context.Context, userID, canvasID string) (*
 
 // UpdateAgent writes a new DSL to the draft (user_canvas.dsl) and toggles
 // release=false. The call does NOT create a new user_canvas_version row —
-// versions are produced only by PublishAgent.
-func (s *AgentService) UpdateAgent(ctx context.Context, userID, canvasID string, dsl entity.JSONMap) error {
+// versions are produced only by PublishAgent. The saved draft is linted
+// and the diagnostics returned; a draft with errors is still saved so
+// work in progress is never lost.
+func (s *AgentService) UpdateAgent(ctx context.Context, userID, canvasID string, dsl entity.JSONMap) ([]dslpkg.Diagnostic, error) {
 	row, err := s.loadCanvasForUser(ctx, userID, canvasID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	row.DSL = dslpkg.NormalizeForCanvas(dsl)
 	row.Release = false
 	if err := s.canvasDAO.Update(row); err != nil {
-		return fmt.Errorf("update agent %s: %w", canvasID, err)
+		return nil, fmt.Errorf("update agent %s: %w", canvasID, err)
 	}
-	return nil
+	return s.lintDSL(row.CanvasCategory, row.DSL), nil
 }
 
 // ResetAgent clears the per-run state of a canvas (history, retrieval,
@@ -470,6 +498,9 @@ func (s *AgentService) DeleteAgent(ctx context.Context, userID, canvasID string)
 		if _, err := s.versionDAO.DeleteByCanvasIDTx(tx, canvasID); err != nil {
 			return fmt.Errorf("delete agent: cascade versions: %w", err)
 		}
+		if _, err := s.triggerDAO.DeleteByCanvasIDTx(tx, canvasID); err != nil {
+			return fmt.Errorf("delete agent: cascade triggers: %w", err)
+		}
 		if err := s.canvasDAO.DeleteTx(tx, canvasID); err != nil {
 			return fmt.Errorf("delete agent %s: %w", canvasID, err)
 		}
@@ -487,7 +518,9 @@ type PublishAgentRequest struct {
 // PublishAgent appends a new user_canvas_version row and marks the parent
 // canvas as released in a single transaction. Existing versions are never
 // overwritten (§2.9); the parent canvas DSL/title/description/release
-// fields are updated atomically with the new version row.
+// fields are updated atomically with the new version row. A DSL that
+// fails static validation is refused with an *AgentLintError; dataflow
+// canvases (ingestion pipelines) are not linted.
 func (s *AgentService) PublishAgent(ctx context.Context, userID, canvasID string, req *PublishAgentRequest) (*entity.UserCanvasVersion, error) {
 	canvas, err := s.loadCanvasForUser(ctx, userID, canvasID)
 	if err != nil {
@@ -507,6 +540,9 @@ func (s *AgentService) PublishAgent(ctx context.Context, userID, canvasID string
 			description = req.Description
 		}
 	}
+	if diags := s.lintDSL(canvas.CanvasCategory, dsl); dslpkg.HasErrors(diags) {
+		return nil, &AgentLintError{Diagnostics: diags}
+	}
 	row := &entity.UserCanvasVersion{
 		ID:           genID32(),
 		UserCanvasID: canvasID,
@@ -723,6 +759,16 @@ func (s *AgentService) RunAgent(ctx context.Context, userID, canvasID, sessionID
 	if payload, ok := ctx.Value(webhookPayloadKey{}).(map[string]any); ok && payload != nil {
 		root["webhook_payload"] = payload
 	}
+	// Fork injection. Only ForkRunFromCheckpoint sets this context
+	// value; buildRunFunc seeds the state from it.
+	if fork, ok := ctx.Value(agentRunForkKey{}).(*agentRunFork); ok && fork != nil {
+		root["__fork__"] = fork
+	}
+	// Trigger payload injection. Only AgentTriggerScheduler sets this
+	// context value; Begin surfaces it as sys.trigger_payload.
+	if payload, ok := ctx.Value(triggerPayloadKey{}).(map[string]any); ok && payload != nil {
+		root["trigger_payload"] = payload
+	}
 	// Phase 4.4 V2.1 (v3.6.1): populate root["tenant_id"] so the
 	// RunTracker.Start call (in buildRunFunc) records the run
 	// under the right tenant. The lookup is best-effort — a
@@ -839,13 +885,6 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 			return state, nil
 		}
 
-		// DSL → *Canvas.
-		c, err := decodeCanvasFromDSL(dsl)
-		if err != nil {
-			s.markRunFailed(ctx, runID, "decode: "+err.Error())
-			return nil, err
-		}
-
 		// Store events channel + run metadata on the context so the
 		// per-node statePre/statePost wrappers (in scheduler.go) can
 		// emit node_started / node_finished events at the correct
@@ -853,12 +892,37 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 		// state.Sys) because eino's WithGenLocalState creates a fresh
 		// CanvasState per run — only the context thread survives from
 		// the service layer into the state handlers.
-		ctx2 := canvas.WithRunMeta(ctx, &canvas.RunMeta{
+		runMeta := &canvas.RunMeta{
 			Events:    events,
 			MessageID: messageID,
 			TaskID:    taskID,
 			SessionID: sessionID,
-		})
+			Report:    canvas.NewRunReportCollector(time.Now()),
+		}
+		// DSL → *Canvas. A run that fails here or at compile time
+		// still gets a (node-less) report.
+		c, err := decodeCanvasFromDSL(dsl)
+		if err != nil {
+			s.markRunFailed(ctx, runID, "decode: "+err.Error())
+			s.saveRunReport(canvasID, versionRow, root, runMeta, canvas.RunReportFailed)
+			return nil, err
+		}
+
+		if s.nodeCheckpoints != nil {
+			runMeta.RunID = runID
+			runMeta.Checkpoints = s.nodeCheckpoints
+			if versionRow != nil {
+				runMeta.VersionID = versionRow.ID
+			}
+			// A resumed run keeps appending to the list it started;
+			// a fresh run on the same session id starts over.
+			if !isResume || resumeID == "" {
+				if err := s.nodeCheckpoints.Reset(ctx, runID); err != nil {
+					common.Warn("service: reset node checkpoints (best-effort)", zap.String("run", runID), zap.Error(err))
+				}
+			}
+		}
+		ctx2 := canvas.WithRunMeta(ctx, runMeta)
 
 		// Seed initial env/sys values from the Canvas DSL globals.
 		// Python's self.globals dict stores "sys.*" and "env.*" under
@@ -877,6 +941,7 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 				}
 			}
 		}
+		state.SetVarTypes(canvas.DeclaredVarTypes(c))
 		state.Sys["query"] = userInput
 		if uid, ok := root["user_id"].(string); ok && uid != "" {
 			state.Sys["user_id"] = uid
@@ -884,7 +949,18 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 		if tid, ok := root["tenant_id"].(string); ok && tid != "" {
 			state.Sys["tenant_id"] = tid
 		}
+		// Fork path: restore the namespaces and finished-node outputs
+		// captured at a node checkpoint; the finished nodes replay
+		// those outputs instead of running again.
+		if fork, ok := root["__fork__"].(*agentRunFork); ok && fork != nil {
+			delete(root, "__fork__")
+			fork.seed(state)
+			ctx2 = canvas.WithReplayOutputs(ctx2, fork.outputs)
+		}
 		ctx2 = runtime.WithState(ctx2, state)
+		// Record this canvas on the call path so a SubAgent node can
+		// refuse to re-enter it (see component/sub_agent.go).
+		ctx2 = runtime.WithCanvasCall(ctx2, canvasID)
 
 		// Resume path. The user input is the resume payload for the
 		// previously-paused UserFillUp node — it should NOT also be
@@ -937,6 +1013,7 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 				zap.String("type", fmt.Sprintf("%T", err)),
 				zap.Error(err))
 			s.markRunFailed(ctx2, runID, "compile: "+err.Error())
+			s.saveRunReport(canvasID, versionRow, root, runMeta, canvas.RunReportFailed)
 			return nil, fmt.Errorf("canvas compile: %w: %w", ErrAgentStorageError, err)
 		}
 
@@ -966,7 +1043,7 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 		if isResume && resumeID != "" {
 			wfInput = ""
 		}
-		_, err = cc.Workflow.Invoke(ctx2, map[string]any{"query": wfInput}, invokeOpts...)
+		_, err = cc.Workflow.Invoke(ctx2, workflowInput(wfInput, root), invokeOpts...)
 
 		if cpID != "" && s.runTracker != nil {
 			_ = s.runTracker.AttachCheckpoint(ctx2, runID, cpID)
@@ -975,25 +1052,8 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 		// Collect answer and references from the state snapshot.
 		// node_finished events are already emitted per-node by the
 		// statePost wrappers in scheduler.go.
-		var answer string
-		var reference []interface{}
+		answer, reference := collectRunAnswer(state)
 		now := float64(time.Now().UnixNano()) / 1e9
-		for _, bucket := range state.Snapshot() {
-			if v, ok := bucket["answer"].(string); ok && v != "" {
-				if answer == "" {
-					answer = v
-				}
-			}
-			if v, ok := bucket["content"].(string); ok && v != "" && answer == "" {
-				answer = v
-			}
-			if v, ok := bucket["result"].(string); ok && v != "" && answer == "" {
-				answer = v
-			}
-			if v, ok := bucket["reference"].([]interface{}); ok {
-				reference = append(reference, v...)
-			}
-		}
 
 		if err != nil {
 			common.Debug("RunAgent invoke err",
@@ -1005,6 +1065,15 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 				zap.Error(err))
 			if canvas.IsInterruptError(err) {
 				s.markRunFailed(ctx2, runID, "interrupt: "+err.Error())
+				s.saveRunReport(canvasID, versionRow, root, runMeta, canvas.RunReportPaused)
+				versionID := ""
+				if versionRow != nil {
+					versionID = versionRow.ID
+				}
+				if approval := s.recordApprovalPause(ctx2, root, versionID, err); approval != nil {
+					apData, _ := json.Marshal(approval)
+					emit("approval_pending", string(apData))
+				}
 				if answer != "" {
 					msgData, _ := json.Marshal(canvas.MessageEvent{
 						Content:   answer,
@@ -1040,9 +1109,11 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 				emit("workflow_finished", string(wfData))
 
 				s.markRunSucceeded(ctx2, runID)
+				s.saveRunReport(canvasID, versionRow, root, runMeta, canvas.RunReportSucceeded)
 				return state, nil
 			}
 			s.markRunFailed(ctx2, runID, "invoke: "+err.Error())
+			s.saveRunReport(canvasID, versionRow, root, runMeta, canvas.RunReportFailed)
 			return nil, fmt.Errorf("canvas invoke: %w: %w", ErrAgentStorageError, err)
 		}
 
@@ -1068,10 +1139,57 @@ func (s *AgentService) buildRunFunc(canvasID string, versionRow *entity.UserCanv
 		emit("workflow_finished", string(wfData))
 
 		s.markRunSucceeded(ctx2, runID)
+		s.saveRunReport(canvasID, versionRow, root, runMeta, canvas.RunReportSucceeded)
 		return state, nil
 	}
 }
 
+// workflowInput builds the map handed to the Begin node: the query plus
+// the optional webhook / trigger payloads and, for SubAgent child runs,
+// the mapped inputs (surfaced as Begin outputs). Mapped inputs never
+// shadow the reserved keys.
+func workflowInput(query any, root map[string]any) map[string]any {
+	in := map[string]any{"query": query}
+	for _, key := range []string{"webhook_payload", "trigger_payload"} {
+		if v, ok := root[key]; ok {
+			in[key] = v
+		}
+	}
+	if inputs, ok := root["inputs"].(map[string]any); ok {
+		for k, v := range inputs {
+			if _, reserved := in[k]; !reserved && k != "user_id" {
+				in[k] = v
+			}
+		}
+	}
+	return in
+}
+
+// collectRunAnswer extracts the run's answer (the first non-empty
+// answer / content / result output) and every reference list from the
+// state snapshot.
+func collectRunAnswer(state *canvas.CanvasState) (string, []interface{}) {
+	var answer string
+	var reference []interface{}
+	for _, bucket := range state.Snapshot() {
+		if v, ok := bucket["answer"].(string); ok && v != "" {
+			if answer == "" {
+				answer = v
+			}
+		}
+		if v, ok := bucket["content"].(string); ok && v != "" && answer == "" {
+			answer = v
+		}
+		if v, ok := bucket["result"].(string); ok && v != "" && answer == "" {
+			answer = v
+		}
+		if v, ok := bucket["reference"].([]interface{}); ok {
+			reference = append(reference, v...)
+		}
+	}
+	return answer, reference
+}
+
 // runIDFor builds the per-run CanvasState identifier: canvasID
 // alone for first-touch runs, canvasID + sessionID for resumed runs
 // (so two concurrent sessions on the same canvas don't collide in
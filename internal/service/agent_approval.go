@@ -0,0 +1,449 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/common"
+	"ragflow/internal/server"
+	"ragflow/internal/utility"
+)
+
+var (
+	// ErrAgentApprovalsDisabled is returned when no approval store is
+	// installed (the server runs without Redis).
+	ErrAgentApprovalsDisabled = errors.New("approvals are not enabled on this server")
+	// ErrAgentApprovalNotFound is returned for unknown approvals and for
+	// approvals of a tenant the caller does not belong to.
+	ErrAgentApprovalNotFound = errors.New("approval not found")
+	// ErrAgentApprovalNotPending is returned when deciding an approval
+	// that was already decided or timed out.
+	ErrAgentApprovalNotPending = errors.New("approval is no longer pending")
+	// ErrAgentApprovalForbidden is returned when the caller is not on
+	// the approval's current approver list.
+	ErrAgentApprovalForbidden = errors.New("you are not an approver of this approval")
+	// ErrAgentApprovalInvalid wraps a malformed decision.
+	ErrAgentApprovalInvalid = errors.New("invalid approval decision")
+)
+
+// Approval notification events, passed to ApprovalNotifier and sent as
+// the "event" field of webhook callbacks.
+const (
+	ApprovalEventPending   = "approval.pending"
+	ApprovalEventEscalated = "approval.escalated"
+	ApprovalEventResolved  = "approval.resolved"
+)
+
+// approvalSystemApprover is the approver recorded on timeout decisions.
+const approvalSystemApprover = "system"
+
+// ApprovalNotifier tells the outside world about approval events.
+// Notification is best-effort: failures are logged, never returned.
+type ApprovalNotifier interface {
+	NotifyApproval(ctx context.Context, event string, a *canvas.Approval)
+}
+
+// ApprovalDecisionRequest is the body of an approval decision.
+type ApprovalDecisionRequest struct {
+	// Action is "approve", "reject" or "edit".
+	Action  string `json:"action"`
+	Comment string `json:"comment"`
+	// Content replaces the content under review; required for "edit".
+	Content *string `json:"content"`
+}
+
+// SetApprovalStore installs the store that backs the Approval node.
+// Passing nil disables approvals: runs still pause on an Approval node
+// but nobody can decide them.
+func (s *AgentService) SetApprovalStore(store canvas.ApprovalStore) {
+	s.approvals = store
+}
+
+// SetApprovalNotifier replaces the email + webhook notifier.
+func (s *AgentService) SetApprovalNotifier(n ApprovalNotifier) {
+	s.approvalNotifier = n
+}
+
+// recordApprovalPause turns an Approval interrupt into a pending
+// approval and notifies its approvers. A run that pauses again on an
+// approval that is still pending (chat input reached the gate) only
+// refreshes the interrupt id. Returns nil when runErr is not an
+// Approval interrupt or approvals are disabled.
+func (s *AgentService) recordApprovalPause(ctx context.Context, root map[string]any, versionID string, runErr error) *canvas.Approval {
+	if s.approvals == nil {
+		return nil
+	}
+	ctxs := canvas.ExtractInterruptContexts(runErr)
+	ictx := canvas.FirstApprovalInterrupt(ctxs)
+	if ictx == nil {
+		return nil
+	}
+	req, _ := canvas.ApprovalRequestFromInfo(ictx.Info)
+	canvasID, _ := root["canvas_id"].(string)
+	sessionID, _ := root["session_id"].(string)
+	userID, _ := root["user_id"].(string)
+	tenantID := tenantIDFromRoot(root)
+	resumeID := canvas.RootInterruptID(ctxs)
+
+	pending, err := s.approvals.ListPending(ctx, tenantID)
+	if err != nil {
+		common.Warn("service: list pending approvals failed", zap.String("canvas", canvasID), zap.Error(err))
+	}
+	for _, a := range pending {
+		if a.CanvasID == canvasID && a.SessionID == sessionID && a.ComponentID == req.ComponentID {
+			a.InterruptID = resumeID
+			if err := s.approvals.Save(ctx, a); err != nil {
+				common.Warn("service: refresh approval failed", zap.String("approval", a.ID), zap.Error(err))
+			}
+			return a
+		}
+	}
+
+	now := time.Now()
+	a := &canvas.Approval{
+		ID:                  strings.ReplaceAll(uuid.New().String(), "-", ""),
+		TenantID:            tenantID,
+		UserID:              userID,
+		CanvasID:            canvasID,
+		SessionID:           sessionID,
+		VersionID:           versionID,
+		ComponentID:         req.ComponentID,
+		InterruptID:         resumeID,
+		Title:               req.Title,
+		Message:             req.Message,
+		Content:             req.Content,
+		Approvers:           req.Approvers,
+		EscalationApprovers: req.EscalationApprovers,
+		WebhookURL:          req.WebhookURL,
+		TimeoutAction:       req.TimeoutAction,
+		EscalationTimeoutMs: req.EscalationTimeout.Milliseconds(),
+		Status:              canvas.ApprovalStatusPending,
+		CreatedAt:           now.UnixMilli(),
+		Deadline:            now.Add(req.Timeout).UnixMilli(),
+	}
+	if err := s.approvals.Save(ctx, a); err != nil {
+		common.Warn("service: save approval failed", zap.String("canvas", canvasID), zap.Error(err))
+		return nil
+	}
+	s.notifyApproval(ctx, ApprovalEventPending, a)
+	return a
+}
+
+// notifyApproval runs the notifier on a snapshot of a, off the run
+// goroutine so a slow SMTP server never delays the run.
+func (s *AgentService) notifyApproval(ctx context.Context, event string, a *canvas.Approval) {
+	if s.approvalNotifier == nil {
+		return
+	}
+	snapshot := *a
+	go s.approvalNotifier.NotifyApproval(context.WithoutCancel(ctx), event, &snapshot)
+}
+
+// ListApprovals returns the pending approvals of every tenant userID
+// belongs to, newest first.
+func (s *AgentService) ListApprovals(ctx context.Context, userID string) ([]*canvas.Approval, error) {
+	if s.approvals == nil {
+		return nil, ErrAgentApprovalsDisabled
+	}
+	tenantIDs, err := s.userTenantDAO.GetTenantIDsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("ListApprovals: %w: %w", err, ErrAgentStorageError)
+	}
+	out := []*canvas.Approval{}
+	for _, tenantID := range tenantIDs {
+		pending, err := s.approvals.ListPending(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("ListApprovals: %w: %w", err, ErrAgentStorageError)
+		}
+		out = append(out, pending...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
+	return out, nil
+}
+
+// GetApproval returns one approval of a tenant userID belongs to.
+func (s *AgentService) GetApproval(ctx context.Context, userID, approvalID string) (*canvas.Approval, error) {
+	if s.approvals == nil {
+		return nil, ErrAgentApprovalsDisabled
+	}
+	a, ok, err := s.approvals.Get(ctx, approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("GetApproval: %w: %w", err, ErrAgentStorageError)
+	}
+	if !ok {
+		return nil, ErrAgentApprovalNotFound
+	}
+	tenantIDs, err := s.userTenantDAO.GetTenantIDsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetApproval: %w: %w", err, ErrAgentStorageError)
+	}
+	if !slices.Contains(tenantIDs, a.TenantID) {
+		return nil, ErrAgentApprovalNotFound
+	}
+	return a, nil
+}
+
+// DecideApproval records the caller's decision and resumes the paused
+// run, returning its event stream. When the approval names approvers,
+// userEmail must be on the current list.
+func (s *AgentService) DecideApproval(ctx context.Context, userID, userEmail, approvalID string, req *ApprovalDecisionRequest) (*canvas.Approval, <-chan canvas.RunEvent, error) {
+	if req == nil {
+		req = &ApprovalDecisionRequest{}
+	}
+	action := strings.ToLower(strings.TrimSpace(req.Action))
+	switch action {
+	case canvas.ApprovalActionApprove, canvas.ApprovalActionReject:
+	case canvas.ApprovalActionEdit:
+		if req.Content == nil {
+			return nil, nil, fmt.Errorf("%w: action %q needs content", ErrAgentApprovalInvalid, action)
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: action must be approve, reject or edit, got %q", ErrAgentApprovalInvalid, req.Action)
+	}
+
+	a, err := s.GetApproval(ctx, userID, approvalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a.Status != canvas.ApprovalStatusPending {
+		return nil, nil, ErrAgentApprovalNotPending
+	}
+	if len(a.Approvers) > 0 && !slices.ContainsFunc(a.Approvers, func(e string) bool { return strings.EqualFold(e, userEmail) }) {
+		return nil, nil, ErrAgentApprovalForbidden
+	}
+	claimed, err := s.approvals.Claim(ctx, a.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("DecideApproval: %w: %w", err, ErrAgentStorageError)
+	}
+	if !claimed {
+		return nil, nil, ErrAgentApprovalNotPending
+	}
+
+	decision := canvas.ApprovalDecision{
+		Action:   action,
+		Approver: userEmail,
+		Comment:  req.Comment,
+		Content:  a.Content,
+	}
+	if action == canvas.ApprovalActionEdit {
+		decision.Content = *req.Content
+	}
+	events, err := s.resolveApproval(ctx, a, decision)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, events, nil
+}
+
+// resolveApproval stores the decision on a claimed approval and resumes
+// the run through the normal RunAgent path with the decision as the
+// resume payload.
+func (s *AgentService) resolveApproval(ctx context.Context, a *canvas.Approval, d canvas.ApprovalDecision) (<-chan canvas.RunEvent, error) {
+	a.Decision = &d
+	a.DecidedAt = time.Now().UnixMilli()
+	a.Status = canvas.ApprovalStatusRejected
+	if d.Approved() {
+		a.Status = canvas.ApprovalStatusApproved
+	}
+	if err := s.approvals.Save(ctx, a); err != nil {
+		return nil, fmt.Errorf("resolve approval %q: %w: %w", a.ID, err, ErrAgentStorageError)
+	}
+	s.notifyApproval(ctx, ApprovalEventResolved, a)
+
+	s.runner.RestoreInterruptID(a.CanvasID, a.SessionID, a.InterruptID)
+	return s.RunAgent(ctx, a.UserID, a.CanvasID, a.SessionID, a.VersionID, d)
+}
+
+// AgentApprovalSweeper applies approval timeouts. Tick is run
+// periodically by a utility.ScheduledTask: a due approval either
+// escalates (timeout_action "escalate", first timeout) or is rejected
+// and its run resumed in the background.
+type AgentApprovalSweeper struct {
+	agents *AgentService
+	now    func() time.Time
+	wg     sync.WaitGroup
+}
+
+// NewAgentApprovalSweeper returns a sweeper for agents' approvals.
+func NewAgentApprovalSweeper(agents *AgentService) *AgentApprovalSweeper {
+	return &AgentApprovalSweeper{agents: agents, now: time.Now}
+}
+
+// Tick handles every approval whose deadline has passed.
+func (s *AgentApprovalSweeper) Tick() {
+	store := s.agents.approvals
+	if store == nil {
+		return
+	}
+	ctx := context.Background()
+	now := s.now()
+	ids, err := store.Due(ctx, now)
+	if err != nil {
+		common.Warn("agent approval: list due approvals failed", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		a, ok, err := store.Get(ctx, id)
+		if err != nil {
+			common.Warn("agent approval: load approval failed", zap.String("approval", id), zap.Error(err))
+			continue
+		}
+		// Claim even stale entries so they leave the deadline index.
+		claimed, err := store.Claim(ctx, id)
+		if err != nil || !claimed || !ok || a.Status != canvas.ApprovalStatusPending {
+			continue
+		}
+		if a.TimeoutAction == canvas.ApprovalTimeoutEscalate && !a.Escalated && len(a.EscalationApprovers) > 0 {
+			a.Escalated = true
+			a.Approvers = a.EscalationApprovers
+			a.Deadline = now.UnixMilli() + a.EscalationTimeoutMs
+			if err := store.Save(ctx, a); err != nil {
+				common.Warn("agent approval: escalate failed", zap.String("approval", id), zap.Error(err))
+				continue
+			}
+			s.agents.notifyApproval(ctx, ApprovalEventEscalated, a)
+			continue
+		}
+		events, err := s.agents.resolveApproval(ctx, a, canvas.ApprovalDecision{
+			Action:   canvas.ApprovalActionReject,
+			Approver: approvalSystemApprover,
+			Comment:  "approval timed out",
+			Content:  a.Content,
+			TimedOut: true,
+		})
+		if err != nil {
+			common.Warn("agent approval: resume after timeout failed", zap.String("approval", id), zap.Error(err))
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for ev := range events {
+				if ev.Type == "error" {
+					common.Warn("agent approval: resumed run failed", zap.String("approval", id), zap.String("error", ev.Data))
+				}
+			}
+		}()
+	}
+}
+
+// Wait blocks until every run resumed by Tick has finished. Used on
+// shutdown and by tests.
+func (s *AgentApprovalSweeper) Wait() {
+	s.wg.Wait()
+}
+
+// approvalMailer is the default ApprovalNotifier: it emails the
+// current approvers through the configured SMTP server and posts the
+// event to the approval's webhook_url.
+type approvalMailer struct{}
+
+// NotifyApproval implements ApprovalNotifier.
+func (approvalMailer) NotifyApproval(ctx context.Context, event string, a *canvas.Approval) {
+	if cfg := server.GetConfig(); cfg != nil && event != ApprovalEventResolved {
+		subject, body := approvalEmail(event, a)
+		for _, to := range a.Approvers {
+			if _, err := mail.ParseAddress(to); err != nil {
+				common.Warn("agent approval: skip invalid approver address", zap.String("approval", a.ID), zap.String("to", to))
+				continue
+			}
+			if err := utility.SendPlainEmail(cfg.SMTP, to, subject, body); err != nil {
+				common.Warn("agent approval: email failed", zap.String("approval", a.ID), zap.String("to", to), zap.Error(err))
+			}
+		}
+	}
+	if a.WebhookURL != "" {
+		if err := postApprovalWebhook(ctx, event, a); err != nil {
+			common.Warn("agent approval: webhook failed", zap.String("approval", a.ID), zap.Error(err))
+		}
+	}
+}
+
+// approvalEmail renders the notification sent to approvers.
+func approvalEmail(event string, a *canvas.Approval) (subject, body string) {
+	title := a.Title
+	if title == "" {
+		title = "Agent run " + a.CanvasID
+	}
+	subject = "Approval requested: " + title
+	if event == ApprovalEventEscalated {
+		subject = "Escalated approval: " + title
+	}
+	// The title comes from the DSL; keep it on the Subject line.
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+
+	var b strings.Builder
+	b.WriteString("Hello,\n\nAn agent run is waiting for your approval.\n\n")
+	if a.Message != "" {
+		b.WriteString(a.Message + "\n\n")
+	}
+	if a.Content != "" {
+		b.WriteString("Content under review:\n" + a.Content + "\n\n")
+	}
+	fmt.Fprintf(&b, "Approval id: %s\nDecide before: %s\n\n",
+		a.ID, time.UnixMilli(a.Deadline).UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Decide with POST /api/v1/agents/approvals/%s and a JSON body "+
+		`{"action": "approve" | "reject" | "edit", "comment": "...", "content": "..."}.`+"\n", a.ID)
+	return subject, b.String()
+}
+
+// postApprovalWebhook sends {"event", "approval"} to the approval's
+// webhook_url. The URL comes from the DSL, so it goes through the SSRF
+// guard and the request is pinned to the vetted address.
+func postApprovalWebhook(ctx context.Context, event string, a *canvas.Approval) error {
+	hostname, ip, err := assertURLSafe(a.WebhookURL)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]any{"event": event, "approval": a})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := pinnedHTTPClient(hostname, ip, 10*time.Second)
+	// A redirect would dial a host that skipped the check above.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
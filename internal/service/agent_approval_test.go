@@ -0,0 +1,292 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ragflow/internal/agent/canvas"
+)
+
+// recordingNotifier collects approval notifications.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []string
+	got    chan struct{}
+}
+
+func newRecordingNotifier() *recordingNotifier {
+	return &recordingNotifier{got: make(chan struct{}, 16)}
+}
+
+func (n *recordingNotifier) NotifyApproval(_ context.Context, event string, a *canvas.Approval) {
+	n.mu.Lock()
+	n.events = append(n.events, event+":"+strings.Join(a.Approvers, ","))
+	n.mu.Unlock()
+	n.got <- struct{}{}
+}
+
+// wait blocks until count notifications arrived and returns them.
+func (n *recordingNotifier) wait(t *testing.T, count int) []string {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		select {
+		case <-n.got:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d notifications arrived", i, count)
+		}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.events...)
+}
+
+// approvalTestEnv is the shared Redis behind the checkpoint, run and
+// approval stores, so a second AgentService plays a restarted server.
+type approvalTestEnv struct {
+	cp        canvas.CheckPointStore
+	tracker   *canvas.RunTracker
+	approvals *canvas.RedisApprovalStore
+}
+
+func (e *approvalTestEnv) newService(notifier ApprovalNotifier) *AgentService {
+	svc := NewAgentServiceWithOptions(e.cp, nil, e.tracker)
+	svc.SetApprovalStore(e.approvals)
+	svc.SetApprovalNotifier(notifier)
+	return svc
+}
+
+func setupApprovalTest(t *testing.T, params map[string]any) *approvalTestEnv {
+	t.Helper()
+	setupCanvasServiceDB(t)
+
+	dsl := map[string]any{
+		"components": map[string]any{
+			"begin_0": map[string]any{
+				"obj":        map[string]any{"component_name": "Begin", "params": map[string]any{}},
+				"downstream": []any{"approval_0"},
+			},
+			"approval_0": map[string]any{
+				"obj":        map[string]any{"component_name": "Approval", "params": params},
+				"upstream":   []any{"begin_0"},
+				"downstream": []any{"message_0"},
+			},
+			"message_0": map[string]any{
+				"obj": map[string]any{
+					"component_name": "Message",
+					"params":         map[string]any{"text": "{{approval_0@decision}}: {{approval_0@final_content}}"},
+				},
+				"upstream": []any{"approval_0"},
+			},
+		},
+		"path": []any{"begin_0", "approval_0", "message_0"},
+	}
+	makeCanvasWithDSL(t, "canvas-approval", "user-1", "tenant-1", "v-approval", dsl)
+
+	tracker, mr := newRunTrackerForTest(t, time.Hour)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return &approvalTestEnv{
+		cp:        canvas.NewRedisCheckPointStoreWithClient(client, time.Hour),
+		tracker:   tracker,
+		approvals: canvas.NewRedisApprovalStoreWithClient(client, time.Hour),
+	}
+}
+
+// startApprovalRun runs the canvas up to the Approval node and returns
+// the pending approval announced on the stream.
+func startApprovalRun(t *testing.T, svc *AgentService) *canvas.Approval {
+	t.Helper()
+	events, err := svc.RunAgent(context.Background(), "user-1", "canvas-approval", "session-approval", "", "acme")
+	if err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	var approval *canvas.Approval
+	waiting := false
+	for ev := range events {
+		switch ev.Type {
+		case "approval_pending":
+			approval = &canvas.Approval{}
+			if err := json.Unmarshal([]byte(ev.Data), approval); err != nil {
+				t.Fatalf("approval_pending payload: %v", err)
+			}
+		case "waiting_for_user":
+			waiting = true
+		case "error":
+			t.Fatalf("run failed: %s", ev.Data)
+		}
+	}
+	if approval == nil || !waiting {
+		t.Fatalf("run did not pause on the approval (approval=%v waiting=%v)", approval, waiting)
+	}
+	return approval
+}
+
+func TestApproval_DecideResumesRun(t *testing.T) {
+	env := setupApprovalTest(t, map[string]any{
+		"title":     "Publish for {{sys.query}}",
+		"content":   "draft for {{sys.query}}",
+		"approvers": []any{"user-1@test.com"},
+	})
+	notifier := newRecordingNotifier()
+	svc := env.newService(notifier)
+	ctx := context.Background()
+
+	approval := startApprovalRun(t, svc)
+	if approval.Title != "Publish for acme" || approval.Content != "draft for acme" || approval.TenantID != "tenant-1" {
+		t.Errorf("approval = %+v", approval)
+	}
+	if got := notifier.wait(t, 1); got[0] != ApprovalEventPending+":user-1@test.com" {
+		t.Errorf("notifications = %v", got)
+	}
+	pending, err := svc.ListApprovals(ctx, "user-1")
+	if err != nil || len(pending) != 1 || pending[0].ID != approval.ID {
+		t.Fatalf("ListApprovals = %v, %v", pending, err)
+	}
+
+	// Chat input on the paused session is not a decision: the gate
+	// pauses again on the same approval.
+	if again := startApprovalRun(t, svc); again.ID != approval.ID {
+		t.Errorf("chat input raised approval %q, want the pending %q", again.ID, approval.ID)
+	}
+
+	if _, _, err := svc.DecideApproval(ctx, "user-1", "someone@test.com", approval.ID, &ApprovalDecisionRequest{Action: "approve"}); !errors.Is(err, ErrAgentApprovalForbidden) {
+		t.Errorf("non-approver decision err = %v, want ErrAgentApprovalForbidden", err)
+	}
+	if _, _, err := svc.DecideApproval(ctx, "user-1", "user-1@test.com", approval.ID, &ApprovalDecisionRequest{Action: "edit"}); !errors.Is(err, ErrAgentApprovalInvalid) {
+		t.Errorf("edit without content err = %v, want ErrAgentApprovalInvalid", err)
+	}
+	if _, _, err := svc.DecideApproval(ctx, "user-2", "user-1@test.com", approval.ID, &ApprovalDecisionRequest{Action: "approve"}); !errors.Is(err, ErrAgentApprovalNotFound) {
+		t.Errorf("foreign tenant decision err = %v, want ErrAgentApprovalNotFound", err)
+	}
+
+	// A restarted server only has the stores: decide through a fresh
+	// service whose Runner never saw the interrupt.
+	restarted := env.newService(notifier)
+	edited := "final for acme"
+	decided, events, err := restarted.DecideApproval(ctx, "user-1", "USER-1@test.com", approval.ID, &ApprovalDecisionRequest{Action: "edit", Comment: "tightened", Content: &edited})
+	if err != nil {
+		t.Fatalf("DecideApproval: %v", err)
+	}
+	if decided.Status != canvas.ApprovalStatusApproved || decided.Decision.Approver != "USER-1@test.com" {
+		t.Errorf("decided = %+v", decided)
+	}
+	messages, waiting, errs, _ := drainAgentEvents(t, events)
+	if len(errs) > 0 || len(waiting) > 0 {
+		t.Fatalf("resumed run: errors %v, waiting %v", errs, waiting)
+	}
+	if len(messages) != 1 || messages[0].Content != "approved: final for acme" {
+		t.Fatalf("resumed run messages = %+v, want %q", messages, "approved: final for acme")
+	}
+
+	if pending, _ := restarted.ListApprovals(ctx, "user-1"); len(pending) != 0 {
+		t.Errorf("ListApprovals after decision = %v, want empty", pending)
+	}
+	if _, _, err := restarted.DecideApproval(ctx, "user-1", "user-1@test.com", approval.ID, &ApprovalDecisionRequest{Action: "reject"}); !errors.Is(err, ErrAgentApprovalNotPending) {
+		t.Errorf("second decision err = %v, want ErrAgentApprovalNotPending", err)
+	}
+}
+
+func TestApproval_TimeoutEscalatesThenRejects(t *testing.T) {
+	env := setupApprovalTest(t, map[string]any{
+		"content":                    "draft",
+		"approvers":                  []any{"user-1@test.com"},
+		"escalation_approvers":       []any{"boss@test.com"},
+		"timeout_seconds":            float64(60),
+		"timeout_action":             "escalate",
+		"escalation_timeout_seconds": float64(120),
+	})
+	notifier := newRecordingNotifier()
+	approval := startApprovalRun(t, env.newService(notifier))
+	notifier.wait(t, 1)
+
+	svc := env.newService(notifier)
+	sweeper := NewAgentApprovalSweeper(svc)
+	start := time.UnixMilli(approval.Deadline)
+
+	sweeper.now = func() time.Time { return start.Add(-time.Second) }
+	sweeper.Tick()
+	if a, _, _ := env.approvals.Get(context.Background(), approval.ID); a.Escalated {
+		t.Fatal("escalated before the deadline")
+	}
+
+	sweeper.now = func() time.Time { return start.Add(time.Second) }
+	sweeper.Tick()
+	a, _, _ := env.approvals.Get(context.Background(), approval.ID)
+	if !a.Escalated || a.Status != canvas.ApprovalStatusPending || len(a.Approvers) != 1 || a.Approvers[0] != "boss@test.com" {
+		t.Fatalf("after first timeout = %+v, want escalated to boss", a)
+	}
+	if got := notifier.wait(t, 1); got[1] != ApprovalEventEscalated+":boss@test.com" {
+		t.Errorf("notifications = %v", got)
+	}
+
+	sweeper.now = func() time.Time { return start.Add(3 * time.Minute) }
+	sweeper.Tick()
+	sweeper.Wait()
+	a, _, _ = env.approvals.Get(context.Background(), approval.ID)
+	if a.Status != canvas.ApprovalStatusRejected || a.Decision == nil || !a.Decision.TimedOut || a.Decision.Approver != approvalSystemApprover {
+		t.Fatalf("after escalation timeout = %+v, want rejected by timeout", a)
+	}
+	run, err := env.tracker.Get(context.Background(), "canvas-approval-session-approval")
+	if err != nil || run["status"] != "1" {
+		t.Errorf("resumed run status = %q (%v), want succeeded", run["status"], err)
+	}
+}
+
+func TestPostApprovalWebhook_DoesNotFollowRedirects(t *testing.T) {
+	var redirected int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&redirected, 1)
+	}))
+	defer target.Close()
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer hook.Close()
+
+	origAssert := assertURLSafe
+	origPinned := pinnedHTTPClient
+	assertURLSafe = func(rawURL string) (string, string, error) {
+		return "127.0.0.1", "127.0.0.1", nil
+	}
+	pinnedHTTPClient = func(hostname, resolvedIP string, timeout time.Duration) *http.Client {
+		return &http.Client{Timeout: timeout}
+	}
+	t.Cleanup(func() {
+		assertURLSafe = origAssert
+		pinnedHTTPClient = origPinned
+	})
+
+	err := postApprovalWebhook(context.Background(), "requested", &canvas.Approval{ID: "a1", WebhookURL: hook.URL})
+	if err == nil || !strings.Contains(err.Error(), "307") {
+		t.Fatalf("err = %v, want HTTP 307 error", err)
+	}
+	if n := atomic.LoadInt32(&redirected); n != 0 {
+		t.Fatalf("redirect target hit %d times, want 0", n)
+	}
+}
@@ -0,0 +1,209 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"ragflow/internal/agent/canvas"
+)
+
+var (
+	// ErrAgentCheckpointsDisabled is returned when no node checkpoint
+	// store is installed (Redis unavailable).
+	ErrAgentCheckpointsDisabled = errors.New("run checkpoints are not enabled on this server")
+	// ErrAgentCheckpointNotFound is returned for an unknown run or seq.
+	ErrAgentCheckpointNotFound = errors.New("run checkpoint not found")
+	// ErrAgentCheckpointInvalid wraps a rejected variable edit.
+	ErrAgentCheckpointInvalid = errors.New("invalid checkpoint variable")
+)
+
+// AgentRunCheckpoint is one node checkpoint of a run with the ids of
+// every node that had finished by then (the nodes a fork replays).
+type AgentRunCheckpoint struct {
+	canvas.NodeCheckpoint
+	Completed []string `json:"completed"`
+}
+
+// ForkAgentRunRequest is the body of a fork from a checkpoint.
+// Variables override state at the fork point, keyed like template
+// references: "cpn_id@param" (a finished node's output), "sys.x" or
+// "env.x".
+type ForkAgentRunRequest struct {
+	Variables map[string]any `json:"variables"`
+}
+
+// agentRunForkKey is the context key ForkRunFromCheckpoint uses to hand
+// the fork state to RunAgent.
+type agentRunForkKey struct{}
+
+// agentRunFork is the state a forked run starts from.
+type agentRunFork struct {
+	sys, env, globals map[string]any
+	outputs           map[string]map[string]any
+}
+
+// seed copies the fork namespaces and replayed outputs into state.
+func (f *agentRunFork) seed(state *canvas.CanvasState) {
+	maps.Copy(state.Sys, f.sys)
+	maps.Copy(state.Env, f.env)
+	maps.Copy(state.Globals, f.globals)
+	for cpnID, bucket := range f.outputs {
+		for k, v := range bucket {
+			state.SetVar(cpnID, k, v)
+		}
+	}
+}
+
+// ListRunCheckpoints returns the node checkpoints of the run behind
+// sessionID without their state payloads.
+func (s *AgentService) ListRunCheckpoints(ctx context.Context, userID, canvasID, sessionID string) ([]canvas.NodeCheckpoint, error) {
+	checkpoints, err := s.loadRunCheckpoints(ctx, userID, canvasID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range checkpoints {
+		checkpoints[i].State = nil
+	}
+	return checkpoints, nil
+}
+
+// GetRunCheckpoint returns one node checkpoint with its CanvasState.
+func (s *AgentService) GetRunCheckpoint(ctx context.Context, userID, canvasID, sessionID string, seq int) (*AgentRunCheckpoint, error) {
+	checkpoints, err := s.loadRunCheckpoints(ctx, userID, canvasID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if seq < 0 || seq >= len(checkpoints) {
+		return nil, ErrAgentCheckpointNotFound
+	}
+	return &AgentRunCheckpoint{
+		NodeCheckpoint: checkpoints[seq],
+		Completed:      completedAt(checkpoints, seq),
+	}, nil
+}
+
+// ForkRunFromCheckpoint starts a new run (under a new session id) from
+// checkpoint seq of the run behind sessionID. Nodes that had finished
+// successfully by then replay their recorded outputs — edited by
+// req.Variables — and the rest of the canvas runs live against the
+// version the original run used.
+func (s *AgentService) ForkRunFromCheckpoint(ctx context.Context, userID, canvasID, sessionID string, seq int, req *ForkAgentRunRequest) (<-chan canvas.RunEvent, string, error) {
+	cp, err := s.GetRunCheckpoint(ctx, userID, canvasID, sessionID, seq)
+	if err != nil {
+		return nil, "", err
+	}
+	state := &canvas.CanvasState{}
+	if err := json.Unmarshal(cp.State, state); err != nil {
+		return nil, "", fmt.Errorf("ForkRunFromCheckpoint: decode state: %w: %w", err, ErrAgentStorageError)
+	}
+	fork := &agentRunFork{
+		sys:     orEmpty(state.Sys),
+		env:     orEmpty(state.Env),
+		globals: orEmpty(state.Globals),
+		outputs: make(map[string]map[string]any, len(cp.Completed)),
+	}
+	for _, cpnID := range cp.Completed {
+		bucket := make(map[string]any, len(state.Outputs[cpnID]))
+		maps.Copy(bucket, state.Outputs[cpnID])
+		fork.outputs[cpnID] = bucket
+	}
+	if req != nil {
+		for ref, v := range req.Variables {
+			if err := fork.set(ref, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	newSessionID := strings.ReplaceAll(uuid.New().String(), "-", "")
+	query, _ := fork.sys["query"].(string)
+	events, err := s.RunAgent(context.WithValue(ctx, agentRunForkKey{}, fork), userID, canvasID, newSessionID, cp.VersionID, query)
+	if err != nil {
+		return nil, "", err
+	}
+	return events, newSessionID, nil
+}
+
+// set applies one variable edit to the fork state.
+func (f *agentRunFork) set(ref string, v any) error {
+	switch {
+	case strings.HasPrefix(ref, "sys."):
+		f.sys[strings.TrimPrefix(ref, "sys.")] = v
+	case strings.HasPrefix(ref, "env."):
+		f.env[strings.TrimPrefix(ref, "env.")] = v
+	case strings.Contains(ref, "@"):
+		cpnID, param, _ := strings.Cut(ref, "@")
+		bucket, ok := f.outputs[cpnID]
+		if !ok || param == "" {
+			return fmt.Errorf("%w: %q is not an output of a node finished at this checkpoint", ErrAgentCheckpointInvalid, ref)
+		}
+		bucket[param] = v
+	default:
+		return fmt.Errorf("%w: %q must be cpn_id@param, sys.x or env.x", ErrAgentCheckpointInvalid, ref)
+	}
+	return nil
+}
+
+// loadRunCheckpoints checks access and reads the checkpoint list of the
+// run behind sessionID.
+func (s *AgentService) loadRunCheckpoints(ctx context.Context, userID, canvasID, sessionID string) ([]canvas.NodeCheckpoint, error) {
+	if _, err := s.loadCanvasForUser(ctx, userID, canvasID); err != nil {
+		return nil, err
+	}
+	if s.nodeCheckpoints == nil {
+		return nil, ErrAgentCheckpointsDisabled
+	}
+	checkpoints, err := s.nodeCheckpoints.List(ctx, runIDFor(canvasID, map[string]any{"session_id": sessionID}))
+	if err != nil {
+		return nil, fmt.Errorf("load run checkpoints: %w: %w", err, ErrAgentStorageError)
+	}
+	if len(checkpoints) == 0 {
+		return nil, ErrAgentCheckpointNotFound
+	}
+	return checkpoints, nil
+}
+
+// completedAt lists the nodes that finished without error up to and
+// including checkpoint seq, in completion order. A node that ran more
+// than once (loops) is listed once.
+func completedAt(checkpoints []canvas.NodeCheckpoint, seq int) []string {
+	seen := make(map[string]bool, seq+1)
+	out := make([]string, 0, seq+1)
+	for _, cp := range checkpoints[:seq+1] {
+		if cp.Error != "" || seen[cp.ComponentID] {
+			continue
+		}
+		seen[cp.ComponentID] = true
+		out = append(out, cp.ComponentID)
+	}
+	return out
+}
+
+func orEmpty(m map[string]any) map[string]any {
+	if m == nil {
+		return map[string]any{}
+	}
+	return m
+}
@@ -0,0 +1,178 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"ragflow/internal/agent/canvas"
+)
+
+// setupCheckpointTest wires a miniredis-backed node checkpoint store and
+// publishes Begin → message_0("A {{begin_0@query}} {{sys.query}}!").
+func setupCheckpointTest(t *testing.T) *AgentService {
+	t.Helper()
+	setupCanvasServiceDB(t)
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	svc := NewAgentService()
+	svc.SetNodeCheckpointStore(canvas.NewRedisNodeCheckpointStoreWithClient(client, time.Hour))
+
+	makeCanvasWithDSL(t, "canvas-1", "user-1", "tenant-1", "v1", map[string]any{
+		"components": map[string]any{
+			"begin_0": map[string]any{
+				"obj":        map[string]any{"component_name": "Begin", "params": map[string]any{}},
+				"downstream": []any{"message_0"},
+			},
+			"message_0": map[string]any{
+				"obj":      map[string]any{"component_name": "Message", "params": map[string]any{"text": "A {{begin_0@query}} {{sys.query}}!"}},
+				"upstream": []any{"begin_0"},
+			},
+		},
+		"path": []any{"begin_0", "message_0"},
+	})
+	return svc
+}
+
+func runAnswer(t *testing.T, events <-chan canvas.RunEvent) string {
+	t.Helper()
+	var answer string
+	for _, ev := range collectRunEvents(t, events) {
+		switch ev.Type {
+		case "error":
+			t.Fatalf("unexpected error event: %s", ev.Data)
+		case "message":
+			var m canvas.MessageEvent
+			_ = json.Unmarshal([]byte(ev.Data), &m)
+			answer = m.Content
+		}
+	}
+	return answer
+}
+
+func TestRunCheckpoints_ListInspectAndFork(t *testing.T) {
+	svc := setupCheckpointTest(t)
+	ctx := context.Background()
+
+	events, err := svc.RunAgent(ctx, "user-1", "canvas-1", "session-1", "", "world")
+	if err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	if got := runAnswer(t, events); got != "A world world!" {
+		t.Fatalf("original answer = %q, want %q", got, "A world world!")
+	}
+
+	list, err := svc.ListRunCheckpoints(ctx, "user-1", "canvas-1", "session-1")
+	if err != nil {
+		t.Fatalf("ListRunCheckpoints: %v", err)
+	}
+	if len(list) != 2 || list[0].ComponentID != "begin_0" || list[1].ComponentID != "message_0" || list[1].Seq != 1 {
+		t.Fatalf("checkpoints = %+v, want begin_0 then message_0", list)
+	}
+	if list[0].State != nil || list[0].VersionID != "v1" {
+		t.Fatalf("list entry = %+v, want version and no state payload", list[0])
+	}
+
+	cp, err := svc.GetRunCheckpoint(ctx, "user-1", "canvas-1", "session-1", 0)
+	if err != nil {
+		t.Fatalf("GetRunCheckpoint: %v", err)
+	}
+	state := &canvas.CanvasState{}
+	if err := json.Unmarshal(cp.State, state); err != nil {
+		t.Fatalf("decode state: %v", err)
+	}
+	if state.Outputs["begin_0"]["query"] != "world" || state.Sys["query"] != "world" || state.Outputs["message_0"] != nil {
+		t.Fatalf("state at seq 0: outputs=%v sys.query=%v", state.Outputs, state.Sys["query"])
+	}
+	if len(cp.Completed) != 1 || cp.Completed[0] != "begin_0" {
+		t.Fatalf("completed = %v, want [begin_0]", cp.Completed)
+	}
+
+	// Fork after Begin with its output edited: Begin replays the edit
+	// instead of re-reading the query, message_0 runs live.
+	events, forkSession, err := svc.ForkRunFromCheckpoint(ctx, "user-1", "canvas-1", "session-1", 0, &ForkAgentRunRequest{
+		Variables: map[string]any{"begin_0@query": "edited"},
+	})
+	if err != nil {
+		t.Fatalf("ForkRunFromCheckpoint: %v", err)
+	}
+	if got := runAnswer(t, events); got != "A edited world!" {
+		t.Fatalf("fork answer = %q, want %q", got, "A edited world!")
+	}
+	if forkSession == "" || forkSession == "session-1" {
+		t.Fatalf("fork session = %q, want a new session id", forkSession)
+	}
+	if forked, err := svc.ListRunCheckpoints(ctx, "user-1", "canvas-1", forkSession); err != nil || len(forked) != 2 {
+		t.Fatalf("fork checkpoints = %v, %v; want a full list of its own", forked, err)
+	}
+
+	// Editing a sys variable reaches the live nodes.
+	events, _, err = svc.ForkRunFromCheckpoint(ctx, "user-1", "canvas-1", "session-1", 0, &ForkAgentRunRequest{
+		Variables: map[string]any{"sys.query": "there"},
+	})
+	if err != nil {
+		t.Fatalf("ForkRunFromCheckpoint(sys.query): %v", err)
+	}
+	if got := runAnswer(t, events); got != "A world there!" {
+		t.Fatalf("fork answer = %q, want %q", got, "A world there!")
+	}
+}
+
+func TestRunCheckpoints_Errors(t *testing.T) {
+	svc := setupCheckpointTest(t)
+	ctx := context.Background()
+
+	if _, err := svc.ListRunCheckpoints(ctx, "user-1", "canvas-1", "never-ran"); !errors.Is(err, ErrAgentCheckpointNotFound) {
+		t.Fatalf("unknown session: error = %v, want ErrAgentCheckpointNotFound", err)
+	}
+
+	events, err := svc.RunAgent(ctx, "user-1", "canvas-1", "session-1", "", "world")
+	if err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	runAnswer(t, events)
+
+	if _, err := svc.GetRunCheckpoint(ctx, "user-1", "canvas-1", "session-1", 9); !errors.Is(err, ErrAgentCheckpointNotFound) {
+		t.Fatalf("seq out of range: error = %v, want ErrAgentCheckpointNotFound", err)
+	}
+	// message_0 has not finished at seq 0, so its output cannot be edited.
+	_, _, err = svc.ForkRunFromCheckpoint(ctx, "user-1", "canvas-1", "session-1", 0, &ForkAgentRunRequest{
+		Variables: map[string]any{"message_0@content": "x"},
+	})
+	if !errors.Is(err, ErrAgentCheckpointInvalid) {
+		t.Fatalf("edit unfinished node: error = %v, want ErrAgentCheckpointInvalid", err)
+	}
+
+	svc.SetNodeCheckpointStore(nil)
+	if _, err := svc.ListRunCheckpoints(ctx, "user-1", "canvas-1", "session-1"); !errors.Is(err, ErrAgentCheckpointsDisabled) {
+		t.Fatalf("disabled: error = %v, want ErrAgentCheckpointsDisabled", err)
+	}
+}
@@ -0,0 +1,167 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ragflow/internal/agent/component"
+	"ragflow/internal/common"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+var (
+	// ErrAgentDatasetForbidden is returned when the canvas owner cannot
+	// access the target dataset (or it does not exist; the two are not
+	// distinguished so a canvas cannot probe dataset ids).
+	ErrAgentDatasetForbidden = errors.New("canvas owner has no access to the dataset")
+	// ErrAgentDatasetParseFailed is returned when wait_for_parse sees the
+	// document's parse fail or get cancelled.
+	ErrAgentDatasetParseFailed = errors.New("document parsing failed")
+	// ErrAgentDatasetParseTimeout is returned when wait_for_parse runs
+	// out of time; the document stays in the dataset and keeps parsing.
+	ErrAgentDatasetParseTimeout = errors.New("timed out waiting for document parsing")
+)
+
+// agentDatasetSourceType is the document source_type of write-back
+// documents, so the dataset UI can tell them from user uploads.
+const agentDatasetSourceType = "agent"
+
+// defaultAgentDatasetPollInterval is how often wait_for_parse re-reads
+// the document's run status.
+const defaultAgentDatasetPollInterval = 2 * time.Second
+
+// AgentDatasetWriter stores DatasetWriter node output as dataset
+// documents. It implements component.DatasetWriter.
+type AgentDatasetWriter struct {
+	canvasDAO   *dao.UserCanvasDAO
+	documentDAO *dao.DocumentDAO
+	datasets    *DatasetService
+	documents   *DocumentService
+	// parse queues a stored document for parsing as ownerID. Tests
+	// replace it because the production path needs the task queue.
+	parse        func(ownerID, docID string) error
+	pollInterval time.Duration
+}
+
+// NewAgentDatasetWriter builds the writer over the dataset and document
+// services.
+func NewAgentDatasetWriter(datasets *DatasetService, documents *DocumentService) *AgentDatasetWriter {
+	w := &AgentDatasetWriter{
+		canvasDAO:    dao.NewUserCanvasDAO(),
+		documentDAO:  dao.NewDocumentDAO(),
+		datasets:     datasets,
+		documents:    documents,
+		pollInterval: defaultAgentDatasetPollInterval,
+	}
+	w.parse = func(ownerID, docID string) error {
+		_, err := documents.Ingest(ownerID, &IngestDocumentRequest{
+			DocIDs: []string{docID},
+			Run:    string(entity.TaskStatusRunning),
+		})
+		return err
+	}
+	return w
+}
+
+// WriteDocument stores req.Content in req.DatasetID as the owner of
+// req.CanvasID, then optionally parses it and waits for the parse.
+func (w *AgentDatasetWriter) WriteDocument(ctx context.Context, req component.DatasetWriteRequest) (*component.DatasetWriteResult, error) {
+	canvasRow, err := w.canvasDAO.GetByID(req.CanvasID)
+	if err != nil {
+		return nil, fmt.Errorf("load canvas %q: %w", req.CanvasID, err)
+	}
+	owner := canvasRow.UserID
+
+	kb, err := w.datasets.GetKnowledgebaseByID(req.DatasetID)
+	if err != nil || kb == nil || !w.datasets.Accessible(kb.ID, owner) {
+		return nil, fmt.Errorf("dataset %q: %w", req.DatasetID, ErrAgentDatasetForbidden)
+	}
+
+	doc, _, err := w.documents.UploadBlobDocument(kb, owner, req.Filename, req.Content, agentDatasetSourceType)
+	if err != nil {
+		return nil, fmt.Errorf("upload %q: %w", req.Filename, err)
+	}
+	res := &component.DatasetWriteResult{
+		DatasetID: kb.ID,
+		Status:    "uploaded",
+	}
+	res.DocumentID, _ = doc["id"].(string)
+	res.Filename, _ = doc["name"].(string)
+	common.Info(fmt.Sprintf("agent: canvas %s wrote document %s to dataset %s", req.CanvasID, res.DocumentID, kb.ID))
+
+	if !req.Parse {
+		return res, nil
+	}
+	if err := w.parse(owner, res.DocumentID); err != nil {
+		return nil, fmt.Errorf("parse document %q: %w", res.DocumentID, err)
+	}
+	res.Status = "parsing"
+	if !req.WaitForParse {
+		return res, nil
+	}
+	if err := w.waitForParse(ctx, res, req.ParseTimeout); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// waitForParse polls the document until its run status is terminal.
+func (w *AgentDatasetWriter) waitForParse(ctx context.Context, res *component.DatasetWriteResult, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		doc, err := w.documentDAO.GetByID(res.DocumentID)
+		if err != nil {
+			return fmt.Errorf("load document %q: %w", res.DocumentID, err)
+		}
+		run := ""
+		if doc.Run != nil {
+			run = *doc.Run
+		}
+		switch entity.TaskStatus(run) {
+		case entity.TaskStatusDone:
+			res.Status = "parsed"
+			res.ChunkCount = doc.ChunkNum
+			res.TokenCount = doc.TokenNum
+			return nil
+		case entity.TaskStatusFail, entity.TaskStatusCancel:
+			msg := ""
+			if doc.ProgressMsg != nil {
+				msg = *doc.ProgressMsg
+			}
+			return fmt.Errorf("document %q: %w: %s", res.DocumentID, ErrAgentDatasetParseFailed, msg)
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("document %q: %w", res.DocumentID, ErrAgentDatasetParseTimeout)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
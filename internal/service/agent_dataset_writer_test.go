@@ -0,0 +1,143 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ragflow/internal/agent/component"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+	"ragflow/internal/storage"
+)
+
+const (
+	writerOwnKB     = "0a1b2c3d4e5f60718293a4b5c6d7e8f9"
+	writerForeignKB = "1a1b2c3d4e5f60718293a4b5c6d7e8f9"
+)
+
+// setupDatasetWriterTest creates a canvas owned by owner-1, a dataset
+// of owner-1 and a private dataset of someone else.
+func setupDatasetWriterTest(t *testing.T) (*AgentDatasetWriter, *fakeUploadStorage) {
+	t.Helper()
+	db := setupServiceTestDB(t)
+	if err := db.AutoMigrate(&entity.UserCanvas{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	pushServiceDB(t, db)
+
+	mockStorage := newFakeUploadStorage()
+	factory := storage.GetStorageFactory()
+	origStorage := factory.GetStorage()
+	factory.SetStorage(mockStorage)
+	t.Cleanup(func() { factory.SetStorage(origStorage) })
+
+	dao.DB.Create(&entity.UserCanvas{ID: "canvas-w", UserID: "owner-1", Permission: "team", CanvasCategory: "agent_canvas"})
+	for id, tenant := range map[string]string{writerOwnKB: "owner-1", writerForeignKB: "other-1"} {
+		if err := dao.DB.Create(&entity.Knowledgebase{
+			ID:           id,
+			TenantID:     tenant,
+			Name:         "kb-" + tenant,
+			ParserID:     "naive",
+			Permission:   string(entity.TenantPermissionMe),
+			Status:       sptr(string(entity.StatusValid)),
+			ParserConfig: entity.JSONMap{},
+		}).Error; err != nil {
+			t.Fatalf("insert kb: %v", err)
+		}
+	}
+
+	w := NewAgentDatasetWriter(&DatasetService{kbDAO: dao.NewKnowledgebaseDAO()}, testDocumentService(t))
+	w.pollInterval = 5 * time.Millisecond
+	return w, mockStorage
+}
+
+func TestAgentDatasetWriter_WritesAndWaitsForParse(t *testing.T) {
+	w, mockStorage := setupDatasetWriterTest(t)
+	var parsedBy string
+	w.parse = func(ownerID, docID string) error {
+		parsedBy = ownerID
+		return dao.NewDocumentDAO().UpdateByID(docID, map[string]interface{}{
+			"run":       string(entity.TaskStatusDone),
+			"chunk_num": 4,
+			"token_num": 120,
+		})
+	}
+
+	res, err := w.WriteDocument(context.Background(), component.DatasetWriteRequest{
+		CanvasID:     "canvas-w",
+		DatasetID:    writerOwnKB,
+		Filename:     "summary.md",
+		Content:      []byte("# Summary"),
+		Parse:        true,
+		WaitForParse: true,
+		ParseTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("WriteDocument: %v", err)
+	}
+	if res.Status != "parsed" || res.ChunkCount != 4 || res.TokenCount != 120 || res.Filename != "summary.md" {
+		t.Errorf("result = %+v", res)
+	}
+	if parsedBy != "owner-1" {
+		t.Errorf("parsed as %q, want the canvas owner", parsedBy)
+	}
+	doc, err := dao.NewDocumentDAO().GetByID(res.DocumentID)
+	if err != nil || doc.SourceType != agentDatasetSourceType || doc.KbID != writerOwnKB {
+		t.Fatalf("stored document = %+v, %v", doc, err)
+	}
+	if blob, err := mockStorage.Get(writerOwnKB, "summary.md"); err != nil || string(blob) != "# Summary" {
+		t.Errorf("stored blob = %q, %v", blob, err)
+	}
+}
+
+func TestAgentDatasetWriter_ChecksOwnerAccess(t *testing.T) {
+	w, _ := setupDatasetWriterTest(t)
+	w.parse = func(string, string) error {
+		t.Error("parse called for a rejected write")
+		return nil
+	}
+	for _, id := range []string{writerForeignKB, "2a1b2c3d4e5f60718293a4b5c6d7e8f9"} {
+		_, err := w.WriteDocument(context.Background(), component.DatasetWriteRequest{
+			CanvasID: "canvas-w", DatasetID: id, Filename: "x.md", Content: []byte("x"), Parse: true,
+		})
+		if !errors.Is(err, ErrAgentDatasetForbidden) {
+			t.Errorf("dataset %s: err = %v, want ErrAgentDatasetForbidden", id, err)
+		}
+	}
+}
+
+func TestAgentDatasetWriter_ParseTimeout(t *testing.T) {
+	w, _ := setupDatasetWriterTest(t)
+	w.parse = func(string, string) error { return nil }
+
+	_, err := w.WriteDocument(context.Background(), component.DatasetWriteRequest{
+		CanvasID:     "canvas-w",
+		DatasetID:    writerOwnKB,
+		Filename:     "slow.txt",
+		Content:      []byte("slow"),
+		Parse:        true,
+		WaitForParse: true,
+		ParseTimeout: 30 * time.Millisecond,
+	})
+	if !errors.Is(err, ErrAgentDatasetParseTimeout) {
+		t.Errorf("err = %v, want ErrAgentDatasetParseTimeout", err)
+	}
+}
@@ -0,0 +1,103 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+
+	dslpkg "ragflow/internal/agent/dsl"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+// AgentDraftVersion names the canvas's unpublished DSL as a diff side.
+const AgentDraftVersion = "draft"
+
+// AgentVersionRef identifies one side of a version diff.
+type AgentVersionRef struct {
+	ID         string  `json:"id"`
+	Title      *string `json:"title,omitempty"`
+	CreateTime *int64  `json:"create_time,omitempty"`
+}
+
+// AgentVersionDiff is the structural diff between two versions of a
+// canvas. From is nil when the target has no earlier version, in which
+// case everything in To is reported as added.
+type AgentVersionDiff struct {
+	From *AgentVersionRef `json:"from"`
+	To   AgentVersionRef  `json:"to"`
+	*dslpkg.DSLDiff
+}
+
+// DiffVersions diffs two versions of a canvas the user can see. toID
+// defaults to the draft; fromID defaults to the version published just
+// before toID (the latest one when toID is the draft), so the default
+// answers "what would publishing change". Either id may be
+// AgentDraftVersion.
+func (s *AgentService) DiffVersions(ctx context.Context, userID, canvasID, fromID, toID string) (*AgentVersionDiff, error) {
+	canvas, err := s.loadCanvasForUser(ctx, userID, canvasID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.versionDAO.ListByCanvasID(canvasID)
+	if err != nil {
+		return nil, err
+	}
+	if toID == "" {
+		toID = AgentDraftVersion
+	}
+	toRef, toDSL, toIdx, err := resolveDiffSide(canvas, versions, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &AgentVersionDiff{To: toRef}
+	var fromDSL map[string]any
+	switch {
+	case fromID != "":
+		ref, dsl, _, err := resolveDiffSide(canvas, versions, fromID)
+		if err != nil {
+			return nil, err
+		}
+		out.From, fromDSL = &ref, dsl
+	case toIdx+1 < len(versions):
+		// versions is newest first; the draft sits before index 0.
+		prev := versions[toIdx+1]
+		out.From = versionRef(prev)
+		fromDSL = prev.DSL
+	}
+	out.DSLDiff = dslpkg.Diff(dslpkg.NormalizeForCanvas(fromDSL), dslpkg.NormalizeForCanvas(toDSL))
+	return out, nil
+}
+
+// resolveDiffSide returns the ref, DSL and position in versions of id.
+// The draft's position is -1 so the version after it is versions[0].
+func resolveDiffSide(canvas *entity.UserCanvas, versions []*entity.UserCanvasVersion, id string) (AgentVersionRef, map[string]any, int, error) {
+	if id == AgentDraftVersion {
+		return AgentVersionRef{ID: AgentDraftVersion, Title: canvas.Title, CreateTime: canvas.UpdateTime}, canvas.DSL, -1, nil
+	}
+	for i, v := range versions {
+		if v.ID == id {
+			return *versionRef(v), v.DSL, i, nil
+		}
+	}
+	return AgentVersionRef{}, nil, 0, dao.ErrUserCanvasVersionNotFound
+}
+
+func versionRef(v *entity.UserCanvasVersion) *AgentVersionRef {
+	return &AgentVersionRef{ID: v.ID, Title: v.Title, CreateTime: v.CreateTime}
+}
@@ -0,0 +1,113 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dslpkg "ragflow/internal/agent/dsl"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+func setupDiffTest(t *testing.T) *AgentService {
+	t.Helper()
+	setupCanvasServiceDB(t)
+
+	// v1 says "hello", v2 says "hi", and the draft adds a second message.
+	makeCanvasWithDSL(t, "diff-canvas", "diff-user", "diff-tenant", "diff-v1", helloTriggerDSL())
+	older := int64(1000)
+	dao.DB.Model(&entity.UserCanvasVersion{}).Where("id = ?", "diff-v1").Update("create_time", older)
+	v2 := helloTriggerDSL()
+	setMessageText(v2, "message_0", "hi {{sys.query}}")
+	newer := int64(2000)
+	dao.DB.Create(&entity.UserCanvasVersion{ID: "diff-v2", UserCanvasID: "diff-canvas", DSL: entity.JSONMap(v2),
+		BaseModel: entity.BaseModel{CreateTime: &newer}})
+
+	draft := helloTriggerDSL()
+	setMessageText(draft, "message_0", "hi {{sys.query}}")
+	comps := draft["components"].(map[string]any)
+	comps["message_0"].(map[string]any)["downstream"] = []any{"message_1"}
+	comps["message_1"] = map[string]any{
+		"obj":      map[string]any{"component_name": "Message", "params": map[string]any{"text": "bye"}},
+		"upstream": []any{"message_0"},
+	}
+	dao.DB.Model(&entity.UserCanvas{}).Where("id = ?", "diff-canvas").Update("dsl", entity.JSONMap(draft))
+	return NewAgentService()
+}
+
+func setMessageText(dsl map[string]any, id, text string) {
+	comp := dsl["components"].(map[string]any)[id].(map[string]any)
+	comp["obj"].(map[string]any)["params"].(map[string]any)["text"] = text
+}
+
+func TestDiffVersions_DefaultsToDraftAgainstLatest(t *testing.T) {
+	svc := setupDiffTest(t)
+
+	d, err := svc.DiffVersions(context.Background(), "diff-user", "diff-canvas", "", "")
+	if err != nil {
+		t.Fatalf("DiffVersions: %v", err)
+	}
+	if d.To.ID != AgentDraftVersion || d.From == nil || d.From.ID != "diff-v2" {
+		t.Fatalf("sides = %+v -> %+v, want diff-v2 -> draft", d.From, d.To)
+	}
+	if len(d.Components) != 1 || d.Components[0].ComponentID != "message_1" || d.Components[0].Kind != dslpkg.ChangeAdded {
+		t.Errorf("components = %+v, want message_1 added", d.Components)
+	}
+	if len(d.Edges.Added) != 1 || d.Edges.Added[0] != (dslpkg.Edge{From: "message_0", To: "message_1"}) {
+		t.Errorf("edges = %+v", d.Edges)
+	}
+}
+
+func TestDiffVersions_PreviousVersionAndExplicitSides(t *testing.T) {
+	svc := setupDiffTest(t)
+	ctx := context.Background()
+
+	d, err := svc.DiffVersions(ctx, "diff-user", "diff-canvas", "", "diff-v2")
+	if err != nil {
+		t.Fatalf("DiffVersions: %v", err)
+	}
+	if d.From == nil || d.From.ID != "diff-v1" {
+		t.Fatalf("from = %+v, want diff-v1", d.From)
+	}
+	if len(d.Components) != 1 || len(d.Components[0].Params) != 1 {
+		t.Fatalf("components = %+v, want one text change", d.Components)
+	}
+	if p := d.Components[0].Params[0]; p.Path != "text" || p.Old != "hello {{sys.query}}" || p.New != "hi {{sys.query}}" {
+		t.Errorf("param change = %+v", p)
+	}
+
+	// The first version has nothing before it.
+	d, err = svc.DiffVersions(ctx, "diff-user", "diff-canvas", "", "diff-v1")
+	if err != nil || d.From != nil || len(d.Components) != 2 {
+		t.Fatalf("first version diff = %+v, %v", d, err)
+	}
+
+	d, err = svc.DiffVersions(ctx, "diff-user", "diff-canvas", "diff-v2", "diff-v2")
+	if err != nil || !d.Empty() {
+		t.Fatalf("self diff = %+v, %v", d, err)
+	}
+
+	if _, err := svc.DiffVersions(ctx, "diff-user", "diff-canvas", "nope", ""); !errors.Is(err, dao.ErrUserCanvasVersionNotFound) {
+		t.Errorf("unknown version: err = %v", err)
+	}
+	if _, err := svc.DiffVersions(ctx, "someone-else", "diff-canvas", "", ""); err == nil {
+		t.Errorf("diffing a canvas the user cannot see must fail")
+	}
+}
@@ -0,0 +1,86 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	dslpkg "ragflow/internal/agent/dsl"
+	"ragflow/internal/entity"
+)
+
+// ErrAgentDSLInvalid is returned by PublishAgent when static
+// validation finds error-severity diagnostics. The concrete error is
+// an *AgentLintError carrying the diagnostics.
+var ErrAgentDSLInvalid = errors.New("agent DSL has validation errors")
+
+// AgentLintError blocks a publish. Unwraps to ErrAgentDSLInvalid.
+type AgentLintError struct {
+	Diagnostics []dslpkg.Diagnostic
+}
+
+func (e *AgentLintError) Error() string {
+	n := 0
+	for _, d := range e.Diagnostics {
+		if d.Severity == dslpkg.SeverityError {
+			n++
+		}
+	}
+	return fmt.Sprintf("agent DSL has %d validation error(s)", n)
+}
+
+func (e *AgentLintError) Unwrap() error { return ErrAgentDSLInvalid }
+
+// SetComponentCatalog installs the lookup the DSL validator uses to
+// flag unknown component names (component.IsRegistered in
+// production). Nil skips that check.
+func (s *AgentService) SetComponentCatalog(known func(name string) bool) {
+	s.knownComponent = known
+}
+
+// LintAgent validates a DSL for a canvas the user can see. A nil dsl
+// lints the canvas's saved draft; otherwise the supplied DSL is
+// normalized the same way UpdateAgent would store it and linted
+// without being saved.
+func (s *AgentService) LintAgent(ctx context.Context, userID, canvasID string, dsl entity.JSONMap) ([]dslpkg.Diagnostic, error) {
+	row, err := s.loadCanvasForUser(ctx, userID, canvasID)
+	if err != nil {
+		return nil, err
+	}
+	if dsl == nil {
+		return s.lintDSL(row.CanvasCategory, row.DSL), nil
+	}
+	return s.lintDSL(row.CanvasCategory, dslpkg.NormalizeForCanvas(dsl)), nil
+}
+
+// dataflowCanvasCategory is the canvas_category of ingestion pipelines.
+// They run on the document pipeline rather than the agent canvas, start
+// from a File node instead of Begin and use their own components
+// (Parser, TokenChunker, ...), so the agent DSL validator does not
+// apply to them.
+const dataflowCanvasCategory = "dataflow_canvas"
+
+// lintDSL runs the static validator over an already-normalized DSL of
+// a canvas in the given category. Dataflow canvases are not linted.
+func (s *AgentService) lintDSL(category string, dsl map[string]any) []dslpkg.Diagnostic {
+	if category == dataflowCanvasCategory {
+		return []dslpkg.Diagnostic{}
+	}
+	return dslpkg.Validate(dsl, dslpkg.ValidateOptions{KnownComponent: s.knownComponent})
+}
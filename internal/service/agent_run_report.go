@@ -0,0 +1,258 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/common"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+	"ragflow/internal/server"
+)
+
+// Bounds of the run-report window used by RunReportStats.
+const (
+	defaultRunReportStatsLimit = 200
+	maxRunReportStatsLimit     = 1000
+)
+
+// AgentSessionReport is the cost and latency report of one session: a
+// row per run (a resumed interrupt is a run of its own) and their sum.
+type AgentSessionReport struct {
+	SessionID  string                   `json:"session_id"`
+	Runs       []*entity.AgentRunReport `json:"runs"`
+	DurationMs float64                  `json:"duration_ms"`
+	Totals     canvas.RunReportTotals   `json:"totals"`
+}
+
+// AgentNodeStats is the latency and cost distribution of one node over
+// the runs of a RunReportStats window.
+type AgentNodeStats struct {
+	ComponentID   string  `json:"component_id"`
+	ComponentName string  `json:"component_name"`
+	Samples       int     `json:"samples"`
+	ErrorRate     float64 `json:"error_rate"`
+	WallTimeP50Ms float64 `json:"wall_time_p50_ms"`
+	WallTimeP95Ms float64 `json:"wall_time_p95_ms"`
+	TokensP50     float64 `json:"tokens_p50"`
+	TokensP95     float64 `json:"tokens_p95"`
+	CostP50       float64 `json:"cost_p50"`
+	CostP95       float64 `json:"cost_p95"`
+}
+
+// AgentRunStats aggregates the most recent run reports of a canvas.
+type AgentRunStats struct {
+	Runs          int              `json:"runs"`
+	DurationP50Ms float64          `json:"duration_p50_ms"`
+	DurationP95Ms float64          `json:"duration_p95_ms"`
+	CostP50       float64          `json:"cost_p50"`
+	CostP95       float64          `json:"cost_p95"`
+	TotalCost     float64          `json:"total_cost"`
+	Nodes         []AgentNodeStats `json:"nodes"`
+}
+
+// runReportPrices returns the configured model price table.
+func runReportPrices() map[string]canvas.ModelPrice {
+	cfg := server.GetConfig()
+	if cfg == nil || len(cfg.Agent.ModelPrices) == 0 {
+		return nil
+	}
+	prices := make(map[string]canvas.ModelPrice, len(cfg.Agent.ModelPrices))
+	for model, p := range cfg.Agent.ModelPrices {
+		prices[model] = canvas.ModelPrice{InputPerMillion: p.InputPerMillion, OutputPerMillion: p.OutputPerMillion}
+	}
+	return prices
+}
+
+// saveRunReport closes the run's report and stores it with the session.
+// Best-effort: a failed write is logged and never fails the run.
+func (s *AgentService) saveRunReport(canvasID string, versionRow *entity.UserCanvasVersion, root map[string]any, meta *canvas.RunMeta, status string) {
+	if s.runReportDAO == nil || dao.DB == nil || meta == nil || meta.Report == nil || meta.SessionID == "" {
+		return
+	}
+	report := meta.Report.Finish(status, time.Now(), runReportPrices())
+	row := &entity.AgentRunReport{
+		ID:            genID32(),
+		UserCanvasID:  canvasID,
+		SessionID:     meta.SessionID,
+		TaskID:        meta.TaskID,
+		Status:        report.Status,
+		StartedAt:     report.StartedAt,
+		DurationMs:    report.DurationMs,
+		TotalTokens:   report.Totals.TotalTokens,
+		EstimatedCost: report.Totals.EstimatedCost,
+	}
+	if versionRow != nil {
+		row.VersionID = versionRow.ID
+	}
+	if uid, ok := root["user_id"].(string); ok {
+		row.UserID = uid
+	}
+	raw, err := json.Marshal(report)
+	if err == nil {
+		err = json.Unmarshal(raw, &row.Report)
+	}
+	if err == nil {
+		err = s.runReportDAO.Create(row)
+	}
+	if err != nil {
+		common.Warn("service: save run report (best-effort)",
+			zap.String("canvas", canvasID),
+			zap.String("session", meta.SessionID),
+			zap.Error(err))
+	}
+}
+
+// GetRunReport returns the run reports of one session of a canvas the
+// caller can access.
+func (s *AgentService) GetRunReport(ctx context.Context, userID, canvasID, sessionID string) (*AgentSessionReport, error) {
+	if _, err := s.loadCanvasForUser(ctx, userID, canvasID); err != nil {
+		return nil, err
+	}
+	rows, err := s.runReportDAO.ListBySession(canvasID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("GetRunReport: %w: %w", err, ErrAgentStorageError)
+	}
+	out := &AgentSessionReport{SessionID: sessionID, Runs: rows}
+	for _, row := range rows {
+		r, err := decodeRunReport(row)
+		if err != nil {
+			return nil, fmt.Errorf("GetRunReport: %w: %w", err, ErrAgentStorageError)
+		}
+		out.DurationMs += r.DurationMs
+		out.Totals.Retries += r.Totals.Retries
+		out.Totals.PromptTokens += r.Totals.PromptTokens
+		out.Totals.CompletionTokens += r.Totals.CompletionTokens
+		out.Totals.TotalTokens += r.Totals.TotalTokens
+		out.Totals.EstimatedCost += r.Totals.EstimatedCost
+		out.Totals.ToolCalls += r.Totals.ToolCalls
+		out.Totals.SandboxTimeMs += r.Totals.SandboxTimeMs
+	}
+	return out, nil
+}
+
+// RunReportStats aggregates the latest limit run reports of a canvas
+// (default 200, at most 1000) into p50/p95 latency and cost per node.
+func (s *AgentService) RunReportStats(ctx context.Context, userID, canvasID string, limit int) (*AgentRunStats, error) {
+	if _, err := s.loadCanvasForUser(ctx, userID, canvasID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultRunReportStatsLimit
+	}
+	if limit > maxRunReportStatsLimit {
+		limit = maxRunReportStatsLimit
+	}
+	rows, err := s.runReportDAO.ListRecentByCanvas(canvasID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("RunReportStats: %w: %w", err, ErrAgentStorageError)
+	}
+
+	type nodeSamples struct {
+		name                string
+		errors              int
+		wall, tokens, costs []float64
+	}
+	var (
+		order     []string
+		nodes     = map[string]*nodeSamples{}
+		durations []float64
+		costs     []float64
+	)
+	out := &AgentRunStats{Runs: len(rows), Nodes: []AgentNodeStats{}}
+	// Oldest first so node order follows the canvas's first runs.
+	for i := len(rows) - 1; i >= 0; i-- {
+		r, err := decodeRunReport(rows[i])
+		if err != nil {
+			return nil, fmt.Errorf("RunReportStats: %w: %w", err, ErrAgentStorageError)
+		}
+		durations = append(durations, r.DurationMs)
+		costs = append(costs, r.Totals.EstimatedCost)
+		out.TotalCost += r.Totals.EstimatedCost
+		for _, n := range r.Nodes {
+			ns, ok := nodes[n.ComponentID]
+			if !ok {
+				ns = &nodeSamples{name: n.ComponentName}
+				nodes[n.ComponentID] = ns
+				order = append(order, n.ComponentID)
+			}
+			if n.Errors > 0 {
+				ns.errors++
+			}
+			ns.wall = append(ns.wall, n.WallTimeMs)
+			ns.tokens = append(ns.tokens, float64(n.TotalTokens))
+			ns.costs = append(ns.costs, n.EstimatedCost)
+		}
+	}
+	out.DurationP50Ms, out.DurationP95Ms = percentile(durations, 50), percentile(durations, 95)
+	out.CostP50, out.CostP95 = percentile(costs, 50), percentile(costs, 95)
+	for _, id := range order {
+		ns := nodes[id]
+		out.Nodes = append(out.Nodes, AgentNodeStats{
+			ComponentID:   id,
+			ComponentName: ns.name,
+			Samples:       len(ns.wall),
+			ErrorRate:     float64(ns.errors) / float64(len(ns.wall)),
+			WallTimeP50Ms: percentile(ns.wall, 50),
+			WallTimeP95Ms: percentile(ns.wall, 95),
+			TokensP50:     percentile(ns.tokens, 50),
+			TokensP95:     percentile(ns.tokens, 95),
+			CostP50:       percentile(ns.costs, 50),
+			CostP95:       percentile(ns.costs, 95),
+		})
+	}
+	return out, nil
+}
+
+// decodeRunReport reads the stored report of row.
+func decodeRunReport(row *entity.AgentRunReport) (*canvas.RunReport, error) {
+	var r canvas.RunReport
+	if len(row.Report) == 0 {
+		return &r, nil
+	}
+	raw, err := json.Marshal(row.Report)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("decode run report %s: %w", row.ID, err)
+	}
+	return &r, nil
+}
+
+// percentile returns the nearest-rank p-th percentile of values; 0 for
+// an empty slice. values is sorted in place.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(p / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	return values[rank-1]
+}
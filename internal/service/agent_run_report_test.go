@@ -0,0 +1,169 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+func setupRunReportTest(t *testing.T) *AgentService {
+	t.Helper()
+	setupCanvasServiceDB(t, &entity.API4Conversation{}, &entity.AgentRunReport{})
+	makeCanvasWithDSL(t, "canvas-1", "user-1", "tenant-1", "v1", helloTriggerDSL())
+	return NewAgentService()
+}
+
+func TestRunReport_PersistedWithSession(t *testing.T) {
+	svc := setupRunReportTest(t)
+	ctx := context.Background()
+
+	events, err := svc.RunAgent(ctx, "user-1", "canvas-1", "session-1", "", "world")
+	if err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	if got := runAnswer(t, events); got != "hello world" {
+		t.Fatalf("answer = %q, want %q", got, "hello world")
+	}
+
+	report, err := svc.GetRunReport(ctx, "user-1", "canvas-1", "session-1")
+	if err != nil {
+		t.Fatalf("GetRunReport: %v", err)
+	}
+	if len(report.Runs) != 1 {
+		t.Fatalf("runs = %d, want 1", len(report.Runs))
+	}
+	row := report.Runs[0]
+	if row.Status != canvas.RunReportSucceeded || row.VersionID != "v1" || row.UserID != "user-1" {
+		t.Fatalf("run row = %+v", row)
+	}
+	r, err := decodeRunReport(row)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(r.Nodes) != 2 || r.Nodes[0].ComponentID != "begin_0" || r.Nodes[1].ComponentID != "message_0" {
+		t.Fatalf("nodes = %+v, want begin_0 then message_0", r.Nodes)
+	}
+	if r.Nodes[1].Runs != 1 || r.Nodes[1].ComponentName != "Message" {
+		t.Fatalf("message node = %+v", r.Nodes[1])
+	}
+
+	if _, err := svc.GetRunReport(ctx, "user-2", "canvas-1", "session-1"); !errors.Is(err, dao.ErrUserCanvasNotFound) {
+		t.Fatalf("GetRunReport as stranger: error = %v, want ErrUserCanvasNotFound", err)
+	}
+}
+
+func TestRunReport_PersistedWhenCompileFails(t *testing.T) {
+	svc := setupRunReportTest(t)
+	ctx := context.Background()
+	makeCanvasWithDSL(t, "canvas-bogus", "user-1", "tenant-1", "v-bogus", map[string]any{
+		"components": map[string]any{
+			"begin_0": map[string]any{
+				"obj":        map[string]any{"component_name": "Begin", "params": map[string]any{}},
+				"downstream": []any{"bogus_0"},
+			},
+			"bogus_0": map[string]any{
+				"obj": map[string]any{"component_name": "NonExistentComponent", "params": map[string]any{}},
+			},
+		},
+		"path": []any{"begin_0", "bogus_0"},
+	})
+
+	events, err := svc.RunAgent(ctx, "user-1", "canvas-bogus", "session-1", "", "hi")
+	if err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	if _, _, errs, _ := drainAgentEvents(t, events); len(errs) == 0 {
+		t.Fatal("expected a compile error event")
+	}
+
+	report, err := svc.GetRunReport(ctx, "user-1", "canvas-bogus", "session-1")
+	if err != nil {
+		t.Fatalf("GetRunReport: %v", err)
+	}
+	if len(report.Runs) != 1 || report.Runs[0].Status != canvas.RunReportFailed {
+		t.Fatalf("runs = %+v, want one failed run", report.Runs)
+	}
+	r, err := decodeRunReport(report.Runs[0])
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(r.Nodes) != 0 {
+		t.Fatalf("nodes = %+v, want none", r.Nodes)
+	}
+}
+
+func TestRunReportStats_Percentiles(t *testing.T) {
+	svc := setupRunReportTest(t)
+	base := time.Unix(1000, 0)
+	for i := 1; i <= 20; i++ {
+		c := canvas.NewRunReportCollector(base.Add(time.Duration(i) * time.Minute))
+		c.RecordNode("llm_0", "LLM", time.Duration(i)*10*time.Millisecond, map[string]any{"model": "m", "tokens": i * 100}, nil)
+		var nodeErr error
+		if i%10 == 0 {
+			nodeErr = errors.New("boom")
+		}
+		c.RecordNode("code_0", "CodeExec", time.Duration(i)*time.Millisecond, nil, nodeErr)
+		r := c.Finish(canvas.RunReportSucceeded, base.Add(time.Duration(i)*time.Minute+time.Second), map[string]canvas.ModelPrice{"m": {InputPerMillion: 1}})
+		row := &entity.AgentRunReport{
+			ID:           genID32(),
+			UserCanvasID: "canvas-1",
+			SessionID:    "s",
+			Status:       r.Status,
+			StartedAt:    r.StartedAt,
+			DurationMs:   r.DurationMs,
+		}
+		raw, _ := json.Marshal(r)
+		_ = json.Unmarshal(raw, &row.Report)
+		if err := dao.NewAgentRunReportDAO().Create(row); err != nil {
+			t.Fatalf("create report: %v", err)
+		}
+	}
+
+	stats, err := svc.RunReportStats(context.Background(), "user-1", "canvas-1", 0)
+	if err != nil {
+		t.Fatalf("RunReportStats: %v", err)
+	}
+	if stats.Runs != 20 || len(stats.Nodes) != 2 {
+		t.Fatalf("stats = %+v, want 20 runs over 2 nodes", stats)
+	}
+	llm := stats.Nodes[0]
+	if llm.ComponentID != "llm_0" || llm.WallTimeP50Ms != 100 || llm.WallTimeP95Ms != 190 {
+		t.Fatalf("llm stats = %+v, want p50 100ms / p95 190ms", llm)
+	}
+	if llm.TokensP50 != 1000 || llm.CostP95 != 0.0019 {
+		t.Fatalf("llm tokens/cost = %v / %v", llm.TokensP50, llm.CostP95)
+	}
+	if code := stats.Nodes[1]; code.ErrorRate != 0.1 || code.WallTimeP95Ms != 19 {
+		t.Fatalf("code stats = %+v, want 10%% errors and p95 19ms", code)
+	}
+
+	recent, err := svc.RunReportStats(context.Background(), "user-1", "canvas-1", 5)
+	if err != nil {
+		t.Fatalf("RunReportStats(limit 5): %v", err)
+	}
+	if recent.Runs != 5 || recent.Nodes[0].WallTimeP50Ms != 180 {
+		t.Fatalf("recent stats = %+v, want the 5 latest runs", recent)
+	}
+}
@@ -17,6 +17,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,8 +27,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	agenttool "ragflow/internal/agent/tool"
 	"ragflow/internal/common"
 	"ragflow/internal/dao"
 	"ragflow/internal/entity"
@@ -425,6 +428,7 @@ func (s *AgentService) DeleteAgentSessionItem(userID, agentID, sessionID string)
 	if row == 0 {
 		return false, common.CodeSuccess, nil
 	}
+	closeSandboxSession(sessionID)
 	return true, common.CodeSuccess, nil
 }
 
@@ -482,6 +486,7 @@ func (s *AgentService) DeleteAgentSessions(userID, agentID string, ids []string,
 		if _, err := s.api4ConversationDAO.DeleteBySessionIDAndAgentID(sessionID, agentID); err != nil {
 			return nil, common.CodeServerError, err
 		}
+		closeSandboxSession(sessionID)
 		successCount++
 	}
 
@@ -511,6 +516,14 @@ func (s *AgentService) DeleteAgentSessions(userID, agentID string, ids []string,
 	return &DeleteAgentSessionsResult{}, common.CodeSuccess, nil
 }
 
+// closeSandboxSession tears down the CodeExec sandbox kept for a
+// deleted session. Best-effort: the idle TTL reclaims it anyway.
+func closeSandboxSession(sessionID string) {
+	if err := agenttool.CloseSandboxSession(context.Background(), sessionID); err != nil {
+		common.Warn("service: close sandbox session (best-effort)", zap.String("session", sessionID), zap.Error(err))
+	}
+}
+
 // normalizeAgentTags returns an error for unsupported tag payload types.
 // The branch behaviour intentionally mirrors the Python implementation:
 //   - string: treat the value as a CSV — split on "," and use each piece
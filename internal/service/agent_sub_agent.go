@@ -0,0 +1,180 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/agent/runtime"
+	agenttool "ragflow/internal/agent/tool"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+// ErrSubAgentWaitingForUser is returned when a child canvas pauses for
+// user input. A SubAgent node has no way to relay the prompt, so such
+// canvases cannot be used as sub-agents.
+var ErrSubAgentWaitingForUser = errors.New("sub-agent canvas paused waiting for user input")
+
+// RunSubAgent runs a published version of canvasID as a SubAgent node of
+// the canvas currently executing on ctx. It implements
+// component.SubAgentRunner.
+//
+// The child runs synchronously on the parent's goroutine rather than
+// through the Runner, so it neither registers a cancel slot of its own
+// nor pre-empts an interactive run of the same canvas. It stops when ctx
+// is cancelled or the parent task's canvas cancel flag is raised. Its
+// node_started / node_finished and tool_cache_hit events are forwarded into the parent
+// stream tagged with parent_component_id and sub_canvas_id; the child's
+// workflow-level and message events are dropped because the answer
+// surfaces as the SubAgent node's output instead. Without a run on ctx,
+// as when the built-in MCP server's run_agent tool calls it, the child
+// events are dropped.
+//
+// versionID pins the child version; "" runs the latest published one.
+func (s *AgentService) RunSubAgent(ctx context.Context, userID, canvasID, versionID, query string, inputs map[string]any) (map[string]any, error) {
+	if _, err := s.loadCanvasForUser(ctx, userID, canvasID); err != nil {
+		return nil, err
+	}
+	versionRow, err := s.subAgentVersion(canvasID, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := canvas.GetRunMeta(ctx)
+	parentCpnID := runtime.ComponentIDFromContext(ctx)
+	sessionID := strings.ReplaceAll(uuid.New().String(), "-", "")
+
+	childCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if meta != nil && meta.TaskID != "" {
+		go canvas.WatchCancel(childCtx, meta.TaskID, cancel)
+	}
+
+	events := make(chan canvas.RunEvent, 64)
+	var forwarded sync.WaitGroup
+	forwarded.Add(1)
+	go func() {
+		defer forwarded.Done()
+		for ev := range events {
+			forwardSubAgentEvent(meta, parentCpnID, canvasID, ev)
+		}
+	}()
+
+	root := map[string]any{
+		"canvas_id":      canvasID,
+		"version_id":     versionRow.ID,
+		"session_id":     sessionID,
+		"user_id":        userID,
+		"user_input":     query,
+		"inputs":         inputs,
+		"__events__":     events,
+		"__session_id__": sessionID,
+	}
+	if meta != nil {
+		root["__message_id__"] = meta.MessageID
+		root["__task_id__"] = meta.TaskID
+	}
+	if tenantIDs, terr := s.userTenantDAO.GetTenantIDsByUserID(userID); terr == nil && len(tenantIDs) > 0 {
+		root["tenant_id"] = tenantIDs[0]
+	}
+
+	state, runErr := s.buildRunFunc(canvasID, versionRow, normalisedDSLForRun(versionRow))(childCtx, root)
+	close(events)
+	forwarded.Wait()
+	if runErr != nil {
+		if canvas.IsInterruptError(runErr) {
+			return nil, fmt.Errorf("RunSubAgent: canvas %q: %w", canvasID, ErrSubAgentWaitingForUser)
+		}
+		return nil, fmt.Errorf("RunSubAgent: canvas %q: %w", canvasID, runErr)
+	}
+
+	answer, reference := collectRunAnswer(state)
+	out := map[string]any{
+		"answer":     answer,
+		"version_id": versionRow.ID,
+		"session_id": sessionID,
+	}
+	if len(reference) > 0 {
+		out["reference"] = reference
+	}
+	return out, nil
+}
+
+// subAgentVersion loads the child version a SubAgent node runs: the
+// pinned versionID (which must belong to canvasID) or, when empty, the
+// latest published version.
+func (s *AgentService) subAgentVersion(canvasID, versionID string) (*entity.UserCanvasVersion, error) {
+	if versionID == "" {
+		row, err := s.versionDAO.GetLatest(canvasID)
+		if err != nil {
+			if errors.Is(err, dao.ErrUserCanvasVersionNotFound) {
+				return nil, fmt.Errorf("RunSubAgent: canvas %q has no published version: %w", canvasID, err)
+			}
+			return nil, fmt.Errorf("RunSubAgent: load latest version for canvas %q: %w: %w", canvasID, err, ErrAgentStorageError)
+		}
+		return row, nil
+	}
+	row, err := s.versionDAO.GetByID(versionID)
+	if err != nil {
+		if errors.Is(err, dao.ErrUserCanvasVersionNotFound) {
+			return nil, fmt.Errorf("RunSubAgent: load version %q: %w", versionID, err)
+		}
+		return nil, fmt.Errorf("RunSubAgent: load version %q: %w: %w", versionID, err, ErrAgentStorageError)
+	}
+	if row.UserCanvasID != canvasID {
+		return nil, fmt.Errorf("RunSubAgent: version %q belongs to canvas %q, not %q: %w",
+			versionID, row.UserCanvasID, canvasID, dao.ErrUserCanvasVersionNotFound)
+	}
+	return row, nil
+}
+
+// forwardSubAgentEvent re-emits a child node event on the parent run's
+// stream, nested under the SubAgent node that started the child.
+func forwardSubAgentEvent(meta *canvas.RunMeta, parentCpnID, canvasID string, ev canvas.RunEvent) {
+	if meta == nil || meta.Events == nil {
+		return
+	}
+	if ev.Type != "node_started" && ev.Type != "node_finished" && ev.Type != agenttool.ToolCacheHitEvent {
+		return
+	}
+	data := map[string]any{}
+	if ev.Data != "" {
+		if err := json.Unmarshal([]byte(ev.Data), &data); err != nil {
+			return
+		}
+	}
+	data["parent_component_id"] = parentCpnID
+	data["sub_canvas_id"] = canvasID
+	payload, _ := json.Marshal(data)
+	canvas.PushEvent(meta.Events, canvas.RunEvent{
+		Type:      ev.Type,
+		Data:      string(payload),
+		MessageID: meta.MessageID,
+		CreatedAt: ev.CreatedAt,
+		TaskID:    meta.TaskID,
+		SessionID: meta.SessionID,
+	})
+}
@@ -0,0 +1,191 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/agent/component"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+func setupSubAgentTest(t *testing.T) *AgentService {
+	t.Helper()
+	setupCanvasServiceDB(t)
+
+	svc := NewAgentService()
+	component.SetSubAgentRunner(svc)
+	t.Cleanup(func() { component.SetSubAgentRunner(nil) })
+	return svc
+}
+
+// subAgentDSL is Begin → <middle> → Message(text).
+func subAgentDSL(middle map[string]any, text string) map[string]any {
+	return map[string]any{
+		"components": map[string]any{
+			"begin_0": map[string]any{
+				"obj":        map[string]any{"component_name": "Begin", "params": map[string]any{}},
+				"downstream": []any{"sub_0"},
+			},
+			"sub_0": map[string]any{
+				"obj":        middle,
+				"upstream":   []any{"begin_0"},
+				"downstream": []any{"message_0"},
+			},
+			"message_0": map[string]any{
+				"obj":      map[string]any{"component_name": "Message", "params": map[string]any{"text": text}},
+				"upstream": []any{"sub_0"},
+			},
+		},
+		"path": []any{"begin_0", "sub_0", "message_0"},
+	}
+}
+
+func collectRunEvents(t *testing.T, events <-chan canvas.RunEvent) []canvas.RunEvent {
+	t.Helper()
+	var out []canvas.RunEvent
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return out
+			}
+			out = append(out, ev)
+		case <-deadline:
+			t.Fatal("run did not finish")
+		}
+	}
+}
+
+// TestRunAgent_SubAgentRunsPinnedChild runs a parent canvas whose
+// SubAgent node invokes a pinned child version with mapped inputs, and
+// checks the child answer flows into the parent and the child's node
+// events are nested under the SubAgent node.
+func TestRunAgent_SubAgentRunsPinnedChild(t *testing.T) {
+	svc := setupSubAgentTest(t)
+
+	childDSL := subAgentDSL(
+		map[string]any{"component_name": "Message", "params": map[string]any{"text": "child {{sys.query}} on {{begin_0@topic}}"}},
+		"{{sub_0@content}}",
+	)
+	makeCanvasWithDSL(t, "canvas-child", "user-1", "tenant-1", "v-child", childDSL)
+	// A later child publish must not change the parent's pinned run.
+	time.Sleep(2 * time.Millisecond)
+	dao.DB.Create(&entity.UserCanvasVersion{
+		ID: "v-child-2", UserCanvasID: "canvas-child", Title: sptr("v2"),
+		DSL: entity.JSONMap(subAgentDSL(
+			map[string]any{"component_name": "Message", "params": map[string]any{"text": "broken"}},
+			"{{sub_0@content}}",
+		)),
+	})
+
+	parentDSL := subAgentDSL(
+		map[string]any{"component_name": "SubAgent", "params": map[string]any{
+			"canvas_id":  "canvas-child",
+			"version_id": "v-child",
+			"query":      "{{sys.query}}",
+			"inputs":     map[string]any{"topic": "rivers"},
+		}},
+		"parent got: {{sub_0@response}}",
+	)
+	makeCanvasWithDSL(t, "canvas-parent", "user-1", "tenant-1", "v-parent", parentDSL)
+
+	events, err := svc.RunAgent(context.Background(), "user-1", "canvas-parent", "session-1", "", "hello")
+	if err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	var answer string
+	nested := 0
+	for _, ev := range collectRunEvents(t, events) {
+		switch ev.Type {
+		case "error":
+			t.Fatalf("unexpected error event: %s", ev.Data)
+		case "message":
+			var m canvas.MessageEvent
+			_ = json.Unmarshal([]byte(ev.Data), &m)
+			answer = m.Content
+		case "node_started", "node_finished":
+			var data map[string]any
+			_ = json.Unmarshal([]byte(ev.Data), &data)
+			if data["parent_component_id"] == nil {
+				continue
+			}
+			nested++
+			if data["parent_component_id"] != "sub_0" || data["sub_canvas_id"] != "canvas-child" {
+				t.Errorf("nested event = %v", data)
+			}
+		}
+	}
+	if answer != "parent got: child hello on rivers" {
+		t.Errorf("answer = %q", answer)
+	}
+	// Begin, Message and Message of the child, each started and finished.
+	if nested != 6 {
+		t.Errorf("nested child node events = %d, want 6", nested)
+	}
+}
+
+// TestRunAgent_SubAgentRejectsRecursion: a child that calls back into
+// its parent fails instead of recursing.
+func TestRunAgent_SubAgentRejectsRecursion(t *testing.T) {
+	svc := setupSubAgentTest(t)
+
+	loopBack := map[string]any{"component_name": "SubAgent", "params": map[string]any{
+		"canvas_id":  "canvas-a",
+		"use_latest": true,
+	}}
+	makeCanvasWithDSL(t, "canvas-b", "user-1", "tenant-1", "v-b", subAgentDSL(loopBack, "{{sub_0@response}}"))
+	callChild := map[string]any{"component_name": "SubAgent", "params": map[string]any{
+		"canvas_id":  "canvas-b",
+		"version_id": "v-b",
+	}}
+	makeCanvasWithDSL(t, "canvas-a", "user-1", "tenant-1", "v-a", subAgentDSL(callChild, "{{sub_0@response}}"))
+
+	events, err := svc.RunAgent(context.Background(), "user-1", "canvas-a", "session-1", "", "hi")
+	if err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	var errMsg string
+	for _, ev := range collectRunEvents(t, events) {
+		if ev.Type == "error" {
+			errMsg = ev.Data
+		}
+	}
+	if !strings.Contains(errMsg, "recursive sub-agent invocation") {
+		t.Fatalf("error event = %q, want a recursion error", errMsg)
+	}
+}
+
+// TestRunSubAgent_RejectsForeignVersion: a pinned version must belong to
+// the referenced canvas.
+func TestRunSubAgent_RejectsForeignVersion(t *testing.T) {
+	svc := setupSubAgentTest(t)
+	makeCanvasWithDSL(t, "canvas-x", "user-1", "tenant-1", "v-x", helloTriggerDSL())
+	makeCanvasWithDSL(t, "canvas-y", "user-1", "tenant-1", "v-y", helloTriggerDSL())
+
+	_, err := svc.RunSubAgent(context.Background(), "user-1", "canvas-x", "v-y", "q", nil)
+	if err == nil || !strings.Contains(err.Error(), "belongs to canvas") {
+		t.Fatalf("RunSubAgent() error = %v, want foreign-version rejection", err)
+	}
+}
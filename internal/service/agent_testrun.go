@@ -0,0 +1,106 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"ragflow/internal/agent/canvas"
+	"ragflow/internal/agent/canvastest"
+	dslpkg "ragflow/internal/agent/dsl"
+)
+
+// ErrAgentTestSuiteInvalid is returned by RunAgentTests when the suite
+// cannot run against the canvas.
+var ErrAgentTestSuiteInvalid = errors.New("invalid agent test suite")
+
+// sideEffectComponents are the components whose run reaches outside the
+// canvas: SQL statements, HTTP requests, browser sessions, dataset
+// writes, child canvas runs, image generation, approval requests and
+// sandboxed code. A test run refuses them unless the case mocks their
+// outputs.
+var sideEffectComponents = map[string]bool{
+	"exesql":         true,
+	"invoke":         true,
+	"browser":        true,
+	"datasetwriter":  true,
+	"subagent":       true,
+	"imagegenerator": true,
+	"approval":       true,
+	"codeexec":       true,
+}
+
+// RunAgentTests runs a canvastest suite against a canvas the user can
+// see. The suite's own DSL, when set, is used instead of the saved
+// draft so an unsaved edit can be tested. Models and tools are fakes,
+// every side-effecting component must be mocked under
+// mocks.components, and no run is tracked.
+func (s *AgentService) RunAgentTests(ctx context.Context, userID, canvasID string, suite *canvastest.Suite) (*canvastest.Report, error) {
+	row, err := s.loadCanvasForUser(ctx, userID, canvasID)
+	if err != nil {
+		return nil, err
+	}
+	if suite == nil {
+		return nil, fmt.Errorf("%w: empty suite", ErrAgentTestSuiteInvalid)
+	}
+	if err := suite.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAgentTestSuiteInvalid, err)
+	}
+	src := map[string]any(row.DSL)
+	if len(suite.DSL) > 0 {
+		src = suite.DSL
+	}
+	if len(src) == 0 {
+		return nil, fmt.Errorf("%w: canvas has no DSL", ErrAgentTestSuiteInvalid)
+	}
+	dsl := dslpkg.NormalizeForRun(src)
+	cv, err := decodeCanvasFromDSL(dsl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAgentTestSuiteInvalid, err)
+	}
+	if err := checkSideEffectMocks(cv, suite); err != nil {
+		return nil, err
+	}
+	return canvastest.Run(ctx, suite, func() (*canvas.Canvas, error) {
+		return decodeCanvasFromDSL(dsl)
+	}), nil
+}
+
+// checkSideEffectMocks reports the first case that would run a
+// side-effecting component for real.
+func checkSideEffectMocks(cv *canvas.Canvas, suite *canvastest.Suite) error {
+	var ids []string
+	for id, cpn := range cv.Components {
+		if sideEffectComponents[strings.ToLower(cpn.Obj.ComponentName)] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, c := range suite.Cases {
+		for _, id := range ids {
+			if _, ok := c.Mocks.Components[id]; !ok {
+				return fmt.Errorf("%w: case %q: component %s (%s) has side effects and must be mocked under mocks.components",
+					ErrAgentTestSuiteInvalid, c.Name, id, cv.Components[id].Obj.ComponentName)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ragflow/internal/agent/canvastest"
+)
+
+func TestRunAgentTests(t *testing.T) {
+	setupCanvasServiceDB(t)
+	makeCanvasWithDSL(t, "test-canvas", "test-user", "test-tenant", "test-v1", nil)
+	svc := NewAgentService()
+	ctx := context.Background()
+
+	suite := &canvastest.Suite{Name: "hello", Cases: []canvastest.Case{
+		{Name: "greets", Query: "bob", Expect: canvastest.Expect{
+			AnswerContains: []string{"hello bob"},
+			Path:           []string{"begin_0", "message_0"},
+		}},
+		{Name: "wrong", Query: "bob", Expect: canvastest.Expect{AnswerContains: []string{"goodbye"}}},
+	}}
+
+	// The seeded draft is empty, so only a suite-supplied DSL can run.
+	if _, err := svc.RunAgentTests(ctx, "test-user", "test-canvas", suite); !errors.Is(err, ErrAgentTestSuiteInvalid) {
+		t.Fatalf("empty draft: err = %v, want ErrAgentTestSuiteInvalid", err)
+	}
+
+	suite.DSL = helloTriggerDSL()
+	report, err := svc.RunAgentTests(ctx, "test-user", "test-canvas", suite)
+	if err != nil {
+		t.Fatalf("RunAgentTests: %v", err)
+	}
+	if report.Total != 2 || report.Passed != 1 || report.Failed != 1 {
+		t.Fatalf("report = %+v, want 1 passed and 1 failed", report)
+	}
+	if !report.Cases[0].Passed || report.Cases[1].Passed {
+		t.Errorf("cases = %+v", report.Cases)
+	}
+
+	if _, err := svc.RunAgentTests(ctx, "someone-else", "test-canvas", suite); err == nil {
+		t.Fatalf("testing a canvas the user cannot see must fail")
+	}
+}
+
+func TestRunAgentTests_SideEffectsMustBeMocked(t *testing.T) {
+	setupCanvasServiceDB(t)
+	makeCanvasWithDSL(t, "test-canvas", "test-user", "test-tenant", "test-v1", nil)
+	svc := NewAgentService()
+	ctx := context.Background()
+
+	dsl := map[string]any{
+		"components": map[string]any{
+			"begin_0": map[string]any{
+				"obj":        map[string]any{"component_name": "Begin", "params": map[string]any{}},
+				"downstream": []any{"invoke_0"},
+			},
+			"invoke_0": map[string]any{
+				"obj":        map[string]any{"component_name": "Invoke", "params": map[string]any{"url": "http://example.invalid/hook", "method": "post"}},
+				"upstream":   []any{"begin_0"},
+				"downstream": []any{"message_0"},
+			},
+			"message_0": map[string]any{
+				"obj":      map[string]any{"component_name": "Message", "params": map[string]any{"text": "sent {{invoke_0@result}}"}},
+				"upstream": []any{"invoke_0"},
+			},
+		},
+		"path": []any{"begin_0"},
+	}
+	suite := &canvastest.Suite{Name: "hook", DSL: dsl, Cases: []canvastest.Case{
+		{Name: "posts", Query: "bob", Expect: canvastest.Expect{AnswerContains: []string{"sent ok"}}},
+	}}
+	if _, err := svc.RunAgentTests(ctx, "test-user", "test-canvas", suite); !errors.Is(err, ErrAgentTestSuiteInvalid) {
+		t.Fatalf("unmocked Invoke: err = %v, want ErrAgentTestSuiteInvalid", err)
+	}
+
+	suite.Cases[0].Mocks.Components = map[string]map[string]any{"invoke_0": {"result": "ok"}}
+	report, err := svc.RunAgentTests(ctx, "test-user", "test-canvas", suite)
+	if err != nil {
+		t.Fatalf("RunAgentTests: %v", err)
+	}
+	if report.Passed != 1 {
+		t.Fatalf("report = %+v, want the mocked case to pass", report.Cases)
+	}
+}
+
+func TestRunAgentTests_RefusesEachUnmockedSideEffect(t *testing.T) {
+	setupCanvasServiceDB(t)
+	makeCanvasWithDSL(t, "test-canvas", "test-user", "test-tenant", "test-v1", nil)
+	svc := NewAgentService()
+
+	for _, name := range []string{"ExeSQL", "Invoke", "Browser", "DatasetWriter", "SubAgent", "ImageGenerator", "Approval", "CodeExec"} {
+		t.Run(name, func(t *testing.T) {
+			dsl := map[string]any{
+				"components": map[string]any{
+					"begin_0": map[string]any{
+						"obj":        map[string]any{"component_name": "Begin", "params": map[string]any{}},
+						"downstream": []any{"node_0"},
+					},
+					"node_0": map[string]any{
+						"obj":        map[string]any{"component_name": name, "params": map[string]any{}},
+						"upstream":   []any{"begin_0"},
+						"downstream": []any{"message_0"},
+					},
+					"message_0": map[string]any{
+						"obj":      map[string]any{"component_name": "Message", "params": map[string]any{"text": "done"}},
+						"upstream": []any{"node_0"},
+					},
+				},
+				"path": []any{"begin_0"},
+			}
+			suite := &canvastest.Suite{Name: name, DSL: dsl, Cases: []canvastest.Case{
+				{Name: "unmocked", Query: "go", Expect: canvastest.Expect{AnswerContains: []string{"done"}}},
+			}}
+			_, err := svc.RunAgentTests(context.Background(), "test-user", "test-canvas", suite)
+			if !errors.Is(err, ErrAgentTestSuiteInvalid) || !strings.Contains(err.Error(), "node_0 ("+name+") has side effects") {
+				t.Fatalf("err = %v, want side-effect refusal for node_0", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+
+	"ragflow/internal/entity"
+)
+
+// setupCanvasServiceDB installs a test DB holding the tables every agent
+// service test needs (users, tenants, canvases and their versions) plus
+// the extra models a test names.
+func setupCanvasServiceDB(t *testing.T, extra ...interface{}) *gorm.DB {
+	t.Helper()
+	testDB := setupServiceTestDB(t)
+	models := append([]interface{}{
+		&entity.User{},
+		&entity.Tenant{},
+		&entity.UserTenant{},
+		&entity.UserCanvas{},
+		&entity.UserCanvasVersion{},
+	}, extra...)
+	if err := testDB.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// Each new connection to ":memory:" opens an empty database; agent
+	// runs and trigger firings query from background goroutines, so keep
+	// them all on the migrated one.
+	sqlDB, err := testDB.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	pushServiceDB(t, testDB)
+	return testDB
+}
@@ -0,0 +1,879 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ragflow/internal/agent/canvas"
+	agenttool "ragflow/internal/agent/tool"
+	"ragflow/internal/common"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+	"ragflow/internal/utility"
+)
+
+// AgentTriggerSessionSource is the api_4_conversation.source value of
+// sessions created by a trigger firing, so they can be told apart from
+// interactive, webhook and agentbot sessions.
+const AgentTriggerSessionSource = "trigger"
+
+const (
+	// agentTriggerRunTimeout bounds a single triggered run.
+	agentTriggerRunTimeout = 10 * time.Minute
+	// agentTriggerMaxQueued caps the firings a "queue" trigger holds while
+	// a previous run is still in progress; further firings are dropped.
+	agentTriggerMaxQueued = 8
+	// agentTriggerLeaseTTL is how long a run holds its trigger's
+	// concurrency slot without renewal; a third of it is the renewal
+	// interval.
+	agentTriggerLeaseTTL = time.Minute
+	// agentTriggerCASAttempts bounds the retries of a run queue update
+	// that lost a race with another replica.
+	agentTriggerCASAttempts = 5
+	// agentTriggerEventBatch caps how many events one watcher poll fires.
+	agentTriggerEventBatch = 20
+	// maxTriggerErrorLength matches the last_error column size.
+	maxTriggerErrorLength = 512
+)
+
+// ErrAgentTriggerInvalid is returned when a trigger request fails
+// validation. The handler maps it to an argument error carrying the
+// wrapped detail.
+var ErrAgentTriggerInvalid = errors.New("invalid agent trigger")
+
+// ErrAgentTriggerUnpublished is returned when a trigger is added to a
+// canvas that has no published version to bind to.
+var ErrAgentTriggerUnpublished = errors.New("agent must be published before adding a trigger")
+
+// triggerPayloadKey is the context key RunAgent reads to inject
+// root["trigger_payload"]. Only AgentTriggerScheduler sets it.
+type triggerPayloadKey struct{}
+
+// CreateAgentTriggerRequest is the wire shape for
+// POST /api/v1/agents/:canvas_id/triggers.
+type CreateAgentTriggerRequest struct {
+	Name              string         `json:"name"`
+	Type              string         `json:"type"`
+	VersionID         string         `json:"version_id"`
+	Cron              string         `json:"cron"`
+	Timezone          string         `json:"timezone"`
+	Event             string         `json:"event"`
+	EventFilter       entity.JSONMap `json:"event_filter"`
+	Query             string         `json:"query"`
+	ConcurrencyPolicy string         `json:"concurrency_policy"`
+}
+
+// CreateTrigger validates req and attaches a new trigger to a published
+// version of the canvas. An empty VersionID binds the latest version.
+func (s *AgentService) CreateTrigger(ctx context.Context, userID, canvasID string, req *CreateAgentTriggerRequest) (*entity.AgentTrigger, error) {
+	if _, err := s.loadCanvasForUser(ctx, userID, canvasID); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, fmt.Errorf("%w: empty request", ErrAgentTriggerInvalid)
+	}
+
+	var version *entity.UserCanvasVersion
+	var err error
+	if req.VersionID != "" {
+		version, err = s.versionDAO.GetByID(req.VersionID)
+		if err == nil && version.UserCanvasID != canvasID {
+			err = dao.ErrUserCanvasVersionNotFound
+		}
+	} else {
+		version, err = s.versionDAO.GetLatest(canvasID)
+		if errors.Is(err, dao.ErrUserCanvasVersionNotFound) {
+			return nil, ErrAgentTriggerUnpublished
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trigger := &entity.AgentTrigger{
+		ID:                genID32(),
+		UserCanvasID:      canvasID,
+		VersionID:         version.ID,
+		UserID:            userID,
+		Name:              strings.TrimSpace(req.Name),
+		TriggerType:       strings.TrimSpace(req.Type),
+		Cron:              strings.TrimSpace(req.Cron),
+		Timezone:          strings.TrimSpace(req.Timezone),
+		Event:             strings.TrimSpace(req.Event),
+		EventFilter:       req.EventFilter,
+		Query:             req.Query,
+		ConcurrencyPolicy: strings.TrimSpace(req.ConcurrencyPolicy),
+		Status:            entity.AgentTriggerStatusActive,
+	}
+	if err := normalizeAgentTrigger(trigger, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := checkAgentTriggerAccess(ctx, trigger); err != nil {
+		return nil, err
+	}
+	if err := s.triggerDAO.Create(trigger); err != nil {
+		return nil, fmt.Errorf("create agent trigger: %w", err)
+	}
+	return trigger, nil
+}
+
+// normalizeAgentTrigger fills defaults, validates the trigger and, for
+// schedule triggers, computes the first fire time after now.
+func normalizeAgentTrigger(t *entity.AgentTrigger, now time.Time) error {
+	if t.ConcurrencyPolicy == "" {
+		t.ConcurrencyPolicy = entity.AgentTriggerConcurrencySkip
+	}
+	switch t.ConcurrencyPolicy {
+	case entity.AgentTriggerConcurrencySkip, entity.AgentTriggerConcurrencyQueue, entity.AgentTriggerConcurrencyReplace:
+	default:
+		return fmt.Errorf("%w: concurrency_policy must be skip, queue or replace", ErrAgentTriggerInvalid)
+	}
+
+	switch t.TriggerType {
+	case entity.AgentTriggerTypeSchedule:
+		if t.Timezone == "" {
+			t.Timezone = "UTC"
+		}
+		next, err := nextAgentTriggerFire(t, now)
+		if err != nil {
+			return err
+		}
+		t.Event, t.EventFilter = "", nil
+		nextMillis := next.UnixMilli()
+		t.NextFireTime = &nextMillis
+	case entity.AgentTriggerTypeEvent:
+		t.Cron, t.Timezone = "", ""
+		switch t.Event {
+		case entity.AgentTriggerEventDocumentParsed:
+			if stringFromMap(t.EventFilter, "dataset_id") == "" {
+				return fmt.Errorf("%w: event_filter.dataset_id is required for %s", ErrAgentTriggerInvalid, t.Event)
+			}
+		case entity.AgentTriggerEventConnectorSynced:
+			if stringFromMap(t.EventFilter, "connector_id") == "" {
+				return fmt.Errorf("%w: event_filter.connector_id is required for %s", ErrAgentTriggerInvalid, t.Event)
+			}
+		case entity.AgentTriggerEventMemoryThreshold:
+			if stringFromMap(t.EventFilter, "memory_id") == "" {
+				return fmt.Errorf("%w: event_filter.memory_id is required for %s", ErrAgentTriggerInvalid, t.Event)
+			}
+			if threshold, ok := common.GetInt(t.EventFilter["threshold"]); !ok || threshold <= 0 {
+				return fmt.Errorf("%w: event_filter.threshold must be a positive integer", ErrAgentTriggerInvalid)
+			}
+		case entity.AgentTriggerEventEmailReceived:
+			if stringFromMap(t.EventFilter, "account_id") == "" {
+				return fmt.Errorf("%w: event_filter.account_id is required for %s", ErrAgentTriggerInvalid, t.Event)
+			}
+		default:
+			return fmt.Errorf("%w: unsupported event %q", ErrAgentTriggerInvalid, t.Event)
+		}
+	default:
+		return fmt.Errorf("%w: type must be schedule or event", ErrAgentTriggerInvalid)
+	}
+	return nil
+}
+
+// checkAgentTriggerAccess rejects an event trigger whose event_filter
+// names a dataset, connector or memory its user cannot access. It runs
+// when the trigger is created and again before every poll, so a user who
+// loses access to the resource also stops receiving its events.
+// email.received needs no check: the account is loaded scoped to the
+// user.
+func checkAgentTriggerAccess(ctx context.Context, t *entity.AgentTrigger) error {
+	if t.TriggerType != entity.AgentTriggerTypeEvent {
+		return nil
+	}
+	switch t.Event {
+	case entity.AgentTriggerEventDocumentParsed:
+		datasetID := stringFromMap(t.EventFilter, "dataset_id")
+		if !NewDatasetService().Accessible(datasetID, t.UserID) {
+			return fmt.Errorf("%w: no access to dataset %s", ErrAgentTriggerInvalid, datasetID)
+		}
+	case entity.AgentTriggerEventConnectorSynced:
+		connectorID := stringFromMap(t.EventFilter, "connector_id")
+		ok, err := NewConnectorService().accessible(connectorID, t.UserID)
+		if err != nil && !errors.Is(err, ErrConnectorNotFound) {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: no access to connector %s", ErrAgentTriggerInvalid, connectorID)
+		}
+	case entity.AgentTriggerEventMemoryThreshold:
+		memoryID := stringFromMap(t.EventFilter, "memory_id")
+		memories, err := NewMemoryService().filterAccessibleMemories(ctx, t.UserID, []string{memoryID})
+		if err != nil {
+			return err
+		}
+		if len(memories) == 0 {
+			return fmt.Errorf("%w: no access to memory %s", ErrAgentTriggerInvalid, memoryID)
+		}
+	}
+	return nil
+}
+
+// nextAgentTriggerFire returns the schedule trigger's next activation
+// strictly after now, evaluated in the trigger's timezone.
+func nextAgentTriggerFire(t *entity.AgentTrigger, now time.Time) (time.Time, error) {
+	schedule, err := utility.ParseCron(t.Cron)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrAgentTriggerInvalid, err)
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown timezone %q", ErrAgentTriggerInvalid, t.Timezone)
+	}
+	next := schedule.Next(now.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: cron %q never fires", ErrAgentTriggerInvalid, t.Cron)
+	}
+	return next, nil
+}
+
+// ListTriggers returns every trigger of a canvas the user can see.
+func (s *AgentService) ListTriggers(ctx context.Context, userID, canvasID string) ([]*entity.AgentTrigger, error) {
+	if _, err := s.loadCanvasForUser(ctx, userID, canvasID); err != nil {
+		return nil, err
+	}
+	return s.triggerDAO.ListByCanvasID(canvasID)
+}
+
+// SetTriggerStatus pauses or resumes a trigger. Resuming a schedule
+// trigger recomputes its next fire time so missed activations are not
+// replayed.
+func (s *AgentService) SetTriggerStatus(ctx context.Context, userID, canvasID, triggerID, status string) (*entity.AgentTrigger, error) {
+	trigger, err := s.loadTrigger(ctx, userID, canvasID, triggerID)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{"status": status}
+	switch status {
+	case entity.AgentTriggerStatusPaused:
+	case entity.AgentTriggerStatusActive:
+		if trigger.TriggerType == entity.AgentTriggerTypeSchedule {
+			next, err := nextAgentTriggerFire(trigger, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			nextMillis := next.UnixMilli()
+			trigger.NextFireTime = &nextMillis
+			fields["next_fire_time"] = nextMillis
+		}
+	default:
+		return nil, fmt.Errorf("%w: status must be active or paused", ErrAgentTriggerInvalid)
+	}
+	if err := s.triggerDAO.UpdateFields(trigger.ID, fields); err != nil {
+		return nil, fmt.Errorf("update agent trigger: %w", err)
+	}
+	trigger.Status = status
+	return trigger, nil
+}
+
+// DeleteTrigger removes a trigger of a canvas the user can see.
+func (s *AgentService) DeleteTrigger(ctx context.Context, userID, canvasID, triggerID string) error {
+	if _, err := s.loadTrigger(ctx, userID, canvasID, triggerID); err != nil {
+		return err
+	}
+	return s.triggerDAO.Delete(triggerID)
+}
+
+// loadTrigger loads a trigger and checks it belongs to a canvas the user
+// can see. A trigger of another canvas is reported as not found.
+func (s *AgentService) loadTrigger(ctx context.Context, userID, canvasID, triggerID string) (*entity.AgentTrigger, error) {
+	if _, err := s.loadCanvasForUser(ctx, userID, canvasID); err != nil {
+		return nil, err
+	}
+	trigger, err := s.triggerDAO.GetByID(triggerID)
+	if err != nil {
+		return nil, err
+	}
+	if trigger.UserCanvasID != canvasID {
+		return nil, dao.ErrAgentTriggerNotFound
+	}
+	return trigger, nil
+}
+
+// agentTriggerEventSource polls for occurrences of one event type since
+// the watermark in state. It returns one payload per occurrence and the
+// advanced watermark; an empty state only establishes the watermark so
+// history from before the trigger was created does not fire.
+type agentTriggerEventSource func(ctx context.Context, trigger *entity.AgentTrigger, state entity.JSONMap) ([]map[string]any, entity.JSONMap, error)
+
+// agentTriggerRun is an in-flight run this replica holds a trigger's
+// concurrency slot for. The slot itself lives on the trigger row
+// (run_owner, run_lease_until, run_queue) so every replica sees it.
+type agentTriggerRun struct {
+	owner  string
+	cancel context.CancelFunc
+}
+
+// AgentTriggerScheduler fires schedule and event triggers. Tick is run
+// periodically by a utility.ScheduledTask; every firing creates a normal
+// agent session with source "trigger" and runs the bound version.
+type AgentTriggerScheduler struct {
+	agents     *AgentService
+	triggerDAO *dao.AgentTriggerDAO
+	sources    map[string]agentTriggerEventSource
+	now        func() time.Time
+	// run executes one firing; tests replace it.
+	run func(ctx context.Context, trigger *entity.AgentTrigger, payload map[string]any)
+	// leaseRenew is how often a run renews its slot lease; zero means a
+	// third of agentTriggerLeaseTTL.
+	leaseRenew time.Duration
+
+	mu sync.Mutex
+	// running holds the runs this replica owns a trigger's slot for.
+	running map[string]*agentTriggerRun
+	wg      sync.WaitGroup
+}
+
+// NewAgentTriggerScheduler wires the built-in event watchers. memories
+// may be nil, in which case memory.threshold triggers never fire.
+func NewAgentTriggerScheduler(agents *AgentService, memories *MemoryService) *AgentTriggerScheduler {
+	s := &AgentTriggerScheduler{
+		agents:     agents,
+		triggerDAO: dao.NewAgentTriggerDAO(),
+		now:        time.Now,
+		running:    make(map[string]*agentTriggerRun),
+	}
+	s.sources = map[string]agentTriggerEventSource{
+		entity.AgentTriggerEventDocumentParsed:  documentParsedEvents(dao.NewDocumentDAO()),
+		entity.AgentTriggerEventConnectorSynced: connectorSyncedEvents(dao.NewConnectorDAO()),
+		entity.AgentTriggerEventEmailReceived:   emailReceivedEvents(agenttool.LoadEmailAccount, agenttool.DialEmailAccount),
+	}
+	if memories != nil {
+		s.sources[entity.AgentTriggerEventMemoryThreshold] = memoryThresholdEvents(memories.CountMemoryMessages)
+	}
+	s.run = s.runTrigger
+	return s
+}
+
+// Tick fires due schedule triggers and polls the event watchers once.
+func (s *AgentTriggerScheduler) Tick() {
+	ctx := context.Background()
+	now := s.now()
+
+	schedules, err := s.triggerDAO.ListActive(entity.AgentTriggerTypeSchedule)
+	if err != nil {
+		common.Warn("agent trigger: list schedule triggers failed", zap.Error(err))
+	}
+	for _, trigger := range schedules {
+		s.tickSchedule(trigger, now)
+	}
+
+	events, err := s.triggerDAO.ListActive(entity.AgentTriggerTypeEvent)
+	if err != nil {
+		common.Warn("agent trigger: list event triggers failed", zap.Error(err))
+	}
+	for _, trigger := range events {
+		s.tickEvent(ctx, trigger)
+	}
+
+	// Firings queued behind a run whose replica went away are picked up
+	// once that run's lease expires.
+	for _, trigger := range append(schedules, events...) {
+		s.resumeQueued(trigger, now)
+	}
+}
+
+func (s *AgentTriggerScheduler) tickSchedule(trigger *entity.AgentTrigger, now time.Time) {
+	next, err := nextAgentTriggerFire(trigger, now)
+	if err != nil {
+		s.recordError(trigger.ID, err)
+		return
+	}
+	nextMillis := next.UnixMilli()
+	due := trigger.NextFireTime != nil && *trigger.NextFireTime <= now.UnixMilli()
+	if trigger.NextFireTime != nil && !due {
+		return
+	}
+	// Every replica runs Tick; only the one whose claim moves
+	// next_fire_time fires this activation.
+	claimed, err := s.triggerDAO.ClaimNextFire(trigger.ID, trigger.NextFireTime, nextMillis)
+	if err != nil {
+		common.Warn("agent trigger: update next fire time failed", zap.String("trigger_id", trigger.ID), zap.Error(err))
+		return
+	}
+	if !claimed || !due {
+		return
+	}
+	scheduledAt := time.UnixMilli(*trigger.NextFireTime)
+	if loc, lerr := time.LoadLocation(trigger.Timezone); lerr == nil {
+		scheduledAt = scheduledAt.In(loc)
+	}
+	s.Fire(trigger, map[string]any{
+		"trigger_id":   trigger.ID,
+		"type":         entity.AgentTriggerTypeSchedule,
+		"cron":         trigger.Cron,
+		"scheduled_at": scheduledAt.Format(time.RFC3339),
+	})
+}
+
+func (s *AgentTriggerScheduler) tickEvent(ctx context.Context, trigger *entity.AgentTrigger) {
+	source, ok := s.sources[trigger.Event]
+	if !ok {
+		return
+	}
+	if err := checkAgentTriggerAccess(ctx, trigger); err != nil {
+		s.recordError(trigger.ID, err)
+		return
+	}
+	payloads, state, err := source(ctx, trigger, trigger.EventState)
+	if err != nil {
+		s.recordError(trigger.ID, err)
+		return
+	}
+	// As with schedules, the replica that stores the advanced watermark
+	// owns the events of this poll; the others drop theirs.
+	claimed, err := s.triggerDAO.AdvanceEventState(trigger.ID, trigger.EventSeq, state)
+	if err != nil {
+		common.Warn("agent trigger: update event state failed", zap.String("trigger_id", trigger.ID), zap.Error(err))
+		return
+	}
+	if !claimed {
+		return
+	}
+	for _, payload := range payloads {
+		payload["trigger_id"] = trigger.ID
+		payload["type"] = entity.AgentTriggerTypeEvent
+		payload["event"] = trigger.Event
+		s.Fire(trigger, payload)
+	}
+}
+
+// Fire starts a run for trigger, applying its concurrency policy when a
+// previous run, on this or another replica, is still in progress: skip
+// drops the firing, queue stores it on the trigger row to run after the
+// current one finishes, and replace takes the slot over and cancels the
+// current run.
+func (s *AgentTriggerScheduler) Fire(trigger *entity.AgentTrigger, payload map[string]any) {
+	now := s.now()
+	owner := genID32()
+	until := now.Add(agentTriggerLeaseTTL).UnixMilli()
+	claimed, err := s.triggerDAO.ClaimRun(trigger.ID, owner, now.UnixMilli(), until)
+	if err != nil {
+		common.Warn("agent trigger: claim run failed", zap.String("trigger_id", trigger.ID), zap.Error(err))
+		return
+	}
+	if !claimed {
+		switch trigger.ConcurrencyPolicy {
+		case entity.AgentTriggerConcurrencyQueue:
+			s.enqueue(trigger.ID, payload)
+			return
+		case entity.AgentTriggerConcurrencyReplace:
+			if err := s.triggerDAO.ReplaceRun(trigger.ID, owner, until); err != nil {
+				common.Warn("agent trigger: replace run failed", zap.String("trigger_id", trigger.ID), zap.Error(err))
+				return
+			}
+		default:
+			common.Info("agent trigger: previous run still in progress, firing skipped", zap.String("trigger_id", trigger.ID))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current := s.running[trigger.ID]; current != nil && current.cancel != nil {
+		// A replaced run on another replica is cancelled when its lease
+		// renewal fails; a local one is cancelled right away.
+		current.cancel()
+	}
+	run := &agentTriggerRun{owner: owner}
+	s.running[trigger.ID] = run
+	s.startLocked(run, trigger, payload)
+}
+
+// enqueue appends payload to the trigger's stored run queue.
+func (s *AgentTriggerScheduler) enqueue(triggerID string, payload map[string]any) {
+	for i := 0; i < agentTriggerCASAttempts; i++ {
+		row, err := s.triggerDAO.GetByID(triggerID)
+		if err != nil {
+			common.Warn("agent trigger: load run queue failed", zap.String("trigger_id", triggerID), zap.Error(err))
+			return
+		}
+		if len(row.RunQueue) >= agentTriggerMaxQueued {
+			common.Warn("agent trigger: queue full, firing dropped", zap.String("trigger_id", triggerID))
+			return
+		}
+		queue := append(append(entity.JSONSlice{}, row.RunQueue...), payload)
+		stored, err := s.triggerDAO.PushRunQueue(triggerID, row.RunSeq, queue)
+		if err != nil {
+			common.Warn("agent trigger: store run queue failed", zap.String("trigger_id", triggerID), zap.Error(err))
+			return
+		}
+		if stored {
+			return
+		}
+	}
+	common.Warn("agent trigger: run queue contended, firing dropped", zap.String("trigger_id", triggerID))
+}
+
+// resumeQueued starts the head of a trigger's stored queue when no live
+// run holds its slot, as after the replica that held it restarted.
+func (s *AgentTriggerScheduler) resumeQueued(trigger *entity.AgentTrigger, now time.Time) {
+	if len(trigger.RunQueue) == 0 || (trigger.RunOwner != "" && trigger.RunLeaseUntil >= now.UnixMilli()) {
+		return
+	}
+	s.mu.Lock()
+	busy := s.running[trigger.ID] != nil
+	s.mu.Unlock()
+	if busy {
+		return
+	}
+	owner := genID32()
+	claimed, err := s.triggerDAO.ClaimRun(trigger.ID, owner, now.UnixMilli(), now.Add(agentTriggerLeaseTTL).UnixMilli())
+	if err != nil {
+		common.Warn("agent trigger: claim run failed", zap.String("trigger_id", trigger.ID), zap.Error(err))
+		return
+	}
+	if !claimed {
+		return
+	}
+	run := &agentTriggerRun{owner: owner}
+	s.mu.Lock()
+	s.running[trigger.ID] = run
+	s.mu.Unlock()
+	s.next(run, trigger)
+}
+
+// startLocked launches one run; s.mu must be held.
+func (s *AgentTriggerScheduler) startLocked(run *agentTriggerRun, trigger *entity.AgentTrigger, payload map[string]any) {
+	ctx, cancel := context.WithTimeout(context.Background(), agentTriggerRunTimeout)
+	run.cancel = cancel
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		stop := s.keepLease(trigger.ID, run.owner, cancel)
+		s.run(ctx, trigger, payload)
+		stop()
+		s.next(run, trigger)
+	}()
+}
+
+// keepLease renews run owner's slot lease until the returned stop is
+// called, cancelling the run once another replica has replaced it.
+func (s *AgentTriggerScheduler) keepLease(triggerID, owner string, cancel context.CancelFunc) (stop func()) {
+	interval := s.leaseRenew
+	if interval <= 0 {
+		interval = agentTriggerLeaseTTL / 3
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			held, err := s.triggerDAO.RenewRun(triggerID, owner, s.now().Add(agentTriggerLeaseTTL).UnixMilli())
+			if err != nil {
+				common.Warn("agent trigger: renew run lease failed", zap.String("trigger_id", triggerID), zap.Error(err))
+				continue
+			}
+			if !held {
+				cancel()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// next hands run's slot to the first queued firing, or frees it when the
+// queue is empty. A run replaced in the meantime gives the slot up.
+func (s *AgentTriggerScheduler) next(run *agentTriggerRun, trigger *entity.AgentTrigger) {
+	for i := 0; i < agentTriggerCASAttempts; i++ {
+		s.mu.Lock()
+		replaced := s.running[trigger.ID] != run
+		s.mu.Unlock()
+		if replaced {
+			// A newer run on this replica owns the slot now.
+			return
+		}
+		row, err := s.triggerDAO.GetByID(trigger.ID)
+		if err != nil {
+			if !errors.Is(err, dao.ErrAgentTriggerNotFound) {
+				common.Warn("agent trigger: load run queue failed", zap.String("trigger_id", trigger.ID), zap.Error(err))
+			}
+			break
+		}
+		if row.RunOwner != run.owner {
+			break
+		}
+		if len(row.RunQueue) == 0 {
+			released, err := s.triggerDAO.ReleaseRun(trigger.ID, run.owner, row.RunSeq)
+			if err != nil {
+				common.Warn("agent trigger: release run failed", zap.String("trigger_id", trigger.ID), zap.Error(err))
+				break
+			}
+			if released {
+				break
+			}
+			continue
+		}
+		payload, _ := row.RunQueue[0].(map[string]interface{})
+		if payload == nil {
+			payload = map[string]any{}
+		}
+		rest := append(entity.JSONSlice{}, row.RunQueue[1:]...)
+		popped, err := s.triggerDAO.PopRunQueue(trigger.ID, run.owner, row.RunSeq, rest, s.now().Add(agentTriggerLeaseTTL).UnixMilli())
+		if err != nil {
+			common.Warn("agent trigger: pop run queue failed", zap.String("trigger_id", trigger.ID), zap.Error(err))
+			break
+		}
+		if !popped {
+			continue
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.running[trigger.ID] == run {
+			s.startLocked(run, trigger, payload)
+		}
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[trigger.ID] == run {
+		delete(s.running, trigger.ID)
+	}
+}
+
+// Wait blocks until every in-flight run has finished. Used on shutdown
+// and by tests.
+func (s *AgentTriggerScheduler) Wait() {
+	s.wg.Wait()
+}
+
+// runTrigger creates the trigger session, drives the run to completion
+// and stores the transcript on the session and the outcome on the trigger.
+func (s *AgentTriggerScheduler) runTrigger(ctx context.Context, trigger *entity.AgentTrigger, payload map[string]any) {
+	firedAt := s.now().UnixMilli()
+	name := trigger.Name
+	if name == "" {
+		name = trigger.TriggerType
+	}
+	session, _, err := s.agents.CreateAgentSession(&CreateAgentSessionRequest{
+		UserID:  trigger.UserID,
+		AgentID: trigger.UserCanvasID,
+		Name:    "trigger: " + name,
+		Source:  AgentTriggerSessionSource,
+	})
+	if err != nil {
+		s.recordRun(trigger.ID, firedAt, "", err)
+		return
+	}
+
+	ctx = context.WithValue(ctx, triggerPayloadKey{}, payload)
+	events, err := s.agents.RunAgent(ctx, trigger.UserID, trigger.UserCanvasID, session.ID, trigger.VersionID, trigger.Query)
+	var answer strings.Builder
+	var runErrors []string
+	if err != nil {
+		runErrors = append(runErrors, err.Error())
+	} else {
+		for ev := range events {
+			switch ev.Type {
+			case "message":
+				var msg canvas.MessageEvent
+				if json.Unmarshal([]byte(ev.Data), &msg) == nil {
+					answer.WriteString(msg.Content)
+				}
+			case "error":
+				var msg canvas.ErrorEvent
+				if json.Unmarshal([]byte(ev.Data), &msg) == nil && msg.Message != "" {
+					runErrors = append(runErrors, msg.Message)
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			runErrors = append(runErrors, ctx.Err().Error())
+		}
+	}
+
+	now := time.Now().Unix()
+	transcript, _ := json.Marshal([]map[string]any{
+		{"role": "user", "content": trigger.Query, "created_at": now},
+		{"role": "assistant", "content": answer.String(), "created_at": now},
+	})
+	session.Message = transcript
+	session.Round = 1
+	session.Duration = float64(s.now().UnixMilli()-firedAt) / 1000
+	var runErr error
+	if len(runErrors) > 0 {
+		joined := strings.Join(runErrors, "; ")
+		session.Errors = &joined
+		runErr = errors.New(joined)
+	}
+	if uerr := s.agents.api4ConversationDAO.Update(session); uerr != nil {
+		common.Warn("agent trigger: session update failed", zap.String("session_id", session.ID), zap.Error(uerr))
+	}
+	s.recordRun(trigger.ID, firedAt, session.ID, runErr)
+}
+
+func (s *AgentTriggerScheduler) recordRun(triggerID string, firedAt int64, sessionID string, runErr error) {
+	fields := map[string]interface{}{
+		"last_fire_time":  firedAt,
+		"last_session_id": sessionID,
+		"last_error":      "",
+	}
+	if runErr != nil {
+		fields["last_error"] = truncateTriggerError(runErr)
+	}
+	if err := s.triggerDAO.UpdateFields(triggerID, fields); err != nil {
+		common.Warn("agent trigger: record run failed", zap.String("trigger_id", triggerID), zap.Error(err))
+	}
+}
+
+func (s *AgentTriggerScheduler) recordError(triggerID string, err error) {
+	common.Warn("agent trigger: evaluation failed", zap.String("trigger_id", triggerID), zap.Error(err))
+	if uerr := s.triggerDAO.UpdateFields(triggerID, map[string]interface{}{"last_error": truncateTriggerError(err)}); uerr != nil {
+		common.Warn("agent trigger: record error failed", zap.String("trigger_id", triggerID), zap.Error(uerr))
+	}
+}
+
+func truncateTriggerError(err error) string {
+	msg := err.Error()
+	if len(msg) > maxTriggerErrorLength {
+		msg = msg[:maxTriggerErrorLength]
+	}
+	return msg
+}
+
+// watermarkFromState reads the (update_time, id) cursor an event watcher
+// stored on the previous poll. Rows are ordered by both columns so rows
+// sharing the boundary update_time are neither skipped nor fired twice.
+func watermarkFromState(state entity.JSONMap) (int64, string, bool) {
+	if state == nil {
+		return 0, "", false
+	}
+	afterID, _ := state["after_id"].(string)
+	switch v := state["since"].(type) {
+	case float64:
+		return int64(v), afterID, true
+	case int64:
+		return v, afterID, true
+	case int:
+		return int64(v), afterID, true
+	}
+	return 0, "", false
+}
+
+// documentParsedEvents fires once per document of event_filter.dataset_id
+// whose parsing finished since the previous poll.
+func documentParsedEvents(documents *dao.DocumentDAO) agentTriggerEventSource {
+	return func(_ context.Context, trigger *entity.AgentTrigger, state entity.JSONMap) ([]map[string]any, entity.JSONMap, error) {
+		since, afterID, ok := watermarkFromState(state)
+		if !ok {
+			return nil, entity.JSONMap{"since": time.Now().UnixMilli()}, nil
+		}
+		datasetID := stringFromMap(trigger.EventFilter, "dataset_id")
+		docs, err := documents.ListParsedSince(datasetID, since, afterID, agentTriggerEventBatch)
+		if err != nil {
+			return nil, state, err
+		}
+		payloads := make([]map[string]any, 0, len(docs))
+		for _, doc := range docs {
+			name := ""
+			if doc.Name != nil {
+				name = *doc.Name
+			}
+			payloads = append(payloads, map[string]any{
+				"dataset_id":    datasetID,
+				"document_id":   doc.ID,
+				"document_name": name,
+				"chunk_num":     doc.ChunkNum,
+			})
+			if doc.UpdateTime != nil {
+				since, afterID = *doc.UpdateTime, doc.ID
+			}
+		}
+		return payloads, entity.JSONMap{"since": since, "after_id": afterID}, nil
+	}
+}
+
+// connectorSyncedEvents fires once per completed sync run of
+// event_filter.connector_id since the previous poll.
+func connectorSyncedEvents(connectors *dao.ConnectorDAO) agentTriggerEventSource {
+	return func(_ context.Context, trigger *entity.AgentTrigger, state entity.JSONMap) ([]map[string]any, entity.JSONMap, error) {
+		since, afterID, ok := watermarkFromState(state)
+		if !ok {
+			return nil, entity.JSONMap{"since": time.Now().UnixMilli()}, nil
+		}
+		connectorID := stringFromMap(trigger.EventFilter, "connector_id")
+		logs, err := connectors.ListFinishedSyncLogsSince(connectorID, since, afterID, agentTriggerEventBatch)
+		if err != nil {
+			return nil, state, err
+		}
+		payloads := make([]map[string]any, 0, len(logs))
+		for _, log := range logs {
+			payloads = append(payloads, map[string]any{
+				"connector_id":       connectorID,
+				"dataset_id":         log.KbID,
+				"sync_log_id":        log.ID,
+				"new_docs_indexed":   log.NewDocsIndexed,
+				"total_docs_indexed": log.TotalDocsIndexed,
+				"error_count":        log.ErrorCount,
+			})
+			if log.UpdateTime != nil {
+				since, afterID = *log.UpdateTime, log.ID
+			}
+		}
+		return payloads, entity.JSONMap{"since": since, "after_id": afterID}, nil
+	}
+}
+
+// memoryThresholdEvents fires when the message count of
+// event_filter.memory_id rises to event_filter.threshold. It re-arms once
+// the count drops below the threshold again (e.g. after forgetting).
+func memoryThresholdEvents(count func(ctx context.Context, memoryID string) (int64, error)) agentTriggerEventSource {
+	return func(ctx context.Context, trigger *entity.AgentTrigger, state entity.JSONMap) ([]map[string]any, entity.JSONMap, error) {
+		memoryID := stringFromMap(trigger.EventFilter, "memory_id")
+		threshold, _ := common.GetInt(trigger.EventFilter["threshold"])
+		total, err := count(ctx, memoryID)
+		if err != nil {
+			return nil, state, err
+		}
+		reached := total >= int64(threshold)
+		next := entity.JSONMap{"reached": reached}
+		if state == nil {
+			return nil, next, nil
+		}
+		wasReached, _ := state["reached"].(bool)
+		if !reached || wasReached {
+			return nil, next, nil
+		}
+		return []map[string]any{{
+			"memory_id":     memoryID,
+			"threshold":     threshold,
+			"message_count": total,
+		}}, next, nil
+	}
+}
@@ -0,0 +1,170 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	agenttool "ragflow/internal/agent/tool"
+	"ragflow/internal/common"
+	"ragflow/internal/entity"
+	"ragflow/internal/utility"
+)
+
+// emailTriggerPollTimeout bounds one poll of an email.received trigger,
+// from connecting to fetching the new messages.
+const emailTriggerPollTimeout = 60 * time.Second
+
+// emailReceivedEvents fires once per message that arrives in the
+// mailbox of event_filter.account_id (event_filter.mailbox, default the
+// account's mailbox) and matches the optional from, to, subject and
+// text filters. The watermark is the mailbox's UIDVALIDITY and the last
+// UID seen; when UIDVALIDITY changes the mailbox was recreated and the
+// watermark restarts at its current end without firing.
+//
+// Payloads carry the decoded message with attachment metadata only;
+// the agent can read attachments with the account's imap tool.
+func emailReceivedEvents(
+	load func(ctx context.Context, tenantID, accountID string) (*agenttool.EmailAccountRecord, error),
+	dial func(ctx context.Context, account *agenttool.EmailAccountRecord) (*utility.IMAPClient, error),
+) agentTriggerEventSource {
+	return func(ctx context.Context, trigger *entity.AgentTrigger, state entity.JSONMap) ([]map[string]any, entity.JSONMap, error) {
+		accountID := stringFromMap(trigger.EventFilter, "account_id")
+		account, err := load(ctx, trigger.UserID, accountID)
+		if err != nil {
+			return nil, state, err
+		}
+		if account == nil {
+			return nil, state, fmt.Errorf("email account %s not found", accountID)
+		}
+		mailbox := stringFromMap(trigger.EventFilter, "mailbox")
+		if mailbox == "" {
+			mailbox = account.Mailbox
+		}
+		if mailbox == "" {
+			mailbox = "INBOX"
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, emailTriggerPollTimeout)
+		defer cancel()
+		client, err := dial(ctx, account)
+		if err != nil {
+			return nil, state, err
+		}
+		defer client.Close()
+		status, err := client.Examine(mailbox)
+		if err != nil {
+			return nil, state, err
+		}
+
+		validity, ok := common.GetInt(state["uid_validity"])
+		lastUID, _ := common.GetInt(state["last_uid"])
+		if !ok || uint32(validity) != status.UIDValidity {
+			end, err := mailboxLastUID(client, status, 0)
+			if err != nil {
+				return nil, state, err
+			}
+			return nil, emailTriggerState(status.UIDValidity, end), nil
+		}
+
+		uids, err := client.UIDSearch(utility.IMAPSearchCriteria{
+			AfterUID: uint32(lastUID),
+			From:     stringFromMap(trigger.EventFilter, "from"),
+			To:       stringFromMap(trigger.EventFilter, "to"),
+			Subject:  stringFromMap(trigger.EventFilter, "subject"),
+			Text:     stringFromMap(trigger.EventFilter, "text"),
+		})
+		if err != nil {
+			return nil, state, err
+		}
+		end, err := mailboxLastUID(client, status, uint32(lastUID))
+		if err != nil {
+			return nil, state, err
+		}
+		if len(uids) > agentTriggerEventBatch {
+			// The rest is picked up by the next poll.
+			uids = uids[:agentTriggerEventBatch]
+			end = uids[len(uids)-1]
+		}
+		messages, err := client.UIDFetch(uids, false)
+		if err != nil {
+			return nil, state, err
+		}
+
+		payloads := make([]map[string]any, 0, len(messages))
+		for _, m := range messages {
+			detail, err := agenttool.NewEmailMessageDetail(m, false)
+			if err != nil {
+				common.Warn("agent trigger: skip undecodable email",
+					zap.String("trigger_id", trigger.ID), zap.Uint32("uid", m.UID), zap.Error(err))
+				continue
+			}
+			payloads = append(payloads, emailReceivedPayload(account.ID, mailbox, detail))
+		}
+		return payloads, emailTriggerState(status.UIDValidity, end), nil
+	}
+}
+
+// mailboxLastUID is the highest UID in the mailbox, never below after.
+// It comes from UIDNEXT, or from a search when the server omits it.
+func mailboxLastUID(client *utility.IMAPClient, status *utility.IMAPMailboxStatus, after uint32) (uint32, error) {
+	if status.UIDNext > 0 {
+		return max(status.UIDNext-1, after), nil
+	}
+	uids, err := client.UIDSearch(utility.IMAPSearchCriteria{AfterUID: after})
+	if err != nil {
+		return 0, err
+	}
+	if len(uids) == 0 {
+		return after, nil
+	}
+	return uids[len(uids)-1], nil
+}
+
+func emailTriggerState(uidValidity, lastUID uint32) entity.JSONMap {
+	return entity.JSONMap{"uid_validity": int(uidValidity), "last_uid": int(lastUID)}
+}
+
+func emailReceivedPayload(accountID, mailbox string, msg *agenttool.EmailMessageDetail) map[string]any {
+	attachments := make([]map[string]any, 0, len(msg.Attachments))
+	for _, a := range msg.Attachments {
+		attachments = append(attachments, map[string]any{
+			"filename":     a.Filename,
+			"content_type": a.ContentType,
+			"size":         a.Size,
+		})
+	}
+	return map[string]any{
+		"account_id":  accountID,
+		"mailbox":     mailbox,
+		"uid":         int(msg.UID),
+		"message_id":  msg.MessageID,
+		"in_reply_to": msg.InReplyTo,
+		"from":        msg.From,
+		"to":          msg.To,
+		"cc":          msg.Cc,
+		"subject":     msg.Subject,
+		"date":        msg.Date,
+		"text":        msg.Text,
+		"truncated":   msg.Truncated,
+		"attachments": attachments,
+	}
+}
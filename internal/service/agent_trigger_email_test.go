@@ -0,0 +1,138 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	agenttool "ragflow/internal/agent/tool"
+	"ragflow/internal/entity"
+	"ragflow/internal/utility/imaptest"
+)
+
+func testInboundMail(from, subject string) []byte {
+	return []byte(fmt.Sprintf("From: %s\r\nTo: support@example.com\r\nSubject: %s\r\n"+
+		"Message-ID: <%s@example.com>\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n"+
+		"--b\r\nContent-Type: text/plain\r\n\r\nPlease help.\r\n"+
+		"--b\r\nContent-Type: text/csv\r\nContent-Disposition: attachment; filename=\"log.csv\"\r\n\r\na,b\r\n--b--\r\n",
+		from, subject, subject))
+}
+
+func setupEmailTriggerSource(t *testing.T) (*imaptest.Server, agentTriggerEventSource) {
+	t.Helper()
+	srv, err := imaptest.NewServer(imaptest.SecurityStartTLS, "support@example.com", "pw")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	load := func(_ context.Context, tenantID, accountID string) (*agenttool.EmailAccountRecord, error) {
+		if tenantID != "user-1" || accountID != "acc-1" {
+			return nil, nil
+		}
+		return &agenttool.EmailAccountRecord{
+			ID:        accountID,
+			Host:      srv.Host,
+			Port:      srv.Port,
+			Security:  imaptest.SecurityStartTLS,
+			Username:  srv.Username,
+			Password:  srv.Password,
+			Mailbox:   "INBOX",
+			TLSConfig: srv.ClientTLSConfig(),
+		}, nil
+	}
+	return srv, emailReceivedEvents(load, agenttool.DialEmailAccount)
+}
+
+func TestEmailReceivedEvents(t *testing.T) {
+	srv, source := setupEmailTriggerSource(t)
+	ctx := context.Background()
+	trigger := &entity.AgentTrigger{
+		ID:          "trigger-1",
+		UserID:      "user-1",
+		EventFilter: entity.JSONMap{"account_id": "acc-1", "subject": "refund"},
+	}
+
+	srv.Deliver("INBOX", testInboundMail("old@example.com", "refund-old"))
+	payloads, state, err := source(ctx, trigger, nil)
+	if err != nil || len(payloads) != 0 {
+		t.Fatalf("first poll = %v, %v; want only a watermark", payloads, err)
+	}
+
+	srv.Deliver("INBOX", testInboundMail("a@example.com", "refund-1"))
+	srv.Deliver("INBOX", testInboundMail("b@example.com", "hello"))
+	payloads, state, err = source(ctx, trigger, state)
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if len(payloads) != 1 {
+		t.Fatalf("payloads = %v, want only refund-1", payloads)
+	}
+	p := payloads[0]
+	if p["subject"] != "refund-1" || p["from"] != "a@example.com" || p["uid"] != 2 || p["text"] != "Please help." || p["account_id"] != "acc-1" {
+		t.Fatalf("payload = %v", p)
+	}
+	if atts := p["attachments"].([]map[string]any); len(atts) != 1 || atts[0]["filename"] != "log.csv" {
+		t.Fatalf("attachments = %v", p["attachments"])
+	}
+	if state["last_uid"] != 3 {
+		t.Fatalf("state = %v, want last_uid 3", state)
+	}
+	if flags := srv.Flags("INBOX", 2); len(flags) != 0 {
+		t.Fatalf("polling marked mail as seen: %v", flags)
+	}
+	if payloads, state, _ = source(ctx, trigger, state); len(payloads) != 0 {
+		t.Fatalf("repeat poll = %v, want nothing new", payloads)
+	}
+
+	// A JSON round trip through the trigger row turns numbers into float64.
+	state = entity.JSONMap{"uid_validity": float64(state["uid_validity"].(int)), "last_uid": float64(3)}
+	for i := 0; i < agentTriggerEventBatch+2; i++ {
+		srv.Deliver("INBOX", testInboundMail("c@example.com", fmt.Sprintf("refund-%d", i+10)))
+	}
+	payloads, state, err = source(ctx, trigger, state)
+	if err != nil || len(payloads) != agentTriggerEventBatch {
+		t.Fatalf("batch poll = %d payloads, %v", len(payloads), err)
+	}
+	payloads, state, _ = source(ctx, trigger, state)
+	if len(payloads) != 2 || payloads[1]["uid"] != 3+agentTriggerEventBatch+2 {
+		t.Fatalf("remainder poll = %v", payloads)
+	}
+
+	// A recreated mailbox restarts the watermark instead of replaying.
+	srv.AddMailbox("INBOX", 99)
+	srv.Deliver("INBOX", testInboundMail("d@example.com", "refund-new-box"))
+	payloads, state, err = source(ctx, trigger, state)
+	if err != nil || len(payloads) != 0 || state["uid_validity"] != 99 || state["last_uid"] != 1 {
+		t.Fatalf("uidvalidity change = %v, %v, %v", payloads, state, err)
+	}
+}
+
+func TestEmailReceivedEvents_Errors(t *testing.T) {
+	_, source := setupEmailTriggerSource(t)
+	state := entity.JSONMap{"uid_validity": 1, "last_uid": 0}
+
+	missing := &entity.AgentTrigger{UserID: "user-2", EventFilter: entity.JSONMap{"account_id": "acc-1"}}
+	if _, next, err := source(context.Background(), missing, state); err == nil || next["last_uid"] != 0 {
+		t.Fatalf("other tenant's account: err = %v, state = %v", err, next)
+	}
+	badBox := &entity.AgentTrigger{UserID: "user-1", EventFilter: entity.JSONMap{"account_id": "acc-1", "mailbox": "Nope"}}
+	if _, _, err := source(context.Background(), badBox, state); err == nil {
+		t.Fatal("missing mailbox: expected an error")
+	}
+}
@@ -0,0 +1,525 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+func setupAgentTriggerTest(t *testing.T) {
+	t.Helper()
+	setupCanvasServiceDB(t,
+		&entity.AgentTrigger{},
+		&entity.API4Conversation{},
+		&entity.Document{},
+		&entity.Knowledgebase{},
+		&entity.Connector{},
+		&entity.Memory{},
+	)
+}
+
+func helloTriggerDSL() map[string]any {
+	return map[string]any{
+		"components": map[string]any{
+			"begin_0": map[string]any{
+				"obj":        map[string]any{"component_name": "Begin", "params": map[string]any{}},
+				"downstream": []any{"message_0"},
+			},
+			"message_0": map[string]any{
+				"obj":      map[string]any{"component_name": "Message", "params": map[string]any{"text": "hello {{sys.query}}"}},
+				"upstream": []any{"begin_0"},
+			},
+		},
+		"path": []any{"begin_0", "message_0"},
+	}
+}
+
+func TestCreateTrigger_RequiresPublishedVersion(t *testing.T) {
+	setupAgentTriggerTest(t)
+	createAgentSessionTestCanvas(t, "canvas-1", "user-1")
+
+	_, err := NewAgentService().CreateTrigger(context.Background(), "user-1", "canvas-1", &CreateAgentTriggerRequest{
+		Type: entity.AgentTriggerTypeSchedule,
+		Cron: "*/5 * * * *",
+	})
+	if !errors.Is(err, ErrAgentTriggerUnpublished) {
+		t.Fatalf("CreateTrigger() error = %v, want ErrAgentTriggerUnpublished", err)
+	}
+}
+
+func TestCreateTrigger_Validation(t *testing.T) {
+	setupAgentTriggerTest(t)
+	makeCanvasWithDSL(t, "canvas-1", "user-1", "tenant-1", "v1", helloTriggerDSL())
+	svc := NewAgentService()
+
+	cases := []struct {
+		name string
+		req  CreateAgentTriggerRequest
+	}{
+		{"bad cron", CreateAgentTriggerRequest{Type: "schedule", Cron: "every minute"}},
+		{"bad timezone", CreateAgentTriggerRequest{Type: "schedule", Cron: "0 * * * *", Timezone: "Mars/Olympus"}},
+		{"bad policy", CreateAgentTriggerRequest{Type: "schedule", Cron: "0 * * * *", ConcurrencyPolicy: "parallel"}},
+		{"unknown event", CreateAgentTriggerRequest{Type: "event", Event: "user.signup"}},
+		{"missing dataset", CreateAgentTriggerRequest{Type: "event", Event: entity.AgentTriggerEventDocumentParsed}},
+		{"missing threshold", CreateAgentTriggerRequest{Type: "event", Event: entity.AgentTriggerEventMemoryThreshold, EventFilter: entity.JSONMap{"memory_id": "m1"}}},
+		{"missing email account", CreateAgentTriggerRequest{Type: "event", Event: entity.AgentTriggerEventEmailReceived, EventFilter: entity.JSONMap{"mailbox": "INBOX"}}},
+		{"unknown type", CreateAgentTriggerRequest{Type: "manual"}},
+	}
+	for _, tc := range cases {
+		req := tc.req
+		if _, err := svc.CreateTrigger(context.Background(), "user-1", "canvas-1", &req); !errors.Is(err, ErrAgentTriggerInvalid) {
+			t.Errorf("%s: error = %v, want ErrAgentTriggerInvalid", tc.name, err)
+		}
+	}
+}
+
+func TestCreateTrigger_EventFilterAccess(t *testing.T) {
+	setupAgentTriggerTest(t)
+	makeCanvasWithDSL(t, "canvas-1", "user-1", "tenant-1", "v1", helloTriggerDSL())
+	svc := NewAgentService()
+	ctx := context.Background()
+
+	valid := string(entity.StatusValid)
+	for _, row := range []any{
+		&entity.Knowledgebase{ID: "kb-own", TenantID: "user-1", Name: "own", CreatedBy: "user-1", Permission: "me", Status: &valid},
+		&entity.Knowledgebase{ID: "kb-other", TenantID: "user-2", Name: "other", CreatedBy: "user-2", Permission: "me", Status: &valid},
+		&entity.Connector{ID: "conn-other", TenantID: "user-2", Name: "other", Source: "s3", InputType: "poll", Config: entity.JSONMap{}},
+		&entity.Memory{ID: "mem-other", TenantID: "user-2", Name: "other", Permissions: "me"},
+	} {
+		if err := dao.DB.Create(row).Error; err != nil {
+			t.Fatalf("seed %T: %v", row, err)
+		}
+	}
+
+	denied := []CreateAgentTriggerRequest{
+		{Type: "event", Event: entity.AgentTriggerEventDocumentParsed, EventFilter: entity.JSONMap{"dataset_id": "kb-other"}},
+		{Type: "event", Event: entity.AgentTriggerEventDocumentParsed, EventFilter: entity.JSONMap{"dataset_id": "kb-missing"}},
+		{Type: "event", Event: entity.AgentTriggerEventConnectorSynced, EventFilter: entity.JSONMap{"connector_id": "conn-other"}},
+		{Type: "event", Event: entity.AgentTriggerEventMemoryThreshold, EventFilter: entity.JSONMap{"memory_id": "mem-other", "threshold": float64(3)}},
+	}
+	for _, req := range denied {
+		if _, err := svc.CreateTrigger(ctx, "user-1", "canvas-1", &req); !errors.Is(err, ErrAgentTriggerInvalid) {
+			t.Errorf("%s %v: error = %v, want ErrAgentTriggerInvalid", req.Event, req.EventFilter, err)
+		}
+	}
+
+	trigger, err := svc.CreateTrigger(ctx, "user-1", "canvas-1", &CreateAgentTriggerRequest{
+		Type: "event", Event: entity.AgentTriggerEventDocumentParsed, EventFilter: entity.JSONMap{"dataset_id": "kb-own"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTrigger(own dataset) error = %v", err)
+	}
+
+	// Losing access later stops the watcher before it polls.
+	if err := dao.DB.Model(&entity.Knowledgebase{}).Where("id = ?", "kb-own").Update("tenant_id", "user-2").Error; err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	scheduler := NewAgentTriggerScheduler(svc, nil)
+	polled := false
+	scheduler.sources[entity.AgentTriggerEventDocumentParsed] = func(context.Context, *entity.AgentTrigger, entity.JSONMap) ([]map[string]any, entity.JSONMap, error) {
+		polled = true
+		return nil, nil, nil
+	}
+	scheduler.Tick()
+	if polled {
+		t.Fatal("source polled after access was revoked")
+	}
+	stored, err := dao.NewAgentTriggerDAO().GetByID(trigger.ID)
+	if err != nil || !strings.Contains(stored.LastError, "no access to dataset") {
+		t.Fatalf("last_error = %q, %v; want the access error", stored.LastError, err)
+	}
+}
+
+func TestCreateTrigger_ScheduleAndPause(t *testing.T) {
+	setupAgentTriggerTest(t)
+	makeCanvasWithDSL(t, "canvas-1", "user-1", "tenant-1", "v1", helloTriggerDSL())
+	svc := NewAgentService()
+	ctx := context.Background()
+
+	trigger, err := svc.CreateTrigger(ctx, "user-1", "canvas-1", &CreateAgentTriggerRequest{
+		Name:     "nightly",
+		Type:     entity.AgentTriggerTypeSchedule,
+		Cron:     "0 2 * * *",
+		Timezone: "Asia/Shanghai",
+	})
+	if err != nil {
+		t.Fatalf("CreateTrigger() error = %v", err)
+	}
+	if trigger.VersionID != "v1" || trigger.ConcurrencyPolicy != entity.AgentTriggerConcurrencySkip {
+		t.Fatalf("trigger = %+v, want latest version and skip policy", trigger)
+	}
+	if trigger.NextFireTime == nil || *trigger.NextFireTime <= time.Now().UnixMilli() {
+		t.Fatalf("next_fire_time = %v, want a future time", trigger.NextFireTime)
+	}
+
+	paused, err := svc.SetTriggerStatus(ctx, "user-1", "canvas-1", trigger.ID, entity.AgentTriggerStatusPaused)
+	if err != nil || paused.Status != entity.AgentTriggerStatusPaused {
+		t.Fatalf("pause: trigger = %+v, err = %v", paused, err)
+	}
+	active, err := NewAgentTriggerScheduler(svc, nil).triggerDAO.ListActive(entity.AgentTriggerTypeSchedule)
+	if err != nil || len(active) != 0 {
+		t.Fatalf("ListActive() = %v, %v; want no active triggers while paused", active, err)
+	}
+
+	listed, err := svc.ListTriggers(ctx, "user-1", "canvas-1")
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("ListTriggers() = %v, %v", listed, err)
+	}
+
+	createAgentSessionTestCanvas(t, "canvas-2", "user-1")
+	if _, err := svc.SetTriggerStatus(ctx, "user-1", "canvas-2", trigger.ID, entity.AgentTriggerStatusActive); !errors.Is(err, dao.ErrAgentTriggerNotFound) {
+		t.Fatalf("resume via other canvas: error = %v, want ErrAgentTriggerNotFound", err)
+	}
+}
+
+func TestAgentTriggerScheduler_ScheduleFiresTriggerSession(t *testing.T) {
+	setupAgentTriggerTest(t)
+	makeCanvasWithDSL(t, "canvas-1", "user-1", "tenant-1", "v1", helloTriggerDSL())
+	svc := NewAgentService()
+
+	trigger, err := svc.CreateTrigger(context.Background(), "user-1", "canvas-1", &CreateAgentTriggerRequest{
+		Type:  entity.AgentTriggerTypeSchedule,
+		Cron:  "* * * * *",
+		Query: "world",
+	})
+	if err != nil {
+		t.Fatalf("CreateTrigger() error = %v", err)
+	}
+
+	scheduler := NewAgentTriggerScheduler(svc, nil)
+	scheduler.now = func() time.Time { return time.UnixMilli(*trigger.NextFireTime).Add(time.Second) }
+	scheduler.Tick()
+	scheduler.Wait()
+
+	stored, err := dao.NewAgentTriggerDAO().GetByID(trigger.ID)
+	if err != nil {
+		t.Fatalf("reload trigger: %v", err)
+	}
+	if stored.LastSessionID == "" || stored.LastError != "" {
+		t.Fatalf("trigger last run = %q / %q, want a session and no error", stored.LastSessionID, stored.LastError)
+	}
+	if *stored.NextFireTime <= *trigger.NextFireTime {
+		t.Fatalf("next_fire_time not advanced: %d <= %d", *stored.NextFireTime, *trigger.NextFireTime)
+	}
+
+	session, err := dao.NewAPI4ConversationDAO().GetBySessionID(stored.LastSessionID, "canvas-1")
+	if err != nil || session == nil {
+		t.Fatalf("load session: %v", err)
+	}
+	if session.Source == nil || *session.Source != AgentTriggerSessionSource {
+		t.Fatalf("session source = %v, want %q", session.Source, AgentTriggerSessionSource)
+	}
+	if !strings.Contains(string(session.Message), "hello world") {
+		t.Fatalf("session message = %s, want the run answer", session.Message)
+	}
+}
+
+func TestAgentTriggerScheduler_ReplicasFireOnce(t *testing.T) {
+	setupAgentTriggerTest(t)
+	triggers := dao.NewAgentTriggerDAO()
+	firstFire := time.Now().Add(-time.Second).UnixMilli()
+	for _, row := range []*entity.AgentTrigger{
+		{ID: "sched", UserCanvasID: "canvas-1", VersionID: "v1", UserID: "user-1", TriggerType: entity.AgentTriggerTypeSchedule,
+			Cron: "* * * * *", Timezone: "UTC", NextFireTime: &firstFire, Status: entity.AgentTriggerStatusActive},
+		{ID: "event", UserCanvasID: "canvas-1", VersionID: "v1", UserID: "user-1", TriggerType: entity.AgentTriggerTypeEvent,
+			Event: "test.event", EventState: entity.JSONMap{"n": float64(0)}, Status: entity.AgentTriggerStatusActive},
+	} {
+		if err := triggers.Create(row); err != nil {
+			t.Fatalf("seed %s: %v", row.ID, err)
+		}
+	}
+	// Both replicas act on the rows as listed before either of them
+	// stored anything, as happens when their ticks overlap.
+	sched, _ := triggers.GetByID("sched")
+	event, _ := triggers.GetByID("event")
+
+	var mu sync.Mutex
+	fired := map[string]int{}
+	for i := 0; i < 2; i++ {
+		replica := &AgentTriggerScheduler{triggerDAO: triggers, now: time.Now, running: map[string]*agentTriggerRun{}}
+		replica.sources = map[string]agentTriggerEventSource{
+			"test.event": func(context.Context, *entity.AgentTrigger, entity.JSONMap) ([]map[string]any, entity.JSONMap, error) {
+				return []map[string]any{{}}, entity.JSONMap{"n": float64(1)}, nil
+			},
+		}
+		replica.run = func(_ context.Context, trigger *entity.AgentTrigger, _ map[string]any) {
+			mu.Lock()
+			fired[trigger.ID]++
+			mu.Unlock()
+		}
+		replica.tickSchedule(sched, time.Now())
+		replica.tickEvent(context.Background(), event)
+		replica.Wait()
+	}
+	if fired["sched"] != 1 || fired["event"] != 1 {
+		t.Fatalf("fired = %v, want each trigger once across replicas", fired)
+	}
+}
+
+func TestAgentTriggerScheduler_ConcurrencyPolicies(t *testing.T) {
+	cases := []struct {
+		policy string
+		want   []string
+	}{
+		{entity.AgentTriggerConcurrencySkip, []string{"first"}},
+		{entity.AgentTriggerConcurrencyQueue, []string{"first", "second"}},
+		{entity.AgentTriggerConcurrencyReplace, []string{"first", "second"}},
+	}
+	for _, replicas := range []int{1, 2} {
+		for _, tc := range cases {
+			t.Run(fmt.Sprintf("%s/%d-replicas", tc.policy, replicas), func(t *testing.T) {
+				setupAgentTriggerTest(t)
+				triggers := dao.NewAgentTriggerDAO()
+				trigger := &entity.AgentTrigger{ID: "t1", UserCanvasID: "canvas-1", VersionID: "v1", UserID: "user-1",
+					TriggerType: entity.AgentTriggerTypeEvent, ConcurrencyPolicy: tc.policy, Status: entity.AgentTriggerStatusActive}
+				if err := triggers.Create(trigger); err != nil {
+					t.Fatalf("seed trigger: %v", err)
+				}
+
+				release := make(chan struct{})
+				started := make(chan string, 4)
+				stopped := make(chan string, 4)
+				var mu sync.Mutex
+				var completed []string
+				var cancelled []string
+				run := func(ctx context.Context, _ *entity.AgentTrigger, payload map[string]any) {
+					name := payload["name"].(string)
+					started <- name
+					select {
+					case <-release:
+					case <-ctx.Done():
+						mu.Lock()
+						cancelled = append(cancelled, name)
+						mu.Unlock()
+						stopped <- name
+						return
+					}
+					mu.Lock()
+					completed = append(completed, name)
+					mu.Unlock()
+				}
+				// With two replicas the second firing lands on the replica
+				// that does not run the first one.
+				schedulers := make([]*AgentTriggerScheduler, replicas)
+				for i := range schedulers {
+					schedulers[i] = &AgentTriggerScheduler{triggerDAO: triggers, now: time.Now, running: map[string]*agentTriggerRun{},
+						run: run, leaseRenew: 10 * time.Millisecond}
+				}
+
+				schedulers[0].Fire(trigger, map[string]any{"name": "first"})
+				<-started
+				schedulers[replicas-1].Fire(trigger, map[string]any{"name": "second"})
+				if tc.policy == entity.AgentTriggerConcurrencyReplace {
+					<-started
+					// Across replicas the replaced run learns of it on its
+					// next lease renewal.
+					select {
+					case <-stopped:
+					case <-time.After(5 * time.Second):
+						t.Fatal("replaced run was not cancelled")
+					}
+				}
+				close(release)
+				for _, scheduler := range schedulers {
+					scheduler.Wait()
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				var ran []string
+				ran = append(ran, cancelled...)
+				ran = append(ran, completed...)
+				if strings.Join(ran, ",") != strings.Join(tc.want, ",") {
+					t.Fatalf("runs = %v, want %v", ran, tc.want)
+				}
+				if tc.policy == entity.AgentTriggerConcurrencyReplace && (len(cancelled) != 1 || cancelled[0] != "first") {
+					t.Fatalf("cancelled = %v, want [first]", cancelled)
+				}
+				for _, scheduler := range schedulers {
+					if len(scheduler.running) != 0 {
+						t.Fatalf("running = %v, want empty after completion", scheduler.running)
+					}
+				}
+				stored, err := triggers.GetByID("t1")
+				if err != nil {
+					t.Fatalf("reload trigger: %v", err)
+				}
+				if stored.RunOwner != "" || len(stored.RunQueue) != 0 {
+					t.Fatalf("slot = %q queue = %v, want released and empty", stored.RunOwner, stored.RunQueue)
+				}
+			})
+		}
+	}
+}
+
+func TestAgentTriggerScheduler_QueueSurvivesRestart(t *testing.T) {
+	setupAgentTriggerTest(t)
+	triggers := dao.NewAgentTriggerDAO()
+	now := time.Now()
+	for _, row := range []*entity.AgentTrigger{
+		// Held by a replica that went away: its lease has expired.
+		{ID: "orphaned", RunOwner: "crashed", RunLeaseUntil: now.Add(-time.Second).UnixMilli()},
+		// Held by a live run elsewhere: its queue is that run's to drain.
+		{ID: "held", RunOwner: "alive", RunLeaseUntil: now.Add(time.Minute).UnixMilli()},
+	} {
+		row.UserCanvasID, row.VersionID, row.UserID = "canvas-1", "v1", "user-1"
+		row.TriggerType, row.Status = entity.AgentTriggerTypeEvent, entity.AgentTriggerStatusActive
+		row.ConcurrencyPolicy = entity.AgentTriggerConcurrencyQueue
+		row.RunQueue = entity.JSONSlice{map[string]any{"name": row.ID}}
+		if err := triggers.Create(row); err != nil {
+			t.Fatalf("seed %s: %v", row.ID, err)
+		}
+	}
+
+	var mu sync.Mutex
+	var ran []string
+	scheduler := &AgentTriggerScheduler{triggerDAO: triggers, now: time.Now, running: map[string]*agentTriggerRun{},
+		sources: map[string]agentTriggerEventSource{}}
+	scheduler.run = func(_ context.Context, _ *entity.AgentTrigger, payload map[string]any) {
+		mu.Lock()
+		ran = append(ran, payload["name"].(string))
+		mu.Unlock()
+	}
+	scheduler.Tick()
+	scheduler.Wait()
+
+	if strings.Join(ran, ",") != "orphaned" {
+		t.Fatalf("runs = %v, want [orphaned]", ran)
+	}
+	orphaned, _ := triggers.GetByID("orphaned")
+	if orphaned.RunOwner != "" || len(orphaned.RunQueue) != 0 {
+		t.Fatalf("orphaned slot = %q queue = %v, want released and drained", orphaned.RunOwner, orphaned.RunQueue)
+	}
+	held, _ := triggers.GetByID("held")
+	if held.RunOwner != "alive" || len(held.RunQueue) != 1 {
+		t.Fatalf("held slot = %q queue = %v, want untouched", held.RunOwner, held.RunQueue)
+	}
+}
+
+func TestDocumentParsedEvents(t *testing.T) {
+	setupAgentTriggerTest(t)
+	source := documentParsedEvents(dao.NewDocumentDAO())
+	trigger := &entity.AgentTrigger{EventFilter: entity.JSONMap{"dataset_id": "kb-1"}}
+
+	payloads, state, err := source(context.Background(), trigger, nil)
+	if err != nil || len(payloads) != 0 {
+		t.Fatalf("first poll = %v, %v; want only a watermark", payloads, err)
+	}
+	since, _, _ := watermarkFromState(state)
+
+	done := string(entity.TaskStatusDone)
+	running := string(entity.TaskStatusRunning)
+	for _, doc := range []*entity.Document{
+		{ID: "doc-old", KbID: "kb-1", Run: &done, Name: sptr("old.pdf"), BaseModel: entity.BaseModel{UpdateTime: ptr(since - 10)}},
+		{ID: "doc-new", KbID: "kb-1", Run: &done, Name: sptr("new.pdf"), BaseModel: entity.BaseModel{UpdateTime: ptr(since + 10)}},
+		{ID: "doc-running", KbID: "kb-1", Run: &running, Name: sptr("run.pdf"), BaseModel: entity.BaseModel{UpdateTime: ptr(since + 20)}},
+		{ID: "doc-other-kb", KbID: "kb-2", Run: &done, Name: sptr("x.pdf"), BaseModel: entity.BaseModel{UpdateTime: ptr(since + 30)}},
+	} {
+		doc.ParserConfig = entity.JSONMap{}
+		if err := dao.DB.Create(doc).Error; err != nil {
+			t.Fatalf("seed %s: %v", doc.ID, err)
+		}
+	}
+
+	payloads, state, err = source(context.Background(), trigger, state)
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if len(payloads) != 1 || payloads[0]["document_id"] != "doc-new" {
+		t.Fatalf("payloads = %v, want only doc-new", payloads)
+	}
+	if next, afterID, _ := watermarkFromState(state); next != since+10 || afterID != "doc-new" {
+		t.Fatalf("watermark = %d/%s, want %d/doc-new", next, afterID, since+10)
+	}
+	if payloads, _, _ = source(context.Background(), trigger, state); len(payloads) != 0 {
+		t.Fatalf("repeat poll = %v, want nothing new", payloads)
+	}
+}
+
+func TestDocumentParsedEvents_SharedUpdateTime(t *testing.T) {
+	setupAgentTriggerTest(t)
+	source := documentParsedEvents(dao.NewDocumentDAO())
+	trigger := &entity.AgentTrigger{EventFilter: entity.JSONMap{"dataset_id": "kb-1"}}
+	_, state, _ := source(context.Background(), trigger, nil)
+	since, _, _ := watermarkFromState(state)
+
+	// One more document than a poll returns, all finishing in the same
+	// millisecond: the batch boundary falls inside the tie.
+	done := string(entity.TaskStatusDone)
+	total := agentTriggerEventBatch + 1
+	for i := 0; i < total; i++ {
+		doc := &entity.Document{ID: fmt.Sprintf("doc-%02d", i), KbID: "kb-1", Run: &done, ParserConfig: entity.JSONMap{},
+			BaseModel: entity.BaseModel{UpdateTime: ptr(since + 5)}}
+		if err := dao.DB.Create(doc).Error; err != nil {
+			t.Fatalf("seed %s: %v", doc.ID, err)
+		}
+	}
+
+	seen := map[any]bool{}
+	for poll := 0; poll < 3; poll++ {
+		var payloads []map[string]any
+		var err error
+		payloads, state, err = source(context.Background(), trigger, state)
+		if err != nil {
+			t.Fatalf("poll %d: %v", poll, err)
+		}
+		for _, p := range payloads {
+			if seen[p["document_id"]] {
+				t.Fatalf("poll %d fired %v again", poll, p["document_id"])
+			}
+			seen[p["document_id"]] = true
+		}
+	}
+	if len(seen) != total {
+		t.Fatalf("fired %d documents, want %d", len(seen), total)
+	}
+}
+
+func TestMemoryThresholdEvents(t *testing.T) {
+	count := int64(3)
+	source := memoryThresholdEvents(func(context.Context, string) (int64, error) { return count, nil })
+	trigger := &entity.AgentTrigger{EventFilter: entity.JSONMap{"memory_id": "m1", "threshold": float64(5)}}
+
+	payloads, state, _ := source(context.Background(), trigger, nil)
+	if len(payloads) != 0 {
+		t.Fatalf("first poll fired: %v", payloads)
+	}
+	count = 6
+	payloads, state, _ = source(context.Background(), trigger, state)
+	if len(payloads) != 1 || payloads[0]["message_count"] != int64(6) {
+		t.Fatalf("crossing poll = %v, want one firing", payloads)
+	}
+	count = 7
+	if payloads, state, _ = source(context.Background(), trigger, state); len(payloads) != 0 {
+		t.Fatalf("poll above threshold fired again: %v", payloads)
+	}
+	count = 1
+	_, state, _ = source(context.Background(), trigger, state)
+	count = 5
+	if payloads, _, _ = source(context.Background(), trigger, state); len(payloads) != 1 {
+		t.Fatalf("re-armed crossing = %v, want one firing", payloads)
+	}
+}
@@ -80,6 +80,9 @@ func decodeCanvasFromDSL(dsl map[string]any) (*canvas.Canvas, error) {
 	if p, ok := dsl["globals"].(map[string]any); ok {
 		c.Globals = p
 	}
+	if p, ok := dsl["variables"].(map[string]any); ok {
+		c.Variables = p
+	}
 	if graph, ok := dsl["graph"].(map[string]any); ok {
 		if nodes, ok := graph["nodes"].([]any); ok {
 			for _, raw := range nodes {
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"ragflow/internal/common"
@@ -28,6 +29,7 @@ import (
 	modelModule "ragflow/internal/entity/models"
 	"ragflow/internal/service/kg"
 	"ragflow/internal/service/nlp"
+	"ragflow/internal/utility/sqlguard"
 	"regexp"
 	"sort"
 	"strings"
@@ -847,12 +849,21 @@ func (s *ChatPipelineService) AsyncChat(
 		if knowledge != "" {
 			kwargs["knowledge"] = "\n------\n" + knowledge
 		}
+		// Cache-friendly layout: keep the knowledge out of the system
+		// prompt so it stays identical across turns; it is prepended to
+		// the final user turn below instead.
+		cacheLayout := promptCacheLayout(chat)
+		movedKnowledge := ""
+		if cacheLayout && len(knowledges) > 0 {
+			movedKnowledge, _ = kwargs["knowledge"].(string)
+			kwargs["knowledge"] = ""
+		}
 		systemPrompt = ""
 		if sp, ok := promptConfig["system"].(string); ok {
 			systemPrompt = s.formatPrompt(sp, kwargs) + attachments
 			// If knowledge was retrieved but the template has no {knowledge}
 			// placeholder, auto-append it so the LLM still sees the context.
-			if len(knowledges) > 0 && !strings.Contains(sp, "{knowledge}") {
+			if len(knowledges) > 0 && !cacheLayout && !strings.Contains(sp, "{knowledge}") {
 				if kw, ok := kwargs["knowledge"].(string); ok {
 					systemPrompt += kw
 				}
@@ -912,6 +923,10 @@ func (s *ChatPipelineService) AsyncChat(
 			})
 		}
 
+		if cacheLayout {
+			moveKnowledgeToLastUser(llmMessages, movedKnowledge)
+		}
+
 		// Fit messages within token budget.
 		usedTokenCount, llmMessages := s.messageFitIn(llmMessages, int(float64(modelMaxTokens)*0.95))
 		common.Debug("Messages fitted in token budget",
@@ -1026,6 +1041,7 @@ func (s *ChatPipelineService) AsyncChat(
 			thinkState := &thinkStreamState{}
 
 			chatCfg := BuildChatConfig(chat, nil)
+			chatCfg.UsageResult = &modelModule.ChatUsage{}
 
 			// Tool routing: use tool-loop method when tools are bound.
 			var driverErr error
@@ -1181,7 +1197,7 @@ func (s *ChatPipelineService) AsyncChat(
 			visibleAnswer := s.extractVisibleAnswer(fullReasoning + fullAnswer)
 
 			// Pass nil for ttsModel — audio was already produced per-delta.
-			final := s.decorateAnswer(ctx, visibleAnswer, kbinfos, prompt, questions, usedTokenCount, timer, embModel, chat.VectorSimilarityWeight, quote, nil, langfuseTraceID, llmModelConfig, chat.TenantID, kbTenantIDStrings(kbs), len(knowledges) > 0)
+			final := s.decorateAnswer(withChatUsage(ctx, chatCfg.UsageResult), visibleAnswer, kbinfos, prompt, questions, usedTokenCount, timer, embModel, chat.VectorSimilarityWeight, quote, nil, langfuseTraceID, llmModelConfig, chat.TenantID, kbTenantIDStrings(kbs), len(knowledges) > 0)
 			final.Final = true
 			final.AudioBinary = nil
 			timer.Exit(common.PhaseGenerateAnswer)
@@ -1191,6 +1207,7 @@ func (s *ChatPipelineService) AsyncChat(
 			var answer string
 			var err error
 			chatCfg := BuildChatConfig(chat, nil)
+			chatCfg.UsageResult = &modelModule.ChatUsage{}
 
 			// Tool routing: use tool-loop when tools are bound.
 			if chatDriver.ToolConfig != nil {
@@ -1224,7 +1241,7 @@ func (s *ChatPipelineService) AsyncChat(
 			common.Debug("User: " + userContent + "|Assistant: " + answer)
 
 			// Synthesize TTS for the full answer (non-stream, one-shot).
-			final := s.decorateAnswer(ctx, answer, kbinfos, prompt, questions, usedTokenCount, timer, embModel, chat.VectorSimilarityWeight, quote, ttsModel, langfuseTraceID, llmModelConfig, chat.TenantID, kbTenantIDStrings(kbs), len(knowledges) > 0)
+			final := s.decorateAnswer(withChatUsage(ctx, chatCfg.UsageResult), answer, kbinfos, prompt, questions, usedTokenCount, timer, embModel, chat.VectorSimilarityWeight, quote, ttsModel, langfuseTraceID, llmModelConfig, chat.TenantID, kbTenantIDStrings(kbs), len(knowledges) > 0)
 			final.Final = true
 			timer.Exit(common.PhaseGenerateAnswer)
 			out <- final
@@ -1368,6 +1385,7 @@ func (s *ChatPipelineService) AsyncChatSolo(
 				Content: content,
 			})
 		}
+		markCacheBreakpoints(chatMessages)
 
 		// 7. Drive the LLM: stream (per-delta with think markers) or non-stream (one-shot).
 		if stream {
@@ -2407,6 +2425,7 @@ func (s *ChatPipelineService) buildChatMessages(systemContent string, messages [
 		}
 		result = append(result, modelModule.Message{Role: role, Content: content})
 	}
+	markCacheBreakpoints(result)
 	return result
 }
 
@@ -2710,6 +2729,10 @@ func (s *ChatPipelineService) decorateAnswer(
 
 	timeStats := prompt + timer.Markdown() + "\n"
 	timeStats += fmt.Sprintf("  - Generated tokens(approximately): %d\n", tkNum)
+	usage := chatUsageFromContext(ctx)
+	if usage != nil {
+		timeStats += promptCacheStats(usage)
+	}
 	if totalMs > 0 {
 		timeStats += fmt.Sprintf("  - Token speed: %d/s", int(float64(tkNum)/(totalMs/1000.0)))
 	}
@@ -2725,11 +2748,18 @@ func (s *ChatPipelineService) decorateAnswer(
 			// + token-usage block) and replaces \n with "  \n" for
 			// markdown line breaks.
 			langfuseOutput := langfuseExtractTimeElapsed(timeStats)
-			usage := &LangfuseUsage{
+			lfUsage := &LangfuseUsage{
 				PromptTokens:     usedTokenCount,
 				CompletionTokens: tkNum,
 				TotalTokens:      usedTokenCount + tkNum,
 			}
+			if usage != nil {
+				lfUsage.PromptTokens = usage.PromptTokens
+				if usage.CompletionTokens > 0 {
+					lfUsage.CompletionTokens = usage.CompletionTokens
+				}
+				lfUsage.TotalTokens = lfUsage.PromptTokens + lfUsage.CompletionTokens
+			}
 			modelName := ""
 			if llmModelConfig != nil {
 				if mn, ok := llmModelConfig["llm_name"].(string); ok {
@@ -2744,7 +2774,7 @@ func (s *ChatPipelineService) decorateAnswer(
 				StartTime: time.Now().UTC().Format(time.RFC3339Nano),
 				EndTime:   time.Now().UTC().Format(time.RFC3339Nano),
 				Output:    langfuseOutput,
-				Usage:     usage,
+				Usage:     lfUsage,
 			})
 		}
 	}
@@ -3201,8 +3231,9 @@ func (s *ChatPipelineService) useSQL(
 
 	// Step 2: try to execute. On failure, repair once with the
 	// engine-specific execution-error prompt so the LLM regenerates
-	// correctly (Flow B at dialog_service.py:1164-1205).
-	rows, execErr := docEngine.RunSQL(ctx, tableName, sqlText, kbIDStrings(kbs), "json")
+	// correctly (Flow B at dialog_service.py:1164-1205). SQL the guard
+	// rejects takes the same path, with the violation as the error.
+	sqlText, rows, execErr := runChatSQL(ctx, docEngine, engineName, tableName, sqlText, kbs)
 	if execErr != nil {
 		common.Debug("SQL retrieval: initial execution failed, attempting repair",
 			zap.String("sql", sqlText), zap.Error(execErr))
@@ -3217,7 +3248,7 @@ func (s *ChatPipelineService) useSQL(
 		if filtered, ok := addKBFilter(repaired, engineName, kbs); ok {
 			repaired = filtered
 		}
-		rows, execErr = docEngine.RunSQL(ctx, tableName, repaired, kbIDStrings(kbs), "json")
+		sqlText, rows, execErr = runChatSQL(ctx, docEngine, engineName, tableName, repaired, kbs)
 		if execErr != nil {
 			common.Warn("SQL retrieval: repaired SQL also failed", zap.Error(execErr))
 			return nil, nil
@@ -3248,7 +3279,7 @@ func (s *ChatPipelineService) useSQL(
 			if filtered, ok := addKBFilter(repaired, engineName, kbs); ok {
 				repaired = filtered
 			}
-			repairedRows, repairedErr := docEngine.RunSQL(ctx, tableName, repaired, kbIDStrings(kbs), "json")
+			repaired, repairedRows, repairedErr := runChatSQL(ctx, docEngine, engineName, tableName, repaired, kbs)
 			if repairedErr == nil && len(repairedRows) > 0 && hasSourceColumns(repairedRows) {
 				common.Debug("SQL retrieval: missing-columns repair succeeded",
 					zap.String("sql", repaired))
@@ -3281,6 +3312,43 @@ func (s *ChatPipelineService) useSQL(
 	}, nil
 }
 
+// chatSQLMaxRows caps the rows an SQL-retrieval query returns; the
+// rows end up in the answer prompt, so more would only be truncated.
+const chatSQLMaxRows = 1024
+
+// guardChatSQL parses LLM-generated SQL in the document engine's
+// dialect and returns it with the row cap applied. Only a single
+// read-only query against tableName is accepted. A *sqlguard.Violation
+// explains the rejection in terms the repair prompt can act on.
+func guardChatSQL(sqlText, engineName, tableName string) (string, error) {
+	res, err := sqlguard.Check(sqlText, sqlguard.Policy{
+		Dialect:       sqlguard.DialectFor(engineName),
+		MaxRows:       chatSQLMaxRows,
+		AllowedTables: []string{tableName},
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.SQL, nil
+}
+
+// runChatSQL guards sqlText and runs it on the document engine. It
+// returns the statement that ran, or sqlText unchanged when the guard
+// rejected it.
+func runChatSQL(
+	ctx context.Context,
+	docEngine engine.DocEngine,
+	engineName, tableName, sqlText string,
+	kbs []*entity.Knowledgebase,
+) (string, []map[string]interface{}, error) {
+	guarded, err := guardChatSQL(sqlText, engineName, tableName)
+	if err != nil {
+		return sqlText, nil, err
+	}
+	rows, err := docEngine.RunSQL(ctx, tableName, guarded, kbIDStrings(kbs), "json")
+	return guarded, rows, err
+}
+
 // ragflowTableName returns the engine-specific SQL target name.
 // Mirrors dialog_service.py:954-963. For Infinity with a single KB,
 // validates the kb_id is a canonical UUID before interpolating
@@ -3580,6 +3648,21 @@ func buildExecutionErrorRepairPrompt(engineName, tableName, question, errMsg str
 	)
 }
 
+// sqlResponseSchema constrains SQL-generation answers to {"sql": "..."}
+// so prose around the statement cannot leak into the executed query.
+var sqlResponseSchema = &modelModule.ResponseSchema{
+	Name: "sql_query",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"sql": map[string]interface{}{"type": "string", "minLength": 1},
+		},
+		"required":             []interface{}{"sql"},
+		"additionalProperties": false,
+	},
+	Strict: true,
+}
+
 // chatForSQL is the shared chat-model invocation for SQL generation
 // and both repair flows. Returns the cleaned (normalized) SQL or an
 // error. errPrefix is included in error messages to disambiguate
@@ -3597,7 +3680,8 @@ func chatForSQL(
 	// SQL that diverged from the Python reference on some prompts.
 	tempLow := 0.06
 	cfg := &modelModule.ChatConfig{
-		Temperature: &tempLow,
+		Temperature:    &tempLow,
+		ResponseSchema: sqlResponseSchema,
 	}
 	modelName := ""
 	if chatModel.ModelName != nil {
@@ -3607,16 +3691,17 @@ func chatForSQL(
 		modelModule.Message{Role: "system", Content: sysPrompt},
 		modelModule.Message{Role: "user", Content: userPrompt},
 	}
-	resp, err := chatModel.ModelDriver.ChatWithMessages(
-		modelName, msgs, chatModel.APIConfig, cfg,
+	_, value, err := modelModule.ChatWithResponseSchema(
+		chatModel.ModelDriver, modelName, msgs, chatModel.APIConfig, cfg, 1,
 	)
 	if err != nil {
+		if errors.Is(err, modelModule.ErrStructuredOutput) {
+			return "", fmt.Errorf("%s: %w", errPrefix, err)
+		}
 		return "", err
 	}
-	if resp == nil || resp.Answer == nil {
-		return "", fmt.Errorf("%s: empty response", errPrefix)
-	}
-	cleaned := normalizeSQL(*resp.Answer)
+	sql, _ := value.(map[string]interface{})["sql"].(string)
+	cleaned := normalizeSQL(sql)
 	if cleaned == "" {
 		return "", fmt.Errorf("%s: empty after normalize", errPrefix)
 	}
@@ -3897,6 +3982,14 @@ func (s *ChatPipelineService) fetchAggregateChunks(
 		return nil, nil
 	}
 
+	// The WHERE clause is lifted from model output by regex, so the
+	// assembled query is checked again before it runs.
+	chunksSQL, err := guardChatSQL(chunksSQL, docEngine.GetType(), tableName)
+	if err != nil {
+		common.Warn("SQL retrieval: aggregate secondary fetch rejected",
+			zap.String("sql", originalSQL), zap.Error(err))
+		return nil, nil
+	}
 	rows, err := docEngine.RunSQL(ctx, tableName, chunksSQL, kbIDs, "json")
 	if err != nil {
 		common.Warn("SQL retrieval: aggregate secondary fetch failed",
@@ -4211,6 +4304,12 @@ func BuildChatConfig(dialog *entity.Chat, config map[string]interface{}) *modelM
 			cfg.Verbosity = &v
 		}
 	}
+	// Every turn of a dialog shares its system prompt, so the dialog ID
+	// is the natural prompt-cache routing key.
+	if dialog.ID != "" {
+		key := dialog.ID
+		cfg.PromptCacheKey = &key
+	}
 
 	if config != nil {
 		if v, ok := config["stream"].(bool); ok {
@@ -18,6 +18,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -26,6 +27,8 @@ import (
 	"ragflow/internal/common"
 	"ragflow/internal/engine"
 	"ragflow/internal/entity"
+	modelModule "ragflow/internal/entity/models"
+	"ragflow/internal/utility/sqlguard"
 )
 
 // dialForTest builds a minimal *entity.Chat suitable for the
@@ -1274,6 +1277,81 @@ func TestFetchAggregateChunks_RunSQLError(t *testing.T) {
 	}
 }
 
+// TestGuardChatSQL covers the checks applied to model-written SQL
+// before it reaches the document engine.
+func TestGuardChatSQL(t *testing.T) {
+	accepted := []struct {
+		engine, sql, want string
+	}{
+		{"elasticsearch", "SELECT doc_id, docnm_kwd FROM ragflow_t WHERE kb_id = 'k'",
+			"SELECT doc_id, docnm_kwd FROM ragflow_t WHERE kb_id = 'k' LIMIT 1024"},
+		{"elasticsearch", "SELECT COUNT(*) AS rows FROM ragflow_t LIMIT 5", "SELECT COUNT(*) AS rows FROM ragflow_t LIMIT 5"},
+		{"infinity", "SELECT doc_id FROM ragflow_t WHERE json_extract_isnull(chunk_data, '$.a') == false;",
+			"SELECT doc_id FROM ragflow_t WHERE json_extract_isnull(chunk_data, '$.a') == false LIMIT 1024"},
+	}
+	for _, tc := range accepted {
+		got, err := guardChatSQL(tc.sql, tc.engine, "ragflow_t")
+		if err != nil {
+			t.Errorf("guardChatSQL(%q) error = %v", tc.sql, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("guardChatSQL(%q) = %q, want %q", tc.sql, got, tc.want)
+		}
+	}
+
+	rejected := []struct {
+		sql  string
+		code sqlguard.Code
+	}{
+		{"DELETE FROM ragflow_t", sqlguard.CodeNotReadOnly},
+		{"SELECT 1 FROM ragflow_t; SELECT 2 FROM ragflow_t", sqlguard.CodeMultipleStatements},
+		{"SELECT * FROM ragflow_other", sqlguard.CodeTable},
+		{"SELECT * FROM ragflow_t WHERE doc_id IN (SELECT doc_id FROM ragflow_other)", sqlguard.CodeTable},
+	}
+	for _, tc := range rejected {
+		_, err := guardChatSQL(tc.sql, "elasticsearch", "ragflow_t")
+		var v *sqlguard.Violation
+		if !errors.As(err, &v) || v.Code != tc.code {
+			t.Errorf("guardChatSQL(%q) error = %v, want %s", tc.sql, err, tc.code)
+		}
+	}
+}
+
+// TestRunChatSQL_RejectedSQLNeverRuns verifies that a guard violation
+// is returned as the execution error (so the repair prompt sees it)
+// without the engine being called.
+func TestRunChatSQL_RejectedSQLNeverRuns(t *testing.T) {
+	var calls []string
+	eng := &sqlFakeEngine{engineType: "elasticsearch", sqlCalls: &calls}
+	sqlText := "DROP INDEX ragflow_t"
+	ran, rows, err := runChatSQL(context.Background(), eng, "elasticsearch", "ragflow_t", sqlText, nil)
+	if !errors.Is(err, sqlguard.ErrRejected) {
+		t.Fatalf("err = %v, want a sqlguard violation", err)
+	}
+	if !strings.Contains(err.Error(), "not_read_only") {
+		t.Errorf("err = %q, want the violation code in the repair text", err)
+	}
+	if ran != sqlText || rows != nil || len(calls) != 0 {
+		t.Errorf("ran=%q rows=%v calls=%v, want the input back and no engine call", ran, rows, calls)
+	}
+}
+
+func TestRunChatSQL_RunsGuardedSQL(t *testing.T) {
+	want := "SELECT doc_id FROM ragflow_t LIMIT 1024"
+	eng := &sqlFakeEngine{
+		engineType: "elasticsearch",
+		rowsBySQL:  map[string][]map[string]interface{}{want: {{"doc_id": "d1"}}},
+	}
+	ran, rows, err := runChatSQL(context.Background(), eng, "elasticsearch", "ragflow_t", "SELECT doc_id FROM ragflow_t", nil)
+	if err != nil {
+		t.Fatalf("runChatSQL: %v", err)
+	}
+	if ran != want || len(rows) != 1 {
+		t.Errorf("ran=%q rows=%v, want %q with one row", ran, rows, want)
+	}
+}
+
 // TestBuildSQLReference_EmptyRows verifies the empty-rows path.
 func TestBuildSQLReference_EmptyRows(t *testing.T) {
 	s := &ChatPipelineService{}
@@ -1462,3 +1540,95 @@ func TestBuildChatConfig_FromEmptyDialog(t *testing.T) {
 		t.Fatalf("expected temperature %v, got %v", temp, cfg.Temperature)
 	}
 }
+
+// sqlChatDriver is a non-native structured-output driver returning a
+// fixed answer sequence for chatForSQL.
+type sqlChatDriver struct {
+	modelModule.ModelDriver
+	answers []string
+	calls   int
+}
+
+func (d *sqlChatDriver) Name() string { return "deepseek" }
+
+func (d *sqlChatDriver) ChatWithMessages(string, []modelModule.Message, *modelModule.APIConfig, *modelModule.ChatConfig) (*modelModule.ChatResponse, error) {
+	answer := d.answers[d.calls]
+	d.calls++
+	return &modelModule.ChatResponse{Answer: &answer}, nil
+}
+
+// TestChatForSQL_RepairsNonJSONAnswer verifies chatForSQL asks for the
+// {"sql": ...} schema and repairs an answer that ignores it.
+func TestChatForSQL_RepairsNonJSONAnswer(t *testing.T) {
+	driver := &sqlChatDriver{answers: []string{
+		"Sure! Here is the query: SELECT 1",
+		`{"sql": "SELECT doc_id FROM t;"}`,
+	}}
+	name := "m"
+	sql, err := chatForSQL(context.Background(), &modelModule.ChatModel{ModelDriver: driver, ModelName: &name}, "sys", "user", "sql generation")
+	if err != nil {
+		t.Fatalf("chatForSQL: %v", err)
+	}
+	if driver.calls != 2 {
+		t.Errorf("calls=%d, want 2", driver.calls)
+	}
+	if sql != normalizeSQL("SELECT doc_id FROM t;") {
+		t.Errorf("sql=%q", sql)
+	}
+}
+
+// TestMarkCacheBreakpoints flags the system prompt and the history
+// before the newest user turn, leaving the newest turn unmarked.
+func TestMarkCacheBreakpoints(t *testing.T) {
+	msgs := []modelModule.Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "q1"},
+		{Role: "assistant", Content: "a1"},
+		{Role: "user", Content: "q2"},
+	}
+	markCacheBreakpoints(msgs)
+	got := []bool{msgs[0].CacheBreakpoint, msgs[1].CacheBreakpoint, msgs[2].CacheBreakpoint, msgs[3].CacheBreakpoint}
+	if !reflect.DeepEqual(got, []bool{true, false, true, false}) {
+		t.Errorf("breakpoints=%v", got)
+	}
+}
+
+// TestMoveKnowledgeToLastUser keeps the knowledge out of the system
+// prompt by prefixing the final user turn.
+func TestMoveKnowledgeToLastUser(t *testing.T) {
+	msgs := []map[string]interface{}{
+		{"role": "system", "content": "sys"},
+		{"role": "user", "content": "what?"},
+	}
+	moveKnowledgeToLastUser(msgs, "\n------\nchunk")
+	if msgs[0]["content"] != "sys" {
+		t.Errorf("system changed: %v", msgs[0]["content"])
+	}
+	if msgs[1]["content"] != "### Knowledge:\n------\nchunk\n\n### Question:\nwhat?" {
+		t.Errorf("user=%q", msgs[1]["content"])
+	}
+}
+
+// TestBuildChatConfig_PromptCacheKey uses the dialog ID as the cache key.
+func TestBuildChatConfig_PromptCacheKey(t *testing.T) {
+	cfg := BuildChatConfig(&entity.Chat{ID: "dialog-1"}, nil)
+	if cfg.PromptCacheKey == nil || *cfg.PromptCacheKey != "dialog-1" {
+		t.Fatalf("PromptCacheKey=%v", cfg.PromptCacheKey)
+	}
+}
+
+// TestPromptCacheStats carries provider usage through the context into
+// the timing stats line.
+func TestPromptCacheStats(t *testing.T) {
+	ctx := withChatUsage(context.Background(), &modelModule.ChatUsage{PromptTokens: 1200, CacheReadTokens: 1024})
+	usage := chatUsageFromContext(ctx)
+	if usage == nil {
+		t.Fatal("usage not carried in context")
+	}
+	if got := promptCacheStats(usage); got != "  - Prompt tokens: 1200 (cached: 1024)\n" {
+		t.Errorf("stats=%q", got)
+	}
+	if chatUsageFromContext(withChatUsage(context.Background(), &modelModule.ChatUsage{})) != nil {
+		t.Error("empty usage should be treated as unreported")
+	}
+}
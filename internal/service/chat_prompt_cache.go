@@ -0,0 +1,99 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"ragflow/internal/entity"
+	modelModule "ragflow/internal/entity/models"
+)
+
+// chatUsageCtxKeyType carries the provider-reported usage of the answer
+// call from the LLM phase into decorateAnswer.
+type chatUsageCtxKeyType struct{}
+
+var chatUsageCtxKey = chatUsageCtxKeyType{}
+
+func withChatUsage(ctx context.Context, usage *modelModule.ChatUsage) context.Context {
+	if usage == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, chatUsageCtxKey, usage)
+}
+
+// chatUsageFromContext returns the usage stored by withChatUsage, or nil
+// when the driver reported none.
+func chatUsageFromContext(ctx context.Context) *modelModule.ChatUsage {
+	usage, ok := ctx.Value(chatUsageCtxKey).(*modelModule.ChatUsage)
+	if !ok || usage == nil || usage.PromptTokens == 0 {
+		return nil
+	}
+	return usage
+}
+
+// promptCacheLayout reports whether the dialog opted into the
+// cache-friendly prompt layout (llm_setting.prompt_cache). The layout keeps
+// the system prompt identical across turns by moving the retrieved
+// knowledge out of it and into the final user turn.
+func promptCacheLayout(chat *entity.Chat) bool {
+	if chat == nil || chat.LLMSetting == nil {
+		return false
+	}
+	enabled, _ := chat.LLMSetting["prompt_cache"].(bool)
+	return enabled
+}
+
+// moveKnowledgeToLastUser prefixes the final user turn with knowledge so
+// that everything before it stays byte-identical between turns. Messages
+// with non-string content are left untouched.
+func moveKnowledgeToLastUser(messages []map[string]interface{}, knowledge string) {
+	if knowledge == "" || len(messages) == 0 {
+		return
+	}
+	last := messages[len(messages)-1]
+	if role, _ := last["role"].(string); role != "user" {
+		return
+	}
+	content, ok := last["content"].(string)
+	if !ok {
+		return
+	}
+	last["content"] = fmt.Sprintf("### Knowledge:%s\n\n### Question:\n%s", knowledge, content)
+}
+
+// markCacheBreakpoints flags the stable prompt prefixes: the system
+// message, and the conversation history before the newest user turn, which
+// the next turn will resend unchanged.
+func markCacheBreakpoints(messages []modelModule.Message) {
+	if len(messages) == 0 {
+		return
+	}
+	if messages[0].Role == "system" {
+		messages[0].CacheBreakpoint = true
+	}
+	if n := len(messages); n >= 3 && messages[n-1].Role == "user" {
+		messages[n-2].CacheBreakpoint = true
+	}
+}
+
+// promptCacheStats renders the cache line appended to the answer's
+// timing stats.
+func promptCacheStats(usage *modelModule.ChatUsage) string {
+	return fmt.Sprintf("  - Prompt tokens: %d (cached: %d)\n", usage.PromptTokens, usage.CacheReadTokens)
+}
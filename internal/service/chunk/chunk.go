@@ -32,6 +32,7 @@ import (
 	"io"
 	"math"
 	"math/rand"
+	"net/http"
 	"path/filepath"
 	"ragflow/internal/common"
 	"ragflow/internal/engine/redis"
@@ -96,6 +97,7 @@ type ChunkService struct {
 	beginParseDocumentFunc        func(string) error
 	deleteTasksByDocIDsFunc       func([]string) (int64, error)
 	getEmbeddingModelFunc         func(string, string) (*models.EmbeddingModel, error)
+	getImageEmbeddingModelFunc    func(string, string) (*models.EmbeddingModel, error)
 	incrementChunkStatsFunc       func(string, string, int64, int64, float64) error
 	storeChunkImageFunc           func(string, string, []byte) error
 	tokenizeFunc                  func(string) (string, error)
@@ -429,6 +431,7 @@ func (s *ChunkService) RetrievalTest(req *service.RetrievalTestRequest, userID s
 		RerankModel:            rerankModel,
 		RankFeature:            &labels,
 		EmbeddingModel:         embeddingModel,
+		ImageEmbeddingModel:    service.DatasetImageEmbeddingModel(modelProviderSvc, kbRecords[0]),
 	}
 
 	// Call RetrievalService to perform retrieval
@@ -1825,8 +1828,9 @@ func (s *ChunkService) AddChunk(req *service.AddChunkRequest, userID string) (*s
 		chunkData["tag_feas"] = tagFeas
 	}
 
+	var imageBinary []byte
 	if req.ImageBase64 != nil {
-		imageBinary, err := decodeChunkImageBase64(*req.ImageBase64)
+		imageBinary, err = decodeChunkImageBase64(*req.ImageBase64)
 		if err != nil {
 			return nil, addChunkError{code: common.CodeDataError, message: err.Error()}
 		}
@@ -1858,6 +1862,14 @@ func (s *ChunkService) AddChunk(req *service.AddChunkRequest, userID string) (*s
 	}
 	chunkData[fmt.Sprintf("q_%d_vec", len(mergedVec))] = mergedVec
 
+	if imageEmbdID := service.DatasetImageEmbeddingID(kb); imageBinary != nil && imageEmbdID != "" {
+		imageVec, err := s.embedChunkImage(kb.TenantID, imageEmbdID, imageBinary)
+		if err != nil {
+			return nil, addChunkError{code: common.CodeServerError, message: fmt.Sprintf("encode chunk image embedding: %v", err)}
+		}
+		chunkData[nlp.ImageVectorColumn(len(imageVec))] = imageVec
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Second)
 	defer cancel()
 	if _, err := s.docEngine.InsertChunks(ctx, []map[string]interface{}{chunkData}, indexName, req.DatasetID); err != nil {
@@ -2006,6 +2018,31 @@ func (s *ChunkService) getEmbeddingModel(tenantID, embdID string) (*models.Embed
 	return service.NewModelProviderService().GetEmbeddingModel(tenantID, embdID)
 }
 
+func (s *ChunkService) getImageEmbeddingModel(tenantID, imageEmbdID string) (*models.EmbeddingModel, error) {
+	if s.getImageEmbeddingModelFunc != nil {
+		return s.getImageEmbeddingModelFunc(tenantID, imageEmbdID)
+	}
+	return service.NewModelProviderService().GetImageEmbeddingModel(tenantID, imageEmbdID)
+}
+
+// embedChunkImage embeds a chunk image with the dataset's multimodal
+// embedding model, sending it inline as a data URI.
+func (s *ChunkService) embedChunkImage(tenantID, imageEmbdID string, imageBinary []byte) ([]float64, error) {
+	imageModel, err := s.getImageEmbeddingModel(tenantID, imageEmbdID)
+	if err != nil {
+		return nil, err
+	}
+	dataURI := fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(imageBinary), base64.StdEncoding.EncodeToString(imageBinary))
+	embeddings, err := imageModel.EmbedImages([]string{dataURI})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != 1 || len(embeddings[0].Embedding) == 0 {
+		return nil, fmt.Errorf("unexpected image embedding count: %d", len(embeddings))
+	}
+	return embeddings[0].Embedding, nil
+}
+
 func (s *ChunkService) incrementChunkStats(docID, kbID string, tokenNum, chunkNum int64, duration float64) error {
 	if s.incrementChunkStatsFunc != nil {
 		return s.incrementChunkStatsFunc(docID, kbID, tokenNum, chunkNum, duration)
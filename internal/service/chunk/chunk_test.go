@@ -638,6 +638,89 @@ func TestAddChunkImageAndTagFeatureValidation(t *testing.T) {
 	}
 }
 
+// stubImageEmbeddingDriver reports a multimodal driver name so
+// EmbedImages accepts it, and records the inputs it was asked to embed.
+type stubImageEmbeddingDriver struct {
+	stubEmbeddingDriver
+	inputs    []string
+	inputType string
+}
+
+func (d *stubImageEmbeddingDriver) Name() string { return "jina" }
+func (d *stubImageEmbeddingDriver) Embed(_ *string, texts []string, _ *models.APIConfig, cfg *models.EmbeddingConfig) ([]models.EmbeddingData, error) {
+	d.inputs = texts
+	if cfg != nil {
+		d.inputType = cfg.InputType
+	}
+	return []models.EmbeddingData{{Embedding: []float64{0.5, 0.5, 0.5}}}, nil
+}
+
+func TestAddChunkStoresImageVectorWhenDatasetHasImageEmbedding(t *testing.T) {
+	db := setupChunkTestDB(t)
+	pushChunkTestDB(t, db)
+	userID, datasetID, documentID := "user-1", "kb-1", "doc-1"
+	insertChunkTestKB(t, datasetID, userID)
+	insertChunkTestDoc(t, documentID, datasetID)
+
+	engine := &addChunkTestEngine{}
+	imageDriver := &stubImageEmbeddingDriver{}
+	svc := &ChunkService{
+		docEngine:      engine,
+		kbDAO:          dao.NewKnowledgebaseDAO(),
+		documentDAO:    dao.NewDocumentDAO(),
+		accessibleFunc: func(string, string) bool { return true },
+		getKnowledgebaseByIDFunc: func(id string) (*entity.Knowledgebase, error) {
+			return &entity.Knowledgebase{ID: id, TenantID: userID, EmbdID: "embed-1",
+				ParserConfig: entity.JSONMap{"image_embd_id": "jina-clip-v2@Jina"}}, nil
+		},
+		tokenizeFunc:            func(text string) (string, error) { return text, nil },
+		fineGrainedTokenizeFunc: func(text string) (string, error) { return text + "_fg", nil },
+		numTokensFunc:           func(text string) int { return len(text) },
+		getEmbeddingModelFunc: func(string, string) (*models.EmbeddingModel, error) {
+			driver := &stubEmbeddingDriver{
+				embeddings: []models.EmbeddingData{
+					{Embedding: []float64{1, 1}},
+					{Embedding: []float64{1, 1}},
+				},
+			}
+			modelName := "embed-1"
+			return models.NewEmbeddingModel(driver, &modelName, &models.APIConfig{}, 0), nil
+		},
+		getImageEmbeddingModelFunc: func(tenantID, imageEmbdID string) (*models.EmbeddingModel, error) {
+			if imageEmbdID != "jina-clip-v2@Jina" {
+				t.Fatalf("image embd id = %q", imageEmbdID)
+			}
+			modelName := "jina-clip-v2"
+			return models.NewEmbeddingModel(imageDriver, &modelName, &models.APIConfig{}, 0), nil
+		},
+		incrementChunkStatsFunc: func(string, string, int64, int64, float64) error { return nil },
+		storeChunkImageFunc:     func(string, string, []byte) error { return nil },
+	}
+
+	validPNG := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mP8/x8AAwMCAO2pRZ0AAAAASUVORK5CYII="
+	if _, err := svc.AddChunk(&service.AddChunkRequest{
+		DatasetID:   datasetID,
+		DocumentID:  documentID,
+		Content:     "a figure",
+		ImageBase64: strPtr(validPNG),
+	}, userID); err != nil {
+		t.Fatalf("AddChunk() error = %v", err)
+	}
+	if imageDriver.inputType != models.EmbeddingInputImage {
+		t.Fatalf("input type = %q, want image", imageDriver.inputType)
+	}
+	if len(imageDriver.inputs) != 1 || !strings.HasPrefix(imageDriver.inputs[0], "data:image/png;base64,") {
+		t.Fatalf("image inputs = %v, want a png data URI", imageDriver.inputs)
+	}
+	inserted := engine.insertedChunks[0]
+	if _, ok := inserted["img_3_vec"]; !ok {
+		t.Fatalf("inserted chunk missing img_3_vec: %#v", inserted)
+	}
+	if _, ok := inserted["q_2_vec"]; !ok {
+		t.Fatalf("inserted chunk missing text vector q_2_vec")
+	}
+}
+
 func TestAddChunkIncrementsStatsAfterInsert(t *testing.T) {
 	db := setupChunkTestDB(t)
 	pushChunkTestDB(t, db)
@@ -1019,6 +1102,9 @@ func (d *stubEmbeddingDriver) OCRFile(*string, []byte, *string, *models.APIConfi
 func (d *stubEmbeddingDriver) ParseFile(*string, []byte, *string, *models.APIConfig, *models.ParseFileConfig) (*models.ParseFileResponse, error) {
 	return nil, nil
 }
+func (d *stubEmbeddingDriver) GenerateImage(*string, string, *models.APIConfig, *models.ImageGenerationConfig) (*models.ImageGenerationResponse, error) {
+	return nil, nil
+}
 func (d *stubEmbeddingDriver) ListModels(*models.APIConfig) ([]models.ListModelResponse, error) {
 	return nil, nil
 }
@@ -1137,6 +1137,34 @@ func datasetCosSim(a, b []float64) float64 {
 	return dot / (math.Sqrt(na) * math.Sqrt(nb))
 }
 
+// DatasetImageEmbeddingID returns the multimodal embedding model that
+// image chunks of the dataset are embedded with (parser_config
+// "image_embd_id"), or "" when image embeddings are disabled.
+func DatasetImageEmbeddingID(kb *entity.Knowledgebase) string {
+	if kb == nil || kb.ParserConfig == nil {
+		return ""
+	}
+	id, _ := kb.ParserConfig["image_embd_id"].(string)
+	return strings.TrimSpace(id)
+}
+
+// DatasetImageEmbeddingModel resolves the image embedding model used to
+// search kb. A missing or unusable model only disables image matching,
+// so failures are logged and nil is returned.
+func DatasetImageEmbeddingModel(modelProviderSvc *ModelProviderService, kb *entity.Knowledgebase) *models.EmbeddingModel {
+	imageEmbdID := DatasetImageEmbeddingID(kb)
+	if imageEmbdID == "" {
+		return nil
+	}
+	imageModel, err := modelProviderSvc.GetImageEmbeddingModel(kb.TenantID, imageEmbdID)
+	if err != nil {
+		common.Warn("Image embedding model unavailable, searching text only",
+			zap.String("datasetID", kb.ID), zap.String("imageEmbdID", imageEmbdID), zap.Error(err))
+		return nil
+	}
+	return imageModel
+}
+
 func datasetCleanEmbeddingText(s string) string {
 	re := regexp.MustCompile(`</?(table|td|caption|tr|th)( [^<>]{0,12})?>`)
 	return strings.TrimSpace(re.ReplaceAllString(s, " "))
@@ -2094,6 +2122,7 @@ func (s *DatasetService) SearchDatasets(req *SearchDatasetsRequest, userID strin
 		RerankModel:            rerankModel,
 		RankFeature:            &labels,
 		EmbeddingModel:         embeddingModel,
+		ImageEmbeddingModel:    DatasetImageEmbeddingModel(modelProviderSvc, kbRecords[0]),
 	}
 
 	retrievalResult, err := nlp.NewRetrievalService(s.docEngine, s.documentDAO).Retrieval(ctx, retrievalReq)
@@ -3001,6 +3001,41 @@ func (s *DocumentService) addFileFromKB(doc *entity.Document, kbFolderID, tenant
 }
 
 func (s *DocumentService) UploadWebDocument(kb *entity.Knowledgebase, tenantID, name, url string) (map[string]interface{}, common.ErrorCode, error) {
+	blob, headers, _, err := fetchRemoteFileSafely(url, maxUploadDocSize)
+	if err != nil {
+		return nil, common.CodeDataError, err
+	}
+	contentType := ""
+	if headers != nil {
+		contentType = headers.Get("Content-Type")
+	}
+	filename := normalizeWebDocumentName(name, contentType, blob)
+	filename, _, blob = normalizeUploadInfoContent(filename, contentType, blob)
+	return s.storeDatasetBlob(kb, tenantID, filename, blob, "web")
+}
+
+// UploadBlobDocument stores an in-memory payload as a new dataset document,
+// the same way UploadLocalDocuments stores one multipart file. src is the
+// document source_type (e.g. "agent" for canvas write-back).
+func (s *DocumentService) UploadBlobDocument(kb *entity.Knowledgebase, tenantID, name string, blob []byte, src string) (map[string]interface{}, common.ErrorCode, error) {
+	if len(blob) > maxUploadDocSize {
+		return nil, common.CodeDataError, fmt.Errorf("file exceeds the maximum allowed size of %d bytes", maxUploadDocSize)
+	}
+	// The name comes from canvas output, not a browser: keep the base name
+	// only so it can never address a storage path outside the dataset.
+	filename := filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if filename == "" || filename == "." || filename == "/" || filename == ".." {
+		return nil, common.CodeArgumentError, fmt.Errorf("Invalid document name %q.", name)
+	}
+	if len(filename) > 255 {
+		return nil, common.CodeArgumentError, fmt.Errorf("File name must be 255 bytes or less.")
+	}
+	return s.storeDatasetBlob(kb, tenantID, filename, blob, src)
+}
+
+// storeDatasetBlob dedup-renames filename, writes blob to object storage and
+// inserts the linked Document row, rolling back on failure.
+func (s *DocumentService) storeDatasetBlob(kb *entity.Knowledgebase, tenantID, filename string, blob []byte, src string) (map[string]interface{}, common.ErrorCode, error) {
 	storageImpl := storage.GetStorageFactory().GetStorage()
 	if storageImpl == nil {
 		return nil, common.CodeServerError, fmt.Errorf("storage not initialized")
@@ -3019,17 +3054,6 @@ func (s *DocumentService) UploadWebDocument(kb *entity.Knowledgebase, tenantID,
 	for _, n := range names {
 		taken[n] = true
 	}
-
-	blob, headers, _, err := fetchRemoteFileSafely(url, maxUploadDocSize)
-	if err != nil {
-		return nil, common.CodeDataError, err
-	}
-	contentType := ""
-	if headers != nil {
-		contentType = headers.Get("Content-Type")
-	}
-	filename := normalizeWebDocumentName(name, contentType, blob)
-	filename, _, blob = normalizeUploadInfoContent(filename, contentType, blob)
 	filename = uniqueUploadName(filename, taken)
 
 	filetype := utility.FilenameType(filename)
@@ -3045,7 +3069,7 @@ func (s *DocumentService) UploadWebDocument(kb *entity.Knowledgebase, tenantID,
 		return nil, common.CodeServerError, err
 	}
 
-	doc := s.newDatasetDocument(kb, tenantID, filename, location, string(filetype), kb.ParserConfig, "web", int64(len(blob)), blob)
+	doc := s.newDatasetDocument(kb, tenantID, filename, location, string(filetype), kb.ParserConfig, src, int64(len(blob)), blob)
 	if err := s.documentDAO.Create(doc); err != nil {
 		_ = storageImpl.Remove(kb.ID, location)
 		return nil, common.CodeServerError, err
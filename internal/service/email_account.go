@@ -0,0 +1,350 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	agenttool "ragflow/internal/agent/tool"
+	"ragflow/internal/common"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+	"ragflow/internal/ingestion/parser"
+	"ragflow/internal/utility"
+)
+
+const (
+	emailAccountNameLimit   = 255
+	defaultEmailTestTimeout = 15 * time.Second
+)
+
+// EmailAccountService manages the IMAP accounts a tenant exposes to the
+// Agent component as "imap:" tools and to email.received triggers.
+type EmailAccountService struct {
+	accountDAO *dao.EmailAccountDAO
+	// dial connects to an account; tests replace it.
+	dial func(ctx context.Context, account *agenttool.EmailAccountRecord) (*utility.IMAPClient, error)
+}
+
+// NewEmailAccountService creates an email account service.
+func NewEmailAccountService() *EmailAccountService {
+	return &EmailAccountService{
+		accountDAO: dao.NewEmailAccountDAO(),
+		dial:       agenttool.DialEmailAccount,
+	}
+}
+
+// CreateEmailAccountRequest is the request payload for registering an
+// account.
+type CreateEmailAccountRequest struct {
+	Name        string  `json:"name"`
+	Host        string  `json:"host"`
+	Port        int     `json:"port"`
+	Security    string  `json:"security"`
+	Username    string  `json:"username"`
+	Password    string  `json:"password"`
+	Mailbox     string  `json:"mailbox"`
+	Description *string `json:"description,omitempty"`
+}
+
+// UpdateEmailAccountRequest is the request payload for updating an
+// account. Nil fields are left unchanged.
+type UpdateEmailAccountRequest struct {
+	Name        *string `json:"name"`
+	Host        *string `json:"host"`
+	Port        *int    `json:"port"`
+	Security    *string `json:"security"`
+	Username    *string `json:"username"`
+	Password    *string `json:"password"`
+	Mailbox     *string `json:"mailbox"`
+	Description *string `json:"description"`
+}
+
+// EmailAccountResponse is an account as returned to clients. The stored
+// password is never echoed back.
+type EmailAccountResponse struct {
+	ID          string  `json:"id"`
+	TenantID    string  `json:"tenant_id"`
+	Name        string  `json:"name"`
+	Host        string  `json:"host"`
+	Port        int     `json:"port"`
+	Security    string  `json:"security"`
+	Username    string  `json:"username"`
+	Mailbox     string  `json:"mailbox"`
+	Description *string `json:"description"`
+	HasPassword bool    `json:"has_password"`
+	ToolName    string  `json:"tool_name"`
+	CreateDate  *string `json:"create_date"`
+	UpdateDate  *string `json:"update_date"`
+}
+
+// CreateEmailAccount registers an account for a tenant.
+func (s *EmailAccountService) CreateEmailAccount(tenantID string, req CreateEmailAccountRequest) (*EmailAccountResponse, common.ErrorCode, error) {
+	account := &entity.EmailAccount{
+		ID:          common.GenerateUUID(),
+		Name:        strings.TrimSpace(req.Name),
+		TenantID:    tenantID,
+		Host:        strings.TrimSpace(req.Host),
+		Port:        req.Port,
+		Security:    strings.ToLower(strings.TrimSpace(req.Security)),
+		Username:    strings.TrimSpace(req.Username),
+		Mailbox:     strings.TrimSpace(req.Mailbox),
+		Description: req.Description,
+		Credentials: entity.JSONMap{"password": req.Password},
+	}
+	if account.Security == "" {
+		account.Security = utility.IMAPSecurityTLS
+	}
+	if account.Port == 0 {
+		account.Port = defaultIMAPPort(account.Security)
+	}
+	if account.Mailbox == "" {
+		account.Mailbox = "INBOX"
+	}
+	if err := validateEmailAccount(account); err != nil {
+		return nil, common.CodeDataError, err
+	}
+	exists, err := s.accountDAO.ExistsByNameAndTenant(account.Name, tenantID)
+	if err != nil {
+		return nil, common.CodeServerError, err
+	}
+	if exists {
+		return nil, common.CodeDataError, errors.New("Duplicated email account name.")
+	}
+	if err := s.accountDAO.Create(account); err != nil {
+		return nil, common.CodeDataError, errors.New("Failed to create email account.")
+	}
+	return newEmailAccountResponse(account), common.CodeSuccess, nil
+}
+
+// GetEmailAccount returns a tenant's account.
+func (s *EmailAccountService) GetEmailAccount(tenantID, accountID string) (*EmailAccountResponse, common.ErrorCode, error) {
+	account, code, err := s.getAccount(tenantID, accountID)
+	if err != nil {
+		return nil, code, err
+	}
+	return newEmailAccountResponse(account), common.CodeSuccess, nil
+}
+
+// ListEmailAccounts lists a tenant's accounts.
+func (s *EmailAccountService) ListEmailAccounts(tenantID, keywords string) ([]*EmailAccountResponse, common.ErrorCode, error) {
+	accounts, err := s.accountDAO.ListByTenant(tenantID, keywords)
+	if err != nil {
+		return nil, common.CodeServerError, err
+	}
+	out := make([]*EmailAccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		out = append(out, newEmailAccountResponse(account))
+	}
+	return out, common.CodeSuccess, nil
+}
+
+// UpdateEmailAccount updates a tenant's account. An empty password
+// keeps the stored one.
+func (s *EmailAccountService) UpdateEmailAccount(tenantID, accountID string, req UpdateEmailAccountRequest) (*EmailAccountResponse, common.ErrorCode, error) {
+	account, code, err := s.getAccount(tenantID, accountID)
+	if err != nil {
+		return nil, code, err
+	}
+	updates := map[string]interface{}{}
+	oldName := account.Name
+
+	if req.Name != nil {
+		account.Name = strings.TrimSpace(*req.Name)
+		updates["name"] = account.Name
+	}
+	if req.Host != nil {
+		account.Host = strings.TrimSpace(*req.Host)
+		updates["host"] = account.Host
+	}
+	if req.Port != nil {
+		account.Port = *req.Port
+		updates["port"] = account.Port
+	}
+	if req.Security != nil {
+		account.Security = strings.ToLower(strings.TrimSpace(*req.Security))
+		updates["security"] = account.Security
+	}
+	if req.Username != nil {
+		account.Username = strings.TrimSpace(*req.Username)
+		updates["username"] = account.Username
+	}
+	if req.Password != nil && *req.Password != "" {
+		account.Credentials = entity.JSONMap{"password": *req.Password}
+		updates["credentials"] = account.Credentials
+	}
+	if req.Mailbox != nil {
+		account.Mailbox = strings.TrimSpace(*req.Mailbox)
+		if account.Mailbox == "" {
+			account.Mailbox = "INBOX"
+		}
+		updates["mailbox"] = account.Mailbox
+	}
+	if req.Description != nil {
+		account.Description = req.Description
+		updates["description"] = *req.Description
+	}
+	if err := validateEmailAccount(account); err != nil {
+		return nil, common.CodeDataError, err
+	}
+	if account.Name != oldName {
+		exists, err := s.accountDAO.ExistsByNameAndTenant(account.Name, tenantID)
+		if err != nil {
+			return nil, common.CodeServerError, err
+		}
+		if exists {
+			return nil, common.CodeDataError, errors.New("Duplicated email account name.")
+		}
+	}
+	if len(updates) > 0 {
+		if _, err := s.accountDAO.Update(accountID, tenantID, updates); err != nil {
+			return nil, common.CodeServerError, fmt.Errorf("failed to update email account %s: %w", accountID, err)
+		}
+	}
+	return newEmailAccountResponse(account), common.CodeSuccess, nil
+}
+
+// DeleteEmailAccount removes a tenant's account. Agents that still list
+// its tool fail to build, and its triggers report errors, until they
+// are edited.
+func (s *EmailAccountService) DeleteEmailAccount(tenantID, accountID string) (common.ErrorCode, error) {
+	deleted, err := s.accountDAO.Delete(accountID, tenantID)
+	if err != nil {
+		return common.CodeServerError, err
+	}
+	if !deleted {
+		return common.CodeDataError, emailAccountNotFoundError(accountID, tenantID)
+	}
+	return common.CodeSuccess, nil
+}
+
+// TestEmailAccount logs in to a tenant's account and lists its
+// mailboxes.
+func (s *EmailAccountService) TestEmailAccount(ctx context.Context, tenantID, accountID string) ([]utility.IMAPMailbox, common.ErrorCode, error) {
+	record, err := agenttool.LoadEmailAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, common.CodeServerError, fmt.Errorf("failed to get email account %s: %w", accountID, err)
+	}
+	if record == nil {
+		return nil, common.CodeDataError, emailAccountNotFoundError(accountID, tenantID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaultEmailTestTimeout)
+	defer cancel()
+	client, err := s.dial(ctx, record)
+	if err != nil {
+		return nil, common.CodeDataError, fmt.Errorf("Failed to connect to email account: %v", err)
+	}
+	defer client.Close()
+	mailboxes, err := client.List("*")
+	if err != nil {
+		return nil, common.CodeDataError, fmt.Errorf("Failed to list mailboxes: %v", err)
+	}
+	if mailboxes == nil {
+		mailboxes = []utility.IMAPMailbox{}
+	}
+	return mailboxes, common.CodeSuccess, nil
+}
+
+func (s *EmailAccountService) getAccount(tenantID, accountID string) (*entity.EmailAccount, common.ErrorCode, error) {
+	account, err := s.accountDAO.GetByIDAndTenant(accountID, tenantID)
+	if err != nil {
+		return nil, common.CodeServerError, fmt.Errorf("failed to get email account %s: %w", accountID, err)
+	}
+	if account == nil {
+		return nil, common.CodeDataError, emailAccountNotFoundError(accountID, tenantID)
+	}
+	return account, common.CodeSuccess, nil
+}
+
+func validateEmailAccount(account *entity.EmailAccount) error {
+	if account.Name == "" || len([]byte(account.Name)) > emailAccountNameLimit {
+		return fmt.Errorf("Invalid email account name or length is %d which is large than 255.", len([]byte(account.Name)))
+	}
+	if account.Host == "" {
+		return errors.New("host is required.")
+	}
+	if account.Port <= 0 || account.Port > 65535 {
+		return errors.New("port must be in [1, 65535].")
+	}
+	if account.Security != utility.IMAPSecurityTLS && account.Security != utility.IMAPSecurityStartTLS {
+		return fmt.Errorf("security must be %q or %q.", utility.IMAPSecurityTLS, utility.IMAPSecurityStartTLS)
+	}
+	if account.Username == "" {
+		return errors.New("username is required.")
+	}
+	if password, _ := account.Credentials["password"].(string); password == "" {
+		return errors.New("password is required.")
+	}
+	return nil
+}
+
+func defaultIMAPPort(security string) int {
+	if security == utility.IMAPSecurityStartTLS {
+		return 143
+	}
+	return 993
+}
+
+func newEmailAccountResponse(account *entity.EmailAccount) *EmailAccountResponse {
+	password, _ := account.Credentials["password"].(string)
+	return &EmailAccountResponse{
+		ID:          account.ID,
+		TenantID:    account.TenantID,
+		Name:        account.Name,
+		Host:        account.Host,
+		Port:        account.Port,
+		Security:    account.Security,
+		Username:    account.Username,
+		Mailbox:     account.Mailbox,
+		Description: account.Description,
+		HasPassword: password != "",
+		ToolName:    agenttool.IMAPToolName(account.ID),
+		CreateDate:  formatMCPServerDate(account.CreateDate),
+		UpdateDate:  formatMCPServerDate(account.UpdateDate),
+	}
+}
+
+func emailAccountNotFoundError(accountID, tenantID string) error {
+	return fmt.Errorf("Cannot find email account %s for user %s", accountID, tenantID)
+}
+
+// ParseEmailAttachment extracts the text of an email attachment with
+// the document parsers used for uploads. The server installs it as the
+// IMAP tool's attachment parser. Files without a document parser are
+// returned as-is when they are UTF-8 text.
+func ParseEmailAttachment(filename string, data []byte) (string, error) {
+	fileType := utility.GetFileType(filename)
+	if fileType == utility.FileTypeOTHER {
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("unsupported attachment type %q", filename)
+		}
+		return string(data), nil
+	}
+	fp, err := parser.GetParser(fileType, map[string]string{"lib_type": "office_oxide"})
+	if err != nil {
+		return "", err
+	}
+	if err := fp.Parse(filename, data); err != nil {
+		return "", fmt.Errorf("parse %s: %w", filename, err)
+	}
+	return fp.String(), nil
+}
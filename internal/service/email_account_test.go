@@ -0,0 +1,140 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	agenttool "ragflow/internal/agent/tool"
+	"ragflow/internal/common"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+	"ragflow/internal/utility"
+	"ragflow/internal/utility/imaptest"
+)
+
+func setupEmailAccountServiceTest(t *testing.T) *EmailAccountService {
+	t.Helper()
+	testDB := setupServiceTestDB(t)
+	if err := testDB.AutoMigrate(&entity.EmailAccount{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	orig := dao.DB
+	dao.DB = testDB
+	t.Cleanup(func() { dao.DB = orig })
+	return NewEmailAccountService()
+}
+
+func TestEmailAccountService_CRUD(t *testing.T) {
+	svc := setupEmailAccountServiceTest(t)
+
+	resp, code, err := svc.CreateEmailAccount("tenant-1", CreateEmailAccountRequest{
+		Name:     "Support",
+		Host:     "imap.example.com",
+		Username: "support@example.com",
+		Password: "secret-pw",
+	})
+	if err != nil {
+		t.Fatalf("CreateEmailAccount: %v (code %d)", err, code)
+	}
+	if resp.Port != 993 || resp.Security != "tls" || resp.Mailbox != "INBOX" || !resp.HasPassword || resp.ToolName != "imap:"+resp.ID {
+		t.Fatalf("resp = %+v", resp)
+	}
+	raw, _ := json.Marshal(resp)
+	if strings.Contains(string(raw), "secret-pw") {
+		t.Fatalf("response leaks the password: %s", raw)
+	}
+
+	for name, req := range map[string]CreateEmailAccountRequest{
+		"duplicate":  {Name: "Support", Host: "h", Username: "u", Password: "p"},
+		"plaintext":  {Name: "Plain", Host: "h", Security: "none", Username: "u", Password: "p"},
+		"no host":    {Name: "NoHost", Username: "u", Password: "p"},
+		"no pass":    {Name: "NoPass", Host: "h", Username: "u"},
+		"bad port":   {Name: "BadPort", Host: "h", Port: 70000, Username: "u", Password: "p"},
+		"empty name": {Host: "h", Username: "u", Password: "p"},
+	} {
+		if _, code, err := svc.CreateEmailAccount("tenant-1", req); err == nil || code != common.CodeDataError {
+			t.Errorf("%s: code=%d err=%v", name, code, err)
+		}
+	}
+
+	security, empty := "starttls", ""
+	updated, _, err := svc.UpdateEmailAccount("tenant-1", resp.ID, UpdateEmailAccountRequest{Security: &security, Password: &empty})
+	if err != nil || updated.Security != "starttls" || !updated.HasPassword {
+		t.Fatalf("update = %+v, %v", updated, err)
+	}
+	record, err := agenttool.LoadEmailAccount(context.Background(), "tenant-1", resp.ID)
+	if err != nil || record == nil || record.Password != "secret-pw" || record.Security != "starttls" {
+		t.Fatalf("loaded record = %+v, %v", record, err)
+	}
+	if r, _ := agenttool.LoadEmailAccount(context.Background(), "tenant-2", resp.ID); r != nil {
+		t.Fatal("tenant-2 loaded tenant-1's account")
+	}
+
+	if list, _, _ := svc.ListEmailAccounts("tenant-2", ""); len(list) != 0 {
+		t.Fatalf("tenant-2 sees %d accounts", len(list))
+	}
+	if _, code, err := svc.GetEmailAccount("tenant-2", resp.ID); err == nil || code != common.CodeDataError {
+		t.Fatalf("cross-tenant get: code=%d err=%v", code, err)
+	}
+	if code, err := svc.DeleteEmailAccount("tenant-1", resp.ID); err != nil {
+		t.Fatalf("delete: %v (code %d)", err, code)
+	}
+	if list, _, _ := svc.ListEmailAccounts("tenant-1", ""); len(list) != 0 {
+		t.Fatalf("accounts after delete = %d", len(list))
+	}
+}
+
+func TestEmailAccountService_Test(t *testing.T) {
+	svc := setupEmailAccountServiceTest(t)
+	srv, err := imaptest.NewServer(imaptest.SecurityTLS, "support@example.com", "pw")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	srv.AddMailbox("Archive", 1)
+
+	resp, _, err := svc.CreateEmailAccount("tenant-1", CreateEmailAccountRequest{
+		Name: "Support", Host: srv.Host, Port: srv.Port, Username: srv.Username, Password: srv.Password,
+	})
+	if err != nil {
+		t.Fatalf("CreateEmailAccount: %v", err)
+	}
+	svc.dial = func(ctx context.Context, account *agenttool.EmailAccountRecord) (*utility.IMAPClient, error) {
+		account.TLSConfig = srv.ClientTLSConfig()
+		return agenttool.DialEmailAccount(ctx, account)
+	}
+	mailboxes, code, err := svc.TestEmailAccount(context.Background(), "tenant-1", resp.ID)
+	if err != nil || len(mailboxes) != 2 {
+		t.Fatalf("TestEmailAccount = %v, %v (code %d)", mailboxes, err, code)
+	}
+	if _, code, err := svc.TestEmailAccount(context.Background(), "tenant-2", resp.ID); err == nil || code != common.CodeDataError {
+		t.Fatalf("cross-tenant test: code=%d err=%v", code, err)
+	}
+}
+
+func TestParseEmailAttachment(t *testing.T) {
+	if text, err := ParseEmailAttachment("notes.txt", []byte("hello")); err != nil || text != "hello" {
+		t.Fatalf("text attachment = %q, %v", text, err)
+	}
+	if _, err := ParseEmailAttachment("photo.jpg", []byte{0xff, 0xd8, 0xff, 0xe0}); err == nil {
+		t.Fatal("binary attachment: expected an error")
+	}
+}
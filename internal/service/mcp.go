@@ -23,8 +23,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	agentsandbox "ragflow/internal/agent/sandbox"
 	"ragflow/internal/common"
 	"ragflow/internal/dao"
 	"ragflow/internal/entity"
@@ -36,6 +38,7 @@ import (
 const (
 	mcpServerTypeSSE            = "sse"
 	mcpServerTypeStreamableHTTP = "streamable-http"
+	mcpServerTypeStdio          = utility.TransportStdio
 	mcpServerNameLimit          = 255
 	defaultMCPFetchTimeoutSec   = 10
 	mcpServerDateFormat         = "2006-01-02T15:04:05"
@@ -49,6 +52,7 @@ type MCPService struct {
 
 // NewMCPService creates an MCP service.
 func NewMCPService() *MCPService {
+	installMCPStdioLauncher()
 	return &MCPService{
 		mcpServerDAO: dao.NewMCPServerDAO(),
 		tenantDAO:    dao.NewTenantDAO(),
@@ -98,6 +102,10 @@ type ExportMCPServer struct {
 	Name               string      `json:"name"`
 	AuthorizationToken interface{} `json:"authorization_token"`
 	Tools              interface{} `json:"tools"`
+	Command            interface{} `json:"command,omitempty"`
+	Args               interface{} `json:"args,omitempty"`
+	Env                interface{} `json:"env,omitempty"`
+	Cwd                interface{} `json:"cwd,omitempty"`
 }
 
 type ExportMCPServerResponse struct {
@@ -128,7 +136,12 @@ func (s *MCPService) CreateMCPServer(tenantID string, req CreateMCPServerRequest
 		return nil, common.CodeDataError, errors.New("Duplicated MCP server name.")
 	}
 
-	if req.URL == "" {
+	variables := safeJSONMap(req.Variables)
+	if req.ServerType == mcpServerTypeStdio {
+		if _, err := utility.StdioServerFromConfig(variables); err != nil {
+			return nil, common.CodeDataError, err
+		}
+	} else if req.URL == "" {
 		return nil, common.CodeDataError, errors.New("Invalid url.")
 	}
 
@@ -137,7 +150,6 @@ func (s *MCPService) CreateMCPServer(tenantID string, req CreateMCPServerRequest
 	}
 
 	headers := safeJSONMap(req.Headers)
-	variables := safeJSONMap(req.Variables)
 	delete(variables, "tools")
 	variables["tools"] = map[string]interface{}{}
 
@@ -204,16 +216,21 @@ func newExportMCPServerResponse(server *entity.MCPServer) *ExportMCPServerRespon
 	if tools == nil {
 		tools = map[string]interface{}{}
 	}
+	export := ExportMCPServer{
+		Type:               server.ServerType,
+		URL:                server.URL,
+		Name:               server.Name,
+		AuthorizationToken: token,
+		Tools:              tools,
+	}
+	if server.ServerType == mcpServerTypeStdio {
+		export.Command = vars["command"]
+		export.Args = vars["args"]
+		export.Env = vars["env"]
+		export.Cwd = vars["cwd"]
+	}
 	return &ExportMCPServerResponse{
-		MCPServers: map[string]ExportMCPServer{
-			server.Name: {
-				Type:               server.ServerType,
-				URL:                server.URL,
-				Name:               server.Name,
-				AuthorizationToken: token,
-				Tools:              tools,
-			},
-		},
+		MCPServers: map[string]ExportMCPServer{server.Name: export},
 	}
 }
 
@@ -260,12 +277,12 @@ func (s *MCPService) UpdateMCPServer(tenantID, mcpID string, req UpdateMCPServer
 		return nil, common.CodeDataError, err
 	} else if ok {
 		serverURL = strings.TrimSpace(value)
-		if serverURL == "" {
+		if serverURL == "" && serverType != mcpServerTypeStdio {
 			return nil, common.CodeDataError, errors.New("Invalid url.")
 		}
 		serverURLProvided = true
 	}
-	if serverURL == "" {
+	if serverURL == "" && serverType != mcpServerTypeStdio {
 		return nil, common.CodeDataError, errors.New("Invalid url.")
 	}
 
@@ -284,6 +301,11 @@ func (s *MCPService) UpdateMCPServer(tenantID, mcpID string, req UpdateMCPServer
 	if variables == nil {
 		variables = entity.JSONMap{}
 	}
+	if serverType == mcpServerTypeStdio {
+		if _, err := utility.StdioServerFromConfig(variables); err != nil {
+			return nil, common.CodeDataError, err
+		}
+	}
 	delete(variables, "tools")
 	variables["tools"] = map[string]interface{}{}
 
@@ -396,7 +418,18 @@ func mcpServerNotFoundError(mcpID, tenantID string) error {
 }
 
 func isValidMCPServerType(serverType string) bool {
-	return serverType == mcpServerTypeSSE || serverType == mcpServerTypeStreamableHTTP
+	return serverType == mcpServerTypeSSE || serverType == mcpServerTypeStreamableHTTP || serverType == mcpServerTypeStdio
+}
+
+var mcpStdioLauncherOnce sync.Once
+
+// installMCPStdioLauncher routes stdio MCP servers through the local
+// sandbox provider so they run under its isolation and resource limits
+// rather than as plain children of the API server.
+func installMCPStdioLauncher() {
+	mcpStdioLauncherOnce.Do(func() {
+		utility.SetStdioLauncher(agentsandbox.NewMCPStdioLauncher())
+	})
 }
 
 func optionalString(req UpdateMCPServerRequest, key string) (string, bool, error) {
@@ -475,7 +508,9 @@ type ImportResult struct {
 }
 
 // ImportServers bulk-imports MCP servers from a {"mcpServers": {name: config}}
-// map. For each entry: validate type and URL, de-duplicate the name with a
+// map. For each entry: validate type and URL (or, for stdio servers, the
+// command; entries with a "command" and no "type" are treated as stdio,
+// as in desktop MCP client configs), de-duplicate the name with a
 // "_N" suffix, fetch the remote tool list via mcpclient (SSRF-guarded), and
 // persist the server with tools stored under variables.tools. Mirrors
 // Python's import_multiple.
@@ -492,7 +527,10 @@ func (s *MCPService) ImportServers(tenantID string, servers map[string]map[strin
 	for serverName, config := range servers {
 		url, hasURL := config["url"].(string)
 		stype, hasType := config["type"].(string)
-		if !hasType || !hasURL {
+		if _, hasCommand := config["command"]; !hasType && hasCommand {
+			stype, hasType = mcpServerTypeStdio, true
+		}
+		if !hasType || (!hasURL && stype != mcpServerTypeStdio) {
 			results = append(results, ImportResult{Server: serverName, Success: false, Message: "Missing required fields (type or url)"})
 			continue
 		}
@@ -504,6 +542,15 @@ func (s *MCPService) ImportServers(tenantID string, servers map[string]map[strin
 			results = append(results, ImportResult{Server: serverName, Success: false, Message: "Unsupported MCP server type."})
 			continue
 		}
+		var stdio *utility.StdioServer
+		if stype == mcpServerTypeStdio {
+			srv, err := utility.StdioServerFromConfig(config)
+			if err != nil {
+				results = append(results, ImportResult{Server: serverName, Success: false, Message: err.Error()})
+				continue
+			}
+			stdio = srv
+		}
 
 		baseName := serverName
 		newName, err := s.nextAvailableMCPName(baseName, tenantID)
@@ -556,6 +603,7 @@ func (s *MCPService) ImportServers(tenantID string, servers map[string]map[strin
 			ServerType: stype,
 			Headers:    headers,
 			Variables:  stringVars,
+			Stdio:      stdio,
 			Timeout:    timeout,
 		})
 		cancel()
@@ -620,19 +668,29 @@ type TestServerRequest struct {
 // advertises. Mirrors Python's test_mcp. mcpID is used for log correlation
 // only.
 func (s *MCPService) TestServer(mcpID string, req *TestServerRequest) ([]map[string]interface{}, error) {
-	if req == nil || req.URL == "" {
+	if req == nil || (req.URL == "" && req.ServerType != mcpServerTypeStdio) {
 		return nil, fmt.Errorf("%w: Invalid MCP url.", ErrMCPInvalidURL)
 	}
 	if !isValidMCPServerType(req.ServerType) {
 		return nil, ErrMCPInvalidType
 	}
 
-	// Run the SSRF guard up front so URL-shape failures (disallowed
-	// scheme, missing host, non-public address) surface as
-	// ErrMCPInvalidURL data errors instead of being swallowed inside the
-	// generic FetchTools error and re-classified by the handler as a 500.
-	// FetchTools repeats the check internally; the second call is cheap.
-	if _, _, err := utility.AssertURLSafe(req.URL); err != nil {
+	// Stdio servers are launched from the command in variables; there is
+	// no URL to guard.
+	var stdio *utility.StdioServer
+	if req.ServerType == mcpServerTypeStdio {
+		srv, err := utility.StdioServerFromConfig(req.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrMCPTestFailed, err.Error())
+		}
+		stdio = srv
+	} else if _, _, err := utility.AssertURLSafe(req.URL); err != nil {
+		// Run the SSRF guard up front so URL-shape failures (disallowed
+		// scheme, missing host, non-public address) surface as
+		// ErrMCPInvalidURL data errors instead of being swallowed inside
+		// the generic FetchTools error and re-classified by the handler
+		// as a 500. FetchTools repeats the check internally; the second
+		// call is cheap.
 		return nil, fmt.Errorf("%w: %s", ErrMCPInvalidURL, err.Error())
 	}
 
@@ -662,6 +720,7 @@ func (s *MCPService) TestServer(mcpID string, req *TestServerRequest) ([]map[str
 		ServerType: req.ServerType,
 		Headers:    headers,
 		Variables:  vars,
+		Stdio:      stdio,
 		Timeout:    timeout,
 	})
 	if err != nil {
@@ -27,12 +27,12 @@ import (
 )
 
 func TestIsValidMCPServerType(t *testing.T) {
-	for _, v := range []string{mcpServerTypeSSE, mcpServerTypeStreamableHTTP} {
+	for _, v := range []string{mcpServerTypeSSE, mcpServerTypeStreamableHTTP, mcpServerTypeStdio} {
 		if !isValidMCPServerType(v) {
 			t.Errorf("expected %q to be a valid MCP server type", v)
 		}
 	}
-	for _, v := range []string{"", "stdio", "http", "SSE"} {
+	for _, v := range []string{"", "websocket", "http", "SSE"} {
 		if isValidMCPServerType(v) {
 			t.Errorf("expected %q to be an invalid MCP server type", v)
 		}
@@ -53,9 +53,15 @@ func TestServerInputValidation(t *testing.T) {
 	}
 
 	// Invalid server type is rejected before connecting.
-	if _, err := s.TestServer("id-1", &TestServerRequest{URL: "http://example.com/sse", ServerType: "stdio"}); !errors.Is(err, ErrMCPInvalidType) {
+	if _, err := s.TestServer("id-1", &TestServerRequest{URL: "http://example.com/sse", ServerType: "websocket"}); !errors.Is(err, ErrMCPInvalidType) {
 		t.Errorf("expected ErrMCPInvalidType for bad type, got %v", err)
 	}
+
+	// Stdio servers need no URL but do need a command.
+	_, err := s.TestServer("id-1", &TestServerRequest{ServerType: mcpServerTypeStdio, Variables: map[string]interface{}{"args": []interface{}{"x"}}})
+	if !errors.Is(err, ErrMCPTestFailed) || !strings.Contains(err.Error(), "requires a command") {
+		t.Errorf("expected missing-command error for stdio, got %v", err)
+	}
 }
 
 func TestImportServersValidationErrors(t *testing.T) {
@@ -65,14 +71,16 @@ func TestImportServersValidationErrors(t *testing.T) {
 	// failing the batch.
 	configs := map[string]map[string]interface{}{
 		"missing-fields": {"foo": "bar"},
-		"bad-type":       {"url": "http://example.com", "type": "stdio"},
+		"bad-type":       {"url": "http://example.com", "type": "websocket"},
+		"stdio-no-cmd":   {"type": "stdio", "args": []interface{}{"serve"}},
+		"stdio-bad-args": {"command": "/usr/bin/mcp", "args": "serve"},
 	}
 	results, err := s.ImportServers("tenant-1", configs, 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(results) != 2 {
-		t.Fatalf("expected 2 results, got %d", len(results))
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
 	}
 	for _, r := range results {
 		if r.Success {
@@ -84,6 +92,13 @@ func TestImportServersValidationErrors(t *testing.T) {
 		if r.Server == "bad-type" && !strings.Contains(r.Message, "Unsupported MCP server type") {
 			t.Errorf("unexpected message for bad-type: %q", r.Message)
 		}
+		if r.Server == "stdio-no-cmd" && !strings.Contains(r.Message, "requires a command") {
+			t.Errorf("unexpected message for stdio-no-cmd: %q", r.Message)
+		}
+		// An entry with a command and no type is read as stdio.
+		if r.Server == "stdio-bad-args" && !strings.Contains(r.Message, "args must be a list") {
+			t.Errorf("unexpected message for stdio-bad-args: %q", r.Message)
+		}
 	}
 }
 
@@ -149,6 +164,37 @@ func TestNewExportMCPServerResponseDefaultsMissingVariablesLikePython(t *testing
 	}
 }
 
+func TestNewExportMCPServerResponseIncludesStdioCommand(t *testing.T) {
+	response := newExportMCPServerResponse(&entity.MCPServer{
+		Name:       "files",
+		ServerType: mcpServerTypeStdio,
+		Variables: entity.JSONMap{
+			"command": "/usr/local/bin/mcp-files",
+			"args":    []interface{}{"--root", "/data"},
+			"env":     map[string]interface{}{"LOG_LEVEL": "warn"},
+		},
+	})
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("marshal response: %v", err)
+	}
+	var decoded map[string]map[string]map[string]interface{}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	server := decoded["mcpServers"]["files"]
+	if server["command"] != "/usr/local/bin/mcp-files" {
+		t.Fatalf("command = %v", server["command"])
+	}
+	if args, _ := server["args"].([]interface{}); len(args) != 2 {
+		t.Fatalf("args = %#v", server["args"])
+	}
+	if _, ok := server["cwd"]; ok {
+		t.Fatalf("cwd should be omitted when unset: %#v", server)
+	}
+}
+
 func TestPaginateMCPServersNegativeValuesMatchPythonSlice(t *testing.T) {
 	servers := makeMCPServers(13)
 
@@ -1531,6 +1531,37 @@ func (s *MemoryService) GetMemoryMessages(ctx context.Context, userID, memoryID
 	}).(map[string]interface{}), nil
 }
 
+// CountMemoryMessages returns the number of raw messages stored in a
+// memory. It skips the access check and is meant for internal callers
+// such as the agent trigger "memory.threshold" event watcher, which
+// checks access itself before every poll.
+func (s *MemoryService) CountMemoryMessages(ctx context.Context, memoryID string) (int64, error) {
+	if s.docEngine == nil {
+		return 0, errors.New("message store is not initialized")
+	}
+	memory, err := s.memoryDAO.GetByID(memoryID)
+	if err != nil {
+		return 0, err
+	}
+	result, err := s.docEngine.Search(ctx, &enginetypes.SearchRequest{
+		IndexNames:   memorySearchIndexNames([]*entity.Memory{memory}),
+		Limit:        1,
+		SelectFields: []string{"message_id"},
+		Filter: map[string]interface{}{
+			"message_type": "raw",
+			"memory_id":    []string{memory.ID},
+		},
+		MatchExprs: []interface{}{},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if result == nil {
+		return 0, nil
+	}
+	return result.Total, nil
+}
+
 func (s *MemoryService) listMemoryMessages(ctx context.Context, memory *entity.Memory, agentIDs []string, keywords string, page int, pageSize int) (map[string]interface{}, error) {
 	if s.docEngine == nil {
 		return nil, errors.New("message store is not initialized")
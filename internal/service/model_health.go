@@ -0,0 +1,239 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ragflow/internal/common"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+	modelModule "ragflow/internal/entity/models"
+)
+
+const (
+	// defaultModelHealthProbeInterval is how often every active model
+	// instance is probed when RAGFLOW_MODEL_HEALTH_PROBE_INTERVAL is unset.
+	defaultModelHealthProbeInterval = 5 * time.Minute
+	// modelHealthDegradedAfter is the number of consecutive failed probes
+	// after which an instance is marked degraded.
+	modelHealthDegradedAfter = 3
+	// maxProbeErrorLength matches the probe_error column size.
+	maxProbeErrorLength = 512
+)
+
+// ModelHealthProbeInterval returns the probe interval configured through
+// RAGFLOW_MODEL_HEALTH_PROBE_INTERVAL (seconds). A value of 0 disables the
+// prober; an unset or invalid value falls back to the default.
+func ModelHealthProbeInterval() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("RAGFLOW_MODEL_HEALTH_PROBE_INTERVAL"))
+	if raw == "" {
+		return defaultModelHealthProbeInterval
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 {
+		common.Warn("Invalid RAGFLOW_MODEL_HEALTH_PROBE_INTERVAL, using default", zap.String("value", raw))
+		return defaultModelHealthProbeInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ModelHealthProber periodically calls CheckConnection on every active
+// tenant model instance, records latency and error state on the instance
+// row, and marks instances degraded after repeated failures so model
+// resolution stops routing requests to them. A single successful probe
+// restores an instance to healthy.
+type ModelHealthProber struct {
+	providerDAO *dao.TenantModelProviderDAO
+	instanceDAO *dao.TenantModelInstanceDAO
+	// check is the connectivity probe; tests replace it.
+	check func(providerName string, instance *entity.TenantModelInstance) error
+	now   func() time.Time
+}
+
+// NewModelHealthProber creates a prober that checks instances through their
+// provider's model driver.
+func NewModelHealthProber() *ModelHealthProber {
+	return &ModelHealthProber{
+		providerDAO: dao.NewTenantModelProviderDAO(),
+		instanceDAO: dao.NewTenantModelInstanceDAO(),
+		check:       checkModelInstanceConnection,
+		now:         time.Now,
+	}
+}
+
+// ProbeAll probes every active instance once. It is the job run by the
+// scheduled task; failures are logged and never abort the sweep.
+func (p *ModelHealthProber) ProbeAll() {
+	instances, err := p.instanceDAO.ListActive()
+	if err != nil {
+		common.Warn("Model health probe: failed to list instances", zap.Error(err))
+		return
+	}
+
+	providerNames := make(map[string]string)
+	for _, instance := range instances {
+		providerName, ok := providerNames[instance.ProviderID]
+		if !ok {
+			provider, err := p.providerDAO.GetByID(instance.ProviderID)
+			if err != nil {
+				common.Warn("Model health probe: provider lookup failed",
+					zap.String("instance_id", instance.ID), zap.Error(err))
+				continue
+			}
+			providerName = provider.ProviderName
+			providerNames[instance.ProviderID] = providerName
+		}
+		if err := p.Probe(providerName, instance); err != nil {
+			common.Warn("Model health probe: failed to record result",
+				zap.String("instance_id", instance.ID), zap.Error(err))
+		}
+	}
+}
+
+// Probe checks one instance and persists the outcome. The returned error
+// reports a failure to store the result, not a failed probe.
+func (p *ModelHealthProber) Probe(providerName string, instance *entity.TenantModelInstance) error {
+	start := p.now()
+	checkErr := p.check(providerName, instance)
+	latency := p.now().Sub(start).Milliseconds()
+
+	status := entity.ModelInstanceHealthHealthy
+	failures := 0
+	probeError := ""
+	if isProbeUnsupported(checkErr) {
+		// The driver has no real connectivity check; the instance's health
+		// cannot be observed, so it must never be degraded by the prober.
+		status = entity.ModelInstanceHealthUnknown
+	} else if checkErr != nil {
+		failures = instance.ProbeFailures + 1
+		probeError = checkErr.Error()
+		if len(probeError) > maxProbeErrorLength {
+			probeError = probeError[:maxProbeErrorLength]
+		}
+		// Keep the previous state until the threshold is reached so a
+		// single transient error does not disable the instance.
+		status = instance.HealthStatus
+		if status == "" {
+			status = entity.ModelInstanceHealthUnknown
+		}
+		if failures >= modelHealthDegradedAfter {
+			status = entity.ModelInstanceHealthDegraded
+		}
+		if status == entity.ModelInstanceHealthDegraded && instance.HealthStatus != entity.ModelInstanceHealthDegraded {
+			common.Warn("Model instance marked degraded",
+				zap.String("provider", providerName),
+				zap.String("instance", instance.InstanceName),
+				zap.String("error", probeError))
+		}
+	}
+
+	probeTime := p.now().UnixMilli()
+	if err := p.instanceDAO.UpdateHealth(instance.ID, status, latency, probeError, failures, probeTime); err != nil {
+		return err
+	}
+	instance.HealthStatus = status
+	instance.ProbeLatencyMs = latency
+	instance.ProbeError = probeError
+	instance.ProbeFailures = failures
+	instance.LastProbeTime = &probeTime
+	return nil
+}
+
+// checkModelInstanceConnection runs the provider driver's CheckConnection
+// against the instance's credentials and endpoint.
+func checkModelInstanceConnection(providerName string, instance *entity.TenantModelInstance) error {
+	driver, apiConfig, err := modelInstanceDriver(providerName, instance)
+	if err != nil {
+		return err
+	}
+	return driver.CheckConnection(apiConfig)
+}
+
+// isProbeUnsupported reports whether err is the "no such method" stub a
+// driver returns from CheckConnection when it cannot probe its provider.
+func isProbeUnsupported(err error) bool {
+	return err != nil && strings.HasSuffix(err.Error(), "no such method")
+}
+
+// modelInstanceDriver resolves the driver and API config for an instance,
+// honouring a per-instance base_url override.
+func modelInstanceDriver(providerName string, instance *entity.TenantModelInstance) (modelModule.ModelDriver, *modelModule.APIConfig, error) {
+	providerInfo := dao.GetModelProviderManager().FindProvider(providerName)
+	if providerInfo == nil {
+		return nil, nil, fmt.Errorf("provider %s not found", providerName)
+	}
+
+	var extra map[string]string
+	if err := json.Unmarshal([]byte(instance.Extra), &extra); err != nil {
+		return nil, nil, err
+	}
+
+	region := extra["region"]
+	apiConfig := &modelModule.APIConfig{
+		ApiKey: &instance.APIKey,
+		Region: &region,
+	}
+
+	driver := providerInfo.ModelDriver
+	if baseURL, ok := extra["base_url"]; ok && baseURL != "" {
+		var err error
+		driver, err = newModelDriverForBaseURL(driver, providerName, region, baseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return driver, apiConfig, nil
+}
+
+// checkModelInstanceHealthy rejects instances the health prober has marked
+// degraded so callers fail fast instead of waiting on a broken endpoint.
+// Rows degraded only because their driver lacks a probe are let through.
+func checkModelInstanceHealthy(providerName string, instance *entity.TenantModelInstance) error {
+	if instance != nil && instance.HealthStatus == entity.ModelInstanceHealthDegraded &&
+		!strings.HasSuffix(instance.ProbeError, "no such method") {
+		return fmt.Errorf("model instance %s/%s is degraded: %s", providerName, instance.InstanceName, instance.ProbeError)
+	}
+	return nil
+}
+
+// modelInstanceHealthFields returns the health columns in the snake_case
+// shape used by the providers API.
+func modelInstanceHealthFields(instance *entity.TenantModelInstance) map[string]interface{} {
+	healthStatus := instance.HealthStatus
+	if healthStatus == "" {
+		healthStatus = entity.ModelInstanceHealthUnknown
+	}
+	var lastProbeTime interface{}
+	if instance.LastProbeTime != nil {
+		lastProbeTime = *instance.LastProbeTime
+	}
+	return map[string]interface{}{
+		"health_status":    healthStatus,
+		"probe_latency_ms": instance.ProbeLatencyMs,
+		"probe_error":      instance.ProbeError,
+		"probe_failures":   instance.ProbeFailures,
+		"last_probe_time":  lastProbeTime,
+	}
+}
@@ -0,0 +1,193 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ragflow/internal/entity"
+	modelModule "ragflow/internal/entity/models"
+)
+
+func newTestModelHealthProber(check func(string, *entity.TenantModelInstance) error) *ModelHealthProber {
+	prober := NewModelHealthProber()
+	prober.check = check
+	return prober
+}
+
+func reloadTestModelInstance(t *testing.T, id string) *entity.TenantModelInstance {
+	t.Helper()
+	instance, err := NewModelProviderService().modelInstanceDAO.GetByID(id)
+	if err != nil {
+		t.Fatalf("failed to reload instance %s: %v", id, err)
+	}
+	return instance
+}
+
+func TestModelHealthProberMarksDegradedAfterRepeatedFailures(t *testing.T) {
+	db := setupModelProviderServiceTestDB(t)
+	useModelProviderServiceTestDB(t, db)
+	seedModelProviderServiceScope(t, db)
+
+	prober := newTestModelHealthProber(func(string, *entity.TenantModelInstance) error {
+		return errors.New("connection refused")
+	})
+
+	for i := 1; i <= modelHealthDegradedAfter; i++ {
+		prober.ProbeAll()
+		instance := reloadTestModelInstance(t, "instance-1")
+		if instance.ProbeFailures != i {
+			t.Fatalf("probe %d: failures = %d, want %d", i, instance.ProbeFailures, i)
+		}
+		if instance.ProbeError != "connection refused" {
+			t.Fatalf("probe %d: error = %q", i, instance.ProbeError)
+		}
+		if instance.LastProbeTime == nil {
+			t.Fatalf("probe %d: last_probe_time not recorded", i)
+		}
+		want := entity.ModelInstanceHealthUnknown
+		if i == modelHealthDegradedAfter {
+			want = entity.ModelInstanceHealthDegraded
+		}
+		if instance.HealthStatus != want {
+			t.Fatalf("probe %d: health = %q, want %q", i, instance.HealthStatus, want)
+		}
+	}
+
+	err := checkModelInstanceHealthy("OpenAI", reloadTestModelInstance(t, "instance-1"))
+	if err == nil || !strings.Contains(err.Error(), "degraded") {
+		t.Fatalf("checkModelInstanceHealthy() error = %v, want degraded error", err)
+	}
+}
+
+func TestModelHealthProberNeverDegradesStubDriver(t *testing.T) {
+	db := setupModelProviderServiceTestDB(t)
+	useModelProviderServiceTestDB(t, db)
+	seedModelProviderServiceScope(t, db)
+
+	// Baichuan has no connectivity check: CheckConnection is a stub.
+	driver := modelModule.NewBaichuanModel(map[string]string{"default": "http://127.0.0.1:1"}, modelModule.URLSuffix{})
+	prober := newTestModelHealthProber(func(_ string, instance *entity.TenantModelInstance) error {
+		return driver.CheckConnection(&modelModule.APIConfig{ApiKey: &instance.APIKey})
+	})
+
+	for i := 0; i < modelHealthDegradedAfter+1; i++ {
+		prober.ProbeAll()
+	}
+	instance := reloadTestModelInstance(t, "instance-1")
+	if instance.HealthStatus != entity.ModelInstanceHealthUnknown {
+		t.Fatalf("health = %q, want unknown", instance.HealthStatus)
+	}
+	if instance.ProbeFailures != 0 || instance.ProbeError != "" {
+		t.Fatalf("failures = %d error = %q, want none", instance.ProbeFailures, instance.ProbeError)
+	}
+	if err := checkModelInstanceHealthy("Baichuan", instance); err != nil {
+		t.Fatalf("checkModelInstanceHealthy() error = %v", err)
+	}
+
+	// A row degraded by the stub before probes learned to skip it still
+	// resolves.
+	instance.HealthStatus = entity.ModelInstanceHealthDegraded
+	instance.ProbeError = "no such method"
+	if err := checkModelInstanceHealthy("Baichuan", instance); err != nil {
+		t.Fatalf("checkModelInstanceHealthy() on stale row error = %v", err)
+	}
+}
+
+func TestModelHealthProberRecoversOnSuccess(t *testing.T) {
+	db := setupModelProviderServiceTestDB(t)
+	useModelProviderServiceTestDB(t, db)
+	seedModelProviderServiceScope(t, db)
+	if err := db.Model(&entity.TenantModelInstance{}).Where("id = ?", "instance-1").Updates(map[string]interface{}{
+		"health_status":  entity.ModelInstanceHealthDegraded,
+		"probe_failures": 5,
+		"probe_error":    "timeout",
+	}).Error; err != nil {
+		t.Fatalf("failed to seed degraded instance: %v", err)
+	}
+
+	prober := newTestModelHealthProber(func(string, *entity.TenantModelInstance) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+	prober.ProbeAll()
+
+	instance := reloadTestModelInstance(t, "instance-1")
+	if instance.HealthStatus != entity.ModelInstanceHealthHealthy {
+		t.Fatalf("health = %q, want healthy", instance.HealthStatus)
+	}
+	if instance.ProbeFailures != 0 || instance.ProbeError != "" {
+		t.Fatalf("failures = %d error = %q, want reset", instance.ProbeFailures, instance.ProbeError)
+	}
+	if instance.ProbeLatencyMs <= 0 {
+		t.Fatalf("latency = %d, want > 0", instance.ProbeLatencyMs)
+	}
+	if err := checkModelInstanceHealthy("OpenAI", instance); err != nil {
+		t.Fatalf("checkModelInstanceHealthy() error = %v", err)
+	}
+}
+
+func TestModelHealthProberSkipsInactiveInstances(t *testing.T) {
+	db := setupModelProviderServiceTestDB(t)
+	useModelProviderServiceTestDB(t, db)
+	seedModelProviderServiceScope(t, db)
+	if err := db.Create(&entity.TenantModelInstance{ID: "instance-2", ProviderID: "provider-1", InstanceName: "off", APIKey: "sk-test", Status: "inactive", Extra: "{}"}).Error; err != nil {
+		t.Fatalf("failed to seed inactive instance: %v", err)
+	}
+
+	var probed []string
+	prober := newTestModelHealthProber(func(providerName string, instance *entity.TenantModelInstance) error {
+		if providerName != "OpenAI" {
+			t.Fatalf("provider = %q, want OpenAI", providerName)
+		}
+		probed = append(probed, instance.ID)
+		return nil
+	})
+	prober.ProbeAll()
+
+	if len(probed) != 1 || probed[0] != "instance-1" {
+		t.Fatalf("probed = %v, want [instance-1]", probed)
+	}
+}
+
+func TestShowProviderInstanceIncludesHealth(t *testing.T) {
+	db := setupModelProviderServiceTestDB(t)
+	useModelProviderServiceTestDB(t, db)
+	seedModelProviderServiceScope(t, db)
+
+	prober := newTestModelHealthProber(func(string, *entity.TenantModelInstance) error {
+		return errors.New("401 unauthorized")
+	})
+	prober.ProbeAll()
+
+	shown, _, err := NewModelProviderService().ShowProviderInstance("OpenAI", "default", "user-1")
+	if err != nil {
+		t.Fatalf("ShowProviderInstance() error = %v", err)
+	}
+	if shown["health_status"] != entity.ModelInstanceHealthUnknown {
+		t.Fatalf("health_status = %v", shown["health_status"])
+	}
+	if shown["probe_error"] != "401 unauthorized" || shown["probe_failures"] != 1 {
+		t.Fatalf("probe fields = %v / %v", shown["probe_error"], shown["probe_failures"])
+	}
+	if shown["last_probe_time"] == nil {
+		t.Fatalf("last_probe_time missing")
+	}
+}
@@ -430,14 +430,18 @@ func (m *ModelProviderService) ListProviderInstances(providerName, userID string
 		// web/src/pages/user-setting/setting-model/components/used-model.tsx
 		// and made `useFetchInstanceModels(providerName,
 		// instance.instance_name)` hit `/api/v1/providers/<p>/instances/undefined/models`.
-		result = append(result, map[string]interface{}{
+		item := map[string]interface{}{
 			"id":            instance.ID,
 			"instance_name": instance.InstanceName,
 			"provider_id":   instance.ProviderID,
 			"api_key":       instance.APIKey,
 			"status":        instance.Status,
 			"extra":         instance.Extra,
-		})
+		}
+		for key, value := range modelInstanceHealthFields(instance) {
+			item[key] = value
+		}
+		result = append(result, item)
 	}
 
 	return result, common.CodeSuccess, nil
@@ -489,6 +493,9 @@ func (m *ModelProviderService) ShowProviderInstance(providerName, instanceName,
 		"region":        extra["region"],
 		"base_url":      extra["base_url"],
 	}
+	for key, value := range modelInstanceHealthFields(instance) {
+		result[key] = value
+	}
 
 	return result, common.CodeSuccess, nil
 }
@@ -612,34 +619,11 @@ func (m *ModelProviderService) CheckInstanceConnection(providerName, instanceNam
 		return common.CodeServerError, err
 	}
 
-	providerInfo := dao.GetModelProviderManager().FindProvider(providerName)
-	if providerInfo == nil {
-		return common.CodeServerError, fmt.Errorf("provider %s not found", providerName)
-	}
-
-	var extra map[string]string
-	err = json.Unmarshal([]byte(instance.Extra), &extra)
+	driver, apiConfig, err := modelInstanceDriver(providerName, instance)
 	if err != nil {
 		return common.CodeServerError, err
 	}
 
-	apiConfig := &modelModule.APIConfig{
-		ApiKey: nil,
-		Region: nil,
-	}
-
-	region := extra["region"]
-	apiConfig.Region = &region
-	apiConfig.ApiKey = &instance.APIKey
-
-	driver := providerInfo.ModelDriver
-	if baseURL, ok := extra["base_url"]; ok && baseURL != "" {
-		driver, err = newModelDriverForBaseURL(driver, providerName, region, baseURL)
-		if err != nil {
-			return common.CodeServerError, err
-		}
-	}
-
 	err = driver.CheckConnection(apiConfig)
 	if err != nil {
 		return common.CodeServerError, err
@@ -1342,6 +1326,9 @@ func (m *ModelProviderService) getModelInstanceAndProviderByName(providerName, i
 	if err != nil {
 		return nil, err
 	}
+	if err = checkModelInstanceHealthy(*providerName, instanceEntity); err != nil {
+		return nil, err
+	}
 
 	modelEntity, err := m.modelDAO.GetModelByProviderIDAndInstanceIDAndModelName(providerEntity.ID, instanceEntity.ID, *modelName)
 	if err != nil {
@@ -1423,6 +1410,9 @@ func (m *ModelProviderService) getModelInstanceAndProviderByID(modelID *string,
 	if providerEntity.TenantID != tenantID {
 		return nil, errors.New("provider not found")
 	}
+	if err = checkModelInstanceHealthy(providerEntity.ProviderName, instanceEntity); err != nil {
+		return nil, err
+	}
 
 	providerInfo := dao.GetModelProviderManager().FindProvider(providerEntity.ProviderName)
 	if providerInfo == nil {
@@ -1523,7 +1513,11 @@ func (m *ModelProviderService) ChatToModelWithMessages(providerName, instanceNam
 		}
 	}
 
-	response, err = modelDriver.ChatWithMessages(resolvedModelName, messages, info.APIConfig, modelConfig)
+	if modelConfig.ResponseSchema != nil {
+		response, _, err = modelModule.ChatWithResponseSchema(modelDriver, resolvedModelName, messages, info.APIConfig, modelConfig, structuredOutputMaxRepairs)
+	} else {
+		response, err = modelDriver.ChatWithMessages(resolvedModelName, messages, info.APIConfig, modelConfig)
+	}
 	if err != nil {
 		return nil, common.CodeServerError, err
 	}
@@ -1534,6 +1528,10 @@ func (m *ModelProviderService) ChatToModelWithMessages(providerName, instanceNam
 	return response, common.CodeSuccess, nil
 }
 
+// structuredOutputMaxRepairs bounds how many times an answer that violates
+// ChatConfig.ResponseSchema is sent back to the model for correction.
+const structuredOutputMaxRepairs = 2
+
 // ChatToModelStreamWithSender streams chat response directly via sender function ( the best performance, no channel)
 func (m *ModelProviderService) ChatToModelStreamWithSender(providerName, instanceName, modelName, modelID *string, userID string, messages []modelModule.Message, apiConfig *modelModule.APIConfig, modelConfig *modelModule.ChatConfig, sender func(*string, *string) error) (common.ErrorCode, error) {
 
@@ -2079,6 +2077,72 @@ func (m *ModelProviderService) ParseFile(providerName, instanceName, modelName,
 	return response, common.CodeSuccess, nil
 }
 
+// GenerateImage generates images from a text prompt with an image_generation model
+func (m *ModelProviderService) GenerateImage(providerName, instanceName, modelName, modelID *string, userID string, prompt string, apiConfig *modelModule.APIConfig, modelConfig *modelModule.ImageGenerationConfig) (*modelModule.ImageGenerationResponse, common.ErrorCode, error) {
+
+	var err error
+	var info *ModelInstanceAndProviderInfo
+
+	if modelID != nil {
+		info, err = m.getModelInstanceAndProviderByID(modelID, userID, apiConfig)
+		if err != nil || info == nil {
+			return nil, common.CodeNotFound, err
+		}
+	} else {
+		info, err = m.getModelInstanceAndProviderByName(providerName, instanceName, modelName, userID, apiConfig)
+		if err != nil || info == nil {
+			return nil, common.CodeNotFound, err
+		}
+	}
+
+	if modelConfig == nil {
+		modelConfig = &modelModule.ImageGenerationConfig{}
+	}
+
+	// Resolve the names from info: on the model_id path the name
+	// pointers are nil.
+	resolvedModelName := info.ModelInfo.Name
+	if info.ModelEntity != nil && info.ModelEntity.ModelName != "" {
+		resolvedModelName = info.ModelEntity.ModelName
+	}
+	resolvedProviderName := info.ProviderEntity.ProviderName
+
+	var modelDriver modelModule.ModelDriver
+
+	modelType := string(entity.ModelTypeImageGeneration)
+	if info.ModelEntity == nil {
+		if !info.ModelInfo.ModelTypeMap[modelType] {
+			return nil, common.CodeNotFound, errors.New(fmt.Sprintf("expect model %s@%s is an image generation model", resolvedModelName, resolvedProviderName))
+		}
+		modelDriver = info.ProviderInfo.ModelDriver
+	} else {
+		// model entity exists
+		if info.ModelEntity.Status == "active" {
+			if info.ModelEntity.ModelType != modelType {
+				return nil, common.CodeNotFound, errors.New(fmt.Sprintf("expect model %s@%s is an image generation model", resolvedModelName, resolvedProviderName))
+			}
+
+			modelDriver, err = newModelDriverForBaseURL(info.ProviderInfo.ModelDriver, resolvedProviderName, *info.APIConfig.Region, *info.APIConfig.BaseURL)
+			if err != nil {
+				return nil, common.CodeServerError, err
+			}
+		} else {
+			return nil, common.CodeServerError, errors.New("model is inactive")
+		}
+	}
+
+	var response *modelModule.ImageGenerationResponse
+	response, err = modelDriver.GenerateImage(&resolvedModelName, prompt, apiConfig, modelConfig)
+	if err != nil {
+		return nil, common.CodeServerError, err
+	}
+	if response == nil || len(response.Images) == 0 {
+		return nil, common.CodeServerError, errors.New("empty image generation response")
+	}
+
+	return response, common.CodeSuccess, nil
+}
+
 // GetEmbeddingModel returns an EmbeddingModel wrapper for the given tenant
 func (m *ModelProviderService) GetEmbeddingModel(tenantID, compositeModelName string) (*modelModule.EmbeddingModel, error) {
 	driver, modelName, apiConfig, maxTokens, err := m.getModelConfig(tenantID, compositeModelName)
@@ -2088,6 +2152,19 @@ func (m *ModelProviderService) GetEmbeddingModel(tenantID, compositeModelName st
 	return modelModule.NewEmbeddingModel(driver, &modelName, apiConfig, maxTokens), nil
 }
 
+// GetImageEmbeddingModel returns an EmbeddingModel for a multimodal model
+// that can embed images, rejecting models whose driver only embeds text.
+func (m *ModelProviderService) GetImageEmbeddingModel(tenantID, compositeModelName string) (*modelModule.EmbeddingModel, error) {
+	embeddingModel, err := m.GetEmbeddingModel(tenantID, compositeModelName)
+	if err != nil {
+		return nil, err
+	}
+	if !modelModule.SupportsImageEmbedding(embeddingModel.ModelDriver) {
+		return nil, fmt.Errorf("model %s does not support image embeddings", compositeModelName)
+	}
+	return embeddingModel, nil
+}
+
 // GetChatModel  returns a ChatModel wrapper for the given tenant
 func (m *ModelProviderService) GetChatModel(tenantID, compositeModelName string) (*modelModule.ChatModel, error) {
 	driver, modelName, apiConfig, _, err := m.getModelConfig(tenantID, compositeModelName)
@@ -2410,6 +2487,9 @@ func (m *ModelProviderService) GetModelConfigFromProviderInstance(tenantID strin
 	if instance == nil {
 		return nil, "", nil, 0, fmt.Errorf("instance %q not found for model %q", instanceName, modelName)
 	}
+	if err := checkModelInstanceHealthy(providerName, instance); err != nil {
+		return nil, "", nil, 0, err
+	}
 
 	// Decode api_key and extra fields from the instance row
 	apiKey := instance.APIKey
@@ -2536,6 +2616,9 @@ func (m *ModelProviderService) getModelConfig(tenantID, compositeModelName strin
 		if instance == nil {
 			return nil, "", nil, 0, fmt.Errorf("instance %s not found for provider %s", instanceName, providerName)
 		}
+		if err = checkModelInstanceHealthy(providerName, instance); err != nil {
+			return nil, "", nil, 0, err
+		}
 		common.Debug("getModelConfig instance found", zap.String("instanceName", instanceName))
 	}
 
@@ -219,3 +219,27 @@ func TestModelProviderServiceUpdateModelStatusRejectsWrongScopedModelID(t *testi
 		t.Fatalf("code = %v, want %v", code, common.CodeNotFound)
 	}
 }
+
+// TestModelProviderServiceGenerateImageByModelID: the model_id path
+// leaves the name pointers nil; errors must name the model from the
+// resolved records instead of dereferencing them.
+func TestModelProviderServiceGenerateImageByModelID(t *testing.T) {
+	db := setupModelProviderServiceTestDB(t)
+	useModelProviderServiceTestDB(t, db)
+	seedModelProviderServiceScope(t, db)
+	if err := db.Model(&entity.TenantModel{}).Where("id = ?", "model-1").Update("model_name", "gpt-5.4").Error; err != nil {
+		t.Fatalf("failed to rename model: %v", err)
+	}
+
+	modelID := "model-1"
+	_, code, err := NewModelProviderService().GenerateImage(nil, nil, nil, &modelID, "user-1", "a fox", &modelModule.APIConfig{}, nil)
+	if err == nil {
+		t.Fatalf("GenerateImage() error = nil, want chat-model rejection")
+	}
+	if code == common.CodeSuccess {
+		t.Fatalf("code = %v, want failure", code)
+	}
+	if !strings.Contains(err.Error(), "gpt-5.4@OpenAI") {
+		t.Fatalf("error = %v, want resolved model name", err)
+	}
+}
@@ -0,0 +1,141 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package nlp
+
+import (
+	"context"
+	"fmt"
+
+	"ragflow/internal/engine/types"
+	"ragflow/internal/entity/models"
+)
+
+// ChunkTypeImage marks retrieval results that were matched through their
+// image vector rather than their text.
+const ChunkTypeImage = "image"
+
+// imageHitSimilarity is the minimum cosine similarity for an image
+// vector hit. Text-to-image similarities of CLIP-style models sit well
+// below text-to-text ones, so this is lower than the text threshold.
+const imageHitSimilarity = 0.1
+
+// ImageVectorColumn is the column holding image embeddings of dimension
+// dim. It is kept apart from q_<dim>_vec so a dataset can carry a text
+// and a multimodal embedding of the same size side by side.
+func ImageVectorColumn(dim int) string {
+	return fmt.Sprintf("img_%d_vec", dim)
+}
+
+// searchImageVectors embeds the question with the multimodal model and
+// runs a dense-only search over the image vector column, using the same
+// indexes, filters and window as the text search in base.
+func (s *RetrievalService) searchImageVectors(ctx context.Context, question string, imageModel *models.EmbeddingModel, base *types.SearchRequest, topk int) ([]map[string]interface{}, error) {
+	embeddings, err := imageModel.ModelDriver.Embed(imageModel.ModelName, []string{question}, imageModel.APIConfig, &models.EmbeddingConfig{InputType: models.EmbeddingInputText})
+	if err != nil {
+		return nil, fmt.Errorf("embed question for image search: %w", err)
+	}
+	if len(embeddings) == 0 || len(embeddings[0].Embedding) == 0 {
+		return nil, fmt.Errorf("embed question for image search: empty embedding")
+	}
+	vector := embeddings[0].Embedding
+
+	imageRequest := &types.SearchRequest{
+		IndexNames:   base.IndexNames,
+		KbIDs:        base.KbIDs,
+		Offset:       base.Offset,
+		Limit:        base.Limit,
+		Filter:       base.Filter,
+		SelectFields: base.SelectFields,
+		MatchExprs: []interface{}{&types.MatchDenseExpr{
+			VectorColumnName:  ImageVectorColumn(len(vector)),
+			EmbeddingData:     vector,
+			EmbeddingDataType: "float",
+			DistanceType:      "cosine",
+			TopN:              topk,
+			ExtraOptions:      map[string]interface{}{"similarity": imageHitSimilarity},
+		}},
+	}
+	result, err := s.docEngine.Search(ctx, imageRequest)
+	if err != nil {
+		return nil, err
+	}
+	return result.Chunks, nil
+}
+
+// mergeImageHits folds image vector hits into the text search result.
+// Chunks the text search already found keep their position; the rest
+// are appended. The returned map holds the image similarity of every
+// image hit, keyed by chunk ID.
+func (s *RetrievalService) mergeImageHits(result *types.SearchResult, imageChunks []map[string]interface{}) map[string]float64 {
+	scores := make(map[string]float64, len(imageChunks))
+	if len(imageChunks) == 0 {
+		return scores
+	}
+	present := make(map[string]struct{}, len(result.Chunks))
+	for _, id := range s.docEngine.GetChunkIDs(result.Chunks) {
+		present[id] = struct{}{}
+	}
+	for _, chunk := range imageChunks {
+		ids := s.docEngine.GetChunkIDs([]map[string]interface{}{chunk})
+		if len(ids) == 0 {
+			continue
+		}
+		id := ids[0]
+		scores[id] = chunkSearchScore(chunk)
+		if _, ok := present[id]; ok {
+			continue
+		}
+		present[id] = struct{}{}
+		result.Chunks = append(result.Chunks, chunk)
+		result.Total++
+	}
+	return scores
+}
+
+// applyImageScores lifts the similarity of image hits to their image
+// similarity when that is higher than the text-based score. It returns
+// fresh slices so callers that alias sim and vectorSim stay consistent.
+func applyImageScores(ids []string, imageScores map[string]float64, sim, vectorSim []float64) ([]float64, []float64) {
+	if len(imageScores) == 0 {
+		return sim, vectorSim
+	}
+	outSim := append([]float64(nil), sim...)
+	outVec := append([]float64(nil), vectorSim...)
+	for i, id := range ids {
+		score, ok := imageScores[id]
+		if !ok || i >= len(outSim) {
+			continue
+		}
+		if score > outSim[i] {
+			outSim[i] = score
+		}
+		if i < len(outVec) && score > outVec[i] {
+			outVec[i] = score
+		}
+	}
+	return outSim, outVec
+}
+
+// chunkSearchScore reads the engine score of a raw search hit.
+func chunkSearchScore(chunk map[string]interface{}) float64 {
+	for _, key := range []string{"_score", "SCORE", "SIMILARITY"} {
+		if score, ok := chunk[key].(float64); ok {
+			return score
+		}
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package nlp
+
+import "testing"
+
+func TestImageVectorColumn(t *testing.T) {
+	if got := ImageVectorColumn(768); got != "img_768_vec" {
+		t.Errorf("ImageVectorColumn(768) = %q", got)
+	}
+}
+
+func TestApplyImageScoresLiftsImageHitsOnly(t *testing.T) {
+	sim := []float64{0.5, 0.1, 0.2}
+	ids := []string{"text", "image-low-text", "image-high-text"}
+	scores := map[string]float64{"image-low-text": 0.3, "image-high-text": 0.15}
+
+	// The Infinity path aliases sim and vector similarity; the original
+	// slice must stay untouched.
+	gotSim, gotVec := applyImageScores(ids, scores, sim, sim)
+	want := []float64{0.5, 0.3, 0.2}
+	for i := range want {
+		if gotSim[i] != want[i] || gotVec[i] != want[i] {
+			t.Errorf("index %d: sim=%v vec=%v, want %v", i, gotSim[i], gotVec[i], want[i])
+		}
+	}
+	if sim[1] != 0.1 {
+		t.Errorf("input slice modified: %v", sim)
+	}
+}
+
+func TestApplyImageScoresNoImageHits(t *testing.T) {
+	sim := []float64{0.4}
+	gotSim, _ := applyImageScores([]string{"a"}, nil, sim, sim)
+	if &gotSim[0] != &sim[0] {
+		t.Error("expected the original slice when there are no image hits")
+	}
+}
+
+func TestChunkSearchScoreFallbacks(t *testing.T) {
+	if got := chunkSearchScore(map[string]interface{}{"SIMILARITY": 0.7}); got != 0.7 {
+		t.Errorf("SIMILARITY fallback = %v", got)
+	}
+	if got := chunkSearchScore(map[string]interface{}{}); got != 0 {
+		t.Errorf("missing score = %v", got)
+	}
+}
@@ -59,6 +59,7 @@ type RetrievalRequest struct {
 	RankFeature            *map[string]float64
 	RerankModel            *models.RerankModel
 	EmbeddingModel         *models.EmbeddingModel
+	ImageEmbeddingModel    *models.EmbeddingModel // Optional multimodal model matching the question against image vectors
 	Aggs                   *bool
 	Highlight              *bool
 }
@@ -136,15 +137,16 @@ func (s *RetrievalService) Retrieval(ctx context.Context, req *RetrievalRequest)
 
 	// Execute search via Search()
 	searchReq := &RetrievalSearchRequest{
-		TenantIDs:      req.TenantIDs,
-		Question:       req.Question,
-		KbIDs:          req.KbIDs,
-		DocIDs:         req.DocIDs,
-		Page:           searchPage,
-		PageSize:       rerankLimit,
-		Top:            *req.Top,
-		RankFeature:    *req.RankFeature,
-		EmbeddingModel: req.EmbeddingModel,
+		TenantIDs:           req.TenantIDs,
+		Question:            req.Question,
+		KbIDs:               req.KbIDs,
+		DocIDs:              req.DocIDs,
+		Page:                searchPage,
+		PageSize:            rerankLimit,
+		Top:                 *req.Top,
+		RankFeature:         *req.RankFeature,
+		EmbeddingModel:      req.EmbeddingModel,
+		ImageEmbeddingModel: req.ImageEmbeddingModel,
 	}
 	searchResult, err := s.Search(ctx, searchReq)
 	if err != nil {
@@ -282,6 +284,7 @@ func (s *RetrievalService) Retrieval(ctx context.Context, req *RetrievalRequest)
 	if len(sim) == 0 {
 		return &RetrievalResult{Chunks: []map[string]interface{}{}, DocAggs: []map[string]interface{}{}, Total: 0}, nil
 	}
+	sim, vector_similarity = applyImageScores(searchResult.IDs, searchResult.ImageScores, sim, vector_similarity)
 
 	// Sort indices (positions into search results) by score descending
 	// After sorting by score descending, we process chunks in relevance order
@@ -415,6 +418,9 @@ func (s *RetrievalService) Retrieval(ctx context.Context, req *RetrievalRequest)
 		resultChunk["similarity"] = sim[i]
 		resultChunk["term_similarity"] = term_similarity[i]
 		resultChunk["vector_similarity"] = vector_similarity[i]
+		if _, ok := searchResult.ImageScores[chunkID]; ok {
+			resultChunk["chunk_type"] = ChunkTypeImage
+		}
 
 		// Always set these fields even if empty, to match Python response format
 		if v, ok := chunk["important_kwd"]; ok {
@@ -543,6 +549,7 @@ type RetrievalSearchRequest struct {
 	RankFeature         map[string]float64
 	Filter              map[string]interface{}
 	EmbeddingModel      *models.EmbeddingModel
+	ImageEmbeddingModel *models.EmbeddingModel
 }
 
 type RetrievalSearchResult struct {
@@ -556,12 +563,15 @@ type RetrievalSearchResult struct {
 	Aggregation []map[string]interface{}          // Doc aggregation by field
 	Options     map[string]interface{}            // Engine-specific options (e.g., total from get_total)
 	IndexNames  []string                          // Index names for second-pass queries (e.g., KNN scores)
+	ImageScores map[string]float64                // Chunk ID -> image vector similarity, for chunks matched by image
 }
 
 // Search performs search based on question and EmbeddingModel:
-// - Empty question: list data matching filters, optionally sorted
-// - Non-empty question, no EmbeddingModel: fulltext search only
-// - Non-empty question, with EmbeddingModel: hybrid search (fulltext + vector + fusion)
+//   - Empty question: list data matching filters, optionally sorted
+//   - Non-empty question, no EmbeddingModel: fulltext search only
+//   - Non-empty question, with EmbeddingModel: hybrid search (fulltext + vector + fusion)
+//   - Non-empty question, with ImageEmbeddingModel: additionally a dense search over
+//     the image vector column, whose hits are merged into the result
 //
 // Hybrid search path retries with lower thresholds if no results found.
 func (s *RetrievalService) Search(ctx context.Context, req *RetrievalSearchRequest) (*RetrievalSearchResult, error) {
@@ -612,6 +622,7 @@ func (s *RetrievalService) Search(ctx context.Context, req *RetrievalSearchReque
 	// queryVector tracks the query vector for reranking
 	var engineResult *types.SearchResult
 	var queryVector []float64
+	var imageScores map[string]float64
 	var err error
 
 	if req.Question == "" {
@@ -723,6 +734,18 @@ func (s *RetrievalService) Search(ctx context.Context, req *RetrievalSearchReque
 			queryVector = matchDense.EmbeddingData
 		}
 
+		if req.ImageEmbeddingModel != nil {
+			imageBase := *searchRequest
+			imageBase.SelectFields = src
+			imageChunks, imageErr := s.searchImageVectors(ctx, req.Question, req.ImageEmbeddingModel, &imageBase, topk)
+			if imageErr != nil {
+				// e.g. no chunk of these datasets carries an image vector yet
+				common.Warn("Image vector search failed, returning text hits only", zap.Error(imageErr))
+			} else {
+				imageScores = s.mergeImageHits(engineResult, imageChunks)
+			}
+		}
+
 		// Build kwds from keywords with fine-grained tokenization
 		for _, k := range keywords {
 			kwds[k] = struct{}{}
@@ -777,6 +800,7 @@ func (s *RetrievalService) Search(ctx context.Context, req *RetrievalSearchReque
 		Keywords:    keywordsList,
 		Aggregation: aggregation,
 		IndexNames:  searchRequest.IndexNames,
+		ImageScores: imageScores,
 	}, nil
 }
 
@@ -0,0 +1,424 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	agenttool "ragflow/internal/agent/tool"
+	"ragflow/internal/common"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+const (
+	openAPISpecNameLimit       = 255
+	openAPISpecMaxBytes        = 5 << 20
+	defaultOpenAPIFetchTimeout = 15 * time.Second
+)
+
+// OpenAPIService manages the OpenAPI specs a tenant exposes to the
+// Agent component as tools.
+type OpenAPIService struct {
+	specDAO *dao.OpenAPISpecDAO
+	// fetch downloads a spec by URL. It goes through the agent tools'
+	// SSRF guard so a spec URL cannot be pointed at internal services;
+	// tests replace it.
+	fetch func(ctx context.Context, rawURL string) ([]byte, error)
+}
+
+// NewOpenAPIService creates an OpenAPI service.
+func NewOpenAPIService() *OpenAPIService {
+	return &OpenAPIService{
+		specDAO: dao.NewOpenAPISpecDAO(),
+		fetch:   fetchOpenAPISpec,
+	}
+}
+
+// CreateOpenAPISpecRequest is the request payload for registering a
+// spec. Exactly one of Spec (the uploaded document, JSON or YAML) and
+// URL must be set.
+type CreateOpenAPISpecRequest struct {
+	Name        string          `json:"name"`
+	Spec        string          `json:"spec"`
+	URL         string          `json:"url"`
+	BaseURL     string          `json:"base_url"`
+	Description *string         `json:"description,omitempty"`
+	Credentials json.RawMessage `json:"credentials,omitempty"`
+}
+
+// UpdateOpenAPISpecRequest is the request payload for updating a spec.
+// Nil fields are left unchanged. Setting Refresh re-downloads the
+// document from its source URL.
+type UpdateOpenAPISpecRequest struct {
+	Name        *string         `json:"name"`
+	Spec        *string         `json:"spec"`
+	URL         *string         `json:"url"`
+	BaseURL     *string         `json:"base_url"`
+	Description *string         `json:"description"`
+	Credentials json.RawMessage `json:"credentials,omitempty"`
+	Refresh     bool            `json:"refresh"`
+}
+
+// OpenAPIOperationItem describes one operation of a spec.
+type OpenAPIOperationItem struct {
+	ToolName    string `json:"tool_name"`
+	OperationID string `json:"operation_id"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Summary     string `json:"summary,omitempty"`
+}
+
+// OpenAPISpecResponse is a spec as returned to clients. Stored
+// credentials are never echoed back; only their type is reported.
+type OpenAPISpecResponse struct {
+	ID             string                  `json:"id"`
+	TenantID       string                  `json:"tenant_id"`
+	Name           string                  `json:"name"`
+	SourceURL      string                  `json:"source_url"`
+	BaseURL        string                  `json:"base_url"`
+	Description    *string                 `json:"description"`
+	Title          string                  `json:"title"`
+	Version        string                  `json:"version"`
+	CredentialType string                  `json:"credential_type"`
+	Operations     []*OpenAPIOperationItem `json:"operations"`
+	CreateDate     *string                 `json:"create_date"`
+	UpdateDate     *string                 `json:"update_date"`
+}
+
+// OpenAPIToolItem is an entry in the Agent tool picker.
+type OpenAPIToolItem struct {
+	Name        string `json:"name"`
+	SpecID      string `json:"spec_id"`
+	SpecName    string `json:"spec_name"`
+	OperationID string `json:"operation_id"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
+}
+
+// CreateOpenAPISpec registers a spec for a tenant.
+func (s *OpenAPIService) CreateOpenAPISpec(ctx context.Context, tenantID string, req CreateOpenAPISpecRequest) (*OpenAPISpecResponse, common.ErrorCode, error) {
+	req.URL = strings.TrimSpace(req.URL)
+	if (req.Spec == "") == (req.URL == "") {
+		return nil, common.CodeDataError, errors.New("Provide either an uploaded spec or a spec URL.")
+	}
+	raw := req.Spec
+	if req.URL != "" {
+		body, err := s.fetch(ctx, req.URL)
+		if err != nil {
+			return nil, common.CodeDataError, fmt.Errorf("Failed to fetch OpenAPI spec: %v", err)
+		}
+		raw = string(body)
+	}
+	if len(raw) > openAPISpecMaxBytes {
+		return nil, common.CodeDataError, fmt.Errorf("OpenAPI spec exceeds %d bytes.", openAPISpecMaxBytes)
+	}
+	doc, err := agenttool.ParseOpenAPISpec([]byte(raw))
+	if err != nil {
+		return nil, common.CodeDataError, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = doc.Title
+	}
+	if name == "" || len([]byte(name)) > openAPISpecNameLimit {
+		return nil, common.CodeDataError, fmt.Errorf("Invalid OpenAPI spec name or length is %d which is large than 255.", len([]byte(name)))
+	}
+	exists, err := s.specDAO.ExistsByNameAndTenant(name, tenantID)
+	if err != nil {
+		return nil, common.CodeServerError, err
+	}
+	if exists {
+		return nil, common.CodeDataError, errors.New("Duplicated OpenAPI spec name.")
+	}
+
+	creds := safeJSONMap(req.Credentials)
+	if _, err := agenttool.DecodeOpenAPICredentials(creds); err != nil {
+		return nil, common.CodeDataError, err
+	}
+	baseURL := strings.TrimSpace(req.BaseURL)
+	if baseURL == "" && doc.ServerURL == "" {
+		return nil, common.CodeDataError, errors.New("The spec declares no servers; base_url is required.")
+	}
+
+	spec := &entity.OpenAPISpec{
+		ID:          common.GenerateUUID(),
+		Name:        name,
+		TenantID:    tenantID,
+		SourceURL:   req.URL,
+		BaseURL:     baseURL,
+		Description: req.Description,
+		Spec:        raw,
+		Credentials: creds,
+	}
+	if err := s.specDAO.Create(spec); err != nil {
+		return nil, common.CodeDataError, errors.New("Failed to create OpenAPI spec.")
+	}
+	return newOpenAPISpecResponse(spec, doc), common.CodeSuccess, nil
+}
+
+// GetOpenAPISpec returns a tenant's spec with its operations.
+func (s *OpenAPIService) GetOpenAPISpec(tenantID, specID string) (*OpenAPISpecResponse, common.ErrorCode, error) {
+	spec, code, err := s.getSpec(tenantID, specID)
+	if err != nil {
+		return nil, code, err
+	}
+	doc, err := agenttool.ParseOpenAPISpec([]byte(spec.Spec))
+	if err != nil {
+		return nil, common.CodeDataError, err
+	}
+	return newOpenAPISpecResponse(spec, doc), common.CodeSuccess, nil
+}
+
+// ListOpenAPISpecs lists a tenant's specs.
+func (s *OpenAPIService) ListOpenAPISpecs(tenantID, keywords string) ([]*OpenAPISpecResponse, common.ErrorCode, error) {
+	specs, err := s.specDAO.ListByTenant(tenantID, keywords)
+	if err != nil {
+		return nil, common.CodeServerError, err
+	}
+	out := make([]*OpenAPISpecResponse, 0, len(specs))
+	for _, spec := range specs {
+		// A spec that no longer parses is still listed so it can be
+		// fixed or deleted; it just has no operations.
+		doc, _ := agenttool.ParseOpenAPISpec([]byte(spec.Spec))
+		out = append(out, newOpenAPISpecResponse(spec, doc))
+	}
+	return out, common.CodeSuccess, nil
+}
+
+// UpdateOpenAPISpec updates a tenant's spec.
+func (s *OpenAPIService) UpdateOpenAPISpec(ctx context.Context, tenantID, specID string, req UpdateOpenAPISpecRequest) (*OpenAPISpecResponse, common.ErrorCode, error) {
+	spec, code, err := s.getSpec(tenantID, specID)
+	if err != nil {
+		return nil, code, err
+	}
+	updates := map[string]interface{}{}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len([]byte(name)) > openAPISpecNameLimit {
+			return nil, common.CodeDataError, fmt.Errorf("Invalid OpenAPI spec name or length is %d which is large than 255.", len([]byte(name)))
+		}
+		if name != spec.Name {
+			exists, err := s.specDAO.ExistsByNameAndTenant(name, tenantID)
+			if err != nil {
+				return nil, common.CodeServerError, err
+			}
+			if exists {
+				return nil, common.CodeDataError, errors.New("Duplicated OpenAPI spec name.")
+			}
+		}
+		spec.Name = name
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		spec.Description = req.Description
+		updates["description"] = *req.Description
+	}
+	if req.BaseURL != nil {
+		spec.BaseURL = strings.TrimSpace(*req.BaseURL)
+		updates["base_url"] = spec.BaseURL
+	}
+	if req.Credentials != nil {
+		creds := safeJSONMap(req.Credentials)
+		if _, err := agenttool.DecodeOpenAPICredentials(creds); err != nil {
+			return nil, common.CodeDataError, err
+		}
+		spec.Credentials = creds
+		updates["credentials"] = creds
+	}
+
+	raw := ""
+	switch {
+	case req.Spec != nil:
+		raw = *req.Spec
+		spec.SourceURL = ""
+	case req.URL != nil:
+		spec.SourceURL = strings.TrimSpace(*req.URL)
+		if spec.SourceURL == "" {
+			return nil, common.CodeDataError, errors.New("Invalid url.")
+		}
+		fallthrough
+	case req.Refresh:
+		if spec.SourceURL == "" {
+			return nil, common.CodeDataError, errors.New("The spec was uploaded and has no source URL to refresh from.")
+		}
+		body, err := s.fetch(ctx, spec.SourceURL)
+		if err != nil {
+			return nil, common.CodeDataError, fmt.Errorf("Failed to fetch OpenAPI spec: %v", err)
+		}
+		raw = string(body)
+	}
+	if raw != "" {
+		if len(raw) > openAPISpecMaxBytes {
+			return nil, common.CodeDataError, fmt.Errorf("OpenAPI spec exceeds %d bytes.", openAPISpecMaxBytes)
+		}
+		if _, err := agenttool.ParseOpenAPISpec([]byte(raw)); err != nil {
+			return nil, common.CodeDataError, err
+		}
+		spec.Spec = raw
+		updates["spec"] = raw
+		updates["source_url"] = spec.SourceURL
+	}
+
+	doc, err := agenttool.ParseOpenAPISpec([]byte(spec.Spec))
+	if err != nil {
+		return nil, common.CodeDataError, err
+	}
+	if spec.BaseURL == "" && doc.ServerURL == "" {
+		return nil, common.CodeDataError, errors.New("The spec declares no servers; base_url is required.")
+	}
+	if len(updates) > 0 {
+		if _, err := s.specDAO.Update(specID, tenantID, updates); err != nil {
+			return nil, common.CodeServerError, fmt.Errorf("failed to update OpenAPI spec %s: %w", specID, err)
+		}
+	}
+	return newOpenAPISpecResponse(spec, doc), common.CodeSuccess, nil
+}
+
+// DeleteOpenAPISpec removes a tenant's spec. Agents that still list
+// its tools fail to build until they are edited.
+func (s *OpenAPIService) DeleteOpenAPISpec(tenantID, specID string) (common.ErrorCode, error) {
+	deleted, err := s.specDAO.Delete(specID, tenantID)
+	if err != nil {
+		return common.CodeServerError, err
+	}
+	if !deleted {
+		return common.CodeDataError, openAPISpecNotFoundError(specID, tenantID)
+	}
+	return common.CodeSuccess, nil
+}
+
+// ListOpenAPITools lists every operation of every tenant spec under the
+// tool name the Agent component resolves, for the tool picker.
+func (s *OpenAPIService) ListOpenAPITools(tenantID string) ([]*OpenAPIToolItem, common.ErrorCode, error) {
+	specs, err := s.specDAO.ListByTenant(tenantID, "")
+	if err != nil {
+		return nil, common.CodeServerError, err
+	}
+	out := make([]*OpenAPIToolItem, 0)
+	for _, spec := range specs {
+		doc, err := agenttool.ParseOpenAPISpec([]byte(spec.Spec))
+		if err != nil {
+			continue
+		}
+		for _, op := range doc.Operations {
+			desc := op.Summary
+			if desc == "" {
+				desc = op.Description
+			}
+			out = append(out, &OpenAPIToolItem{
+				Name:        agenttool.OpenAPIToolName(spec.ID, op.ID),
+				SpecID:      spec.ID,
+				SpecName:    spec.Name,
+				OperationID: op.ID,
+				Method:      op.Method,
+				Path:        op.Path,
+				Description: desc,
+			})
+		}
+	}
+	return out, common.CodeSuccess, nil
+}
+
+func (s *OpenAPIService) getSpec(tenantID, specID string) (*entity.OpenAPISpec, common.ErrorCode, error) {
+	spec, err := s.specDAO.GetByIDAndTenant(specID, tenantID)
+	if err != nil {
+		return nil, common.CodeServerError, fmt.Errorf("failed to get OpenAPI spec %s: %w", specID, err)
+	}
+	if spec == nil {
+		return nil, common.CodeDataError, openAPISpecNotFoundError(specID, tenantID)
+	}
+	return spec, common.CodeSuccess, nil
+}
+
+func newOpenAPISpecResponse(spec *entity.OpenAPISpec, doc *agenttool.OpenAPIDocument) *OpenAPISpecResponse {
+	resp := &OpenAPISpecResponse{
+		ID:             spec.ID,
+		TenantID:       spec.TenantID,
+		Name:           spec.Name,
+		SourceURL:      spec.SourceURL,
+		BaseURL:        spec.BaseURL,
+		Description:    spec.Description,
+		CredentialType: agenttool.OpenAPIAuthNone,
+		Operations:     []*OpenAPIOperationItem{},
+		CreateDate:     formatMCPServerDate(spec.CreateDate),
+		UpdateDate:     formatMCPServerDate(spec.UpdateDate),
+	}
+	if creds, err := agenttool.DecodeOpenAPICredentials(spec.Credentials); err == nil {
+		resp.CredentialType = creds.Type
+	}
+	if doc == nil {
+		return resp
+	}
+	resp.Title = doc.Title
+	resp.Version = doc.Version
+	if resp.BaseURL == "" {
+		resp.BaseURL = doc.ServerURL
+	}
+	for _, op := range doc.Operations {
+		resp.Operations = append(resp.Operations, &OpenAPIOperationItem{
+			ToolName:    agenttool.OpenAPIToolName(spec.ID, op.ID),
+			OperationID: op.ID,
+			Method:      op.Method,
+			Path:        op.Path,
+			Summary:     op.Summary,
+		})
+	}
+	return resp
+}
+
+func openAPISpecNotFoundError(specID, tenantID string) error {
+	return fmt.Errorf("Cannot find OpenAPI spec %s for user %s", specID, tenantID)
+}
+
+// fetchOpenAPISpec downloads a spec through the SSRF guard with the
+// connection pinned to the validated IP.
+func fetchOpenAPISpec(ctx context.Context, rawURL string) ([]byte, error) {
+	host, ip, err := agenttool.ResolveAndValidate(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaultOpenAPIFetchTimeout)
+	defer cancel()
+	resp, err := agenttool.NewHTTPHelper().DoPinned(ctx, http.MethodGet, rawURL, "", "", nil, host, ip)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", agenttool.SanitizeURL(rawURL), resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, openAPISpecMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > openAPISpecMaxBytes {
+		return nil, fmt.Errorf("OpenAPI spec exceeds %d bytes", openAPISpecMaxBytes)
+	}
+	return bytes.TrimSpace(body), nil
+}
@@ -0,0 +1,194 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"ragflow/internal/common"
+	"ragflow/internal/dao"
+	"ragflow/internal/entity"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.0
+info:
+  title: Weather
+  version: "1"
+servers:
+  - url: https://api.weather.example.com
+paths:
+  /forecast:
+    get:
+      operationId: getForecast
+      summary: Daily forecast
+      parameters:
+        - {name: city, in: query, required: true, schema: {type: string}}
+  /alerts:
+    post:
+      operationId: createAlert
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object, properties: {city: {type: string}}}
+`
+
+func setupOpenAPIServiceTest(t *testing.T) *OpenAPIService {
+	t.Helper()
+	testDB := setupServiceTestDB(t)
+	if err := testDB.AutoMigrate(&entity.OpenAPISpec{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	orig := dao.DB
+	dao.DB = testDB
+	t.Cleanup(func() { dao.DB = orig })
+	return NewOpenAPIService()
+}
+
+func TestOpenAPIService_CreateFromUploadAndListTools(t *testing.T) {
+	svc := setupOpenAPIServiceTest(t)
+	ctx := context.Background()
+
+	resp, code, err := svc.CreateOpenAPISpec(ctx, "tenant-1", CreateOpenAPISpecRequest{
+		Spec:        testOpenAPISpec,
+		Credentials: json.RawMessage(`{"type": "bearer", "token": "secret-token"}`),
+	})
+	if err != nil {
+		t.Fatalf("CreateOpenAPISpec: %v (code %d)", err, code)
+	}
+	if resp.Name != "Weather" || resp.BaseURL != "https://api.weather.example.com" || resp.CredentialType != "bearer" {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if len(resp.Operations) != 2 || resp.Operations[0].ToolName != "openapi:"+resp.ID+":createAlert" {
+		t.Fatalf("operations = %+v", resp.Operations)
+	}
+	raw, _ := json.Marshal(resp)
+	if strings.Contains(string(raw), "secret-token") {
+		t.Fatalf("response leaks credentials: %s", raw)
+	}
+
+	if _, code, err := svc.CreateOpenAPISpec(ctx, "tenant-1", CreateOpenAPISpecRequest{Spec: testOpenAPISpec}); err == nil || code != common.CodeDataError {
+		t.Fatalf("duplicate name: code=%d err=%v", code, err)
+	}
+
+	tools, _, err := svc.ListOpenAPITools("tenant-1")
+	if err != nil {
+		t.Fatalf("ListOpenAPITools: %v", err)
+	}
+	if len(tools) != 2 || tools[1].Name != "openapi:"+resp.ID+":getForecast" || tools[1].Description != "Daily forecast" {
+		t.Fatalf("tools = %+v", tools)
+	}
+	if other, _, _ := svc.ListOpenAPITools("tenant-2"); len(other) != 0 {
+		t.Fatalf("tenant-2 sees %d tools", len(other))
+	}
+	if _, code, err := svc.GetOpenAPISpec("tenant-2", resp.ID); err == nil || code != common.CodeDataError {
+		t.Fatalf("cross-tenant get: code=%d err=%v", code, err)
+	}
+}
+
+func TestOpenAPIService_CreateFromURLAndRefresh(t *testing.T) {
+	svc := setupOpenAPIServiceTest(t)
+	ctx := context.Background()
+	served := testOpenAPISpec
+	var fetched []string
+	svc.fetch = func(_ context.Context, rawURL string) ([]byte, error) {
+		fetched = append(fetched, rawURL)
+		return []byte(served), nil
+	}
+
+	resp, _, err := svc.CreateOpenAPISpec(ctx, "tenant-1", CreateOpenAPISpecRequest{Name: "wx", URL: " https://specs.example.com/wx.yaml "})
+	if err != nil {
+		t.Fatalf("CreateOpenAPISpec: %v", err)
+	}
+	if resp.SourceURL != "https://specs.example.com/wx.yaml" || len(fetched) != 1 {
+		t.Fatalf("source=%q fetched=%v", resp.SourceURL, fetched)
+	}
+
+	served = strings.Replace(testOpenAPISpec, "getForecast", "getDailyForecast", 1)
+	updated, _, err := svc.UpdateOpenAPISpec(ctx, "tenant-1", resp.ID, UpdateOpenAPISpecRequest{Refresh: true})
+	if err != nil {
+		t.Fatalf("UpdateOpenAPISpec: %v", err)
+	}
+	if updated.Operations[1].OperationID != "getDailyForecast" {
+		t.Fatalf("operations after refresh = %+v", updated.Operations)
+	}
+	stored, _, _ := svc.GetOpenAPISpec("tenant-1", resp.ID)
+	if stored.Operations[1].OperationID != "getDailyForecast" {
+		t.Fatal("refresh was not persisted")
+	}
+
+	svc.fetch = func(context.Context, string) ([]byte, error) { return nil, errors.New("ssrf: blocked") }
+	if _, code, err := svc.CreateOpenAPISpec(ctx, "tenant-1", CreateOpenAPISpecRequest{URL: "http://10.0.0.1/spec"}); err == nil || code != common.CodeDataError {
+		t.Fatalf("blocked fetch: code=%d err=%v", code, err)
+	}
+}
+
+func TestOpenAPIService_Validation(t *testing.T) {
+	svc := setupOpenAPIServiceTest(t)
+	ctx := context.Background()
+	cases := map[string]CreateOpenAPISpecRequest{
+		"neither spec nor url": {Name: "x"},
+		"both spec and url":    {Spec: testOpenAPISpec, URL: "https://a.example.com"},
+		"invalid document":     {Spec: "openapi: 2.0\npaths: {}"},
+		"bad credentials":      {Spec: testOpenAPISpec, Credentials: json.RawMessage(`{"type": "bearer"}`)},
+		"no server":            {Spec: strings.Replace(testOpenAPISpec, "servers:\n  - url: https://api.weather.example.com\n", "", 1)},
+	}
+	for name, req := range cases {
+		if _, code, err := svc.CreateOpenAPISpec(ctx, "tenant-1", req); err == nil || code != common.CodeDataError {
+			t.Errorf("%s: code=%d err=%v", name, code, err)
+		}
+	}
+}
+
+func TestOpenAPIService_UpdateAndDelete(t *testing.T) {
+	svc := setupOpenAPIServiceTest(t)
+	ctx := context.Background()
+	resp, _, err := svc.CreateOpenAPISpec(ctx, "tenant-1", CreateOpenAPISpecRequest{Spec: testOpenAPISpec})
+	if err != nil {
+		t.Fatalf("CreateOpenAPISpec: %v", err)
+	}
+
+	name, base := "Weather v2", "https://eu.weather.example.com"
+	updated, _, err := svc.UpdateOpenAPISpec(ctx, "tenant-1", resp.ID, UpdateOpenAPISpecRequest{
+		Name:        &name,
+		BaseURL:     &base,
+		Credentials: json.RawMessage(`{"type": "api_key", "name": "key", "in": "query", "value": "v"}`),
+	})
+	if err != nil {
+		t.Fatalf("UpdateOpenAPISpec: %v", err)
+	}
+	if updated.Name != name || updated.BaseURL != base || updated.CredentialType != "api_key" {
+		t.Fatalf("updated = %+v", updated)
+	}
+	if _, _, err := svc.UpdateOpenAPISpec(ctx, "tenant-1", resp.ID, UpdateOpenAPISpecRequest{Refresh: true}); err == nil {
+		t.Fatal("refreshing an uploaded spec: expected an error")
+	}
+
+	if code, err := svc.DeleteOpenAPISpec("tenant-2", resp.ID); err == nil || code != common.CodeDataError {
+		t.Fatalf("cross-tenant delete: code=%d err=%v", code, err)
+	}
+	if _, err := svc.DeleteOpenAPISpec("tenant-1", resp.ID); err != nil {
+		t.Fatalf("DeleteOpenAPISpec: %v", err)
+	}
+	if specs, _, _ := svc.ListOpenAPISpecs("tenant-1", ""); len(specs) != 0 {
+		t.Fatalf("specs after delete = %d", len(specs))
+	}
+}
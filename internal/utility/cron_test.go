@@ -0,0 +1,82 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package utility
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) error = nil, want error", expr)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	utc := time.UTC
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 1, 10, 7, 30, 0, utc), time.Date(2026, 3, 1, 10, 15, 0, 0, utc)},
+		{"0 9 * * mon-fri", time.Date(2026, 3, 6, 9, 0, 0, 0, utc), time.Date(2026, 3, 9, 9, 0, 0, 0, utc)},
+		{"@daily", time.Date(2026, 12, 31, 23, 59, 0, 0, utc), time.Date(2027, 1, 1, 0, 0, 0, 0, utc)},
+		{"30 2 1 * *", time.Date(2026, 1, 15, 0, 0, 0, 0, utc), time.Date(2026, 2, 1, 2, 30, 0, 0, utc)},
+		{"0 0 13 * 5", time.Date(2026, 3, 1, 0, 0, 0, 0, utc), time.Date(2026, 3, 6, 0, 0, 0, 0, utc)},
+		{"0 12 * * 7", time.Date(2026, 3, 2, 0, 0, 0, 0, utc), time.Date(2026, 3, 8, 12, 0, 0, 0, utc)},
+		{"5/20 * * * *", time.Date(2026, 3, 1, 10, 30, 0, 0, utc), time.Date(2026, 3, 1, 10, 45, 0, 0, utc)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronScheduleNextHonoursLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	schedule, err := ParseCron("0 8 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).In(loc)
+	got := schedule.Next(from)
+	if want := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("Next() = %v, want %v", got.UTC(), want)
+	}
+}
+
+func TestCronScheduleNextImpossible(t *testing.T) {
+	schedule, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+	if got := schedule.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Fatalf("Next() = %v, want zero time", got)
+	}
+}
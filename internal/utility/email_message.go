@@ -0,0 +1,283 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// RFC 5322 / MIME decoding of messages fetched over IMAP: headers with
+// RFC 2047 encoded words, text and HTML bodies in their declared
+// charset, and attachments.
+
+package utility
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// emailMaxPartDepth bounds multipart nesting.
+const emailMaxPartDepth = 10
+
+// EmailMessage is a decoded message.
+type EmailMessage struct {
+	MessageID   string
+	InReplyTo   string
+	From        string
+	To          []string
+	Cc          []string
+	Subject     string
+	Date        time.Time
+	Text        string
+	HTML        string
+	Attachments []EmailAttachment
+}
+
+// EmailAttachment is an attached or inline non-text part.
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+var emailWordDecoder = &mime.WordDecoder{CharsetReader: emailCharsetReader}
+
+// ParseEmailMessage decodes raw, which may be a full message or only
+// its header block.
+func ParseEmailMessage(raw []byte) (*EmailMessage, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	msg := &EmailMessage{
+		MessageID: strings.TrimSpace(m.Header.Get("Message-Id")),
+		InReplyTo: strings.TrimSpace(m.Header.Get("In-Reply-To")),
+		From:      decodeEmailAddressHeader(m.Header.Get("From")),
+		To:        decodeEmailAddressList(m.Header.Get("To")),
+		Cc:        decodeEmailAddressList(m.Header.Get("Cc")),
+		Subject:   DecodeEmailHeader(m.Header.Get("Subject")),
+	}
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date
+	}
+	header := textproto.MIMEHeader(m.Header)
+	if err := msg.walk(header, m.Body, 0); err != nil {
+		return nil, err
+	}
+	if msg.Text == "" && msg.HTML != "" {
+		msg.Text = HTMLToText(msg.HTML)
+	}
+	return msg, nil
+}
+
+// walk decodes one MIME entity into msg.
+func (msg *EmailMessage) walk(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > emailMaxPartDepth {
+		return errors.New("email: multipart nesting too deep")
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return nil
+		}
+		mr := multipart.NewReader(body, boundary)
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				// A truncated multipart keeps what was decoded so far.
+				return nil
+			}
+			if err := msg.walk(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	filename = DecodeEmailHeader(filename)
+
+	isText := mediaType == "text/plain" || mediaType == "text/html"
+	if isText && disposition != "attachment" && filename == "" {
+		text := decodeEmailCharset(params["charset"], data)
+		if mediaType == "text/html" {
+			msg.HTML = joinEmailText(msg.HTML, text)
+		} else {
+			msg.Text = joinEmailText(msg.Text, text)
+		}
+		return nil
+	}
+	if len(data) == 0 && filename == "" {
+		return nil
+	}
+	msg.Attachments = append(msg.Attachments, EmailAttachment{
+		Filename:    filename,
+		ContentType: mediaType,
+		Data:        data,
+	})
+	return nil
+}
+
+func joinEmailText(existing, text string) string {
+	text = strings.TrimSpace(text)
+	if existing == "" {
+		return text
+	}
+	if text == "" {
+		return existing
+	}
+	return existing + "\n\n" + text
+}
+
+func decodeTransferEncoding(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// base64Cleaner drops the whitespace base64 bodies are wrapped with,
+// beyond the CR and LF the decoder already skips.
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:n] {
+		if b != ' ' && b != '\t' {
+			out = append(out, b)
+		}
+	}
+	return len(out), err
+}
+
+// decodeEmailCharset converts data from charset to UTF-8. Unknown
+// charsets are passed through unchanged.
+func decodeEmailCharset(charset string, data []byte) string {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return string(data)
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return string(data)
+	}
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(out)
+}
+
+func emailCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(strings.ToLower(charset))
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// DecodeEmailHeader decodes RFC 2047 encoded words in a header value.
+func DecodeEmailHeader(value string) string {
+	decoded, err := emailWordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+func decodeEmailAddressHeader(value string) string {
+	if value == "" {
+		return ""
+	}
+	addr, err := (&mail.AddressParser{WordDecoder: emailWordDecoder}).Parse(value)
+	if err != nil {
+		return DecodeEmailHeader(value)
+	}
+	return formatEmailAddress(addr)
+}
+
+func decodeEmailAddressList(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	addrs, err := (&mail.AddressParser{WordDecoder: emailWordDecoder}).ParseList(value)
+	if err != nil {
+		return []string{DecodeEmailHeader(value)}
+	}
+	out := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		out = append(out, formatEmailAddress(a))
+	}
+	return out
+}
+
+// formatEmailAddress renders "Name <addr>" without the quoting and
+// re-encoding mail.Address.String applies.
+func formatEmailAddress(a *mail.Address) string {
+	if a.Name == "" {
+		return a.Address
+	}
+	return a.Name + " <" + a.Address + ">"
+}
+
+var (
+	emailHTMLDropRe  = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)\s*>`)
+	emailHTMLBreakRe = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/tr|/li|/h[1-6])\b[^>]*>`)
+	emailHTMLTagRe   = regexp.MustCompile(`<[^>]*>`)
+	emailBlankRe     = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+)
+
+// HTMLToText renders an HTML mail body as plain text: scripts and
+// styles are dropped, block ends become line breaks and entities are
+// unescaped.
+func HTMLToText(s string) string {
+	s = emailHTMLDropRe.ReplaceAllString(s, "")
+	s = emailHTMLBreakRe.ReplaceAllString(s, "\n")
+	s = emailHTMLTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = emailBlankRe.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
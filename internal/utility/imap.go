@@ -0,0 +1,809 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Minimal IMAP client for the Agent email tools and the inbound-email
+// trigger. It speaks the IMAP4rev1 subset (RFC 3501) needed to read a
+// mailbox: LOGIN over implicit TLS or STARTTLS, LIST, EXAMINE, UID
+// SEARCH and UID FETCH. Mailboxes are always opened read-only and bodies
+// fetched with BODY.PEEK, so reading mail never changes its flags.
+//
+// Like sendMail in smtp.go, credentials are never sent over a plaintext
+// connection: only the "tls" and "starttls" security modes exist.
+package utility
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// IMAP connection security modes.
+const (
+	IMAPSecurityTLS      = "tls"
+	IMAPSecurityStartTLS = "starttls"
+)
+
+const (
+	defaultIMAPTimeout = 30 * time.Second
+	// imapMaxLine bounds one response line outside literals.
+	imapMaxLine = 1 << 20
+	// imapMaxLiteral bounds one literal, i.e. one fetched message.
+	imapMaxLiteral = 50 << 20
+	// imapMaxResponseLiterals bounds the literals of one response
+	// together, however many of them the server sends.
+	imapMaxResponseLiterals = 64 << 20
+)
+
+// IMAPConfig describes how to reach and log in to an IMAP server.
+type IMAPConfig struct {
+	Host     string
+	Port     int
+	Security string
+	Username string
+	Password string
+	// TLSConfig overrides the TLS settings; ServerName defaults to Host.
+	TLSConfig *tls.Config
+	// Timeout bounds each command round trip (default 30s).
+	Timeout time.Duration
+}
+
+// IMAPError is a NO or BAD completion of an IMAP command.
+type IMAPError struct {
+	Command string
+	Status  string
+	Text    string
+}
+
+func (e *IMAPError) Error() string {
+	return fmt.Sprintf("imap %s: %s %s", e.Command, e.Status, e.Text)
+}
+
+// IMAPMailbox is an entry of the LIST response.
+type IMAPMailbox struct {
+	Name       string   `json:"name"`
+	Delimiter  string   `json:"delimiter,omitempty"`
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+// IMAPMailboxStatus is what EXAMINE reports about the opened mailbox.
+type IMAPMailboxStatus struct {
+	Name        string
+	Messages    uint32
+	UIDValidity uint32
+	UIDNext     uint32
+}
+
+// IMAPSearchCriteria selects messages for UIDSearch. Zero fields are
+// ignored; an empty criteria matches every message.
+type IMAPSearchCriteria struct {
+	// AfterUID restricts the search to UIDs greater than this one.
+	AfterUID uint32
+	From     string
+	To       string
+	Subject  string
+	// Text matches headers and body.
+	Text   string
+	Since  time.Time
+	Before time.Time
+	Unseen bool
+}
+
+// IMAPMessage is one message returned by UIDFetch. Raw holds the
+// header block, or the full RFC 5322 message when the body was fetched.
+type IMAPMessage struct {
+	UID          uint32
+	Flags        []string
+	Size         int64
+	InternalDate time.Time
+	Raw          []byte
+}
+
+// Seen reports whether the message carries the \Seen flag.
+func (m *IMAPMessage) Seen() bool {
+	for _, f := range m.Flags {
+		if strings.EqualFold(f, `\Seen`) {
+			return true
+		}
+	}
+	return false
+}
+
+// IMAPClient is a logged-in IMAP connection. It is not safe for
+// concurrent use.
+type IMAPClient struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+	timeout time.Duration
+	tag     int
+	stop    func() bool
+}
+
+// DialIMAP connects, negotiates TLS and logs in. Cancelling ctx closes
+// the connection.
+func DialIMAP(ctx context.Context, cfg IMAPConfig) (*IMAPClient, error) {
+	if cfg.Host == "" || cfg.Port <= 0 || cfg.Port > 65535 {
+		return nil, errors.New("imap: host and port are required")
+	}
+	if cfg.Security != IMAPSecurityTLS && cfg.Security != IMAPSecurityStartTLS {
+		return nil, fmt.Errorf("imap: security must be %q or %q", IMAPSecurityTLS, IMAPSecurityStartTLS)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultIMAPTimeout
+	}
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSConfig != nil {
+		tlsCfg = cfg.TLSConfig.Clone()
+	}
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName = cfg.Host
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if cfg.Security == IMAPSecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsCfg}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("imap dial %s: %w", addr, err)
+	}
+
+	c := &IMAPClient{timeout: timeout}
+	c.setConn(conn)
+	c.stop = context.AfterFunc(ctx, func() { _ = c.conn.Close() })
+	if err := c.open(cfg, tlsCfg); err != nil {
+		c.stop()
+		_ = c.conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *IMAPClient) setConn(conn net.Conn) {
+	c.conn = conn
+	c.r = bufio.NewReader(conn)
+	c.w = bufio.NewWriter(conn)
+}
+
+// open reads the greeting, upgrades to TLS when asked and logs in.
+func (c *IMAPClient) open(cfg IMAPConfig, tlsCfg *tls.Config) error {
+	c.deadline()
+	line, _, err := c.readLine()
+	if err != nil {
+		return fmt.Errorf("imap greeting: %w", err)
+	}
+	greeting := string(line)
+	if strings.HasPrefix(greeting, "* PREAUTH") {
+		return nil
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("imap greeting: %s", greeting)
+	}
+	if cfg.Security == IMAPSecurityStartTLS {
+		if err := c.execute("STARTTLS", nil); err != nil {
+			return err
+		}
+		tlsConn := tls.Client(c.conn, tlsCfg)
+		if err := tlsConn.Handshake(); err != nil {
+			return fmt.Errorf("imap starttls: %w", err)
+		}
+		c.setConn(tlsConn)
+	}
+	return c.execute("LOGIN", nil, imapString(cfg.Username), imapString(cfg.Password))
+}
+
+// Close logs out and closes the connection.
+func (c *IMAPClient) Close() error {
+	if c.stop != nil {
+		c.stop()
+	}
+	_ = c.execute("LOGOUT", nil)
+	return c.conn.Close()
+}
+
+// List returns the mailboxes matching pattern ("*" for all). Names are
+// decoded from modified UTF-7.
+func (c *IMAPClient) List(pattern string) ([]IMAPMailbox, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	var out []IMAPMailbox
+	err := c.execute("LIST", func(fields []any) {
+		if len(fields) < 4 || !imapAtomIs(fields[0], "LIST") {
+			return
+		}
+		box := IMAPMailbox{}
+		if attrs, ok := fields[1].([]any); ok {
+			for _, a := range attrs {
+				if s, ok := a.(string); ok {
+					box.Attributes = append(box.Attributes, s)
+				}
+			}
+		}
+		if d, ok := fields[2].(string); ok {
+			box.Delimiter = d
+		}
+		name, _ := fields[3].(string)
+		box.Name = DecodeIMAPMailboxName(name)
+		out = append(out, box)
+	}, imapString(""), imapString(EncodeIMAPMailboxName(pattern)))
+	return out, err
+}
+
+// Examine opens mailbox read-only.
+func (c *IMAPClient) Examine(mailbox string) (*IMAPMailboxStatus, error) {
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	status := &IMAPMailboxStatus{Name: mailbox}
+	err := c.execute("EXAMINE", func(fields []any) {
+		if len(fields) >= 2 && imapAtomIs(fields[1], "EXISTS") {
+			status.Messages = imapUint(fields[0])
+			return
+		}
+		if len(fields) < 2 || !imapAtomIs(fields[0], "OK") {
+			return
+		}
+		code, _ := fields[1].(string)
+		code = strings.Trim(code, "[]")
+		key, value, _ := strings.Cut(code, " ")
+		switch strings.ToUpper(key) {
+		case "UIDVALIDITY":
+			status.UIDValidity = imapUint(value)
+		case "UIDNEXT":
+			status.UIDNext = imapUint(value)
+		}
+	}, imapString(EncodeIMAPMailboxName(mailbox)))
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// UIDSearch returns the UIDs in the open mailbox matching criteria, in
+// ascending order.
+func (c *IMAPClient) UIDSearch(criteria IMAPSearchCriteria) ([]uint32, error) {
+	args := []any{}
+	addString := func(key, value string) {
+		if value != "" {
+			args = append(args, key, imapString(value))
+		}
+	}
+	if criteria.AfterUID > 0 {
+		args = append(args, "UID", fmt.Sprintf("%d:*", criteria.AfterUID+1))
+	}
+	addString("FROM", criteria.From)
+	addString("TO", criteria.To)
+	addString("SUBJECT", criteria.Subject)
+	addString("TEXT", criteria.Text)
+	if !criteria.Since.IsZero() {
+		args = append(args, "SINCE", criteria.Since.Format("2-Jan-2006"))
+	}
+	if !criteria.Before.IsZero() {
+		args = append(args, "BEFORE", criteria.Before.Format("2-Jan-2006"))
+	}
+	if criteria.Unseen {
+		args = append(args, "UNSEEN")
+	}
+	if len(args) == 0 {
+		args = append(args, "ALL")
+	}
+	for _, a := range args {
+		if s, ok := a.(imapString); ok && !isASCII(string(s)) {
+			args = append([]any{"CHARSET", "UTF-8"}, args...)
+			break
+		}
+	}
+
+	var uids []uint32
+	err := c.execute("UID SEARCH", func(fields []any) {
+		if len(fields) == 0 || !imapAtomIs(fields[0], "SEARCH") {
+			return
+		}
+		for _, f := range fields[1:] {
+			// "UID n:*" always matches the highest UID, even when it
+			// is not above AfterUID.
+			if uid := imapUint(f); uid > criteria.AfterUID {
+				uids = append(uids, uid)
+			}
+		}
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+	return uids, nil
+}
+
+// UIDFetch fetches the given messages of the open mailbox. With
+// headersOnly only the header block is fetched into Raw.
+func (c *IMAPClient) UIDFetch(uids []uint32, headersOnly bool) ([]*IMAPMessage, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	set := make([]string, len(uids))
+	for i, uid := range uids {
+		set[i] = strconv.FormatUint(uint64(uid), 10)
+	}
+	section := "BODY.PEEK[]"
+	if headersOnly {
+		section = "BODY.PEEK[HEADER]"
+	}
+	items := "(UID FLAGS RFC822.SIZE INTERNALDATE " + section + ")"
+
+	byUID := map[uint32]*IMAPMessage{}
+	err := c.execute("UID FETCH", func(fields []any) {
+		if len(fields) < 3 || !imapAtomIs(fields[1], "FETCH") {
+			return
+		}
+		attrs, ok := fields[2].([]any)
+		if !ok {
+			return
+		}
+		msg := &IMAPMessage{}
+		for i := 0; i+1 < len(attrs); i += 2 {
+			key, _ := attrs[i].(string)
+			value := attrs[i+1]
+			switch key = strings.ToUpper(key); {
+			case key == "UID":
+				msg.UID = imapUint(value)
+			case key == "FLAGS":
+				list, _ := value.([]any)
+				for _, f := range list {
+					if s, ok := f.(string); ok {
+						msg.Flags = append(msg.Flags, s)
+					}
+				}
+			case key == "RFC822.SIZE":
+				msg.Size = int64(imapUint(value))
+			case key == "INTERNALDATE":
+				if s, ok := value.(string); ok {
+					msg.InternalDate, _ = time.Parse("2-Jan-2006 15:04:05 -0700", strings.TrimSpace(s))
+				}
+			case strings.HasPrefix(key, "BODY["):
+				if s, ok := value.(string); ok {
+					msg.Raw = []byte(s)
+				}
+			}
+		}
+		if msg.UID != 0 {
+			byUID[msg.UID] = msg
+		}
+	}, strings.Join(set, ","), items)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*IMAPMessage, 0, len(byUID))
+	for _, uid := range uids {
+		if msg, ok := byUID[uid]; ok {
+			out = append(out, msg)
+		}
+	}
+	return out, nil
+}
+
+// imapBase64 is the base64 alphabet of modified UTF-7 before ","
+// replaces "/".
+var imapBase64 = base64.StdEncoding
+
+// imapString is a command argument sent as a quoted string, or as a
+// literal when quoting cannot represent it.
+type imapString string
+
+// execute runs one command, passing every untagged response to
+// untagged, and returns an *IMAPError unless it completes with OK.
+// String arguments are sent verbatim as atoms.
+func (c *IMAPClient) execute(command string, untagged func(fields []any), args ...any) error {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	c.deadline()
+	if err := c.writeCommand(tag, command, args); err != nil {
+		return err
+	}
+	for {
+		c.deadline()
+		line, literals, err := c.readLine()
+		if err != nil {
+			return fmt.Errorf("imap %s: %w", command, err)
+		}
+		if rest, ok := bytes.CutPrefix(line, []byte("* ")); ok {
+			if untagged != nil {
+				if fields, perr := parseIMAPFields(rest, literals); perr == nil {
+					untagged(fields)
+				}
+			}
+			continue
+		}
+		if rest, ok := bytes.CutPrefix(line, []byte(tag+" ")); ok {
+			status, text, _ := strings.Cut(string(rest), " ")
+			if strings.EqualFold(status, "OK") {
+				return nil
+			}
+			return &IMAPError{Command: command, Status: strings.ToUpper(status), Text: text}
+		}
+		// Stray continuation requests are ignored.
+	}
+}
+
+// writeCommand sends one command line. Literal arguments are sent with
+// a synchronizing literal, waiting for the server's continuation.
+func (c *IMAPClient) writeCommand(tag, command string, args []any) error {
+	var line bytes.Buffer
+	line.WriteString(tag + " " + command)
+	for _, a := range args {
+		line.WriteByte(' ')
+		s, ok := a.(imapString)
+		if !ok {
+			fmt.Fprint(&line, a)
+			continue
+		}
+		if quoted, ok := imapQuote(string(s)); ok {
+			line.WriteString(quoted)
+			continue
+		}
+		fmt.Fprintf(&line, "{%d}\r\n", len(s))
+		if _, err := c.w.Write(line.Bytes()); err != nil {
+			return err
+		}
+		if err := c.w.Flush(); err != nil {
+			return err
+		}
+		if err := c.awaitContinuation(command, tag); err != nil {
+			return err
+		}
+		line.Reset()
+		line.WriteString(string(s))
+	}
+	line.WriteString("\r\n")
+	if _, err := c.w.Write(line.Bytes()); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func (c *IMAPClient) awaitContinuation(command, tag string) error {
+	for {
+		line, _, err := c.readLine()
+		if err != nil {
+			return fmt.Errorf("imap %s: %w", command, err)
+		}
+		if bytes.HasPrefix(line, []byte("+")) {
+			return nil
+		}
+		if rest, ok := bytes.CutPrefix(line, []byte(tag+" ")); ok {
+			status, text, _ := strings.Cut(string(rest), " ")
+			return &IMAPError{Command: command, Status: strings.ToUpper(status), Text: text}
+		}
+	}
+}
+
+func (c *IMAPClient) deadline() {
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+}
+
+// readLine reads one response line. Literals ("{n}" followed by n raw
+// bytes) are collected in order; the line keeps their "{n}" markers.
+func (c *IMAPClient) readLine() ([]byte, [][]byte, error) {
+	var line []byte
+	var literals [][]byte
+	total := 0
+	for {
+		part, err := c.readRawLine()
+		if err != nil {
+			return nil, nil, err
+		}
+		line = append(line, part...)
+		n, ok := trailingLiteral(part)
+		if !ok {
+			return line, literals, nil
+		}
+		if n > imapMaxLiteral {
+			return nil, nil, fmt.Errorf("literal of %d bytes exceeds %d", n, imapMaxLiteral)
+		}
+		if total += n; total > imapMaxResponseLiterals {
+			return nil, nil, fmt.Errorf("response literals exceed %d bytes", imapMaxResponseLiterals)
+		}
+		lit := make([]byte, n)
+		if _, err := io.ReadFull(c.r, lit); err != nil {
+			return nil, nil, err
+		}
+		literals = append(literals, lit)
+	}
+}
+
+// readRawLine reads up to CRLF and strips it.
+func (c *IMAPClient) readRawLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := c.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > imapMaxLine {
+			return nil, errors.New("response line too long")
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return bytes.TrimRight(line, "\r\n"), nil
+	}
+}
+
+// trailingLiteral reports whether line ends with a literal marker
+// "{n}" (or the non-synchronizing "{n+}") and returns n.
+func trailingLiteral(line []byte) (int, bool) {
+	if !bytes.HasSuffix(line, []byte("}")) {
+		return 0, false
+	}
+	open := bytes.LastIndexByte(line, '{')
+	if open < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(string(line[open+1:len(line)-1]), "+"))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseIMAPFields splits a response line into atoms and strings
+// (string), NIL (nil) and parenthesized lists ([]any). A bracketed
+// section such as "BODY[HEADER]" or "[UIDNEXT 4]" stays one atom.
+func parseIMAPFields(line []byte, literals [][]byte) ([]any, error) {
+	p := &imapFieldParser{line: line, literals: literals}
+	fields, err := p.list(0)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+type imapFieldParser struct {
+	line     []byte
+	pos      int
+	literals [][]byte
+}
+
+// list parses fields until the closing paren (depth > 0) or the end of
+// the line.
+func (p *imapFieldParser) list(depth int) ([]any, error) {
+	out := []any{}
+	for {
+		for p.pos < len(p.line) && p.line[p.pos] == ' ' {
+			p.pos++
+		}
+		if p.pos >= len(p.line) {
+			if depth > 0 {
+				return nil, errors.New("unterminated list")
+			}
+			return out, nil
+		}
+		switch ch := p.line[p.pos]; ch {
+		case ')':
+			if depth == 0 {
+				return nil, errors.New("unexpected )")
+			}
+			p.pos++
+			return out, nil
+		case '(':
+			p.pos++
+			inner, err := p.list(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, inner)
+		case '"':
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, s)
+		case '{':
+			end := bytes.IndexByte(p.line[p.pos:], '}')
+			if end < 0 || len(p.literals) == 0 {
+				return nil, errors.New("bad literal")
+			}
+			p.pos += end + 1
+			out = append(out, string(p.literals[0]))
+			p.literals = p.literals[1:]
+		default:
+			atom := p.atom()
+			if strings.EqualFold(atom, "NIL") {
+				out = append(out, nil)
+			} else {
+				out = append(out, atom)
+			}
+		}
+	}
+}
+
+func (p *imapFieldParser) quoted() (string, error) {
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.line); p.pos++ {
+		switch ch := p.line[p.pos]; ch {
+		case '\\':
+			p.pos++
+			if p.pos < len(p.line) {
+				b.WriteByte(p.line[p.pos])
+			}
+		case '"':
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", errors.New("unterminated quoted string")
+}
+
+func (p *imapFieldParser) atom() string {
+	start := p.pos
+	depth := 0
+	for ; p.pos < len(p.line); p.pos++ {
+		ch := p.line[p.pos]
+		switch {
+		case ch == '[':
+			depth++
+		case ch == ']' && depth > 0:
+			depth--
+		case depth == 0 && (ch == ' ' || ch == '(' || ch == ')'):
+			return string(p.line[start:p.pos])
+		}
+	}
+	return string(p.line[start:])
+}
+
+// imapQuote returns s as a quoted string, or false when s needs a
+// literal (line breaks or non-ASCII bytes).
+func imapQuote(s string) (string, bool) {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch == '\r' || ch == '\n' || ch == 0 || ch >= 0x80 {
+			return "", false
+		}
+		if ch == '"' || ch == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(ch)
+	}
+	b.WriteByte('"')
+	return b.String(), true
+}
+
+func imapAtomIs(field any, want string) bool {
+	s, ok := field.(string)
+	return ok && strings.EqualFold(s, want)
+}
+
+func imapUint(field any) uint32 {
+	s, _ := field.(string)
+	n, _ := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+	return uint32(n)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeIMAPMailboxName encodes a mailbox name in the modified UTF-7 of
+// RFC 3501 section 5.1.3.
+func EncodeIMAPMailboxName(name string) string {
+	var b strings.Builder
+	var pending []rune
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		units := utf16.Encode(pending)
+		raw := make([]byte, 0, len(units)*2)
+		for _, u := range units {
+			raw = append(raw, byte(u>>8), byte(u))
+		}
+		b.WriteByte('&')
+		b.WriteString(strings.ReplaceAll(strings.TrimRight(imapBase64.EncodeToString(raw), "="), "/", ","))
+		b.WriteByte('-')
+		pending = pending[:0]
+	}
+	for _, r := range name {
+		if r >= 0x20 && r <= 0x7e {
+			flush()
+			if r == '&' {
+				b.WriteString("&-")
+			} else {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		pending = append(pending, r)
+	}
+	flush()
+	return b.String()
+}
+
+// DecodeIMAPMailboxName decodes a modified UTF-7 mailbox name. Invalid
+// input is returned unchanged.
+func DecodeIMAPMailboxName(name string) string {
+	if !strings.Contains(name, "&") {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '&' {
+			b.WriteByte(name[i])
+			continue
+		}
+		end := strings.IndexByte(name[i:], '-')
+		if end < 0 {
+			return name
+		}
+		chunk := name[i+1 : i+end]
+		i += end
+		if chunk == "" {
+			b.WriteByte('&')
+			continue
+		}
+		raw, err := imapBase64.DecodeString(padBase64(strings.ReplaceAll(chunk, ",", "/")))
+		if err != nil || len(raw)%2 != 0 {
+			return name
+		}
+		units := make([]uint16, len(raw)/2)
+		for j := range units {
+			units[j] = uint16(raw[2*j])<<8 | uint16(raw[2*j+1])
+		}
+		for _, r := range utf16.Decode(units) {
+			if r == utf8.RuneError {
+				return name
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func padBase64(s string) string {
+	if m := len(s) % 4; m != 0 {
+		s += strings.Repeat("=", 4-m)
+	}
+	return s
+}
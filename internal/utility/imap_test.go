@@ -0,0 +1,304 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package utility
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ragflow/internal/utility/imaptest"
+)
+
+const testMultipartMail = "From: =?UTF-8?B?5byg5LiJ?= <zhang@example.com>\r\n" +
+	"To: support@example.com, Ops <ops@example.com>\r\n" +
+	"Subject: =?UTF-8?Q?Refund_request_=E2=80=94_order_42?=\r\n" +
+	"Message-ID: <m1@example.com>\r\n" +
+	"Date: Mon, 12 Oct 2026 09:30:00 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Caf=E9 order 42 arrived broken.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Caf&eacute; order 42 arrived broken.</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/csv; name=\"items.csv\"\r\n" +
+	"Content-Disposition: attachment; filename=\"items.csv\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"c2t1LHF0eQpBMSwy\r\n" +
+	"--outer--\r\n"
+
+func plainMail(from, subject, body string) []byte {
+	return []byte("From: " + from + "\r\nTo: support@example.com\r\nSubject: " + subject +
+		"\r\nDate: Tue, 13 Oct 2026 10:00:00 +0000\r\n\r\n" + body + "\r\n")
+}
+
+func dialTestServer(t *testing.T, srv *imaptest.Server, security string) *IMAPClient {
+	t.Helper()
+	c, err := DialIMAP(context.Background(), IMAPConfig{
+		Host:      srv.Host,
+		Port:      srv.Port,
+		Security:  security,
+		Username:  srv.Username,
+		Password:  srv.Password,
+		TLSConfig: srv.ClientTLSConfig(),
+		Timeout:   5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("DialIMAP: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestParseEmailMessage_Multipart(t *testing.T) {
+	msg, err := ParseEmailMessage([]byte(testMultipartMail))
+	if err != nil {
+		t.Fatalf("ParseEmailMessage: %v", err)
+	}
+	if msg.From != "张三 <zhang@example.com>" {
+		t.Errorf("From = %q", msg.From)
+	}
+	if len(msg.To) != 2 || msg.To[1] != "Ops <ops@example.com>" {
+		t.Errorf("To = %q", msg.To)
+	}
+	if msg.Subject != "Refund request — order 42" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	if msg.MessageID != "<m1@example.com>" || msg.Date.IsZero() {
+		t.Errorf("MessageID = %q, Date = %v", msg.MessageID, msg.Date)
+	}
+	if msg.Text != "Café order 42 arrived broken." {
+		t.Errorf("Text = %q", msg.Text)
+	}
+	if !strings.Contains(msg.HTML, "<p>") {
+		t.Errorf("HTML = %q", msg.HTML)
+	}
+	if len(msg.Attachments) != 1 {
+		t.Fatalf("Attachments = %d, want 1", len(msg.Attachments))
+	}
+	att := msg.Attachments[0]
+	if att.Filename != "items.csv" || att.ContentType != "text/csv" || string(att.Data) != "sku,qty\nA1,2" {
+		t.Errorf("attachment = %q %q %q", att.Filename, att.ContentType, att.Data)
+	}
+}
+
+func TestParseEmailMessage_HTMLOnly(t *testing.T) {
+	raw := "Subject: hi\r\nContent-Type: text/html\r\n\r\n" +
+		"<html><head><style>p{}</style></head><body><p>Hello&nbsp;there</p><script>x()</script><div>Bye</div></body></html>"
+	msg, err := ParseEmailMessage([]byte(raw))
+	if err != nil {
+		t.Fatalf("ParseEmailMessage: %v", err)
+	}
+	if msg.Text != "Hello there\nBye" {
+		t.Errorf("Text = %q", msg.Text)
+	}
+}
+
+func TestIMAPMailboxNameEncoding(t *testing.T) {
+	for _, name := range []string{"INBOX", "R&D", "客服/工单", "Entwürfe", "😀"} {
+		enc := EncodeIMAPMailboxName(name)
+		if !isASCII(enc) {
+			t.Errorf("Encode(%q) = %q is not ASCII", name, enc)
+		}
+		if got := DecodeIMAPMailboxName(enc); got != name {
+			t.Errorf("round trip %q -> %q -> %q", name, enc, got)
+		}
+	}
+	if got := EncodeIMAPMailboxName("R&D"); got != "R&-D" {
+		t.Errorf("Encode(R&D) = %q", got)
+	}
+}
+
+func TestIMAPClient_SearchAndFetch(t *testing.T) {
+	for _, security := range []string{IMAPSecurityTLS, IMAPSecurityStartTLS} {
+		t.Run(security, func(t *testing.T) {
+			srv, err := imaptest.NewServer(security, "bot@example.com", "s3cret")
+			if err != nil {
+				t.Fatalf("NewServer: %v", err)
+			}
+			defer srv.Close()
+			srv.AddMailbox("INBOX", 7)
+			srv.AddMailbox("客服", 3)
+			first := srv.Deliver("INBOX", plainMail("alice@example.com", "Invoice 1", "first"))
+			srv.Deliver("INBOX", plainMail("bob@example.com", "Hello", "second"), `\Seen`)
+			third := srv.Deliver("INBOX", []byte(testMultipartMail))
+
+			c := dialTestServer(t, srv, security)
+
+			boxes, err := c.List("*")
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			names := []string{}
+			for _, b := range boxes {
+				names = append(names, b.Name)
+			}
+			if strings.Join(names, ",") != "INBOX,客服" {
+				t.Errorf("List = %v", names)
+			}
+
+			status, err := c.Examine("INBOX")
+			if err != nil {
+				t.Fatalf("Examine: %v", err)
+			}
+			if status.Messages != 3 || status.UIDValidity != 7 || status.UIDNext != 4 {
+				t.Errorf("status = %+v", status)
+			}
+
+			cases := []struct {
+				name     string
+				criteria IMAPSearchCriteria
+				want     []uint32
+			}{
+				{"all", IMAPSearchCriteria{}, []uint32{1, 2, 3}},
+				{"from", IMAPSearchCriteria{From: "alice"}, []uint32{first}},
+				{"unseen", IMAPSearchCriteria{Unseen: true}, []uint32{first, third}},
+				{"after uid", IMAPSearchCriteria{AfterUID: 2}, []uint32{third}},
+				{"after last uid", IMAPSearchCriteria{AfterUID: 3}, nil},
+				{"non-ascii subject", IMAPSearchCriteria{Subject: "request —"}, []uint32{third}},
+				{"since", IMAPSearchCriteria{Since: time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)}, []uint32{1, 2}},
+			}
+			for _, tc := range cases {
+				got, err := c.UIDSearch(tc.criteria)
+				if err != nil {
+					t.Fatalf("%s: UIDSearch: %v", tc.name, err)
+				}
+				if len(got) != len(tc.want) {
+					t.Errorf("%s: UIDSearch = %v, want %v", tc.name, got, tc.want)
+					continue
+				}
+				for i := range got {
+					if got[i] != tc.want[i] {
+						t.Errorf("%s: UIDSearch = %v, want %v", tc.name, got, tc.want)
+						break
+					}
+				}
+			}
+
+			headers, err := c.UIDFetch([]uint32{third, first}, true)
+			if err != nil {
+				t.Fatalf("UIDFetch headers: %v", err)
+			}
+			if len(headers) != 2 || headers[0].UID != third || headers[1].UID != first {
+				t.Fatalf("UIDFetch order = %+v", headers)
+			}
+			if strings.Contains(string(headers[0].Raw), "--outer") {
+				t.Error("header fetch returned the body")
+			}
+
+			full, err := c.UIDFetch([]uint32{third}, false)
+			if err != nil {
+				t.Fatalf("UIDFetch: %v", err)
+			}
+			if len(full) != 1 || string(full[0].Raw) != testMultipartMail || full[0].Seen() {
+				t.Fatalf("UIDFetch = %+v", full)
+			}
+			if full[0].Size != int64(len(testMultipartMail)) || full[0].InternalDate.IsZero() {
+				t.Errorf("size = %d, internal date = %v", full[0].Size, full[0].InternalDate)
+			}
+			if flags := srv.Flags("INBOX", third); len(flags) != 0 {
+				t.Errorf("reading set flags %v", flags)
+			}
+
+			if _, err := c.Examine("客服"); err != nil {
+				t.Errorf("Examine non-ASCII mailbox: %v", err)
+			}
+			var imapErr *IMAPError
+			if _, err := c.Examine("missing"); !errors.As(err, &imapErr) || imapErr.Status != "NO" {
+				t.Errorf("Examine missing = %v", err)
+			}
+		})
+	}
+}
+
+func TestIMAPClient_FetchRejectsOversizedResponse(t *testing.T) {
+	srv, err := imaptest.NewServer(imaptest.SecurityTLS, "bot@example.com", "s3cret")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	uid := srv.Deliver("INBOX", plainMail("alice@example.com", "Hello", "body"))
+	c := dialTestServer(t, srv, IMAPSecurityTLS)
+	if _, err := c.Examine("INBOX"); err != nil {
+		t.Fatalf("Examine: %v", err)
+	}
+
+	// Every literal is under the per-literal cap; together they are not.
+	srv.PadFetch(imapMaxResponseLiterals>>20+1, 1<<20)
+	_, err = c.UIDFetch([]uint32{uid}, true)
+	if err == nil || !strings.Contains(err.Error(), "response literals exceed") {
+		t.Fatalf("UIDFetch err = %v", err)
+	}
+}
+
+func TestDialIMAP_Errors(t *testing.T) {
+	srv, err := imaptest.NewServer(imaptest.SecurityStartTLS, "bot@example.com", "s3cret")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+
+	cfg := IMAPConfig{
+		Host:      srv.Host,
+		Port:      srv.Port,
+		Security:  IMAPSecurityStartTLS,
+		Username:  srv.Username,
+		Password:  "wrong",
+		TLSConfig: srv.ClientTLSConfig(),
+		Timeout:   5 * time.Second,
+	}
+	var imapErr *IMAPError
+	if _, err := DialIMAP(context.Background(), cfg); !errors.As(err, &imapErr) || imapErr.Command != "LOGIN" {
+		t.Errorf("bad password: err = %v", err)
+	}
+
+	cfg.Password = srv.Password
+	cfg.Security = "none"
+	if _, err := DialIMAP(context.Background(), cfg); err == nil {
+		t.Error("plaintext security accepted")
+	}
+
+	cfg.Security = IMAPSecurityStartTLS
+	cfg.TLSConfig = nil
+	if _, err := DialIMAP(context.Background(), cfg); err == nil {
+		t.Error("untrusted certificate accepted")
+	}
+	if srv.Logins() != 0 {
+		t.Errorf("logins = %d, want 0", srv.Logins())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg.TLSConfig = srv.ClientTLSConfig()
+	if _, err := DialIMAP(ctx, cfg); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled dial: err = %v", err)
+	}
+}
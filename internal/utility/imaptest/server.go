@@ -0,0 +1,797 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package imaptest provides an in-process IMAP server for tests, in the
+// spirit of net/http/httptest. It keeps mailboxes in memory and answers
+// the commands the utility IMAP client sends: CAPABILITY, STARTTLS,
+// LOGIN, LIST, SELECT / EXAMINE, UID SEARCH, UID FETCH, NOOP and LOGOUT.
+// Connections use a self-signed certificate that ClientTLSConfig trusts.
+package imaptest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"net"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Security modes, matching the utility IMAP client.
+const (
+	SecurityTLS      = "tls"
+	SecurityStartTLS = "starttls"
+)
+
+// Message is a stored message.
+type Message struct {
+	UID          uint32
+	Flags        []string
+	InternalDate time.Time
+	Raw          []byte
+}
+
+type mailbox struct {
+	uidValidity uint32
+	uidNext     uint32
+	messages    []*Message
+}
+
+// Server is a running in-memory IMAP server.
+type Server struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+
+	security string
+	ln       net.Listener
+	cert     tls.Certificate
+	pool     *x509.CertPool
+
+	mu        sync.Mutex
+	mailboxes map[string]*mailbox
+	conns     map[net.Conn]struct{}
+	logins    int
+	padCount  int
+	padSize   int
+	wg        sync.WaitGroup
+}
+
+// NewServer starts a server on a loopback port with an empty INBOX.
+// security is SecurityTLS or SecurityStartTLS.
+func NewServer(security, username, password string) (*Server, error) {
+	cert, pool, err := selfSignedCert()
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	s := &Server{
+		Host:      "127.0.0.1",
+		Port:      addr.Port,
+		Username:  username,
+		Password:  password,
+		security:  security,
+		ln:        ln,
+		cert:      cert,
+		pool:      pool,
+		mailboxes: map[string]*mailbox{},
+		conns:     map[net.Conn]struct{}{},
+	}
+	s.AddMailbox("INBOX", 1)
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// ClientTLSConfig returns a TLS config that trusts the server.
+func (s *Server) ClientTLSConfig() *tls.Config {
+	return &tls.Config{RootCAs: s.pool, ServerName: s.Host, MinVersion: tls.VersionTLS12}
+}
+
+// Close stops the listener, closes open connections and waits for
+// their sessions to end.
+func (s *Server) Close() {
+	_ = s.ln.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// AddMailbox creates (or resets) a mailbox with the given UIDVALIDITY.
+func (s *Server) AddMailbox(name string, uidValidity uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mailboxes[name] = &mailbox{uidValidity: uidValidity, uidNext: 1}
+}
+
+// Deliver appends raw to a mailbox and returns its UID.
+func (s *Server) Deliver(mailboxName string, raw []byte, flags ...string) uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	box, ok := s.mailboxes[mailboxName]
+	if !ok {
+		box = &mailbox{uidValidity: 1, uidNext: 1}
+		s.mailboxes[mailboxName] = box
+	}
+	msg := &Message{UID: box.uidNext, Flags: flags, InternalDate: time.Now(), Raw: raw}
+	box.uidNext++
+	box.messages = append(box.messages, msg)
+	return msg.UID
+}
+
+// Flags returns the flags of a stored message.
+func (s *Server) Flags(mailboxName string, uid uint32) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if box := s.mailboxes[mailboxName]; box != nil {
+		for _, m := range box.messages {
+			if m.UID == uid {
+				return append([]string(nil), m.Flags...)
+			}
+		}
+	}
+	return nil
+}
+
+// PadFetch makes every later FETCH response carry count extra literals
+// of size bytes each, to exercise client response limits.
+func (s *Server) PadFetch(count, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.padCount, s.padSize = count, size
+}
+
+// Logins returns the number of successful logins.
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func(raw net.Conn) {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, raw)
+				s.mu.Unlock()
+				_ = raw.Close()
+			}()
+			_ = raw.SetDeadline(time.Now().Add(30 * time.Second))
+			conn := raw
+			if s.security == SecurityTLS {
+				conn = tls.Server(raw, &tls.Config{Certificates: []tls.Certificate{s.cert}})
+			}
+			(&session{server: s, conn: conn, tls: s.security == SecurityTLS}).run()
+		}(conn)
+	}
+}
+
+type session struct {
+	server   *Server
+	conn     net.Conn
+	r        *bufio.Reader
+	w        *bufio.Writer
+	tls      bool
+	loggedIn bool
+	selected string
+	readOnly bool
+}
+
+func (c *session) run() {
+	c.r = bufio.NewReader(c.conn)
+	c.w = bufio.NewWriter(c.conn)
+	c.reply("* OK imaptest ready")
+	for {
+		fields, err := c.readCommand()
+		if err != nil {
+			return
+		}
+		if len(fields) < 2 {
+			c.reply("* BAD missing command")
+			continue
+		}
+		tag := fields[0].(string)
+		cmd := strings.ToUpper(fields[1].(string))
+		args := fields[2:]
+		if cmd == "UID" && len(args) > 0 {
+			cmd += " " + strings.ToUpper(fmt.Sprint(args[0]))
+			args = args[1:]
+		}
+		if !c.handle(tag, cmd, args) {
+			return
+		}
+	}
+}
+
+// handle runs one command; it returns false when the session ends.
+func (c *session) handle(tag, cmd string, args []any) bool {
+	s := c.server
+	switch cmd {
+	case "CAPABILITY":
+		caps := "IMAP4rev1 AUTH=PLAIN"
+		if s.security == SecurityStartTLS && !c.tls {
+			caps += " STARTTLS LOGINDISABLED"
+		}
+		c.reply("* CAPABILITY " + caps)
+	case "NOOP":
+	case "LOGOUT":
+		c.reply("* BYE logging out")
+		c.reply(tag + " OK LOGOUT completed")
+		return false
+	case "STARTTLS":
+		if s.security != SecurityStartTLS || c.tls {
+			c.reply(tag + " BAD STARTTLS not available")
+			return true
+		}
+		c.reply(tag + " OK begin TLS negotiation")
+		tlsConn := tls.Server(c.conn, &tls.Config{Certificates: []tls.Certificate{s.cert}})
+		if err := tlsConn.Handshake(); err != nil {
+			return false
+		}
+		c.conn, c.tls = tlsConn, true
+		c.r, c.w = bufio.NewReader(tlsConn), bufio.NewWriter(tlsConn)
+		return true
+	case "LOGIN":
+		if !c.tls {
+			c.reply(tag + " NO [PRIVACYREQUIRED] use STARTTLS first")
+			return true
+		}
+		if len(args) != 2 || args[0] != s.Username || args[1] != s.Password {
+			c.reply(tag + " NO [AUTHENTICATIONFAILED] invalid credentials")
+			return true
+		}
+		s.mu.Lock()
+		s.logins++
+		s.mu.Unlock()
+		c.loggedIn = true
+	default:
+		if !c.loggedIn {
+			c.reply(tag + " NO not authenticated")
+			return true
+		}
+		if !c.handleMailbox(tag, cmd, args) {
+			return true
+		}
+	}
+	c.reply(tag + " OK " + cmd + " completed")
+	return true
+}
+
+// handleMailbox runs the commands that need a login. It returns false
+// when it already sent the tagged completion.
+func (c *session) handleMailbox(tag, cmd string, args []any) bool {
+	s := c.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch cmd {
+	case "LIST":
+		names := make([]string, 0, len(s.mailboxes))
+		for name := range s.mailboxes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		pattern := ""
+		if len(args) == 2 {
+			pattern, _ = args[1].(string)
+		}
+		for _, name := range names {
+			if pattern == "*" || pattern == "%" || pattern == name {
+				c.reply(fmt.Sprintf(`* LIST () "/" %s`, quote(encodeMailboxName(name))))
+			}
+		}
+	case "SELECT", "EXAMINE":
+		name := ""
+		if len(args) == 1 {
+			name, _ = args[0].(string)
+		}
+		name = decodeMailboxName(name)
+		box, ok := s.mailboxes[name]
+		if !ok {
+			c.reply(tag + " NO [NONEXISTENT] no such mailbox")
+			return false
+		}
+		c.selected, c.readOnly = name, cmd == "EXAMINE"
+		c.reply(fmt.Sprintf("* %d EXISTS", len(box.messages)))
+		c.reply("* FLAGS (\\Seen \\Answered \\Flagged \\Deleted \\Draft)")
+		c.reply(fmt.Sprintf("* OK [UIDVALIDITY %d] UIDs valid", box.uidValidity))
+		c.reply(fmt.Sprintf("* OK [UIDNEXT %d] predicted next UID", box.uidNext))
+		mode := "READ-WRITE"
+		if c.readOnly {
+			mode = "READ-ONLY"
+		}
+		c.reply(fmt.Sprintf("%s OK [%s] %s completed", tag, mode, cmd))
+		return false
+	case "UID SEARCH":
+		box := s.mailboxes[c.selected]
+		if box == nil {
+			c.reply(tag + " BAD no mailbox selected")
+			return false
+		}
+		match, err := searchMatcher(args, box)
+		if err != nil {
+			c.reply(tag + " BAD " + err.Error())
+			return false
+		}
+		var uids []string
+		for _, m := range box.messages {
+			if match(m) {
+				uids = append(uids, strconv.FormatUint(uint64(m.UID), 10))
+			}
+		}
+		c.reply(strings.TrimSpace("* SEARCH " + strings.Join(uids, " ")))
+	case "UID FETCH":
+		box := s.mailboxes[c.selected]
+		if box == nil || len(args) != 2 {
+			c.reply(tag + " BAD no mailbox selected")
+			return false
+		}
+		set, _ := args[0].(string)
+		inSet := uidSet(set, box.uidNext-1)
+		items := fetchItems(args[1])
+		for i, m := range box.messages {
+			if inSet(m.UID) {
+				c.writeFetch(i+1, m, items)
+			}
+		}
+	default:
+		c.reply(tag + " BAD unknown command " + cmd)
+		return false
+	}
+	return true
+}
+
+// writeFetch sends one FETCH response. Non-peek body fetches set \Seen
+// on read-write mailboxes.
+func (c *session) writeFetch(seq int, m *Message, items []string) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "* %d FETCH (UID %d", seq, m.UID)
+	for _, item := range items {
+		switch item {
+		case "UID":
+		case "FLAGS":
+			fmt.Fprintf(&b, " FLAGS (%s)", strings.Join(m.Flags, " "))
+		case "RFC822.SIZE":
+			fmt.Fprintf(&b, " RFC822.SIZE %d", len(m.Raw))
+		case "INTERNALDATE":
+			fmt.Fprintf(&b, ` INTERNALDATE "%s"`, m.InternalDate.Format("02-Jan-2006 15:04:05 -0700"))
+		case "BODY.PEEK[]", "BODY[]", "RFC822":
+			fmt.Fprintf(&b, " BODY[] {%d}\r\n", len(m.Raw))
+			b.Write(m.Raw)
+			if item != "BODY.PEEK[]" && !c.readOnly && !hasFlag(m.Flags, `\Seen`) {
+				m.Flags = append(m.Flags, `\Seen`)
+			}
+		case "BODY.PEEK[HEADER]", "BODY[HEADER]", "RFC822.HEADER":
+			header := m.Raw
+			if i := bytes.Index(header, []byte("\r\n\r\n")); i >= 0 {
+				header = header[:i+4]
+			}
+			fmt.Fprintf(&b, " BODY[HEADER] {%d}\r\n", len(header))
+			b.Write(header)
+		}
+	}
+	for i := 0; i < c.server.padCount; i++ {
+		fmt.Fprintf(&b, " X-PAD%d {%d}\r\n", i, c.server.padSize)
+		b.Write(bytes.Repeat([]byte("x"), c.server.padSize))
+	}
+	b.WriteString(")")
+	c.reply(b.String())
+}
+
+func (c *session) reply(line string) {
+	_, _ = c.w.WriteString(line + "\r\n")
+	_ = c.w.Flush()
+}
+
+// readCommand reads one command line, answering synchronizing literals
+// with a continuation request.
+func (c *session) readCommand() ([]any, error) {
+	var line []byte
+	var literals [][]byte
+	for {
+		part, err := c.r.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+		part = bytes.TrimRight(part, "\r\n")
+		line = append(line, part...)
+		n, ok := trailingLiteral(part)
+		if !ok {
+			break
+		}
+		c.reply("+ Ready for literal data")
+		lit := make([]byte, n)
+		if _, err := io.ReadFull(c.r, lit); err != nil {
+			return nil, err
+		}
+		literals = append(literals, lit)
+	}
+	return parseFields(line, literals), nil
+}
+
+func trailingLiteral(line []byte) (int, bool) {
+	if !bytes.HasSuffix(line, []byte("}")) {
+		return 0, false
+	}
+	open := bytes.LastIndexByte(line, '{')
+	if open < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(string(line[open+1:len(line)-1]), "+"))
+	return n, err == nil && n >= 0
+}
+
+// parseFields splits a command into atoms / strings and parenthesized
+// lists. Bracketed sections stay inside their atom.
+func parseFields(line []byte, literals [][]byte) []any {
+	pos := 0
+	var list func() []any
+	list = func() []any {
+		out := []any{}
+		for pos < len(line) {
+			switch ch := line[pos]; {
+			case ch == ' ':
+				pos++
+			case ch == ')':
+				pos++
+				return out
+			case ch == '(':
+				pos++
+				out = append(out, list())
+			case ch == '"':
+				var b strings.Builder
+				for pos++; pos < len(line) && line[pos] != '"'; pos++ {
+					if line[pos] == '\\' && pos+1 < len(line) {
+						pos++
+					}
+					b.WriteByte(line[pos])
+				}
+				pos++
+				out = append(out, b.String())
+			case ch == '{' && len(literals) > 0:
+				for pos < len(line) && line[pos] != '}' {
+					pos++
+				}
+				pos++
+				out = append(out, string(literals[0]))
+				literals = literals[1:]
+			default:
+				start, depth := pos, 0
+				for ; pos < len(line); pos++ {
+					c := line[pos]
+					if c == '[' {
+						depth++
+					} else if c == ']' && depth > 0 {
+						depth--
+					} else if depth == 0 && (c == ' ' || c == '(' || c == ')') {
+						break
+					}
+				}
+				out = append(out, string(line[start:pos]))
+			}
+		}
+		return out
+	}
+	return list()
+}
+
+func fetchItems(arg any) []string {
+	var raw []any
+	switch v := arg.(type) {
+	case []any:
+		raw = v
+	case string:
+		raw = []any{v}
+	}
+	out := make([]string, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, strings.ToUpper(fmt.Sprint(r)))
+	}
+	return out
+}
+
+// uidSet parses a sequence set such as "1,4:6,9:*".
+func uidSet(set string, max uint32) func(uint32) bool {
+	type span struct{ lo, hi uint32 }
+	var spans []span
+	bound := func(s string) uint32 {
+		if s == "*" {
+			return max
+		}
+		n, _ := strconv.ParseUint(s, 10, 32)
+		return uint32(n)
+	}
+	for _, part := range strings.Split(set, ",") {
+		lo, hi, isRange := strings.Cut(part, ":")
+		sp := span{bound(lo), bound(lo)}
+		if isRange {
+			sp.hi = bound(hi)
+		}
+		if sp.lo > sp.hi {
+			sp.lo, sp.hi = sp.hi, sp.lo
+		}
+		spans = append(spans, sp)
+	}
+	return func(uid uint32) bool {
+		for _, sp := range spans {
+			if uid >= sp.lo && uid <= sp.hi {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// searchMatcher compiles the search keys the client sends into one
+// predicate; all keys must match.
+func searchMatcher(args []any, box *mailbox) (func(*Message) bool, error) {
+	var preds []func(*Message) bool
+	max := box.uidNext - 1
+	if len(box.messages) > 0 {
+		max = box.messages[len(box.messages)-1].UID
+	}
+	for i := 0; i < len(args); i++ {
+		key := strings.ToUpper(fmt.Sprint(args[i]))
+		next := func() (string, error) {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%s needs an argument", key)
+			}
+			i++
+			return fmt.Sprint(args[i]), nil
+		}
+		switch key {
+		case "ALL":
+		case "CHARSET":
+			if _, err := next(); err != nil {
+				return nil, err
+			}
+		case "UID":
+			set, err := next()
+			if err != nil {
+				return nil, err
+			}
+			in := uidSet(set, max)
+			preds = append(preds, func(m *Message) bool { return in(m.UID) })
+		case "FROM", "TO", "SUBJECT":
+			value, err := next()
+			if err != nil {
+				return nil, err
+			}
+			header := strings.ToUpper(key[:1]) + strings.ToLower(key[1:])
+			preds = append(preds, func(m *Message) bool {
+				return containsFold(headerValue(m.Raw, header), value)
+			})
+		case "TEXT", "BODY":
+			value, err := next()
+			if err != nil {
+				return nil, err
+			}
+			preds = append(preds, func(m *Message) bool {
+				return containsFold(string(m.Raw), value) || containsFold(headerValue(m.Raw, "Subject"), value)
+			})
+		case "SINCE", "BEFORE":
+			value, err := next()
+			if err != nil {
+				return nil, err
+			}
+			day, err := time.Parse("2-Jan-2006", value)
+			if err != nil {
+				return nil, fmt.Errorf("bad date %q", value)
+			}
+			since := key == "SINCE"
+			preds = append(preds, func(m *Message) bool {
+				d := messageDate(m)
+				if since {
+					return !d.Before(day)
+				}
+				return d.Before(day)
+			})
+		case "UNSEEN":
+			preds = append(preds, func(m *Message) bool { return !hasFlag(m.Flags, `\Seen`) })
+		case "SEEN":
+			preds = append(preds, func(m *Message) bool { return hasFlag(m.Flags, `\Seen`) })
+		default:
+			return nil, fmt.Errorf("unsupported search key %s", key)
+		}
+	}
+	return func(m *Message) bool {
+		for _, p := range preds {
+			if !p(m) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+var wordDecoder = new(mime.WordDecoder)
+
+func headerValue(raw []byte, name string) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return ""
+	}
+	value := msg.Header.Get(name)
+	if decoded, err := wordDecoder.DecodeHeader(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// messageDate is the Date header's calendar day, falling back to the
+// internal date.
+func messageDate(m *Message) time.Time {
+	d := m.InternalDate
+	if msg, err := mail.ReadMessage(bytes.NewReader(m.Raw)); err == nil {
+		if hd, err := msg.Header.Date(); err == nil {
+			d = hd
+		}
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if strings.EqualFold(f, flag) {
+			return true
+		}
+	}
+	return false
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+// encodeMailboxName and decodeMailboxName implement the modified UTF-7
+// of RFC 3501 for the non-ASCII mailbox names tests use.
+func encodeMailboxName(name string) string {
+	var b strings.Builder
+	var pending []uint16
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		raw := make([]byte, 0, len(pending)*2)
+		for _, u := range pending {
+			raw = append(raw, byte(u>>8), byte(u))
+		}
+		enc := strings.TrimRight(base64.StdEncoding.EncodeToString(raw), "=")
+		b.WriteString("&" + strings.ReplaceAll(enc, "/", ",") + "-")
+		pending = pending[:0]
+	}
+	for _, r := range name {
+		if r >= 0x20 && r <= 0x7e {
+			flush()
+			if r == '&' {
+				b.WriteString("&-")
+			} else {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		if r > 0xffff {
+			r -= 0x10000
+			pending = append(pending, uint16(0xd800+(r>>10)), uint16(0xdc00+(r&0x3ff)))
+		} else {
+			pending = append(pending, uint16(r))
+		}
+	}
+	flush()
+	return b.String()
+}
+
+func decodeMailboxName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '&' {
+			b.WriteByte(name[i])
+			continue
+		}
+		end := strings.IndexByte(name[i:], '-')
+		if end < 0 {
+			return name
+		}
+		chunk := name[i+1 : i+end]
+		i += end
+		if chunk == "" {
+			b.WriteByte('&')
+			continue
+		}
+		raw, err := base64.RawStdEncoding.DecodeString(strings.ReplaceAll(chunk, ",", "/"))
+		if err != nil {
+			return name
+		}
+		var units []uint16
+		for j := 0; j+1 < len(raw); j += 2 {
+			units = append(units, uint16(raw[j])<<8|uint16(raw[j+1]))
+		}
+		for j := 0; j < len(units); j++ {
+			u := rune(units[j])
+			if u >= 0xd800 && u < 0xdc00 && j+1 < len(units) {
+				u = 0x10000 + (u-0xd800)<<10 + (rune(units[j+1]) - 0xdc00)
+				j++
+			}
+			b.WriteRune(u)
+		}
+	}
+	return b.String()
+}
+
+func selfSignedCert() (tls.Certificate, *x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "imaptest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool, nil
+}
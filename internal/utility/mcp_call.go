@@ -19,11 +19,9 @@
 // tools/call invocation path so the MCPToolAdapter can return
 // real results instead of "not yet implemented" errors.
 //
-// The implementation focuses on the streamable-HTTP transport
-// (spec 2025-03-26) because that is the dominant transport for
-// modern MCP servers. The legacy SSE transport's session
-// lifecycle is more complex; deferring it matches the rest of
-// the package's "loud-fail with a clear error" pattern.
+// CallTool is a one-shot session (mcp_session.go) over any of
+// the transports; callers that invoke a server repeatedly keep a
+// Session, or a SessionPool, instead.
 
 package utility
 
@@ -45,6 +43,7 @@ type CallOptions struct {
 	ServerType string
 	Headers    map[string]string
 	Variables  map[string]string
+	Stdio      *StdioServer
 	ToolName   string
 	Arguments  json.RawMessage // JSON-encoded argument object
 	Timeout    time.Duration
@@ -72,91 +71,34 @@ type CallResult struct {
 // the same way FetchTools does it; the same protocol constants
 // (protocolVersion, clientName, etc.) apply. The session is
 // per-call (initialize + notifications/initialized +
-// tools/call) — a future optimization can pool sessions.
+// tools/call).
 func CallTool(ctx context.Context, opts CallOptions) (*CallResult, error) {
-	if opts.URL == "" {
+	if opts.URL == "" && opts.ServerType != TransportStdio {
 		return nil, errors.New("Invalid url.")
 	}
 	if opts.ToolName == "" {
 		return nil, errors.New("MCP tool name is required")
 	}
-	if opts.Timeout <= 0 {
-		opts.Timeout = 10 * time.Second
-	}
-	hostname, resolvedIP, err := AssertURLSafe(opts.URL)
-	if err != nil {
-		return nil, err
-	}
-	if opts.HTTPClient == nil {
-		opts.HTTPClient = PinnedHTTPClient(hostname, resolvedIP, opts.Timeout)
-	}
-	headers, headerErr := renderHeaders(opts.Headers, opts.Variables)
-	if headerErr != nil {
-		return nil, headerErr
-	}
-	connectCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
-	defer cancel()
-
-	switch opts.ServerType {
-	case TransportStreamableHTTP, "":
+	serverType := opts.ServerType
+	if serverType == "" {
 		// Empty ServerType is treated as streamable-http because
-		// that is the default per the spec. Servers explicitly
-		// declaring the legacy SSE transport get the legacy path.
-		return callToolStreamableHTTP(connectCtx, opts.URL, headers, opts.HTTPClient, opts.ToolName, opts.Arguments)
-	case TransportSSE:
-		return nil, errors.New("MCP tools/call on legacy SSE transport is not yet implemented in Go (Phase 3.7 deferred; use streamable-http)")
-	default:
-		return nil, fmt.Errorf("Unsupported MCP server type.")
-	}
-}
-
-// callToolStreamableHTTP drives the streamable-HTTP session:
-// initialize → notifications/initialized → tools/call. The
-// session is torn down at the end (the server is free to
-// garbage-collect the session id; future calls re-initialize).
-func callToolStreamableHTTP(ctx context.Context, endpoint string, headers map[string]string, client *http.Client, toolName string, args json.RawMessage) (*CallResult, error) {
-	sessionID, initRes, err := streamableSend(ctx, client, endpoint, "", headers, jsonRPCRequest{
-		JSONRPC: jsonRPCVersion,
-		ID:      0,
-		Method:  "initialize",
-		Params:  initializeParams(),
-	}, true)
+		// that is the default per the spec.
+		serverType = TransportStreamableHTTP
+	}
+	session, err := Connect(ctx, ConnectOptions{
+		URL:        opts.URL,
+		ServerType: serverType,
+		Headers:    opts.Headers,
+		Variables:  opts.Variables,
+		Stdio:      opts.Stdio,
+		Timeout:    opts.Timeout,
+		HTTPClient: opts.HTTPClient,
+	})
 	if err != nil {
 		return nil, err
 	}
-	if initRes.Error != nil {
-		return nil, formatMCPError("initialize", initRes.Error)
-	}
-
-	if _, _, err := streamableSend(ctx, client, endpoint, sessionID, headers, jsonRPCRequest{
-		JSONRPC: jsonRPCVersion,
-		Method:  "notifications/initialized",
-	}, false); err != nil {
-		return nil, err
-	}
-
-	var argsAny any
-	if len(args) > 0 {
-		if err := json.Unmarshal(args, &argsAny); err != nil {
-			return nil, fmt.Errorf("mcp tools/call: arguments are not valid JSON: %w", err)
-		}
-	}
-	_, callRes, err := streamableSend(ctx, client, endpoint, sessionID, headers, jsonRPCRequest{
-		JSONRPC: jsonRPCVersion,
-		ID:      2,
-		Method:  "tools/call",
-		Params: map[string]any{
-			"name":      toolName,
-			"arguments": argsAny,
-		},
-	}, true)
-	if err != nil {
-		return nil, err
-	}
-	if callRes.Error != nil {
-		return nil, formatMCPError("tools/call", callRes.Error)
-	}
-	return parseCallResult(callRes.Result)
+	defer session.Close()
+	return session.CallTool(ctx, opts.ToolName, opts.Arguments)
 }
 
 // parseCallResult decodes the tools/call response envelope.
@@ -19,6 +19,7 @@ package utility
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -125,10 +126,10 @@ func TestCallTool_StreamableHTTP(t *testing.T) {
 		}
 		// tools/call returns the canned result.
 		if req.Method == "tools/call" {
-			_, _ = w.Write([]byte(`{
-				"jsonrpc":"2.0","id":2,
+			fmt.Fprintf(w, `{
+				"jsonrpc":"2.0","id":%v,
 				"result":{"content":[{"type":"text","text":"hello from mcp"}],"isError":false}
-			}`))
+			}`, req.ID)
 			return
 		}
 		// notifications/initialized + others: 202 with no body.
@@ -170,7 +171,7 @@ func TestCallTool_ServerError(t *testing.T) {
 			return
 		}
 		if req.Method == "tools/call" {
-			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found"}}`))
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%v,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
 			return
 		}
 		w.WriteHeader(http.StatusAccepted)
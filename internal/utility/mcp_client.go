@@ -15,9 +15,10 @@
 //
 
 // Package mcpclient is a minimal Model Context Protocol (MCP) client used by
-// the Go MCP-management endpoints to list a remote server's tools during
-// import and the "test" endpoint. It implements just enough of the spec to
-// negotiate a session and call tools/list:
+// the Go MCP-management endpoints and the Agent's MCP tools. It implements
+// enough of the spec to negotiate a session (see Session in
+// mcp_session.go) and use a server's tools, resources and prompts over
+// three transports:
 //
 //   - streamable-HTTP transport (spec 2025-03-26): single endpoint, JSON-RPC
 //     requests via POST, responses either as application/json or as an SSE
@@ -25,9 +26,11 @@
 //   - SSE transport (spec 2024-11-05, legacy): server returns an "endpoint"
 //     event whose data is the URL the client POSTs JSON-RPC requests to;
 //     responses are pushed back on the same SSE stream.
+//   - stdio transport: the server is a local process started by the
+//     registered StdioLauncher, exchanging newline-delimited JSON-RPC on
+//     its stdin / stdout (mcp_stdio.go).
 //
-// The full Python implementation lives in common/mcp_tool_call_conn.py; this
-// is a reduced port focused on tools/list discovery.
+// The full Python implementation lives in common/mcp_tool_call_conn.py.
 package utility
 
 import (
@@ -49,6 +52,7 @@ import (
 const (
 	TransportSSE            = "sse"
 	TransportStreamableHTTP = "streamable-http"
+	TransportStdio          = "stdio"
 )
 
 const (
@@ -68,56 +72,38 @@ type Tool struct {
 	Raw         map[string]interface{} `json:"-"`
 }
 
-// FetchOptions controls a single tools/list discovery call.
+// FetchOptions controls a single tools/list discovery call. URL,
+// Headers and HTTPClient apply to the HTTP transports, Stdio to the
+// stdio one.
 type FetchOptions struct {
-	URL         string
-	ServerType  string
-	Headers     map[string]string
-	Variables   map[string]string
-	Timeout     time.Duration
-	HTTPClient  *http.Client
-	pinHostname string
-	pinIP       string
+	URL        string
+	ServerType string
+	Headers    map[string]string
+	Variables  map[string]string
+	Stdio      *StdioServer
+	Timeout    time.Duration
+	HTTPClient *http.Client
 }
 
-// FetchTools opens a connection to the MCP server described by opts and
+// FetchTools opens a session to the MCP server described by opts and
 // returns the tools advertised by tools/list. URL safety / DNS pinning is
-// performed here so callers get the same SSRF guarantees the Python path
-// has via pin_dns_global + assert_url_is_safe.
+// performed by Connect so callers get the same SSRF guarantees the Python
+// path has via pin_dns_global + assert_url_is_safe.
 func FetchTools(ctx context.Context, opts FetchOptions) ([]Tool, error) {
-	if opts.URL == "" {
-		return nil, errors.New("Invalid url.")
-	}
-	if opts.Timeout <= 0 {
-		opts.Timeout = 10 * time.Second
-	}
-
-	hostname, resolvedIP, err := AssertURLSafe(opts.URL)
+	session, err := Connect(ctx, ConnectOptions{
+		URL:        opts.URL,
+		ServerType: opts.ServerType,
+		Headers:    opts.Headers,
+		Variables:  opts.Variables,
+		Stdio:      opts.Stdio,
+		Timeout:    opts.Timeout,
+		HTTPClient: opts.HTTPClient,
+	})
 	if err != nil {
 		return nil, err
 	}
-	opts.pinHostname = hostname
-	opts.pinIP = resolvedIP
-	if opts.HTTPClient == nil {
-		opts.HTTPClient = PinnedHTTPClient(hostname, resolvedIP, opts.Timeout)
-	}
-
-	headers, headerErr := renderHeaders(opts.Headers, opts.Variables)
-	if headerErr != nil {
-		return nil, headerErr
-	}
-
-	connectCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
-	defer cancel()
-
-	switch strings.ToLower(opts.ServerType) {
-	case TransportStreamableHTTP:
-		return fetchToolsStreamableHTTP(connectCtx, opts.URL, headers, opts.HTTPClient)
-	case TransportSSE:
-		return fetchToolsSSE(connectCtx, opts.URL, headers, opts.HTTPClient)
-	default:
-		return nil, fmt.Errorf("Unsupported MCP server type.")
-	}
+	defer session.Close()
+	return session.ListTools(ctx)
 }
 
 // renderHeaders applies ${name} substitution to header keys and values using
@@ -209,46 +195,81 @@ func initializeParams() map[string]interface{} {
 
 const sessionHeader = "Mcp-Session-Id"
 
-func fetchToolsStreamableHTTP(ctx context.Context, endpoint string, headers map[string]string, client *http.Client) ([]Tool, error) {
-	sessionID, initRes, err := streamableSend(ctx, client, endpoint, "", headers, jsonRPCRequest{
-		JSONRPC: jsonRPCVersion,
-		ID:      0,
-		Method:  "initialize",
-		Params:  initializeParams(),
-	}, true)
-	if err != nil {
-		return nil, err
-	}
-	if initRes.Error != nil {
-		return nil, formatMCPError("initialize", initRes.Error)
-	}
+// streamableConn is a streamable-HTTP session: every message is its own
+// POST, carrying the session id the server handed out on initialize.
+// Server notifications are only seen when the server streams them
+// alongside a response; the optional standalone GET stream is not
+// opened.
+type streamableConn struct {
+	endpoint  string
+	headers   map[string]string
+	client    *http.Client
+	onMessage func(*jsonRPCResponse)
 
-	if _, _, err := streamableSend(ctx, client, endpoint, sessionID, headers, jsonRPCRequest{
-		JSONRPC: jsonRPCVersion,
-		Method:  "notifications/initialized",
-	}, false); err != nil {
-		return nil, err
+	mu        sync.Mutex
+	sessionID string
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newStreamableConn(endpoint string, headers map[string]string, client *http.Client, onMessage func(*jsonRPCResponse)) *streamableConn {
+	return &streamableConn{
+		endpoint:  endpoint,
+		headers:   headers,
+		client:    client,
+		onMessage: onMessage,
+		closed:    make(chan struct{}),
 	}
+}
+
+func (c *streamableConn) session() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionID
+}
 
-	_, listRes, err := streamableSend(ctx, client, endpoint, sessionID, headers, jsonRPCRequest{
-		JSONRPC: jsonRPCVersion,
-		ID:      1,
-		Method:  "tools/list",
-	}, true)
+func (c *streamableConn) call(ctx context.Context, req jsonRPCRequest) (*jsonRPCResponse, error) {
+	select {
+	case <-c.closed:
+		return nil, errMCPSessionClosed
+	default:
+	}
+	sid, res, err := streamableSend(ctx, c.client, c.endpoint, c.session(), c.headers, req, true, c.onMessage)
 	if err != nil {
 		return nil, err
 	}
-	if listRes.Error != nil {
-		return nil, formatMCPError("tools/list", listRes.Error)
+	c.mu.Lock()
+	if c.sessionID == "" {
+		c.sessionID = sid
 	}
-	return parseToolsResult(listRes.Result)
+	c.mu.Unlock()
+	return res, nil
+}
+
+func (c *streamableConn) send(ctx context.Context, msg any) error {
+	_, _, err := streamableSend(ctx, c.client, c.endpoint, c.session(), c.headers, msg, false, nil)
+	return err
 }
 
-// streamableSend POSTs a JSON-RPC payload to the streamable-HTTP endpoint.
-// When expectResponse is false (notifications), the response body is not
-// parsed. The session id returned by the initial initialize call is
-// propagated via the Mcp-Session-Id header per the spec.
-func streamableSend(ctx context.Context, client *http.Client, endpoint, sessionID string, headers map[string]string, payload jsonRPCRequest, expectResponse bool) (string, *jsonRPCResponse, error) {
+func (c *streamableConn) done() <-chan struct{} { return c.closed }
+
+func (c *streamableConn) cause() error { return errMCPSessionClosed }
+
+// close only stops the connection from being used: no DELETE is sent,
+// the server expires the session on its own.
+func (c *streamableConn) close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+// streamableSend POSTs a JSON-RPC message to the streamable-HTTP endpoint.
+// When expectResponse is false (notifications, replies to server
+// requests), the response body is not parsed. The session id returned by
+// the initial initialize call is propagated via the Mcp-Session-Id header
+// per the spec. Server messages streamed ahead of the response are handed
+// to onMessage.
+func streamableSend(ctx context.Context, client *http.Client, endpoint, sessionID string, headers map[string]string, payload any, expectResponse bool, onMessage func(*jsonRPCResponse)) (string, *jsonRPCResponse, error) {
+	method, id := messageLabel(payload)
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return "", nil, fmt.Errorf("marshal MCP request: %w", err)
@@ -278,7 +299,7 @@ func streamableSend(ctx context.Context, client *http.Client, endpoint, sessionI
 
 	if !expectResponse {
 		if resp.StatusCode >= 400 {
-			return "", nil, fmt.Errorf("MCP server returned HTTP %d for %s", resp.StatusCode, payload.Method)
+			return "", nil, fmt.Errorf("MCP server returned HTTP %d for %s", resp.StatusCode, method)
 		}
 		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
 		return resp.Header.Get(sessionHeader), nil, nil
@@ -286,7 +307,7 @@ func streamableSend(ctx context.Context, client *http.Client, endpoint, sessionI
 
 	if resp.StatusCode >= 400 {
 		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
-		return "", nil, fmt.Errorf("MCP server returned HTTP %d for %s: %s", resp.StatusCode, payload.Method, strings.TrimSpace(string(raw)))
+		return "", nil, fmt.Errorf("MCP server returned HTTP %d for %s: %s", resp.StatusCode, method, strings.TrimSpace(string(raw)))
 	}
 
 	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
@@ -295,7 +316,7 @@ func streamableSend(ctx context.Context, client *http.Client, endpoint, sessionI
 		sessionID = sid
 	}
 	if strings.Contains(contentType, "text/event-stream") {
-		r, err := readJSONRPCFromSSE(resp.Body, payload.ID)
+		r, err := readJSONRPCFromSSE(resp.Body, id, onMessage)
 		if err != nil {
 			return "", nil, err
 		}
@@ -305,45 +326,88 @@ func streamableSend(ctx context.Context, client *http.Client, endpoint, sessionI
 	if err != nil {
 		return "", nil, fmt.Errorf("read MCP response: %w", err)
 	}
-	parsed, err := parseJSONRPC(raw, payload.ID)
+	parsed, err := parseJSONRPC(raw, id)
 	if err != nil {
 		return "", nil, err
 	}
 	return sessionID, parsed, nil
 }
 
+// messageLabel returns the method (for error messages) and id of an
+// outgoing JSON-RPC message.
+func messageLabel(msg any) (string, interface{}) {
+	switch m := msg.(type) {
+	case jsonRPCRequest:
+		return m.Method, m.ID
+	case jsonRPCResponse:
+		return "response", m.ID
+	default:
+		return "message", nil
+	}
+}
+
 // ---------- SSE transport ----------
 
-func fetchToolsSSE(ctx context.Context, endpoint string, headers map[string]string, client *http.Client) ([]Tool, error) {
-	streamReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+// sseConn is a legacy SSE session: one long-lived GET stream carries the
+// server's messages, and every client message is POSTed to the URL the
+// server announced in its "endpoint" event.
+type sseConn struct {
+	postURL string
+	headers map[string]string
+	client  *http.Client
+	pending *pendingResponses
+	cancel  context.CancelFunc
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// dialSSE opens the SSE stream and waits for the endpoint event. ctx
+// bounds the handshake only; the stream stays open until close.
+func dialSSE(ctx context.Context, endpoint string, headers map[string]string, client *http.Client, onMessage func(*jsonRPCResponse)) (*sseConn, error) {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stopHandshakeWatch := context.AfterFunc(ctx, cancel)
+	fail := func(err error) (*sseConn, error) {
+		cancel()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	streamReq, err := http.NewRequestWithContext(streamCtx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("build SSE request: %w", err)
+		return fail(fmt.Errorf("build SSE request: %w", err))
 	}
 	streamReq.Header.Set("Accept", "text/event-stream")
 	streamReq.Header.Set("Cache-Control", "no-cache")
 	for k, v := range headers {
 		streamReq.Header.Set(k, v)
 	}
+	// The stream outlives any single request, so the client's overall
+	// timeout must not cut it off; dial / header timeouts still apply.
+	streamClient := *client
+	streamClient.Timeout = 0
 	// operator-configured (tenant MCP URL, set per-tenant by admin) and
 	// is passed through AssertURLSafe + PinnedHTTPClient before we
 	// reach this point.
 	// codeql[go/request-forgery] False positive: the SSE endpoint is
-	streamResp, err := client.Do(streamReq)
+	streamResp, err := streamClient.Do(streamReq)
 	if err != nil {
-		return nil, mapMCPConnectionError(err)
+		return fail(mapMCPConnectionError(err))
 	}
 	if streamResp.StatusCode >= 400 {
 		body, _ := io.ReadAll(io.LimitReader(streamResp.Body, 1<<20))
 		streamResp.Body.Close()
-		return nil, fmt.Errorf("MCP SSE handshake returned HTTP %d: %s", streamResp.StatusCode, strings.TrimSpace(string(body)))
+		return fail(fmt.Errorf("MCP SSE handshake returned HTTP %d: %s", streamResp.StatusCode, strings.TrimSpace(string(body))))
 	}
 
 	stream := newSSEReader(streamResp.Body)
-	defer streamResp.Body.Close()
-
-	postURL, err := waitForEndpoint(ctx, stream, endpoint)
+	postURL, err := waitForEndpoint(streamCtx, stream, endpoint)
 	if err != nil {
-		return nil, err
+		streamResp.Body.Close()
+		return fail(err)
 	}
 
 	// The endpoint event can hand us an arbitrary absolute URL. A
@@ -354,77 +418,101 @@ func fetchToolsSSE(ctx context.Context, endpoint string, headers map[string]stri
 	// client so the dial-time IP override still applies.
 	postClient := client
 	if postHost, postIP, vErr := AssertURLSafe(postURL); vErr != nil {
-		return nil, vErr
+		streamResp.Body.Close()
+		return fail(vErr)
 	} else if u, perr := url.Parse(postURL); perr == nil && u.Hostname() != "" {
 		if u.Hostname() != originalHost(endpoint) {
 			postClient = PinnedHTTPClient(postHost, postIP, sseTimeoutFrom(ctx))
 		}
 	}
+	if !stopHandshakeWatch() {
+		streamResp.Body.Close()
+		return fail(ctx.Err())
+	}
 
-	pending := newPendingResponses()
-	streamDone := make(chan error, 1)
+	c := &sseConn{
+		postURL: postURL,
+		headers: headers,
+		client:  postClient,
+		pending: newPendingResponses(),
+		cancel:  cancel,
+		closed:  make(chan struct{}),
+	}
 	go func() {
-		streamDone <- stream.dispatch(ctx, pending)
-	}()
-
-	postOnce := func(payload jsonRPCRequest) error {
-		body, _ := json.Marshal(payload)
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, postURL, bytes.NewReader(body))
-		if err != nil {
-			return fmt.Errorf("build SSE POST: %w", err)
-		}
-		req.Header.Set("Content-Type", "application/json")
-		for k, v := range headers {
-			req.Header.Set(k, v)
-		}
-		// just re-validated against AssertURLSafe above (and re-pinned
-		// to a fresh client if the host differs from the original
-		// SSE endpoint), so the request cannot be redirected to an
-		// internal target.
-		// codeql[go/request-forgery] False positive: postURL was
-		resp, err := postClient.Do(req)
-		if err != nil {
-			return mapMCPConnectionError(err)
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode >= 400 {
-			raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
-			return fmt.Errorf("MCP server returned HTTP %d for %s: %s", resp.StatusCode, payload.Method, strings.TrimSpace(string(raw)))
+		err := stream.dispatch(streamCtx, c.pending, onMessage)
+		streamResp.Body.Close()
+		if err == nil {
+			err = errors.New("MCP SSE stream closed before response arrived")
 		}
-		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
-		return nil
-	}
+		c.finish(err)
+	}()
+	return c, nil
+}
 
+func (c *sseConn) call(ctx context.Context, req jsonRPCRequest) (*jsonRPCResponse, error) {
 	// Register the waiter BEFORE issuing the POST so a fast server that
-	// pushes its response before our wait() call doesn't drop the delivery.
-	initWaiter := pending.register(0)
-	if err := postOnce(jsonRPCRequest{JSONRPC: jsonRPCVersion, ID: 0, Method: "initialize", Params: initializeParams()}); err != nil {
-		pending.cancel(0)
+	// pushes its response before our await() call doesn't drop the delivery.
+	w := c.pending.register(req.ID)
+	if err := c.send(ctx, req); err != nil {
+		c.pending.cancel(req.ID)
 		return nil, err
 	}
-	initRes, err := pending.await(ctx, initWaiter, streamDone)
-	if err != nil {
-		return nil, err
+	return c.pending.await(ctx, w, c.closed, c.cause)
+}
+
+func (c *sseConn) send(ctx context.Context, msg any) error {
+	select {
+	case <-c.closed:
+		return c.cause()
+	default:
 	}
-	if initRes.Error != nil {
-		return nil, formatMCPError("initialize", initRes.Error)
+	method, _ := messageLabel(msg)
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal MCP request: %w", err)
 	}
-	if err := postOnce(jsonRPCRequest{JSONRPC: jsonRPCVersion, Method: "notifications/initialized"}); err != nil {
-		return nil, err
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.postURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build SSE POST: %w", err)
 	}
-	listWaiter := pending.register(1)
-	if err := postOnce(jsonRPCRequest{JSONRPC: jsonRPCVersion, ID: 1, Method: "tools/list"}); err != nil {
-		pending.cancel(1)
-		return nil, err
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
 	}
-	listRes, err := pending.await(ctx, listWaiter, streamDone)
+	// just re-validated against AssertURLSafe in dialSSE (and re-pinned
+	// to a fresh client if the host differs from the original SSE
+	// endpoint), so the request cannot be redirected to an internal
+	// target.
+	// codeql[go/request-forgery] False positive: postURL was
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, err
+		return mapMCPConnectionError(err)
 	}
-	if listRes.Error != nil {
-		return nil, formatMCPError("tools/list", listRes.Error)
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return fmt.Errorf("MCP server returned HTTP %d for %s: %s", resp.StatusCode, method, strings.TrimSpace(string(raw)))
 	}
-	return parseToolsResult(listRes.Result)
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return nil
+}
+
+func (c *sseConn) done() <-chan struct{} { return c.closed }
+
+func (c *sseConn) cause() error { return c.err }
+
+// finish records why the stream ended and wakes every waiter.
+func (c *sseConn) finish(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.closed)
+	})
+}
+
+func (c *sseConn) close() error {
+	c.finish(errMCPSessionClosed)
+	c.cancel()
+	return nil
 }
 
 // waitForEndpoint reads SSE events until an "endpoint" event arrives and
@@ -483,7 +571,7 @@ func sseTimeoutFrom(ctx context.Context) time.Duration {
 }
 
 // pendingResponses correlates outstanding JSON-RPC ids with channels that
-// receive the corresponding response from the SSE dispatcher.
+// receive the corresponding response from the SSE or stdio reader.
 type pendingResponses struct {
 	mu      sync.Mutex
 	waiters map[string]chan *jsonRPCResponse
@@ -521,9 +609,9 @@ func (p *pendingResponses) cancel(id interface{}) {
 	p.mu.Unlock()
 }
 
-// await blocks until the registered waiter's response arrives, the SSE
-// stream closes, or ctx expires.
-func (p *pendingResponses) await(ctx context.Context, w pendingWaiter, streamDone <-chan error) (*jsonRPCResponse, error) {
+// await blocks until the registered waiter's response arrives, the
+// connection closes (done; cause says why), or ctx expires.
+func (p *pendingResponses) await(ctx context.Context, w pendingWaiter, done <-chan struct{}, cause func() error) (*jsonRPCResponse, error) {
 	defer func() {
 		p.mu.Lock()
 		delete(p.waiters, w.key)
@@ -532,11 +620,15 @@ func (p *pendingResponses) await(ctx context.Context, w pendingWaiter, streamDon
 	select {
 	case res := <-w.ch:
 		return res, nil
-	case err := <-streamDone:
-		if err == nil {
-			return nil, errors.New("MCP SSE stream closed before response arrived")
-		}
-		return nil, err
+	case <-done:
+		// A response delivered just before the connection closed
+		// still counts.
+		select {
+		case res := <-w.ch:
+			return res, nil
+		default:
+		}
+		return nil, cause()
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	}
@@ -632,8 +724,9 @@ func (s *sseReader) nextEvent(ctx context.Context) (*sseEvent, error) {
 }
 
 // dispatch reads events off the SSE stream and forwards JSON-RPC responses
-// to the matching waiter. It returns when the stream closes.
-func (s *sseReader) dispatch(ctx context.Context, pending *pendingResponses) error {
+// to the matching waiter and server-initiated messages (notifications and
+// requests) to onMessage. It returns when the stream closes.
+func (s *sseReader) dispatch(ctx context.Context, pending *pendingResponses, onMessage func(*jsonRPCResponse)) error {
 	for {
 		ev, err := s.nextEvent(ctx)
 		if err != nil {
@@ -653,8 +746,10 @@ func (s *sseReader) dispatch(ctx context.Context, pending *pendingResponses) err
 		if err != nil {
 			continue
 		}
-		if parsed.Method != "" && parsed.ID == nil {
-			// Server-initiated notification; nothing to deliver.
+		if parsed.Method != "" {
+			if onMessage != nil {
+				onMessage(parsed)
+			}
 			continue
 		}
 		pending.deliver(parsed)
@@ -663,8 +758,9 @@ func (s *sseReader) dispatch(ctx context.Context, pending *pendingResponses) err
 
 // readJSONRPCFromSSE consumes a single JSON-RPC response off an inline SSE
 // stream returned by a streamable-HTTP POST. The response with matching id
-// is returned; everything else is skipped.
-func readJSONRPCFromSSE(r io.Reader, wantID interface{}) (*jsonRPCResponse, error) {
+// is returned; server-initiated messages go to onMessage (when set) and
+// everything else is skipped.
+func readJSONRPCFromSSE(r io.Reader, wantID interface{}, onMessage func(*jsonRPCResponse)) (*jsonRPCResponse, error) {
 	stream := newSSEReader(r)
 	for {
 		ev, err := stream.nextEvent(context.Background())
@@ -681,10 +777,16 @@ func readJSONRPCFromSSE(r io.Reader, wantID interface{}) (*jsonRPCResponse, erro
 		if len(bytes.TrimSpace(raw)) == 0 {
 			continue
 		}
-		parsed, err := parseJSONRPC(raw, wantID)
+		parsed, err := parseJSONRPC(raw, nil)
 		if err != nil {
 			continue
 		}
+		if parsed.Method != "" {
+			if onMessage != nil {
+				onMessage(parsed)
+			}
+			continue
+		}
 		if normalizeID(parsed.ID) == normalizeID(wantID) {
 			return parsed, nil
 		}
@@ -205,7 +205,7 @@ func TestFetchToolsUnsupportedType(t *testing.T) {
 	defer allowLoopbackForTests(t)()
 	_, err := FetchTools(context.Background(), FetchOptions{
 		URL:        "https://example.com",
-		ServerType: "stdio",
+		ServerType: "websocket",
 		Timeout:    time.Second,
 	})
 	if err == nil || !strings.Contains(err.Error(), "Unsupported MCP server type") {
@@ -0,0 +1,162 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package utility
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSessionIdle is how long a pooled MCP session may sit unused
+// before NewSessionPool's pools close it.
+const DefaultSessionIdle = 5 * time.Minute
+
+// SessionPool keeps one Session per server configuration, so repeated
+// calls reuse a running stdio server or an open HTTP session and its
+// cached tool list. Sessions unused for the pool's idle time are closed;
+// a session whose server went away is replaced on the next Get.
+type SessionPool struct {
+	idle     time.Duration
+	mu       sync.Mutex
+	sessions map[string]*pooledSession
+}
+
+type pooledSession struct {
+	mu      sync.Mutex // held while connecting
+	session *Session
+	timer   *time.Timer
+	removed bool
+}
+
+// NewSessionPool returns an empty pool; idle <= 0 means
+// DefaultSessionIdle.
+func NewSessionPool(idle time.Duration) *SessionPool {
+	if idle <= 0 {
+		idle = DefaultSessionIdle
+	}
+	return &SessionPool{idle: idle, sessions: map[string]*pooledSession{}}
+}
+
+// Get returns the pooled session for opts, connecting one when there is
+// none. opts.HTTPClient is not part of the pool key.
+func (p *SessionPool) Get(ctx context.Context, opts ConnectOptions) (*Session, error) {
+	key := sessionKey(opts)
+	for {
+		p.mu.Lock()
+		e, ok := p.sessions[key]
+		if !ok {
+			e = &pooledSession{}
+			p.sessions[key] = e
+		}
+		p.mu.Unlock()
+
+		e.mu.Lock()
+		if e.removed {
+			// Reaped between the lookup and the lock; start over.
+			e.mu.Unlock()
+			continue
+		}
+		if e.session != nil && !e.session.Closed() {
+			s := e.session
+			s.touch()
+			e.mu.Unlock()
+			return s, nil
+		}
+		if e.session != nil {
+			_ = e.session.Close()
+			e.session = nil
+		}
+		s, err := Connect(ctx, opts)
+		if err != nil {
+			e.mu.Unlock()
+			return nil, err
+		}
+		e.session = s
+		if e.timer == nil {
+			e.timer = time.AfterFunc(p.idle, func() { p.reap(key, e) })
+		}
+		e.mu.Unlock()
+		return s, nil
+	}
+}
+
+// reap closes e's session when it has been idle for the pool's idle time,
+// and re-arms the timer otherwise.
+func (p *SessionPool) reap(key string, e *pooledSession) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.removed {
+		return
+	}
+	if e.session != nil && !e.session.Closed() {
+		if since := e.session.idleSince(); since.IsZero() || time.Since(since) < p.idle {
+			wait := p.idle
+			if !since.IsZero() {
+				wait = p.idle - time.Since(since)
+			}
+			e.timer.Reset(wait)
+			return
+		}
+	}
+	e.removed = true
+	p.mu.Lock()
+	if p.sessions[key] == e {
+		delete(p.sessions, key)
+	}
+	p.mu.Unlock()
+	if e.session != nil {
+		_ = e.session.Close()
+	}
+}
+
+// Close closes every pooled session.
+func (p *SessionPool) Close() {
+	p.mu.Lock()
+	entries := p.sessions
+	p.sessions = map[string]*pooledSession{}
+	p.mu.Unlock()
+	for _, e := range entries {
+		e.mu.Lock()
+		e.removed = true
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		if e.session != nil {
+			_ = e.session.Close()
+		}
+		e.mu.Unlock()
+	}
+}
+
+// sessionKey identifies a server configuration.
+func sessionKey(opts ConnectOptions) string {
+	raw, _ := json.Marshal(struct {
+		URL        string
+		ServerType string
+		Headers    map[string]string
+		Variables  map[string]string
+		Stdio      *StdioServer
+		Timeout    time.Duration
+	}{opts.URL, strings.ToLower(opts.ServerType), opts.Headers, opts.Variables, opts.Stdio, opts.Timeout})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,573 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// mcp_session.go — a long-lived MCP session over any of the three
+// transports, with the resources and prompts methods on top of tools.
+//
+// Connect negotiates the session (initialize + notifications/initialized)
+// and keeps it open until Close; FetchTools and CallTool are one-shot
+// wrappers around it. The session caches the server's tool list and drops
+// and re-fetches it when the server sends
+// notifications/tools/list_changed, so a pooled session (mcp_pool.go)
+// always hands out the current list.
+
+package utility
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultMCPTimeout applies when ConnectOptions.Timeout is unset.
+const defaultMCPTimeout = 10 * time.Second
+
+// mcpMaxListPages bounds how many pages a paginated */list call follows,
+// so a server handing out cursors forever cannot loop the client.
+const mcpMaxListPages = 100
+
+// Server capabilities a Session can report through Supports.
+const (
+	CapabilityTools     = "tools"
+	CapabilityResources = "resources"
+	CapabilityPrompts   = "prompts"
+)
+
+var errMCPSessionClosed = errors.New("MCP session closed")
+
+// mcpConn is one transport's half of a session.
+type mcpConn interface {
+	// call sends a request and waits for the response with its id.
+	call(ctx context.Context, req jsonRPCRequest) (*jsonRPCResponse, error)
+	// send sends a message that gets no response: a notification or a
+	// reply to a server request.
+	send(ctx context.Context, msg any) error
+	// done is closed once the connection is gone; cause then says why.
+	done() <-chan struct{}
+	cause() error
+	close() error
+}
+
+// ConnectOptions describes the MCP server a Session connects to. URL,
+// Headers and HTTPClient apply to the HTTP transports, Stdio to the
+// stdio transport. Variables fill ${name} placeholders in the headers
+// and in the stdio server's args and env. Timeout bounds the handshake
+// and every request made on the session.
+type ConnectOptions struct {
+	URL        string
+	ServerType string
+	Headers    map[string]string
+	Variables  map[string]string
+	Stdio      *StdioServer
+	Timeout    time.Duration
+	HTTPClient *http.Client
+}
+
+// Session is an initialized MCP connection. It is safe for concurrent
+// use.
+type Session struct {
+	conn         mcpConn
+	timeout      time.Duration
+	nextID       atomic.Int64
+	capabilities map[string]interface{}
+	instructions string
+
+	// ctx is cancelled by Close; background tool-list refreshes run
+	// under it.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	inflight atomic.Int64
+	lastUsed atomic.Int64 // unix nanos
+
+	mu             sync.Mutex
+	tools          []Tool
+	toolsCached    bool
+	toolsGen       int
+	onToolsChanged func([]Tool)
+}
+
+// Connect opens a transport to the server described by opts and runs the
+// initialize handshake. For the HTTP transports the URL goes through
+// AssertURLSafe and, unless opts.HTTPClient is set, a DNS-pinned client.
+// The stdio server is started by the registered StdioLauncher.
+func Connect(ctx context.Context, opts ConnectOptions) (*Session, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultMCPTimeout
+	}
+	s := &Session{timeout: opts.Timeout}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.touch()
+
+	connectCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	serverType := strings.ToLower(opts.ServerType)
+	switch serverType {
+	case TransportStdio:
+		if opts.Stdio == nil || strings.TrimSpace(opts.Stdio.Command) == "" {
+			s.cancel()
+			return nil, errors.New("MCP stdio server requires a command")
+		}
+		srv := opts.Stdio.render(opts.Variables)
+		conn, err := dialStdio(connectCtx, srv, s.handleServerMessage)
+		if err != nil {
+			s.cancel()
+			return nil, err
+		}
+		s.conn = conn
+	case TransportStreamableHTTP, TransportSSE:
+		if opts.URL == "" {
+			s.cancel()
+			return nil, errors.New("Invalid url.")
+		}
+		hostname, resolvedIP, err := AssertURLSafe(opts.URL)
+		if err != nil {
+			s.cancel()
+			return nil, err
+		}
+		client := opts.HTTPClient
+		if client == nil {
+			client = PinnedHTTPClient(hostname, resolvedIP, opts.Timeout)
+		}
+		headers, err := renderHeaders(opts.Headers, opts.Variables)
+		if err != nil {
+			s.cancel()
+			return nil, err
+		}
+		if serverType == TransportSSE {
+			conn, err := dialSSE(connectCtx, opts.URL, headers, client, s.handleServerMessage)
+			if err != nil {
+				s.cancel()
+				return nil, err
+			}
+			s.conn = conn
+		} else {
+			s.conn = newStreamableConn(opts.URL, headers, client, s.handleServerMessage)
+		}
+	default:
+		s.cancel()
+		return nil, fmt.Errorf("Unsupported MCP server type.")
+	}
+
+	if err := s.initialize(connectCtx); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// initialize runs the handshake and records the server's capabilities.
+func (s *Session) initialize(ctx context.Context) error {
+	raw, err := s.call(ctx, "initialize", initializeParams())
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+		Instructions string                 `json:"instructions"`
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return fmt.Errorf("parse initialize result: %w", err)
+		}
+	}
+	s.capabilities = result.Capabilities
+	s.instructions = result.Instructions
+	return s.conn.send(ctx, jsonRPCRequest{
+		JSONRPC: jsonRPCVersion,
+		Method:  "notifications/initialized",
+	})
+}
+
+// Close ends the session: the stdio server is stopped, the SSE stream
+// closed and the streamable-HTTP session deleted. It is idempotent.
+func (s *Session) Close() error {
+	s.cancel()
+	return s.conn.close()
+}
+
+// Closed reports whether the session is gone, either through Close or
+// because the server went away.
+func (s *Session) Closed() bool {
+	select {
+	case <-s.conn.done():
+		return true
+	default:
+		return false
+	}
+}
+
+// Supports reports whether the server advertised capability (one of the
+// Capability* constants) in its initialize result.
+func (s *Session) Supports(capability string) bool {
+	_, ok := s.capabilities[capability]
+	return ok
+}
+
+// Instructions returns the usage hints the server sent on initialize.
+func (s *Session) Instructions() string { return s.instructions }
+
+// OnToolsChanged installs fn to be called with the re-fetched tool list
+// after the server announces a tool-list change. It replaces any earlier
+// callback; fn runs on its own goroutine.
+func (s *Session) OnToolsChanged(fn func([]Tool)) {
+	s.mu.Lock()
+	s.onToolsChanged = fn
+	s.mu.Unlock()
+}
+
+func (s *Session) touch() { s.lastUsed.Store(time.Now().UnixNano()) }
+
+// idleSince returns when the session was last used, or the zero time
+// while a request is in flight.
+func (s *Session) idleSince() time.Time {
+	if s.inflight.Load() > 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, s.lastUsed.Load())
+}
+
+// call sends one request and returns its result, turning a JSON-RPC
+// error into a Go error.
+func (s *Session) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	s.inflight.Add(1)
+	s.touch()
+	defer func() {
+		s.touch()
+		s.inflight.Add(-1)
+	}()
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	res, err := s.conn.call(ctx, jsonRPCRequest{
+		JSONRPC: jsonRPCVersion,
+		ID:      s.nextID.Add(1) - 1,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, formatMCPError(method, res.Error)
+	}
+	return res.Result, nil
+}
+
+// list follows a paginated */list method, handing each page's result to
+// page.
+func (s *Session) list(ctx context.Context, method string, page func(json.RawMessage) error) error {
+	cursor := ""
+	for i := 0; i < mcpMaxListPages; i++ {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]interface{}{"cursor": cursor}
+		}
+		raw, err := s.call(ctx, method, params)
+		if err != nil {
+			return err
+		}
+		if err := page(raw); err != nil {
+			return err
+		}
+		var next struct {
+			NextCursor string `json:"nextCursor"`
+		}
+		if len(raw) > 0 {
+			_ = json.Unmarshal(raw, &next)
+		}
+		if next.NextCursor == "" || next.NextCursor == cursor {
+			return nil
+		}
+		cursor = next.NextCursor
+	}
+	return fmt.Errorf("MCP %s returned more than %d pages", method, mcpMaxListPages)
+}
+
+// handleServerMessage receives the notifications and requests the server
+// sends. It runs on the transport's reader, so anything that talks back
+// to the server is moved to its own goroutine.
+func (s *Session) handleServerMessage(msg *jsonRPCResponse) {
+	if msg.ID != nil {
+		go s.answerServerRequest(msg)
+		return
+	}
+	if msg.Method == "notifications/tools/list_changed" {
+		s.toolsChanged()
+	}
+}
+
+// answerServerRequest replies to a server-initiated request. Only ping is
+// supported; the client declares no capabilities, so anything else is
+// answered with "method not found".
+func (s *Session) answerServerRequest(req *jsonRPCResponse) {
+	reply := jsonRPCResponse{JSONRPC: jsonRPCVersion, ID: req.ID}
+	if req.Method == "ping" {
+		reply.Result = json.RawMessage("{}")
+	} else {
+		reply.Error = &jsonRPCError{Code: -32601, Message: "Method not found"}
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, s.timeout)
+	defer cancel()
+	_ = s.conn.send(ctx, reply)
+}
+
+// toolsChanged drops the cached tool list and fetches the new one in the
+// background, then reports it to the OnToolsChanged callback.
+func (s *Session) toolsChanged() {
+	s.mu.Lock()
+	s.toolsGen++
+	s.tools = nil
+	s.toolsCached = false
+	s.mu.Unlock()
+	go func() {
+		tools, err := s.ListTools(s.ctx)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		fn := s.onToolsChanged
+		s.mu.Unlock()
+		if fn != nil {
+			fn(tools)
+		}
+	}()
+}
+
+// ListTools returns the server's tools. The list is cached for the life
+// of the session and re-fetched after a tools/list_changed notification.
+func (s *Session) ListTools(ctx context.Context) ([]Tool, error) {
+	s.mu.Lock()
+	if s.toolsCached {
+		tools := append([]Tool(nil), s.tools...)
+		s.mu.Unlock()
+		return tools, nil
+	}
+	gen := s.toolsGen
+	s.mu.Unlock()
+
+	tools := []Tool{}
+	err := s.list(ctx, "tools/list", func(raw json.RawMessage) error {
+		page, err := parseToolsResult(raw)
+		tools = append(tools, page...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	// A change announced while this list was in flight makes it stale.
+	if gen == s.toolsGen {
+		s.tools = tools
+		s.toolsCached = true
+	}
+	s.mu.Unlock()
+	return append([]Tool(nil), tools...), nil
+}
+
+// CallTool invokes the named tool. args is the JSON-encoded argument
+// object; empty means no arguments.
+func (s *Session) CallTool(ctx context.Context, name string, args json.RawMessage) (*CallResult, error) {
+	if name == "" {
+		return nil, errors.New("MCP tool name is required")
+	}
+	var argsAny any
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &argsAny); err != nil {
+			return nil, fmt.Errorf("mcp tools/call: arguments are not valid JSON: %w", err)
+		}
+	}
+	raw, err := s.call(ctx, "tools/call", map[string]any{
+		"name":      name,
+		"arguments": argsAny,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseCallResult(raw)
+}
+
+// Resource is an entry of resources/list.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate is an entry of resources/templates/list: a URI
+// template (RFC 6570) the server can read resources for.
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is one item of a resources/read result. Exactly one of
+// Text and Blob (base64) is set.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// ListResources returns the resources the server exposes.
+func (s *Session) ListResources(ctx context.Context) ([]Resource, error) {
+	out := []Resource{}
+	err := s.list(ctx, "resources/list", func(raw json.RawMessage) error {
+		var page struct {
+			Resources []Resource `json:"resources"`
+		}
+		if err := unmarshalResult(raw, &page); err != nil {
+			return fmt.Errorf("parse resources/list result: %w", err)
+		}
+		out = append(out, page.Resources...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListResourceTemplates returns the server's resource templates.
+func (s *Session) ListResourceTemplates(ctx context.Context) ([]ResourceTemplate, error) {
+	out := []ResourceTemplate{}
+	err := s.list(ctx, "resources/templates/list", func(raw json.RawMessage) error {
+		var page struct {
+			ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+		}
+		if err := unmarshalResult(raw, &page); err != nil {
+			return fmt.Errorf("parse resources/templates/list result: %w", err)
+		}
+		out = append(out, page.ResourceTemplates...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReadResource reads the resource at uri.
+func (s *Session) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	if uri == "" {
+		return nil, errors.New("MCP resource uri is required")
+	}
+	raw, err := s.call(ctx, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Contents []ResourceContents `json:"contents"`
+	}
+	if err := unmarshalResult(raw, &result); err != nil {
+		return nil, fmt.Errorf("parse resources/read result: %w", err)
+	}
+	return result.Contents, nil
+}
+
+// Prompt is an entry of prompts/list.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is one argument a prompt template accepts.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is one message of a rendered prompt. Content is the raw
+// content block ({type: "text", text} or an image / audio / resource
+// block); Text holds the text of a text block.
+type PromptMessage struct {
+	Role    string         `json:"role"`
+	Text    string         `json:"-"`
+	Content map[string]any `json:"content"`
+}
+
+// PromptResult is the prompts/get result: the template rendered with the
+// caller's arguments.
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// ListPrompts returns the prompt templates the server exposes.
+func (s *Session) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	out := []Prompt{}
+	err := s.list(ctx, "prompts/list", func(raw json.RawMessage) error {
+		var page struct {
+			Prompts []Prompt `json:"prompts"`
+		}
+		if err := unmarshalResult(raw, &page); err != nil {
+			return fmt.Errorf("parse prompts/list result: %w", err)
+		}
+		out = append(out, page.Prompts...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetPrompt renders the named prompt template with args.
+func (s *Session) GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error) {
+	if name == "" {
+		return nil, errors.New("MCP prompt name is required")
+	}
+	params := map[string]any{"name": name}
+	if len(args) > 0 {
+		params["arguments"] = args
+	}
+	raw, err := s.call(ctx, "prompts/get", params)
+	if err != nil {
+		return nil, err
+	}
+	result := &PromptResult{}
+	if err := unmarshalResult(raw, result); err != nil {
+		return nil, fmt.Errorf("parse prompts/get result: %w", err)
+	}
+	for i := range result.Messages {
+		m := &result.Messages[i]
+		if t, _ := m.Content["type"].(string); t == "text" {
+			m.Text, _ = m.Content["text"].(string)
+		}
+	}
+	return result, nil
+}
+
+// unmarshalResult decodes a JSON-RPC result, treating an empty one as
+// the zero value.
+func unmarshalResult(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,463 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package utility
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeStdioServer is an in-memory stdio MCP server. It offers tools
+// (paginated one per page), a resource, a resource template and a
+// prompt. Calling the "rename" tool swaps the tool list and announces
+// the change; calling "ping-me" makes the server ping the client.
+type fakeStdioServer struct {
+	inR  *io.PipeReader
+	inW  *io.PipeWriter
+	outR *io.PipeReader
+	outW *io.PipeWriter
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	tools   []string
+	pongs   chan json.RawMessage
+	closed  atomic.Bool
+}
+
+func newFakeStdioServer(tools ...string) *fakeStdioServer {
+	f := &fakeStdioServer{tools: tools, pongs: make(chan json.RawMessage, 1)}
+	f.inR, f.inW = io.Pipe()
+	f.outR, f.outW = io.Pipe()
+	go f.serve()
+	return f
+}
+
+func (f *fakeStdioServer) Stdin() io.Writer  { return f.inW }
+func (f *fakeStdioServer) Stdout() io.Reader { return f.outR }
+func (f *fakeStdioServer) Stderr() string    { return "fake server log" }
+
+func (f *fakeStdioServer) Close() error {
+	f.closed.Store(true)
+	f.inW.Close()
+	return f.outW.Close()
+}
+
+// crash ends the server's stdout as if the process died.
+func (f *fakeStdioServer) crash() { f.outW.Close() }
+
+func (f *fakeStdioServer) write(msg any) {
+	body, _ := json.Marshal(msg)
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+	_, _ = f.outW.Write(append(body, '\n'))
+}
+
+func (f *fakeStdioServer) reply(id json.RawMessage, result any) {
+	f.write(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func (f *fakeStdioServer) serve() {
+	sc := bufio.NewScanner(f.inR)
+	for sc.Scan() {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Name      string            `json:"name"`
+				Cursor    string            `json:"cursor"`
+				URI       string            `json:"uri"`
+				Arguments map[string]string `json:"arguments"`
+			} `json:"params"`
+			Result json.RawMessage `json:"result"`
+		}
+		if json.Unmarshal(sc.Bytes(), &msg) != nil {
+			continue
+		}
+		if msg.Method == "" {
+			f.pongs <- msg.Result
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+		switch msg.Method {
+		case "initialize":
+			f.reply(msg.ID, map[string]any{
+				"protocolVersion": protocolVersion,
+				"capabilities": map[string]any{
+					"tools":     map[string]any{"listChanged": true},
+					"resources": map[string]any{},
+					"prompts":   map[string]any{},
+				},
+				"instructions": "Use the tools sparingly.",
+			})
+		case "tools/list":
+			f.mu.Lock()
+			names := append([]string(nil), f.tools...)
+			f.mu.Unlock()
+			result := map[string]any{}
+			if msg.Params.Cursor == "" && len(names) > 1 {
+				result["tools"] = []map[string]any{{"name": names[0]}}
+				result["nextCursor"] = "rest"
+			} else {
+				if msg.Params.Cursor == "rest" {
+					names = names[1:]
+				}
+				tools := []map[string]any{}
+				for _, n := range names {
+					tools = append(tools, map[string]any{"name": n})
+				}
+				result["tools"] = tools
+			}
+			f.reply(msg.ID, result)
+		case "tools/call":
+			switch msg.Params.Name {
+			case "rename":
+				f.mu.Lock()
+				f.tools = []string{"renamed"}
+				f.mu.Unlock()
+				f.write(map[string]any{"jsonrpc": "2.0", "method": "notifications/tools/list_changed"})
+			case "ping-me":
+				f.write(map[string]any{"jsonrpc": "2.0", "id": "srv-1", "method": "ping"})
+			}
+			f.reply(msg.ID, map[string]any{
+				"content": []map[string]any{{"type": "text", "text": "called " + msg.Params.Name}},
+			})
+		case "resources/list":
+			f.reply(msg.ID, map[string]any{"resources": []map[string]any{
+				{"uri": "file:///readme.md", "name": "readme", "mimeType": "text/markdown"},
+			}})
+		case "resources/templates/list":
+			f.reply(msg.ID, map[string]any{"resourceTemplates": []map[string]any{
+				{"uriTemplate": "file:///{path}", "name": "file"},
+			}})
+		case "resources/read":
+			f.reply(msg.ID, map[string]any{"contents": []map[string]any{
+				{"uri": msg.Params.URI, "mimeType": "text/plain", "text": "contents of " + msg.Params.URI},
+			}})
+		case "prompts/list":
+			f.reply(msg.ID, map[string]any{"prompts": []map[string]any{
+				{"name": "review", "description": "Review code", "arguments": []map[string]any{
+					{"name": "code", "required": true},
+				}},
+			}})
+		case "prompts/get":
+			f.reply(msg.ID, map[string]any{
+				"description": "Code review",
+				"messages": []map[string]any{
+					{"role": "user", "content": map[string]any{"type": "text", "text": "Review: " + msg.Params.Arguments["code"]}},
+				},
+			})
+		default:
+			f.write(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "error": map[string]any{"code": -32601, "message": "Method not found"}})
+		}
+	}
+}
+
+// useFakeStdioLauncher installs a launcher that starts a new fake server
+// per launch, and returns the launched servers and configs.
+func useFakeStdioLauncher(t *testing.T, tools ...string) (*[]*fakeStdioServer, *[]StdioServer) {
+	t.Helper()
+	var mu sync.Mutex
+	servers := &[]*fakeStdioServer{}
+	configs := &[]StdioServer{}
+	SetStdioLauncher(func(_ context.Context, srv StdioServer) (StdioProcess, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		f := newFakeStdioServer(tools...)
+		*servers = append(*servers, f)
+		*configs = append(*configs, srv)
+		return f, nil
+	})
+	t.Cleanup(func() { SetStdioLauncher(nil) })
+	return servers, configs
+}
+
+func connectFakeStdio(t *testing.T) *Session {
+	t.Helper()
+	s, err := Connect(context.Background(), ConnectOptions{
+		ServerType: TransportStdio,
+		Stdio:      &StdioServer{Command: "mcp-files", Args: []string{"--root", "${root}"}, Env: map[string]string{"TOKEN": "${token}"}},
+		Variables:  map[string]string{"root": "/data", "token": "t0k"},
+		Timeout:    5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// TestSessionStdioToolsResourcesPrompts: a stdio session runs the
+// handshake, follows tools/list pagination and exposes resources and
+// prompts.
+func TestSessionStdioToolsResourcesPrompts(t *testing.T) {
+	_, configs := useFakeStdioLauncher(t, "search", "fetch")
+	s := connectFakeStdio(t)
+
+	launched := (*configs)[0]
+	if strings.Join(launched.Args, " ") != "--root /data" || launched.Env["TOKEN"] != "t0k" {
+		t.Errorf("variables not substituted into launch config: %+v", launched)
+	}
+	for _, c := range []string{CapabilityTools, CapabilityResources, CapabilityPrompts} {
+		if !s.Supports(c) {
+			t.Errorf("Supports(%q)=false", c)
+		}
+	}
+	if s.Instructions() != "Use the tools sparingly." {
+		t.Errorf("Instructions=%q", s.Instructions())
+	}
+
+	ctx := context.Background()
+	tools, err := s.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "search" || tools[1].Name != "fetch" {
+		t.Fatalf("tools=%+v, want search and fetch across two pages", tools)
+	}
+	res, err := s.CallTool(ctx, "search", json.RawMessage(`{"q":"x"}`))
+	if err != nil || res.Text != "called search" {
+		t.Fatalf("CallTool: res=%+v err=%v", res, err)
+	}
+
+	resources, err := s.ListResources(ctx)
+	if err != nil || len(resources) != 1 || resources[0].URI != "file:///readme.md" || resources[0].MIMEType != "text/markdown" {
+		t.Fatalf("ListResources: %+v, %v", resources, err)
+	}
+	templates, err := s.ListResourceTemplates(ctx)
+	if err != nil || len(templates) != 1 || templates[0].URITemplate != "file:///{path}" {
+		t.Fatalf("ListResourceTemplates: %+v, %v", templates, err)
+	}
+	contents, err := s.ReadResource(ctx, "file:///notes.txt")
+	if err != nil || len(contents) != 1 || contents[0].Text != "contents of file:///notes.txt" {
+		t.Fatalf("ReadResource: %+v, %v", contents, err)
+	}
+
+	prompts, err := s.ListPrompts(ctx)
+	if err != nil || len(prompts) != 1 || prompts[0].Name != "review" || !prompts[0].Arguments[0].Required {
+		t.Fatalf("ListPrompts: %+v, %v", prompts, err)
+	}
+	prompt, err := s.GetPrompt(ctx, "review", map[string]string{"code": "x := 1"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	if len(prompt.Messages) != 1 || prompt.Messages[0].Role != "user" || prompt.Messages[0].Text != "Review: x := 1" {
+		t.Fatalf("GetPrompt messages=%+v", prompt.Messages)
+	}
+}
+
+// TestSessionToolsListChangedRefreshesCache: a tools/list_changed
+// notification drops the cached list, and the refreshed list is
+// reported to the OnToolsChanged callback.
+func TestSessionToolsListChangedRefreshesCache(t *testing.T) {
+	useFakeStdioLauncher(t, "search")
+	s := connectFakeStdio(t)
+	ctx := context.Background()
+
+	if tools, err := s.ListTools(ctx); err != nil || len(tools) != 1 || tools[0].Name != "search" {
+		t.Fatalf("ListTools before change: %+v, %v", tools, err)
+	}
+	changed := make(chan []Tool, 1)
+	s.OnToolsChanged(func(tools []Tool) { changed <- tools })
+
+	if _, err := s.CallTool(ctx, "rename", nil); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	select {
+	case tools := <-changed:
+		if len(tools) != 1 || tools[0].Name != "renamed" {
+			t.Fatalf("OnToolsChanged got %+v, want [renamed]", tools)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnToolsChanged was not called")
+	}
+	if tools, err := s.ListTools(ctx); err != nil || len(tools) != 1 || tools[0].Name != "renamed" {
+		t.Fatalf("ListTools after change: %+v, %v", tools, err)
+	}
+}
+
+// TestSessionAnswersServerPing: a ping from the server gets an empty
+// result back.
+func TestSessionAnswersServerPing(t *testing.T) {
+	servers, _ := useFakeStdioLauncher(t, "search")
+	s := connectFakeStdio(t)
+
+	if _, err := s.CallTool(context.Background(), "ping-me", nil); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	select {
+	case result := <-(*servers)[0].pongs:
+		if string(result) != "{}" {
+			t.Errorf("ping result=%s, want {}", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not answer the server's ping")
+	}
+}
+
+// TestSessionStdioServerExit: when the server dies the session is
+// closed and calls fail with the server's stderr.
+func TestSessionStdioServerExit(t *testing.T) {
+	servers, _ := useFakeStdioLauncher(t, "search")
+	s := connectFakeStdio(t)
+
+	(*servers)[0].crash()
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.Closed() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !s.Closed() {
+		t.Fatal("session should be closed after the server exited")
+	}
+	_, err := s.CallTool(context.Background(), "search", nil)
+	if err == nil || !strings.Contains(err.Error(), "exited") || !strings.Contains(err.Error(), "fake server log") {
+		t.Fatalf("err=%v, want exit error with stderr tail", err)
+	}
+}
+
+func TestConnectStdioRequiresCommand(t *testing.T) {
+	_, err := Connect(context.Background(), ConnectOptions{ServerType: TransportStdio, Stdio: &StdioServer{}})
+	if err == nil || !strings.Contains(err.Error(), "requires a command") {
+		t.Fatalf("err=%v, want missing command", err)
+	}
+}
+
+// TestSessionPoolReusesAndReconnects: the pool hands out one session per
+// configuration, replaces it once the server died, and stops servers on
+// Close.
+func TestSessionPoolReusesAndReconnects(t *testing.T) {
+	servers, _ := useFakeStdioLauncher(t, "search")
+	pool := NewSessionPool(time.Minute)
+	opts := ConnectOptions{ServerType: TransportStdio, Stdio: &StdioServer{Command: "mcp-files"}, Timeout: 5 * time.Second}
+	ctx := context.Background()
+
+	first, err := pool.Get(ctx, opts)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	again, err := pool.Get(ctx, opts)
+	if err != nil || again != first || len(*servers) != 1 {
+		t.Fatalf("second Get should reuse the session (launches=%d, err=%v)", len(*servers), err)
+	}
+
+	(*servers)[0].crash()
+	for deadline := time.Now().Add(5 * time.Second); !first.Closed() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	next, err := pool.Get(ctx, opts)
+	if err != nil || next == first || len(*servers) != 2 {
+		t.Fatalf("Get after exit should relaunch (launches=%d, err=%v)", len(*servers), err)
+	}
+
+	pool.Close()
+	if !next.Closed() || !(*servers)[1].closed.Load() {
+		t.Fatal("Close should stop pooled servers")
+	}
+}
+
+// TestSessionPoolReapsIdle: an unused session is closed after the idle
+// time.
+func TestSessionPoolReapsIdle(t *testing.T) {
+	servers, _ := useFakeStdioLauncher(t, "search")
+	pool := NewSessionPool(50 * time.Millisecond)
+	defer pool.Close()
+
+	s, err := pool.Get(context.Background(), ConnectOptions{ServerType: TransportStdio, Stdio: &StdioServer{Command: "mcp-files"}})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); !s.Closed() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !s.Closed() || !(*servers)[0].closed.Load() {
+		t.Fatal("idle session should have been reaped")
+	}
+}
+
+// TestFetchToolsStdioCommand runs a real process through the default
+// launcher: a shell script that answers initialize and tools/list, and
+// names its tool after an env var to show Env reaches the server.
+func TestFetchToolsStdioCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs /bin/sh")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("needs /bin/sh")
+	}
+	SetStdioLauncher(nil)
+	script := `read line
+printf '{"jsonrpc":"2.0","id":0,"result":{"capabilities":{"tools":{}}}}\n'
+read line
+read line
+printf '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"%s"}]}}\n' "$TOOL_NAME"
+read line`
+	tools, err := FetchTools(context.Background(), FetchOptions{
+		ServerType: TransportStdio,
+		Stdio:      &StdioServer{Command: "/bin/sh", Args: []string{"-c", script}, Env: map[string]string{"TOOL_NAME": "${tool}"}},
+		Variables:  map[string]string{"tool": "grep"},
+		Timeout:    5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("FetchTools: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "grep" {
+		t.Fatalf("tools=%+v, want [grep]", tools)
+	}
+}
+
+func TestStdioServerFromConfig(t *testing.T) {
+	srv, err := StdioServerFromConfig(map[string]interface{}{
+		"command": " npx ",
+		"args":    []interface{}{"-y", "@modelcontextprotocol/server-filesystem"},
+		"env":     map[string]interface{}{"B": "2", "A": "1"},
+		"cwd":     "data/srv",
+	})
+	if err != nil {
+		t.Fatalf("StdioServerFromConfig: %v", err)
+	}
+	if srv.Command != "npx" || len(srv.Args) != 2 || srv.Dir != "data/srv" {
+		t.Errorf("srv=%+v", srv)
+	}
+	if got := strings.Join(srv.Environ(), ","); got != "A=1,B=2" {
+		t.Errorf("Environ=%q, want sorted pairs", got)
+	}
+
+	for name, cfg := range map[string]map[string]interface{}{
+		"no command":     {"args": []interface{}{"x"}},
+		"args not list":  {"command": "x", "args": "y"},
+		"arg not string": {"command": "x", "args": []interface{}{1.0}},
+		"env not object": {"command": "x", "env": "A=1"},
+		"env not string": {"command": "x", "env": map[string]interface{}{"A": 1.0}},
+		"cwd not string": {"command": "x", "cwd": 1.0},
+		"cwd absolute":   {"command": "x", "cwd": "/etc"},
+		"cwd escapes":    {"command": "x", "cwd": "../.."},
+	} {
+		if _, err := StdioServerFromConfig(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// mcp_stdio.go — the stdio transport: the MCP server is a local process
+// speaking newline-delimited JSON-RPC on stdin / stdout, with stderr free
+// for logging.
+//
+// The process is started by the registered StdioLauncher. The default
+// launcher runs the command directly in a temporary directory with a
+// scrubbed environment; the
+// server installs the sandbox's launcher at boot
+// (agent/sandbox.NewMCPStdioLauncher), which applies the local sandbox
+// provider's isolation and limits to the server process.
+
+package utility
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stdioMaxMessageBytes caps one JSON-RPC message read from a stdio
+// server, matching the HTTP transports' response cap.
+const stdioMaxMessageBytes = 8 << 20
+
+// stdioStderrTailBytes is how much of a stdio server's stderr is kept for
+// error messages.
+const stdioStderrTailBytes = 4 << 10
+
+// stdioStopGrace is how long a stdio server gets to exit after its stdin
+// is closed before it is killed.
+const stdioStopGrace = 2 * time.Second
+
+// StdioServer is the launch configuration of a stdio MCP server.
+type StdioServer struct {
+	Command string
+	Args    []string
+	// Env is added to the server's environment. The launcher decides
+	// what else the server inherits.
+	Env map[string]string
+	// Dir is the working directory, relative to the directory the
+	// launcher gives the server; empty uses that directory.
+	Dir string
+}
+
+// StdioServerFromConfig reads a stdio server's launch configuration from
+// a stored MCP server's variables (or an import entry): "command", plus
+// the optional "args" (list of strings), "env" (string map) and "cwd"
+// (a relative path that stays inside the server's directory).
+func StdioServerFromConfig(cfg map[string]interface{}) (*StdioServer, error) {
+	command, _ := cfg["command"].(string)
+	if strings.TrimSpace(command) == "" {
+		return nil, errors.New("MCP stdio server requires a command")
+	}
+	srv := &StdioServer{Command: strings.TrimSpace(command)}
+	switch args := cfg["args"].(type) {
+	case nil:
+	case []interface{}:
+		for _, a := range args {
+			s, ok := a.(string)
+			if !ok {
+				return nil, errors.New("MCP stdio server args must be strings")
+			}
+			srv.Args = append(srv.Args, s)
+		}
+	case []string:
+		srv.Args = append(srv.Args, args...)
+	default:
+		return nil, errors.New("MCP stdio server args must be a list of strings")
+	}
+	switch env := cfg["env"].(type) {
+	case nil:
+	case map[string]interface{}:
+		srv.Env = make(map[string]string, len(env))
+		for k, v := range env {
+			s, ok := v.(string)
+			if !ok || k == "" || strings.ContainsAny(k, "=\x00") {
+				return nil, fmt.Errorf("MCP stdio server env %q must be a string", k)
+			}
+			srv.Env[k] = s
+		}
+	case map[string]string:
+		srv.Env = make(map[string]string, len(env))
+		for k, v := range env {
+			srv.Env[k] = v
+		}
+	default:
+		return nil, errors.New("MCP stdio server env must be an object of strings")
+	}
+	if cwd, ok := cfg["cwd"]; ok && cwd != nil {
+		dir, ok := cwd.(string)
+		if !ok {
+			return nil, errors.New("MCP stdio server cwd must be a string")
+		}
+		srv.Dir = strings.TrimSpace(dir)
+		if srv.Dir != "" && !filepath.IsLocal(srv.Dir) {
+			return nil, fmt.Errorf("MCP stdio server cwd %q must be a relative path inside the server's directory", srv.Dir)
+		}
+	}
+	return srv, nil
+}
+
+// Environ returns Env as KEY=value pairs in key order.
+func (srv StdioServer) Environ() []string {
+	keys := make([]string, 0, len(srv.Env))
+	for k := range srv.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, k+"="+srv.Env[k])
+	}
+	return out
+}
+
+// render applies ${name} substitution from vars to the args and env
+// values, the way renderHeaders does for the HTTP transports' headers.
+func (srv StdioServer) render(vars map[string]string) StdioServer {
+	out := StdioServer{Command: srv.Command, Dir: srv.Dir}
+	for _, a := range srv.Args {
+		out.Args = append(out.Args, substituteTemplate(a, vars))
+	}
+	if srv.Env != nil {
+		out.Env = make(map[string]string, len(srv.Env))
+		for k, v := range srv.Env {
+			out.Env[k] = substituteTemplate(v, vars)
+		}
+	}
+	return out
+}
+
+// StdioProcess is a running stdio MCP server as returned by a
+// StdioLauncher.
+type StdioProcess interface {
+	// Stdin receives the client's messages.
+	Stdin() io.Writer
+	// Stdout yields the server's messages; it reaches EOF when the
+	// server exits.
+	Stdout() io.Reader
+	// Stderr returns the tail of what the server wrote to stderr.
+	Stderr() string
+	// Close stops the server and releases its resources.
+	Close() error
+}
+
+// StdioLauncher starts a stdio MCP server. ctx bounds the start only:
+// the process runs until its StdioProcess is closed.
+type StdioLauncher func(ctx context.Context, srv StdioServer) (StdioProcess, error)
+
+var (
+	stdioLauncherMu sync.RWMutex
+	stdioLauncher   StdioLauncher = launchStdioCommand
+)
+
+// SetStdioLauncher installs the launcher stdio MCP servers are started
+// with. Passing nil restores the default, which runs the command directly
+// in a temporary directory with only PATH inherited from the host
+// environment.
+func SetStdioLauncher(l StdioLauncher) {
+	stdioLauncherMu.Lock()
+	defer stdioLauncherMu.Unlock()
+	if l == nil {
+		l = launchStdioCommand
+	}
+	stdioLauncher = l
+}
+
+func currentStdioLauncher() StdioLauncher {
+	stdioLauncherMu.RLock()
+	defer stdioLauncherMu.RUnlock()
+	return stdioLauncher
+}
+
+// launchStdioCommand is the default launcher. The server gets PATH and
+// its own Env only, so secrets in the host environment do not leak into
+// it, and runs in a temporary directory removed when it exits.
+func launchStdioCommand(_ context.Context, srv StdioServer) (StdioProcess, error) {
+	dir, err := os.MkdirTemp("", "mcp-")
+	if err != nil {
+		return nil, fmt.Errorf("MCP stdio server dir: %w", err)
+	}
+	removeDir := func() { _ = os.RemoveAll(dir) }
+	cmd := exec.Command(srv.Command, srv.Args...)
+	cmd.Dir = filepath.Join(dir, srv.Dir)
+	if err := os.MkdirAll(cmd.Dir, 0o700); err != nil {
+		removeDir()
+		return nil, fmt.Errorf("MCP stdio server dir: %w", err)
+	}
+	cmd.Env = srv.Environ()
+	if path := os.Getenv("PATH"); path != "" {
+		// Last wins, so the configured env cannot replace it.
+		cmd.Env = append(cmd.Env, "PATH="+path)
+	}
+	return StartStdioCommand(cmd, StdioCommandHooks{Cleanup: removeDir})
+}
+
+// StdioCommandHooks let a launcher customise how StartStdioCommand manages
+// the process.
+type StdioCommandHooks struct {
+	// Started runs right after the process started; an error stops it
+	// and fails the launch.
+	Started func() error
+	// Stop kills the process when it did not exit after its stdin was
+	// closed. Defaults to killing the process itself.
+	Stop func(p *os.Process)
+	// Cleanup runs once the process has been reaped.
+	Cleanup func()
+}
+
+// StartStdioCommand starts cmd with its stdin / stdout connected to the
+// returned StdioProcess. cmd.Stdin, Stdout and Stderr must be unset.
+func StartStdioCommand(cmd *exec.Cmd, hooks StdioCommandHooks) (StdioProcess, error) {
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("MCP stdio pipe: %w", err)
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		stdinR.Close()
+		stdinW.Close()
+		return nil, fmt.Errorf("MCP stdio pipe: %w", err)
+	}
+	p := &commandProcess{
+		cmd:    cmd,
+		stdin:  stdinW,
+		stdout: stdoutR,
+		stderr: &tailBuffer{max: stdioStderrTailBytes},
+		hooks:  hooks,
+		exited: make(chan struct{}),
+	}
+	cmd.Stdin = stdinR
+	cmd.Stdout = stdoutW
+	cmd.Stderr = p.stderr
+	err = cmd.Start()
+	// The child holds its own copies of these ends.
+	stdinR.Close()
+	stdoutW.Close()
+	if err != nil {
+		stdinW.Close()
+		stdoutR.Close()
+		if hooks.Cleanup != nil {
+			hooks.Cleanup()
+		}
+		return nil, fmt.Errorf("start MCP stdio server %q: %w", cmd.Path, err)
+	}
+	go func() {
+		_ = cmd.Wait()
+		close(p.exited)
+	}()
+	if hooks.Started != nil {
+		if err := hooks.Started(); err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// commandProcess is the StdioProcess of StartStdioCommand.
+type commandProcess struct {
+	cmd       *exec.Cmd
+	stdin     *os.File
+	stdout    *os.File
+	stderr    *tailBuffer
+	hooks     StdioCommandHooks
+	exited    chan struct{}
+	closeOnce sync.Once
+}
+
+func (p *commandProcess) Stdin() io.Writer  { return p.stdin }
+func (p *commandProcess) Stdout() io.Reader { return p.stdout }
+func (p *commandProcess) Stderr() string    { return p.stderr.String() }
+
+// Close closes the server's stdin, which tells a well-behaved server to
+// exit, and kills it after stdioStopGrace.
+func (p *commandProcess) Close() error {
+	p.closeOnce.Do(func() {
+		_ = p.stdin.Close()
+		select {
+		case <-p.exited:
+		case <-time.After(stdioStopGrace):
+			if p.hooks.Stop != nil {
+				p.hooks.Stop(p.cmd.Process)
+			} else {
+				_ = p.cmd.Process.Kill()
+			}
+			<-p.exited
+		}
+		_ = p.stdout.Close()
+		if p.hooks.Cleanup != nil {
+			p.hooks.Cleanup()
+		}
+	})
+	return nil
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
+
+// stdioConn is a session's stdio transport.
+type stdioConn struct {
+	proc      StdioProcess
+	pending   *pendingResponses
+	onMessage func(*jsonRPCResponse)
+	writeMu   sync.Mutex
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// dialStdio launches the server and starts reading its stdout.
+func dialStdio(ctx context.Context, srv StdioServer, onMessage func(*jsonRPCResponse)) (*stdioConn, error) {
+	proc, err := currentStdioLauncher()(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+	c := &stdioConn{
+		proc:      proc,
+		pending:   newPendingResponses(),
+		onMessage: onMessage,
+		closed:    make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// read dispatches the server's messages until its stdout closes. Lines
+// that are not JSON-RPC are skipped.
+func (c *stdioConn) read() {
+	sc := bufio.NewScanner(c.proc.Stdout())
+	sc.Buffer(make([]byte, 0, 64<<10), stdioMaxMessageBytes)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		msg, err := parseJSONRPC(line, nil)
+		if err != nil {
+			continue
+		}
+		if msg.Method != "" {
+			if c.onMessage != nil {
+				c.onMessage(msg)
+			}
+			continue
+		}
+		c.pending.deliver(msg)
+	}
+	err := errors.New("MCP stdio server exited")
+	if scanErr := sc.Err(); errors.Is(scanErr, bufio.ErrTooLong) {
+		err = fmt.Errorf("MCP stdio server sent a message over %d bytes", stdioMaxMessageBytes)
+	} else if scanErr != nil {
+		err = fmt.Errorf("read MCP stdio server: %w", scanErr)
+	}
+	if tail := c.proc.Stderr(); tail != "" {
+		err = fmt.Errorf("%w: %s", err, tail)
+	}
+	c.finish(err)
+}
+
+func (c *stdioConn) call(ctx context.Context, req jsonRPCRequest) (*jsonRPCResponse, error) {
+	w := c.pending.register(req.ID)
+	if err := c.send(ctx, req); err != nil {
+		c.pending.cancel(req.ID)
+		return nil, err
+	}
+	return c.pending.await(ctx, w, c.closed, c.cause)
+}
+
+func (c *stdioConn) send(_ context.Context, msg any) error {
+	select {
+	case <-c.closed:
+		return c.cause()
+	default:
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal MCP request: %w", err)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.proc.Stdin().Write(append(body, '\n')); err != nil {
+		return fmt.Errorf("write to MCP stdio server: %w", err)
+	}
+	return nil
+}
+
+func (c *stdioConn) done() <-chan struct{} { return c.closed }
+
+func (c *stdioConn) cause() error { return c.err }
+
+func (c *stdioConn) finish(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.closed)
+	})
+}
+
+func (c *stdioConn) close() error {
+	c.finish(errMCPSessionClosed)
+	return c.proc.Close()
+}
@@ -70,15 +70,21 @@ func (SMTPInsecureAuthError) Error() string {
 // — same subject, same plaintext body shape (see RESET_CODE_EMAIL_TMPL in
 // api/utils/email_templates.py).
 func SendResetCodeEmail(cfg common.SMTPConfig, toEmail, otp string, ttlMinutes int) error {
-	if cfg.MailServer == "" || cfg.MailPort == 0 {
-		return SMTPNotConfiguredError{}
-	}
-
 	subject := "Your Password Reset Code"
 	body := fmt.Sprintf(
 		"Hello,\nYour password reset code is: %s\nThis code will expire in %d minutes.\n",
 		otp, ttlMinutes,
 	)
+	return SendPlainEmail(cfg, toEmail, subject, body)
+}
+
+// SendPlainEmail delivers one plain-text email through the configured
+// SMTP server. The From header uses mail_from_address / mail_from_name,
+// falling back to the SMTP username and "RAGFlow".
+func SendPlainEmail(cfg common.SMTPConfig, toEmail, subject, body string) error {
+	if cfg.MailServer == "" || cfg.MailPort == 0 {
+		return SMTPNotConfiguredError{}
+	}
 
 	fromAddr := cfg.MailFromAddress
 	if fromAddr == "" {
@@ -0,0 +1,533 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sqlguard
+
+import "strings"
+
+// analyzer walks an accepted parse tree, records the tables and
+// functions it uses and enforces the deny list and allowlists.
+//
+// Without a schema, column checks are necessarily approximate: a bare
+// column is accepted when any table in scope may supply it under the
+// allowlist, and sources whose columns cannot be known (SELECT * in a
+// derived table, table functions) accept anything — their own inputs
+// were checked where they were defined.
+type analyzer struct {
+	d         *Dialect
+	tables    map[string]struct{}
+	functions map[string]struct{}
+
+	allowTables  []string            // nil when unrestricted
+	allowColumns map[string]struct{} // nil when unrestricted
+	columnList   []string
+}
+
+// source is one FROM entry visible to column references.
+type source struct {
+	name  string // alias, or the table name's last part
+	table string // full table name; "" for CTEs, derived tables and functions
+	short string // last part of table
+	cols  map[string]struct{}
+}
+
+type scope struct {
+	parent  *scope
+	ctes    map[string]*source
+	sources []*source
+	outputs map[string]struct{}
+}
+
+func (s *scope) cte(name string) *source {
+	for ; s != nil; s = s.parent {
+		if c, ok := s.ctes[name]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+func newAnalyzer(d *Dialect, p Policy) *analyzer {
+	a := &analyzer{
+		d:         d,
+		tables:    map[string]struct{}{},
+		functions: map[string]struct{}{},
+	}
+	for _, t := range p.AllowedTables {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			a.allowTables = append(a.allowTables, t)
+		}
+	}
+	for _, c := range p.AllowedColumns {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			if a.allowColumns == nil {
+				a.allowColumns = map[string]struct{}{}
+			}
+			a.allowColumns[c] = struct{}{}
+			a.columnList = append(a.columnList, c)
+		}
+	}
+	return a
+}
+
+func (a *analyzer) fail(v *Violation) { panic(bailout{v}) }
+
+func (a *analyzer) statement(stmt *Statement) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(bailout)
+			if !ok {
+				panic(r)
+			}
+			err = b.v
+		}
+	}()
+	switch stmt.Kind {
+	case StmtQuery, StmtExplain:
+		if stmt.Query != nil {
+			a.query(stmt.Query, nil)
+		}
+		if stmt.Table != nil {
+			a.realTable(stmt.Table)
+		}
+	case StmtDescribe:
+		a.realTable(stmt.Table)
+	case StmtShow, StmtPragma:
+		if a.allowTables != nil || a.allowColumns != nil {
+			a.fail(violationf(CodeTable, stmt.pos, "",
+				"SHOW and PRAGMA statements are not allowed when the query is restricted to specific tables or columns. Write a SELECT against the allowed tables instead."))
+		}
+	}
+	return nil
+}
+
+// query walks q and returns the scope its ORDER BY resolves in.
+func (a *analyzer) query(q *Query, parent *scope) *scope {
+	s := parent
+	if len(q.With) > 0 {
+		s = &scope{parent: parent, ctes: map[string]*source{}}
+		for _, c := range q.With {
+			name := strings.ToLower(c.Name)
+			src := &source{name: name}
+			if q.Recursive {
+				s.ctes[name] = src
+			}
+			a.query(c.Query, s)
+			src.cols = outputColumns(c.Columns, c.Query)
+			s.ctes[name] = src
+		}
+	}
+	inner := a.body(q.Body, s)
+	for _, o := range q.OrderBy {
+		a.orderExpr(o.Expr, inner)
+	}
+	if q.Limit != nil {
+		a.expr(q.Limit.Count, s)
+		a.expr(q.Limit.Offset, s)
+	}
+	return inner
+}
+
+func (a *analyzer) body(b QueryBody, parent *scope) *scope {
+	switch b := b.(type) {
+	case *Select:
+		return a.selectBlock(b, parent)
+	case *SetOp:
+		left := a.body(b.Left, parent)
+		a.body(b.Right, parent)
+		return left
+	case *ParenQuery:
+		return a.query(b.Query, parent)
+	case *Values:
+		for _, row := range b.Rows {
+			for _, e := range row {
+				a.expr(e, parent)
+			}
+		}
+	}
+	return &scope{parent: parent}
+}
+
+func (a *analyzer) selectBlock(sel *Select, parent *scope) *scope {
+	s := &scope{parent: parent}
+	var conds []Expr
+	for _, te := range sel.From {
+		a.from(te, s, &conds)
+	}
+	// Join conditions see every source in the FROM list.
+	for _, e := range conds {
+		a.expr(e, s)
+	}
+	if sel.Top != nil {
+		a.expr(sel.Top.Count, s)
+	}
+	for _, e := range sel.DistinctOn {
+		a.expr(e, s)
+	}
+	s.outputs = map[string]struct{}{}
+	for _, it := range sel.Items {
+		a.expr(it.Expr, s)
+		if it.Alias != "" {
+			s.outputs[strings.ToLower(it.Alias)] = struct{}{}
+		}
+	}
+	a.expr(sel.Where, s)
+	for _, e := range sel.GroupBy {
+		a.expr(e, s)
+	}
+	a.expr(sel.Having, s)
+	for _, w := range sel.Windows {
+		a.window(w, s)
+	}
+	a.expr(sel.Qualify, s)
+	return s
+}
+
+func (a *analyzer) from(te TableExpr, s *scope, conds *[]Expr) {
+	switch t := te.(type) {
+	case *TableName:
+		alias := strings.ToLower(t.Alias)
+		if len(t.Parts) == 1 {
+			name := strings.ToLower(t.Parts[0])
+			if c := s.cte(name); c != nil {
+				if alias == "" {
+					alias = name
+				}
+				s.sources = append(s.sources, &source{name: alias, cols: c.cols})
+				return
+			}
+		}
+		full := a.realTable(t)
+		short := strings.ToLower(t.Parts[len(t.Parts)-1])
+		if alias == "" {
+			alias = short
+		}
+		s.sources = append(s.sources, &source{name: alias, table: full, short: short})
+	case *DerivedTable:
+		// A derived table sees the enclosing query, not its siblings,
+		// unless it is LATERAL.
+		outer := s.parent
+		if t.Lateral {
+			outer = s
+		}
+		a.query(t.Query, outer)
+		s.sources = append(s.sources, &source{
+			name: strings.ToLower(t.Alias),
+			cols: outputColumns(t.Columns, t.Query),
+		})
+	case *TableFunc:
+		a.funcCall(t.Func, s)
+		name := strings.ToLower(t.Alias)
+		if name == "" {
+			name = strings.ToLower(t.Func.Name[len(t.Func.Name)-1])
+		}
+		s.sources = append(s.sources, &source{name: name, cols: nameSet(t.Columns)})
+	case *Join:
+		a.from(t.Left, s, conds)
+		a.from(t.Right, s, conds)
+		if t.On != nil {
+			*conds = append(*conds, t.On)
+		}
+		for _, u := range t.Using {
+			*conds = append(*conds, &ColumnRef{Parts: []string{u}})
+		}
+	}
+}
+
+// realTable records a base table and checks it against the allowlist.
+func (a *analyzer) realTable(t *TableName) string {
+	full := strings.ToLower(strings.Join(t.Parts, "."))
+	a.tables[full] = struct{}{}
+	if a.allowTables != nil && !a.tableAllowed(full) {
+		a.fail(violationf(CodeTable, t.pos, full,
+			"table %s is not allowed. Only these tables may be queried: %s. Rewrite the query to use only them, qualified exactly as listed.",
+			full, promptList(a.allowTables)))
+	}
+	if len(t.Columns) > 0 && a.allowColumns != nil {
+		a.fail(violationf(CodeColumn, t.pos, full,
+			"renaming the columns of table %s in its alias is not allowed when columns are restricted. Select the allowed columns by name instead.",
+			full))
+	}
+	return full
+}
+
+// tableAllowed matches full against the allowlist. An entry matches
+// the same name or any name it is a dotted suffix of, so "orders"
+// covers "sales.orders" but "sales.orders" does not cover "orders".
+func (a *analyzer) tableAllowed(full string) bool {
+	for _, t := range a.allowTables {
+		if full == t || strings.HasSuffix(full, "."+t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) columnAllowed(src *source, col string) bool {
+	for _, k := range []string{col, src.short + "." + col, src.table + "." + col, src.short + ".*", src.table + ".*"} {
+		if _, ok := a.allowColumns[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// orderExpr allows ORDER BY to name an output column by its alias.
+func (a *analyzer) orderExpr(e Expr, s *scope) {
+	if c, ok := e.(*ColumnRef); ok && len(c.Parts) == 1 && s != nil {
+		if _, out := s.outputs[strings.ToLower(c.Parts[0])]; out {
+			return
+		}
+	}
+	a.expr(e, s)
+}
+
+func (a *analyzer) expr(e Expr, s *scope) {
+	switch e := e.(type) {
+	case nil:
+	case *ColumnRef:
+		a.column(e, s)
+	case *Star:
+		a.star(e, s)
+	case *FuncCall:
+		a.funcCall(e, s)
+	case *Unary:
+		a.expr(e.X, s)
+	case *Binary:
+		a.expr(e.L, s)
+		a.expr(e.R, s)
+	case *Between:
+		a.expr(e.X, s)
+		a.expr(e.Lo, s)
+		a.expr(e.Hi, s)
+	case *In:
+		a.expr(e.X, s)
+		for _, x := range e.List {
+			a.expr(x, s)
+		}
+		if e.Query != nil {
+			a.query(e.Query, s)
+		}
+	case *Is:
+		a.expr(e.X, s)
+		a.expr(e.From, s)
+	case *Subquery:
+		a.query(e.Query, s)
+	case *Case:
+		a.expr(e.Operand, s)
+		for _, w := range e.Whens {
+			a.expr(w.Cond, s)
+			a.expr(w.Then, s)
+		}
+		a.expr(e.Else, s)
+	case *Cast:
+		a.expr(e.X, s)
+	case *Interval:
+		a.expr(e.X, s)
+	case *Tuple:
+		for _, x := range e.Items {
+			a.expr(x, s)
+		}
+	case *Subscript:
+		a.expr(e.X, s)
+		a.expr(e.Index, s)
+		a.expr(e.End, s)
+	}
+}
+
+func (a *analyzer) funcCall(f *FuncCall, s *scope) {
+	full := strings.ToLower(strings.Join(f.Name, "."))
+	a.functions[full] = struct{}{}
+	if a.d.isDeniedFunction(f.Name[len(f.Name)-1]) || a.d.isDeniedFunction(full) {
+		a.fail(violationf(CodeFunction, f.pos, full,
+			"function %s is not allowed: it can read files, reach other servers, run dynamic SQL or change server state. Rewrite the query without it.",
+			full))
+	}
+	for _, x := range f.Args {
+		a.expr(x, s)
+	}
+	for _, o := range f.OrderBy {
+		a.expr(o.Expr, s)
+	}
+	a.expr(f.Filter, s)
+	if f.Over != nil {
+		a.window(f.Over, s)
+	}
+}
+
+func (a *analyzer) window(w *WindowSpec, s *scope) {
+	for _, x := range w.PartitionBy {
+		a.expr(x, s)
+	}
+	for _, o := range w.OrderBy {
+		a.expr(o.Expr, s)
+	}
+}
+
+func (a *analyzer) column(c *ColumnRef, s *scope) {
+	if a.allowColumns == nil {
+		return
+	}
+	parts := make([]string, len(c.Parts))
+	for i, p := range c.Parts {
+		parts[i] = strings.ToLower(p)
+	}
+	if len(parts) > 1 {
+		if src := lookup(s, parts[:len(parts)-1]); src != nil {
+			if src.table != "" && !a.columnAllowed(src, parts[len(parts)-1]) {
+				a.fail(a.columnViolation(c.pos, src.short+"."+parts[len(parts)-1]))
+			}
+			return
+		}
+		// Not a visible table: a nested field name (Elasticsearch
+		// "author.name") or a composite field access. Check it whole.
+	}
+	name := strings.Join(parts, ".")
+	// A bare table alias is a whole-row reference in Postgres
+	// (row_to_json(t)), which exposes every column.
+	if len(parts) == 1 {
+		if src := lookup(s, parts); src != nil && src.table != "" {
+			a.starAllowed(src, c.pos)
+			return
+		}
+	}
+	for sc := s; sc != nil; sc = sc.parent {
+		if len(sc.sources) > 0 && a.resolves(name, sc) {
+			return
+		}
+	}
+	a.fail(a.columnViolation(c.pos, name))
+}
+
+// resolves reports whether some source in sc may supply col.
+func (a *analyzer) resolves(col string, sc *scope) bool {
+	for _, src := range sc.sources {
+		switch {
+		case src.table != "":
+			if a.columnAllowed(src, col) {
+				return true
+			}
+		case src.cols == nil:
+			return true
+		default:
+			if _, ok := src.cols[col]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *analyzer) star(st *Star, s *scope) {
+	if a.allowColumns == nil || s == nil {
+		return
+	}
+	if len(st.Qualifier) > 0 {
+		q := make([]string, len(st.Qualifier))
+		for i, p := range st.Qualifier {
+			q[i] = strings.ToLower(p)
+		}
+		if src := lookup(s, q); src != nil {
+			a.starAllowed(src, st.pos)
+		}
+		return
+	}
+	for _, src := range s.sources {
+		a.starAllowed(src, st.pos)
+	}
+}
+
+func (a *analyzer) starAllowed(src *source, pos int) {
+	if src.table == "" {
+		return
+	}
+	for _, k := range []string{src.short + ".*", src.table + ".*"} {
+		if _, ok := a.allowColumns[k]; ok {
+			return
+		}
+	}
+	a.fail(violationf(CodeColumn, pos, src.short+".*",
+		"selecting every column of %s is not allowed. Name the columns instead; allowed columns: %s.",
+		src.table, promptList(a.columnList)))
+}
+
+func (a *analyzer) columnViolation(pos int, col string) *Violation {
+	return violationf(CodeColumn, pos, col,
+		"column %s is not allowed. Use only these columns: %s.", col, promptList(a.columnList))
+}
+
+// lookup finds the source a qualifier names: its alias, its table
+// name, or its full qualified name.
+func lookup(s *scope, qual []string) *source {
+	q := strings.Join(qual, ".")
+	for ; s != nil; s = s.parent {
+		for _, src := range s.sources {
+			if src.name == q || (src.table != "" && (src.table == q || strings.HasSuffix(src.table, "."+q))) {
+				return src
+			}
+		}
+	}
+	return nil
+}
+
+// outputColumns is the column set of a CTE or derived table, or nil
+// when it cannot be known without a schema.
+func outputColumns(names []string, q *Query) map[string]struct{} {
+	if len(names) > 0 {
+		return nameSet(names)
+	}
+	sel := mainSelect(q)
+	if sel == nil {
+		return nil
+	}
+	cols := map[string]struct{}{}
+	for _, it := range sel.Items {
+		switch e := it.Expr.(type) {
+		case *Star:
+			return nil
+		case *ColumnRef:
+			if it.Alias == "" {
+				cols[strings.ToLower(e.Parts[len(e.Parts)-1])] = struct{}{}
+				continue
+			}
+		}
+		if it.Alias == "" {
+			return nil // engine-generated name
+		}
+		cols[strings.ToLower(it.Alias)] = struct{}{}
+	}
+	return cols
+}
+
+func nameSet(names []string) map[string]struct{} {
+	if len(names) == 0 {
+		return nil
+	}
+	m := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		m[strings.ToLower(n)] = struct{}{}
+	}
+	return m
+}
+
+// promptList joins names for an error message, truncating long lists.
+func promptList(names []string) string {
+	const max = 30
+	if len(names) > max {
+		return strings.Join(names[:max], ", ") + ", ..."
+	}
+	return strings.Join(names, ", ")
+}
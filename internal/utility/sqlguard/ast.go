@@ -0,0 +1,295 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sqlguard
+
+// StatementKind is the top-level form of a statement.
+type StatementKind int
+
+const (
+	StmtQuery StatementKind = iota
+	StmtExplain
+	StmtDescribe
+	StmtShow
+	StmtPragma
+)
+
+// Statement is a parsed top-level statement.
+type Statement struct {
+	Kind StatementKind
+	// Query is set for StmtQuery and for StmtExplain of a query.
+	Query *Query
+	// Table is set for StmtDescribe (and EXPLAIN <table> in MySQL).
+	Table *TableName
+	// Pragma holds the PRAGMA name for StmtPragma.
+	Pragma string
+
+	pos, end int // span of the statement's tokens, semicolon excluded
+}
+
+// Query is a full query expression: optional WITH, a body, and the
+// trailing ORDER BY / LIMIT that apply to the whole body.
+type Query struct {
+	With      []*CTE
+	Recursive bool
+	Body      QueryBody
+	OrderBy   []*OrderItem
+	Limit     *Limit
+
+	end int // offset after the last token of ORDER BY / LIMIT / body
+}
+
+// CTE is one WITH entry.
+type CTE struct {
+	Name    string
+	Columns []string
+	Query   *Query
+}
+
+// QueryBody is *Select, *SetOp, *ParenQuery or *Values.
+type QueryBody interface{ queryBody() }
+
+// SetOp is UNION / INTERSECT / EXCEPT.
+type SetOp struct {
+	Op          string
+	All         bool
+	Left, Right QueryBody
+}
+
+// ParenQuery is a parenthesised query used as a set operand.
+type ParenQuery struct{ Query *Query }
+
+// Values is a VALUES row list.
+type Values struct{ Rows [][]Expr }
+
+// Select is one SELECT block.
+type Select struct {
+	Distinct   bool
+	DistinctOn []Expr
+	Top        *Limit // SQL Server TOP
+	Items      []*SelectItem
+	From       []TableExpr
+	Where      Expr
+	GroupBy    []Expr
+	Having     Expr
+	Windows    []*WindowSpec
+	Qualify    Expr
+
+	keywordEnd int // offset after the SELECT keyword
+	topAt      int // offset after SELECT [ALL|DISTINCT], where TOP goes
+}
+
+func (*Select) queryBody()     {}
+func (*SetOp) queryBody()      {}
+func (*ParenQuery) queryBody() {}
+func (*Values) queryBody()     {}
+
+// SelectItem is one output expression.
+type SelectItem struct {
+	Expr  Expr
+	Alias string
+}
+
+// OrderItem is one ORDER BY entry.
+type OrderItem struct {
+	Expr Expr
+	Desc bool
+}
+
+// Limit is a row cap: LIMIT, FETCH FIRST, or TOP. Count is nil for
+// LIMIT ALL and for FETCH FIRST ROW ONLY.
+type Limit struct {
+	Count   Expr
+	Offset  Expr
+	All     bool
+	Fetch   bool
+	Percent bool
+
+	// countPos/countEnd span the count (and PERCENT) so a rewrite can
+	// replace it; countPos is -1 when there is no count.
+	countPos, countEnd int
+}
+
+// TableExpr is *TableName, *DerivedTable, *TableFunc or *Join.
+type TableExpr interface{ tableExpr() }
+
+// TableName is a (possibly qualified) table reference.
+type TableName struct {
+	Parts []string
+	Alias string
+	// Columns renames the table's columns positionally: t AS x(a, b).
+	Columns []string
+	pos     int
+}
+
+// DerivedTable is a subquery in FROM.
+type DerivedTable struct {
+	Query   *Query
+	Alias   string
+	Columns []string
+	Lateral bool
+}
+
+// TableFunc is a set-returning function in FROM.
+type TableFunc struct {
+	Func    *FuncCall
+	Alias   string
+	Columns []string
+}
+
+// Join combines two table expressions.
+type Join struct {
+	Kind        string
+	Left, Right TableExpr
+	On          Expr
+	Using       []string
+}
+
+func (*TableName) tableExpr()    {}
+func (*DerivedTable) tableExpr() {}
+func (*TableFunc) tableExpr()    {}
+func (*Join) tableExpr()         {}
+
+// Expr is any scalar expression node.
+type Expr interface{ expr() }
+
+// ColumnRef is a (possibly qualified) column name.
+type ColumnRef struct {
+	Parts []string
+	pos   int
+}
+
+// Star is * or qualifier.* in a select list.
+type Star struct {
+	Qualifier []string
+	pos       int
+}
+
+// Literal is a number, string, boolean or NULL.
+type Literal struct{ Value string }
+
+// Param is a bind parameter or session variable (?, $1, :name, @v).
+type Param struct{ Name string }
+
+// Keyword is a bare word in argument position that is not a column: a
+// date part (EXTRACT(YEAR FROM ...), DATEADD(day, ...)), an interval
+// unit, or a type name.
+type Keyword struct{ Word string }
+
+// FuncCall is a function or aggregate call.
+type FuncCall struct {
+	Name     []string
+	Star     bool // COUNT(*)
+	Distinct bool
+	Args     []Expr
+	OrderBy  []*OrderItem // inside the parens or WITHIN GROUP
+	Filter   Expr
+	Over     *WindowSpec
+	pos      int
+}
+
+// WindowSpec is an OVER clause or a WINDOW definition.
+type WindowSpec struct {
+	Name        string
+	PartitionBy []Expr
+	OrderBy     []*OrderItem
+}
+
+// Unary is a prefix operator, including NOT.
+type Unary struct {
+	Op string
+	X  Expr
+}
+
+// Binary is an infix operator, including comparisons, LIKE and AND/OR.
+type Binary struct {
+	Op   string
+	L, R Expr
+}
+
+// Between is x [NOT] BETWEEN lo AND hi.
+type Between struct {
+	X, Lo, Hi Expr
+	Not       bool
+}
+
+// In is x [NOT] IN (list) or x [NOT] IN (subquery).
+type In struct {
+	X     Expr
+	List  []Expr
+	Query *Query
+	Not   bool
+}
+
+// Is is x IS [NOT] NULL/TRUE/FALSE/UNKNOWN or IS [NOT] DISTINCT FROM y.
+type Is struct {
+	X    Expr
+	Not  bool
+	What string
+	From Expr
+}
+
+// Subquery is a scalar, EXISTS or ANY/ALL subquery.
+type Subquery struct {
+	Query  *Query
+	Exists bool
+}
+
+// Case is a CASE expression.
+type Case struct {
+	Operand Expr
+	Whens   []*When
+	Else    Expr
+}
+
+// When is one CASE arm.
+type When struct{ Cond, Then Expr }
+
+// Cast is CAST(x AS type), TRY_CAST, or x::type.
+type Cast struct {
+	X    Expr
+	Type string
+}
+
+// Interval is INTERVAL x [unit].
+type Interval struct {
+	X    Expr
+	Unit string
+}
+
+// Tuple is a parenthesised expression list or ARRAY[...] constructor.
+type Tuple struct{ Items []Expr }
+
+// Subscript is x[i] or x[i:j].
+type Subscript struct{ X, Index, End Expr }
+
+func (*ColumnRef) expr() {}
+func (*Star) expr()      {}
+func (*Literal) expr()   {}
+func (*Param) expr()     {}
+func (*Keyword) expr()   {}
+func (*FuncCall) expr()  {}
+func (*Unary) expr()     {}
+func (*Binary) expr()    {}
+func (*Between) expr()   {}
+func (*In) expr()        {}
+func (*Is) expr()        {}
+func (*Subquery) expr()  {}
+func (*Case) expr()      {}
+func (*Cast) expr()      {}
+func (*Interval) expr()  {}
+func (*Tuple) expr()     {}
+func (*Subscript) expr() {}
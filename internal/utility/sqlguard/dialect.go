@@ -0,0 +1,212 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sqlguard
+
+import (
+	"strings"
+	"time"
+)
+
+// limitStyle is how a dialect spells a row cap.
+type limitStyle int
+
+const (
+	limitClause limitStyle = iota // trailing LIMIT n
+	limitTop                      // SELECT TOP (n) ...
+	limitFetch                    // trailing FETCH FIRST n ROWS ONLY
+)
+
+// Dialect captures the lexical and rewrite differences between the SQL
+// engines RAGFlow sends generated SQL to. The zero value is not usable;
+// use one of the package-level dialects or DialectFor.
+type Dialect struct {
+	name string
+	// identQuotes are the opening characters of quoted identifiers;
+	// brackets close with ']'.
+	identQuotes string
+	// doubleQuoteStrings treats "..." as a string literal (MySQL's
+	// default sql_mode) instead of an identifier.
+	doubleQuoteStrings bool
+	// backslashEscapes honours \' inside string literals.
+	backslashEscapes bool
+	// dollarQuotes enables Postgres $tag$...$tag$ strings.
+	dollarQuotes bool
+	// hashComments treats # as a line comment (MySQL).
+	hashComments bool
+	// executableComments marks /*! ... */ as code, not a comment
+	// (MySQL family); the guard rejects them outright.
+	executableComments bool
+	// nestedComments allows /* /* */ */ (Postgres family).
+	nestedComments bool
+	limit          limitStyle
+	// timeout renders the per-statement execution limit: an optimizer
+	// hint placed after the main SELECT keyword, or (prefix) a clause
+	// placed before the statement. nil when the dialect has no
+	// in-statement form and the caller's context deadline is the only
+	// bound.
+	timeout func(d time.Duration) (text string, prefix bool)
+	// denied adds dialect-specific names to deniedFunctions for
+	// functions whose names are common words elsewhere.
+	denied []string
+}
+
+// Name returns the dialect's canonical name.
+func (d *Dialect) Name() string { return d.name }
+
+var (
+	// MySQL covers MySQL 5.7+ and TiDB.
+	MySQL = &Dialect{
+		name: "mysql", identQuotes: "`", doubleQuoteStrings: true,
+		backslashEscapes: true, hashComments: true, executableComments: true,
+		limit: limitClause, timeout: hintTimeout("MAX_EXECUTION_TIME", time.Millisecond),
+	}
+	// MariaDB ignores MySQL's optimizer hint and uses SET STATEMENT.
+	MariaDB = &Dialect{
+		name: "mariadb", identQuotes: "`", doubleQuoteStrings: true,
+		backslashEscapes: true, hashComments: true, executableComments: true,
+		limit: limitClause, timeout: mariaDBTimeout,
+	}
+	// OceanBase (MySQL mode) takes its timeout hint in microseconds.
+	OceanBase = &Dialect{
+		name: "oceanbase", identQuotes: "`", doubleQuoteStrings: true,
+		backslashEscapes: true, hashComments: true, executableComments: true,
+		limit: limitClause, timeout: hintTimeout("QUERY_TIMEOUT", time.Microsecond),
+	}
+	Postgres = &Dialect{
+		name: "postgres", identQuotes: `"`, dollarQuotes: true,
+		nestedComments: true, limit: limitClause,
+	}
+	MSSQL = &Dialect{
+		name: "mssql", identQuotes: `"[`, limit: limitTop,
+	}
+	// ESSQL is Elasticsearch / OpenSearch SQL. RAGFlow's ES path
+	// strips backticks before sending, so they are accepted here.
+	ESSQL = &Dialect{
+		name: "essql", identQuotes: "\"`", limit: limitClause,
+	}
+	// Infinity speaks the Postgres wire protocol and grammar.
+	Infinity = &Dialect{
+		name: "infinity", identQuotes: `"`, nestedComments: true, limit: limitClause,
+	}
+	SQLite = &Dialect{
+		name: "sqlite", identQuotes: "\"`[", limit: limitClause,
+	}
+	// DuckDB's query() / query_table() run SQL given as a string, which
+	// would hide it from the guard; glob() lists server-side files, while
+	// SQLite's glob() only matches a pattern.
+	DuckDB = &Dialect{
+		name: "duckdb", identQuotes: `"`, dollarQuotes: true,
+		nestedComments: true, limit: limitClause,
+		denied: []string{"query", "query_table", "glob"},
+	}
+	Trino = &Dialect{
+		name: "trino", identQuotes: `"`, limit: limitClause,
+	}
+	DB2 = &Dialect{
+		name: "db2", identQuotes: `"`, limit: limitFetch,
+	}
+)
+
+// DialectFor maps an ExeSQL db_type or a document-engine name onto a
+// dialect. Unknown names get the ANSI-flavoured Postgres rules, which
+// are the strictest about quoting.
+func DialectFor(name string) *Dialect {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "mysql", "tidb":
+		return MySQL
+	case "mariadb":
+		return MariaDB
+	case "oceanbase":
+		return OceanBase
+	case "postgres", "postgresql":
+		return Postgres
+	case "mssql", "sqlserver":
+		return MSSQL
+	case "elasticsearch", "opensearch", "es", "essql":
+		return ESSQL
+	case "infinity":
+		return Infinity
+	case "sqlite", "sqlite3":
+		return SQLite
+	case "duckdb":
+		return DuckDB
+	case "trino", "presto":
+		return Trino
+	case "db2", "ibm db2":
+		return DB2
+	}
+	return Postgres
+}
+
+// deniedFunctions are rejected in every dialect: each either reads or
+// writes server-side files, executes dynamic SQL or shell commands,
+// reaches other servers, changes server state, or exists to stall a
+// connection. Checking the union is simpler than per-dialect lists and
+// costs nothing — a name that is harmless in one engine is not a
+// plausible function for an LLM to need in another.
+var deniedFunctions = map[string]struct{}{
+	// MySQL / MariaDB
+	"sleep": {}, "benchmark": {}, "load_file": {}, "get_lock": {},
+	"release_lock": {}, "release_all_locks": {}, "master_pos_wait": {},
+	"source_pos_wait": {}, "sys_exec": {}, "sys_eval": {},
+	// Postgres
+	"pg_sleep": {}, "pg_sleep_for": {}, "pg_sleep_until": {},
+	"pg_read_file": {}, "pg_read_binary_file": {}, "pg_ls_dir": {},
+	"pg_stat_file": {}, "lo_import": {}, "lo_export": {}, "lo_unlink": {},
+	"lo_from_bytea": {}, "lo_put": {}, "lo_get": {}, "dblink": {}, "dblink_exec": {},
+	"dblink_connect": {}, "dblink_send_query": {}, "pg_terminate_backend": {},
+	"pg_cancel_backend": {}, "pg_reload_conf": {}, "pg_rotate_logfile": {},
+	"pg_promote": {}, "pg_switch_wal": {}, "set_config": {},
+	"pg_advisory_lock": {}, "pg_advisory_xact_lock": {},
+	"pg_advisory_lock_shared": {}, "pg_logical_emit_message": {},
+	"nextval": {}, "setval": {}, "query_to_xml": {},
+	"query_to_xml_and_xmlschema": {}, "cursor_to_xml": {},
+	"pg_create_restore_point": {}, "pg_file_write": {},
+	// SQL Server
+	"openrowset": {}, "opendatasource": {}, "openquery": {},
+	// SQLite
+	"load_extension": {}, "readfile": {}, "writefile": {}, "edit": {},
+	"fts3_tokenizer": {},
+	// DuckDB
+	"read_csv": {}, "read_csv_auto": {}, "read_parquet": {}, "parquet_scan": {},
+	"read_json": {}, "read_json_auto": {}, "read_ndjson": {}, "read_text": {},
+	"read_blob": {}, "sniff_csv": {}, "read_xlsx": {}, "getenv": {},
+	"sqlite_scan": {}, "postgres_scan": {}, "mysql_scan": {},
+}
+
+// deniedFunctionPrefixes catch SQL Server's extended and system
+// procedures when they are called like functions, and Postgres's
+// pg_ls_* directory listings (logdir, waldir, tmpdir, ...).
+var deniedFunctionPrefixes = []string{"xp_", "sp_", "pg_ls_"}
+
+func (d *Dialect) isDeniedFunction(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := deniedFunctions[name]; ok {
+		return true
+	}
+	for _, n := range d.denied {
+		if n == name {
+			return true
+		}
+	}
+	for _, p := range deniedFunctionPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
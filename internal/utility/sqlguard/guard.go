@@ -0,0 +1,164 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package sqlguard parses SQL produced by a language model and decides
+// whether it is safe to run against a user's database or RAGFlow's own
+// document engine.
+//
+// A statement is accepted only when it parses as a single read-only
+// query (SELECT / WITH / VALUES, or SHOW / DESCRIBE / EXPLAIN / PRAGMA
+// introspection) in the target dialect, calls no function from the
+// deny list, and — when the policy carries allowlists — touches only
+// the listed tables and columns. Accepted queries are rewritten to cap
+// the rows returned and, where the dialect has an in-statement form, to
+// make the server abort them after the timeout; dialects without one
+// rely on the caller's context deadline.
+//
+// Rejections are *Violation values whose Error text is written for the
+// model: it names the rule that failed and says how to fix the query,
+// so SQL repair loops can feed it straight back.
+//
+// The parser covers the query grammar the supported engines share plus
+// their common extensions. It is deliberately not a validator of
+// semantics — unknown functions, types and columns are the engine's
+// business — and anything it cannot parse is rejected rather than
+// guessed at.
+package sqlguard
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Code classifies a Violation.
+type Code string
+
+const (
+	CodeEmpty              Code = "empty"
+	CodeSyntax             Code = "syntax_error"
+	CodeNotReadOnly        Code = "not_read_only"
+	CodeMultipleStatements Code = "multiple_statements"
+	CodeFunction           Code = "function_not_allowed"
+	CodeTable              Code = "table_not_allowed"
+	CodeColumn             Code = "column_not_allowed"
+	CodeRowLimit           Code = "row_limit"
+)
+
+// ErrRejected matches every *Violation via errors.Is.
+var ErrRejected = errors.New("sql rejected")
+
+// Violation is a structured rejection.
+type Violation struct {
+	Code Code
+	// Object is the offending keyword, function, table or column, when
+	// there is one.
+	Object string
+	// Pos is the byte offset of the offending token in the input.
+	Pos int
+	// Message explains the rejection and how to repair the query.
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("SQL rejected before execution (%s): %s", v.Code, v.Message)
+}
+
+// Is reports whether target is ErrRejected.
+func (v *Violation) Is(target error) bool { return target == ErrRejected }
+
+func violationf(code Code, pos int, object, format string, args ...any) *Violation {
+	return &Violation{Code: code, Object: object, Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// Policy configures Check.
+type Policy struct {
+	// Dialect selects lexing rules and rewrite forms. Defaults to
+	// Postgres.
+	Dialect *Dialect
+	// MaxRows caps the rows a query may return; 0 leaves the row count
+	// alone. Existing limits above the cap are lowered, limits below
+	// it are kept.
+	MaxRows int
+	// Timeout is written into the statement for dialects that support
+	// a per-statement execution limit; 0 adds none.
+	Timeout time.Duration
+	// AllowedTables, when non-empty, is the complete set of tables the
+	// query may read. An unqualified entry ("orders") matches that
+	// table in any schema; "sales.orders" matches only that one.
+	// Case-insensitive.
+	AllowedTables []string
+	// AllowedColumns, when non-empty, is the complete set of columns
+	// the query may reference on real tables. Entries are "column"
+	// (allowed on every table), "table.column", or "table.*".
+	// Case-insensitive. SELECT * is rejected on tables without a
+	// "table.*" entry.
+	AllowedColumns []string
+}
+
+// Result is an accepted statement.
+type Result struct {
+	// SQL is the statement to execute: the input with the row cap and
+	// timeout applied and any trailing semicolon removed.
+	SQL string
+	// Statement is the parsed input.
+	Statement *Statement
+	// Tables lists the real tables read, lower-cased and sorted.
+	Tables []string
+	// Functions lists the functions called, lower-cased and sorted.
+	Functions []string
+}
+
+// Check parses sql under p and returns the rewritten statement, or a
+// *Violation explaining why it was refused.
+func Check(sql string, p Policy) (*Result, error) {
+	d := p.Dialect
+	if d == nil {
+		d = Postgres
+	}
+	if strings.TrimSpace(sql) == "" {
+		return nil, violationf(CodeEmpty, 0, "", "the SQL statement is empty. Return a single SELECT query.")
+	}
+	stmt, err := Parse(sql, d)
+	if err != nil {
+		return nil, err
+	}
+	a := newAnalyzer(d, p)
+	if err := a.statement(stmt); err != nil {
+		return nil, err
+	}
+	out, err := rewrite(sql, stmt, d, p)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		SQL:       out,
+		Statement: stmt,
+		Tables:    sortedKeys(a.tables),
+		Functions: sortedKeys(a.functions),
+	}, nil
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
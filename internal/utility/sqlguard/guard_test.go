@@ -0,0 +1,278 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sqlguard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func checkCode(t *testing.T, sql string, p Policy, want Code) {
+	t.Helper()
+	_, err := Check(sql, p)
+	var v *Violation
+	if !errors.As(err, &v) {
+		t.Fatalf("Check(%q) error = %v, want *Violation %s", sql, err, want)
+	}
+	if v.Code != want {
+		t.Errorf("Check(%q) code = %s (%s), want %s", sql, v.Code, v.Message, want)
+	}
+	if !errors.Is(err, ErrRejected) {
+		t.Errorf("Check(%q) error does not match ErrRejected", sql)
+	}
+}
+
+func TestCheckRejectsWrites(t *testing.T) {
+	for _, sql := range []string{
+		"INSERT INTO t VALUES (1)",
+		"update t set a = 1",
+		"DELETE FROM t",
+		"DROP TABLE t",
+		"CREATE TABLE x (a int)",
+		"TRUNCATE t",
+		"GRANT ALL ON t TO bob",
+		"SET search_path = evil",
+		"CALL proc()",
+		"WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d",
+		"SELECT a FROM t FOR UPDATE",
+		"SELECT a INTO new_table FROM t",
+		"EXPLAIN ANALYZE DELETE FROM t",
+		"PRAGMA journal_mode = WAL",
+	} {
+		checkCode(t, sql, Policy{Dialect: Postgres}, CodeNotReadOnly)
+	}
+	checkCode(t, "SELECT a FROM t INTO OUTFILE '/tmp/x'", Policy{Dialect: MySQL}, CodeNotReadOnly)
+	checkCode(t, "SELECT 1 /*!50000 , sleep(10) */", Policy{Dialect: MySQL}, CodeNotReadOnly)
+}
+
+func TestCheckRejectsMultipleStatements(t *testing.T) {
+	for _, sql := range []string{
+		"SELECT 1; SELECT 2",
+		"SELECT 1; DROP TABLE t",
+		"SELECT ';'; DELETE FROM t",
+	} {
+		checkCode(t, sql, Policy{Dialect: MySQL}, CodeMultipleStatements)
+	}
+	// A lone trailing semicolon is fine and is stripped.
+	res, err := Check("SELECT 1;  ", Policy{Dialect: MySQL})
+	if err != nil {
+		t.Fatalf("Check error = %v", err)
+	}
+	if res.SQL != "SELECT 1" {
+		t.Errorf("SQL = %q, want %q", res.SQL, "SELECT 1")
+	}
+}
+
+func TestCheckRejectsDeniedFunctions(t *testing.T) {
+	tests := []struct {
+		d   *Dialect
+		sql string
+	}{
+		{MySQL, "SELECT LOAD_FILE('/etc/passwd')"},
+		{MySQL, "SELECT a FROM t WHERE sleep(10) = 0"},
+		{MySQL, "SELECT benchmark(1000000, md5('x'))"},
+		{Postgres, "SELECT pg_catalog.pg_read_file('pg_hba.conf')"},
+		{Postgres, "SELECT * FROM dblink('host=x', 'select 1') AS t(a int)"},
+		{Postgres, "SELECT a FROM t WHERE a IN (SELECT pg_sleep(5))"},
+		{Postgres, "SELECT * FROM pg_ls_logdir()"},
+		{Postgres, "SELECT * FROM pg_ls_waldir()"},
+		{Postgres, "SELECT * FROM pg_ls_tmpdir()"},
+		{Postgres, "SELECT * FROM pg_ls_archive_statusdir()"},
+		{Postgres, "SELECT lo_get(16384)"},
+		{MSSQL, "SELECT * FROM OPENROWSET('SQLNCLI', 'x', 'select 1')"},
+		{MSSQL, "SELECT xp_cmdshell('dir')"},
+		{SQLite, "SELECT load_extension('evil')"},
+		{DuckDB, "SELECT * FROM read_csv('/etc/passwd')"},
+		{DuckDB, "SELECT * FROM query('DELETE FROM t')"},
+		{DuckDB, "SELECT getenv('AWS_SECRET_ACCESS_KEY')"},
+		{DuckDB, "SELECT * FROM read_xlsx('/data/salaries.xlsx')"},
+		{DuckDB, "SELECT * FROM sqlite_scan('/var/lib/app.db', 'users')"},
+		{DuckDB, "SELECT * FROM postgres_scan('host=x', 'public', 'users')"},
+		{DuckDB, "SELECT * FROM mysql_scan('host=x', 'db', 'users')"},
+		{DuckDB, "SELECT * FROM glob('/etc/*')"},
+	}
+	for _, tt := range tests {
+		checkCode(t, tt.sql, Policy{Dialect: tt.d}, CodeFunction)
+	}
+	// query() is an Elasticsearch full-text predicate, not dynamic SQL.
+	if _, err := Check("SELECT title FROM idx WHERE QUERY('foo')", Policy{Dialect: ESSQL}); err != nil {
+		t.Errorf("ES QUERY() rejected: %v", err)
+	}
+	// SQLite's glob() is a pattern match, not a file listing.
+	if _, err := Check("SELECT a FROM t WHERE glob('a*', a)", Policy{Dialect: SQLite}); err != nil {
+		t.Errorf("SQLite glob() rejected: %v", err)
+	}
+}
+
+func TestCheckRowLimit(t *testing.T) {
+	tests := []struct {
+		d    *Dialect
+		sql  string
+		want string
+	}{
+		{MySQL, "SELECT a FROM t", "SELECT a FROM t LIMIT 100"},
+		{MySQL, "SELECT a FROM t LIMIT 5", "SELECT a FROM t LIMIT 5"},
+		{MySQL, "SELECT a FROM t LIMIT 5000", "SELECT a FROM t LIMIT 100"},
+		{MySQL, "SELECT a FROM t LIMIT 10, 5000", "SELECT a FROM t LIMIT 10, 100"},
+		{MySQL, "SELECT a FROM t LIMIT ?", "SELECT a FROM t LIMIT 100"},
+		{Postgres, "SELECT a FROM t LIMIT ALL OFFSET 3", "SELECT a FROM t LIMIT 100 OFFSET 3"},
+		{Postgres, "SELECT a FROM t ORDER BY a FETCH FIRST 500 ROWS ONLY", "SELECT a FROM t ORDER BY a FETCH FIRST 100 ROWS ONLY"},
+		{Postgres, "SELECT a FROM t UNION SELECT b FROM u", "SELECT a FROM t UNION SELECT b FROM u LIMIT 100"},
+		{Postgres, "WITH x AS (SELECT a FROM t LIMIT 1000) SELECT * FROM x", "WITH x AS (SELECT a FROM t LIMIT 1000) SELECT * FROM x LIMIT 100"},
+		{MSSQL, "SELECT a FROM t", "SELECT TOP (100) a FROM t"},
+		{MSSQL, "SELECT DISTINCT a FROM t", "SELECT DISTINCT TOP (100) a FROM t"},
+		{MSSQL, "SELECT TOP 10 a FROM t", "SELECT TOP 10 a FROM t"},
+		{MSSQL, "SELECT TOP 1000 a FROM t", "SELECT TOP (100) a FROM t"},
+		{MSSQL, "SELECT a FROM t ORDER BY a OFFSET 5 ROWS", "SELECT a FROM t ORDER BY a OFFSET 5 ROWS FETCH NEXT 100 ROWS ONLY"},
+		{MSSQL, "SELECT a FROM t UNION SELECT b FROM u", "SELECT a FROM t UNION SELECT b FROM u ORDER BY 1 OFFSET 0 ROWS FETCH NEXT 100 ROWS ONLY"},
+		{DB2, "SELECT a FROM t", "SELECT a FROM t FETCH FIRST 100 ROWS ONLY"},
+		{Postgres, "EXPLAIN SELECT a FROM t", "EXPLAIN SELECT a FROM t"},
+		{MySQL, "SHOW TABLES", "SHOW TABLES"},
+	}
+	for _, tt := range tests {
+		res, err := Check(tt.sql, Policy{Dialect: tt.d, MaxRows: 100})
+		if err != nil {
+			t.Errorf("Check(%q) error = %v", tt.sql, err)
+			continue
+		}
+		if res.SQL != tt.want {
+			t.Errorf("Check(%q, %s).SQL = %q, want %q", tt.sql, tt.d.name, res.SQL, tt.want)
+		}
+	}
+	checkCode(t, "SELECT TOP 50 PERCENT a FROM t", Policy{Dialect: MSSQL, MaxRows: 100}, CodeRowLimit)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	tests := []struct {
+		d    *Dialect
+		want string
+	}{
+		{MySQL, "SELECT /*+ MAX_EXECUTION_TIME(1500) */ a FROM t"},
+		{OceanBase, "SELECT /*+ QUERY_TIMEOUT(1500000) */ a FROM t"},
+		{MariaDB, "SET STATEMENT max_statement_time=1.5 FOR SELECT a FROM t"},
+		{Postgres, "SELECT a FROM t"},
+	}
+	for _, tt := range tests {
+		res, err := Check("SELECT a FROM t", Policy{Dialect: tt.d, Timeout: 1500 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("Check(%s) error = %v", tt.d.name, err)
+		}
+		if res.SQL != tt.want {
+			t.Errorf("Check(%s).SQL = %q, want %q", tt.d.name, res.SQL, tt.want)
+		}
+	}
+
+	res, err := Check("WITH x AS (SELECT a FROM t) SELECT a FROM x", Policy{Dialect: MySQL, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "WITH x AS (SELECT a FROM t) SELECT /*+ MAX_EXECUTION_TIME(1000) */ a FROM x"; res.SQL != want {
+		t.Errorf("SQL = %q, want %q", res.SQL, want)
+	}
+}
+
+func TestCheckAllowedTables(t *testing.T) {
+	p := Policy{Dialect: Postgres, AllowedTables: []string{"orders", "Sales.Customers"}}
+	for _, sql := range []string{
+		"SELECT * FROM orders",
+		"SELECT * FROM public.orders o JOIN sales.customers c ON c.id = o.cid",
+		"WITH orders_2024 AS (SELECT * FROM orders) SELECT * FROM orders_2024",
+		"SELECT * FROM (SELECT id FROM orders) AS sub",
+		"SELECT * FROM generate_series(1, 3)",
+	} {
+		if _, err := Check(sql, p); err != nil {
+			t.Errorf("Check(%q) error = %v", sql, err)
+		}
+	}
+	for _, sql := range []string{
+		"SELECT * FROM users",
+		"SELECT * FROM customers",
+		"SELECT * FROM orders WHERE id IN (SELECT order_id FROM refunds)",
+		"SELECT * FROM orders o, LATERAL (SELECT * FROM secrets s WHERE s.id = o.id) x",
+		"SELECT table_name FROM information_schema.tables",
+		"DESCRIBE users",
+	} {
+		checkCode(t, sql, p, CodeTable)
+	}
+	checkCode(t, "SHOW TABLES", Policy{Dialect: MySQL, AllowedTables: []string{"orders"}}, CodeTable)
+
+	_, err := Check("SELECT * FROM users", p)
+	if !strings.Contains(err.Error(), "orders, sales.customers") {
+		t.Errorf("violation %q does not list the allowed tables", err)
+	}
+}
+
+func TestCheckAllowedColumns(t *testing.T) {
+	p := Policy{
+		Dialect:        Postgres,
+		AllowedTables:  []string{"orders", "customers"},
+		AllowedColumns: []string{"id", "orders.*", "customers.name"},
+	}
+	for _, sql := range []string{
+		"SELECT * FROM orders",
+		"SELECT o.total, c.name FROM orders o JOIN customers c ON c.id = o.customer_id",
+		"SELECT name FROM customers ORDER BY name",
+		"SELECT count(*) AS n FROM customers ORDER BY n",
+		"WITH x AS (SELECT id, name AS label FROM customers) SELECT label FROM x",
+		"SELECT (SELECT c.name FROM customers c WHERE c.id = o.customer_id) FROM orders o",
+	} {
+		if _, err := Check(sql, p); err != nil {
+			t.Errorf("Check(%q) error = %v", sql, err)
+		}
+	}
+	for _, sql := range []string{
+		"SELECT * FROM customers",
+		"SELECT c.* FROM customers c",
+		"SELECT email FROM customers",
+		"SELECT c.email FROM customers c",
+		"SELECT name FROM customers WHERE email LIKE '%@x.com'",
+		"SELECT row_to_json(c) FROM customers c",
+		"SELECT x FROM customers AS c(x)",
+	} {
+		checkCode(t, sql, p, CodeColumn)
+	}
+}
+
+func TestCheckResultListsTablesAndFunctions(t *testing.T) {
+	res, err := Check("SELECT upper(c.name), COUNT(*) FROM Sales.Customers c JOIN orders o ON o.cid = c.id GROUP BY 1", Policy{Dialect: MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(res.Tables, ","); got != "orders,sales.customers" {
+		t.Errorf("Tables = %v", res.Tables)
+	}
+	if got := strings.Join(res.Functions, ","); got != "count,upper" {
+		t.Errorf("Functions = %v", res.Functions)
+	}
+}
+
+func TestDialectFor(t *testing.T) {
+	tests := map[string]*Dialect{
+		"mysql": MySQL, "MariaDB": MariaDB, "oceanbase": OceanBase,
+		"postgres": Postgres, "postgresql": Postgres, "mssql": MSSQL,
+		"elasticsearch": ESSQL, "opensearch": ESSQL, "infinity": Infinity,
+		"sqlite3": SQLite, "duckdb": DuckDB, "trino": Trino, "db2": DB2,
+		"unknown": Postgres,
+	}
+	for name, want := range tests {
+		if got := DialectFor(name); got != want {
+			t.Errorf("DialectFor(%q) = %s, want %s", name, got.name, want.name)
+		}
+	}
+}
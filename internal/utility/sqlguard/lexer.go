@@ -0,0 +1,360 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sqlguard
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokNumber
+	tokString
+	tokParam
+	tokOp
+)
+
+// token is one lexeme. pos/end are byte offsets into the original SQL so
+// rewrites can splice the caller's text instead of re-rendering it.
+type token struct {
+	kind  tokenKind
+	text  string // identifier name (unquoted), literal body, or operator
+	upper string // upper-cased text for keyword comparison; empty for quoted identifiers
+	pos   int
+	end   int
+}
+
+func (t token) is(kw string) bool { return t.kind == tokIdent && t.upper == kw }
+
+func (t token) isOp(op string) bool { return t.kind == tokOp && t.text == op }
+
+// multiCharOps is ordered longest first so the lexer is greedy.
+var multiCharOps = []string{
+	"->>", "#>>", "<=>", "!~*", "::", "||", "<>", "<=", ">=", "!=", "==",
+	"->", "#>", "@>", "<@", "&&", "<<", ">>", ":=", "=>", "!~", "~*",
+}
+
+type lexer struct {
+	d    *Dialect
+	src  string
+	pos  int
+	toks []token
+}
+
+// lex splits src into tokens, dropping comments. It fails on unterminated
+// literals and comments and on MySQL executable comments, which would
+// otherwise hide SQL from the parser.
+func lex(src string, d *Dialect) ([]token, error) {
+	l := &lexer{d: d, src: src}
+	for {
+		if err := l.skipSpaceAndComments(); err != nil {
+			return nil, err
+		}
+		if l.pos >= len(l.src) {
+			l.toks = append(l.toks, token{kind: tokEOF, pos: l.pos, end: l.pos})
+			return l.toks, nil
+		}
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (l *lexer) peekByte(off int) byte {
+	if l.pos+off < len(l.src) {
+		return l.src[l.pos+off]
+	}
+	return 0
+}
+
+func (l *lexer) skipSpaceAndComments() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case c == '-' && l.peekByte(1) == '-', c == '#' && l.d.hashComments:
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case c == '/' && l.peekByte(1) == '*':
+			if l.d.executableComments && l.peekByte(2) == '!' {
+				return violationf(CodeNotReadOnly, l.pos, "/*!", "MySQL executable comments (/*! ... */) are not allowed; remove the comment and return a plain SELECT query.")
+			}
+			start := l.pos
+			depth := 0
+			for l.pos < len(l.src) {
+				if l.src[l.pos] == '/' && l.peekByte(1) == '*' {
+					if depth == 0 || l.d.nestedComments {
+						depth++
+					}
+					l.pos += 2
+					continue
+				}
+				if l.src[l.pos] == '*' && l.peekByte(1) == '/' {
+					depth--
+					l.pos += 2
+					if depth == 0 {
+						break
+					}
+					continue
+				}
+				l.pos++
+			}
+			if depth != 0 {
+				return violationf(CodeSyntax, start, "", "unterminated block comment")
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (l *lexer) emit(kind tokenKind, text string, start int) {
+	t := token{kind: kind, text: text, pos: start, end: l.pos}
+	if kind == tokIdent {
+		t.upper = strings.ToUpper(text)
+	}
+	l.toks = append(l.toks, t)
+}
+
+func (l *lexer) next() error {
+	start := l.pos
+	c := l.src[l.pos]
+
+	// Prefixed strings: E'..' (Postgres escapes), N'..' (national), X'..'
+	// and B'..' (hex/bit).
+	if (c == 'E' || c == 'e' || c == 'N' || c == 'n' || c == 'X' || c == 'x' || c == 'B' || c == 'b') && l.peekByte(1) == '\'' {
+		l.pos++
+		backslash := l.d.backslashEscapes || c == 'E' || c == 'e'
+		body, err := l.quoted('\'', '\'', backslash)
+		if err != nil {
+			return err
+		}
+		l.emit(tokString, body, start)
+		return nil
+	}
+
+	switch {
+	case c == '\'':
+		body, err := l.quoted('\'', '\'', l.d.backslashEscapes)
+		if err != nil {
+			return err
+		}
+		l.emit(tokString, body, start)
+		return nil
+	case c == '"' && l.d.doubleQuoteStrings:
+		body, err := l.quoted('"', '"', l.d.backslashEscapes)
+		if err != nil {
+			return err
+		}
+		l.emit(tokString, body, start)
+		return nil
+	case strings.IndexByte(l.d.identQuotes, c) >= 0:
+		closer := c
+		if c == '[' {
+			closer = ']'
+		}
+		body, err := l.quoted(c, closer, false)
+		if err != nil {
+			return err
+		}
+		l.emit(tokQuotedIdent, body, start)
+		return nil
+	case c == '$':
+		if l.d.dollarQuotes {
+			body, ok, err := l.dollarQuoted()
+			if err != nil {
+				return err
+			}
+			if ok {
+				l.emit(tokString, body, start)
+				return nil
+			}
+		}
+		if isDigit(l.peekByte(1)) {
+			l.pos++
+			for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+				l.pos++
+			}
+			l.emit(tokParam, l.src[start:l.pos], start)
+			return nil
+		}
+	case isDigit(c) || (c == '.' && isDigit(l.peekByte(1))):
+		l.number()
+		l.emit(tokNumber, l.src[start:l.pos], start)
+		return nil
+	case c == '?':
+		l.pos++
+		l.emit(tokParam, "?", start)
+		return nil
+	case c == '@':
+		// @var / @@system_var (MySQL, SQL Server). A bare @ is an
+		// operator (Postgres abs, containment follows as @>).
+		n := 1
+		if l.peekByte(1) == '@' {
+			n = 2
+		}
+		if r, _ := utf8.DecodeRuneInString(l.src[min(l.pos+n, len(l.src)):]); l.pos+n < len(l.src) && isIdentStart(r) {
+			l.pos += n
+			l.identRunes()
+			l.emit(tokParam, l.src[start:l.pos], start)
+			return nil
+		}
+	case c == ':' && l.peekByte(1) != ':' && l.peekByte(1) != '=':
+		if r, _ := utf8.DecodeRuneInString(l.src[min(l.pos+1, len(l.src)):]); l.pos+1 < len(l.src) && isIdentStart(r) {
+			l.pos++
+			l.identRunes()
+			l.emit(tokParam, l.src[start:l.pos], start)
+			return nil
+		}
+	}
+
+	if r, _ := utf8.DecodeRuneInString(l.src[l.pos:]); isIdentStart(r) {
+		l.identRunes()
+		l.emit(tokIdent, l.src[start:l.pos], start)
+		return nil
+	}
+
+	for _, op := range multiCharOps {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			l.emit(tokOp, op, start)
+			return nil
+		}
+	}
+	if strings.IndexByte("(),.;+-*/%=<>~!&|^[]{}:@#", c) >= 0 {
+		l.pos++
+		l.emit(tokOp, string(c), start)
+		return nil
+	}
+	return violationf(CodeSyntax, start, string(c), "unexpected character %q", c)
+}
+
+// quoted consumes an open...close literal where a doubled closer is an
+// escaped closer, returning the unescaped body.
+func (l *lexer) quoted(open, closer byte, backslash bool) (string, error) {
+	start := l.pos
+	l.pos++ // opener
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if backslash && c == '\\' && l.pos+1 < len(l.src) {
+			b.WriteByte(l.src[l.pos+1])
+			l.pos += 2
+			continue
+		}
+		if c == closer {
+			if l.peekByte(1) == closer {
+				b.WriteByte(closer)
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			return b.String(), nil
+		}
+		b.WriteByte(c)
+		l.pos++
+	}
+	kind := "string literal"
+	if open != '\'' {
+		kind = "quoted identifier"
+	}
+	return "", violationf(CodeSyntax, start, "", "unterminated %s", kind)
+}
+
+// dollarQuoted consumes $tag$...$tag$. ok is false when the text at pos
+// is a positional parameter ($1) rather than a dollar quote.
+func (l *lexer) dollarQuoted() (string, bool, error) {
+	end := strings.IndexByte(l.src[l.pos+1:], '$')
+	if end < 0 {
+		return "", false, nil
+	}
+	tag := l.src[l.pos : l.pos+1+end+1]
+	for i, r := range tag[1 : len(tag)-1] {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return "", false, nil
+		}
+	}
+	bodyStart := l.pos + len(tag)
+	closeAt := strings.Index(l.src[bodyStart:], tag)
+	if closeAt < 0 {
+		return "", true, violationf(CodeSyntax, l.pos, "", "unterminated dollar-quoted string")
+	}
+	l.pos = bodyStart + closeAt + len(tag)
+	return l.src[bodyStart : bodyStart+closeAt], true, nil
+}
+
+func (l *lexer) number() {
+	if l.src[l.pos] == '0' && (l.peekByte(1) == 'x' || l.peekByte(1) == 'X') {
+		l.pos += 2
+		for l.pos < len(l.src) && isHex(l.src[l.pos]) {
+			l.pos++
+		}
+		return
+	}
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' && l.peekByte(1) != '.' {
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		save := l.pos
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+				l.pos++
+			}
+		} else {
+			l.pos = save
+		}
+	}
+}
+
+func (l *lexer) identRunes() {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !isIdentPart(r) {
+			return
+		}
+		l.pos += size
+	}
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHex(c byte) bool { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+
+func isIdentStart(r rune) bool { return r == '_' || unicode.IsLetter(r) }
+
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,1515 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sqlguard
+
+import "strings"
+
+func wordSet(words ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		m[w] = struct{}{}
+	}
+	return m
+}
+
+// reserved words end an expression or clause and so cannot be bare
+// column names or implicit aliases.
+var reserved = wordSet(
+	"SELECT", "FROM", "WHERE", "GROUP", "HAVING", "ORDER", "BY", "LIMIT",
+	"OFFSET", "FETCH", "UNION", "INTERSECT", "EXCEPT", "MINUS", "JOIN",
+	"INNER", "LEFT", "RIGHT", "FULL", "CROSS", "OUTER", "NATURAL", "ON",
+	"USING", "AS", "AND", "OR", "XOR", "NOT", "IN", "IS", "LIKE", "ILIKE",
+	"RLIKE", "REGEXP", "GLOB", "SIMILAR", "BETWEEN", "CASE", "WHEN", "THEN",
+	"ELSE", "END", "WITH", "INTO", "FOR", "WINDOW", "QUALIFY", "APPLY",
+	"LATERAL", "STRAIGHT_JOIN", "DISTINCT", "ALL", "ESCAPE", "COLLATE",
+	"EXISTS", "NULL", "TRUE", "FALSE", "ASC", "DESC", "LOCK", "DIV", "MOD",
+	"ISNULL", "NOTNULL", "OPTION",
+)
+
+// callableReserved are reserved words that are also function names.
+var callableReserved = wordSet("LEFT", "RIGHT", "MOD", "ALL", "ISNULL", "GLOB")
+
+// notAlias extends reserved with words that may follow a select item
+// or table reference without being its alias.
+var notAlias = wordSet(
+	"ROWS", "ROW", "ONLY", "FIRST", "NEXT", "NULLS", "OVER", "FILTER",
+	"WITHIN", "PERCENT", "TIES", "USE", "FORCE", "IGNORE", "TABLESAMPLE",
+	"PARTITION", "SEPARATOR", "RESPECT", "PIVOT", "UNPIVOT", "EXCLUDE",
+	"REPLACE", "ASOF", "POSITIONAL", "SEMI", "ANTI",
+)
+
+// writeVerbs start statements that change data, schema, session or
+// server state. Anything that is not a query or an allowed
+// introspection statement is refused as well; listing these just gives
+// the clearer message.
+var writeVerbs = wordSet(
+	"INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "UPSERT", "TRUNCATE",
+	"CREATE", "DROP", "ALTER", "RENAME", "GRANT", "REVOKE", "DENY", "LOCK",
+	"UNLOCK", "CALL", "EXEC", "EXECUTE", "DO", "HANDLER", "COPY", "VACUUM",
+	"ANALYZE", "CLUSTER", "REINDEX", "REFRESH", "SET", "RESET", "USE",
+	"KILL", "LOAD", "CHECKPOINT", "BEGIN", "COMMIT", "ROLLBACK", "START",
+	"SAVEPOINT", "RELEASE", "SHUTDOWN", "ATTACH", "DETACH", "DECLARE",
+	"PREPARE", "DEALLOCATE", "LISTEN", "NOTIFY", "UNLISTEN", "COMMENT",
+	"SECURITY", "IMPORT", "EXPORT", "INSTALL", "FLUSH", "OPTIMIZE", "REPAIR",
+	"PURGE", "RESTORE", "BACKUP", "BULK", "WAITFOR", "DBCC", "OPEN", "CLOSE",
+	"DISCARD", "ABORT", "END",
+)
+
+// niladic are functions called without parentheses.
+var niladic = wordSet(
+	"CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "LOCALTIME",
+	"LOCALTIMESTAMP", "CURRENT_USER", "SESSION_USER", "SYSTEM_USER",
+	"CURRENT_SCHEMA", "CURRENT_CATALOG", "CURRENT_ROLE", "SYSDATE",
+	"SYSTIMESTAMP",
+)
+
+// unitFirst functions take a bare date part as their first argument.
+var unitFirst = wordSet(
+	"DATEADD", "DATEDIFF", "DATEDIFF_BIG", "DATEPART", "DATENAME",
+	"DATETRUNC", "DATE_BUCKET", "TIMESTAMPADD", "TIMESTAMPDIFF",
+)
+
+var intervalUnits = wordSet(
+	"YEAR", "QUARTER", "MONTH", "WEEK", "DAY", "HOUR", "MINUTE", "SECOND",
+	"MILLISECOND", "MICROSECOND", "NANOSECOND", "DECADE", "CENTURY",
+)
+
+func isIntervalUnit(w string) bool {
+	for _, part := range strings.Split(w, "_") {
+		part = strings.TrimSuffix(part, "S")
+		if _, ok := intervalUnits[part]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var compareOps = wordSet("=", "==", "<>", "!=", "<", ">", "<=", ">=", "<=>",
+	"~", "!~", "~*", "!~*", "@>", "<@", "&&", "=>")
+
+// typeWords continue a multi-word type name after '::' or AS.
+var typeWords = wordSet("PRECISION", "VARYING", "WITHOUT", "TIME", "ZONE",
+	"UNSIGNED", "SIGNED", "CHARACTER", "VARCHAR", "INTEGER", "INT")
+
+type parser struct {
+	d    *Dialect
+	toks []token
+	i    int
+}
+
+// bailout unwinds the parser to Parse with a violation.
+type bailout struct{ v *Violation }
+
+// Parse parses exactly one statement in dialect d. A trailing semicolon
+// is allowed; anything after it is a CodeMultipleStatements violation.
+func Parse(sql string, d *Dialect) (stmt *Statement, err error) {
+	if d == nil {
+		d = Postgres
+	}
+	toks, err := lex(sql, d)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{d: d, toks: toks}
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(bailout)
+			if !ok {
+				panic(r)
+			}
+			stmt, err = nil, b.v
+		}
+	}()
+
+	for p.peek().isOp(";") {
+		p.i++
+	}
+	if p.peek().kind == tokEOF {
+		return nil, violationf(CodeEmpty, 0, "", "the SQL statement is empty. Return a single SELECT query.")
+	}
+	stmt = p.statement()
+	semicolon := false
+	for p.peek().isOp(";") {
+		p.i++
+		semicolon = true
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		if semicolon {
+			return nil, violationf(CodeMultipleStatements, t.pos, t.text,
+				"only one statement may run per call, but another statement follows the first semicolon. Return exactly one SELECT query.")
+		}
+		p.unexpected("end of statement")
+	}
+	return stmt, nil
+}
+
+// ---------------------------------------------------------------------------
+// Token helpers
+// ---------------------------------------------------------------------------
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) peekN(n int) token {
+	if p.i+n < len(p.toks) {
+		return p.toks[p.i+n]
+	}
+	return p.toks[len(p.toks)-1]
+}
+
+func (p *parser) advance() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) prevEnd() int {
+	if p.i == 0 {
+		return 0
+	}
+	return p.toks[p.i-1].end
+}
+
+func (p *parser) accept(kw string) bool {
+	if p.peek().is(kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.peek().isOp(op) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kw string) {
+	if !p.accept(kw) {
+		p.unexpected(kw)
+	}
+}
+
+func (p *parser) expectOp(op string) {
+	if !p.acceptOp(op) {
+		p.unexpected("'" + op + "'")
+	}
+}
+
+func (p *parser) fail(v *Violation) { panic(bailout{v}) }
+
+func (p *parser) unexpected(want string) {
+	t := p.peek()
+	got := "end of input"
+	if t.kind != tokEOF {
+		got = "'" + t.text + "'"
+	}
+	p.fail(violationf(CodeSyntax, t.pos, t.text,
+		"could not parse the query: unexpected %s at offset %d, expected %s. Fix the syntax and return a single SELECT query.",
+		got, t.pos, want))
+}
+
+// try runs fn and rewinds on a syntax error, for the few places where
+// the grammar needs lookahead beyond a token or two.
+func (p *parser) try(fn func()) (ok bool) {
+	save := p.i
+	defer func() {
+		if r := recover(); r != nil {
+			if b, isB := r.(bailout); isB && b.v.Code == CodeSyntax {
+				p.i = save
+				ok = false
+				return
+			}
+			panic(r)
+		}
+	}()
+	fn()
+	return true
+}
+
+func isReserved(t token) bool {
+	if t.kind != tokIdent {
+		return false
+	}
+	_, ok := reserved[t.upper]
+	return ok
+}
+
+// startsQuery reports whether t begins a query expression.
+func startsQuery(t token) bool {
+	return t.is("SELECT") || t.is("WITH") || t.is("VALUES") || t.is("TABLE")
+}
+
+func (p *parser) rejectWrite(t token, where string) {
+	if t.kind != tokIdent {
+		return
+	}
+	if _, ok := writeVerbs[t.upper]; ok {
+		p.fail(violationf(CodeNotReadOnly, t.pos, t.upper,
+			"%s statements are not allowed%s; only read-only SELECT queries can run. Rewrite it as a single SELECT that does not modify data or session state.",
+			t.upper, where))
+	}
+}
+
+// name consumes an identifier usable as a table, column or alias.
+func (p *parser) name() string {
+	t := p.peek()
+	switch {
+	case t.kind == tokQuotedIdent:
+		p.i++
+		return t.text
+	case t.kind == tokIdent && !isReserved(t):
+		p.i++
+		return t.text
+	}
+	p.unexpected("an identifier")
+	return ""
+}
+
+// memberName consumes the part after a '.', where reserved words are
+// fine (t.order).
+func (p *parser) memberName() string {
+	t := p.peek()
+	if t.kind == tokQuotedIdent || t.kind == tokIdent {
+		p.i++
+		return t.text
+	}
+	p.unexpected("an identifier")
+	return ""
+}
+
+// skipBalanced consumes tokens through the ')' matching an already
+// consumed '('.
+func (p *parser) skipBalanced() {
+	depth := 1
+	for depth > 0 {
+		t := p.advance()
+		switch {
+		case t.kind == tokEOF:
+			p.unexpected("')'")
+		case t.isOp("("):
+			depth++
+		case t.isOp(")"):
+			depth--
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Statements
+// ---------------------------------------------------------------------------
+
+func (p *parser) statement() *Statement {
+	t := p.peek()
+	st := &Statement{pos: t.pos}
+	switch {
+	case startsQuery(t) || t.isOp("("):
+		st.Kind = StmtQuery
+		st.Query = p.query()
+	case t.is("EXPLAIN") || ((t.is("DESCRIBE") || t.is("DESC")) && startsQuery(p.peekN(1))):
+		p.i++
+		st.Kind = StmtExplain
+		p.explainOptions()
+		n := p.peek()
+		switch {
+		case startsQuery(n) || n.isOp("("):
+			st.Query = p.query()
+		case n.kind == tokIdent || n.kind == tokQuotedIdent:
+			p.rejectWrite(n, " even under EXPLAIN")
+			st.Kind = StmtDescribe
+			st.Table = p.tableName()
+		default:
+			p.unexpected("a query")
+		}
+	case t.is("DESCRIBE") || t.is("DESC"):
+		p.i++
+		st.Kind = StmtDescribe
+		st.Table = p.tableName()
+		// MySQL: DESCRIBE t column_or_pattern
+		if n := p.peek(); n.kind == tokIdent || n.kind == tokQuotedIdent || n.kind == tokString {
+			p.i++
+		}
+	case t.is("SHOW"):
+		p.i++
+		st.Kind = StmtShow
+		for n := p.peek(); n.kind != tokEOF && !n.isOp(";"); n = p.peek() {
+			p.i++
+		}
+	case t.is("PRAGMA"):
+		p.i++
+		st.Kind = StmtPragma
+		st.Pragma = p.pragma()
+	default:
+		if t.kind != tokIdent {
+			p.unexpected("SELECT")
+		}
+		p.rejectWrite(t, "")
+		p.fail(violationf(CodeNotReadOnly, t.pos, t.text,
+			"'%s' does not start a read-only query; only SELECT queries can run. Return a single SELECT query.", t.text))
+	}
+	st.end = p.prevEnd()
+	return st
+}
+
+func (p *parser) explainOptions() {
+	for {
+		t := p.peek()
+		switch {
+		case t.is("ANALYZE") || t.is("ANALYSE") || t.is("VERBOSE") || t.is("EXTENDED") ||
+			t.is("PARTITIONS") || t.is("PLAN") || t.is("ESTIMATE") ||
+			(t.is("QUERY") && p.peekN(1).is("PLAN")):
+			p.i++
+		case t.is("FORMAT"):
+			p.i++
+			p.acceptOp("=")
+			p.advance()
+		case t.isOp("("):
+			p.i++
+			p.skipBalanced()
+		default:
+			return
+		}
+	}
+}
+
+// readOnlyPragmas may take an argument; every other PRAGMA with an
+// argument assigns a setting.
+var readOnlyPragmas = wordSet(
+	"TABLE_INFO", "TABLE_XINFO", "INDEX_LIST", "INDEX_INFO", "INDEX_XINFO",
+	"FOREIGN_KEY_LIST", "FOREIGN_KEY_CHECK", "INTEGRITY_CHECK", "QUICK_CHECK",
+	"SHOW", "STORAGE_INFO",
+)
+
+func (p *parser) pragma() string {
+	parts := []string{p.memberName()}
+	for p.acceptOp(".") {
+		parts = append(parts, p.memberName())
+	}
+	name := parts[len(parts)-1]
+	if t := p.peek(); t.isOp("=") || (t.isOp("(") && !hasWord(readOnlyPragmas, name)) {
+		p.fail(violationf(CodeNotReadOnly, t.pos, name,
+			"PRAGMA %s with a value changes a setting; only read-only queries can run.", name))
+	}
+	if p.acceptOp("(") {
+		p.skipBalanced()
+	}
+	return strings.Join(parts, ".")
+}
+
+func hasWord(set map[string]struct{}, w string) bool {
+	_, ok := set[strings.ToUpper(w)]
+	return ok
+}
+
+// ---------------------------------------------------------------------------
+// Queries
+// ---------------------------------------------------------------------------
+
+func (p *parser) query() *Query {
+	q := &Query{}
+	if p.accept("WITH") {
+		q.Recursive = p.accept("RECURSIVE")
+		for {
+			cte := &CTE{Name: p.name()}
+			if p.acceptOp("(") {
+				cte.Columns = p.nameList()
+			}
+			p.expect("AS")
+			p.accept("NOT")
+			p.accept("MATERIALIZED")
+			p.expectOp("(")
+			p.rejectWrite(p.peek(), " inside WITH")
+			cte.Query = p.query()
+			p.expectOp(")")
+			q.With = append(q.With, cte)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	q.Body = p.setExpr()
+	if p.peek().is("ORDER") && p.peekN(1).is("BY") {
+		p.i += 2
+		q.OrderBy = p.orderItems()
+	}
+	p.limitClauses(q)
+	q.end = p.prevEnd()
+	p.trailingClauses()
+	return q
+}
+
+// nameList parses "a, b)" after an already consumed '('.
+func (p *parser) nameList() []string {
+	var out []string
+	for {
+		out = append(out, p.name())
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.expectOp(")")
+	return out
+}
+
+func (p *parser) setExpr() QueryBody {
+	left := p.setTerm()
+	for {
+		t := p.peek()
+		if !(t.is("UNION") || t.is("INTERSECT") || t.is("EXCEPT") || t.is("MINUS")) {
+			return left
+		}
+		p.i++
+		op := &SetOp{Op: t.upper, Left: left}
+		op.All = p.accept("ALL")
+		if !op.All {
+			p.accept("DISTINCT")
+		}
+		op.Right = p.setTerm()
+		left = op
+	}
+}
+
+func (p *parser) setTerm() QueryBody {
+	t := p.peek()
+	switch {
+	case t.is("SELECT"):
+		return p.selectBlock()
+	case t.isOp("("):
+		p.i++
+		q := p.query()
+		p.expectOp(")")
+		return &ParenQuery{Query: q}
+	case t.is("VALUES"):
+		p.i++
+		return p.values()
+	case t.is("TABLE"):
+		p.i++
+		tn := p.tableName()
+		return &Select{Items: []*SelectItem{{Expr: &Star{pos: t.pos}}}, From: []TableExpr{tn}, keywordEnd: t.end, topAt: t.end}
+	}
+	p.rejectWrite(t, "")
+	p.unexpected("SELECT")
+	return nil
+}
+
+func (p *parser) values() *Values {
+	v := &Values{}
+	for {
+		p.accept("ROW")
+		p.expectOp("(")
+		v.Rows = append(v.Rows, p.exprList(")"))
+		if !p.acceptOp(",") {
+			return v
+		}
+	}
+}
+
+// exprList parses expressions up to and including closer.
+func (p *parser) exprList(closer string) []Expr {
+	var out []Expr
+	if p.acceptOp(closer) {
+		return out
+	}
+	for {
+		out = append(out, p.expr())
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.expectOp(closer)
+	return out
+}
+
+func (p *parser) limitClauses(q *Query) {
+	get := func() *Limit {
+		if q.Limit == nil {
+			q.Limit = &Limit{countPos: -1}
+		}
+		return q.Limit
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.is("LIMIT"):
+			p.i++
+			l := get()
+			if a := p.peek(); a.is("ALL") {
+				p.i++
+				l.All = true
+				l.countPos, l.countEnd = a.pos, a.end
+			} else {
+				start := p.peek().pos
+				e := p.expr()
+				if p.acceptOp(",") {
+					l.Offset = e
+					start = p.peek().pos
+					e = p.expr()
+				}
+				l.Count = e
+				l.countPos, l.countEnd = start, p.prevEnd()
+			}
+			if p.accept("OFFSET") {
+				l.Offset = p.expr()
+			}
+		case t.is("OFFSET"):
+			p.i++
+			get().Offset = p.expr()
+			if !p.accept("ROWS") {
+				p.accept("ROW")
+			}
+		case t.is("FETCH"):
+			p.i++
+			l := get()
+			l.Fetch = true
+			if !p.accept("FIRST") {
+				p.expect("NEXT")
+			}
+			if n := p.peek(); !n.is("ROW") && !n.is("ROWS") {
+				l.countPos = n.pos
+				l.Count = p.expr()
+				if p.accept("PERCENT") {
+					l.Percent = true
+				}
+				l.countEnd = p.prevEnd()
+			}
+			if !p.accept("ROWS") {
+				p.expect("ROW")
+			}
+			if p.peek().is("WITH") && p.peekN(1).is("TIES") {
+				p.i += 2
+			} else {
+				p.expect("ONLY")
+			}
+		default:
+			return
+		}
+	}
+}
+
+// trailingClauses handles what may follow ORDER BY / LIMIT: row locks
+// and SELECT ... INTO are refused, SQL Server's FOR XML / FOR JSON and
+// OPTION (...) hints are read-only and skipped.
+func (p *parser) trailingClauses() {
+	for {
+		t := p.peek()
+		switch {
+		case t.is("FOR"):
+			n := p.peekN(1)
+			if n.is("XML") || n.is("JSON") || n.is("BROWSE") {
+				p.i += 2
+				p.skipClause()
+				continue
+			}
+			p.fail(violationf(CodeNotReadOnly, t.pos, "FOR "+n.upper,
+				"row-locking clauses (FOR UPDATE / FOR SHARE) are not allowed; remove the FOR clause."))
+		case t.is("LOCK"):
+			p.fail(violationf(CodeNotReadOnly, t.pos, "LOCK",
+				"row-locking clauses (LOCK IN SHARE MODE) are not allowed; remove the LOCK clause."))
+		case t.is("INTO"):
+			p.failInto(t)
+		case t.is("OPTION") && p.peekN(1).isOp("("):
+			p.i += 2
+			p.skipBalanced()
+		default:
+			return
+		}
+	}
+}
+
+// skipClause consumes tokens up to the end of the enclosing query.
+func (p *parser) skipClause() {
+	depth := 0
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokEOF, t.isOp(";"):
+			return
+		case t.isOp("("):
+			depth++
+		case t.isOp(")"):
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+		p.i++
+	}
+}
+
+func (p *parser) failInto(t token) {
+	p.fail(violationf(CodeNotReadOnly, t.pos, "INTO",
+		"SELECT ... INTO writes the result to a table, variable or file and is not allowed; remove the INTO clause."))
+}
+
+func (p *parser) selectBlock() *Select {
+	kw := p.advance()
+	s := &Select{keywordEnd: kw.end, topAt: kw.end}
+	for {
+		t := p.peek()
+		switch {
+		case t.is("ALL") || t.is("DISTINCTROW"):
+			p.i++
+			s.Distinct = t.is("DISTINCTROW")
+			s.topAt = p.prevEnd()
+		case t.is("DISTINCT"):
+			p.i++
+			s.Distinct = true
+			if p.accept("ON") {
+				p.expectOp("(")
+				s.DistinctOn = p.exprList(")")
+			}
+			s.topAt = p.prevEnd()
+		case t.is("TOP") && (p.peekN(1).kind == tokNumber || p.peekN(1).isOp("(") || p.peekN(1).kind == tokParam):
+			p.i++
+			s.Top = p.top()
+		case t.is("HIGH_PRIORITY") || t.is("STRAIGHT_JOIN") || t.is("SQL_SMALL_RESULT") ||
+			t.is("SQL_BIG_RESULT") || t.is("SQL_BUFFER_RESULT") || t.is("SQL_NO_CACHE") ||
+			t.is("SQL_CACHE") || t.is("SQL_CALC_FOUND_ROWS"):
+			p.i++
+		default:
+			goto items
+		}
+	}
+items:
+	for {
+		s.Items = append(s.Items, p.selectItem())
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	if t := p.peek(); t.is("INTO") {
+		p.failInto(t)
+	}
+	if p.accept("FROM") {
+		for {
+			s.From = append(s.From, p.tableRef())
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if p.accept("WHERE") {
+		s.Where = p.expr()
+	}
+	if p.peek().is("GROUP") && p.peekN(1).is("BY") {
+		p.i += 2
+		if !p.accept("ALL") {
+			p.accept("DISTINCT")
+		}
+		for {
+			if p.peek().is("GROUPING") && p.peekN(1).is("SETS") {
+				p.i += 2
+			}
+			s.GroupBy = append(s.GroupBy, p.expr())
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+		if p.peek().is("WITH") && (p.peekN(1).is("ROLLUP") || p.peekN(1).is("CUBE")) {
+			p.i += 2
+		}
+	}
+	if p.accept("HAVING") {
+		s.Having = p.expr()
+	}
+	if p.accept("WINDOW") {
+		for {
+			name := p.name()
+			p.expect("AS")
+			ws := p.windowSpec()
+			ws.Name = name
+			s.Windows = append(s.Windows, ws)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if p.accept("QUALIFY") {
+		s.Qualify = p.expr()
+	}
+	return s
+}
+
+// top parses SQL Server's TOP n | TOP (expr) [PERCENT] [WITH TIES]
+// after the TOP keyword.
+func (p *parser) top() *Limit {
+	l := &Limit{countPos: p.peek().pos}
+	if p.acceptOp("(") {
+		l.Count = p.expr()
+		p.expectOp(")")
+	} else {
+		l.Count = p.primary()
+	}
+	l.countEnd = p.prevEnd()
+	if p.accept("PERCENT") {
+		l.Percent = true
+	}
+	if p.peek().is("WITH") && p.peekN(1).is("TIES") {
+		p.i += 2
+	}
+	return l
+}
+
+func (p *parser) selectItem() *SelectItem {
+	item := &SelectItem{}
+	if t := p.peek(); t.isOp("*") {
+		p.i++
+		item.Expr = &Star{pos: t.pos}
+		// DuckDB: * EXCLUDE (a, b) / * REPLACE (expr AS a)
+		for p.peek().is("EXCLUDE") || p.peek().is("REPLACE") {
+			p.i++
+			p.expectOp("(")
+			p.skipBalanced()
+		}
+		return item
+	}
+	item.Expr = p.expr()
+	item.Alias = p.alias()
+	return item
+}
+
+// alias parses an optional [AS] alias.
+func (p *parser) alias() string {
+	if p.accept("AS") {
+		if t := p.peek(); t.kind == tokString {
+			p.i++
+			return t.text
+		}
+		return p.memberName()
+	}
+	t := p.peek()
+	if t.kind == tokQuotedIdent {
+		p.i++
+		return t.text
+	}
+	if t.kind == tokIdent && !isReserved(t) {
+		if _, no := notAlias[t.upper]; !no {
+			p.i++
+			return t.text
+		}
+	}
+	return ""
+}
+
+// ---------------------------------------------------------------------------
+// FROM
+// ---------------------------------------------------------------------------
+
+func (p *parser) tableRef() TableExpr {
+	left := p.tablePrimary()
+	for {
+		kind, ok := p.joinKind()
+		if !ok {
+			return left
+		}
+		j := &Join{Kind: kind, Left: left, Right: p.tablePrimary()}
+		if p.accept("ON") {
+			j.On = p.expr()
+		} else if p.accept("USING") {
+			p.expectOp("(")
+			j.Using = p.nameList()
+		}
+		left = j
+	}
+}
+
+// joinKind consumes a join operator and returns its normalised text.
+func (p *parser) joinKind() (string, bool) {
+	save := p.i
+	var words []string
+	take := func(w string) bool {
+		if p.accept(w) {
+			words = append(words, w)
+			return true
+		}
+		return false
+	}
+	take("NATURAL")
+	switch {
+	case take("JOIN"), take("STRAIGHT_JOIN"):
+		return strings.Join(words, " "), true
+	case take("CROSS"), take("OUTER"):
+		if take("JOIN") || take("APPLY") {
+			return strings.Join(words, " "), true
+		}
+	case take("INNER"), take("POSITIONAL"), take("SEMI"), take("ANTI"):
+		if take("JOIN") {
+			return strings.Join(words, " "), true
+		}
+	case take("ASOF"), take("LEFT"), take("RIGHT"), take("FULL"):
+		if !take("OUTER") && !take("SEMI") && !take("ANTI") {
+			take("LEFT")
+		}
+		if take("JOIN") {
+			return strings.Join(words, " "), true
+		}
+	}
+	p.i = save
+	return "", false
+}
+
+func (p *parser) tablePrimary() TableExpr {
+	lateral := p.accept("LATERAL")
+	t := p.peek()
+	if t.isOp("(") {
+		n := p.peekN(1)
+		if startsQuery(n) || n.isOp("(") {
+			var dt *DerivedTable
+			if p.try(func() {
+				p.i++
+				q := p.query()
+				p.expectOp(")")
+				dt = &DerivedTable{Query: q, Lateral: lateral}
+			}) {
+				dt.Alias, dt.Columns = p.tableAlias()
+				return dt
+			}
+		}
+		p.i++
+		inner := p.tableRef()
+		p.expectOp(")")
+		return inner
+	}
+	if t.is("ONLY") && (p.peekN(1).kind == tokIdent || p.peekN(1).kind == tokQuotedIdent) {
+		p.i++
+	}
+	if _, ok := callableReserved[t.upper]; ok && isReserved(t) && p.peekN(1).isOp("(") {
+		// DuckDB's glob() is a table function named by a keyword.
+		p.i++
+		tf := &TableFunc{Func: p.funcCall([]string{t.text}, t.pos)}
+		tf.Alias, tf.Columns = p.tableAlias()
+		return tf
+	}
+	tn := p.tableName()
+	if p.peek().isOp("(") {
+		tf := &TableFunc{Func: p.funcCall(tn.Parts, tn.pos)}
+		if p.peek().is("WITH") && p.peekN(1).is("ORDINALITY") {
+			p.i += 2
+		}
+		tf.Alias, tf.Columns = p.tableAlias()
+		return tf
+	}
+	tn.Alias, tn.Columns = p.tableAlias()
+	p.tableHints()
+	return tn
+}
+
+func (p *parser) tableName() *TableName {
+	tn := &TableName{pos: p.peek().pos}
+	tn.Parts = []string{p.name()}
+	for p.acceptOp(".") {
+		tn.Parts = append(tn.Parts, p.memberName())
+	}
+	return tn
+}
+
+// tableAlias parses [AS] alias [(column [type], ...)]; the types are
+// Postgres column definitions for record-returning functions.
+func (p *parser) tableAlias() (string, []string) {
+	a := p.alias()
+	if a == "" || !p.peek().isOp("(") || (p.peekN(1).kind != tokIdent && p.peekN(1).kind != tokQuotedIdent) {
+		return a, nil
+	}
+	p.i++
+	var cols []string
+	for {
+		cols = append(cols, p.name())
+		if t := p.peek(); !t.isOp(",") && !t.isOp(")") {
+			p.typeName()
+		}
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.expectOp(")")
+	return a, cols
+}
+
+// tableHints skips SQL Server WITH (NOLOCK), MySQL index hints and
+// TABLESAMPLE, none of which affect what is read.
+func (p *parser) tableHints() {
+	for {
+		t := p.peek()
+		switch {
+		case t.is("WITH") && p.peekN(1).isOp("("):
+			p.i += 2
+			p.skipBalanced()
+		case (t.is("USE") || t.is("FORCE") || t.is("IGNORE")) && (p.peekN(1).is("INDEX") || p.peekN(1).is("KEY")):
+			p.i += 2
+			if p.accept("FOR") {
+				p.advance()
+				p.accept("BY")
+			}
+			p.expectOp("(")
+			p.skipBalanced()
+		case t.is("TABLESAMPLE"):
+			p.i++
+			p.advance()
+			p.expectOp("(")
+			p.skipBalanced()
+			if p.accept("REPEATABLE") {
+				p.expectOp("(")
+				p.skipBalanced()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Expressions
+// ---------------------------------------------------------------------------
+
+func (p *parser) expr() Expr { return p.or() }
+
+func (p *parser) or() Expr {
+	l := p.and()
+	for p.peek().is("OR") || p.peek().is("XOR") {
+		op := p.advance().upper
+		l = &Binary{Op: op, L: l, R: p.and()}
+	}
+	return l
+}
+
+func (p *parser) and() Expr {
+	l := p.not()
+	for p.peek().is("AND") {
+		p.i++
+		l = &Binary{Op: "AND", L: l, R: p.not()}
+	}
+	return l
+}
+
+func (p *parser) not() Expr {
+	if p.accept("NOT") {
+		return &Unary{Op: "NOT", X: p.not()}
+	}
+	return p.predicate()
+}
+
+func (p *parser) predicate() Expr {
+	l := p.bitwise()
+	for {
+		t := p.peek()
+		negated := false
+		if t.is("NOT") {
+			n := p.peekN(1)
+			if n.is("IN") || n.is("BETWEEN") || n.is("LIKE") || n.is("ILIKE") || n.is("RLIKE") ||
+				n.is("REGEXP") || n.is("SIMILAR") || n.is("GLOB") {
+				p.i++
+				negated = true
+				t = n
+			}
+		}
+		switch {
+		case t.kind == tokOp && hasWord(compareOps, t.text):
+			p.i++
+			l = &Binary{Op: t.text, L: l, R: p.bitwise()}
+		case t.is("IS"):
+			p.i++
+			is := &Is{X: l, Not: p.accept("NOT")}
+			if p.accept("DISTINCT") {
+				p.expect("FROM")
+				is.What = "DISTINCT FROM"
+				is.From = p.bitwise()
+			} else {
+				w := p.advance()
+				if w.kind != tokIdent {
+					p.i--
+					p.unexpected("NULL, TRUE, FALSE or DISTINCT FROM")
+				}
+				is.What = w.upper
+			}
+			l = is
+		case t.is("ISNULL") || t.is("NOTNULL"):
+			p.i++
+			l = &Is{X: l, Not: t.is("NOTNULL"), What: "NULL"}
+		case t.is("IN"):
+			if !p.peekN(1).isOp("(") {
+				// POSITION(a IN b) and friends: leave IN to the caller.
+				if negated {
+					p.unexpected("'('")
+				}
+				return l
+			}
+			p.i += 2
+			in := &In{X: l, Not: negated}
+			if startsQuery(p.peek()) {
+				in.Query = p.query()
+				p.expectOp(")")
+			} else {
+				in.List = p.exprList(")")
+			}
+			l = in
+		case t.is("BETWEEN"):
+			p.i++
+			if !p.accept("SYMMETRIC") {
+				p.accept("ASYMMETRIC")
+			}
+			b := &Between{X: l, Not: negated, Lo: p.bitwise()}
+			p.expect("AND")
+			b.Hi = p.bitwise()
+			l = b
+		case t.is("LIKE") || t.is("ILIKE") || t.is("RLIKE") || t.is("REGEXP") || t.is("GLOB") || t.is("SIMILAR"):
+			p.i++
+			op := t.upper
+			if t.is("SIMILAR") {
+				p.expect("TO")
+				op = "SIMILAR TO"
+			}
+			var e Expr = &Binary{Op: op, L: l, R: p.bitwise()}
+			if p.accept("ESCAPE") {
+				e = &Binary{Op: "ESCAPE", L: e, R: p.bitwise()}
+			}
+			if negated {
+				e = &Unary{Op: "NOT", X: e}
+			}
+			l = e
+		default:
+			return l
+		}
+	}
+}
+
+func (p *parser) bitwise() Expr {
+	l := p.additive()
+	for {
+		t := p.peek()
+		if t.isOp("|") || t.isOp("&") || t.isOp("^") || t.isOp("<<") || t.isOp(">>") {
+			p.i++
+			l = &Binary{Op: t.text, L: l, R: p.additive()}
+			continue
+		}
+		return l
+	}
+}
+
+func (p *parser) additive() Expr {
+	l := p.multiplicative()
+	for {
+		t := p.peek()
+		if t.isOp("+") || t.isOp("-") || t.isOp("||") {
+			p.i++
+			l = &Binary{Op: t.text, L: l, R: p.multiplicative()}
+			continue
+		}
+		return l
+	}
+}
+
+func (p *parser) multiplicative() Expr {
+	l := p.unary()
+	for {
+		t := p.peek()
+		if t.isOp("*") || t.isOp("/") || t.isOp("%") || t.is("DIV") || t.is("MOD") {
+			p.i++
+			l = &Binary{Op: strings.ToUpper(t.text), L: l, R: p.unary()}
+			continue
+		}
+		return l
+	}
+}
+
+func (p *parser) unary() Expr {
+	t := p.peek()
+	if t.isOp("-") || t.isOp("+") || t.isOp("~") || t.isOp("!") {
+		p.i++
+		return &Unary{Op: t.text, X: p.unary()}
+	}
+	return p.postfix(p.primary())
+}
+
+func (p *parser) postfix(e Expr) Expr {
+	for {
+		t := p.peek()
+		switch {
+		case t.isOp("::"):
+			p.i++
+			e = &Cast{X: e, Type: p.typeName()}
+		case t.isOp("["):
+			p.i++
+			s := &Subscript{X: e}
+			if !p.peek().isOp(":") && !p.peek().isOp("]") {
+				s.Index = p.expr()
+			}
+			if p.acceptOp(":") && !p.peek().isOp("]") {
+				s.End = p.expr()
+			}
+			p.expectOp("]")
+			e = s
+		case t.isOp("->") || t.isOp("->>") || t.isOp("#>") || t.isOp("#>>"):
+			p.i++
+			e = &Binary{Op: t.text, L: e, R: p.postfix(p.primary())}
+		case t.is("COLLATE"):
+			p.i++
+			p.advance()
+		case t.is("AT") && p.peekN(1).is("TIME") && p.peekN(2).is("ZONE"):
+			p.i += 3
+			e = &Binary{Op: "AT TIME ZONE", L: e, R: p.postfix(p.primary())}
+		default:
+			return e
+		}
+	}
+}
+
+// typeName parses a type after '::' or AS: words, an optional
+// (precision) and optional [] suffixes.
+func (p *parser) typeName() string {
+	start := p.peek().pos
+	p.memberName()
+	for {
+		t := p.peek()
+		if t.kind == tokIdent && hasWord(typeWords, t.upper) {
+			p.i++
+			continue
+		}
+		if t.is("WITH") && (p.peekN(1).is("TIME") || p.peekN(1).is("LOCAL")) {
+			p.i++
+			continue
+		}
+		break
+	}
+	if p.acceptOp("(") {
+		p.skipBalanced()
+	}
+	for p.peek().isOp("[") {
+		p.i++
+		for !p.acceptOp("]") {
+			if p.advance().kind == tokEOF {
+				p.unexpected("']'")
+			}
+		}
+	}
+	return strings.TrimSpace(p.text(start, p.prevEnd()))
+}
+
+// text returns the source for a byte range; the lexer kept offsets.
+func (p *parser) text(start, end int) string {
+	// Tokens are the only record of the source; rebuild from them.
+	var b strings.Builder
+	for _, t := range p.toks {
+		if t.pos >= start && t.end <= end && t.kind != tokEOF {
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+func (p *parser) primary() Expr {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber, tokString:
+		p.i++
+		return &Literal{Value: t.text}
+	case tokParam:
+		p.i++
+		return &Param{Name: t.text}
+	case tokQuotedIdent:
+		return p.columnOrCall()
+	case tokOp:
+		switch {
+		case t.isOp("("):
+			p.i++
+			if startsQuery(p.peek()) {
+				q := p.query()
+				p.expectOp(")")
+				return &Subquery{Query: q}
+			}
+			items := p.exprList(")")
+			if len(items) == 1 {
+				return items[0]
+			}
+			return &Tuple{Items: items}
+		case t.isOp("["):
+			p.i++
+			return &Tuple{Items: p.exprList("]")}
+		}
+		p.unexpected("an expression")
+	case tokEOF:
+		p.unexpected("an expression")
+	}
+
+	// Identifiers and keywords.
+	next := p.peekN(1)
+	switch t.upper {
+	case "NULL", "TRUE", "FALSE":
+		p.i++
+		return &Literal{Value: t.upper}
+	case "NOT":
+		return p.not()
+	case "CASE":
+		return p.caseExpr()
+	case "CAST", "TRY_CAST", "SAFE_CAST":
+		if next.isOp("(") {
+			p.i += 2
+			c := &Cast{X: p.expr()}
+			p.expect("AS")
+			start := p.peek().pos
+			p.skipBalanced()
+			c.Type = p.text(start, p.prevEnd()-1)
+			return c
+		}
+	case "EXISTS":
+		if next.isOp("(") {
+			p.i += 2
+			p.rejectWrite(p.peek(), " inside EXISTS")
+			q := p.query()
+			p.expectOp(")")
+			return &Subquery{Query: q, Exists: true}
+		}
+	case "INTERVAL":
+		if next.kind != tokOp || next.isOp("(") || next.isOp("-") {
+			p.i++
+			iv := &Interval{X: p.unary()}
+			if u := p.peek(); u.kind == tokIdent && isIntervalUnit(u.upper) {
+				p.i++
+				iv.Unit = u.upper
+				if p.accept("TO") {
+					iv.Unit += " TO " + p.advance().upper
+				}
+			}
+			return iv
+		}
+	case "DATE", "TIME", "TIMESTAMP", "TIMESTAMPTZ", "DATETIME":
+		if next.kind == tokString {
+			p.i += 2
+			return &Literal{Value: next.text}
+		}
+	case "ARRAY":
+		if next.isOp("[") {
+			p.i += 2
+			return &Tuple{Items: p.exprList("]")}
+		}
+	case "CURRENT":
+		if next.is("DATE") || next.is("TIME") || next.is("TIMESTAMP") {
+			p.i += 2
+			return &FuncCall{Name: []string{"CURRENT_" + next.upper}, pos: t.pos}
+		}
+	}
+	if _, ok := niladic[t.upper]; ok && !next.isOp("(") {
+		p.i++
+		return &FuncCall{Name: []string{t.text}, pos: t.pos}
+	}
+	if isReserved(t) {
+		if _, ok := callableReserved[t.upper]; !(ok && next.isOp("(")) {
+			p.unexpected("an expression")
+		}
+		p.i++
+		return p.funcCall([]string{t.text}, t.pos)
+	}
+	return p.columnOrCall()
+}
+
+// columnOrCall parses a dotted name, then a call, a qualified star or
+// a column reference.
+func (p *parser) columnOrCall() Expr {
+	t := p.advance()
+	parts := []string{t.text}
+	for p.peek().isOp(".") {
+		p.i++
+		if s := p.peek(); s.isOp("*") {
+			p.i++
+			return &Star{Qualifier: parts, pos: t.pos}
+		}
+		parts = append(parts, p.memberName())
+	}
+	if p.peek().isOp("(") {
+		return p.funcCall(parts, t.pos)
+	}
+	return &ColumnRef{Parts: parts, pos: t.pos}
+}
+
+// funcCall parses the argument list and trailing clauses of a call
+// whose name has been consumed. Argument syntax is deliberately loose —
+// FROM / FOR / IN / USING / SEPARATOR / AS type may separate arguments —
+// because the supported engines between them use all of these forms.
+func (p *parser) funcCall(name []string, pos int) *FuncCall {
+	p.expectOp("(")
+	f := &FuncCall{Name: name, pos: pos}
+	fn := strings.ToUpper(name[len(name)-1])
+	switch {
+	case p.acceptOp(")"):
+		goto after
+	case p.peek().isOp("*") && p.peekN(1).isOp(")"):
+		p.i += 2
+		f.Star = true
+		goto after
+	}
+	if p.accept("DISTINCT") {
+		f.Distinct = true
+	} else if p.peek().is("ALL") && !p.peekN(1).isOp("(") {
+		p.i++
+	}
+	if t := p.peek(); (fn == "EXTRACT" || hasWord(unitFirst, fn)) && t.kind == tokIdent &&
+		(p.peekN(1).is("FROM") || p.peekN(1).isOp(",")) {
+		p.i += 2
+		f.Args = append(f.Args, &Keyword{Word: t.upper})
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.isOp(")"):
+			p.i++
+			goto after
+		case t.isOp(","):
+			p.i++
+			continue
+		case t.is("BOTH") || t.is("LEADING") || t.is("TRAILING"):
+			p.i++
+			continue
+		case t.is("ORDER") && p.peekN(1).is("BY"):
+			p.i += 2
+			f.OrderBy = append(f.OrderBy, p.orderItems()...)
+			continue
+		case t.is("FROM") || t.is("FOR") || t.is("IN") || t.is("SEPARATOR"):
+			p.i++
+			continue
+		case t.is("USING"):
+			p.i++
+			f.Args = append(f.Args, &Keyword{Word: p.advance().upper})
+			continue
+		case t.is("AS"):
+			p.i++
+			f.Args = append(f.Args, &Keyword{Word: p.typeName()})
+			continue
+		case (t.is("IGNORE") || t.is("RESPECT")) && p.peekN(1).is("NULLS"):
+			p.i += 2
+			continue
+		case startsQuery(t):
+			f.Args = append(f.Args, &Subquery{Query: p.query()})
+		case t.kind == tokEOF:
+			p.unexpected("')'")
+		default:
+			f.Args = append(f.Args, p.expr())
+		}
+		if n := p.peek(); !n.isOp(",") && !n.isOp(")") && n.kind != tokIdent {
+			p.unexpected("',' or ')'")
+		}
+	}
+after:
+	if p.peek().is("AGAINST") && p.peekN(1).isOp("(") {
+		// MySQL MATCH (cols) AGAINST ('text' IN BOOLEAN MODE)
+		p.i += 2
+		f.Args = append(f.Args, p.expr())
+		p.skipBalanced()
+	}
+	if p.peek().is("WITHIN") && p.peekN(1).is("GROUP") {
+		p.i += 2
+		p.expectOp("(")
+		p.expect("ORDER")
+		p.expect("BY")
+		f.OrderBy = append(f.OrderBy, p.orderItems()...)
+		p.expectOp(")")
+	}
+	if p.peek().is("FILTER") && p.peekN(1).isOp("(") {
+		p.i += 2
+		p.expect("WHERE")
+		f.Filter = p.expr()
+		p.expectOp(")")
+	}
+	if (p.peek().is("IGNORE") || p.peek().is("RESPECT")) && p.peekN(1).is("NULLS") {
+		p.i += 2
+	}
+	if p.accept("OVER") {
+		if p.peek().isOp("(") {
+			f.Over = p.windowSpec()
+		} else {
+			f.Over = &WindowSpec{Name: p.name()}
+		}
+	}
+	return f
+}
+
+// windowSpec parses "( [name] [PARTITION BY ...] [ORDER BY ...] [frame] )".
+func (p *parser) windowSpec() *WindowSpec {
+	p.expectOp("(")
+	ws := &WindowSpec{}
+	if t := p.peek(); (t.kind == tokIdent || t.kind == tokQuotedIdent) &&
+		!t.is("PARTITION") && !t.is("ORDER") && !t.is("ROWS") && !t.is("RANGE") && !t.is("GROUPS") {
+		ws.Name = p.name()
+	}
+	if p.peek().is("PARTITION") && p.peekN(1).is("BY") {
+		p.i += 2
+		for {
+			ws.PartitionBy = append(ws.PartitionBy, p.expr())
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if p.peek().is("ORDER") && p.peekN(1).is("BY") {
+		p.i += 2
+		ws.OrderBy = p.orderItems()
+	}
+	if t := p.peek(); t.is("ROWS") || t.is("RANGE") || t.is("GROUPS") {
+		// Frame bounds are literals and keywords only.
+		p.skipBalanced()
+		return ws
+	}
+	p.expectOp(")")
+	return ws
+}
+
+func (p *parser) orderItems() []*OrderItem {
+	var out []*OrderItem
+	for {
+		item := &OrderItem{Expr: p.expr()}
+		if p.accept("DESC") {
+			item.Desc = true
+		} else if !p.accept("ASC") && p.accept("USING") {
+			p.advance()
+		}
+		if p.accept("NULLS") {
+			if !p.accept("FIRST") {
+				p.expect("LAST")
+			}
+		}
+		out = append(out, item)
+		if !p.acceptOp(",") {
+			return out
+		}
+	}
+}
+
+func (p *parser) caseExpr() Expr {
+	p.i++
+	c := &Case{}
+	if !p.peek().is("WHEN") {
+		c.Operand = p.expr()
+	}
+	for p.accept("WHEN") {
+		w := &When{Cond: p.expr()}
+		p.expect("THEN")
+		w.Then = p.expr()
+		c.Whens = append(c.Whens, w)
+	}
+	if len(c.Whens) == 0 {
+		p.unexpected("WHEN")
+	}
+	if p.accept("ELSE") {
+		c.Else = p.expr()
+	}
+	p.expect("END")
+	return c
+}
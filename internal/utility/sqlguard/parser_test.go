@@ -0,0 +1,122 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sqlguard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAcceptsReadOnlyQueries(t *testing.T) {
+	tests := []struct {
+		d   *Dialect
+		sql string
+	}{
+		{MySQL, "select `order`.id, `order`.`desc` from `order` where id > 3"},
+		{MySQL, "SELECT a FROM t WHERE b = \"x\" AND c LIKE 'a\\'b%' # trailing"},
+		{MySQL, "SELECT SQL_NO_CACHE DISTINCT a FROM t USE INDEX (idx) WHERE MATCH(a) AGAINST('x' IN BOOLEAN MODE)"},
+		{MySQL, "SELECT a, COUNT(*) FROM t GROUP BY a WITH ROLLUP HAVING COUNT(*) > 1"},
+		{MySQL, "SELECT GROUP_CONCAT(DISTINCT a ORDER BY a SEPARATOR ',') FROM t"},
+		{MySQL, "SELECT DATE_ADD(created, INTERVAL 1 DAY), TIMESTAMPDIFF(MINUTE, a, b) FROM t"},
+		{MySQL, "SELECT j->>'$.name', CAST(x AS UNSIGNED) FROM t"},
+		{MySQL, "DESC t"},
+		{MySQL, "EXPLAIN t"},
+		{Postgres, "WITH RECURSIVE r(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM r WHERE n < 10) SELECT n FROM r"},
+		{Postgres, "SELECT DISTINCT ON (a) a, b FROM t ORDER BY a, b DESC NULLS LAST"},
+		{Postgres, "SELECT x::text, y::numeric(10, 2), z::timestamp with time zone FROM t"},
+		{Postgres, "SELECT $1::int, E'a\\'b', $tag$ it's $tag$ FROM t"},
+		{Postgres, "SELECT a FROM t WHERE tags @> ARRAY['x'] AND data->'k' IS NOT NULL"},
+		{Postgres, "SELECT count(*) FILTER (WHERE ok), sum(v) OVER w FROM t WINDOW w AS (PARTITION BY g ORDER BY ts)"},
+		{Postgres, "SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY v) FROM t"},
+		{Postgres, "SELECT EXTRACT(YEAR FROM ts), date_trunc('month', ts) AT TIME ZONE 'UTC' FROM t"},
+		{Postgres, "SELECT * FROM t1 NATURAL LEFT JOIN t2 FULL OUTER JOIN t3 USING (id)"},
+		{Postgres, "SELECT * FROM t, LATERAL unnest(t.arr) WITH ORDINALITY AS u(v, i)"},
+		{Postgres, "(SELECT a FROM t) UNION (SELECT a FROM u) ORDER BY 1 LIMIT 5"},
+		{Postgres, "VALUES (1, 'a'), (2, 'b')"},
+		{Postgres, "SELECT CASE WHEN a BETWEEN 1 AND 2 THEN 'x' ELSE 'y' END FROM t WHERE b NOT IN (1, 2) AND c IS DISTINCT FROM d"},
+		{Postgres, "SELECT /* comment; not a statement */ 1 -- ; neither"},
+		{Postgres, "EXPLAIN (ANALYZE false, FORMAT JSON) SELECT 1"},
+		{MSSQL, "SELECT TOP (10) WITH TIES [first name], DATEADD(day, -1, GETDATE()) FROM dbo.[people] WITH (NOLOCK) ORDER BY 1"},
+		{MSSQL, "SELECT a FROM t CROSS APPLY OPENJSON(t.j) AS j FOR JSON PATH"},
+		{MSSQL, "SELECT a FROM t ORDER BY a OFFSET 10 ROWS FETCH NEXT 5 ROWS ONLY OPTION (MAXDOP 1)"},
+		{ESSQL, "SELECT \"author.name\", SCORE() FROM \"my-index\" WHERE MATCH(title, 'go') ORDER BY SCORE() DESC"},
+		{Infinity, "SELECT docnm_kwd, COUNT(*) FROM ragflow_abc WHERE kb_id = 'x' GROUP BY docnm_kwd"},
+		{SQLite, "PRAGMA table_info(t)"},
+		{SQLite, "SELECT a FROM t WHERE b GLOB 'x*'"},
+		{DuckDB, "SELECT * EXCLUDE (secret) FROM t QUALIFY row_number() OVER (PARTITION BY g) = 1"},
+		{DuckDB, "SELECT list_transform([1, 2], x -> x + 1)"},
+		{Trino, "SELECT approx_percentile(v, 0.9) FROM hive.web.t TABLESAMPLE BERNOULLI (10)"},
+		{DB2, "SELECT a FROM t FETCH FIRST 5 ROWS ONLY"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.sql, tt.d); err != nil {
+			t.Errorf("Parse(%s, %q) error = %v", tt.d.name, tt.sql, err)
+		}
+	}
+}
+
+func TestParseRejectsBrokenSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want Code
+	}{
+		{"", CodeEmpty},
+		{" ; ", CodeEmpty},
+		{"SELECT a FROM", CodeSyntax},
+		{"SELECT (a FROM t", CodeSyntax},
+		{"SELECT 'unterminated", CodeSyntax},
+		{"SELECT a FROM t /* unterminated", CodeSyntax},
+		{"hello world", CodeNotReadOnly},
+		{"42", CodeSyntax},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.sql, Postgres)
+		var v *Violation
+		if !errors.As(err, &v) {
+			t.Errorf("Parse(%q) error = %v, want %s", tt.sql, err, tt.want)
+			continue
+		}
+		if v.Code != tt.want {
+			t.Errorf("Parse(%q) code = %s (%s), want %s", tt.sql, v.Code, v.Message, tt.want)
+		}
+	}
+}
+
+func TestParseStatementKinds(t *testing.T) {
+	tests := []struct {
+		d    *Dialect
+		sql  string
+		want StatementKind
+	}{
+		{MySQL, "SELECT 1", StmtQuery},
+		{MySQL, "WITH x AS (SELECT 1) SELECT * FROM x", StmtQuery},
+		{MySQL, "EXPLAIN SELECT 1", StmtExplain},
+		{MySQL, "DESCRIBE t", StmtDescribe},
+		{MySQL, "SHOW COLUMNS FROM t", StmtShow},
+		{SQLite, "PRAGMA table_info('t')", StmtPragma},
+	}
+	for _, tt := range tests {
+		stmt, err := Parse(tt.sql, tt.d)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.sql, err)
+			continue
+		}
+		if stmt.Kind != tt.want {
+			t.Errorf("Parse(%q).Kind = %d, want %d", tt.sql, stmt.Kind, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+//
+//  Copyright 2026 The InfiniFlow Authors. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package sqlguard
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// edit replaces src[pos:end] with text; pos == end inserts.
+type edit struct {
+	pos, end int
+	text     string
+}
+
+func hintTimeout(name string, unit time.Duration) func(time.Duration) (string, bool) {
+	return func(d time.Duration) (string, bool) {
+		// 0 means "no limit" to both servers.
+		n := int64(d / unit)
+		if n < 1 {
+			n = 1
+		}
+		return fmt.Sprintf(" /*+ %s(%d) */", name, n), false
+	}
+}
+
+// mariaDBTimeout uses SET STATEMENT ... FOR, which scopes the variable
+// to this one statement. max_statement_time is in seconds.
+func mariaDBTimeout(d time.Duration) (string, bool) {
+	return "SET STATEMENT max_statement_time=" + strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + " FOR ", true
+}
+
+// rewrite applies the policy's row cap and timeout to an accepted
+// statement and returns the SQL to execute.
+func rewrite(src string, stmt *Statement, d *Dialect, p Policy) (string, error) {
+	var edits []edit
+	if stmt.Kind == StmtQuery {
+		if p.MaxRows > 0 {
+			e, err := limitEdit(stmt.Query, d, p.MaxRows)
+			if err != nil {
+				return "", err
+			}
+			if e != nil {
+				edits = append(edits, *e)
+			}
+		}
+		if p.Timeout > 0 && d.timeout != nil {
+			text, prefix := d.timeout(p.Timeout)
+			switch sel := mainSelect(stmt.Query); {
+			case prefix:
+				edits = append(edits, edit{pos: stmt.pos, end: stmt.pos, text: text})
+			case sel != nil:
+				edits = append(edits, edit{pos: sel.keywordEnd, end: sel.keywordEnd, text: text})
+			}
+		}
+	}
+
+	out := src[:stmt.end]
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].pos > edits[j].pos })
+	for _, e := range edits {
+		out = out[:e.pos] + e.text + out[e.end:]
+	}
+	return strings.TrimSpace(out[stmt.pos:]), nil
+}
+
+// mainSelect is the SELECT block whose keyword starts the top-level
+// query body — the one optimizer hints attach to.
+func mainSelect(q *Query) *Select {
+	body := q.Body
+	for {
+		switch b := body.(type) {
+		case *Select:
+			return b
+		case *SetOp:
+			body = b.Left
+		case *ParenQuery:
+			body = b.Query.Body
+		default:
+			return nil
+		}
+	}
+}
+
+// limitEdit caps the rows q returns at max. Existing caps at or below
+// max are kept; larger or non-literal ones are replaced.
+func limitEdit(q *Query, d *Dialect, max int) (*edit, error) {
+	n := strconv.Itoa(max)
+	sel, _ := q.Body.(*Select)
+
+	if sel != nil && sel.Top != nil {
+		return clamp(sel.Top, max, "("+n+")")
+	}
+	if l := q.Limit; l != nil {
+		if l.Count != nil || l.All {
+			return clamp(l, max, n)
+		}
+		if l.Fetch {
+			return nil, nil // FETCH FIRST ROW ONLY
+		}
+	}
+
+	at := q.end
+	switch d.limit {
+	case limitFetch:
+		return &edit{pos: at, end: at, text: " FETCH FIRST " + n + " ROWS ONLY"}, nil
+	case limitTop:
+		switch {
+		case q.Limit != nil: // OFFSET without FETCH
+			return &edit{pos: at, end: at, text: " FETCH NEXT " + n + " ROWS ONLY"}, nil
+		case sel != nil:
+			return &edit{pos: sel.topAt, end: sel.topAt, text: " TOP (" + n + ")"}, nil
+		case len(q.OrderBy) > 0:
+			return &edit{pos: at, end: at, text: " OFFSET 0 ROWS FETCH NEXT " + n + " ROWS ONLY"}, nil
+		default:
+			// A set operation without ORDER BY: OFFSET/FETCH needs one,
+			// and ordinal 1 is valid for every UNION.
+			return &edit{pos: at, end: at, text: " ORDER BY 1 OFFSET 0 ROWS FETCH NEXT " + n + " ROWS ONLY"}, nil
+		}
+	}
+	return &edit{pos: at, end: at, text: " LIMIT " + n}, nil
+}
+
+func clamp(l *Limit, max int, replacement string) (*edit, error) {
+	if l.Percent {
+		return nil, violationf(CodeRowLimit, l.countPos, "PERCENT",
+			"percentage row limits cannot be capped at %d rows; use an absolute row count instead of PERCENT.", max)
+	}
+	if lit, ok := l.Count.(*Literal); ok && !l.All {
+		if v, err := strconv.Atoi(lit.Value); err == nil && v >= 0 && v <= max {
+			return nil, nil
+		}
+	}
+	return &edit{pos: l.countPos, end: l.countEnd, text: replacement}, nil
+}
@@ -156,6 +156,14 @@ class Base(ABC):
     def encode_queries(self, text: str):
         raise NotImplementedError("Please implement encode method!")
 
+    def encode_images(self, images: list[bytes]):
+        """Embed raw image bytes into the same space as encode_queries.
+
+        Only multimodal models implement it; a dataset's image_embd_id must
+        name one of them.
+        """
+        raise NotImplementedError(f"{type(self).__name__} does not embed images")
+
     def _batched_encode(self, texts: list, call_fn, *, batch_size: int, truncate_to: int | None = None):
         """Drive an embedding provider over ``texts`` in batches.
 
@@ -588,6 +596,9 @@ class JinaMultiVecEmbed(Base):
         vectors, token_count = self.encode([text], task="retrieval.query")
         return vectors[0], token_count
 
+    def encode_images(self, images: list[bytes]):
+        return self.encode(images)
+
 
 class MistralEmbed(Base):
     _FACTORY_NAME = "Mistral"
@@ -1042,6 +1053,18 @@ class VoyageEmbed(Base):
     def encode(self, texts: list):
         return self._batched_encode(texts, self._call, batch_size=16)
 
+    def encode_images(self, images: list[bytes]):
+        from io import BytesIO
+
+        from PIL import Image
+
+        def _call(batch):
+            inputs = [[Image.open(BytesIO(b))] for b in batch]
+            res = self.client.multimodal_embed(inputs=inputs, model=self.model_name, input_type="document")
+            return res.embeddings, res.total_tokens
+
+        return self._batched_encode(images, _call, batch_size=16)
+
     def encode_queries(self, text):
         try:
             res = self.client.embed(texts=text, model=self.model_name, input_type="query")
@@ -44,7 +44,7 @@ from api.db.services.pipeline_operation_log_service import PipelineOperationLogS
 from api.db.joint_services.memory_message_service import handle_save_to_memory_task
 from common.connection_utils import timeout
 from common.metadata_utils import turn2jsonschema, update_metadata_to
-from rag.utils.base64_image import image2id
+from rag.utils.base64_image import image2id, parse_storage_composite_id
 from rag.utils.raptor_utils import (
     collect_raptor_chunk_ids,
     collect_raptor_methods,
@@ -729,6 +729,35 @@ async def embedding(docs, mdl, parser_config=None, callback=None):
     return tk_count, vector_size
 
 
+@timed_with_recording
+async def embed_chunk_images(docs, mdl, callback=None):
+    """Embed the stored image of every chunk that has one into img_<dim>_vec
+    with the dataset's multimodal model, so text queries can match figures
+    and pictures directly rather than only through their captions."""
+    images, targets = [], []
+    for d in docs:
+        loc = parse_storage_composite_id(d.get("img_id") or "")
+        if not loc:
+            continue
+        async with minio_limiter:
+            binary = await thread_pool_exec(settings.STORAGE_IMPL.get, loc[0], loc[1])
+        if binary:
+            images.append(binary)
+            targets.append(d)
+
+    tk_count = 0
+    for i in range(0, len(images), settings.EMBEDDING_BATCH_SIZE):
+        async with embed_limiter:
+            vts, c = await thread_pool_exec(mdl.encode_images, images[i : i + settings.EMBEDDING_BATCH_SIZE])
+        for d, v in zip(targets[i : i + settings.EMBEDDING_BATCH_SIZE], vts):
+            v = [float(x) for x in v]
+            d["img_%d_vec" % len(v)] = v
+        tk_count += c
+        if callback:
+            callback(msg="")
+    return tk_count
+
+
 @timed_with_recording
 async def run_dataflow(task: dict):
     from api.db.services.canvas_service import UserCanvasService
@@ -1558,6 +1587,19 @@ async def do_handle_task(task):
             logging.exception(error_message)
             token_count = 0
             raise
+        image_embd_id = (task["kb_parser_config"].get("image_embd_id") or "").strip()
+        if image_embd_id:
+            try:
+                image_model_config = get_model_config_from_provider_instance(task_tenant_id, LLMType.EMBEDDING, image_embd_id)
+                image_model = LLMBundle(task_tenant_id, image_model_config, lang=task_language)
+                token_count += await embed_chunk_images(chunks, image_model, progress_callback)
+            except TaskCanceledException:
+                raise
+            except Exception as e:
+                error_message = "Generate image embedding error:{}".format(str(e))
+                progress_callback(-1, error_message)
+                logging.exception(error_message)
+                raise
         get_recording_context().record("token_count", token_count)
         get_recording_context().record("vector_size", vector_size)
         progress_message = "Embedding chunks ({:.2f}s)".format(timer() - start_ts)
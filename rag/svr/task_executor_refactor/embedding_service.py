@@ -27,6 +27,7 @@ from common.misc_utils import thread_pool_exec
 from common.token_utils import truncate
 from rag.svr.task_executor_refactor.embedding_utils import EmbeddingUtils
 from rag.svr.task_executor_refactor.task_context import TaskContext
+from rag.utils.base64_image import parse_storage_composite_id
 
 
 class EmbeddingService:
@@ -116,6 +117,45 @@ class EmbeddingService:
 
         return tk_count, vector_size
 
+    async def embed_chunk_images(
+        self,
+        docs: List[Dict[str, Any]],
+        image_model,
+    ) -> int:
+        """Embed the stored image of every chunk that has one.
+
+        The vector goes to img_<dim>_vec, next to the text vector, so text
+        queries embedded by the same multimodal model match figures and
+        pictures directly rather than only through their captions.
+
+        Args:
+            docs: Chunks after image upload; images are read back by img_id.
+            image_model: The dataset's multimodal embedding bundle (LLMBundle).
+
+        Returns:
+            Token count consumed.
+        """
+        images, targets = [], []
+        for d in docs:
+            loc = parse_storage_composite_id(d.get("img_id") or "")
+            if not loc:
+                continue
+            async with self._task_context.minio_limiter:
+                binary = await thread_pool_exec(settings.STORAGE_IMPL.get, loc[0], loc[1])
+            if binary:
+                images.append(binary)
+                targets.append(d)
+
+        tk_count = 0
+        for i in range(0, len(images), self._embedding_batch_size):
+            async with self._task_context.embed_limiter:
+                vts, c = await thread_pool_exec(image_model.encode_images, images[i: i + self._embedding_batch_size])
+            for d, v in zip(targets[i: i + self._embedding_batch_size], vts):
+                v = [float(x) for x in v]
+                d["img_%d_vec" % len(v)] = v
+            tk_count += c
+        return tk_count
+
     @staticmethod
     def _batch_encode_wrapper(txts: List[str], embedding_model) -> Tuple[np.ndarray, int]:
         """Synchronous wrapper for batch encoding — used with thread_pool_exec."""
@@ -450,6 +450,19 @@ class TaskHandler:
             ctx.progress_cb(-1, error_message)
             logging.exception(error_message)
             raise
+        image_embd_id = (ctx.kb_parser_config.get("image_embd_id") or "").strip()
+        if image_embd_id:
+            try:
+                image_model_config = get_model_config_from_provider_instance(ctx.tenant_id, LLMType.EMBEDDING, image_embd_id)
+                image_model = LLMBundle(ctx.tenant_id, image_model_config, lang=ctx.language)
+                token_count += await embedding_service.embed_chunk_images(chunks, image_model)
+            except TaskCanceledException:
+                raise
+            except Exception as e:
+                error_message = "Generate image embedding error:{}".format(str(e))
+                ctx.progress_cb(-1, error_message)
+                logging.exception(error_message)
+                raise
 
         ctx.recording_context.record("token_count", token_count)
         ctx.recording_context.record("vector_size", vector_size)
@@ -17,7 +17,8 @@
 import re
 import json
 import copy
-from infinity.common import InfinityException, SortType
+from infinity.common import ConflictType, InfinityException, SortType
+from infinity.index import IndexInfo, IndexType
 from infinity.errors import ErrorCode
 from common.decorator import singleton
 import pandas as pd
@@ -377,6 +378,8 @@ class InfinityConnection(InfinityConnectionBase):
                 self.create_idx(index_name, knowledgebase_id, vector_size, parser_id)
                 table_instance = db_instance.get_table(table_name)
 
+            self._ensure_image_vector_columns(table_instance, documents)
+
             # embedding fields can't have a default value....
             embedding_clmns = []
             clmns = table_instance.show_columns().rows()
@@ -492,6 +495,24 @@ class InfinityConnection(InfinityConnectionBase):
         self.logger.debug(f"INFINITY inserted into {table_name} {str_ids}.")
         return []
 
+    def _ensure_image_vector_columns(self, table_instance, documents: list[dict]):
+        """Add the img_<dim>_vec columns of multimodal datasets, with their
+        HNSW index, the first time chunks carry them."""
+        patt = re.compile(r"img_(\d+)_vec")
+        wanted = {k for d in documents for k in d if patt.fullmatch(k)}
+        if not wanted:
+            return
+        existing = {n for n, _, _, _ in table_instance.show_columns().rows()}
+        for name in wanted - existing:
+            size = int(patt.fullmatch(name).group(1))
+            table_instance.add_columns({name: {"type": f"vector,{size},float"}})
+            table_instance.create_index(
+                f"{name}_idx",
+                IndexInfo(name, IndexType.Hnsw, {"M": "16", "ef_construction": "50", "metric": "cosine", "encode": "lvq"}),
+                ConflictType.Ignore,
+            )
+            self.logger.info(f"INFINITY added image vector column {name}")
+
     def update(self, condition: dict, new_value: dict, index_name: str, knowledgebase_id: str) -> bool:
         # if 'position_int' in newValue:
         #     logger.info(f"update position_int: {newValue['position_int']}")
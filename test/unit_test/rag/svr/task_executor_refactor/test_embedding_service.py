@@ -260,5 +260,74 @@ class TestEmbeddingServiceEmbedChunks:
         assert vector_size > 0
 
 
+class _FakeClipModel:
+    """Multimodal embedder mapping captions and the images they describe to
+    the same vector, as a CLIP-style model would."""
+
+    _VECTORS = {
+        "cat": [1.0, 0.0, 0.0],
+        "chart": [0.0, 1.0, 0.0],
+        "map": [0.0, 0.0, 1.0],
+    }
+
+    def encode_images(self, images):
+        return np.array([self._VECTORS[b.decode()] for b in images]), len(images)
+
+    def encode_queries(self, text):
+        return np.array([self._VECTORS[w] for w in text.split() if w in self._VECTORS][0]), 1
+
+
+class TestEmbeddingServiceEmbedChunkImages:
+    """Tests for embed_chunk_images on chunks parsed from visual files."""
+
+    @pytest.mark.asyncio
+    @patch("rag.svr.task_executor_refactor.embedding_service.settings")
+    @patch("rag.svr.task_executor_refactor.embedding_service.thread_pool_exec", new_callable=AsyncMock)
+    async def test_image_vectors_are_retrievable_by_text(self, mock_thread_pool, mock_settings):
+        """A text query embedded by the same model ranks the matching image first."""
+        mock_thread_pool.side_effect = lambda func, *args, **kw: func(*args, **kw)
+        stored = {("kb", "page-1.png"): b"chart", ("kb", "page-2.png"): b"cat", ("kb", "page-3.png"): b"map"}
+        mock_settings.STORAGE_IMPL.get.side_effect = lambda bucket, name: stored.get((bucket, name))
+        ctx = MagicMock()
+        ctx.minio_limiter = AsyncMockLimiter()
+        ctx.embed_limiter = AsyncMockLimiter()
+        service = EmbeddingService(ctx=ctx, embedding_batch_size=2)
+        model = _FakeClipModel()
+
+        docs = [
+            {"id": "c1", "content_with_weight": "Figure 1", "img_id": "kb-page-1.png"},
+            {"id": "c2", "content_with_weight": "Figure 2", "img_id": "kb-page-2.png"},
+            {"id": "c3", "content_with_weight": "Figure 3", "img_id": "kb-page-3.png"},
+            {"id": "c4", "content_with_weight": "plain text"},
+        ]
+        tk_count = await service.embed_chunk_images(docs, model)
+
+        assert tk_count == 3
+        assert all("img_3_vec" in d for d in docs[:3])
+        assert "img_3_vec" not in docs[3]
+
+        query = model.encode_queries("a photo of a cat")
+        ranked = sorted(docs[:3], key=lambda d: -float(np.dot(query, d["img_3_vec"])))
+        assert ranked[0]["id"] == "c2"
+
+    @pytest.mark.asyncio
+    @patch("rag.svr.task_executor_refactor.embedding_service.settings")
+    @patch("rag.svr.task_executor_refactor.embedding_service.thread_pool_exec", new_callable=AsyncMock)
+    async def test_chunks_without_stored_image_are_skipped(self, mock_thread_pool, mock_settings):
+        """Chunks whose image is missing from storage get no image vector."""
+        mock_thread_pool.side_effect = lambda func, *args, **kw: func(*args, **kw)
+        mock_settings.STORAGE_IMPL.get.return_value = None
+        ctx = MagicMock()
+        ctx.minio_limiter = AsyncMockLimiter()
+        ctx.embed_limiter = AsyncMockLimiter()
+        service = EmbeddingService(ctx=ctx, embedding_batch_size=2)
+
+        docs = [{"id": "c1", "img_id": "kb-gone.png"}]
+        tk_count = await service.embed_chunk_images(docs, _FakeClipModel())
+
+        assert tk_count == 0
+        assert "img_3_vec" not in docs[0]
+
+
 # Reuse from conftest
 from test.unit_test.rag.svr.task_executor_refactor.conftest import AsyncMockLimiter